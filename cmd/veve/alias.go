@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+)
+
+// expandAlias replaces a user alias from veve.toml at the start of args
+// (the arguments after the program name) with the arguments it stands for,
+// so 'veve report notes.md' with report = "--theme academic -N" runs
+// 'veve --theme academic -N notes.md'. veve's own commands and existing
+// files always take precedence, and aliases don't expand other aliases.
+func expandAlias(args []string) ([]string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isCommand(args[0]) {
+		return args, nil
+	}
+	if _, err := os.Stat(args[0]); err == nil {
+		return args, nil
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return args, nil
+	}
+	cfg, err := config.LoadConfig(paths.ConfigFile)
+	if err != nil {
+		return nil, internal.ConfigError("main", "load configuration", err.Error(),
+			"fix "+paths.ConfigFile, err)
+	}
+	value, ok := cfg.Aliases[strings.ToLower(args[0])]
+	if !ok {
+		return args, nil
+	}
+	expanded, err := config.ParseAlias(value)
+	if err != nil {
+		return nil, err
+	}
+	return append(expanded, args[1:]...), nil
+}
+
+// isCommand reports whether name is a veve command or one of its aliases,
+// including the help and completion commands cobra adds itself.
+func isCommand(name string) bool {
+	if name == "help" || strings.HasPrefix(name, "__") {
+		return true
+	}
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == name || slices.Contains(cmd.Aliases, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+// filterAudience removes the content meant only for audiences other than
+// --audience, which must be one of the project's audiences.
+func filterAudience(project *config.ProjectConfig, content string, opts conversionOptions) (string, error) {
+	if opts.Audience == "" {
+		return content, nil
+	}
+	audiences := converter.DefaultAudiences
+	if project != nil && len(project.Audiences) > 0 {
+		audiences = project.Audiences
+	}
+	if !slices.Contains(audiences, opts.Audience) {
+		return "", internal.UsageError("convert", "filter content",
+			fmt.Sprintf("unknown audience %q (audiences: %s)", opts.Audience, strings.Join(audiences, ", ")),
+			"list it under audiences in "+config.ProjectConfigFile, nil)
+	}
+	return converter.FilterAudience(content, opts.Audience, audiences), nil
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/completion"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/logging"
+)
+
+// batchInput is a markdown file to convert in a batch.
+type batchInput struct {
+	path string // The file
+	rel  string // Where its output goes in an output directory, before the extension changes
+}
+
+// batchFailure is an input that failed to convert in a batch.
+type batchFailure struct {
+	input string
+	err   error
+}
+
+// convertInputs converts each input to its own PDF. Inputs may be globs,
+// for shells that don't expand them (e.g. "docs/*.md", quoted), or
+// directories, whose markdown files are converted recursively. A single file
+// converts as it always has, into the directory --output names if it names
+// one; with several, a failure doesn't stop the others, and the failures are
+// summarized at the end, and --merge joins the PDFs once every input has
+// converted. --jobs inputs convert at once, and each is stopped once it has
+// taken --job-timeout. With --json, a report of every output replaces the
+// progress messages. Defaults the flags don't override come from the
+// configuration, with the project's found from the first input.
+func convertInputs(args []string, opts conversionOptions) error {
+	if err := opts.applyConfigDefaults(inputProjectDir(args[0], opts)); err != nil {
+		return err
+	}
+	if opts.JSON {
+		// Stdout is the report's
+		logger.SetLevel(logging.LevelError)
+	}
+	// A configured output directory isn't for stdin
+	if len(args) == 1 && args[0] == "-" && !opts.given("output-dir") {
+		opts.OutputDir = ""
+	}
+	single := len(args) == 1 && !isGlob(args[0]) && !isDir(args[0]) && opts.OutputDir == ""
+	if len(opts.ExtraOutputs) > 0 && (!single || isOutputDir(opts.OutputFile)) {
+		return internal.UsageError("convert", "choose output paths",
+			"a repeated --output names the files of a single input",
+			"use --formats to write several formats of each input", nil)
+	}
+	if opts.JoinFile != "" && single {
+		return internal.UsageError("convert", "join PDFs",
+			"--merge joins the PDFs of several inputs, but one was given",
+			"pass several files, a glob, or a directory, or use veve book", nil)
+	}
+	if opts.JoinFile != "" && !slices.Contains(opts.outputFormats(), formatPDF) {
+		return internal.UsageError("convert", "join PDFs",
+			"--merge joins PDFs, but --formats doesn't write them", "add pdf to --formats", nil)
+	}
+	if single {
+		if isOutputDir(opts.OutputFile) {
+			if inputName(args[0], opts) == "-" {
+				return internal.UsageError("convert", "choose output paths",
+					"--output names a directory, but stdin has no file name to name the PDF after",
+					"pass --stdin-filename, or give --output a file name", nil)
+			}
+			base := filepath.Base(inputName(args[0], opts))
+			opts.OutputFile = filepath.Join(opts.OutputFile, converter.ResolveOutputPath(base, ""))
+		}
+		results, err := convertJob(args[0], opts)
+		return reportConversions(opts, conversionReport{Conversions: results}, err)
+	}
+
+	inputs, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+	outputs, err := batchOutputPaths(inputs, opts.OutputFile, opts.OutputDir)
+	if err != nil {
+		return err
+	}
+
+	// At most --jobs conversions run at once; failures and results are kept
+	// in input order
+	errs := make([]error, len(inputs))
+	results := make([][]conversionResult, len(inputs))
+	slots := make(chan struct{}, opts.Jobs)
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			inputOpts := opts
+			inputOpts.OutputFile = outputs[i]
+			if results[i], errs[i] = convertJob(input.path, inputOpts); errs[i] != nil {
+				logger.Error("%s: %v", input.path, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	var failures []batchFailure
+	var report conversionReport
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, batchFailure{input: inputs[i].path, err: err})
+		}
+		report.Conversions = append(report.Conversions, results[i]...)
+	}
+	result := batchResult(len(inputs), failures)
+	if opts.JoinFile == "" {
+		return reportConversions(opts, report, result)
+	}
+	if result != nil {
+		logger.Warn("Not joining the PDFs into %s, since not every input converted", opts.JoinFile)
+		return reportConversions(opts, report, result)
+	}
+
+	pdfs := make([]string, len(inputs))
+	for i, input := range inputs {
+		pdfs[i] = converter.ResolveOutputPath(input.path, outputs[i])
+	}
+	err = joinOutputs("convert", pdfs, opts, &report)
+	return reportConversions(opts, report, err)
+}
+
+// convertJob converts input as one job of a batch: stopped, with an error
+// saying so, once it has taken --job-timeout. It returns what the conversion
+// wrote, or the error, for the --json report.
+func convertJob(input string, opts conversionOptions) ([]conversionResult, error) {
+	results := &inputResults{}
+	opts.results = results
+	if opts.JobTimeout > 0 {
+		opts.Deadline = time.Now().Add(opts.JobTimeout)
+	}
+	err := convertDocument(input, opts)
+	if opts.JobTimeout > 0 && errors.Is(err, converter.ErrDeadline) {
+		err = fmt.Errorf("timed out after %s: %w", opts.JobTimeout, err)
+	}
+	if err != nil {
+		results.add(conversionResult{Input: inputName(input, opts), Error: firstLine(err.Error())})
+	}
+	return results.results, err
+}
+
+// reportConversions prints report with --json, and returns err, the
+// conversions' result.
+func reportConversions(opts conversionOptions, report conversionReport, err error) error {
+	if !opts.JSON {
+		return err
+	}
+	if reportErr := writeConversionReport(os.Stdout, report); reportErr != nil && err == nil {
+		return reportErr
+	}
+	return err
+}
+
+// inputProjectDir returns the directory an input's project is looked up from.
+func inputProjectDir(arg string, opts conversionOptions) string {
+	if isDir(arg) {
+		return arg
+	}
+	if name := inputName(arg, opts); name != "-" {
+		return filepath.Dir(name)
+	}
+	return "."
+}
+
+// isDir reports whether path is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// isOutputDir reports whether output, the --output flag, names a directory:
+// an existing one, or one ending in a path separator ("build/").
+func isOutputDir(output string) bool {
+	if output == "" || output == "-" {
+		return false
+	}
+	return isDir(output) || strings.HasSuffix(output, "/") || strings.HasSuffix(output, string(filepath.Separator))
+}
+
+// isGlob reports whether arg is a glob pattern rather than a file name.
+// A file whose name happens to contain pattern characters is a file name.
+func isGlob(arg string) bool {
+	if !strings.ContainsAny(arg, "*?[") {
+		return false
+	}
+	_, err := os.Stat(arg)
+	return err != nil
+}
+
+// expandInputs expands the globs and directories in args, returning each
+// input once, in order.
+func expandInputs(args []string) ([]batchInput, error) {
+	var inputs []batchInput
+	seen := make(map[string]bool)
+	add := func(path, rel string) {
+		if !seen[path] {
+			seen[path] = true
+			inputs = append(inputs, batchInput{path: path, rel: rel})
+		}
+	}
+	for _, arg := range args {
+		if arg == "-" {
+			return nil, internal.UsageError("convert", "read inputs",
+				"stdin (-) can't be converted with other inputs or --output-dir",
+				"convert stdin on its own", nil)
+		}
+
+		if isDir(arg) {
+			files, err := markdownFiles(arg)
+			if err != nil {
+				return nil, internal.InputError("convert", "read inputs", err.Error(), "check the directory's permissions", err)
+			}
+			if len(files) == 0 {
+				return nil, internal.InputError("convert", "read inputs",
+					fmt.Sprintf("no markdown files in %s", arg), "check the directory", nil)
+			}
+			for _, file := range files {
+				rel, err := filepath.Rel(arg, file)
+				if err != nil {
+					rel = filepath.Base(file)
+				}
+				add(file, rel)
+			}
+			continue
+		}
+
+		matches := []string{arg}
+		if isGlob(arg) {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, internal.UsageError("convert", "read inputs",
+					fmt.Sprintf("invalid pattern %q: %v", arg, err), "check the pattern's brackets", err)
+			}
+			if len(matches) == 0 {
+				return nil, internal.InputError("convert", "read inputs",
+					fmt.Sprintf("no files match %s", arg), "check the pattern and the current directory", nil)
+			}
+		}
+		for _, match := range matches {
+			add(match, filepath.Base(match))
+		}
+	}
+	return inputs, nil
+}
+
+// markdownFiles returns the markdown files in dir and its subdirectories,
+// in lexical order. Hidden files and directories, such as .git and .veve,
+// are skipped.
+func markdownFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && slices.Contains(completion.MarkdownExtensions, strings.ToLower(filepath.Ext(path))) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// batchOutputPaths returns the output path of each input: beside the input,
+// or in outputDir (--output-dir, or a directory --output names), where the
+// files of a directory input keep their place in its tree. With several
+// inputs, --output can't name a single file.
+func batchOutputPaths(inputs []batchInput, output, outputDir string) ([]string, error) {
+	outputs := make([]string, len(inputs))
+	if output != "" && outputDir != "" {
+		return nil, internal.UsageError("convert", "choose output paths",
+			"--output and --output-dir were both given", "use --output-dir for several inputs", nil)
+	}
+	if output != "" {
+		if isOutputDir(output) {
+			outputDir = output
+		} else if len(inputs) == 1 {
+			outputs[0] = output
+			return outputs, nil
+		} else {
+			return nil, internal.UsageError("convert", "choose output paths",
+				fmt.Sprintf("--output names one file, but %d inputs were given", len(inputs)),
+				"pass --output-dir, or leave it out to write each PDF beside its input", nil)
+		}
+	}
+	if outputDir == "" {
+		return outputs, nil
+	}
+
+	written := make(map[string]string)
+	for i, input := range inputs {
+		outputs[i] = filepath.Join(outputDir, converter.ResolveOutputPath(input.rel, ""))
+		if previous, exists := written[outputs[i]]; exists {
+			return nil, internal.UsageError("convert", "choose output paths",
+				fmt.Sprintf("%s and %s would both be written to %s", previous, input.path, outputs[i]),
+				"convert them separately, or rename one", nil)
+		}
+		written[outputs[i]] = input.path
+		if err := os.MkdirAll(filepath.Dir(outputs[i]), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	return outputs, nil
+}
+
+// batchResult summarizes a batch of total conversions. It returns nil if
+// none failed; otherwise an error of the failures' kind, if they share one,
+// so the exit code still tells scripts what went wrong.
+func batchResult(total int, failures []batchFailure) error {
+	if len(failures) == 0 {
+		if !quiet {
+			logger.Info("Converted %d file(s)", total)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "\nConverted %d of %d file(s); failed:\n", total-len(failures), total)
+	kind := internal.KindOf(failures[0].err)
+	for _, failure := range failures {
+		fmt.Fprintf(os.Stderr, "  - %s: %s\n", failure.input, firstLine(failure.err.Error()))
+		if internal.KindOf(failure.err) != kind {
+			kind = internal.KindGeneral
+		}
+	}
+	return internal.WithKind(fmt.Errorf("%d of %d file(s) failed to convert", len(failures), total), kind)
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
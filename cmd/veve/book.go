@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/book"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/theme"
+	"github.com/madstone-tech/veve-cli/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var bookCmd = &cobra.Command{
+	Use:   "book [manifest]",
+	Short: "Build a book from several documents",
+	Long: `Build a book from the documents listed in a manifest (default: veve.book.yaml).
+
+The manifest lists the chapters in order, metadata shared by all of them, the
+book's theme and per-chapter themes, and the files to build:
+
+  title: Platform Handbook
+  metadata:
+    author: Docs Team
+    date: 2026-10-01
+  theme: corporate
+  chapters:
+    - intro.md
+    - file: api/reference.md
+      theme: technical
+  outputs:
+    - path: build/handbook.pdf
+    - path: build/handbook.epub
+
+Paths are relative to the manifest. Each chapter starts on a new page; its own
+front matter is dropped, except that its title becomes the chapter heading.
+Per-chapter themes apply to HTML engines and EPUB; LaTeX engines use the
+book's theme throughout.
+
+With --merge, each chapter is converted to a PDF of its own and the PDFs are
+joined into the file given, in place of the manifest's outputs. A chapter
+that fails to convert is then named in the error. Joining needs qpdf or
+pdfunite.
+
+Examples:
+  veve book
+  veve book docs/veve.book.yaml
+  veve book --engine weasyprint
+  veve book --merge build/handbook.pdf`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manifestFile := book.ManifestFile
+		if len(args) == 1 {
+			manifestFile = args[0]
+		}
+		manifest, err := book.Load(manifestFile)
+		if err != nil {
+			return internal.InputError("book", "load manifest", err.Error(),
+				"check the manifest, or pass its path: veve book path/to/"+book.ManifestFile, err)
+		}
+
+		opts, err := readConversionOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if err := opts.applyConfigDefaults(manifest.Dir()); err != nil {
+			return err
+		}
+		if manifest.Theme != "" && !cmd.Flags().Changed("theme") {
+			opts.Theme = bookThemePath(manifest, manifest.Theme)
+		}
+		return buildBook(manifest, opts)
+	},
+}
+
+func init() {
+	addConversionFlags(bookCmd)
+	_ = bookCmd.Flags().MarkHidden("output")
+}
+
+// buildBook assembles the manifest's chapters into one document and converts
+// it to each of the manifest's outputs.
+func buildBook(manifest *book.Manifest, opts conversionOptions) error {
+	// Private notes and content for other audiences are dropped from each
+	// chapter, before the assembled book is written next to the manifest
+	project, err := config.FindProject(manifest.Dir())
+	if err != nil {
+		return err
+	}
+	decode := func(file string, data []byte) ([]byte, error) {
+		decoded, err := decodeInput(file, data, opts.Strict)
+		if err != nil {
+			return nil, err
+		}
+		filtered, err := filterAudience(project, stripPrivateComments(project, string(decoded)), opts)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(filtered), nil
+	}
+	if opts.ChapterCSS, err = chapterThemesCSS(manifest, opts); err != nil {
+		return err
+	}
+	if opts.JoinFile != "" {
+		return joinBookChapters(manifest, decode, opts)
+	}
+
+	content, err := manifest.Assemble(decode)
+	if internal.IsVeveError(err) {
+		return err
+	}
+	if err != nil {
+		return internal.InputError("book", "assemble chapters", err.Error(), "check the chapters in "+manifest.File, err)
+	}
+
+	// The assembled book sits next to the manifest, so project settings and
+	// relative paths resolve as they do for the chapters
+	source, err := writeGeneratedMarkdown(manifest.Dir(), ".veve-book-*.md", content)
+	if err != nil {
+		return fmt.Errorf("failed to write assembled book: %w", err)
+	}
+	defer os.Remove(source)
+
+	// Report each output here rather than the conversion of the temporary file
+	wasQuiet := quiet
+	defer func() { quiet = wasQuiet }()
+
+	for _, output := range manifest.Outputs {
+		logger.Debug("Building %s (%s)", output.Path, output.Format)
+		quiet = true
+		switch output.Format {
+		case book.FormatEPUB:
+			if err = convertEPUB(source, output.Path, opts); err == nil && opts.Checksum != converter.ChecksumOff {
+				_, err = converter.WriteChecksum(output.Path, opts.Checksum)
+			}
+			if err == nil && opts.Sign != converter.SignerOff {
+				_, err = converter.Sign(output.Path, opts.Sign, opts.SignKey)
+			}
+		default:
+			outputOpts := opts
+			outputOpts.OutputFile = output.Path
+			err = performConversion(source, outputOpts)
+		}
+		quiet = wasQuiet
+		if err != nil {
+			return err
+		}
+		if !quiet {
+			logger.Info("Successfully built %s", output.Path)
+		}
+	}
+	return nil
+}
+
+// joinBookChapters converts each of the manifest's chapters on its own, with
+// the book's metadata and themes, and joins the PDFs into opts.JoinFile. With
+// --merge this replaces the manifest's outputs.
+func joinBookChapters(manifest *book.Manifest, decode func(string, []byte) ([]byte, error), opts conversionOptions) error {
+	chapters, err := manifest.AssembleChapters(decode)
+	if internal.IsVeveError(err) {
+		return err
+	}
+	if err != nil {
+		return internal.InputError("book", "assemble chapters", err.Error(), "check the chapters in "+manifest.File, err)
+	}
+
+	ws, err := workspace.New()
+	if err != nil {
+		return err
+	}
+	defer ws.Remove()
+
+	// Report the joined file rather than each chapter's conversion
+	wasQuiet := quiet
+	defer func() { quiet = wasQuiet }()
+
+	pdfs := make([]string, len(chapters))
+	for i, content := range chapters {
+		file := manifest.Chapters[i].File
+		// Chapters sit next to the manifest, as the assembled book does
+		source, err := writeGeneratedMarkdown(manifest.Dir(), ".veve-chapter-*.md", content)
+		if err != nil {
+			return fmt.Errorf("failed to write chapter %s: %w", file, err)
+		}
+		chapterOpts := opts
+		chapterOpts.OutputFile = filepath.Join(ws.Dir, fmt.Sprintf("chapter-%03d.pdf", i+1))
+		chapterOpts.Checksum, chapterOpts.Sign = converter.ChecksumOff, converter.SignerOff
+
+		logger.Debug("Building chapter %s", file)
+		quiet = true
+		err = performConversion(source, chapterOpts)
+		quiet = wasQuiet
+		os.Remove(source)
+		if err != nil {
+			return fmt.Errorf("chapter %s: %w", file, err)
+		}
+		pdfs[i] = chapterOpts.OutputFile
+	}
+	return joinOutputs("book", pdfs, opts, &conversionReport{})
+}
+
+// writeGeneratedMarkdown writes markdown veve generated to a new hidden file
+// in dir, named after pattern, and returns its path. The caller removes it.
+func writeGeneratedMarkdown(dir, pattern, content string) (string, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// chapterThemesCSS returns the CSS of the manifest's chapter themes, each
+// scoped to the chapters that use it.
+func chapterThemesCSS(manifest *book.Manifest, opts conversionOptions) (string, error) {
+	themes := manifest.ChapterThemes()
+	if len(themes) == 0 {
+		return "", nil
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config paths: %w", err)
+	}
+	loader, err := newThemeLoader(paths, manifest.Dir())
+	if err != nil {
+		return "", err
+	}
+	if err := loader.DiscoverThemes(); err != nil {
+		logger.Debug("Error discovering themes: %v (continuing with defaults)", err)
+	}
+
+	var sb strings.Builder
+	for _, name := range themes {
+		loaded, err := loadTheme(loader, bookThemePath(manifest, name))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(theme.ScopeCSS(processThemeCSS(paths, name, loaded, opts), "."+book.ThemeClass(name)))
+	}
+	return sb.String(), nil
+}
+
+// bookThemePath resolves a theme given as a path relative to the manifest;
+// theme names are returned unchanged.
+func bookThemePath(manifest *book.Manifest, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	path := filepath.Join(manifest.Dir(), filepath.FromSlash(name))
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return path
+	}
+	return name
+}
+
+// convertEPUB converts the assembled book to EPUB with its theme. EPUB needs
+// no PDF engine, so the engine flags don't apply.
+func convertEPUB(source, output string, opts conversionOptions) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get config paths: %w", err)
+	}
+	loader, err := newThemeLoader(paths, filepath.Dir(source))
+	if err != nil {
+		return err
+	}
+	if err := loader.DiscoverThemes(); err != nil {
+		logger.Debug("Error discovering themes: %v (continuing with defaults)", err)
+	}
+	loaded, err := loadTheme(loader, opts.Theme)
+	if err != nil {
+		return err
+	}
+	opts.applyThemeDefaults(loaded)
+
+	ws, err := workspace.New()
+	if err != nil {
+		return err
+	}
+	defer ws.Remove()
+
+	epubOpts := converter.EPUBOptions{
+		InputFile:  source,
+		OutputFile: output,
+		Limits:     opts.limits(),
+		NoSmart:    opts.NoSmart,
+	}
+	if css := processThemeCSS(paths, opts.Theme, loaded, opts) + opts.ChapterCSS; css != "" {
+		if epubOpts.Stylesheet, err = ws.WriteFile("theme-*.css", []byte(css)); err != nil {
+			return fmt.Errorf("failed to write theme CSS: %w", err)
+		}
+	}
+
+	pandoc, err := converter.NewPandocConverter()
+	if err != nil {
+		return internal.PandocNotFound()
+	}
+	return pandoc.ConvertEPUB(epubOpts)
+}
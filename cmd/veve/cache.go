@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the remote image cache",
+	Long: `Manage the remote image cache.
+
+With --image-cache (or image-cache set with 'veve config'), downloaded remote
+images are kept in the user cache directory with their ETag and Last-Modified
+date. A later conversion sends these with its request for the image, and uses
+the kept image when the server answers 304 Not Modified, instead of
+downloading it again.
+
+Examples:
+  veve report.md --image-cache
+  veve cache clear`,
+	// Managing the cache doesn't need pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:          "clear",
+	Short:        "Remove every cached remote image",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := config.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get config paths: %w", err)
+		}
+		cache := converter.NewImageCache(filepath.Join(paths.CacheDir, converter.ImageCacheDirName))
+		images, size, err := cache.Clear()
+		if err != nil {
+			return err
+		}
+		if !quiet {
+			logger.Info("Removed %d cached image(s), %s, from %s", images, formatBytes(size), cache.Dir())
+		}
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+}
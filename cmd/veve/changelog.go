@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/changelog"
+	"github.com/spf13/cobra"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Convert release notes for a version range to PDF",
+	Long: `Convert release notes for a version range to a themed PDF.
+
+The notes come from the matching sections of CHANGELOG.md, or, if it has none
+for --to, from the git commits between --from and --to, grouped by their
+Conventional Commits type (feat, fix, perf, docs; chores, CI, test, build, and
+style commits are left out). Use --source to choose explicitly.
+
+--from defaults to the tag before --to; --to defaults to HEAD, whose
+CHANGELOG section is "Unreleased". The PDF is written to
+release-notes-<version>.pdf unless --output is given; --markdown prints the
+notes instead of converting them.
+
+Examples:
+  veve changelog --from v1.0 --to v1.1
+  veve changelog --to v1.1 --source git --theme corporate
+  veve changelog --markdown > NOTES.md`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	// Printing the markdown doesn't need pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if markdownOnly, _ := cmd.Flags().GetBool("markdown"); markdownOnly {
+			return nil
+		}
+		return rootCmd.PersistentPreRunE(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := cmd.Flags().GetString("from")
+		if err != nil {
+			return err
+		}
+		to, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return err
+		}
+		source, err := cmd.Flags().GetString("source")
+		if err != nil {
+			return err
+		}
+		changelogFile, err := cmd.Flags().GetString("changelog")
+		if err != nil {
+			return err
+		}
+		title, err := cmd.Flags().GetString("title")
+		if err != nil {
+			return err
+		}
+		markdownOnly, err := cmd.Flags().GetBool("markdown")
+		if err != nil {
+			return err
+		}
+
+		version := to
+		if to == "HEAD" {
+			version = changelog.Unreleased
+		}
+		if title == "" {
+			title = "Release Notes " + version
+		}
+
+		notes, err := releaseNotes(source, changelogFile, from, to, version, title)
+		if err != nil {
+			return err
+		}
+		if markdownOnly {
+			_, err := fmt.Fprint(cmd.OutOrStdout(), notes.Markdown())
+			return err
+		}
+
+		opts, err := readConversionOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if err := opts.applyConfigDefaults("."); err != nil {
+			return err
+		}
+		if opts.OutputFile == "" {
+			opts.OutputFile = "release-notes-" + fileNamePart(version) + ".pdf"
+		}
+
+		input, err := writeGeneratedMarkdown(".", ".veve-changelog-*.md", notes.Markdown())
+		if err != nil {
+			return fmt.Errorf("failed to write release notes: %w", err)
+		}
+		defer os.Remove(input)
+
+		// Report the release notes rather than the temporary file
+		wasQuiet := quiet
+		quiet = true
+		err = performConversion(input, opts)
+		quiet = wasQuiet
+		if err != nil {
+			return err
+		}
+		if !quiet {
+			logger.Info("Successfully wrote release notes to %s", opts.OutputFile)
+		}
+		return nil
+	},
+}
+
+func init() {
+	addConversionFlags(changelogCmd)
+	changelogCmd.Flags().String("from", "", "version or git ref the notes start after (default: the tag before --to)")
+	changelogCmd.Flags().String("to", "HEAD", "version or git ref the notes end at")
+	changelogCmd.Flags().String("source", "auto", "where the notes come from: \"changelog\", \"git\", or \"auto\" (the changelog if it has a section for --to)")
+	changelogCmd.Flags().String("changelog", "CHANGELOG.md", "changelog file")
+	changelogCmd.Flags().String("title", "", "document title (default: \"Release Notes <version>\")")
+	changelogCmd.Flags().Bool("markdown", false, "print the notes as markdown instead of converting them")
+}
+
+// releaseNotes collects the notes for from..to from source.
+func releaseNotes(source, changelogFile, from, to, version, title string) (changelog.Notes, error) {
+	// Releases are dated by their tag's commit, when there is one
+	date := ""
+	if to != "HEAD" {
+		date, _ = changelog.Date(".", to)
+	}
+
+	if source == "auto" || source == "changelog" {
+		content, err := readInput(changelogFile, 0)
+		if err == nil {
+			body, sectionErr := changelog.Sections(string(content), from, version)
+			if sectionErr == nil {
+				logger.Debug("Using %s for %s", changelogFile, version)
+				return changelog.Notes{Title: title, Date: date, Body: body}, nil
+			}
+			err = sectionErr
+		}
+		if source == "changelog" {
+			return changelog.Notes{}, internal.InputError("changelog", "read changelog", err.Error(),
+				"add a section headed with the version to "+changelogFile+", or use --source git", err)
+		}
+	} else if source != "git" {
+		return changelog.Notes{}, fmt.Errorf("invalid --source %q: use changelog, git, or auto", source)
+	}
+
+	if from == "" {
+		from = changelog.PreviousTag(".", to)
+	}
+	logger.Debug("Using git history %s..%s", from, to)
+	commits, err := changelog.Log(".", from, to)
+	if err != nil {
+		return changelog.Notes{}, internal.InputError("changelog", "read git history", err.Error(),
+			"run veve changelog inside the repository, with --from and --to naming existing tags or commits", err)
+	}
+	return changelog.FromCommits(title, date, changelog.Categorize(commits)), nil
+}
+
+// unsafeFileChars are characters replaced in generated file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileNamePart makes s safe to use in a file name.
+func fileNamePart(s string) string {
+	return strings.ToLower(strings.Trim(unsafeFileChars.ReplaceAllString(s, "-"), "-"))
+}
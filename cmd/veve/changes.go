@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/redact"
+)
+
+// markChanges marks the blocks of content, the input name as prepared so
+// far, that changed since --diff-against. The earlier version is prepared
+// the same way first (redacted, private notes and other audiences removed),
+// so only edits to the source are marked.
+func markChanges(project *config.ProjectConfig, name, content string, opts conversionOptions) (string, bool, error) {
+	old, err := readDiffBase(name, opts)
+	if err != nil {
+		return "", false, err
+	}
+	if opts.Redact != "" {
+		rules, err := redact.Load(opts.Redact)
+		if err != nil {
+			return "", false, err
+		}
+		old, _ = rules.Apply(old)
+	}
+	old = stripPrivateComments(project, old)
+	if old, err = filterAudience(project, old, opts); err != nil {
+		return "", false, err
+	}
+
+	marked, changed := converter.MarkChanges(content, old)
+	if !changed {
+		logger.Info("No changes since %s", opts.DiffAgainst)
+	}
+	return marked, changed, nil
+}
+
+// readDiffBase reads the earlier version of the input name: the file
+// --diff-against names, or else the input at the git revision it names.
+func readDiffBase(name string, opts conversionOptions) (string, error) {
+	if info, err := os.Stat(opts.DiffAgainst); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(opts.DiffAgainst)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", opts.DiffAgainst, err)
+		}
+		decoded, err := decodeInput(opts.DiffAgainst, data, opts.Strict)
+		return string(decoded), err
+	}
+
+	if name == "-" {
+		return "", internal.UsageError("convert", "read earlier version",
+			fmt.Sprintf("%s is not a file, and stdin has no git history", opts.DiffAgainst),
+			"pass an earlier markdown file, or name the input with --stdin-filename", nil)
+	}
+	cmd := exec.Command("git", "show", opts.DiffAgainst+":./"+filepath.Base(name))
+	cmd.Dir = filepath.Dir(name)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		reason := fmt.Sprintf("%s is neither a file nor a git revision of %s", opts.DiffAgainst, name)
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			reason += ": " + message
+		}
+		return "", internal.InputError("convert", "read earlier version", reason,
+			"pass an earlier markdown file, or a revision such as HEAD~1 or a tag", err)
+	}
+	decoded, err := decodeInput(name, stdout.Bytes(), opts.Strict)
+	return string(decoded), err
+}
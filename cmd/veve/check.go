@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/lint"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check <input>...",
+	Short: "Check markdown files without converting them",
+	Long: `Check markdown files without converting them.
+
+veve checks that each input exists and is readable. With --lint, it also runs
+the markdown lint rules and reports each problem with its line and column,
+failing if any are found. Rules can be turned off with --disable, or for a
+project in .veve.yaml:
+
+  lint:
+    disable: [bare-urls]
+
+Use --list-rules to see the available rules.
+
+Examples:
+  veve check docs/*.md
+  veve check --lint README.md
+  veve check --lint --disable trailing-spaces report.md`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if listRules, _ := cmd.Flags().GetBool("list-rules"); listRules {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: completeMarkdownFiles(-1),
+	// Checking does not run pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runLint, err := cmd.Flags().GetBool("lint")
+		if err != nil {
+			return err
+		}
+		disabled, err := cmd.Flags().GetStringSlice("disable")
+		if err != nil {
+			return err
+		}
+		listRules, err := cmd.Flags().GetBool("list-rules")
+		if err != nil {
+			return err
+		}
+
+		if listRules {
+			for _, rule := range lint.Rules() {
+				fmt.Fprintf(cmd.OutOrStdout(), "%-20s %s\n", rule.Name, rule.Description)
+			}
+			return nil
+		}
+		if err := lint.ValidateRuleNames(disabled); err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, input := range args {
+			if err := converter.ValidateInputFile(input); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", input, err)
+				failed++
+				continue
+			}
+			if !runLint {
+				continue
+			}
+
+			issues, err := lintFile(input, disabled)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", input, err)
+				failed++
+				continue
+			}
+			for _, issue := range issues {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s:%s\n", input, issue)
+			}
+			if len(issues) > 0 {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d file(s) failed the check", failed, len(args))
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%d file(s) checked, no problems found.\n", len(args))
+		return nil
+	},
+}
+
+func init() {
+	checkCmd.Flags().Bool("lint", false, "also run the markdown lint rules")
+	checkCmd.Flags().StringSlice("disable", nil, "lint rules to skip (comma-separated)")
+	checkCmd.Flags().Bool("list-rules", false, "list the lint rules and exit")
+}
+
+// lintFile lints input, skipping the rules disabled on the command line and in
+// the project configuration.
+func lintFile(input string, disabled []string) ([]lint.Issue, error) {
+	content, err := readInput(input, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if content, err = decodeInput(input, content, false); err != nil {
+		return nil, err
+	}
+
+	startDir := "."
+	if input != "-" {
+		startDir = filepath.Dir(input)
+	}
+	project, err := config.FindProject(startDir)
+	if err != nil {
+		return nil, err
+	}
+	if project != nil {
+		if err := lint.ValidateRuleNames(project.Lint.Disable); err != nil {
+			return nil, fmt.Errorf("%s: %w", project.ConfigFile, err)
+		}
+		disabled = append(disabled, project.Lint.Disable...)
+	}
+
+	return lint.Lint(string(content), disabled), nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set persistent defaults",
+	Long: `Get and set persistent defaults in veve.toml, so flags you always give
+don't need repeating. A flag given on the command line still takes precedence,
+as do the defaults of a project's .veve.yaml.
+
+Examples:
+  veve config set theme dark
+  veve config get pdf-engine
+  veve config list
+  veve config unset output-dir`,
+	// Reading and writing veve.toml doesn't need pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <setting>",
+	Short:             "Print a setting's value",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettings,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFile, err := userConfigFile()
+		if err != nil {
+			return err
+		}
+		if _, err := config.LookupSetting(args[0]); err != nil {
+			return internal.WithKind(err, internal.KindUsage)
+		}
+		values, err := config.ReadSettings(configFile)
+		if err != nil {
+			return configFileError(configFile, err)
+		}
+		value, ok := values[args[0]]
+		if !ok {
+			return fmt.Errorf("%s is not set in %s", args[0], configFile)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:               "set <setting> <value>",
+	Short:             "Set a setting",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSettings,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFile, err := userConfigFile()
+		if err != nil {
+			return err
+		}
+		if _, err := config.LookupSetting(args[0]); err != nil {
+			return internal.WithKind(err, internal.KindUsage)
+		}
+		if err := config.SetSetting(configFile, args[0], args[1]); err != nil {
+			return configFileError(configFile, err)
+		}
+		if !quiet {
+			logger.Info("Set %s to %s in %s", args[0], args[1], configFile)
+		}
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <setting>",
+	Short:             "Remove a setting, restoring the built-in default",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettings,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFile, err := userConfigFile()
+		if err != nil {
+			return err
+		}
+		if _, err := config.LookupSetting(args[0]); err != nil {
+			return internal.WithKind(err, internal.KindUsage)
+		}
+		if err := config.UnsetSetting(configFile, args[0]); err != nil {
+			return configFileError(configFile, err)
+		}
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the settings that are set",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return err
+		}
+		configFile, err := userConfigFile()
+		if err != nil {
+			return err
+		}
+		values, err := config.ReadSettings(configFile)
+		if err != nil {
+			return configFileError(configFile, err)
+		}
+		writeSettings(cmd.OutOrStdout(), configFile, values, all)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+
+	configListCmd.Flags().Bool("all", false, "list every setting, with what it sets, including those not set")
+}
+
+// userConfigFile returns the path of veve.toml.
+func userConfigFile() (string, error) {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config paths: %w", err)
+	}
+	return paths.ConfigFile, nil
+}
+
+// configFileError reports a veve.toml that can't be read, or a value it
+// can't hold.
+func configFileError(configFile string, err error) error {
+	return internal.ConfigError("config", "update configuration", err.Error(),
+		"check the value, or fix "+configFile, err)
+}
+
+// writeSettings lists the settings in values; with all, every setting,
+// described.
+func writeSettings(w io.Writer, configFile string, values map[string]string, all bool) {
+	if !all && len(values) == 0 {
+		fmt.Fprintf(w, "No settings in %s (see veve config list --all)\n", configFile)
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range config.Settings {
+		value, ok := values[s.Name]
+		switch {
+		case all && ok:
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, value, s.Description)
+		case all:
+			fmt.Fprintf(tw, "%s\t(not set)\t%s\n", s.Name, s.Description)
+		case ok:
+			fmt.Fprintf(tw, "%s\t%s\n", s.Name, value)
+		}
+	}
+	tw.Flush()
+}
+
+// completeSettings completes the setting name of a config subcommand and,
+// for set, its value: themes, engines, key files, true or false, or
+// directories.
+func completeSettings(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		var names []string
+		for _, s := range config.Settings {
+			names = append(names, s.Name+"\t"+s.Description)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+	if cmd.Name() != "set" || len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	s, err := config.LookupSetting(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	switch s.Name {
+	case "theme":
+		return completeThemes(cmd, nil, toComplete)
+	case "pdf-engine":
+		return completeEngines(cmd, nil, toComplete)
+	case "sign-key":
+		// A gpg key ID, or a minisign secret key file
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	if s.Kind == "bool" {
+		return []string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
+	}
+	if s.Kind == "string" {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
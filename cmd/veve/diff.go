@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/completion"
+	"github.com/madstone-tech/veve-cli/internal/pdfdiff"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare the rendered output of two documents",
+	Long: `Compare the rendered output of two documents.
+
+Each argument is a PDF or a markdown file; markdown files are converted first,
+using the conversion flags. veve reports the page counts and a unified diff of
+the page text. With --visual, pages are also rasterized and compared, and an
+image highlighting the changes in red is written for each changed page.
+
+Requires the poppler tools (pdftotext, and pdftoppm for --visual).
+
+Examples:
+  veve diff release-1.0.pdf report.md
+  veve diff old.md new.md --theme academic
+  veve diff old.pdf new.pdf --visual diff-pages`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeFiles(2, append(completion.MarkdownExtensions, ".pdf")...),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := readConversionOptions(cmd)
+		if err != nil {
+			return err
+		}
+		// Both versions render with the settings of the newer one's project
+		if err := opts.applyConfigDefaults(inputProjectDir(args[1], opts)); err != nil {
+			return err
+		}
+		visualDir, err := cmd.Flags().GetString("visual")
+		if err != nil {
+			return err
+		}
+		dpi, err := cmd.Flags().GetInt("dpi")
+		if err != nil {
+			return err
+		}
+		ignoreWhitespace, err := cmd.Flags().GetBool("ignore-whitespace")
+		if err != nil {
+			return err
+		}
+
+		workDir, err := os.MkdirTemp("", "veve-diff-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+
+		oldPDF, err := renderForComparison(args[0], filepath.Join(workDir, "old.pdf"), opts)
+		if err != nil {
+			return err
+		}
+		newPDF, err := renderForComparison(args[1], filepath.Join(workDir, "new.pdf"), opts)
+		if err != nil {
+			return err
+		}
+
+		result, err := pdfdiff.Compare(oldPDF, newPDF, args[0], args[1], pdfdiff.Options{IgnoreWhitespace: ignoreWhitespace})
+		if err != nil {
+			return comparisonError("diff", err)
+		}
+		writeComparison(cmd.OutOrStdout(), result)
+
+		if visualDir != "" {
+			pages, err := pdfdiff.VisualDiff(oldPDF, newPDF, visualDir, dpi)
+			if err != nil {
+				return comparisonError("diff", err)
+			}
+			writeVisualComparison(cmd.OutOrStdout(), pages)
+		}
+		return nil
+	},
+}
+
+func init() {
+	addConversionFlags(diffCmd)
+	_ = diffCmd.Flags().MarkHidden("output")
+	diffCmd.Flags().String("visual", "", "also compare rasterized pages, writing diff images to this directory")
+	diffCmd.Flags().Int("dpi", 50, "resolution for --visual")
+	diffCmd.Flags().Bool("ignore-whitespace", false, "ignore spacing changes and blank lines in the text")
+}
+
+// renderForComparison returns input if it is a PDF, or converts it to output.
+func renderForComparison(input, output string, opts conversionOptions) (string, error) {
+	if strings.EqualFold(filepath.Ext(input), ".pdf") {
+		if _, err := os.Stat(input); err != nil {
+			return "", fmt.Errorf("cannot read %s: %w", input, err)
+		}
+		return input, nil
+	}
+
+	opts.OutputFile = output
+	if err := performConversion(input, opts); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// comparisonError explains a missing poppler tool.
+func comparisonError(command string, err error) error {
+	var missing *pdfdiff.MissingToolError
+	if errors.As(err, &missing) {
+		return internal.PopplerNotFound(command, missing.Tool)
+	}
+	return err
+}
+
+// writeComparison reports the page counts and text diff.
+func writeComparison(w io.Writer, result *pdfdiff.Result) {
+	if result.OldPages == result.NewPages {
+		fmt.Fprintf(w, "Pages: %d (unchanged)\n", result.NewPages)
+	} else {
+		fmt.Fprintf(w, "Pages: %d -> %d\n", result.OldPages, result.NewPages)
+	}
+
+	if result.TextDiff == "" {
+		fmt.Fprintln(w, "Text: unchanged")
+		return
+	}
+	fmt.Fprintln(w, "Text: changed")
+	fmt.Fprint(w, result.TextDiff)
+}
+
+// writeVisualComparison reports the changed pages.
+func writeVisualComparison(w io.Writer, pages []pdfdiff.PageDiff) {
+	changed := 0
+	for _, page := range pages {
+		if page.Changed == 0 {
+			continue
+		}
+		changed++
+		fmt.Fprintf(w, "Visual: page %d: %.1f%% changed (%s)\n", page.Page, page.Changed*100, page.ImagePath)
+	}
+	if changed == 0 {
+		fmt.Fprintln(w, "Visual: unchanged")
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/pdfdiff"
+	"github.com/spf13/cobra"
+)
+
+var engineCmd = &cobra.Command{
+	Use:   "engine",
+	Short: "Inspect PDF engines",
+	Long:  "Inspect the PDF engines veve can use.",
+}
+
+var engineBenchCmd = &cobra.Command{
+	Use:   "bench [doc.md]",
+	Short: "Compare the speed and output of each PDF engine",
+	Long: `Compare the speed and output of each PDF engine.
+
+veve converts a document with each installed engine and reports:
+
+  time     wall time of the conversion (the median with --runs)
+  size     size of the PDF
+  unicode  how many of the document's non-ASCII characters appear in the PDF's
+           text (needs pdftotext from the poppler tools)
+
+Without a document, a built-in one with typical content and a range of unicode
+is used. It takes the same flags as a conversion, so the results reflect your
+theme and settings.
+
+Examples:
+  veve engine bench
+  veve engine bench report.md --theme academic
+  veve engine bench report.md --engines xelatex,weasyprint --runs 3
+  veve engine bench --json`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeMarkdownFiles(1),
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := readConversionOptions(cmd)
+		if err != nil {
+			return err
+		}
+		names, err := cmd.Flags().GetStringSlice("engines")
+		if err != nil {
+			return err
+		}
+		runs, err := cmd.Flags().GetInt("runs")
+		if err != nil {
+			return err
+		}
+		if runs < 1 {
+			return fmt.Errorf("--runs must be at least 1")
+		}
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		workDir, err := os.MkdirTemp("", "veve-bench-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+
+		input := filepath.Join(workDir, "benchmark.md")
+		projectDir := "."
+		if len(args) > 0 {
+			input = args[0]
+			projectDir = inputProjectDir(input, opts)
+		} else if err := os.WriteFile(input, []byte(engines.BenchmarkDocument()), 0o644); err != nil {
+			return fmt.Errorf("failed to write benchmark document: %w", err)
+		}
+		source, err := readInput(input, 0)
+		if err != nil {
+			return internal.InputError("engine bench", "read document", err.Error(), "", err)
+		}
+		if source, err = decodeInput(input, source, false); err != nil {
+			return err
+		}
+		// Each engine is benchmarked with the configured theme and image settings
+		if err := opts.applyConfigDefaults(projectDir); err != nil {
+			return err
+		}
+
+		if len(names) == 0 {
+			installed, err := engines.DetectInstalledEngines()
+			if err != nil {
+				return internal.EngineError("engine bench", "detect engines", err.Error(),
+					"install a PDF engine, or name engines with --engines", err)
+			}
+			for _, engine := range installed {
+				names = append(names, engine.Name)
+			}
+		}
+
+		// The per-run success messages would interleave with the report
+		wasQuiet := quiet
+		quiet = true
+		defer func() { quiet = wasQuiet }()
+
+		var results []benchResult
+		for _, name := range names {
+			results = append(results, benchEngine(name, input, string(source), workDir, opts, runs))
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(results)
+		}
+		writeBenchResults(cmd.OutOrStdout(), results)
+		return nil
+	},
+}
+
+func init() {
+	engineCmd.AddCommand(engineBenchCmd)
+
+	addConversionFlags(engineBenchCmd)
+	_ = engineBenchCmd.Flags().MarkHidden("output")
+	_ = engineBenchCmd.Flags().MarkHidden("engine")
+	engineBenchCmd.Flags().StringSlice("engines", nil, "engines to compare (default: every installed engine)")
+	engineBenchCmd.Flags().Int("runs", 1, "conversions per engine; the median time is reported")
+	engineBenchCmd.Flags().Bool("json", false, "print the results as JSON")
+}
+
+// benchResult is the benchmark of one engine.
+type benchResult struct {
+	Engine  string        `json:"engine"`
+	Time    time.Duration `json:"time_ns,omitempty"`
+	Size    int64         `json:"size,omitempty"`
+	Unicode *benchUnicode `json:"unicode,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// benchUnicode is the unicode fidelity of an engine's output.
+type benchUnicode struct {
+	Found int `json:"found"`
+	Total int `json:"total"`
+}
+
+// benchEngine converts input with engine runs times.
+func benchEngine(engine, input, source, workDir string, opts conversionOptions, runs int) benchResult {
+	result := benchResult{Engine: engine}
+	opts.PDFEngine = engine
+	opts.OutputFile = filepath.Join(workDir, "bench-"+filepath.Base(engine)+".pdf")
+
+	var times []time.Duration
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		if err := performConversion(input, opts); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		times = append(times, time.Since(start))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	result.Time = times[len(times)/2]
+
+	if info, err := os.Stat(opts.OutputFile); err == nil {
+		result.Size = info.Size()
+	}
+
+	// Unicode fidelity is left out when pdftotext is missing
+	pages, err := pdfdiff.ExtractText(opts.OutputFile)
+	var missing *pdfdiff.MissingToolError
+	if err != nil && !errors.As(err, &missing) {
+		result.Error = err.Error()
+	} else if err == nil {
+		found, total := engines.UnicodeFidelity(source, strings.Join(pages, "\n"))
+		result.Unicode = &benchUnicode{Found: found, Total: total}
+	}
+	return result
+}
+
+// writeBenchResults prints the results as a table.
+func writeBenchResults(out io.Writer, results []benchResult) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENGINE\tTIME\tSIZE\tUNICODE")
+	fmt.Fprintln(w, "------\t----\t----\t-------")
+	for _, result := range results {
+		if result.Time == 0 {
+			fmt.Fprintf(w, "%s\t-\t-\tfailed: %s\n", result.Engine, result.Error)
+			continue
+		}
+		unicode := "n/a (needs pdftotext)"
+		switch {
+		case result.Error != "":
+			unicode = "failed: " + result.Error
+		case result.Unicode != nil && result.Unicode.Total == 0:
+			unicode = "n/a (document is ASCII)"
+		case result.Unicode != nil:
+			unicode = fmt.Sprintf("%d/%d (%.0f%%)", result.Unicode.Found, result.Unicode.Total,
+				100*float64(result.Unicode.Found)/float64(result.Unicode.Total))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Engine, result.Time.Round(time.Millisecond),
+			formatBytes(result.Size), unicode)
+	}
+	w.Flush()
+}
+
+// formatBytes formats a file size in bytes, KB, or MB.
+func formatBytes(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	if size < 1024*1024 {
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// htmlFallbackFile returns where to write themed HTML instead of outputFile
+// after a conversion failed with err, or "" to report err. It only applies
+// when no PDF engine is installed, and then with --fallback-html or when the
+// user agrees when asked.
+func htmlFallbackFile(opts conversionOptions, outputFile string, err error) string {
+	if !opts.OfferFallback || opts.PDFEngine != "" || outputFile == "-" {
+		return ""
+	}
+	if installed, _ := engines.DetectInstalledEngines(); len(installed) > 0 {
+		return ""
+	}
+
+	htmlFile := strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ".html"
+	if opts.FallbackHTML {
+		return htmlFile
+	}
+
+	// Ask once per run, not once per mail merge record, nor over inputs
+	// converting at once
+	if opts.Data != "" || opts.Jobs > 1 || !stdinIsTerminal() {
+		return ""
+	}
+	fmt.Printf("%v\nNo PDF engine is installed. Write themed HTML to %s instead? (y/n) ", err, htmlFile)
+	var response string
+	if _, err := fmt.Scanln(&response); err != nil {
+		fmt.Println()
+		return ""
+	}
+	if response = strings.ToLower(strings.TrimSpace(response)); response != "y" && response != "yes" {
+		return ""
+	}
+	return htmlFile
+}
+
+// convertHTML writes the processed markdown to htmlFile as standalone HTML
+// styled with the theme.
+func convertHTML(processedInputFile, htmlFile, themeFile string, resourcePath []string, opts conversionOptions) error {
+	pandoc, err := converter.NewPandocConverter()
+	if err != nil {
+		return err
+	}
+	return pandoc.ConvertHTML(converter.HTMLOptions{
+		InputFile:    processedInputFile,
+		OutputFile:   htmlFile,
+		Stylesheet:   themeFile,
+		ResourcePath: resourcePath,
+		Limits:       opts.limits(),
+		NoSmart:      opts.NoSmart,
+	})
+}
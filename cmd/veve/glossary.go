@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+// applyGlossary expands the acronyms in the project's glossary on their
+// first use in content, and appends a section defining the terms content
+// uses if the project asks for one.
+func applyGlossary(project *config.ProjectConfig, content string) (string, error) {
+	if project == nil || project.Glossary.File == "" {
+		return content, nil
+	}
+	path := project.Glossary.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(project.Root, filepath.FromSlash(path))
+	}
+	glossary, err := converter.LoadGlossary(path)
+	if err != nil {
+		return "", internal.ConfigError("convert", "load glossary", err.Error(),
+			"fix the glossary file set in "+project.ConfigFile, err)
+	}
+
+	expanded, used := glossary.Expand(content)
+	logger.Debug("Document uses %d of %d glossary terms", len(used), len(glossary.Terms))
+	if !project.Glossary.Section || len(used) == 0 {
+		return expanded, nil
+	}
+	if !strings.HasSuffix(expanded, "\n") {
+		expanded += "\n"
+	}
+	return expanded + "\n" + converter.GlossarySection(project.Glossary.Title, used), nil
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/spf13/cobra"
+)
+
+// example is a copy-pasteable invocation of veve.
+type example struct {
+	Comment string // What it does
+	Command string
+}
+
+// helpSection is a titled part of a help topic, with examples of it.
+type helpSection struct {
+	Title    string
+	Text     string
+	Examples []example
+}
+
+// helpTopic is long-form help shown by veve help <name>.
+type helpTopic struct {
+	Name     string
+	Short    string
+	Intro    string
+	Sections []helpSection
+}
+
+// helpTopics are the topics of veve help, beside its commands.
+var helpTopics = []helpTopic{
+	{
+		Name:  "themes",
+		Short: "Choosing, installing, and writing themes",
+		Intro: `A theme is a CSS stylesheet that styles the PDF. veve has built-in themes,
+and looks for others in your user and project theme directories.`,
+		Sections: []helpSection{
+			{
+				Title: "Choosing a theme",
+				Text: `--theme takes the name of a theme or the path of a CSS file. Without it,
+the "default" theme is used.`,
+				Examples: []example{
+					{"List the themes veve can find", "veve theme list"},
+					{"Convert with a theme", "veve report.md --theme academic"},
+					{"Convert with a stylesheet of your own", "veve report.md --theme ./brand.css"},
+				},
+			},
+			{
+				Title: "Where themes are found",
+				Text: `Built-in    embedded in the binary
+User        ~/.config/veve/themes/*.css (%APPDATA%\veve\themes on Windows);
+            subdirectories namespace themes, e.g. --theme company/brand
+Project     .veve/themes/*.css in the input's directory or a parent, or
+            the themes_dir set in .veve.yaml; project themes override user
+            and built-in themes with the same name`,
+			},
+			{
+				Title: "Installing themes",
+				Text: `veve theme add installs a CSS file, a zip archive, a .vevetheme package,
+or a theme from a GitHub repository, from a path or a URL. Credentials
+for private hosts are read from [[theme_sources]] in veve.toml, or from
+VEVE_THEME_HOST with VEVE_THEME_TOKEN, and GITHUB_TOKEN.`,
+				Examples: []example{
+					{"Install a theme from a URL", "veve theme add mytheme https://example.com/themes/mytheme.css"},
+					{"Install a theme from a GitHub repository", "veve theme add company/brand github:acme/veve-themes@v2.1/brand"},
+					{"Reinstall themes from where they were added", "veve theme update"},
+					{"Remove a theme", "veve theme remove mytheme"},
+				},
+			},
+			{
+				Title: "Writing a theme",
+				Text: `A theme is CSS with YAML front matter naming it. Front matter may also
+list the engines the theme needs (engines: [weasyprint]). LaTeX engines
+don't read CSS: veve translates fonts, colors, margins, and heading
+styles for them, and ignores other rules with a warning. Use an HTML
+engine such as weasyprint or prince for full CSS support.`,
+				Examples: []example{
+					{"Check a theme for CSS errors and rules the engine can't render", "veve theme validate mytheme --engine weasyprint"},
+					{"Package a theme directory with its fonts and assets", "veve theme pack ./brand -o brand.vevetheme"},
+				},
+			},
+		},
+	},
+	{
+		Name:  "engines",
+		Short: "PDF engines, and how veve picks one",
+		Intro: `pandoc converts markdown with a PDF engine: a LaTeX engine (xelatex,
+lualatex) or an HTML engine (weasyprint, prince, wkhtmltopdf). Without
+--engine, veve uses the first installed engine that can render unicode,
+in that order, unless the theme asks for another.`,
+		Sections: []helpSection{
+			{
+				Title: "Choosing an engine",
+				Text: `xelatex      recommended; fast, with good unicode support
+lualatex     like xelatex
+weasyprint   full CSS support
+prince       full CSS support (commercial)
+wkhtmltopdf  no emoji support
+docker       pandoc and xelatex in a container, docker:<image> for
+             another image; needs neither installed locally`,
+				Examples: []example{
+					{"Convert with a given engine", "veve report.md --engine weasyprint"},
+					{"Convert without installing pandoc or TeX", "veve report.md --engine docker"},
+					{"Pass an argument to the engine as is", "veve report.md --engine xelatex --engine-arg=-shell-escape"},
+				},
+			},
+			{
+				Title: "Detection",
+				Text: `Detected engines are cached, and detected again when an engine or pandoc
+changes. After installing fonts or TeX packages, detect them anyway with
+--refresh-engines. LaTeX packages a document needs can be installed as
+they're reported missing with --auto-install-packages, and a LaTeX run
+that fails only now and then retried once with --retry-latex.`,
+				Examples: []example{
+					{"Detect engines again", "veve report.md --refresh-engines"},
+					{"Install missing TeX packages and convert again", "veve report.md --auto-install-packages"},
+					{"Convert once more if LaTeX fails", "veve report.md --retry-latex"},
+				},
+			},
+			{
+				Title: "Comparing engines",
+				Text: `veve engine bench converts a document with each installed engine and
+reports the time taken, the PDF's size, and how many of its non-ASCII
+characters made it into the PDF.`,
+				Examples: []example{
+					{"Compare the engines on a built-in document", "veve engine bench"},
+					{"Compare some engines on your document", "veve engine bench report.md --engines xelatex,weasyprint --runs 3"},
+				},
+			},
+			{
+				Title: "Without an engine",
+				Text: `With no PDF engine installed, --fallback-html writes themed standalone
+HTML instead of failing.`,
+				Examples: []example{
+					{"Write HTML when no engine is installed", "veve report.md --fallback-html"},
+				},
+			},
+		},
+	},
+	{
+		Name:  "images",
+		Short: "Local and remote images, figures, covers, and logos",
+		Intro: `Images are written in markdown as ![alt](path "title"). Relative paths
+are resolved against the markdown file's directory.`,
+		Sections: []helpSection{
+			{
+				Title: "Remote images",
+				Text: `Images at http:// and https:// URLs are downloaded (5 at a time, with
+retries) and embedded, then removed once the PDF is written. Images that
+fail to download are left as they are, and reported as warnings when
+veve finishes. Downloads are limited to 100MB per image and 500MB per
+run.
+
+With --image-cache, downloaded images are kept in the user cache directory,
+and later runs ask for them with If-None-Match and If-Modified-Since, so an
+image that hasn't changed comes back as 304 Not Modified and isn't
+downloaded again.`,
+				Examples: []example{
+					{"Allow more time for slow networks", "veve report.md --remote-images-timeout=30 --remote-images-max-retries=5"},
+					{"Download to faster storage", "veve report.md --remote-images-temp-dir=/mnt/fast-ssd"},
+					{"Leave remote images alone", "veve report.md --enable-remote-images=false"},
+					{"Keep images for the next run", "veve report.md --image-cache"},
+				},
+			},
+			{
+				Title: "Figures",
+				Text: `With --figures, an image on a line of its own becomes a numbered figure,
+captioned with its alt text or title, even within a paragraph. Images with
+neither, or sharing a line with text, stay as they are.`,
+				Examples: []example{
+					{"Number and caption images", "veve report.md --figures"},
+				},
+			},
+			{
+				Title: "Covers and logos",
+				Text: `--cover-image and --logo take a PNG, JPEG, GIF, SVG, or PDF file, passed
+to templates as $cover-image$ and $logo$.`,
+				Examples: []example{
+					{"Add a cover image and a logo", "veve report.md --cover-image cover.png --logo logo.svg"},
+				},
+			},
+			{
+				Title: "Resource directories",
+				Text: `Images, bibliographies, and CSL styles are found relative to the current
+directory, then in each --resource-dir, then in the resource_dirs listed in
+.veve.yaml, relative to the project root.`,
+				Examples: []example{
+					{"Find images and bibliographies in shared directories", "veve docs/report.md --resource-dir assets --resource-dir references"},
+				},
+			},
+			{
+				Title: "Checking images",
+				Text: `veve validate checks every image is reachable before converting: remote
+images with a HEAD request, local ones on pandoc's resource path.`,
+				Examples: []example{
+					{"Check a document's images", "veve validate report.md"},
+				},
+			},
+		},
+	},
+}
+
+// commandExamples are the examples --examples prints, by command path.
+var commandExamples = map[string][]example{
+	"veve": {
+		{"Convert to report.pdf", "veve report.md"},
+		{"Convert with a theme, to a given file", "veve report.md --theme academic -o build/report.pdf"},
+		{"Convert from stdin to stdout", "cat report.md | veve - -o - > report.pdf"},
+		{"Convert every document in a directory, each to its own PDF", "veve docs/*.md -o pdf/"},
+		{"Number headings and figures", "veve report.md --number-sections --figures"},
+		{"Make a slide deck", "veve talk.md --format slides"},
+	},
+	"veve convert": {
+		{"Convert to report.pdf", "veve convert report.md"},
+		{"Convert with a theme and engine", "veve convert report.md --theme academic --engine weasyprint"},
+		{"Convert several documents", "veve convert intro.md \"chapters/*.md\""},
+		{"Convert a directory tree, mirroring it under pdf/", "veve convert ./docs --output-dir ./pdf"},
+		{"Convert a directory and join the PDFs into one", "veve convert ./docs --output-dir ./pdf --merge docs.pdf"},
+		{"Write PDF, HTML, and EPUB from one pass", "veve convert guide.md --formats pdf,html,epub"},
+		{"Publish with a checksum and a gpg signature", "veve convert guide.md --checksum --sign"},
+	},
+	"veve book": {
+		{"Build the book in veve.book.yaml", "veve book"},
+		{"Build a book from another manifest", "veve book docs/veve.book.yaml"},
+		{"Build with an HTML engine, for per-chapter themes", "veve book --engine weasyprint"},
+		{"Build each chapter on its own and join them", "veve book --merge build/handbook.pdf"},
+	},
+	"veve changelog": {
+		{"Release notes between two tags", "veve changelog --from v1.0 --to v1.1"},
+		{"Release notes from git history, with a theme", "veve changelog --to v1.1 --source git --theme corporate"},
+		{"Write the notes as markdown", "veve changelog --markdown > NOTES.md"},
+	},
+	"veve check": {
+		{"Check markdown files", "veve check docs/*.md"},
+		{"Lint a file", "veve check --lint README.md"},
+		{"Lint without a rule", "veve check --lint --disable trailing-spaces report.md"},
+	},
+	"veve cache clear": {
+		{"Remove the cached remote images", "veve cache clear"},
+	},
+	"veve completion": {
+		{"Load completions into the current bash session", "source <(veve completion bash)"},
+		{"Write the zsh completion script", `veve completion zsh > "${fpath[1]}/_veve"`},
+	},
+	"veve completion install": {
+		{"Install completions for your shell", "veve completion install"},
+		{"Install completions for another shell", "veve completion install fish"},
+	},
+	"veve config get": {
+		{"Print the default theme", "veve config get theme"},
+	},
+	"veve config list": {
+		{"List your defaults", "veve config list"},
+		{"List every setting", "veve config list --all"},
+	},
+	"veve config set": {
+		{"Convert with the dark theme by default", "veve config set theme dark"},
+		{"Wait longer for remote images", "veve config set remote-images-timeout 30"},
+	},
+	"veve config unset": {
+		{"Go back to the built-in theme", "veve config unset theme"},
+	},
+	"veve diff": {
+		{"Compare a document with a released PDF", "veve diff release-1.0.pdf report.md"},
+		{"Compare two versions with a theme", "veve diff old.md new.md --theme academic"},
+		{"Write images of the changed pages", "veve diff old.pdf new.pdf --visual diff-pages"},
+	},
+	"veve engine bench": {
+		{"Compare the engines on a built-in document", "veve engine bench"},
+		{"Compare them on your document and theme", "veve engine bench report.md --theme academic"},
+		{"Compare some engines, three runs each", "veve engine bench report.md --engines xelatex,weasyprint --runs 3"},
+		{"Print the results as JSON", "veve engine bench --json"},
+	},
+	"veve new": {
+		{"Start a report", "veve new report"},
+		{"Start a letter", `veve new letter offer.md --title "Job Offer" --author "Jane Doe"`},
+		{"Add an architecture decision record", `veve new adr docs/adr --title "Use PostgreSQL"`},
+	},
+	"veve release manifest": {
+		{"Write a Homebrew formula", "veve release manifest --format homebrew --tag v0.3.0 -o Formula/veve.rb"},
+		{"Write a Scoop manifest", "veve release manifest --format scoop --checksums dist/checksums.txt"},
+	},
+	"veve stats": {
+		{"Summarize conversions", "veve stats"},
+		{"Show the history by engine", "veve stats --history --by engine"},
+		{"Print the history as JSON", "veve stats --history --json"},
+	},
+	"veve sync": {
+		{"Download the themes and filters pinned in .veve.yaml", "veve sync"},
+	},
+	"veve theme add": {
+		{"Install a theme from a file", "veve theme add mytheme /path/to/mytheme.css"},
+		{"Install a theme from a URL", "veve theme add mytheme https://example.com/themes/mytheme.css"},
+		{"Install one theme of an archive", "veve theme add dark https://example.com/themes.zip --file themes/dark.css"},
+		{"Install a theme from a GitHub repository", "veve theme add company/brand github:acme/veve-themes@v2.1/brand"},
+	},
+	"veve theme list": {
+		{"List the themes veve can find", "veve theme list"},
+	},
+	"veve theme pack": {
+		{"Package a theme directory", "veve theme pack ./brand -o brand.vevetheme"},
+	},
+	"veve theme remove": {
+		{"Remove a theme", "veve theme remove mytheme"},
+	},
+	"veve theme update": {
+		{"Reinstall every theme from where it was added", "veve theme update"},
+		{"Reinstall one theme", "veve theme update company/brand"},
+	},
+	"veve theme validate": {
+		{"Check a theme for CSS errors", "veve theme validate mytheme"},
+		{"Also check what an engine can render", "veve theme validate mytheme --engine weasyprint"},
+	},
+	"veve validate": {
+		{"Check a document can be converted", "veve validate report.md"},
+		{"Check it with a theme and engine", "veve validate report.md --theme academic --engine weasyprint"},
+		{"Print the results as JSON", "veve validate report.md --json"},
+	},
+	"veve verify": {
+		{"Compare the output with a golden PDF", "veve verify report.md --golden testdata/report.pdf"},
+		{"Allow a few changed lines", "veve verify report.md --golden testdata/report.pdf --max-changed-lines 2"},
+		{"Update the golden PDF", "veve verify report.md --golden testdata/report.pdf --update"},
+	},
+}
+
+// helpCmd replaces cobra's help command, which needs pandoc like the root
+// command and answers an unknown topic with the usage, exiting 0.
+var helpCmd = &cobra.Command{
+	Use:   "help [command | topic]",
+	Short: "Help about any command or topic",
+	Long: `Help about any command, or about a topic that cuts across commands.
+
+Run veve help with no arguments to list the commands and topics.`,
+	SilenceUsage: true,
+	// Help doesn't need pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		parent, _, err := rootCmd.Find(args)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, sub := range parent.Commands() {
+			if sub.IsAvailableCommand() || sub.IsAdditionalHelpTopicCommand() {
+				if strings.HasPrefix(sub.Name(), toComplete) {
+					names = append(names, sub.Name()+"\t"+sub.Short)
+				}
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		found, rest, err := rootCmd.Find(args)
+		if err != nil || len(rest) > 0 || (found == rootCmd && len(args) > 0) {
+			return internal.UsageError("help", "show help",
+				fmt.Sprintf("unknown help topic %q", strings.Join(args, " ")),
+				"run 'veve help' to list the commands and topics", nil)
+		}
+		found.InitDefaultHelpFlag()
+		return found.Help()
+	},
+}
+
+func init() {
+	for _, topic := range helpTopics {
+		rootCmd.AddCommand(topic.command())
+	}
+	rootCmd.SetHelpCommand(helpCmd)
+	rootCmd.PersistentFlags().Bool("examples", false, "print examples of the command, ready to copy, and exit")
+}
+
+// command returns the topic as a cobra command without a Run, which cobra
+// lists under "Additional help topics" and shows with veve help <name>.
+func (t helpTopic) command() *cobra.Command {
+	var sb strings.Builder
+	sb.WriteString(t.Intro)
+	for _, section := range t.Sections {
+		sb.WriteString("\n\n" + section.Title + ":\n\n")
+		sb.WriteString(indent(section.Text, "  "))
+		for _, ex := range section.Examples {
+			sb.WriteString("\n\n" + indent(ex.String(), "  "))
+		}
+	}
+	return &cobra.Command{
+		Use:   t.Name,
+		Short: t.Short,
+		Long:  sb.String(),
+	}
+}
+
+// String returns the example as a shell comment and the command.
+func (e example) String() string {
+	return "# " + e.Comment + "\n" + e.Command
+}
+
+// indent prefixes each non-blank line of text with prefix.
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wantsExamples reports whether args ask for --examples, and of which
+// command. It is checked before the command runs, like --help, so that
+// neither its arguments nor pandoc are needed.
+func wantsExamples(args []string) (*cobra.Command, bool) {
+	cmd, rest, err := rootCmd.Find(args)
+	if err != nil {
+		return nil, false
+	}
+	if end := slices.Index(rest, "--"); end >= 0 {
+		rest = rest[:end]
+	}
+	if !slices.Contains(rest, "--examples") && !slices.Contains(rest, "--examples=true") {
+		return nil, false
+	}
+	return cmd, true
+}
+
+// writeExamples writes the examples of cmd to w, or of its subcommands if
+// it has none of its own.
+func writeExamples(w io.Writer, cmd *cobra.Command) error {
+	examples := commandExamples[cmd.CommandPath()]
+	if len(examples) == 0 {
+		for _, sub := range cmd.Commands() {
+			examples = append(examples, commandExamples[sub.CommandPath()]...)
+		}
+	}
+	if len(examples) == 0 {
+		return fmt.Errorf("no examples for %s; see %s --help", cmd.CommandPath(), cmd.CommandPath())
+	}
+	for i, ex := range examples {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, ex)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/hooks"
+)
+
+// runProjectHooks runs the project's hooks for stage. Hooks run commands
+// from whoever wrote .veve.yaml, such as the authors of a cloned repository,
+// so they only run with --hooks or hooks set in veve.toml. They are skipped
+// with --no-hooks, and with --sandbox, since a project holding untrusted input
+// may hold untrusted hooks too.
+func runProjectHooks(stage string, project *config.ProjectConfig, conversion hooks.Conversion, opts conversionOptions) error {
+	if project == nil || opts.NoHooks {
+		return nil
+	}
+	commands := project.Hooks.PreConvert
+	if stage == hooks.PostConvert {
+		commands = project.Hooks.PostConvert
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+	if opts.Sandbox != "" {
+		logger.Warn("Skipping %s hooks from %s in the sandbox", stage, project.ConfigFile)
+		return nil
+	}
+	if !trustsProject(opts) {
+		logger.Warn("Skipping %d %s hook(s) from %s: %s", len(commands), stage, project.ConfigFile, trustHint)
+		return nil
+	}
+
+	logger.Debug("Running %d %s hook(s) from %s", len(commands), stage, project.ConfigFile)
+	// Keep stdout for the PDF when writing it there
+	var stdout io.Writer = os.Stdout
+	if conversion.Output == "" {
+		stdout = os.Stderr
+	}
+	if err := hooks.Run(stage, commands, conversion, stdout, os.Stderr); err != nil {
+		var hookErr *hooks.Error
+		if errors.As(err, &hookErr) {
+			return internal.HookFailed(stage, hookErr.Command, hookErr.Err)
+		}
+		return err
+	}
+	return nil
+}
+
+// trustHint says how to have veve trust a project, to run the code and
+// apply the defaults it skipped.
+const trustHint = "run with --hooks if you trust the project, or set hooks with 'veve config' to always run them"
+
+// trustsProject reports whether the project's own code, its hooks and
+// command and plugin transformers, may run: with --hooks, or with hooks set
+// in veve.toml and --no-hooks not given.
+func trustsProject(opts conversionOptions) bool {
+	return opts.Hooks || (!opts.NoHooks && hooksEnabled())
+}
+
+// hooksEnabled reports whether veve.toml turns project hooks on.
+func hooksEnabled() bool {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return false
+	}
+	cfg, err := config.LoadConfig(paths.ConfigFile)
+	if err != nil {
+		logger.Debug("Warning: Failed to read %s: %v", paths.ConfigFile, err)
+		return false
+	}
+	return cfg.Hooks
+}
+
+// newHookConversion describes a conversion to hooks, with absolute paths since
+// hooks run in the project root.
+func newHookConversion(inputFile, outputFile, themeName string, project *config.ProjectConfig) hooks.Conversion {
+	conversion := hooks.Conversion{Theme: themeName}
+	if project != nil {
+		conversion.Project = project.Root
+	}
+	if inputFile != "-" {
+		conversion.Input, _ = filepath.Abs(inputFile)
+	}
+	if outputFile != "-" {
+		conversion.Output, _ = filepath.Abs(outputFile)
+	}
+	return conversion
+}
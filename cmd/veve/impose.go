@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// imposes reports whether the output's pages are rearranged for printing.
+func (opts conversionOptions) imposes() bool {
+	return opts.Booklet || opts.NUp > 0
+}
+
+// imposeOutput rearranges the pages of the PDF at path for --booklet or
+// --n-up, then writes it to outputFile if that is stdout.
+func imposeOutput(path, outputFile string, loaded *resolvedTheme, opts conversionOptions) error {
+	var enginePath string
+	for _, name := range converter.ImposeEngines {
+		if p, err := engines.LookupEngine(name); err == nil {
+			enginePath = p
+			break
+		}
+	}
+	if enginePath == "" {
+		return internal.EngineError("convert", "impose pages",
+			"--booklet and --n-up need "+strings.Join(converter.ImposeEngines, ", or ")+", which is not installed",
+			"install a TeX distribution such as TeX Live or MiKTeX, which includes the pdfpages package", nil)
+	}
+	logger.Debug("Imposing pages with %s", enginePath)
+
+	if err := converter.Impose(path, converter.ImposeOptions{
+		Booklet:    opts.Booklet,
+		NUp:        opts.NUp,
+		Paper:      converter.ThemePaper(loaded.CSS),
+		EnginePath: enginePath,
+		Limits:     opts.limits(),
+	}); err != nil {
+		return err
+	}
+	if outputFile != "-" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read imposed PDF: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(os.Stdout, f); err != nil {
+		return fmt.Errorf("failed to write PDF to stdout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// joinOutputs joins the PDFs outputs, in order, into opts.JoinFile for
+// --merge, then checksums and signs it as it would a converted output, and
+// adds it to report.
+func joinOutputs(command string, outputs []string, opts conversionOptions, report *conversionReport) error {
+	var toolPath string
+	for _, name := range converter.JoinTools {
+		if p, err := engines.LookupEngine(name); err == nil {
+			toolPath = p
+			break
+		}
+	}
+	if toolPath == "" {
+		return internal.EngineError(command, "join PDFs",
+			"--merge needs "+strings.Join(converter.JoinTools, " or ")+", which is not installed",
+			"install qpdf (e.g. brew install qpdf, or apt install qpdf)", nil)
+	}
+	logger.Debug("Joining %d PDF(s) into %s with %s", len(outputs), opts.JoinFile, toolPath)
+
+	if err := converter.JoinPDFs(outputs, opts.JoinFile, toolPath); err != nil {
+		return internal.EngineError(command, "join PDFs", err.Error(), "check that each PDF opens, then try again", err)
+	}
+	result, err := writeSidecars("", opts.JoinFile, opts)
+	if err != nil {
+		return err
+	}
+	report.Merged = &result
+	if !quiet {
+		logger.Info("Joined %d PDF(s) into %s", len(outputs), opts.JoinFile)
+		logSidecars(result)
+	}
+	return nil
+}
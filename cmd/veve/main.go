@@ -1,17 +1,30 @@
 package main
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/madstone-tech/veve-cli/internal"
 	"github.com/madstone-tech/veve-cli/internal/config"
 	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/hooks"
 	"github.com/madstone-tech/veve-cli/internal/logging"
+	"github.com/madstone-tech/veve-cli/internal/metrics"
+	"github.com/madstone-tech/veve-cli/internal/redact"
 	"github.com/madstone-tech/veve-cli/internal/theme"
+	"github.com/madstone-tech/veve-cli/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -21,20 +34,37 @@ var (
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "veve [input]",
+	Use:   "veve [input]...",
 	Short: "veve - markdown to PDF converter with theme support",
 	Long: `veve is a fast, cross-platform CLI tool for converting markdown files to beautiful PDFs.
 It supports built-in themes, custom styling, and Pandoc-powered conversion.
 
 Usage:
   veve input.md [-o output.pdf] [--theme theme-name] [flags]
+  veve a.md b.md "docs/*.md" [-o out/] [flags]
   veve convert input.md [flags]
   veve theme list|add|remove [...]`,
-	Version: version,
-	Args:    cobra.MaximumNArgs(1),
+	Version:           version,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completeMarkdownFiles(-1),
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Shell completion doesn't need pandoc
+		if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+			return nil
+		}
+
+		// A docker engine runs pandoc in its container
+		if engine, err := cmd.Flags().GetString("engine"); err == nil {
+			if _, ok := engines.ParseDockerEngine(engine); ok {
+				return nil
+			}
+		}
+
 		// Check if pandoc is installed
 		if _, err := exec.LookPath("pandoc"); err != nil {
+			if engines.DockerAvailable() {
+				return internal.PandocNotFoundDockerAvailable(engines.DefaultDockerImage)
+			}
 			return internal.PandocNotFound()
 		}
 		return nil
@@ -53,48 +83,14 @@ Usage:
 			args = []string{"-"}
 		}
 
-		// If a markdown file is provided, treat it as convert command
-		inputFile := args[0]
-
 		// Get flags
-		outputFile, err := cmd.Flags().GetString("output")
-		if err != nil {
-			return err
-		}
-
-		theme, err := cmd.Flags().GetString("theme")
-		if err != nil {
-			return err
-		}
-
-		pdfEngine, err := cmd.Flags().GetString("engine")
-		if err != nil {
-			return err
-		}
-
-		enableRemoteImages, err := cmd.Flags().GetBool("enable-remote-images")
-		if err != nil {
-			return err
-		}
-
-		remoteImagesTimeout, err := cmd.Flags().GetInt("remote-images-timeout")
-		if err != nil {
-			return err
-		}
-
-		remoteImagesMaxRetries, err := cmd.Flags().GetInt("remote-images-max-retries")
-		if err != nil {
-			return err
-		}
-
-		remoteImagesTempDir, err := cmd.Flags().GetString("remote-images-temp-dir")
+		opts, err := readConversionOptions(cmd)
 		if err != nil {
 			return err
 		}
 
-		// Delegate to convert logic
-		return performConversion(inputFile, outputFile, theme, pdfEngine, quiet, verbose,
-			enableRemoteImages, remoteImagesTimeout, remoteImagesMaxRetries, remoteImagesTempDir)
+		// Markdown files are provided, treat it as convert command
+		return convertInputs(args, opts)
 	},
 }
 
@@ -106,20 +102,43 @@ var (
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "enable verbose output")
 	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "suppress non-error output")
-	rootCmd.Flags().StringP("output", "o", "", "output PDF file path (default: input filename with .pdf extension)")
-	rootCmd.Flags().StringP("theme", "t", "default", "theme to use for PDF styling")
-	rootCmd.Flags().StringP("engine", "e", "", "PDF rendering engine to use (xelatex, lualatex, weasyprint, prince); auto-detected if not specified")
-	rootCmd.Flags().BoolP("enable-remote-images", "r", true, "automatically download and embed remote images in PDF")
-	rootCmd.Flags().Int("remote-images-timeout", 10, "timeout in seconds for downloading each remote image")
-	rootCmd.Flags().Int("remote-images-max-retries", 3, "maximum number of retries for failed image downloads")
-	rootCmd.Flags().String("remote-images-temp-dir", "", "custom temporary directory for downloaded images (default: system temp dir)")
+	addConversionFlags(rootCmd)
+	addFallbackFlag(rootCmd)
+	addFormatsFlag(rootCmd)
+	addReportFlag(rootCmd)
+
+	// Unknown or malformed flags are usage errors, for every command
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return internal.WithKind(err, internal.KindUsage)
+	})
 }
 
 // performConversion is a shared function used by both root command and convert subcommand.
-func performConversion(inputFile, outputFile, themeName, pdfEngine string, quiet, verbose bool,
-	enableRemoteImages bool, remoteImagesTimeout, remoteImagesMaxRetries int, remoteImagesTempDir string) error {
+func performConversion(inputFile string, opts conversionOptions) (err error) {
+	themeName := opts.Theme
+
+	// Stdin named with --stdin-filename is treated as that file for messages,
+	// relative paths, and the output name; only its content comes from stdin
+	name := inputName(inputFile, opts)
+
 	// Log if verbose
-	logger.Debug("Converting %s to PDF (theme: %s, engine: %s)", inputFile, themeName, pdfEngine)
+	logger.Debug("Converting %s to PDF (theme: %s, engine: %s)", name, themeName, opts.PDFEngine)
+
+	// With --max-memory, the garbage collector works harder to stay within it
+	budget := converter.MemoryBudget(opts.MaxMemory)
+	if budget > 0 {
+		debug.SetMemoryLimit(opts.MaxMemory)
+	}
+
+	// Cover image and logo are passed to templates as variables, with their
+	// directories on the resource path
+	variables, resourcePath, err := imageVariables(opts)
+	if err != nil {
+		return err
+	}
+	if name != inputFile {
+		resourcePath = addResourceDir(resourcePath, filepath.Dir(name))
+	}
 
 	// Get XDG paths for theme discovery
 	paths, err := config.GetPaths()
@@ -133,178 +152,766 @@ func performConversion(inputFile, outputFile, themeName, pdfEngine string, quiet
 		// Continue anyway - directories may already exist or not be writable
 	}
 
-	// Create theme loader
-	loader := theme.NewLoader(paths.ThemesDir)
+	// Cache engine detection results between runs
+	engines.SetDetectionCache(filepath.Join(paths.CacheDir, "engines.json"), opts.RefreshEngines)
+
+	// Record the conversion in the local metrics log, if the user opted in
+	record := &metrics.Record{Time: time.Now(), Input: name, Theme: themeName, Engine: opts.PDFEngine}
+	if absInput, err := filepath.Abs(name); err == nil && name != "-" {
+		record.Input = absInput
+	}
+	if metricsEnabled(paths) {
+		defer func() { recordConversion(paths, record, err) }()
+	}
+
+	// Create theme loader; project themes are looked up from the input file's directory
+	projectDir := "."
+	if name != "-" {
+		projectDir = filepath.Dir(name)
+	}
+	loader, err := newThemeLoader(paths, projectDir)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the default output path from the original input, not the workspace copy
+	outputFile := converter.ResolveOutputPath(name, opts.OutputFile)
+	if opts.Redact != "" && redactionReportPath(outputFile, opts) == "" {
+		return fmt.Errorf("--redact with output to stdout needs --redact-report")
+	}
+
+	// Run the project's pre-convert hooks before the input is read, so they may update it
+	project, err := config.FindProject(projectDir)
+	if err != nil {
+		return err
+	}
+	hookConversion := newHookConversion(name, outputFile, themeName, project)
+	if err := runProjectHooks(hooks.PreConvert, project, hookConversion, opts); err != nil {
+		return err
+	}
+	resourcePath = addResourceDirs(resourcePath, opts, project)
 
 	// Discover available themes
 	if err := loader.DiscoverThemes(); err != nil {
 		logger.Debug("Error discovering themes: %v (continuing with defaults)", err)
 	}
 
-	// Check if theme is a file path (contains / or \ or .css)
-	isFilePath := strings.ContainsAny(themeName, "/\\") || strings.HasSuffix(themeName, ".css")
+	// Create a private workspace for intermediate files (theme CSS, processed markdown, images)
+	ws, err := workspace.New()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if opts.KeepTemp {
+			fmt.Fprintf(os.Stderr, "Kept temporary files in %s\n", ws.Dir)
+			return
+		}
+		reportCleanup("workspace", ws.Cleanup())
+	}()
+	logger.Debug("Using workspace: %s", ws.Dir)
 
-	// Load theme CSS
+	// Load theme CSS and engine requirements
+	loaded, err := loadTheme(loader, themeName)
+	if err != nil {
+		return err
+	}
+	opts.applyThemeDefaults(loaded)
 	var themeFile string
-	if isFilePath {
-		// Handle file path theme
-		css, err := loader.LoadThemeFromPath(themeName)
+
+	// Write theme CSS into the workspace for Pandoc, with the scoped chapter
+	// themes of a book after it
+	if css := processThemeCSS(paths, themeName, loaded, opts) + opts.ChapterCSS; css != "" {
+		if themeFile, err = ws.WriteFile("theme-*.css", []byte(css)); err != nil {
+			logger.WarnIn(logging.CategoryTheme, "Failed to write theme CSS: %v", err)
+		}
+	}
+
+	// Preprocess markdown (remote images, figures, page breaks, tables, task lists) into a workspace copy
+	content, err := readConversionInput(inputFile, name, opts)
+	if err != nil {
+		return err
+	}
+	record.InputBytes = int64(len(content))
+	processedContent := string(content)
+
+	// Mask the --redact patterns' matches in the input as read, so the
+	// report's lines and columns refer to the source; the report is written
+	// once the conversion succeeds
+	if opts.Redact != "" {
+		var report *redact.Report
+		if processedContent, report, err = redactInput(processedContent, opts); err != nil {
+			return err
+		}
+		defer func() {
+			if err == nil {
+				err = writeRedactionReport(report, name, outputFile, opts)
+			}
+		}()
+	}
+
+	// Drop private notes before anything else sees the content
+	processedContent = stripPrivateComments(project, processedContent)
+
+	// Keep only the content for --audience
+	if processedContent, err = filterAudience(project, processedContent, opts); err != nil {
+		return err
+	}
+
+	// Mark what changed since --diff-against with change bars
+	var hasChanges bool
+	if opts.DiffAgainst != "" {
+		if processedContent, hasChanges, err = markChanges(project, name, processedContent, opts); err != nil {
+			return err
+		}
+	}
+
+	// Run the project's content transformers
+	processedContent, err = applyTransformers(project, hookConversion, processedContent, opts)
+	if err != nil {
+		return err
+	}
+
+	// Convert Obsidian wiki links and embeds to standard links and images
+	processedContent = resolveWikiLinks(project, projectDir, name, processedContent, opts)
+
+	// Expand acronyms on first use and append the project's glossary
+	if processedContent, err = applyGlossary(project, processedContent); err != nil {
+		return err
+	}
+
+	// Large documents are prepared a section at a time, since each stage
+	// parses its input into a syntax tree many times the input's size
+	sections := converter.SplitSections(processedContent, budget.SectionSize())
+	processedContent = ""
+	var imageProcessor *converter.ImageProcessor
+	var tempDir string
+	if opts.EnableRemoteImages {
+		imageProcessor, tempDir = newImageProcessor(opts, ws)
+		if project != nil {
+			imageProcessor.WithImageExtensions(project.ImageExtensionMap())
+		}
+		defer func() {
+			switch {
+			case !opts.KeepTemp:
+				reportCleanup("downloaded images", imageProcessor.Cleanup())
+			case opts.RemoteImagesTempDir != "":
+				fmt.Fprintf(os.Stderr, "Kept downloaded images in %s\n", tempDir)
+			}
+		}()
+	}
+	// Refuse a page with thousands of images before downloading any
+	if limit := opts.RemoteImagesMaxCount; imageProcessor != nil && limit > 0 {
+		if count := countRemoteImages(imageProcessor, sections); count > limit {
+			return internal.TooManyRemoteImages("convert", name, count, limit)
+		}
+	}
+	counter := imageCounter(imageProcessor, opts)
+	var hasTables, hasTasks bool
+	for i, section := range sections {
+		images, remoteImages := counter.CountImages(section)
+		record.Images += images
+		record.RemoteImages += remoteImages
+
+		// Download remote images if enabled
+		if imageProcessor != nil {
+			section = downloadRemoteImages(imageProcessor, section)
+		}
+
+		// Slides are laid out by beamer; the page-oriented stages don't apply
+		if opts.Format == formatSlides {
+			sections[i] = section
+			continue
+		}
+
+		// Turn standalone images into captioned figures
+		if opts.Figures {
+			section = converter.PrepareFigures(section)
+		}
+
+		// Translate page-break markers for the selected engine
+		section = converter.PreparePageBreaks(section)
+
+		// Shrink or rotate tables too wide for the page
+		section, tables := converter.PrepareTables(section)
+
+		// Draw task list checkboxes
+		section, tasks := converter.PrepareTasks(section)
+
+		sections[i] = section
+		hasTables = hasTables || tables
+		hasTasks = hasTasks || tasks
+	}
+	if imageProcessor != nil {
+		reportRemoteImages(imageProcessor, tempDir)
+		_, record.FailedImages, _ = imageProcessor.GetDownloadStats()
+		record.CachedImages = imageProcessor.CachedImages()
+	}
+	processedContent = converter.JoinSections(sections)
+
+	// Write processed content into the workspace
+	processedInputFile, err := ws.WriteFile("processed-*.md", []byte(processedContent))
+	if err != nil {
+		return fmt.Errorf("failed to write processed markdown: %w", err)
+	}
+
+	// Other formats are written from the same prepared markdown; with no PDF
+	// among them, the first is reported as the output
+	outputs := conversionOutputs(outputFile, opts)
+	outputFile = outputs[0].path
+	for _, output := range outputs {
+		if output.format == formatPDF {
+			continue
+		}
+		if err := writeOutput(output, processedInputFile, themeFile, resourcePath, opts); err != nil {
+			return err
+		}
+		if output.path == outputFile {
+			continue
+		}
+		result, err := writeSidecars(name, output.path, opts)
 		if err != nil {
-			return fmt.Errorf("failed to load theme from path '%s': %w", themeName, err)
+			return err
+		}
+		if !quiet {
+			logger.Info("Wrote %s", output.path)
+			logSidecars(result)
+		}
+	}
+	if outputs[0].format != formatPDF {
+		record.Engine = outputs[0].format
+		hookConversion = newHookConversion(name, outputFile, themeName, project)
+		return finishConversion(name, outputFile, record, hookConversion, project, opts)
+	}
+
+	// The PDF is written beside the output and renamed over it when done, so
+	// a failed run leaves the previous PDF alone. Imposed pages are rearranged
+	// after converting, so output to stdout goes through a workspace file.
+	pdfFile := outputFile
+	var staged *converter.StagedOutput
+	if outputFile != "-" {
+		if staged, err = converter.StageOutput(outputFile); err != nil {
+			return err
 		}
+		defer staged.Discard()
+		pdfFile = staged.Path
+	} else if opts.imposes() {
+		pdfFile = filepath.Join(ws.Dir, "output.pdf")
+	}
 
-		if css != "" {
-			// Write theme CSS to temporary file for Pandoc
-			// Extract just the filename without path for temp file naming
-			baseName := filepath.Base(themeName)
-			if !strings.HasSuffix(baseName, ".css") {
-				baseName = baseName + ".css"
+	if opts.Format == formatSlides {
+		if record.Engine, err = convertSlides(processedInputFile, pdfFile, loaded, variables, resourcePath, opts); err != nil {
+			return locateError(err, inputFile, name, processedInputFile, processedContent, opts)
+		}
+		if opts.imposes() {
+			if err := imposeOutput(pdfFile, outputFile, loaded, opts); err != nil {
+				return err
 			}
-			tempThemeFile := filepath.Join(os.TempDir(), fmt.Sprintf("veve-theme-%s", baseName))
-			if err := os.WriteFile(tempThemeFile, []byte(css), 0o644); err != nil {
-				logger.Warn("Failed to write theme CSS: %v", err)
-			} else {
-				themeFile = tempThemeFile
-				defer os.Remove(tempThemeFile) // Clean up temp file after conversion
+		}
+		if staged != nil {
+			if err := staged.Commit(); err != nil {
+				return err
 			}
 		}
+		return finishConversion(name, outputFile, record, hookConversion, project, opts)
+	}
+
+	// Perform conversion with unicode support for intelligent engine selection
+	convertOpts := converter.UnicodeConversionOptions{
+		InputFile:           processedInputFile,
+		Content:             processedContent,
+		OutputFile:          pdfFile,
+		PDFEngine:           opts.PDFEngine,
+		EngineArgs:          opts.EngineArgs,
+		AutoInstallPackages: opts.AutoInstallPackages,
+		RetryLaTeX:          opts.RetryLaTeX,
+		Theme:               themeFile,
+		ThemeName:           themeName,
+		ThemeEngines:        loaded.Engines,
+		LaTeXTemplate:       loaded.LaTeXTemplate,
+		Standalone:          true,
+		Sandbox:             opts.Sandbox,
+		Limits:              opts.limits(),
+		MemoryBudget:        budget,
+		ValidateUnicode:     true,
+		AllowFallback:       true,
+		Verbose:             verbose,
+		Figures:             opts.Figures,
+		Tables:              hasTables,
+		Tasks:               hasTasks,
+		Changes:             hasChanges,
+		Endnotes:            opts.Endnotes,
+		NumberSections:      opts.NumberSections,
+		NumberDepth:         opts.NumberDepth,
+		NoSmart:             opts.NoSmart,
+		Pagination: converter.Pagination{
+			Widows:       opts.Widows,
+			Orphans:      opts.Orphans,
+			KeepHeadings: opts.KeepHeadings,
+		},
+		Variables:    variables,
+		ResourcePath: resourcePath,
+		Warn:         func(category logging.Category, msg string) { logger.WarnIn(category, "%s", msg) },
+		OnEngine:     func(name string) { record.Engine = name },
+	}
+
+	if err := converter.ConvertWithUnicodeSupport(convertOpts); err != nil {
+		// Without a PDF engine, themed HTML is still something to read
+		htmlFile := htmlFallbackFile(opts, outputFile, err)
+		if htmlFile == "" {
+			return locateError(err, inputFile, name, processedInputFile, processedContent, opts)
+		}
+		if err := convertHTML(processedInputFile, htmlFile, themeFile, resourcePath, opts); err != nil {
+			return err
+		}
+		logger.WarnIn(logging.CategoryEngine, "No PDF engine is installed; wrote HTML instead (install xelatex or weasyprint for PDF output)")
+		outputFile, record.Engine = htmlFile, "html"
+		hookConversion = newHookConversion(name, outputFile, themeName, project)
 	} else {
-		// Handle named theme
-		selectedTheme, err := loader.LoadTheme(themeName)
-		if err != nil {
-			// Build helpful error message with available themes
-			availableThemes := loader.ListThemes()
-			themeNames := make([]string, len(availableThemes))
-			for i, t := range availableThemes {
-				themeNames[i] = t.Name
+		if opts.imposes() {
+			if err := imposeOutput(pdfFile, outputFile, loaded, opts); err != nil {
+				return err
 			}
-			return fmt.Errorf("invalid theme '%s': available themes are: %v", themeName, themeNames)
-		}
-
-		// Load theme CSS
-		if selectedTheme.Name != "default" || selectedTheme.IsBuiltIn {
-			css, err := loader.LoadThemeCSS(themeName)
-			if err != nil {
-				// If theme not found in loader's CSS, skip it
-				logger.Debug("Theme CSS not found for %s: %v", themeName, err)
-			} else if css != "" {
-				// Write theme CSS to temporary file for Pandoc
-				tempThemeFile := filepath.Join(os.TempDir(), fmt.Sprintf("veve-theme-%s.css", themeName))
-				if err := os.WriteFile(tempThemeFile, []byte(css), 0o644); err != nil {
-					logger.Warn("Failed to write theme CSS: %v", err)
-				} else {
-					themeFile = tempThemeFile
-					defer os.Remove(tempThemeFile) // Clean up temp file after conversion
-				}
+		}
+		if staged != nil {
+			if err := staged.Commit(); err != nil {
+				return err
 			}
 		}
 	}
+	return finishConversion(name, outputFile, record, hookConversion, project, opts)
+}
 
-	// Process remote images if enabled
-	var processedInputFile string
-	var imageProcessor *converter.ImageProcessor
-	if enableRemoteImages {
-		// Determine temp directory: use custom if provided, otherwise system temp
-		tempDir := remoteImagesTempDir
-		if tempDir == "" {
-			tempDir = filepath.Join(os.TempDir(), fmt.Sprintf("veve-images-%d", os.Getpid()))
+// finishConversion records the output's size, runs the project's
+// post-convert hooks, writes the checksum and signature asked for, and
+// reports the conversion.
+func finishConversion(name, outputFile string, record *metrics.Record, hookConversion hooks.Conversion, project *config.ProjectConfig, opts conversionOptions) error {
+	if info, err := os.Stat(outputFile); err == nil && outputFile != "-" {
+		record.OutputBytes = info.Size()
+	}
+
+	hookConversion.Engine = record.Engine
+	if err := runProjectHooks(hooks.PostConvert, project, hookConversion, opts); err != nil {
+		return err
+	}
+
+	if opts.reportName != "" {
+		name = opts.reportName
+	}
+
+	// The checksum and signature are of the output as published, after hooks
+	// such as metadata stamping have changed it
+	result := conversionResult{Input: name, Output: outputFile}
+	if outputFile != "-" {
+		var err error
+		if result, err = writeSidecars(name, outputFile, opts); err != nil {
+			return err
+		}
+		record.Checksum, record.Signature = result.Checksum, result.Signature
+	}
+
+	// Log success, with what was written beside the output
+	if !quiet {
+		logger.Info("Successfully converted %s to %s", name, outputFile)
+		logSidecars(result)
+	}
+
+	return nil
+}
+
+// writeSidecars writes the checksum and detached signature asked for beside
+// output, converted from input, and adds the output to the --json report.
+func writeSidecars(input, output string, opts conversionOptions) (conversionResult, error) {
+	result := conversionResult{Input: cmp.Or(opts.reportName, input), Output: output}
+	if opts.Checksum != converter.ChecksumOff {
+		sum, err := converter.WriteChecksum(output, opts.Checksum)
+		if err != nil {
+			return result, err
+		}
+		result.Checksum, result.ChecksumFile = opts.Checksum+":"+sum, output+"."+opts.Checksum
+	}
+	// The signature is detached, so the output itself is left as it is
+	if opts.Sign != converter.SignerOff {
+		signature, err := converter.Sign(output, opts.Sign, opts.SignKey)
+		if err != nil {
+			return result, err
+		}
+		result.Signature, result.Signer = signature, opts.Sign
+	}
+	opts.results.add(result)
+	return result, nil
+}
+
+// logSidecars prints the checksum and signature written beside an output.
+func logSidecars(result conversionResult) {
+	if result.Checksum != "" {
+		logger.Info("  Checksum: %s (%s)", result.Checksum, result.ChecksumFile)
+	}
+	if result.Signature != "" {
+		logger.Info("  Signed with %s: %s", result.Signer, result.Signature)
+	}
+}
+
+// processThemeCSS returns a theme's CSS as written for Pandoc: @import rules
+// inlined, minified and, unless the theme is trusted, sanitized. Relative font
+// and asset URLs are resolved against the theme's own directory, since the
+// copy lives elsewhere.
+func processThemeCSS(paths config.Paths, themeName string, loaded *resolvedTheme, opts conversionOptions) string {
+	if loaded.CSS == "" {
+		return ""
+	}
+
+	// Inline @import rules so every engine sees the full stylesheet. Only a
+	// trusted theme's HTTPS imports are downloaded: otherwise the theme is
+	// sanitized first, and the local stylesheets it imports are sanitized
+	// with it once inlined, so an external import is removed, never fetched
+	css := loaded.CSS
+	resolver := theme.NewImportResolver(filepath.Join(paths.CacheDir, "imports"))
+	var removed []string
+	if !opts.NoSanitize {
+		css, removed = theme.ProcessCSS(css, theme.ProcessOptions{Sanitize: true})
+		resolver = resolver.LocalOnly()
+	}
+	resolved, problems := resolver.Resolve(css, loaded.Dir)
+	for _, problem := range problems {
+		logger.WarnIn(logging.CategoryTheme, "Theme '%s': %s", themeName, problem)
+	}
+
+	processed, more := theme.ProcessCSS(resolved, theme.ProcessOptions{
+		Sanitize: !opts.NoSanitize,
+		BaseDir:  loaded.Dir,
+	})
+	for _, construct := range append(removed, more...) {
+		logger.WarnIn(logging.CategoryTheme, "Removed %s from theme '%s' (use --no-sanitize for trusted themes)", construct, themeName)
+	}
+	return processed
+}
+
+// resolvedTheme is a theme found by loadTheme.
+type resolvedTheme struct {
+	CSS           string   // Theme CSS (empty if the theme has none)
+	Dir           string   // Directory relative url() references resolve against
+	LaTeXTemplate string   // LaTeX template shipped with the theme (optional)
+	Engines       []string // Engines the theme supports; empty means any
+	BeamerTheme   string   // Beamer theme for --format slides (optional)
+	NoSmart       bool     // Smart punctuation is off unless --smart is given
+}
+
+// loadTheme resolves themeName, a theme name or a path to a CSS file.
+func loadTheme(loader *theme.Loader, themeName string) (*resolvedTheme, error) {
+	resolved := &resolvedTheme{}
+
+	// Check if theme is a file path (contains / or \ or .css) rather than a namespaced theme
+	if loader.IsThemePath(themeName) {
+		css, err := loader.LoadThemeFromPath(themeName)
+		if err != nil {
+			return nil, internal.WithKind(fmt.Errorf("failed to load theme from path '%s': %w", themeName, err), internal.KindTheme)
+		}
+		resolved.CSS = css
+		if absPath, err := theme.ResolveThemePath(themeName); err == nil {
+			resolved.Dir = filepath.Dir(absPath)
 		}
 
-		// Create temp directory if it doesn't exist
-		if err := os.MkdirAll(tempDir, 0755); err != nil {
-			logger.Debug("Warning: Failed to create temp directory %s: %v", tempDir, err)
-			tempDir = filepath.Join(os.TempDir(), fmt.Sprintf("veve-images-%d", os.Getpid()))
-			os.MkdirAll(tempDir, 0755) // Best effort
+		if meta, err := loader.LoadMetadataFromPath(themeName); err == nil && meta != nil {
+			resolved.Engines = meta.Engines
+			resolved.BeamerTheme = meta.BeamerTheme
+			resolved.NoSmart = meta.NoSmart
 		}
+		return resolved, nil
+	}
 
-		if verbose {
-			logger.Debug("Using temp directory for images: %s", tempDir)
+	selectedTheme, err := loader.LoadTheme(themeName)
+	if err != nil {
+		// Build helpful error message with available themes
+		availableThemes := loader.ListThemes()
+		themeNames := make([]string, len(availableThemes))
+		for i, t := range availableThemes {
+			themeNames[i] = t.Name
 		}
+		return nil, internal.WithKind(fmt.Errorf("invalid theme '%s': available themes are: %v", themeName, themeNames), internal.KindTheme)
+	}
+	resolved.Engines = selectedTheme.Engines
+	resolved.BeamerTheme = selectedTheme.BeamerTheme
+	resolved.NoSmart = selectedTheme.NoSmart
+	resolved.LaTeXTemplate = selectedTheme.LaTeXTemplate
+	if selectedTheme.FilePath != "" {
+		resolved.Dir = filepath.Dir(selectedTheme.FilePath)
+	}
 
-		imageProcessor = converter.NewImageProcessor(tempDir).
-			WithTimeoutSeconds(remoteImagesTimeout).
-			WithMaxRetries(remoteImagesMaxRetries)
-		defer imageProcessor.Cleanup()
+	css, err := loader.LoadThemeCSS(themeName)
+	if err != nil {
+		// If theme not found in loader's CSS, skip it
+		logger.Debug("Theme CSS not found for %s: %v", themeName, err)
+	} else {
+		resolved.CSS = css
+	}
+	return resolved, nil
+}
+
+// errInputTooLarge is returned by readInput for input over its limit.
+var errInputTooLarge = errors.New("input too large")
 
-		// Read markdown content
-		content, err := os.ReadFile(inputFile)
+// readInput reads the markdown input from a file, or from stdin if inputFile
+// is "-", as UTF-8 with LF line endings. Input over limit bytes (if limit is
+// not 0) fails with errInputTooLarge.
+func readInput(inputFile string, limit int64) ([]byte, error) {
+	var r io.Reader = os.Stdin
+	if inputFile != "-" {
+		f, err := os.Open(inputFile)
 		if err != nil {
-			return fmt.Errorf("failed to read input file: %w", err)
+			return nil, err
+		}
+		defer f.Close()
+		if info, err := f.Stat(); err == nil && limit > 0 && info.Mode().IsRegular() && info.Size() > limit {
+			return nil, errInputTooLarge
+		}
+		r = f
+	}
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(content)) > limit {
+		return nil, errInputTooLarge
+	}
+	return converter.NormalizeText(content), nil
+}
+
+// readConversionInput reads the input to convert, named name, within the
+// --max-input-size limit, converting it to UTF-8 as decodeInput does.
+func readConversionInput(inputFile, name string, opts conversionOptions) ([]byte, error) {
+	content, err := readInput(inputFile, opts.MaxInputSize)
+	if errors.Is(err, errInputTooLarge) {
+		if name == "-" {
+			name = "stdin"
+		}
+		return nil, internal.InputTooLarge("convert", name, converter.FormatMemorySize(opts.MaxInputSize))
+	}
+	if err != nil {
+		return nil, internal.WithKind(fmt.Errorf("failed to read input file: %w", err), internal.KindInput)
+	}
+	return decodeInput(name, content, opts.Strict)
+}
+
+// locateError points a conversion error at the line of the markdown it
+// comes from, rather than at processedInputFile, the prepared copy in the
+// workspace, and makes it a pandoc error unless it already has a kind. The
+// input is read again for this, unless it was stdin, whose prepared copy
+// stands in for it.
+func locateError(err error, inputFile, name, processedInputFile, processedContent string, opts conversionOptions) error {
+	if internal.IsVeveError(err) {
+		return err
+	}
+	source := processedContent
+	if inputFile != "-" {
+		if content, readErr := readConversionInput(inputFile, name, opts); readErr == nil {
+			source = string(content)
 		}
+	}
+	if name == "-" {
+		name = "stdin"
+	}
+	return internal.WithKind(converter.LocateError(err, name, processedInputFile, source, processedContent), internal.KindPandoc)
+}
 
-		// Process markdown to download remote images
-		processedContent, err := imageProcessor.ProcessMarkdown(string(content))
+// decodeInput converts content named name to UTF-8 if it is in a legacy
+// encoding, with a warning, or fails if strict is set.
+func decodeInput(name string, content []byte, strict bool) ([]byte, error) {
+	encoding := converter.DetectEncoding(content)
+	if encoding == "" {
+		return content, nil
+	}
+	if name == "-" {
+		name = "stdin"
+	}
+	if strict {
+		return nil, internal.InputNotUTF8("convert", name, encoding)
+	}
+	decoded, err := converter.Transcode(content, encoding)
+	if err != nil {
+		return nil, err
+	}
+	logger.WarnIn(logging.CategoryInput, "%s is not UTF-8; read it as %s (save it as UTF-8, or pass --strict to fail instead)", name, encoding)
+	return decoded, nil
+}
+
+// imageVariables validates the --cover-image and --logo files and returns the
+// template variables referencing them and the resource path to find them on.
+func imageVariables(opts conversionOptions) (map[string]string, []string, error) {
+	images := []struct{ flag, variable, path string }{
+		{"--cover-image", "cover-image", opts.CoverImage},
+		{"--logo", "logo", opts.Logo},
+	}
+
+	variables := map[string]string{}
+	var resourcePath []string
+	for _, image := range images {
+		if image.path == "" {
+			continue
+		}
+		absPath, err := converter.ValidateLocalImage(image.path)
 		if err != nil {
-			logger.Debug("Warning: Image processing failed: %v (continuing with original content)", err)
-			processedInputFile = inputFile
+			return nil, nil, internal.InputError("convert", "use "+image.flag, err.Error(), "pass a PNG, JPEG, GIF, SVG, or PDF file", err)
+		}
+		variables[image.variable] = absPath
+
+		resourcePath = addResourceDir(resourcePath, filepath.Dir(absPath))
+	}
+	return variables, resourcePath, nil
+}
+
+// addResourceDirs adds the --resource-dir directories, then the project's
+// resource_dirs, to pandoc's resource path.
+func addResourceDirs(resourcePath []string, opts conversionOptions, project *config.ProjectConfig) []string {
+	dirs := opts.ResourceDirs
+	if project != nil {
+		dirs = append(slices.Clip(dirs), project.ResourcePaths()...)
+	}
+	for _, dir := range dirs {
+		resourcePath = addResourceDir(resourcePath, dir)
+	}
+	return resourcePath
+}
+
+// addResourceDir adds dir to pandoc's resource path, keeping pandoc's
+// default (the working directory) first.
+func addResourceDir(resourcePath []string, dir string) []string {
+	if len(resourcePath) == 0 {
+		resourcePath = append(resourcePath, ".")
+	}
+	if !slices.Contains(resourcePath, dir) {
+		resourcePath = append(resourcePath, dir)
+	}
+	return resourcePath
+}
+
+// inputName returns the name of the input: the --stdin-filename hint when
+// inputFile is "-" and one is given, otherwise inputFile itself.
+func inputName(inputFile string, opts conversionOptions) string {
+	if inputFile == "-" && opts.StdinFilename != "" {
+		return opts.StdinFilename
+	}
+	return inputFile
+}
+
+// newImageProcessor creates an image processor configured from the remote image flags.
+// Returns the processor and the directory downloaded images are stored in, which is
+// the workspace's images directory unless --remote-images-temp-dir is set.
+func newImageProcessor(opts conversionOptions, ws *workspace.Workspace) (*converter.ImageProcessor, string) {
+	// Images go in a directory of their own inside --remote-images-temp-dir,
+	// since cleanup removes it and parallel runs may share the flag
+	tempDir := ""
+	if opts.RemoteImagesTempDir != "" {
+		if err := os.MkdirAll(opts.RemoteImagesTempDir, 0o700); err != nil {
+			logger.Debug("Warning: Failed to create temp directory %s: %v", opts.RemoteImagesTempDir, err)
+		} else if tempDir, err = os.MkdirTemp(opts.RemoteImagesTempDir, "veve-images-*"); err != nil {
+			logger.Debug("Warning: Failed to create temp directory in %s: %v", opts.RemoteImagesTempDir, err)
+			tempDir = ""
+		}
+	}
+	if tempDir == "" {
+		var err error
+		if tempDir, err = ws.Subdir("images"); err != nil {
+			logger.Debug("Warning: %v", err)
+		}
+	}
+
+	if verbose {
+		logger.Debug("Using temp directory for images: %s", tempDir)
+	}
+
+	imageProcessor := converter.NewImageProcessor(tempDir).
+		WithTimeoutSeconds(opts.RemoteImagesTimeout).
+		WithMaxRetries(opts.RemoteImagesMaxRetries).
+		WithStrictContentType(opts.RemoteImagesStrictType).
+		WithMemoryBudget(converter.MemoryBudget(opts.MaxMemory)).
+		WithSchemes(opts.RemoteImagesSchemes...)
+	if opts.ImageCache {
+		if paths, err := config.GetPaths(); err != nil {
+			logger.Debug("Warning: image cache disabled: %v", err)
 		} else {
-			// Write processed content to temporary file
-			tempProcessedFile := filepath.Join(os.TempDir(), fmt.Sprintf("veve-processed-%d.md", os.Getpid()))
-			if err := os.WriteFile(tempProcessedFile, []byte(processedContent), 0o644); err != nil {
-				logger.Debug("Warning: Failed to write processed markdown: %v (using original)", err)
-				processedInputFile = inputFile
-			} else {
-				processedInputFile = tempProcessedFile
-				defer os.Remove(tempProcessedFile) // Clean up temp file after conversion
-			}
+			imageProcessor.WithImageCache(converter.NewImageCache(filepath.Join(paths.CacheDir, converter.ImageCacheDirName)))
+		}
+	}
 
-			// Log image download summary with detailed error reporting
-			successful, failed, total := imageProcessor.GetDownloadStats()
-			if !quiet {
-				if total > 0 {
-					if failed == 0 {
-						// All succeeded
-						logger.Info("Successfully downloaded %d image(s)", successful)
-					} else if successful == 0 {
-						// All failed
-						logger.Warn("Failed to download %d image(s)", failed)
-					} else {
-						// Partial success
-						logger.Info("Downloaded %d of %d image(s)", successful, total)
-					}
-				}
-
-				// Log detailed error information
-				if failed > 0 {
-					errorSummary := imageProcessor.GetErrorSummary()
-					logger.Warn(errorSummary)
-				}
-			}
+	return imageProcessor, tempDir
+}
 
-			// Log disk space information if verbose
-			if verbose {
-				usedBytes := calculateDirectorySize(tempDir)
-				limitBytes := 500 * 1024 * 1024
-				logger.Debug("Disk space used for images: %d bytes (limit: %d bytes)", usedBytes, limitBytes)
-			}
+// downloadRemoteImages downloads remote images referenced in content and returns the
+// markdown rewritten to use local copies. On failure the original content is returned.
+func downloadRemoteImages(imageProcessor *converter.ImageProcessor, content string) string {
+	// Process markdown to download remote images
+	processedContent, err := imageProcessor.ProcessMarkdown(content)
+	if err != nil {
+		logger.WarnIn(logging.CategoryImages, "Image processing failed: %v (continuing with original content)", err)
+		return content
+	}
+	return processedContent
+}
+
+// imageCounter returns the processor that tells remote images from local
+// ones: imageProcessor, or with remote images off, one that would fetch the
+// same schemes.
+func imageCounter(imageProcessor *converter.ImageProcessor, opts conversionOptions) *converter.ImageProcessor {
+	if imageProcessor != nil {
+		return imageProcessor
+	}
+	return converter.NewImageProcessor("").WithSchemes(opts.RemoteImagesSchemes...)
+}
+
+// countRemoteImages returns the number of distinct remote images sections
+// reference.
+func countRemoteImages(imageProcessor *converter.ImageProcessor, sections []string) int {
+	urls := map[string]bool{}
+	for _, section := range sections {
+		for _, url := range imageProcessor.DetectRemoteImages(section) {
+			urls[url] = true
 		}
-	} else {
-		processedInputFile = inputFile
 	}
+	return len(urls)
+}
 
-	// Perform conversion with unicode support for intelligent engine selection
-	opts := converter.UnicodeConversionOptions{
-		InputFile:       processedInputFile,
-		OutputFile:      outputFile,
-		PDFEngine:       pdfEngine,
-		Theme:           themeFile,
-		Standalone:      true,
-		ValidateUnicode: true,
-		AllowFallback:   true,
-		Verbose:         verbose,
-	}
-
-	if err := converter.ConvertWithUnicodeSupport(opts); err != nil {
-		return err
+// reportRemoteImages logs the outcome of downloadRemoteImages.
+func reportRemoteImages(imageProcessor *converter.ImageProcessor, tempDir string) {
+	// Log image download summary with detailed error reporting
+	successful, failed, total := imageProcessor.GetDownloadStats()
+	if !quiet && total > 0 {
+		if failed == 0 {
+			// All succeeded
+			logger.Info("Successfully downloaded %d image(s)", successful)
+		} else {
+			logger.Info("Downloaded %d of %d image(s)", successful, total)
+		}
+	}
+	if cached := imageProcessor.CachedImages(); cached > 0 {
+		logger.Debug("Used %d unchanged image(s) from the image cache", cached)
 	}
 
-	// Log success
-	resolvedOutput := converter.ResolveOutputPath(inputFile, outputFile)
-	if !quiet {
-		logger.Info("Successfully converted %s to %s", inputFile, resolvedOutput)
+	// Each failed image is a warning in the summary
+	downloadErrors := imageProcessor.GetDownloadErrors()
+	for _, url := range slices.Sorted(maps.Keys(downloadErrors)) {
+		reason := strings.TrimPrefix(downloadErrors[url], "failed to download "+url+": ")
+		logger.WarnIn(logging.CategoryImages, "Failed to download %s: %s", url, reason)
 	}
 
-	return nil
+	// Log disk space information if verbose
+	if verbose {
+		usedBytes := calculateDirectorySize(tempDir)
+		limitBytes := 500 * 1024 * 1024
+		logger.Debug("Disk space used for images: %d bytes (limit: %d bytes)", usedBytes, limitBytes)
+	}
+}
+
+// reportCleanup reports what removing the run's temporary files (what)
+// left behind, as cleanup warnings, and with --verbose, what it removed.
+func reportCleanup(what string, report workspace.CleanupReport) {
+	logger.Debug("Removed %d temporary path(s) of the %s", len(report.Removed), what)
+	for _, failure := range report.Failed {
+		logger.WarnIn(logging.CategoryCleanup, "Failed to remove %s: %v", failure.Path, failure.Err)
+	}
 }
 
 // calculateDirectorySize calculates the total size of all files in a directory.
@@ -330,22 +937,61 @@ func main() {
 	logger = logging.NewLogger(quiet, verbose)
 	logging.SetGlobalLogger(logger)
 
-	// Execute the root command
-	if err := rootCmd.Execute(); err != nil {
-		// Check if it's a VeveError for proper formatting
-		if veveErr, ok := err.(*internal.VeveError); ok {
-			fmt.Fprintf(os.Stderr, "%s\n", veveErr.Error())
-			os.Exit(internal.ExitError)
+	// Execute the root command, with user aliases expanded
+	var cmd *cobra.Command
+	args, err := expandAlias(os.Args[1:])
+	if err == nil {
+		if examplesCmd, ok := wantsExamples(args); ok {
+			cmd, err = examplesCmd, writeExamples(os.Stdout, examplesCmd)
+		} else {
+			rootCmd.SetArgs(args)
+			cmd, err = rootCmd.ExecuteC()
 		}
+	}
+	stopProfiling()
+
+	// Commands printing JSON print their warnings and errors as JSON too;
+	// the others print the warnings as a summary after their output
+	if wantsJSON(cmd) {
+		report := struct {
+			Error    *internal.ErrorReport `json:"error,omitempty"`
+			Warnings []logging.Warning     `json:"warnings,omitempty"`
+		}{Warnings: logger.Warnings()}
+		if err != nil {
+			errReport := internal.Report(err)
+			report.Error = &errReport
+		}
+		if report.Error != nil || len(report.Warnings) > 0 {
+			encoder := json.NewEncoder(os.Stderr)
+			encoder.SetIndent("", "  ")
+			_ = encoder.Encode(report)
+		}
+	} else if !quiet {
+		logger.WriteSummary(os.Stderr)
+	}
 
-		// For Cobra errors and others
-		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
-
-		// Determine exit code based on error type
-		if _, ok := err.(interface{ ExitCode() int }); ok {
-			os.Exit(internal.ExitError)
+	if err != nil {
+		switch veveErr, ok := err.(*internal.VeveError); {
+		case wantsJSON(cmd):
+			// Already reported above
+		case ok:
+			// Check if it's a VeveError for proper formatting
+			fmt.Fprintf(os.Stderr, "%s\n", veveErr.Error())
+		default:
+			// For Cobra errors and others
+			fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
 		}
 
-		os.Exit(internal.ExitError)
+		// The exit code tells scripts what kind of error it was
+		os.Exit(internal.KindOf(err).ExitCode())
+	}
+}
+
+// wantsJSON reports whether cmd was run with --json.
+func wantsJSON(cmd *cobra.Command) bool {
+	if cmd == nil || cmd.Flags().Lookup("json") == nil {
+		return false
 	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	return err == nil && asJSON
 }
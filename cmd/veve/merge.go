@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/merge"
+)
+
+// convertDocument converts inputFile, once per record of the --data file if
+// one is given.
+func convertDocument(inputFile string, opts conversionOptions) error {
+	if opts.Data == "" {
+		return performConversion(inputFile, opts)
+	}
+	return performMerge(inputFile, opts)
+}
+
+// performMerge renders inputFile as a template for each record of the data
+// file and converts the results: to one PDF per record or, with --data-merge, to
+// a single PDF with each record starting on a new page.
+//
+// Per-record output paths come from --output, which may be a template
+// ("invoices/{{.number}}.pdf"); otherwise the record's number is added to the
+// file name (letter-1.pdf, letter-2.pdf, ..., zero-padded to sort in order).
+func performMerge(inputFile string, opts conversionOptions) error {
+	if opts.OutputFile == "-" && !opts.DataMerge {
+		return fmt.Errorf("writing to stdout needs --data-merge, since --data writes one PDF per record")
+	}
+	records, err := merge.LoadRecords(opts.Data)
+	if err != nil {
+		return internal.InputError("convert", "load data", err.Error(),
+			"the data file must hold a YAML or JSON list of records", err)
+	}
+	name := inputName(inputFile, opts)
+	source, err := readConversionInput(inputFile, name, opts)
+	if err != nil {
+		return err
+	}
+	tmpl, err := merge.Parse(name, string(source))
+	if err != nil {
+		return internal.InputError("convert", "parse template", err.Error(),
+			"fix the template syntax in "+name, err)
+	}
+
+	// Rendered documents sit next to the template, so relative paths and
+	// project settings resolve as they do for it
+	dir, base := ".", "document.md"
+	if name != "-" {
+		dir, base = filepath.Dir(name), filepath.Base(name)
+	}
+
+	documents := make([]string, len(records))
+	for i, record := range records {
+		if documents[i], err = merge.Render(tmpl, record, i+1); err != nil {
+			return internal.InputError("convert", "render template", err.Error(),
+				"check that every record in "+opts.Data+" has the fields the template uses", err)
+		}
+	}
+
+	if opts.DataMerge {
+		for i := 1; i < len(documents); i++ {
+			documents[i] = "<!-- pagebreak -->\n\n" + merge.StripFrontMatter(documents[i])
+		}
+		if opts.OutputFile == "" {
+			opts.OutputFile = converter.ResolveOutputPath(filepath.Join(dir, base), "")
+		}
+		return convertMerged(name, dir, strings.Join(documents, "\n\n"), opts)
+	}
+
+	outputs, err := mergeOutputPaths(filepath.Join(dir, base), opts.OutputFile, records)
+	if err != nil {
+		return err
+	}
+	for i, document := range documents {
+		recordOpts := opts
+		recordOpts.OutputFile = outputs[i]
+		if err := convertMerged(name, dir, document, recordOpts); err != nil {
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// convertMerged converts a document rendered from the template name,
+// reporting it as converted from name rather than the temporary file.
+func convertMerged(name, dir, document string, opts conversionOptions) error {
+	input, err := writeGeneratedMarkdown(dir, ".veve-merge-*.md", document)
+	if err != nil {
+		return fmt.Errorf("failed to write merged document: %w", err)
+	}
+	defer os.Remove(input)
+
+	opts.reportName = name
+	return performConversion(input, opts)
+}
+
+// mergeOutputPaths returns the output path of each record: output rendered
+// as a template if it is one, or else output (default: the input with .pdf)
+// with the record's number added.
+func mergeOutputPaths(inputFile, output string, records []merge.Record) ([]string, error) {
+	paths := make([]string, len(records))
+	seen := make(map[string]int)
+
+	if strings.Contains(output, "{{") {
+		tmpl, err := merge.Parse("output", output)
+		if err != nil {
+			return nil, fmt.Errorf("--output: %w", err)
+		}
+		for i, record := range records {
+			path, err := merge.Render(tmpl, record, i+1)
+			if err != nil {
+				return nil, fmt.Errorf("--output: %w", err)
+			}
+			if previous, exists := seen[path]; exists {
+				return nil, fmt.Errorf("--output: records %d and %d are both written to %s", previous, i+1, path)
+			}
+			seen[path] = i + 1
+			paths[i] = path
+		}
+		return paths, nil
+	}
+
+	output = converter.ResolveOutputPath(inputFile, output)
+	ext := filepath.Ext(output)
+	width := len(fmt.Sprint(len(records)))
+	for i := range records {
+		paths[i] = fmt.Sprintf("%s-%0*d%s", strings.TrimSuffix(output, ext), width, i+1, ext)
+	}
+	return paths, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/skeleton"
+	"github.com/spf13/cobra"
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new <type> [file]",
+	Short: "Start a document from a template",
+	Long: `Start a markdown document with front matter and structure for a common
+document type, laid out for one of the built-in themes.
+
+Types:
+  report   Report with summary, findings, and recommendations (default theme)
+  letter   Business letter with sender, recipient, and signature (letter theme)
+  adr      Architecture decision record (default theme)
+  rfc      Request for comments (academic theme)
+  minutes  Meeting minutes with decisions and action items (default theme)
+
+The document is written to file, or to stdout if file is "-". Without a
+file, or if file is a directory, it is named <type>.md, except that ADRs and
+RFCs are numbered: each gets the number after the highest one in its
+directory, in its title and file name (0001-use-postgresql.md), with today's
+date and an initial status. An existing file is only replaced with --force.
+
+Examples:
+  veve new report
+  veve new letter offer.md --title "Job Offer" --author "Jane Doe"
+  veve new adr docs/adr --title "Use PostgreSQL"
+  veve new minutes - > minutes.md`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeSkeletonKinds,
+	SilenceUsage:      true,
+	// Writing a template doesn't need pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		title, err := cmd.Flags().GetString("title")
+		if err != nil {
+			return err
+		}
+		author, err := cmd.Flags().GetString("author")
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		status, err := cmd.Flags().GetString("status")
+		if err != nil {
+			return err
+		}
+		number, err := cmd.Flags().GetInt("number")
+		if err != nil {
+			return err
+		}
+
+		kind, err := skeleton.Find(strings.ToLower(args[0]))
+		if err != nil {
+			return err
+		}
+		doc := skeleton.Document{Title: title, Author: author, Status: status, Number: number}
+
+		// file may name the directory to write the document in
+		file, dir := "", "."
+		if len(args) == 2 {
+			if info, err := os.Stat(args[1]); err == nil && info.IsDir() || strings.HasSuffix(args[1], "/") {
+				dir = args[1]
+			} else {
+				file = args[1]
+				if file != "-" {
+					dir = filepath.Dir(file)
+				}
+			}
+		}
+
+		// ADRs and RFCs are numbered after the ones already in the directory
+		if kind.Prefix != "" && doc.Number == 0 {
+			if doc.Number, err = skeleton.NextNumber(dir); err != nil {
+				return err
+			}
+		}
+		if file == "" {
+			file = filepath.Join(dir, skeleton.FileName(kind, doc))
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dir, err)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := skeleton.Render(&buf, kind, doc); err != nil {
+			return err
+		}
+		if file == "-" {
+			_, err := cmd.OutOrStdout().Write(buf.Bytes())
+			return err
+		}
+
+		flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if force {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		f, err := os.OpenFile(file, flags, 0o644)
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists (use --force to replace it)", file)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", file, err)
+		}
+		if _, err := f.Write(buf.Bytes()); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+
+		if !quiet {
+			fmt.Printf("Created %s\n", file)
+			fmt.Printf("Convert it with: veve %s --theme %s\n", file, kind.Theme)
+		}
+		return nil
+	},
+}
+
+// completeSkeletonKinds completes the document type of veve new.
+func completeSkeletonKinds(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	var names []string
+	for _, kind := range skeleton.Kinds() {
+		names = append(names, kind.Name+"\t"+kind.Description)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	newCmd.Flags().String("title", "", "document title (default: a placeholder for the type)")
+	newCmd.Flags().String("author", "", "author, or the sender of a letter and attendees of minutes")
+	newCmd.Flags().String("status", "", "status of an adr (default: Proposed) or rfc (default: Draft)")
+	newCmd.Flags().Int("number", 0, "number of an adr or rfc (default: one after the highest in its directory)")
+	newCmd.Flags().BoolP("force", "f", false, "replace an existing file")
+}
@@ -0,0 +1,607 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/spf13/cobra"
+)
+
+// conversionOptions holds the conversion settings shared by the root command
+// and the convert subcommand.
+type conversionOptions struct {
+	OutputFile             string
+	ExtraOutputs           []string
+	Formats                []string
+	Format                 string
+	Handout                int
+	Booklet                bool
+	NUp                    int
+	Theme                  string
+	PDFEngine              string
+	EngineArgs             []string
+	AutoInstallPackages    bool
+	RetryLaTeX             bool
+	EnableRemoteImages     bool
+	RemoteImagesTimeout    int
+	RemoteImagesMaxRetries int
+	RemoteImagesMaxCount   int
+	RemoteImagesStrictType bool
+	RemoteImagesTempDir    string
+	RemoteImagesSchemes    []string
+	ImageCache             bool
+	KeepTemp               bool
+	Checksum               string
+	Sign                   string
+	SignKey                string
+	OutputDir              string
+	ResourceDirs           []string
+	Figures                bool
+	NoSanitize             bool
+	CoverImage             string
+	Logo                   string
+	Widows                 int
+	Orphans                int
+	KeepHeadings           bool
+	Endnotes               string
+	NumberSections         bool
+	NumberDepth            int
+	Smart                  bool
+	Audience               string
+	DiffAgainst            string
+	Redact                 string
+	RedactReport           string
+	NoSmart                bool
+	Sandbox                string
+	CPULimit               time.Duration
+	MemoryLimit            int64
+	Jobs                   int
+	JobTimeout             time.Duration
+	MaxInputSize           int64
+	MaxMemory              int64
+	RefreshEngines         bool
+	Hooks                  bool
+	NoHooks                bool
+	Vault                  string
+	Data                   string
+	DataMerge              bool
+	JoinFile               string
+	StdinFilename          string
+	Strict                 bool
+	JSON                   bool
+
+	// FallbackHTML writes themed HTML instead of failing when no PDF engine
+	// is installed; OfferFallback is set for commands with the flag, which
+	// otherwise ask when run interactively
+	FallbackHTML  bool
+	OfferFallback bool
+
+	// ChapterCSS is added after the theme's CSS; veve book sets it to the
+	// book's chapter themes, scoped to their chapters
+	ChapterCSS string
+
+	// Deadline is when a conversion still running is stopped, set from
+	// --job-timeout as each input starts converting
+	Deadline time.Time
+
+	// results collects what converting an input wrote, for the --json report
+	results *inputResults
+
+	// reportName is the input messages and the report name in place of the
+	// file converted, as for the template of a mail merge's documents
+	reportName string
+
+	// changed reports whether a flag was given, which configured defaults
+	// don't override
+	changed func(name string) bool
+}
+
+// defaultMaxInputSize is the --max-input-size default. Large documents are
+// prepared a section at a time, but the whole document is still held in
+// memory (several times over by pandoc), so refuse runaway input up front.
+const defaultMaxInputSize = "512M"
+
+// defaultMaxRemoteImages is the --remote-images-max-count default: more than
+// any hand-written document references, but well short of a page saved from
+// a crawler or an image gallery, which would download for a long time.
+const defaultMaxRemoteImages = 1000
+
+// addConversionFlags registers the conversion flags on cmd.
+func addConversionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("output", "o", nil, "output PDF file path, or a directory to write it to; repeat with .html or .epub files to write those too (default: input filename with .pdf extension)")
+	cmd.Flags().String("output-dir", "", "directory to write the PDFs to; the markdown files of a directory input keep their place in its tree (default: beside each input)")
+	cmd.Flags().StringP("theme", "t", "default", "theme to use for PDF styling")
+	cmd.Flags().String("format", formatPDF, "output format: \"pdf\", or \"slides\" for a beamer slide deck with a slide per level-2 heading")
+	cmd.Flags().Int("handout", 0, "with --format slides, print this many slides per page (1-4), each beside lines for notes")
+	cmd.Flags().Lookup("handout").NoOptDefVal = "3"
+	cmd.Flags().Bool("booklet", false, "reorder pages for a booklet: printed duplex (flip on short edge), two to a sheet side, and folded")
+	cmd.Flags().Int("n-up", 0, "print 2 or 4 pages per sheet side")
+	cmd.Flags().StringP("engine", "e", "", "PDF rendering engine to use (xelatex, lualatex, weasyprint, prince, wkhtmltopdf, or docker[:<image>] to run pandoc and xelatex in a container); auto-detected if not specified")
+	cmd.Flags().StringArray("engine-arg", nil, "argument passed to the PDF engine as is, e.g. --engine-arg=-shell-escape (repeatable)")
+	cmd.Flags().Bool("auto-install-packages", false, "install TeX packages a LaTeX engine reports missing (tlmgr or mpm) and convert again")
+	cmd.Flags().Bool("retry-latex", false, "when a LaTeX engine fails, convert once more with a clean temporary directory (for intermittent aux file or font cache failures)")
+	cmd.Flags().BoolP("enable-remote-images", "r", true, "automatically download and embed remote images in PDF")
+	cmd.Flags().Int("remote-images-timeout", 10, "timeout in seconds for downloading each remote image")
+	cmd.Flags().Int("remote-images-max-retries", 3, "maximum number of retries for failed image downloads")
+	cmd.Flags().Int("remote-images-max-count", defaultMaxRemoteImages, "most distinct remote images a document may reference before conversion stops, e.g. for a crawled page (0 for no limit)")
+	cmd.Flags().Bool("remote-images-strict-type", false, "only accept remote images served as image/* (default: also accept images served as text/plain or application/octet-stream, recognized by their content)")
+	cmd.Flags().String("remote-images-temp-dir", "", "custom directory for downloaded images (default: private per-run temp directory)")
+	cmd.Flags().StringSlice("remote-images-schemes", nil, "also fetch images with these URL schemes besides http and https: file (local files) and s3 (public S3 objects), e.g. --remote-images-schemes file,s3")
+	cmd.Flags().Bool("image-cache", false, "keep remote images in the user cache directory, and use a kept image while its server reports it unchanged (clear with 'veve cache clear')")
+	cmd.Flags().Bool("no-image-cache", false, "download every remote image, even with image-cache set in veve.toml or .veve.yaml")
+	cmd.Flags().String("checksum", "", "write the output's checksum beside it, e.g. report.pdf.sha256, in sha256sum's format: \"sha256\" or \"sha512\"")
+	cmd.Flags().Lookup("checksum").NoOptDefVal = converter.ChecksumSHA256
+	cmd.Flags().String("sign", "", "write a detached signature of the output beside it with \"gpg\" (report.pdf.asc) or \"minisign\" (report.pdf.minisig)")
+	cmd.Flags().Lookup("sign").NoOptDefVal = converter.SignerGPG
+	cmd.Flags().String("sign-key", "", "with --sign, the key to sign with: a gpg key ID or email, or a minisign secret key file (default: the tool's default key, or sign_key in veve.toml)")
+	cmd.Flags().Bool("keep-temp", false, "keep the run's temporary files (processed markdown, downloaded images, theme CSS) and print where they are, for debugging")
+	cmd.Flags().Bool("figures", false, "render images on a line of their own as numbered figures, captioned with their alt text or title")
+	cmd.Flags().String("cover-image", "", "image for the title page, available to templates as $cover-image$")
+	cmd.Flags().String("logo", "", "logo image, available to templates as $logo$")
+	cmd.Flags().StringArray("resource-dir", nil, "directory searched for images, bibliographies, CSL styles, and other files the document names (repeatable; default: the current directory, and resource_dirs in .veve.yaml)")
+	cmd.Flags().Int("widows", 0, "minimum lines of a paragraph at the top of a page (default: theme or engine setting)")
+	cmd.Flags().Int("orphans", 0, "minimum lines of a paragraph at the bottom of a page (default: theme or engine setting)")
+	cmd.Flags().Bool("keep-headings", false, "keep headings on the same page as the text that follows them")
+	cmd.Flags().BoolP("number-sections", "N", false, "number headings (skip one with {.unnumbered} or {-})")
+	cmd.Flags().Int("number-depth", 0, "deepest heading level to number, 1-6 (implies --number-sections; default: every level)")
+	cmd.Flags().Bool("smart", false, "turn straight quotes into curly quotes, -- and --- into dashes, and ... into ellipses (default: on, unless the theme turns it off)")
+	cmd.Flags().Bool("no-smart", false, "keep quotes, dashes, and ellipses as typed, e.g. for code-heavy documents")
+	cmd.Flags().String("endnotes", "", "collect footnotes at the end of the \"document\" or of each \"chapter\" (level-1 section)")
+	cmd.Flags().Lookup("endnotes").NoOptDefVal = converter.EndnotesDocument
+	cmd.Flags().String("audience", "", "keep only the content for this audience: divs with another audience's class, e.g. ::: {.internal}, are removed")
+	cmd.Flags().String("diff-against", "", "mark paragraphs added or changed since an earlier version (a markdown file or a git revision of the input) with change bars")
+	cmd.Flags().String("redact", "", "YAML file of patterns whose matches (emails, keys, names) are masked before converting")
+	cmd.Flags().String("redact-report", "", "where to write the report of what --redact masked (default: output name with .redactions.json)")
+	cmd.Flags().Bool("no-sanitize", false, "keep external @import and script URLs in theme CSS (for trusted themes)")
+	cmd.Flags().String("sandbox", "", "run pandoc with reduced privileges for untrusted input: \"restricted\" or \"firejail\" (Linux)")
+	cmd.Flags().Lookup("sandbox").NoOptDefVal = converter.SandboxRestricted
+	cmd.Flags().Duration("cpu-limit", 0, "maximum CPU time for pandoc and the PDF engine, e.g. 2m (default: unlimited)")
+	cmd.Flags().String("memory-limit", "", "maximum memory for pandoc and the PDF engine, e.g. 2G (default: unlimited)")
+	cmd.Flags().String("max-memory", "", "memory veve itself aims to stay within, e.g. 256M: fewer concurrent image downloads, smaller buffers, stdout output streamed (default: unlimited)")
+	cmd.Flags().String("max-input-size", defaultMaxInputSize, "largest markdown input to read, e.g. 1G (0 for no limit)")
+	cmd.Flags().Bool("refresh-engines", false, "re-detect PDF engines instead of using cached detection results")
+	cmd.Flags().Bool("hooks", false, "run the hooks and the command and plugin transformers in .veve.yaml, and apply all its defaults; without it they are skipped, unless hooks is set in veve.toml")
+	cmd.Flags().Bool("no-hooks", false, "skip the hooks, the command and plugin transformers, and the unsafe defaults in .veve.yaml, even with hooks set in veve.toml")
+	cmd.Flags().String("data", "", "YAML or JSON data file; the markdown is a Go template rendered once per record (mail merge)")
+	cmd.Flags().Bool("data-merge", false, "with --data, write every record into one PDF instead of one PDF each")
+	cmd.Flags().IntP("jobs", "j", 1, "with several inputs, how many to convert at once")
+	cmd.Flags().Duration("job-timeout", 0, "longest each input may take to convert, e.g. 5m; pandoc is stopped and the input reported as failed (default: unlimited)")
+	cmd.Flags().String("merge", "", "with several inputs, or for veve book, also join the PDFs, in order, into this file (needs qpdf or pdfunite)")
+	cmd.Flags().Bool("strict", false, "fail on markdown that isn't UTF-8 instead of converting it from its detected encoding")
+	cmd.Flags().String("stdin-filename", "", "name of the file read from stdin, for messages, relative paths, and the default output name")
+	cmd.Flags().String("vault", "", "Obsidian vault to resolve [[wiki links]] against (default: .veve.yaml vault, or the enclosing vault)")
+
+	cmd.RegisterFlagCompletionFunc("engine", completeEngines)
+	cmd.RegisterFlagCompletionFunc("theme", completeThemes)
+	cmd.MarkFlagDirname("resource-dir")
+	cmd.MarkFlagDirname("output-dir")
+	cmd.MarkFlagFilename("merge", "pdf")
+	cmd.RegisterFlagCompletionFunc("checksum", cobra.FixedCompletions([]string{converter.ChecksumSHA256, converter.ChecksumSHA512}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("sign", cobra.FixedCompletions([]string{converter.SignerGPG, converter.SignerMinisign}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{formatPDF, formatSlides}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// addFallbackFlag registers --fallback-html on cmd, for commands whose
+// output the user reads rather than another command.
+func addFallbackFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("fallback-html", false, "write themed standalone HTML instead of failing when no PDF engine is installed")
+}
+
+// readConversionOptions reads the flags registered by addConversionFlags.
+// Invalid flags and combinations of them are usage errors.
+func readConversionOptions(cmd *cobra.Command) (conversionOptions, error) {
+	opts, err := parseConversionOptions(cmd)
+	return opts, internal.WithKind(err, internal.KindUsage)
+}
+
+// parseConversionOptions reads and checks the conversion flags.
+func parseConversionOptions(cmd *cobra.Command) (conversionOptions, error) {
+	var opts conversionOptions
+	var err error
+	opts.changed = cmd.Flags().Changed
+
+	if opts.OutputDir, err = cmd.Flags().GetString("output-dir"); err != nil {
+		return opts, err
+	}
+	if opts.Format, err = cmd.Flags().GetString("format"); err != nil {
+		return opts, err
+	}
+	if opts.Format != formatPDF && opts.Format != formatSlides {
+		return opts, fmt.Errorf("--format must be %q or %q, not %q", formatPDF, formatSlides, opts.Format)
+	}
+	if opts.Handout, err = cmd.Flags().GetInt("handout"); err != nil {
+		return opts, err
+	}
+	if cmd.Flags().Changed("handout") {
+		if opts.Format != formatSlides {
+			return opts, fmt.Errorf("--handout needs --format slides")
+		}
+		if opts.Handout < 1 || opts.Handout > converter.MaxHandoutSlides {
+			return opts, fmt.Errorf("--handout must be 1 to %d slides per page", converter.MaxHandoutSlides)
+		}
+	}
+	if opts.Booklet, err = cmd.Flags().GetBool("booklet"); err != nil {
+		return opts, err
+	}
+	if opts.NUp, err = cmd.Flags().GetInt("n-up"); err != nil {
+		return opts, err
+	}
+	if err := converter.ValidateNUp(opts.NUp); err != nil {
+		return opts, fmt.Errorf("--n-up: %w", err)
+	}
+	if opts.Booklet && opts.NUp > 0 {
+		return opts, fmt.Errorf("--booklet and --n-up cannot be used together")
+	}
+	if err := parseOutputs(cmd, &opts); err != nil {
+		return opts, err
+	}
+	if opts.Theme, err = cmd.Flags().GetString("theme"); err != nil {
+		return opts, err
+	}
+	if opts.PDFEngine, err = cmd.Flags().GetString("engine"); err != nil {
+		return opts, err
+	}
+	if opts.EngineArgs, err = cmd.Flags().GetStringArray("engine-arg"); err != nil {
+		return opts, err
+	}
+	if opts.AutoInstallPackages, err = cmd.Flags().GetBool("auto-install-packages"); err != nil {
+		return opts, err
+	}
+	if opts.RetryLaTeX, err = cmd.Flags().GetBool("retry-latex"); err != nil {
+		return opts, err
+	}
+	if opts.EnableRemoteImages, err = cmd.Flags().GetBool("enable-remote-images"); err != nil {
+		return opts, err
+	}
+	if opts.RemoteImagesTimeout, err = cmd.Flags().GetInt("remote-images-timeout"); err != nil {
+		return opts, err
+	}
+	if opts.RemoteImagesMaxRetries, err = cmd.Flags().GetInt("remote-images-max-retries"); err != nil {
+		return opts, err
+	}
+	if opts.RemoteImagesMaxCount, err = cmd.Flags().GetInt("remote-images-max-count"); err != nil {
+		return opts, err
+	}
+	if opts.RemoteImagesMaxCount < 0 {
+		return opts, fmt.Errorf("--remote-images-max-count must be 0 (no limit) or more")
+	}
+	if opts.RemoteImagesStrictType, err = cmd.Flags().GetBool("remote-images-strict-type"); err != nil {
+		return opts, err
+	}
+	if opts.RemoteImagesTempDir, err = cmd.Flags().GetString("remote-images-temp-dir"); err != nil {
+		return opts, err
+	}
+	if opts.RemoteImagesSchemes, err = cmd.Flags().GetStringSlice("remote-images-schemes"); err != nil {
+		return opts, err
+	}
+	if err := converter.CheckSchemes(opts.RemoteImagesSchemes); err != nil {
+		return opts, fmt.Errorf("--remote-images-schemes: %w", err)
+	}
+	if opts.ImageCache, err = cmd.Flags().GetBool("image-cache"); err != nil {
+		return opts, err
+	}
+	noImageCache, err := cmd.Flags().GetBool("no-image-cache")
+	if err != nil {
+		return opts, err
+	}
+	if opts.ImageCache && noImageCache {
+		return opts, fmt.Errorf("--image-cache and --no-image-cache cannot be used together")
+	}
+	if opts.KeepTemp, err = cmd.Flags().GetBool("keep-temp"); err != nil {
+		return opts, err
+	}
+	if opts.Checksum, err = cmd.Flags().GetString("checksum"); err != nil {
+		return opts, err
+	}
+	if err := converter.ValidateChecksum(opts.Checksum); err != nil {
+		return opts, err
+	}
+	if opts.Checksum != converter.ChecksumOff && opts.OutputFile == "-" {
+		return opts, fmt.Errorf("--checksum cannot be used with output to stdout")
+	}
+	if opts.Sign, err = cmd.Flags().GetString("sign"); err != nil {
+		return opts, err
+	}
+	if err := converter.ValidateSigner(opts.Sign); err != nil {
+		return opts, err
+	}
+	if opts.Sign != converter.SignerOff && opts.OutputFile == "-" {
+		return opts, fmt.Errorf("--sign cannot be used with output to stdout")
+	}
+	if opts.SignKey, err = cmd.Flags().GetString("sign-key"); err != nil {
+		return opts, err
+	}
+	if opts.SignKey != "" && opts.Sign == converter.SignerOff {
+		return opts, fmt.Errorf("--sign-key requires --sign")
+	}
+	if opts.Figures, err = cmd.Flags().GetBool("figures"); err != nil {
+		return opts, err
+	}
+	if opts.NoSanitize, err = cmd.Flags().GetBool("no-sanitize"); err != nil {
+		return opts, err
+	}
+	if opts.CoverImage, err = cmd.Flags().GetString("cover-image"); err != nil {
+		return opts, err
+	}
+	if opts.Logo, err = cmd.Flags().GetString("logo"); err != nil {
+		return opts, err
+	}
+	resourceDirs, err := cmd.Flags().GetStringArray("resource-dir")
+	if err != nil {
+		return opts, err
+	}
+	for _, dir := range resourceDirs {
+		// Absolute, so they resolve the same in a sandbox's working directory
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return opts, fmt.Errorf("--resource-dir %s: %w", dir, err)
+		}
+		if info, err := os.Stat(absDir); err != nil || !info.IsDir() {
+			return opts, fmt.Errorf("--resource-dir %s is not a directory", dir)
+		}
+		opts.ResourceDirs = append(opts.ResourceDirs, absDir)
+	}
+	if opts.Widows, err = cmd.Flags().GetInt("widows"); err != nil {
+		return opts, err
+	}
+	if opts.Orphans, err = cmd.Flags().GetInt("orphans"); err != nil {
+		return opts, err
+	}
+	if opts.KeepHeadings, err = cmd.Flags().GetBool("keep-headings"); err != nil {
+		return opts, err
+	}
+	if opts.Endnotes, err = cmd.Flags().GetString("endnotes"); err != nil {
+		return opts, err
+	}
+	if err := converter.ValidateEndnotes(opts.Endnotes); err != nil {
+		return opts, err
+	}
+	if opts.Sandbox, err = cmd.Flags().GetString("sandbox"); err != nil {
+		return opts, err
+	}
+	if err := converter.ValidateSandbox(opts.Sandbox); err != nil {
+		return opts, err
+	}
+	// Engine arguments such as -shell-escape would undo the sandbox
+	if opts.Sandbox != converter.SandboxOff && len(opts.EngineArgs) > 0 {
+		return opts, fmt.Errorf("--engine-arg cannot be used with --sandbox")
+	}
+	// Untrusted input shouldn't choose what gets installed
+	if opts.Sandbox != converter.SandboxOff && opts.AutoInstallPackages {
+		return opts, fmt.Errorf("--auto-install-packages cannot be used with --sandbox")
+	}
+	if opts.Format == formatSlides && opts.Sandbox != converter.SandboxOff {
+		return opts, fmt.Errorf("--format slides cannot be used with --sandbox")
+	}
+	if cmd.Flags().Lookup("fallback-html") != nil {
+		opts.OfferFallback = opts.Sandbox == converter.SandboxOff && opts.Format == formatPDF && !opts.imposes() && !opts.multipleOutputs()
+		if opts.FallbackHTML, err = cmd.Flags().GetBool("fallback-html"); err != nil {
+			return opts, err
+		}
+		if opts.FallbackHTML && opts.Sandbox != converter.SandboxOff {
+			return opts, fmt.Errorf("--fallback-html cannot be used with --sandbox")
+		}
+		if opts.FallbackHTML && opts.Format == formatSlides {
+			return opts, fmt.Errorf("--fallback-html cannot be used with --format slides")
+		}
+		if opts.FallbackHTML && opts.imposes() {
+			return opts, fmt.Errorf("--fallback-html cannot be used with --booklet or --n-up")
+		}
+	}
+	if opts.CPULimit, err = cmd.Flags().GetDuration("cpu-limit"); err != nil {
+		return opts, err
+	}
+	if opts.CPULimit < 0 {
+		return opts, fmt.Errorf("--cpu-limit must not be negative")
+	}
+	if opts.Jobs, err = cmd.Flags().GetInt("jobs"); err != nil {
+		return opts, err
+	}
+	if opts.Jobs < 1 {
+		return opts, fmt.Errorf("--jobs must be at least 1")
+	}
+	if opts.JobTimeout, err = cmd.Flags().GetDuration("job-timeout"); err != nil {
+		return opts, err
+	}
+	if opts.JobTimeout < 0 {
+		return opts, fmt.Errorf("--job-timeout must not be negative")
+	}
+	memoryLimit, err := cmd.Flags().GetString("memory-limit")
+	if err != nil {
+		return opts, err
+	}
+	if memoryLimit != "" {
+		if opts.MemoryLimit, err = converter.ParseMemorySize(memoryLimit); err != nil {
+			return opts, fmt.Errorf("--memory-limit: %w", err)
+		}
+	}
+	maxMemory, err := cmd.Flags().GetString("max-memory")
+	if err != nil {
+		return opts, err
+	}
+	if maxMemory != "" {
+		if opts.MaxMemory, err = converter.ParseMemorySize(maxMemory); err != nil {
+			return opts, fmt.Errorf("--max-memory: %w", err)
+		}
+	}
+	maxInputSize, err := cmd.Flags().GetString("max-input-size")
+	if err != nil {
+		return opts, err
+	}
+	if opts.MaxInputSize, err = converter.ParseMemorySize(maxInputSize); err != nil {
+		return opts, fmt.Errorf("--max-input-size: %w", err)
+	}
+	if opts.RefreshEngines, err = cmd.Flags().GetBool("refresh-engines"); err != nil {
+		return opts, err
+	}
+	if opts.Hooks, err = cmd.Flags().GetBool("hooks"); err != nil {
+		return opts, err
+	}
+	if opts.NoHooks, err = cmd.Flags().GetBool("no-hooks"); err != nil {
+		return opts, err
+	}
+	if opts.Hooks && opts.NoHooks {
+		return opts, fmt.Errorf("--hooks and --no-hooks cannot be used together")
+	}
+	if opts.Strict, err = cmd.Flags().GetBool("strict"); err != nil {
+		return opts, err
+	}
+	if opts.StdinFilename, err = cmd.Flags().GetString("stdin-filename"); err != nil {
+		return opts, err
+	}
+	if opts.Vault, err = cmd.Flags().GetString("vault"); err != nil {
+		return opts, err
+	}
+	if opts.Data, err = cmd.Flags().GetString("data"); err != nil {
+		return opts, err
+	}
+	if opts.DataMerge, err = cmd.Flags().GetBool("data-merge"); err != nil {
+		return opts, err
+	}
+	if opts.DataMerge && opts.Data == "" {
+		return opts, fmt.Errorf("--data-merge needs --data")
+	}
+	if opts.JoinFile, err = cmd.Flags().GetString("merge"); err != nil {
+		return opts, err
+	}
+	if opts.JoinFile == "-" {
+		return opts, fmt.Errorf("--merge needs a file to write; the joined PDF cannot be written to stdout")
+	}
+	if opts.JoinFile != "" && opts.Data != "" {
+		return opts, fmt.Errorf("--merge cannot be used with --data; use --data-merge to write every record into one PDF")
+	}
+	if len(opts.ExtraOutputs) > 0 && opts.Data != "" && !opts.DataMerge {
+		return opts, fmt.Errorf("--output can only be given once with --data; use --formats for other formats of each record")
+	}
+	if opts.NumberSections, err = cmd.Flags().GetBool("number-sections"); err != nil {
+		return opts, err
+	}
+	if opts.NumberDepth, err = cmd.Flags().GetInt("number-depth"); err != nil {
+		return opts, err
+	}
+	if err := converter.ValidateNumberDepth(opts.NumberDepth); err != nil {
+		return opts, err
+	}
+	if opts.NumberDepth > 0 {
+		opts.NumberSections = true
+	}
+	if opts.Audience, err = cmd.Flags().GetString("audience"); err != nil {
+		return opts, err
+	}
+	if opts.DiffAgainst, err = cmd.Flags().GetString("diff-against"); err != nil {
+		return opts, err
+	}
+	if opts.DiffAgainst != "" && opts.Format == formatSlides {
+		return opts, fmt.Errorf("--diff-against cannot be used with --format slides")
+	}
+	if opts.Redact, err = cmd.Flags().GetString("redact"); err != nil {
+		return opts, err
+	}
+	if opts.RedactReport, err = cmd.Flags().GetString("redact-report"); err != nil {
+		return opts, err
+	}
+	if opts.RedactReport != "" && opts.Redact == "" {
+		return opts, fmt.Errorf("--redact-report needs --redact")
+	}
+	if opts.Smart, err = cmd.Flags().GetBool("smart"); err != nil {
+		return opts, err
+	}
+	if opts.NoSmart, err = cmd.Flags().GetBool("no-smart"); err != nil {
+		return opts, err
+	}
+	if opts.Smart && opts.NoSmart {
+		return opts, fmt.Errorf("--smart and --no-smart cannot be used together")
+	}
+	if opts.Widows < 0 || opts.Orphans < 0 {
+		return opts, fmt.Errorf("--widows and --orphans must not be negative")
+	}
+	if cmd.Flags().Lookup("json") != nil {
+		if opts.JSON, err = cmd.Flags().GetBool("json"); err != nil {
+			return opts, err
+		}
+		if opts.JSON && opts.OutputFile == "-" {
+			return opts, fmt.Errorf("--json prints its report to stdout, so the PDF can't be written there: give --output a file")
+		}
+	}
+
+	return opts, nil
+}
+
+// applyConfigDefaults fills in the settings whose flags weren't given from
+// the defaults in veve.toml and the .veve.yaml of the project containing
+// projectDir. An untrusted project's defaults are limited to the safe ones
+// (see config.ProjectConfig.SafeDefaults), with a warning naming the others.
+func (opts *conversionOptions) applyConfigDefaults(projectDir string) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return fmt.Errorf("failed to get config paths: %w", err)
+	}
+	defaults, ignored, err := config.LoadDefaults(paths.ConfigFile, projectDir, trustsProject(*opts))
+	if err != nil {
+		return internal.ConfigError("convert", "load configuration", err.Error(),
+			"fix the defaults in "+paths.ConfigFile+" or the project's "+config.ProjectConfigFile, err)
+	}
+	if len(ignored) > 0 {
+		logger.Warn("Ignoring %s in the project's %s: %s", strings.Join(ignored, ", "), config.ProjectConfigFile, trustHint)
+	}
+
+	if defaults.Theme != "" && !opts.given("theme") {
+		opts.Theme = defaults.Theme
+	}
+	if defaults.PDFEngine != "" && !opts.given("engine") {
+		opts.PDFEngine = defaults.PDFEngine
+	}
+	if defaults.SignKey != "" && !opts.given("sign-key") {
+		opts.SignKey = defaults.SignKey
+	}
+	if defaults.OutputDir != "" && !opts.given("output-dir") && !opts.given("output") {
+		opts.OutputDir = defaults.OutputDir
+	}
+	images := defaults.RemoteImages
+	if images.Enabled != nil && !opts.given("enable-remote-images") {
+		opts.EnableRemoteImages = *images.Enabled
+	}
+	if images.Timeout != nil && !opts.given("remote-images-timeout") {
+		opts.RemoteImagesTimeout = *images.Timeout
+	}
+	if images.MaxRetries != nil && !opts.given("remote-images-max-retries") {
+		opts.RemoteImagesMaxRetries = *images.MaxRetries
+	}
+	if images.MaxCount != nil && !opts.given("remote-images-max-count") {
+		opts.RemoteImagesMaxCount = *images.MaxCount
+	}
+	if images.StrictType != nil && !opts.given("remote-images-strict-type") {
+		opts.RemoteImagesStrictType = *images.StrictType
+	}
+	if images.TempDir != "" && !opts.given("remote-images-temp-dir") {
+		opts.RemoteImagesTempDir = images.TempDir
+	}
+	if images.Cache != nil && !opts.given("image-cache") && !opts.given("no-image-cache") {
+		opts.ImageCache = *images.Cache
+	}
+	return nil
+}
+
+// limits returns the limits pandoc and the PDF engine run with.
+func (opts *conversionOptions) limits() converter.Limits {
+	return converter.Limits{CPUTime: opts.CPULimit, Memory: opts.MemoryLimit, Deadline: opts.Deadline}
+}
+
+// given reports whether the flag name was given on the command line.
+func (opts *conversionOptions) given(name string) bool {
+	return opts.changed != nil && opts.changed(name)
+}
+
+// applyThemeDefaults fills in the settings left to the theme: smart
+// punctuation is off if the theme turns it off and --smart isn't given.
+func (opts *conversionOptions) applyThemeDefaults(loaded *resolvedTheme) {
+	if loaded.NoSmart && !opts.Smart {
+		opts.NoSmart = true
+	}
+}
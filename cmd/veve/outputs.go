@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/spf13/cobra"
+)
+
+// Output formats for --formats and a repeated --output, besides formatPDF
+const (
+	formatHTML = "html"
+	formatEPUB = "epub"
+)
+
+// outputFormats are the formats one conversion can write, PDF first.
+var outputFormats = []string{formatPDF, formatHTML, formatEPUB}
+
+// formatOutput is a file a conversion writes, in format.
+type formatOutput struct {
+	format string
+	path   string
+}
+
+// addFormatsFlag registers --formats on cmd, and lets --output be repeated,
+// for commands that convert documents the user names.
+func addFormatsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("formats", nil, "write each of these formats (pdf, html, epub) from one pass over the input, named after the output with the format's extension")
+	cmd.RegisterFlagCompletionFunc("formats", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// parseOutputs reads --output and --formats into opts, and checks the
+// outputs they ask for can be written together.
+func parseOutputs(cmd *cobra.Command, opts *conversionOptions) error {
+	outputs, err := cmd.Flags().GetStringArray("output")
+	if err != nil {
+		return err
+	}
+	if len(outputs) > 0 {
+		opts.OutputFile = outputs[0]
+	}
+	if cmd.Flags().Lookup("formats") == nil {
+		if len(outputs) > 1 {
+			return fmt.Errorf("--output can only be given once")
+		}
+		return nil
+	}
+	opts.ExtraOutputs = outputs[min(len(outputs), 1):]
+
+	formats, err := cmd.Flags().GetStringSlice("formats")
+	if err != nil {
+		return err
+	}
+	for _, format := range formats {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if !slices.Contains(outputFormats, format) {
+			return fmt.Errorf("--formats: unknown format %q (use %s)", format, strings.Join(outputFormats, ", "))
+		}
+		if slices.Contains(opts.Formats, format) {
+			return fmt.Errorf("--formats: %s is listed twice", format)
+		}
+		opts.Formats = append(opts.Formats, format)
+	}
+	if !opts.multipleOutputs() {
+		return nil
+	}
+
+	if len(opts.Formats) > 0 && len(opts.ExtraOutputs) > 0 {
+		return fmt.Errorf("--formats cannot be used with a repeated --output")
+	}
+	for i, output := range outputs {
+		if output == "-" {
+			return fmt.Errorf("several outputs cannot be written to stdout")
+		}
+		for _, other := range outputs[:i] {
+			if outputFormat(other) == outputFormat(output) {
+				return fmt.Errorf("--output %s and %s are both %s files; give one output per format", other, output, outputFormat(output))
+			}
+		}
+	}
+	if opts.OutputFile == "-" {
+		return fmt.Errorf("--formats cannot be used with output to stdout")
+	}
+	if opts.Format == formatSlides {
+		return fmt.Errorf("--formats and a repeated --output cannot be used with --format slides")
+	}
+	if opts.imposes() && !slices.Contains(opts.outputFormats(), formatPDF) {
+		return fmt.Errorf("--booklet and --n-up need a PDF output")
+	}
+	return nil
+}
+
+// multipleOutputs reports whether --formats or a repeated --output ask for
+// more than the PDF.
+func (opts conversionOptions) multipleOutputs() bool {
+	return len(opts.Formats) > 0 || len(opts.ExtraOutputs) > 0
+}
+
+// outputFormats returns the formats the conversion writes.
+func (opts conversionOptions) outputFormats() []string {
+	switch {
+	case len(opts.Formats) > 0:
+		return opts.Formats
+	case len(opts.ExtraOutputs) > 0:
+		formats := []string{outputFormat(opts.OutputFile)}
+		for _, output := range opts.ExtraOutputs {
+			formats = append(formats, outputFormat(output))
+		}
+		return formats
+	}
+	return []string{formatPDF}
+}
+
+// outputFormat returns the format an output file's extension names: HTML,
+// EPUB, or PDF for any other.
+func outputFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return formatHTML
+	case ".epub":
+		return formatEPUB
+	}
+	return formatPDF
+}
+
+// conversionOutputs returns the files a conversion whose output resolved to
+// outputFile writes, the PDF first if one is written. With --formats, each
+// is named after outputFile with the format's extension.
+func conversionOutputs(outputFile string, opts conversionOptions) []formatOutput {
+	var outputs []formatOutput
+	switch {
+	case len(opts.Formats) > 0:
+		base := strings.TrimSuffix(outputFile, filepath.Ext(outputFile))
+		for _, format := range opts.Formats {
+			outputs = append(outputs, formatOutput{format: format, path: base + "." + format})
+		}
+	case len(opts.ExtraOutputs) > 0:
+		for _, path := range append([]string{outputFile}, opts.ExtraOutputs...) {
+			outputs = append(outputs, formatOutput{format: outputFormat(path), path: path})
+		}
+	default:
+		return []formatOutput{{format: formatPDF, path: outputFile}}
+	}
+	slices.SortStableFunc(outputs, func(a, b formatOutput) int {
+		return slices.Index(outputFormats, a.format) - slices.Index(outputFormats, b.format)
+	})
+	return outputs
+}
+
+// writeOutput writes the processed markdown to an HTML or EPUB output,
+// styled with the theme. Like the PDF, it replaces the file only once
+// written.
+func writeOutput(output formatOutput, processedInputFile, themeFile string, resourcePath []string, opts conversionOptions) error {
+	staged, err := converter.StageOutput(output.path)
+	if err != nil {
+		return err
+	}
+	defer staged.Discard()
+
+	switch output.format {
+	case formatHTML:
+		err = convertHTML(processedInputFile, staged.Path, themeFile, resourcePath, opts)
+	case formatEPUB:
+		var pandoc *converter.PandocConverter
+		if pandoc, err = converter.NewPandocConverter(); err != nil {
+			return internal.PandocNotFound()
+		}
+		err = pandoc.ConvertEPUB(converter.EPUBOptions{
+			InputFile:  processedInputFile,
+			OutputFile: staged.Path,
+			Stylesheet: themeFile,
+			Limits:     opts.limits(),
+			NoSmart:    opts.NoSmart,
+		})
+	default:
+		return fmt.Errorf("cannot write %s output %s", output.format, output.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", output.path, err)
+	}
+	return staged.Commit()
+}
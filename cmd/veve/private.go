@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+// stripPrivateComments removes the comments marked private, with the
+// project's private_marker or veve:private, from content.
+func stripPrivateComments(project *config.ProjectConfig, content string) string {
+	marker := converter.DefaultPrivateMarker
+	if project != nil && project.PrivateMarker != "" {
+		marker = project.PrivateMarker
+	}
+	return converter.StripPrivateComments(content, marker)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/spf13/cobra"
+)
+
+// Profiling flags, for diagnosing slow conversions and memory use. The output
+// is read with "go tool pprof" and "go tool trace".
+var (
+	cpuProfile string
+	memProfile string
+	traceFile  string
+)
+
+// Files the running profiles write to, closed by stopProfiling
+var (
+	cpuProfileFile *os.File
+	traceOutFile   *os.File
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpuprofile", "", "write a CPU profile to this file")
+	rootCmd.PersistentFlags().StringVar(&memProfile, "memprofile", "", "write a heap profile to this file on exit")
+	rootCmd.PersistentFlags().StringVar(&traceFile, "trace", "", "write an execution trace to this file")
+	for _, name := range []string{"cpuprofile", "memprofile", "trace"} {
+		_ = rootCmd.PersistentFlags().MarkHidden(name)
+	}
+
+	// Runs for every command, unlike PersistentPreRunE, which subcommands override
+	cobra.OnInitialize(startProfiling)
+}
+
+// startProfiling starts the CPU profile and trace requested by the flags.
+// Profiling is a diagnostic aid, so failures are reported but not fatal.
+func startProfiling() {
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err == nil {
+			if err = pprof.StartCPUProfile(f); err != nil {
+				f.Close()
+			} else {
+				cpuProfileFile = f
+			}
+		}
+		if err != nil {
+			logger.Warn("Failed to start CPU profile: %v", err)
+		}
+	}
+
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err == nil {
+			if err = trace.Start(f); err != nil {
+				f.Close()
+			} else {
+				traceOutFile = f
+			}
+		}
+		if err != nil {
+			logger.Warn("Failed to start trace: %v", err)
+		}
+	}
+}
+
+// stopProfiling stops the running profiles and writes the heap profile.
+func stopProfiling() {
+	if cpuProfileFile != nil {
+		pprof.StopCPUProfile()
+		cpuProfileFile.Close()
+		cpuProfileFile = nil
+	}
+	if traceOutFile != nil {
+		trace.Stop()
+		traceOutFile.Close()
+		traceOutFile = nil
+	}
+
+	if memProfile != "" {
+		f, err := os.Create(memProfile)
+		if err != nil {
+			logger.Warn("Failed to write heap profile: %v", err)
+			return
+		}
+		defer f.Close()
+		// Collect garbage first, so the profile shows live memory
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			logger.Warn("Failed to write heap profile: %v", err)
+		}
+	}
+}
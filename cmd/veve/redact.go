@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/redact"
+)
+
+// redactInput masks the matches of the --redact patterns in content and
+// returns the result with its report.
+func redactInput(content string, opts conversionOptions) (string, *redact.Report, error) {
+	rules, err := redact.Load(opts.Redact)
+	if err != nil {
+		return "", nil, internal.InputError("convert", "load redaction patterns", err.Error(),
+			"check the patterns in "+opts.Redact, err)
+	}
+	redacted, redactions := rules.Apply(content)
+	logger.Debug("Redacted %d matches of %d patterns", len(redactions), len(rules.Rules))
+	return redacted, redact.NewReport(rules, redactions), nil
+}
+
+// redactionReportPath returns where the report of a conversion to
+// outputFile is written: --redact-report, or next to the output.
+func redactionReportPath(outputFile string, opts conversionOptions) string {
+	if opts.RedactReport != "" || outputFile == "-" {
+		return opts.RedactReport
+	}
+	return redact.ReportPath(outputFile)
+}
+
+// writeRedactionReport writes the report of converting name to outputFile.
+func writeRedactionReport(report *redact.Report, name, outputFile string, opts conversionOptions) error {
+	report.Input, report.Output = name, outputFile
+	if abs, err := filepath.Abs(name); err == nil && name != "-" {
+		report.Input = abs
+	}
+	path := redactionReportPath(outputFile, opts)
+	if err := report.Write(path); err != nil {
+		return err
+	}
+	if !quiet {
+		logger.Info("Redacted %d matches; report written to %s", len(report.Redactions), path)
+	}
+	return nil
+}
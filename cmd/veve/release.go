@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/release"
+	"github.com/spf13/cobra"
+)
+
+const (
+	releaseHomepage    = "https://github.com/madstone-tech/veve-cli"
+	releaseDescription = "Fast, themeable markdown to PDF converter with Pandoc"
+)
+
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Tools for publishing veve releases",
+	Long:  `Tools for maintainers publishing veve releases.`,
+	// Release tooling does not run pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+var releaseManifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Generate package manager metadata for a release",
+	Long: `Generate package manager metadata for a release from its checksums file.
+
+Formats:
+  homebrew  Homebrew formula (needs a macOS archive)
+  scoop     Scoop manifest (needs a Windows archive)
+  deb       Debian control file for --arch (needs the matching Linux archive)
+
+The archives and their SHA-256 digests are read from the checksums file
+goreleaser writes (dist/checksums.txt); download URLs point at the GitHub
+release for --tag unless --download-url is given.
+
+Examples:
+  veve release manifest --format homebrew --tag v0.3.0 -o Formula/veve.rb
+  veve release manifest --format scoop --checksums dist/checksums.txt
+  veve release manifest --format deb --arch arm64 --maintainer "Jane Doe <jane@example.com>"`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		checksumsFile, err := cmd.Flags().GetString("checksums")
+		if err != nil {
+			return err
+		}
+		tag, err := cmd.Flags().GetString("tag")
+		if err != nil {
+			return err
+		}
+		downloadURL, err := cmd.Flags().GetString("download-url")
+		if err != nil {
+			return err
+		}
+		maintainer, err := cmd.Flags().GetString("maintainer")
+		if err != nil {
+			return err
+		}
+		arch, err := cmd.Flags().GetString("arch")
+		if err != nil {
+			return err
+		}
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		if tag == "" {
+			tag = "v" + strings.TrimPrefix(version, "v")
+		}
+		if downloadURL == "" {
+			downloadURL = releaseHomepage + "/releases/download/" + tag
+		}
+
+		f, err := os.Open(checksumsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read checksums: %w", err)
+		}
+		artifacts, err := release.ParseChecksums(f, downloadURL)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", checksumsFile, err)
+		}
+
+		r := &release.Release{
+			Version:     strings.TrimPrefix(tag, "v"),
+			Description: releaseDescription,
+			Homepage:    releaseHomepage,
+			License:     "MIT",
+			Maintainer:  maintainer,
+			Arch:        arch,
+			Artifacts:   artifacts,
+		}
+
+		var w io.Writer = cmd.OutOrStdout()
+		if outputFile != "" {
+			out, err := os.Create(outputFile)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", outputFile, err)
+			}
+			defer out.Close()
+			w = out
+		}
+		return release.Render(w, format, r)
+	},
+}
+
+func init() {
+	var formatNames []string
+	for _, format := range release.Formats() {
+		formatNames = append(formatNames, format.Name)
+	}
+
+	releaseManifestCmd.Flags().String("format", "", "manifest format: "+strings.Join(formatNames, ", ")+" (required)")
+	_ = releaseManifestCmd.MarkFlagRequired("format")
+	releaseManifestCmd.Flags().String("checksums", "dist/checksums.txt", "checksums file listing the release archives")
+	releaseManifestCmd.Flags().String("tag", "", "release tag (default: this binary's version)")
+	releaseManifestCmd.Flags().String("download-url", "", "base URL of the release archives (default: the GitHub release for --tag)")
+	releaseManifestCmd.Flags().String("maintainer", "", "package maintainer, \"Name <email>\" (required for deb)")
+	releaseManifestCmd.Flags().String("arch", "amd64", "Debian architecture for deb: amd64, arm64, or i386")
+	releaseManifestCmd.Flags().StringP("output", "o", "", "write the manifest to a file instead of stdout")
+
+	releaseCmd.AddCommand(releaseManifestCmd)
+}
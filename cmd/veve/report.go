@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// conversionReport is what veve and veve convert print with --json: each
+// output written, with its checksum and signature, and each input that
+// failed.
+type conversionReport struct {
+	Conversions []conversionResult `json:"conversions"`
+	Merged      *conversionResult  `json:"merged,omitempty"` // The --merge file, once written
+}
+
+// conversionResult is an output written from an input, or the error that
+// stopped the input converting.
+type conversionResult struct {
+	Input        string `json:"input,omitempty"` // Every conversion's, but not the --merge file's
+	Output       string `json:"output,omitempty"`
+	Checksum     string `json:"checksum,omitempty"`      // With --checksum: "<algorithm>:<hex>"
+	ChecksumFile string `json:"checksum_file,omitempty"` // With --checksum: the sidecar file
+	Signature    string `json:"signature,omitempty"`     // With --sign: the detached signature
+	Signer       string `json:"signer,omitempty"`        // With --sign: the tool that signed it
+	Error        string `json:"error,omitempty"`
+}
+
+// inputResults collects the results of converting one input: one per
+// output, so several for a --data mail merge.
+type inputResults struct {
+	results []conversionResult
+}
+
+// add records a result, unless no report is being collected.
+func (r *inputResults) add(result conversionResult) {
+	if r != nil {
+		r.results = append(r.results, result)
+	}
+}
+
+// addReportFlag registers --json on cmd.
+func addReportFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("json", false, "print a JSON report of the outputs written, with their checksums and signatures, instead of progress messages")
+}
+
+// writeConversionReport writes report to w as indented JSON.
+func writeConversionReport(w io.Writer, report conversionReport) error {
+	if report.Conversions == nil {
+		report.Conversions = []conversionResult{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -1,6 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/completion"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/engines"
 	"github.com/spf13/cobra"
 )
 
@@ -11,45 +22,202 @@ func init() {
 	rootCmd.AddCommand(convertCmd)
 	rootCmd.AddCommand(themeCmd)
 	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(releaseCmd)
+	rootCmd.AddCommand(engineCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(bookCmd)
+	rootCmd.AddCommand(changelogCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(newCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(cacheCmd)
+
+	completionCmd.AddCommand(completionInstallCmd)
 }
 
 // completionCmd provides shell completion generation
 var completionCmd = &cobra.Command{
-	Use:   "completion [bash|zsh|fish]",
+	Use:   "completion [bash|zsh|fish|powershell]",
 	Short: "Generate shell completion scripts",
 	Long: `Generate shell completion scripts for veve.
 
-To load completions:
+To install completions for your shell, run once:
+  veve completion install
+
+To load completions into the current session only:
 
 Bash:
   source <(veve completion bash)
 
-  # To load completions for each session, execute once:
-  veve completion bash > /usr/local/etc/bash_completion.d/veve
-
 Zsh:
   source <(veve completion zsh)
 
-  # To load completions for each session, execute once:
-  veve completion zsh > "${fpath[1]}/_veve"
-
 Fish:
   veve completion fish | source
 
-  # To load completions for each session, execute once:
-  veve completion fish > ~/.config/fish/completions/veve.fish
+PowerShell:
+  veve completion powershell | Out-String | Invoke-Expression
 `,
-	ValidArgs: []string{"bash", "zsh", "fish"},
+	ValidArgs: completion.Shells,
 	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	// Completion scripts don't need pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		switch args[0] {
-		case "bash":
-			return rootCmd.GenBashCompletion(cmd.OutOrStdout())
-		case "zsh":
-			return rootCmd.GenZshCompletion(cmd.OutOrStdout())
-		case "fish":
-			return rootCmd.GenFishCompletion(cmd.OutOrStdout(), true)
+		return writeCompletionScript(cmd.OutOrStdout(), args[0])
+	},
+}
+
+// completionInstallCmd installs the completion script for the user's shell
+var completionInstallCmd = &cobra.Command{
+	Use:   "install [bash|zsh|fish|powershell]",
+	Short: "Install the completion script for your shell",
+	Long: `Install the completion script for your shell, detected from $SHELL
+(PowerShell on Windows) unless named.
+
+  bash        ~/.local/share/bash-completion/completions/veve, also sourced
+              from ~/.bashrc (~/.bash_profile on macOS)
+  zsh         ~/.zsh/completions/_veve, added to fpath in ~/.zshrc
+  fish        ~/.config/fish/completions/veve.fish
+  powershell  veve.ps1 beside your PowerShell 7 profile, loaded from it
+
+Running it again replaces the script, e.g. after upgrading veve; startup
+files are only changed the first time. Open a new shell to use completions.
+
+Examples:
+  veve completion install
+  veve completion install zsh`,
+	ValidArgs: completion.Shells,
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := completion.CurrentEnvironment()
+		if err != nil {
+			return err
+		}
+		var shell string
+		if len(args) == 1 {
+			shell = args[0]
+		} else if shell, err = completion.DetectShell(env); err != nil {
+			return internal.WithKind(err, internal.KindUsage)
+		}
+		location, err := completion.Locate(shell, env)
+		if err != nil {
+			return err
+		}
+
+		var script bytes.Buffer
+		if err := writeCompletionScript(&script, shell); err != nil {
+			return err
+		}
+		profileChanged, err := completion.Install(location, script.Bytes())
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Installed %s completion to %s\n", shell, location.Script)
+		if profileChanged {
+			fmt.Fprintf(out, "Added it to %s\n", location.Profile)
 		}
+		fmt.Fprintln(out, "Open a new shell to use it")
 		return nil
 	},
 }
+
+// writeCompletionScript writes the completion script for shell to w.
+func writeCompletionScript(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return rootCmd.GenBashCompletion(w)
+	case "zsh":
+		return rootCmd.GenZshCompletion(w)
+	case "fish":
+		return rootCmd.GenFishCompletion(w, true)
+	case "powershell":
+		return rootCmd.GenPowerShellCompletionWithDesc(w)
+	}
+	return fmt.Errorf("no completion for shell %q", shell)
+}
+
+// completeFiles completes up to maxArgs positional arguments (any number,
+// if negative) with the files that have one of extensions, and directories
+// to find them in.
+func completeFiles(maxArgs int, extensions ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if maxArgs >= 0 && len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		files := completion.Files(toComplete, extensions)
+		directive := cobra.ShellCompDirectiveNoFileComp
+		// Keep completing inside a directory rather than after it
+		if len(files) == 1 && strings.HasSuffix(files[0], string(filepath.Separator)) {
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+		return files, directive
+	}
+}
+
+// completeMarkdownFiles completes up to maxArgs markdown inputs.
+func completeMarkdownFiles(maxArgs int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return completeFiles(maxArgs, completion.MarkdownExtensions...)
+}
+
+// completeEngines completes --engine with the PDF engines, described by
+// whether they are installed.
+func completeEngines(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, name := range append(completion.EngineFlag().AcceptedValues, "docker") {
+		if !strings.HasPrefix(name, toComplete) {
+			continue
+		}
+		var err error
+		if name == "docker" {
+			_, err = exec.LookPath("docker")
+		} else {
+			_, err = engines.LookupEngine(name)
+		}
+		names = append(names, name+"\t"+completion.NewEngineCompletion(name, err == nil).AdditionalInfo)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeThemes completes --theme with the built-in, user, and project
+// themes, and with CSS files once toComplete looks like a path.
+func completeThemes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	if paths, err := config.GetPaths(); err == nil {
+		if loader, err := newThemeLoader(paths, "."); err == nil && loader.DiscoverThemes() == nil {
+			for _, t := range loader.ListThemes() {
+				if strings.HasPrefix(t.Name, toComplete) {
+					names = append(names, t.Name+"\t"+t.Description)
+				}
+			}
+		}
+	}
+
+	directive := cobra.ShellCompDirectiveNoFileComp
+	if strings.HasPrefix(toComplete, ".") || strings.ContainsAny(toComplete, `/\`) {
+		files := completion.Files(toComplete, []string{".css"})
+		if len(names) == 0 && len(files) == 1 && strings.HasSuffix(files[0], string(filepath.Separator)) {
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+		names = append(names, files...)
+	}
+	return names, directive
+}
+
+// completeThemeNames completes the theme name argument of a theme
+// subcommand, which takes up to maxArgs (any number, if negative).
+func completeThemeNames(maxArgs int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if maxArgs >= 0 && len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeThemes(cmd, args, toComplete)
+	}
+}
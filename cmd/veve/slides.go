@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/logging"
+)
+
+// Output formats for --format
+const (
+	formatPDF    = "pdf"
+	formatSlides = "slides"
+)
+
+// slideEngine returns the LaTeX engine to render slides with and its path:
+// the requested one, or the first installed of converter.SlideEngines.
+func slideEngine(requested string) (string, string, error) {
+	candidates := converter.SlideEngines
+	if requested != "" {
+		if !engines.IsLaTeXEngine(requested) {
+			return "", "", fmt.Errorf("--format slides needs a LaTeX engine (%s), not %s",
+				strings.Join(converter.SlideEngines, ", "), requested)
+		}
+		candidates = []string{requested}
+	}
+	for _, name := range candidates {
+		if path, err := engines.LookupEngine(name); err == nil {
+			return name, path, nil
+		}
+	}
+	return "", "", internal.EngineError("convert", "select engine",
+		"slides need "+strings.Join(candidates, ", or ")+", which is not installed",
+		"install a TeX distribution such as TeX Live or MiKTeX", nil)
+}
+
+// convertSlides renders the processed markdown as a beamer slide deck,
+// styled with the theme's colors and fonts, and returns the engine used.
+func convertSlides(processedInputFile, outputFile string, loaded *resolvedTheme, variables map[string]string, resourcePath []string, opts conversionOptions) (string, error) {
+	engine, path, err := slideEngine(opts.PDFEngine)
+	if err != nil {
+		return "", err
+	}
+	logger.Debug("Rendering slides with %s", engine)
+
+	pandoc, err := converter.NewPandocConverter()
+	if err != nil {
+		return "", internal.PandocNotFound()
+	}
+	slidesOpts := converter.SlidesOptions{
+		InputFile:     processedInputFile,
+		OutputFile:    outputFile,
+		PDFEngine:     engine,
+		PDFEnginePath: path,
+		ThemeCSS:      loaded.CSS + opts.ChapterCSS,
+		BeamerTheme:   loaded.BeamerTheme,
+		ResourcePath:  resourcePath,
+		Variables:     variables,
+		Limits:        opts.limits(),
+		MemoryBudget:  converter.MemoryBudget(opts.MaxMemory),
+		Handout:       opts.Handout,
+		NoSmart:       opts.NoSmart,
+	}
+	err = pandoc.ConvertSlides(slidesOpts)
+	if opts.RetryLaTeX {
+		err = converter.RetryLaTeX(err, engine, func(tempDir string) error {
+			slidesOpts.TempDir = tempDir
+			return pandoc.ConvertSlides(slidesOpts)
+		}, func(category logging.Category, msg string) { logger.WarnIn(category, "%s", msg) })
+	}
+	return engine, err
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show conversion metrics recorded on this machine",
+	Long: `Show conversion metrics recorded on this machine.
+
+When metrics are enabled, veve records each conversion (its duration, engine,
+input size, and image counts) in a log in your data directory. The log never
+leaves your machine. Enable it in veve.toml:
+
+  metrics = true
+
+Without flags, veve reports whether metrics are enabled and where the log is.
+Use --history to summarize the log, by day or by engine.
+
+Examples:
+  veve stats
+  veve stats --history
+  veve stats --history --by engine
+  veve stats --history --json`,
+	Args: cobra.NoArgs,
+	// Reading the metrics log doesn't need pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		history, err := cmd.Flags().GetBool("history")
+		if err != nil {
+			return err
+		}
+		by, err := cmd.Flags().GetString("by")
+		if err != nil {
+			return err
+		}
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		var group func(metrics.Record) string
+		switch by {
+		case "day":
+			group = metrics.ByDay
+		case "engine":
+			group = metrics.ByEngine
+		default:
+			return fmt.Errorf("invalid --by %q: use \"day\" or \"engine\"", by)
+		}
+
+		paths, err := config.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get config paths: %w", err)
+		}
+		logFile := filepath.Join(paths.DataDir, metrics.FileName)
+		records, err := metrics.Read(logFile)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if !history {
+			state := "disabled (set metrics = true in " + paths.ConfigFile + " to enable)"
+			if metricsEnabled(paths) {
+				state = "enabled"
+			}
+			fmt.Fprintf(out, "Metrics: %s\n", state)
+			fmt.Fprintf(out, "Log:     %s (%d conversions)\n", logFile, len(records))
+			return nil
+		}
+
+		summaries := metrics.Summarize(records, group)
+		if asJSON {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(summaries)
+		}
+		if len(summaries) == 0 {
+			fmt.Fprintf(out, "No conversions recorded in %s\n", logFile)
+			return nil
+		}
+		writeStats(out, by, summaries)
+		return nil
+	},
+}
+
+func init() {
+	statsCmd.Flags().Bool("history", false, "summarize the recorded conversions")
+	statsCmd.Flags().String("by", "day", "group the history by \"day\" or \"engine\"")
+	statsCmd.Flags().Bool("json", false, "print the history as JSON")
+}
+
+// writeStats prints the history summaries as a table.
+func writeStats(out io.Writer, by string, summaries []metrics.Summary) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := "DATE"
+	if by == "engine" {
+		header = "ENGINE"
+	}
+	fmt.Fprintf(w, "%s\tCONVERSIONS\tFAILED\tMEDIAN\tP95\tINPUT\tIMAGES\n", header)
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%d\n", s.Group, s.Conversions, s.Failed,
+			formatStatsDuration(s.Median), formatStatsDuration(s.P95), formatBytes(s.InputBytes), s.Images)
+	}
+	w.Flush()
+}
+
+// formatStatsDuration formats a summary time, or "-" if there were no
+// successful conversions.
+func formatStatsDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// metricsEnabled reports whether the user opted in to the metrics log.
+func metricsEnabled(paths config.Paths) bool {
+	cfg, err := config.LoadConfig(paths.ConfigFile)
+	if err != nil {
+		logger.Debug("Warning: Failed to read %s: %v", paths.ConfigFile, err)
+		return false
+	}
+	return cfg.Metrics
+}
+
+// recordConversion appends a finished conversion to the metrics log. The log
+// is a convenience, so failures to write it don't fail the conversion.
+func recordConversion(paths config.Paths, record *metrics.Record, err error) {
+	record.Duration = time.Since(record.Time)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if err := metrics.Append(filepath.Join(paths.DataDir, metrics.FileName), *record); err != nil {
+		logger.Debug("Warning: Failed to record metrics: %v", err)
+	}
+}
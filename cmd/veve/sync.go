@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/assets"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Download and verify the themes and filters pinned in .veve.yaml",
+	Long: `Download the themes and filters a project pins in .veve.yaml and verify each
+against its SHA-256 checksum, so a fresh checkout or CI runner gets exactly
+the same files.
+
+Themes are installed into the project themes directory (.veve/themes by
+default) under their name; a theme URL may point at a CSS file, a .vevetheme
+package, or a zip archive (use file to choose a theme in an archive holding
+several). Filters, such as transformer scripts and plugins, are saved at their
+path in the project.
+
+  themes:
+    - name: brand
+      url: https://example.com/brand-2.1.vevetheme
+      sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+  filters:
+    - path: scripts/toc.sh
+      url: https://example.com/toc.sh
+      sha256: 60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752
+      executable: true
+
+A pin without a sha256 fails and reports the checksum of what was downloaded,
+to copy into .veve.yaml once the file has been reviewed. Verified downloads are
+cached by checksum, so later syncs don't download them again. Credentials and
+the download limit are those of 'veve theme add'.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	// Syncing doesn't need pandoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := config.FindProject(".")
+		if err != nil {
+			return err
+		}
+		if project == nil || project.ConfigFile == "" {
+			return internal.ConfigError("sync", "sync project assets",
+				fmt.Sprintf("no %s found in this directory or its parents", config.ProjectConfigFile), "", nil)
+		}
+		if len(project.Themes) == 0 && len(project.Filters) == 0 {
+			fmt.Printf("No themes or filters pinned in %s\n", project.ConfigFile)
+			return nil
+		}
+
+		paths, err := config.GetPaths()
+		if err != nil {
+			return err
+		}
+		downloader, err := newThemeDownloader(paths)
+		if err != nil {
+			return err
+		}
+		fetcher := &assets.Fetcher{
+			Download: downloader.Fetch,
+			CacheDir: filepath.Join(paths.CacheDir, assets.CacheDirName),
+		}
+
+		failed := 0
+		for _, pin := range project.Themes {
+			if err := syncTheme(project, fetcher, pin); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				failed++
+			}
+		}
+		for _, pin := range project.Filters {
+			if err := syncFilter(project, fetcher, pin); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return internal.NetworkError("sync", "sync project assets",
+				fmt.Sprintf("%d of %d pinned asset(s) failed", failed, len(project.Themes)+len(project.Filters)),
+				fmt.Sprintf("Check the url and sha256 of each pin in %s", project.ConfigFile), nil)
+		}
+		return nil
+	},
+}
+
+// syncTheme fetches a pinned theme and installs it into the project themes directory.
+func syncTheme(project *config.ProjectConfig, fetcher *assets.Fetcher, pin config.PinnedTheme) error {
+	if pin.Name == "" || pin.URL == "" {
+		return fmt.Errorf("%s: every theme needs a name and url", project.ConfigFile)
+	}
+	if err := theme.ValidateThemeName(pin.Name); err != nil {
+		return fmt.Errorf("theme '%s': %w", pin.Name, err)
+	}
+
+	data, err := fetcher.Fetch(pin.URL, pin.SHA256)
+	if err != nil {
+		return fmt.Errorf("theme '%s': %w", pin.Name, err)
+	}
+
+	themesDir := project.ThemesPath()
+	switch ext := urlExt(pin.URL); {
+	case ext == theme.PackageExtension:
+		return installThemePackage(themesDir, pin.Name, data)
+	case theme.IsArchive(ext):
+		downloaded, err := theme.ArchiveTheme(data, pin.File)
+		if err != nil {
+			return fmt.Errorf("theme '%s': %w", pin.Name, err)
+		}
+		if downloaded.Package != nil {
+			return installThemePackage(themesDir, pin.Name, downloaded.Package)
+		}
+		return installThemeCSS(themesDir, pin.Name, downloaded.CSS)
+	default:
+		if err := theme.ValidateCSS(string(data)); err != nil {
+			return fmt.Errorf("theme '%s' doesn't appear to be valid CSS: %w", pin.Name, err)
+		}
+		return installThemeCSS(themesDir, pin.Name, string(data))
+	}
+}
+
+// syncFilter fetches a pinned filter and saves it in the project, unless the
+// file there already matches the pin.
+func syncFilter(project *config.ProjectConfig, fetcher *assets.Fetcher, pin config.PinnedFilter) error {
+	if pin.Path == "" || pin.URL == "" {
+		return fmt.Errorf("%s: every filter needs a path and url", project.ConfigFile)
+	}
+	rel := filepath.FromSlash(pin.Path)
+	if !filepath.IsLocal(rel) {
+		return fmt.Errorf("filter %s: path must stay inside the project", pin.Path)
+	}
+	target := filepath.Join(project.Root, rel)
+
+	perm := os.FileMode(0o644)
+	if pin.Executable {
+		perm = 0o755
+	}
+
+	if existing, err := os.ReadFile(target); err == nil && assets.Verify(pin.URL, existing, pin.SHA256) == nil {
+		fmt.Printf("Filter %s is up to date\n", pin.Path)
+		return os.Chmod(target, perm)
+	}
+
+	data, err := fetcher.Fetch(pin.URL, pin.SHA256)
+	if err != nil {
+		return fmt.Errorf("filter %s: %w", pin.Path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create filter directory: %w", err)
+	}
+	if err := os.WriteFile(target, data, perm); err != nil {
+		return fmt.Errorf("failed to save filter %s: %w", pin.Path, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(target, perm); err != nil {
+		return err
+	}
+
+	fmt.Printf("Filter %s saved at %s\n", pin.Path, target)
+	return nil
+}
+
+// urlExt returns the lowercased extension of a URL's path, ignoring its query.
+func urlExt(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(path.Ext(u.Path))
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/filelock"
 	"github.com/madstone-tech/veve-cli/internal/theme"
+	"github.com/madstone-tech/veve-cli/themes"
 	"github.com/spf13/cobra"
 )
 
@@ -28,8 +38,11 @@ var themeListCmd = &cobra.Command{
 			return fmt.Errorf("failed to get config paths: %w", err)
 		}
 
-		// Create and initialize theme loader
-		loader := theme.NewLoader(paths.ThemesDir)
+		// Create and initialize theme loader, including the current project's themes
+		loader, err := newThemeLoader(paths, ".")
+		if err != nil {
+			return err
+		}
 		if err := loader.DiscoverThemes(); err != nil {
 			return fmt.Errorf("failed to discover themes: %w", err)
 		}
@@ -46,6 +59,8 @@ var themeListCmd = &cobra.Command{
 			themeType := "user"
 			if t.IsBuiltIn {
 				themeType = "built-in"
+			} else if t.IsProject {
+				themeType = "project"
 			}
 			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Name, t.Author, t.Description, themeType)
 		}
@@ -58,14 +73,15 @@ var themeListCmd = &cobra.Command{
 var themeAddCmd = &cobra.Command{
 	Use:   "add [name] [path]",
 	Short: "Add a custom theme",
-	Long:  `Install a custom theme from a CSS file or zip archive.`,
-	Args:  cobra.ExactArgs(2),
+	Long: `Install a custom theme from a CSS file, zip archive, or .vevetheme package.
+Names may be namespaced with slashes (e.g. company/brand) to organize themes in subdirectories.`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		themeName := args[0]
 		source := args[1]
 
-		if themeName == "" {
-			return fmt.Errorf("theme name cannot be empty")
+		if err := theme.ValidateThemeName(themeName); err != nil {
+			return err
 		}
 
 		// Get XDG paths
@@ -79,57 +95,253 @@ var themeAddCmd = &cobra.Command{
 			return fmt.Errorf("failed to create themes directory: %w", err)
 		}
 
-		// Download the theme
-		downloader := theme.NewDownloader()
-		css, err := downloader.Download(source)
+		// Private theme hosts need the credentials from veve.toml or the environment
+		downloader, err := newThemeDownloader(paths)
+		if err != nil {
+			return err
+		}
+
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			return err
+		}
+		return installTheme(paths, downloader, themeName, source, file)
+	},
+}
+
+// installTheme installs the theme at source (a path, URL, or github:
+// source) as themeName and records the source for 'veve theme update'. file
+// selects a theme in a zip archive or repository; if it's empty and there
+// are several, the user picks one when run interactively.
+func installTheme(paths config.Paths, downloader *theme.Downloader, themeName, source, file string) error {
+	downloaded, err := downloadTheme(downloader, source, file)
+	var ambiguous *theme.AmbiguousThemeError
+	if errors.As(err, &ambiguous) && file == "" && stdinIsTerminal() {
+		if file = chooseTheme(ambiguous.Choices); file != "" {
+			downloaded, err = downloadTheme(downloader, source, file)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download theme '%s': %w", themeName, err)
+	}
+
+	if downloaded.Package != nil {
+		err = installThemePackage(paths.ThemesDir, themeName, downloaded.Package)
+	} else {
+		err = installThemeCSS(paths.ThemesDir, themeName, downloaded.CSS)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Local themes are updated from the same file wherever update runs
+	if !strings.Contains(source, "://") && !theme.IsGitHubSource(source) && !strings.HasPrefix(source, "~") {
+		if abs, err := filepath.Abs(source); err == nil {
+			source = abs
+		}
+	}
+
+	sources, err := theme.LoadSources(paths.ThemesDir)
+	if err != nil {
+		return err
+	}
+	sources[themeName] = theme.Source{Source: source, File: file, Commit: downloaded.Commit, Installed: time.Now().UTC()}
+	return sources.Save(paths.ThemesDir)
+}
+
+// downloadTheme downloads the theme at source; file selects a theme in a
+// zip archive or (relative to the source's path) a GitHub repository.
+func downloadTheme(downloader *theme.Downloader, source, file string) (*theme.DownloadedTheme, error) {
+	switch {
+	case theme.IsGitHubSource(source):
+		src, err := theme.ParseGitHubSource(source)
 		if err != nil {
-			return fmt.Errorf("failed to download theme '%s': %w", themeName, err)
+			return nil, err
+		}
+		if file != "" {
+			src.Path = strings.TrimPrefix(path.Join(src.Path, file), "/")
 		}
+		return downloader.DownloadGitHub(src)
 
-		// Save theme to file
-		themeFilePath := filepath.Join(paths.ThemesDir, themeName+".css")
+	case theme.IsArchive(source):
+		return downloader.DownloadArchive(source, file)
 
-		// Parse metadata from the CSS if present
-		metadata, _, err := theme.ParseMetadata(css)
+	case file != "":
+		return nil, fmt.Errorf("--file only applies to zip archives and github: sources")
+
+	// Theme packages bundle CSS, templates, fonts, and assets in a directory
+	case theme.IsPackage(source):
+		data, err := downloader.DownloadPackage(source)
 		if err != nil {
-			metadata = &theme.ThemeMetadata{}
+			return nil, err
 		}
-		if metadata == nil {
-			metadata = &theme.ThemeMetadata{}
+		return &theme.DownloadedTheme{Package: data}, nil
+
+	default:
+		css, err := downloader.Download(source)
+		if err != nil {
+			return nil, err
 		}
+		return &theme.DownloadedTheme{CSS: css}, nil
+	}
+}
+
+// chooseTheme asks the user to pick one of the themes found in an archive,
+// returning "" if they don't.
+func chooseTheme(choices []string) string {
+	fmt.Println("Found several themes:")
+	for i, choice := range choices {
+		fmt.Printf("  %d) %s\n", i+1, choice)
+	}
+	fmt.Printf("Install which? (1-%d) ", len(choices))
 
-		// Apply defaults
-		theme.ApplyMetadataDefaults(metadata, themeName)
+	var response string
+	if _, err := fmt.Scanln(&response); err != nil {
+		fmt.Println()
+		return ""
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(response))
+	if err != nil || n < 1 || n > len(choices) {
+		return ""
+	}
+	return choices[n-1]
+}
 
-		// Reconstruct CSS with metadata if we have it
-		cssToSave := css
-		if metadata.Name != "" {
-			// Rebuild with metadata
-			metadataBlock := fmt.Sprintf(`---
+// stdinIsTerminal reports whether stdin is a terminal, so the user can be asked.
+func stdinIsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// installThemePackage installs a theme package archive as themeName in themesDir.
+func installThemePackage(themesDir, themeName string, data []byte) error {
+	packageDir := theme.NewLoader(themesDir).PackageDir(themeName)
+	manifest, err := theme.InstallPackage(data, packageDir)
+	if err != nil {
+		return fmt.Errorf("failed to install theme '%s': %w", themeName, err)
+	}
+
+	fmt.Printf("Theme '%s' (%s %s) installed successfully at %s\n", themeName, manifest.Name, manifest.Version, packageDir)
+	return nil
+}
+
+// installThemeCSS installs a theme stylesheet as themeName in themesDir.
+func installThemeCSS(themesDir, themeName, css string) error {
+	// Save theme to file; namespaced names (company/brand) go into subdirectories
+	themeFilePath := theme.NewLoader(themesDir).ThemeFilePath(themeName)
+	if err := os.MkdirAll(filepath.Dir(themeFilePath), 0o755); err != nil {
+		return fmt.Errorf("failed to create theme directory: %w", err)
+	}
+
+	// Parse metadata from the CSS if present
+	metadata, body, err := theme.ParseMetadata(css)
+	if err != nil {
+		metadata, body = &theme.ThemeMetadata{}, css
+	}
+	if metadata == nil {
+		metadata = &theme.ThemeMetadata{}
+	}
+
+	// Apply defaults
+	theme.ApplyMetadataDefaults(metadata, themeName)
+
+	// Reconstruct CSS with metadata if we have it
+	cssToSave := css
+	if metadata.Name != "" {
+		// Rebuild with metadata
+		engines := ""
+		if len(metadata.Engines) > 0 {
+			engines = fmt.Sprintf("engines: [%s]\n", strings.Join(metadata.Engines, ", "))
+		}
+		metadataBlock := fmt.Sprintf(`---
 name: %s
 author: %s
 description: %s
 version: %s
----
-`, metadata.Name, metadata.Author, metadata.Description, metadata.Version)
-			cssToSave = metadataBlock + "\n" + css
+%s---
+`, metadata.Name, metadata.Author, metadata.Description, metadata.Version, engines)
+		cssToSave = metadataBlock + "\n" + body
+	}
+
+	// Write theme file; conversions running meanwhile never read a partial theme
+	if err := filelock.WriteFile(themeFilePath, []byte(cssToSave), 0o644); err != nil {
+		return fmt.Errorf("failed to save theme: %w", err)
+	}
+
+	fmt.Printf("Theme '%s' installed successfully at %s\n", themeName, themeFilePath)
+	return nil
+}
+
+var themeUpdateCmd = &cobra.Command{
+	Use:   "update [name...]",
+	Short: "Install themes again from their sources",
+	Long: `Install themes again from the sources they were added from, picking up new
+versions: the latest commit of a github: source's branch, or the current
+content of a URL or file. Without names, every theme with a recorded source is
+updated.`,
+	ValidArgsFunction: completeThemeNames(-1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := config.GetPaths()
+		if err != nil {
+			return fmt.Errorf("failed to get config paths: %w", err)
+		}
+		sources, err := theme.LoadSources(paths.ThemesDir)
+		if err != nil {
+			return err
 		}
 
-		// Write theme file
-		if err := os.WriteFile(themeFilePath, []byte(cssToSave), 0o644); err != nil {
-			return fmt.Errorf("failed to save theme: %w", err)
+		names := args
+		if len(names) == 0 {
+			for name := range sources {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if len(names) == 0 {
+				fmt.Println("No themes were installed with 'veve theme add'; nothing to update.")
+				return nil
+			}
 		}
 
-		fmt.Printf("Theme '%s' installed successfully at %s\n", themeName, themeFilePath)
+		downloader, err := newThemeDownloader(paths)
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, name := range names {
+			previous, ok := sources[name]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Theme '%s' has no recorded source; install it again with 'veve theme add'\n", name)
+				failed++
+				continue
+			}
+			if err := installTheme(paths, downloader, name, previous.Source, previous.File); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to update theme '%s': %v\n", name, err)
+				failed++
+				continue
+			}
+			if previous.Commit != "" {
+				updated, _ := theme.LoadSources(paths.ThemesDir)
+				if commit := updated[name].Commit; commit == previous.Commit {
+					fmt.Printf("Theme '%s' is up to date (%s)\n", name, commit)
+				} else {
+					fmt.Printf("Theme '%s' updated from %s to %s\n", name, previous.Commit, commit)
+				}
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d themes could not be updated", failed, len(names))
+		}
 		return nil
 	},
 }
 
 var themeRemoveCmd = &cobra.Command{
-	Use:   "remove [name]",
-	Short: "Remove a custom theme",
-	Long:  `Uninstall a custom theme.`,
-	Args:  cobra.ExactArgs(1),
+	Use:               "remove [name]",
+	Short:             "Remove a custom theme",
+	Long:              `Uninstall a custom theme.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeThemeNames(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		themeName := args[0]
 
@@ -173,19 +385,224 @@ var themeRemoveCmd = &cobra.Command{
 			}
 		}
 
-		// Delete the theme file
-		if err := os.Remove(t.FilePath); err != nil {
+		// Delete the theme file, or the whole directory of a theme package
+		removePath := t.FilePath
+		if t.PackageDir != "" {
+			removePath = t.PackageDir
+		}
+		if err := os.RemoveAll(removePath); err != nil {
 			return fmt.Errorf("failed to remove theme file: %w", err)
 		}
+		if sources, err := theme.LoadSources(paths.ThemesDir); err == nil {
+			if _, recorded := sources[themeName]; recorded {
+				delete(sources, themeName)
+				if err := sources.Save(paths.ThemesDir); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Clean up namespace directories left empty (os.Remove fails on non-empty ones)
+		themesDir := filepath.Clean(paths.ThemesDir)
+		for dir := filepath.Dir(removePath); strings.HasPrefix(dir, themesDir+string(filepath.Separator)); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
 
 		fmt.Printf("Theme '%s' removed successfully.\n", themeName)
 		return nil
 	},
 }
 
+var themeValidateCmd = &cobra.Command{
+	Use:   "validate [name|path]",
+	Short: "Validate a theme's CSS",
+	Long: `Parse a theme and report CSS syntax errors and unknown at-rules with line and column.
+With --engine, also report properties that engine cannot render.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeThemeNames(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		themeName := args[0]
+
+		engine, err := cmd.Flags().GetString("engine")
+		if err != nil {
+			return err
+		}
+
+		source, content, err := readThemeSource(themeName)
+		if err != nil {
+			return err
+		}
+
+		issues := theme.CheckTheme(content, engine)
+
+		// LaTeX engines only apply the declarations veve can translate
+		if engines.IsLaTeXEngine(engine) {
+			settings := converter.TranslateCSSToLaTeX(theme.BlankFrontMatter(content), converter.SystemFontAvailable)
+			for _, ignored := range settings.Ignored {
+				issues = append(issues, theme.Issue{
+					Severity: theme.SeverityWarning,
+					Line:     ignored.Line,
+					Column:   ignored.Column,
+					Message:  fmt.Sprintf("%s is not supported by %s", ignored, engine),
+				})
+			}
+			theme.SortIssues(issues)
+		}
+
+		errorCount := 0
+		for _, issue := range issues {
+			if issue.Severity == theme.SeverityError {
+				errorCount++
+			}
+			fmt.Printf("%s:%s\n", source, issue)
+		}
+
+		if errorCount > 0 {
+			return fmt.Errorf("theme '%s' has %d error(s)", themeName, errorCount)
+		}
+		if len(issues) == 0 {
+			fmt.Printf("Theme '%s' is valid.\n", themeName)
+		} else {
+			fmt.Printf("Theme '%s' is valid with %d warning(s).\n", themeName, len(issues))
+		}
+		return nil
+	},
+}
+
+var themePackCmd = &cobra.Command{
+	Use:   "pack [dir]",
+	Short: "Create a .vevetheme package",
+	Long: `Package a theme directory into a portable .vevetheme archive.
+The directory must contain a manifest.json naming the stylesheet, and may include
+a LaTeX template, fonts, and assets. Install the result with 'veve theme add'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := args[0]
+
+		outputPath, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		if outputPath == "" {
+			manifest, err := theme.ReadManifest(dir)
+			if err != nil {
+				return err
+			}
+			outputPath = strings.ReplaceAll(manifest.Name, "/", "-") + theme.PackageExtension
+		}
+
+		manifest, err := theme.Pack(dir, outputPath)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Packed theme '%s' into %s\n", manifest.Name, outputPath)
+		return nil
+	},
+}
+
+// readThemeSource returns a display name and the raw content (including front matter)
+// of a theme given by name or path.
+func readThemeSource(themeName string) (string, string, error) {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get config paths: %w", err)
+	}
+
+	loader, err := newThemeLoader(paths, ".")
+	if err != nil {
+		return "", "", err
+	}
+	if err := loader.DiscoverThemes(); err != nil {
+		return "", "", fmt.Errorf("failed to discover themes: %w", err)
+	}
+
+	if loader.IsThemePath(themeName) {
+		content, err := os.ReadFile(themeName)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read theme file: %w", err)
+		}
+		return themeName, string(content), nil
+	}
+
+	t, err := loader.LoadTheme(themeName)
+	if err != nil {
+		return "", "", err
+	}
+	if t.IsBuiltIn {
+		css, _ := themes.GetBuiltInTheme(themeName)
+		return themeName + ".css", css, nil
+	}
+
+	content, err := os.ReadFile(t.FilePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read theme file: %w", err)
+	}
+	return t.FilePath, string(content), nil
+}
+
+// newThemeLoader creates a theme loader for the user themes directory and, when
+// startDir is inside a project (.veve.yaml or .veve/), the project's themes directory.
+// Parsed theme metadata is cached in the cache directory.
+func newThemeLoader(paths config.Paths, startDir string) (*theme.Loader, error) {
+	loader := theme.NewLoader(paths.ThemesDir)
+	loader.SetIndexFile(filepath.Join(paths.CacheDir, "theme-index.json"))
+
+	project, err := config.FindProject(startDir)
+	if err != nil {
+		return nil, err
+	}
+	if project != nil {
+		loader.SetProjectThemesDir(project.ThemesPath())
+	}
+	return loader, nil
+}
+
 func init() {
+	themePackCmd.Flags().StringP("output", "o", "", "package file to create (default: <name>.vevetheme)")
+	themeValidateCmd.Flags().StringP("engine", "e", "", "also check properties against this PDF engine")
+	themeValidateCmd.RegisterFlagCompletionFunc("engine", completeEngines)
+	themeAddCmd.Flags().String("file", "", "theme to install from a zip archive or github: source holding several: a CSS file, .vevetheme package, or package directory")
 	themeRemoveCmd.Flags().BoolP("force", "f", false, "skip confirmation prompt")
 	themeCmd.AddCommand(themeListCmd)
 	themeCmd.AddCommand(themeAddCmd)
 	themeCmd.AddCommand(themeRemoveCmd)
+	themeCmd.AddCommand(themeUpdateCmd)
+	themeCmd.AddCommand(themeValidateCmd)
+	themeCmd.AddCommand(themePackCmd)
+}
+
+// newThemeDownloader returns a downloader with the settings in veve.toml:
+// credentials (theme_sources, then the environment) and the size limit.
+func newThemeDownloader(paths config.Paths) (*theme.Downloader, error) {
+	cfg, err := config.LoadConfig(paths.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", paths.ConfigFile, err)
+	}
+
+	var creds []theme.Credential
+	for _, source := range cfg.ThemeSources {
+		creds = append(creds, theme.Credential{
+			Host:     source.Host,
+			Username: source.Username,
+			Password: source.Password,
+			Token:    source.Token,
+		})
+	}
+	envCreds, err := theme.CredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	downloader := theme.NewDownloader().WithCredentials(append(creds, envCreds...))
+
+	if cfg.ThemeDownloadLimit != "" {
+		limit, err := converter.ParseMemorySize(cfg.ThemeDownloadLimit)
+		if err != nil || limit == 0 {
+			return nil, fmt.Errorf("invalid theme_download_limit %q in %s: use a size such as 200M", cfg.ThemeDownloadLimit, paths.ConfigFile)
+		}
+		downloader.WithMaxSize(limit)
+	}
+	return downloader, nil
 }
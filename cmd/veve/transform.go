@@ -0,0 +1,52 @@
+package main
+
+import (
+	"cmp"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/hooks"
+	"github.com/madstone-tech/veve-cli/internal/transform"
+)
+
+// applyTransformers runs the project's content transformers on content. Like
+// hooks, transformers that run project code (commands and plugins) only run
+// when the project is trusted (see trustsProject), and never with --sandbox.
+// Built-in transformers always run.
+func applyTransformers(project *config.ProjectConfig, conversion hooks.Conversion, content string, opts conversionOptions) (string, error) {
+	if project == nil || len(project.Transformers) == 0 {
+		return content, nil
+	}
+
+	var steps []transform.Step
+	trusted := trustsProject(opts)
+	for _, cfg := range project.Transformers {
+		// Checked before loading, since loading a plugin runs its code
+		if external := cfg.Command != "" || cfg.Plugin != ""; external {
+			name := cmp.Or(cfg.Name, cfg.Command, cfg.Plugin)
+			if opts.Sandbox != "" {
+				logger.Warn("Skipping transformer '%s' from %s in the sandbox", name, project.ConfigFile)
+				continue
+			}
+			if !trusted {
+				logger.Warn("Skipping transformer '%s' from %s: %s", name, project.ConfigFile, trustHint)
+				continue
+			}
+		}
+		step, err := transform.New(cfg, project.Root)
+		if err != nil {
+			return "", internal.ConfigError("convert", "load transformers", err.Error(),
+				"fix the transformers in "+project.ConfigFile, err)
+		}
+		steps = append(steps, step)
+	}
+
+	logger.Debug("Running %d transformer(s) from %s", len(steps), project.ConfigFile)
+	doc := transform.Document{Path: conversion.Input, Theme: conversion.Theme, Project: conversion.Project}
+	transformed, err := transform.Apply(steps, doc, content)
+	if err != nil {
+		return "", internal.ConfigError("convert", "transform content", err.Error(),
+			"fix the transformer, or remove it from "+project.ConfigFile, err)
+	}
+	return transformed, nil
+}
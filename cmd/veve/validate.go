@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/preflight"
+	"github.com/madstone-tech/veve-cli/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <input>",
+	Short: "Check that a document can be converted, without converting it",
+	Long: `Check that a document can be converted, without converting it.
+
+veve runs the checks a conversion depends on and reports each result:
+
+  input         the input file exists and is readable
+  theme         the theme resolves and its CSS parses
+  engine        pandoc and a PDF engine are installed, the engine supports the
+                theme, and the theme's CSS can be rendered by the engine
+  image         every image is reachable (remote images with a HEAD request)
+  front-matter  the YAML metadata block has the expected field types
+
+It takes the same flags as a conversion. Use --json for machine-readable
+results. The command fails if any check fails; warnings do not fail it.
+
+Examples:
+  veve validate report.md
+  veve validate report.md --theme academic --engine weasyprint
+  veve validate report.md --json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMarkdownFiles(1),
+	// Missing pandoc is reported as a failed check
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := readConversionOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if err := opts.applyConfigDefaults(inputProjectDir(args[0], opts)); err != nil {
+			return err
+		}
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		report := validateDocument(cmd.Context(), args[0], opts)
+
+		if asJSON {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			writeReport(cmd.OutOrStdout(), report)
+		}
+
+		if report.Failed() {
+			return internal.NewVeveError("validate", "validate document",
+				fmt.Sprintf("%d check(s) failed for %s", report.Count(preflight.StatusFail), report.Input), "", nil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	addConversionFlags(validateCmd)
+	_ = validateCmd.Flags().MarkHidden("output")
+	validateCmd.Flags().Bool("json", false, "print the results as JSON")
+}
+
+// validateDocument runs the pre-flight checks for input.
+func validateDocument(ctx context.Context, input string, opts conversionOptions) *preflight.Report {
+	report := &preflight.Report{Input: input}
+
+	if err := converter.ValidateInputFile(input); err != nil {
+		report.Add("input", preflight.StatusFail, "", err.Error())
+		return report
+	}
+	content, err := readInput(input, 0)
+	if err != nil {
+		report.Add("input", preflight.StatusFail, "", err.Error())
+		return report
+	}
+	if encoding := converter.DetectEncoding(content); encoding != "" {
+		status := preflight.StatusWarn
+		if opts.Strict {
+			status = preflight.StatusFail
+		}
+		report.Add("input", status, "", fmt.Sprintf("%s is not UTF-8 (it looks like %s); veve converts it from %s unless --strict is set", input, encoding, encoding))
+		content, _ = converter.Transcode(content, encoding)
+	} else {
+		report.Add("input", preflight.StatusPass, "", fmt.Sprintf("%s is readable", input))
+	}
+
+	loaded := validateTheme(report, input, opts.Theme)
+	validateEngine(report, input, opts, loaded)
+
+	for _, flagImage := range []struct{ flag, path string }{
+		{"--cover-image", opts.CoverImage},
+		{"--logo", opts.Logo},
+	} {
+		if flagImage.path == "" {
+			continue
+		}
+		if _, err := converter.ValidateLocalImage(flagImage.path); err != nil {
+			report.Add("image", preflight.StatusFail, flagImage.path, fmt.Sprintf("%s: %v", flagImage.flag, err))
+		} else {
+			report.Add("image", preflight.StatusPass, flagImage.path, flagImage.flag+" image is usable")
+		}
+	}
+
+	// Pandoc resolves relative image paths against the working directory,
+	// and the cover image and logo directories
+	resourcePath := []string{"."}
+	if _, flagPath, err := imageVariables(opts); err == nil && len(flagPath) > 0 {
+		resourcePath = flagPath
+	}
+	project, _ := config.FindProject(filepath.Dir(input))
+	resourcePath = addResourceDirs(resourcePath, opts, project)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Don't check thousands of images one request at a time
+	limit := opts.RemoteImagesMaxCount
+	if count := countRemoteImages(imageCounter(nil, opts), []string{string(content)}); opts.EnableRemoteImages && limit > 0 && count > limit {
+		report.Add("image", preflight.StatusFail, "", fmt.Sprintf("%s references %d remote images, more than --remote-images-max-count (%d)", input, count, limit))
+	} else {
+		client := &http.Client{Timeout: time.Duration(opts.RemoteImagesTimeout) * time.Second}
+		report.Results = append(report.Results, preflight.CheckImages(ctx, client, string(content), resourcePath)...)
+	}
+
+	report.Results = append(report.Results, preflight.CheckFrontMatter(string(content))...)
+	return report
+}
+
+// validateTheme resolves the theme and checks its CSS syntax. Returns nil if
+// the theme cannot be found.
+func validateTheme(report *preflight.Report, input, themeName string) *resolvedTheme {
+	paths, err := config.GetPaths()
+	if err != nil {
+		report.Add("theme", preflight.StatusFail, themeName, fmt.Sprintf("failed to get config paths: %v", err))
+		return nil
+	}
+	loader, err := newThemeLoader(paths, filepath.Dir(input))
+	if err != nil {
+		report.Add("theme", preflight.StatusFail, themeName, err.Error())
+		return nil
+	}
+	if err := loader.DiscoverThemes(); err != nil {
+		logger.Debug("Error discovering themes: %v (continuing with defaults)", err)
+	}
+
+	loaded, err := loadTheme(loader, themeName)
+	if err != nil {
+		report.Add("theme", preflight.StatusFail, themeName, err.Error())
+		return nil
+	}
+
+	issues := theme.CheckTheme(loaded.CSS, "")
+	for _, issue := range issues {
+		status := preflight.StatusWarn
+		if issue.Severity == theme.SeverityError {
+			status = preflight.StatusFail
+		}
+		report.Add("theme", status, themeName, issue.String())
+	}
+	if len(issues) == 0 {
+		report.Add("theme", preflight.StatusPass, themeName, "theme resolved and parsed")
+	}
+	return loaded
+}
+
+// validateEngine checks that pandoc and a suitable PDF engine are installed and
+// that the engine can render the theme.
+func validateEngine(report *preflight.Report, input string, opts conversionOptions, loaded *resolvedTheme) {
+	if image, ok := engines.ParseDockerEngine(opts.PDFEngine); ok {
+		if err := engines.ValidateDockerImage(image); err != nil {
+			report.Add("engine", preflight.StatusFail, "pandoc", err.Error()+" (try: --engine docker or --engine docker:<image>)")
+		} else {
+			report.Add("engine", preflight.StatusPass, "pandoc", "runs in the "+image+" container")
+		}
+	} else if _, err := exec.LookPath("pandoc"); err != nil {
+		pandocErr := internal.PandocNotFound()
+		report.Add("engine", preflight.StatusFail, "pandoc", pandocErr.Reason+" (try: "+pandocErr.Suggestion+")")
+	} else {
+		report.Add("engine", preflight.StatusPass, "pandoc", "installed")
+	}
+
+	selectOpts := converter.UnicodeConversionOptions{
+		InputFile: input,
+		PDFEngine: opts.PDFEngine,
+		ThemeName: opts.Theme,
+	}
+	if loaded != nil {
+		selectOpts.ThemeEngines = loaded.Engines
+	}
+	engine, err := converter.SelectEngine(selectOpts)
+	if err != nil {
+		report.Add("engine", preflight.StatusFail, opts.PDFEngine, err.Error())
+		return
+	}
+	how := "requested"
+	if opts.PDFEngine == "" {
+		how = "selected automatically"
+	}
+	report.Add("engine", preflight.StatusPass, engine.Name, fmt.Sprintf("%s is installed (%s)", engine.DisplayLabel, how))
+
+	if loaded == nil || loaded.CSS == "" {
+		return
+	}
+
+	// Warnings beyond those validateTheme reported are unsupported properties
+	unsupported := len(theme.CheckTheme(loaded.CSS, engine.Name)) - len(theme.CheckTheme(loaded.CSS, ""))
+	// LaTeX engines use the theme's template, or only the CSS veve can translate
+	if engines.IsLaTeXEngine(engine.Name) && loaded.LaTeXTemplate == "" {
+		settings := converter.TranslateCSSToLaTeX(theme.BlankFrontMatter(loaded.CSS), converter.SystemFontAvailable)
+		unsupported += len(settings.Ignored)
+	}
+	if unsupported > 0 {
+		report.Add("engine", preflight.StatusWarn, engine.Name,
+			fmt.Sprintf("%d theme rule(s) cannot be rendered by %s (see 'veve theme validate %s --engine %s')", unsupported, engine.Name, opts.Theme, engine.Name))
+	}
+}
+
+// writeReport prints one line per result and a summary.
+func writeReport(w io.Writer, report *preflight.Report) {
+	for _, result := range report.Results {
+		message := result.Message
+		if result.Subject != "" {
+			message = result.Subject + ": " + message
+		}
+		fmt.Fprintf(w, "%-4s  %-12s  %s\n", result.Status, result.Check, message)
+	}
+	fmt.Fprintf(w, "%d passed, %d warning(s), %d failed\n",
+		report.Count(preflight.StatusPass), report.Count(preflight.StatusWarn), report.Count(preflight.StatusFail))
+}
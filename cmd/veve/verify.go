@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/pdfdiff"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <input> --golden <expected.pdf>",
+	Short: "Check a document's output against a golden PDF",
+	Long: `Check a document's output against a golden PDF.
+
+veve converts the input and compares the page count and page text with the
+golden PDF, failing if they differ by more than the tolerances. Spacing changes
+and blank lines are ignored unless --exact-whitespace is given. Use --update to
+write a new golden PDF after reviewing a change.
+
+The conversion uses SOURCE_DATE_EPOCH=0 (unless it is already set), so engines
+that embed dates produce the same output on every run.
+
+Requires pdftotext from the poppler tools.
+
+Examples:
+  veve verify report.md --golden testdata/report.pdf
+  veve verify report.md --golden testdata/report.pdf --max-changed-lines 2
+  veve verify report.md --golden testdata/report.pdf --update`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMarkdownFiles(1),
+	// A mismatch is a test failure, not a usage error
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input := args[0]
+
+		opts, err := readConversionOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if err := opts.applyConfigDefaults(inputProjectDir(input, opts)); err != nil {
+			return err
+		}
+		golden, err := cmd.Flags().GetString("golden")
+		if err != nil {
+			return err
+		}
+		update, err := cmd.Flags().GetBool("update")
+		if err != nil {
+			return err
+		}
+		maxChangedLines, err := cmd.Flags().GetInt("max-changed-lines")
+		if err != nil {
+			return err
+		}
+		pageTolerance, err := cmd.Flags().GetInt("page-tolerance")
+		if err != nil {
+			return err
+		}
+		exactWhitespace, err := cmd.Flags().GetBool("exact-whitespace")
+		if err != nil {
+			return err
+		}
+
+		// Pin embedded dates; engines and pandoc read it from the environment
+		if _, ok := os.LookupEnv("SOURCE_DATE_EPOCH"); !ok {
+			os.Setenv("SOURCE_DATE_EPOCH", "0")
+		}
+
+		if update {
+			opts.OutputFile = golden
+			if err := performConversion(input, opts); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Updated golden output %s\n", golden)
+			return nil
+		}
+
+		if _, err := os.Stat(golden); err != nil {
+			return internal.InputError("verify", "read golden output", err.Error(),
+				"create it with --update", err)
+		}
+
+		workDir, err := os.MkdirTemp("", "veve-verify-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+
+		opts.OutputFile = filepath.Join(workDir, "output.pdf")
+		if err := performConversion(input, opts); err != nil {
+			return err
+		}
+
+		result, err := pdfdiff.Compare(golden, opts.OutputFile, golden, input, pdfdiff.Options{IgnoreWhitespace: !exactWhitespace})
+		if err != nil {
+			return comparisonError("verify", err)
+		}
+		if !result.Changed() {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s matches %s\n", input, golden)
+			return nil
+		}
+		writeComparison(cmd.OutOrStdout(), result)
+
+		pageDelta := abs(result.NewPages - result.OldPages)
+		if pageDelta > pageTolerance || result.ChangedLines > maxChangedLines {
+			return internal.NewVeveError("verify", "compare with golden output",
+				fmt.Sprintf("%d pages and %d lines differ from %s", pageDelta, result.ChangedLines, golden),
+				"review the diff, then run with --update to accept the new output", nil)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s is within tolerance of %s (%d pages, %d lines differ)\n",
+			input, golden, pageDelta, result.ChangedLines)
+		return nil
+	},
+}
+
+func init() {
+	addConversionFlags(verifyCmd)
+	_ = verifyCmd.Flags().MarkHidden("output")
+	verifyCmd.Flags().String("golden", "", "golden PDF to compare against (required)")
+	_ = verifyCmd.MarkFlagRequired("golden")
+	verifyCmd.Flags().Bool("update", false, "write the output to the golden PDF instead of comparing")
+	verifyCmd.Flags().Int("max-changed-lines", 0, "number of text lines allowed to differ")
+	verifyCmd.Flags().Int("page-tolerance", 0, "difference in page count allowed")
+	verifyCmd.Flags().Bool("exact-whitespace", false, "also compare spacing and blank lines")
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
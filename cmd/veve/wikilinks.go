@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/logging"
+)
+
+// resolveWikiLinks converts Obsidian wiki links and embeds in content to
+// standard markdown. The vault is --vault, the project's vault setting, or the
+// vault containing projectDir; outside a vault, content is returned unchanged.
+func resolveWikiLinks(project *config.ProjectConfig, projectDir, inputFile, content string, opts conversionOptions) string {
+	if !strings.Contains(content, "[[") {
+		return content
+	}
+
+	vault := opts.Vault
+	if vault == "" && project != nil {
+		vault = project.VaultPath()
+	}
+	if vault == "" {
+		vault = config.FindVault(projectDir)
+	}
+	if vault == "" {
+		return content
+	}
+
+	docPath := inputFile
+	if docPath == "-" {
+		docPath = ""
+	}
+	resolver, err := converter.NewWikiLinkResolver(vault, docPath)
+	if err != nil {
+		logger.WarnIn(logging.CategoryInput, "Skipping wiki links: %v", err)
+		return content
+	}
+	logger.Debug("Resolving wiki links against vault %s", vault)
+	resolved, warnings := resolver.Prepare(content)
+	for _, warning := range warnings {
+		logger.WarnIn(logging.CategoryInput, "%s", warning)
+	}
+	return resolved
+}
@@ -5,6 +5,10 @@ go 1.25.3
 require (
 	github.com/spf13/cobra v1.7.0
 	github.com/spf13/viper v1.21.0
+	github.com/yuin/goldmark v1.7.13
+	go.yaml.in/yaml/v3 v3.0.4
+	golang.org/x/sys v0.29.0
+	golang.org/x/text v0.28.0
 )
 
 require (
@@ -18,7 +22,4 @@ require (
 	github.com/spf13/cast v1.10.0 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
 	github.com/subosito/gotenv v1.6.0 // indirect
-	go.yaml.in/yaml/v3 v3.0.4 // indirect
-	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/text v0.28.0 // indirect
 )
@@ -0,0 +1,87 @@
+// Package assets fetches the files a project pins by URL and SHA-256 checksum
+// in .veve.yaml, verifying each one and caching it by checksum in the user
+// cache directory, so 'veve sync' installs the same bytes on every machine.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/filelock"
+)
+
+// CacheDirName is the directory, in the user cache directory, holding
+// verified assets.
+const CacheDirName = "assets"
+
+// Checksum returns the hex-encoded SHA-256 checksum of data.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChecksumError reports an asset whose content doesn't match its pin. Want
+// is empty for an asset without a checksum; Got is then the one to pin.
+type ChecksumError struct {
+	URL  string
+	Want string
+	Got  string
+}
+
+func (e *ChecksumError) Error() string {
+	if e.Want == "" {
+		return fmt.Sprintf("%s has no sha256; pin it with sha256: %s", e.URL, e.Got)
+	}
+	return fmt.Sprintf("checksum mismatch for %s: expected sha256 %s, got %s", e.URL, e.Want, e.Got)
+}
+
+// Verify checks data against the pinned checksum want.
+func Verify(url string, data []byte, want string) error {
+	got := Checksum(data)
+	if want = strings.ToLower(strings.TrimSpace(want)); want != got {
+		return &ChecksumError{URL: url, Want: want, Got: got}
+	}
+	return nil
+}
+
+// Fetcher fetches pinned assets through a cache.
+type Fetcher struct {
+	// Download fetches the content at a URL
+	Download func(url string) ([]byte, error)
+	// CacheDir holds verified assets named by checksum; empty disables the cache
+	CacheDir string
+}
+
+// Fetch returns the content of the asset at url, which must match sha256.
+// A cached copy is used when present.
+func (f *Fetcher) Fetch(url, sha256 string) ([]byte, error) {
+	want := strings.ToLower(strings.TrimSpace(sha256))
+	if want != "" && f.CacheDir != "" {
+		data, err := os.ReadFile(f.cachePath(want))
+		if err == nil && Checksum(data) == want {
+			return data, nil
+		}
+	}
+
+	data, err := f.Download(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(url, data, want); err != nil {
+		return nil, err
+	}
+
+	// The cache only saves a download, so failing to write it isn't an error
+	if f.CacheDir != "" && os.MkdirAll(f.CacheDir, 0o755) == nil {
+		_ = filelock.WriteFile(f.cachePath(want), data, 0o644)
+	}
+	return data, nil
+}
+
+func (f *Fetcher) cachePath(checksum string) string {
+	return filepath.Join(f.CacheDir, checksum)
+}
@@ -0,0 +1,276 @@
+// Package book reads veve.book.yaml manifests, which describe a book built
+// from several markdown documents: their order, shared metadata, per-chapter
+// themes, and the formats to publish.
+package book
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+// ManifestFile is the default manifest name.
+const ManifestFile = "veve.book.yaml"
+
+// Output formats
+const (
+	FormatPDF  = "pdf"
+	FormatEPUB = "epub"
+)
+
+// Manifest is a parsed veve.book.yaml.
+type Manifest struct {
+	// Title is the book title (overrides metadata.title)
+	Title string `yaml:"title"`
+	// Metadata is pandoc metadata shared by every chapter (author, date, lang, ...)
+	Metadata map[string]any `yaml:"metadata"`
+	// Theme is the book's theme (default: the --theme flag)
+	Theme string `yaml:"theme"`
+	// Chapters are the book's documents, in order
+	Chapters []Chapter `yaml:"chapters"`
+	// Outputs are the files to build (default: <directory name>.pdf)
+	Outputs []Output `yaml:"outputs"`
+
+	// File is the manifest's path; chapter and output paths are relative to its directory
+	File string `yaml:"-"`
+}
+
+// Chapter is one document of a book. In the manifest it is a path, or a
+// mapping with a file and a theme.
+type Chapter struct {
+	File  string `yaml:"file"`
+	Theme string `yaml:"theme"` // Theme for this chapter only (HTML engines and EPUB)
+}
+
+// UnmarshalYAML accepts a chapter written as a bare path.
+func (c *Chapter) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.File = node.Value
+		return nil
+	}
+	type plain Chapter
+	return node.Decode((*plain)(c))
+}
+
+// Output is a file to build. Format defaults to the path's extension.
+type Output struct {
+	Path   string `yaml:"path"`
+	Format string `yaml:"format"`
+}
+
+// Load reads and validates the manifest at path.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read book manifest: %w", err)
+	}
+
+	m := &Manifest{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if m.File, err = filepath.Abs(path); err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Dir returns the directory chapter and output paths are relative to.
+func (m *Manifest) Dir() string {
+	return filepath.Dir(m.File)
+}
+
+// validate checks the chapters exist and fills in output defaults.
+func (m *Manifest) validate() error {
+	if len(m.Chapters) == 0 {
+		return errors.New("no chapters listed")
+	}
+	for i, chapter := range m.Chapters {
+		if chapter.File == "" {
+			return fmt.Errorf("chapter %d has no file", i+1)
+		}
+		if _, err := os.Stat(m.path(chapter.File)); err != nil {
+			return fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+	}
+
+	if len(m.Outputs) == 0 {
+		m.Outputs = []Output{{Path: filepath.Base(m.Dir()) + ".pdf"}}
+	}
+	for i := range m.Outputs {
+		output := &m.Outputs[i]
+		if output.Path == "" {
+			return fmt.Errorf("output %d has no path", i+1)
+		}
+		if output.Format == "" {
+			output.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(output.Path)), ".")
+		}
+		if output.Format != FormatPDF && output.Format != FormatEPUB {
+			return fmt.Errorf("output %s: unsupported format %q (use pdf or epub)", output.Path, output.Format)
+		}
+		output.Path = m.path(output.Path)
+	}
+	return nil
+}
+
+// path resolves a manifest path against the manifest's directory.
+func (m *Manifest) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(m.Dir(), filepath.FromSlash(p))
+}
+
+// ChapterThemes returns the distinct chapter themes, in order of first use.
+func (m *Manifest) ChapterThemes() []string {
+	var themes []string
+	seen := make(map[string]bool)
+	for _, chapter := range m.Chapters {
+		if chapter.Theme != "" && !seen[chapter.Theme] {
+			seen[chapter.Theme] = true
+			themes = append(themes, chapter.Theme)
+		}
+	}
+	return themes
+}
+
+// nonClassChars are the characters replaced in theme class names.
+var nonClassChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// ThemeClass returns the class wrapping chapters that use theme, to which
+// the theme's CSS is scoped.
+func ThemeClass(theme string) string {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(theme)), ".css")
+	return "theme-" + strings.Trim(nonClassChars.ReplaceAllString(name, "-"), "-")
+}
+
+// Assemble returns the book as one markdown document: the shared metadata,
+// then each chapter, starting on a new page. A chapter's own front matter is
+// dropped, except that its title becomes the chapter's heading. Chapters with
+// a theme are wrapped in a div with the theme's class, and relative image
+// paths are made absolute, since the chapters may be in other directories.
+// decode, if not nil, receives each chapter's file and content, to convert
+// chapters that aren't UTF-8.
+func (m *Manifest) Assemble(decode func(file string, data []byte) ([]byte, error)) (string, error) {
+	metadata, err := m.metadataBlock(true)
+	if err != nil {
+		return "", err
+	}
+	chapters, err := m.chapters(decode)
+	if err != nil {
+		return "", err
+	}
+	return metadata + strings.Join(chapters, "<!-- pagebreak -->\n\n"), nil
+}
+
+// AssembleChapters returns each chapter as a document of its own, written as
+// Assemble writes it into the book, for building the chapters separately.
+// Every chapter has the shared metadata, but only the first the book's
+// title, so a title page is printed once.
+func (m *Manifest) AssembleChapters(decode func(file string, data []byte) ([]byte, error)) ([]string, error) {
+	chapters, err := m.chapters(decode)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chapters {
+		metadata, err := m.metadataBlock(i == 0)
+		if err != nil {
+			return nil, err
+		}
+		chapters[i] = metadata + chapters[i]
+	}
+	return chapters, nil
+}
+
+// metadataBlock returns the shared metadata as a YAML front matter block,
+// with the book's title if withTitle is set, or "" if there is none.
+func (m *Manifest) metadataBlock(withTitle bool) (string, error) {
+	metadata := make(map[string]any, len(m.Metadata)+1)
+	for key, value := range m.Metadata {
+		if key != "title" || withTitle {
+			metadata[key] = value
+		}
+	}
+	if m.Title != "" && withTitle {
+		metadata["title"] = m.Title
+	}
+	if len(metadata) == 0 {
+		return "", nil
+	}
+	block, err := yaml.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to write book metadata: %w", err)
+	}
+	return "---\n" + string(block) + "---\n\n", nil
+}
+
+// chapters returns the markdown of each chapter, as Assemble writes it.
+func (m *Manifest) chapters(decode func(file string, data []byte) ([]byte, error)) ([]string, error) {
+	chapters := make([]string, len(m.Chapters))
+	for i, chapter := range m.Chapters {
+		path := m.path(chapter.File)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chapter %s: %w", chapter.File, err)
+		}
+		data = converter.NormalizeText(data)
+		if decode != nil {
+			if data, err = decode(chapter.File, data); err != nil {
+				return nil, err
+			}
+		}
+		title, body := splitFrontMatter(string(data))
+		body = converter.RebaseImages(body, filepath.Dir(path))
+
+		var sb strings.Builder
+		if chapter.Theme != "" {
+			sb.WriteString("::: {." + ThemeClass(chapter.Theme) + "}\n\n")
+		}
+		if title != "" {
+			sb.WriteString("# " + title + "\n\n")
+		}
+		sb.WriteString(strings.TrimSpace(body) + "\n\n")
+		if chapter.Theme != "" {
+			sb.WriteString(":::\n\n")
+		}
+		chapters[i] = sb.String()
+	}
+	return chapters, nil
+}
+
+// splitFrontMatter removes a YAML front matter block from content and
+// returns its title, if any, and the rest of the document.
+func splitFrontMatter(content string) (string, string) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return "", content
+	}
+	lines := strings.SplitAfter(content, "\n")
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		offset += len(line)
+		if trimmed := strings.TrimRight(line, " \t\n"); trimmed == "---" || trimmed == "..." {
+			var meta struct {
+				Title string `yaml:"title"`
+			}
+			block := content[len(lines[0]) : offset-len(line)]
+			_ = yaml.Unmarshal([]byte(block), &meta)
+			return strings.TrimSpace(meta.Title), content[offset:]
+		}
+	}
+	return "", content
+}
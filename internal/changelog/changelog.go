@@ -0,0 +1,245 @@
+// Package changelog builds release notes, as markdown, from a repository's
+// git history or from the sections of its CHANGELOG.md.
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Commit is a commit in the range being summarized.
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
+// Log returns the commits reachable from to but not from, newest first, from
+// the git repository in dir. An empty from means the whole history of to.
+func Log(dir, from, to string) ([]Commit, error) {
+	revision := to
+	if from != "" {
+		revision = from + ".." + to
+	}
+	// Fields are separated by US and records by RS, which don't occur in messages
+	out, err := git(dir, "log", "--no-merges", "--format=%H%x1f%s%x1f%b%x1e", revision, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{Hash: fields[0], Subject: fields[1], Body: strings.TrimSpace(fields[2])})
+	}
+	return commits, nil
+}
+
+// PreviousTag returns the most recent tag before ref, or "" if there is none.
+func PreviousTag(dir, ref string) string {
+	tag, err := git(dir, "describe", "--tags", "--abbrev=0", ref+"^")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(tag)
+}
+
+// Date returns the date (YYYY-MM-DD) of the commit ref points to.
+func Date(dir, ref string) (string, error) {
+	date, err := git(dir, "log", "-1", "--format=%cs", ref, "--")
+	return strings.TrimSpace(date), err
+}
+
+// git runs a git command in dir and returns its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// Group is a titled group of release note entries.
+type Group struct {
+	Title   string
+	Entries []string
+}
+
+// conventionalSubject matches a Conventional Commits subject: type(scope)!: text.
+var conventionalSubject = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// groupTitles maps commit types to group titles, in the order groups are listed.
+var groupTitles = []struct{ Type, Title string }{
+	{"breaking", "Breaking Changes"},
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"docs", "Documentation"},
+	{"", "Other Changes"},
+}
+
+// skippedTypes are commit types left out of release notes.
+var skippedTypes = map[string]bool{"chore": true, "ci": true, "test": true, "build": true, "style": true}
+
+// Categorize groups commits by their Conventional Commits type. Breaking
+// changes (type! or a BREAKING CHANGE footer) come first; chores, CI, test,
+// build, and style commits are left out; subjects without a recognized type
+// are other changes. Empty groups are omitted.
+func Categorize(commits []Commit) []Group {
+	entries := make(map[string][]string)
+	for _, commit := range commits {
+		kind, text := "", commit.Subject
+		if match := conventionalSubject.FindStringSubmatch(commit.Subject); match != nil {
+			kind, text = strings.ToLower(match[1]), match[4]
+			if match[2] != "" {
+				text = "**" + match[2] + ":** " + text
+			}
+			if match[3] != "" || strings.Contains(commit.Body, "BREAKING CHANGE") {
+				kind = "breaking"
+			}
+		}
+		if skippedTypes[kind] {
+			continue
+		}
+		if !isGroupType(kind) {
+			kind = ""
+		}
+		if len(commit.Hash) >= 7 {
+			text += " (" + commit.Hash[:7] + ")"
+		}
+		entries[kind] = append(entries[kind], text)
+	}
+
+	var groups []Group
+	for _, g := range groupTitles {
+		if len(entries[g.Type]) > 0 {
+			groups = append(groups, Group{Title: g.Title, Entries: entries[g.Type]})
+		}
+	}
+	return groups
+}
+
+// isGroupType reports whether kind has its own group.
+func isGroupType(kind string) bool {
+	for _, g := range groupTitles {
+		if g.Type == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Notes is a release notes document.
+type Notes struct {
+	Title string
+	Date  string
+	Body  string // Markdown, without front matter
+}
+
+// FromCommits returns release notes listing groups.
+func FromCommits(title, date string, groups []Group) Notes {
+	var sb strings.Builder
+	if len(groups) == 0 {
+		sb.WriteString("No notable changes.\n")
+	}
+	for _, group := range groups {
+		sb.WriteString("## " + group.Title + "\n\n")
+		for _, entry := range group.Entries {
+			sb.WriteString("- " + entry + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return Notes{Title: title, Date: date, Body: sb.String()}
+}
+
+// Markdown returns the notes as a markdown document with a title block.
+func (n Notes) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	sb.WriteString("title: " + quoteYAML(n.Title) + "\n")
+	if n.Date != "" {
+		sb.WriteString("date: " + quoteYAML(n.Date) + "\n")
+	}
+	sb.WriteString("---\n\n")
+	sb.WriteString(strings.TrimSpace(n.Body) + "\n")
+	return sb.String()
+}
+
+// quoteYAML quotes s as a YAML double-quoted scalar.
+func quoteYAML(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// headingPattern matches an ATX heading and captures its level and text.
+var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// Unreleased names the CHANGELOG section for changes not yet released.
+const Unreleased = "Unreleased"
+
+// Sections returns the part of a CHANGELOG for the releases after from, up
+// to and including to: from the heading for to down to the heading for from
+// (or, without from, the next heading at the same level). Headings name a
+// version as their first word, with or without a "v" or [brackets], as in
+// "## [1.1.0] - 2025-12-01" or "### v1.1.0 (December 2025)".
+func Sections(changelog, from, to string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(changelog, "\r\n", "\n"), "\n")
+
+	start, level := -1, 0
+	end := len(lines)
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		match := headingPattern.FindStringSubmatch(line)
+		if inFence || match == nil {
+			continue
+		}
+		if start < 0 {
+			if sameVersion(match[2], to) {
+				start, level = i, len(match[1])
+			}
+			continue
+		}
+		if len(match[1]) > level {
+			continue
+		}
+		if len(match[1]) < level || from == "" || sameVersion(match[2], from) {
+			end = i
+			break
+		}
+	}
+	if start < 0 {
+		return "", fmt.Errorf("no section for %s", to)
+	}
+	return strings.TrimSpace(strings.Join(lines[start:end], "\n")) + "\n", nil
+}
+
+// sameVersion reports whether a heading's first word names version.
+func sameVersion(heading, version string) bool {
+	fields := strings.Fields(heading)
+	if len(fields) == 0 {
+		return false
+	}
+	normalize := func(s string) string {
+		s = strings.Trim(s, "[]")
+		if len(s) > 1 && (s[0] == 'v' || s[0] == 'V') && s[1] >= '0' && s[1] <= '9' {
+			s = s[1:]
+		}
+		return strings.ToLower(s)
+	}
+	return normalize(fields[0]) == normalize(version)
+}
@@ -0,0 +1,62 @@
+// Package completion provides shell autocompletion support for CLI flags and values.
+package completion
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MarkdownExtensions are the extensions of the files veve converts.
+var MarkdownExtensions = []string{".md", ".markdown", ".ipynb"}
+
+// Files returns the completions of toComplete, a partial path: the files
+// in its directory with one of extensions (matched case-insensitively),
+// and the directories, which end in a separator. Hidden entries are left
+// out unless toComplete names one. Completions are listed here rather than
+// left to the shell, since not every shell can filter by extension.
+func Files(toComplete string, extensions []string) []string {
+	dir, prefix := filepath.Split(toComplete)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var completions []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(readDir, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		switch {
+		case isDir:
+			completions = append(completions, dir+name+string(filepath.Separator))
+		case hasExtension(name, extensions):
+			completions = append(completions, dir+name)
+		}
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// hasExtension reports whether name ends in one of extensions, ignoring case.
+func hasExtension(name string, extensions []string) bool {
+	ext := filepath.Ext(name)
+	for _, e := range extensions {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
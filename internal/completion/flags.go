@@ -82,8 +82,8 @@ func EngineFlag() *CLIFlag {
 	return &CLIFlag{
 		Name:              "engine",
 		ShortForm:         "e",
-		Description:       "PDF rendering engine to use (xelatex, weasyprint, prince)",
-		AcceptedValues:    []string{"xelatex", "lualatex", "weasyprint", "prince"},
+		Description:       "PDF rendering engine to use (xelatex, lualatex, weasyprint, prince, wkhtmltopdf)",
+		AcceptedValues:    []string{"xelatex", "lualatex", "weasyprint", "prince", "wkhtmltopdf"},
 		ValueType:         ValueTypeEnum,
 		IsRequired:        false,
 		DefaultValue:      "xelatex",
@@ -0,0 +1,188 @@
+// Package completion provides shell autocompletion support for CLI flags and values.
+package completion
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Shells are the shells veve writes completion scripts for.
+var Shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// profileComment marks the lines Install adds to a shell's startup file.
+const profileComment = "# veve shell completion"
+
+// Environment is what the install location of a completion script depends
+// on.
+type Environment struct {
+	Home   string              // The user's home directory
+	GOOS   string              // The operating system, as runtime.GOOS
+	Getenv func(string) string // Looks up environment variables
+}
+
+// CurrentEnvironment returns the environment of the running process.
+func CurrentEnvironment() (Environment, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Environment{}, err
+	}
+	return Environment{Home: home, GOOS: runtime.GOOS, Getenv: os.Getenv}, nil
+}
+
+// dir returns the directory in the environment variable name, or else
+// Home joined with elem.
+func (e Environment) dir(name string, elem ...string) string {
+	if dir := e.Getenv(name); dir != "" {
+		return dir
+	}
+	return filepath.Join(append([]string{e.Home}, elem...)...)
+}
+
+// DetectShell returns the user's shell, from $SHELL, or PowerShell on
+// Windows without it.
+func DetectShell(env Environment) (string, error) {
+	shell := strings.TrimSuffix(filepath.Base(env.Getenv("SHELL")), ".exe")
+	switch shell {
+	case "bash", "zsh", "fish":
+		return shell, nil
+	case "pwsh", "powershell":
+		return "powershell", nil
+	}
+	if env.GOOS == "windows" && env.Getenv("SHELL") == "" {
+		return "powershell", nil
+	}
+	if shell == "." || shell == "" {
+		return "", fmt.Errorf("could not detect your shell ($SHELL is not set); name it: %s", strings.Join(Shells, ", "))
+	}
+	return "", fmt.Errorf("no completion for shell %q; name one of: %s", shell, strings.Join(Shells, ", "))
+}
+
+// Location is where a shell's completion script is installed.
+type Location struct {
+	Shell   string
+	Script  string   // The completion script
+	Profile string   // Startup file that must load Script, or "" if the shell finds it
+	Lines   []string // Lines that load Script from Profile
+}
+
+// Locate returns where shell's completion script belongs for the user:
+//
+//	bash        ~/.local/share/bash-completion/completions/veve, found by
+//	            bash-completion and also sourced from ~/.bashrc (~/.bash_profile
+//	            on macOS) for shells without it
+//	zsh         ~/.zsh/completions/_veve, added to fpath in ~/.zshrc
+//	fish        ~/.config/fish/completions/veve.fish, which fish finds
+//	powershell  veve.ps1 beside the PowerShell 7 profile, dot-sourced from it
+//
+// XDG_DATA_HOME, BASH_COMPLETION_USER_DIR, ZDOTDIR, and XDG_CONFIG_HOME
+// move them as they move the shells' own files.
+func Locate(shell string, env Environment) (Location, error) {
+	switch shell {
+	case "bash":
+		dir := env.Getenv("BASH_COMPLETION_USER_DIR")
+		if dir == "" {
+			dir = filepath.Join(env.dir("XDG_DATA_HOME", ".local", "share"), "bash-completion")
+		}
+		script := filepath.Join(dir, "completions", "veve")
+		profile := filepath.Join(env.Home, ".bashrc")
+		if env.GOOS == "darwin" {
+			profile = filepath.Join(env.Home, ".bash_profile")
+		}
+		return Location{
+			Shell:   shell,
+			Script:  script,
+			Profile: profile,
+			Lines:   []string{fmt.Sprintf("[ -f %[1]s ] && source %[1]s", shellQuote(script))},
+		}, nil
+	case "zsh":
+		zdotdir := env.dir("ZDOTDIR")
+		dir := filepath.Join(zdotdir, ".zsh", "completions")
+		return Location{
+			Shell:   shell,
+			Script:  filepath.Join(dir, "_veve"),
+			Profile: filepath.Join(zdotdir, ".zshrc"),
+			Lines:   []string{fmt.Sprintf("fpath=(%s $fpath)", shellQuote(dir)), "autoload -Uz compinit && compinit"},
+		}, nil
+	case "fish":
+		return Location{
+			Shell:  shell,
+			Script: filepath.Join(env.dir("XDG_CONFIG_HOME", ".config"), "fish", "completions", "veve.fish"),
+		}, nil
+	case "powershell":
+		dir := filepath.Join(env.dir("XDG_CONFIG_HOME", ".config"), "powershell")
+		if env.GOOS == "windows" {
+			dir = filepath.Join(env.Home, "Documents", "PowerShell")
+		}
+		script := filepath.Join(dir, "veve.ps1")
+		return Location{
+			Shell:   shell,
+			Script:  script,
+			Profile: filepath.Join(dir, "Microsoft.PowerShell_profile.ps1"),
+			Lines:   []string{". " + powerShellQuote(script)},
+		}, nil
+	}
+	return Location{}, fmt.Errorf("no completion for shell %q; name one of: %s", shell, strings.Join(Shells, ", "))
+}
+
+// Install writes script to l.Script, replacing an older one, and adds the
+// lines loading it to l.Profile unless they are there already. It reports
+// whether l.Profile was changed.
+func Install(l Location, script []byte) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(l.Script), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(l.Script), err)
+	}
+	if err := os.WriteFile(l.Script, script, 0o644); err != nil {
+		return false, fmt.Errorf("failed to write completion script: %w", err)
+	}
+	if l.Profile == "" {
+		return false, nil
+	}
+
+	existing, err := os.ReadFile(l.Profile)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s: %w", l.Profile, err)
+	}
+	if strings.Contains(string(existing), l.Lines[0]) {
+		return false, nil
+	}
+
+	var add strings.Builder
+	if len(existing) > 0 {
+		if !strings.HasSuffix(string(existing), "\n") {
+			add.WriteString("\n")
+		}
+		add.WriteString("\n")
+	}
+	add.WriteString(profileComment + "\n")
+	for _, line := range l.Lines {
+		add.WriteString(line + "\n")
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Profile), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(l.Profile), err)
+	}
+	f, err := os.OpenFile(l.Profile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", l.Profile, err)
+	}
+	if _, err := f.WriteString(add.String()); err != nil {
+		f.Close()
+		return false, fmt.Errorf("failed to update %s: %w", l.Profile, err)
+	}
+	if err := f.Close(); err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", l.Profile, err)
+	}
+	return true, nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powerShellQuote quotes s for PowerShell.
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseAlias splits the value of an alias in veve.toml into the arguments
+// it stands for. Arguments are separated by spaces; single or double quotes
+// keep spaces in one ("--theme 'company/brand guide'"), and a backslash
+// escapes the next character outside single quotes.
+func ParseAlias(value string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// validateAliases checks that every alias has a usable name and parses.
+func validateAliases(aliases map[string]string) error {
+	for name, value := range aliases {
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("alias %q: names must not be empty, contain spaces, or start with '-'", name)
+		}
+		args, err := ParseAlias(value)
+		if err != nil {
+			return fmt.Errorf("alias %q: %w", name, err)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("alias %q is empty", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Defaults are conversion settings used when the flags for them aren't
+// given: the [defaults] table of veve.toml, and the defaults of a project's
+// .veve.yaml, which take precedence over the user's (see MergeDefaults).
+// Unset fields leave the flag's own default in place.
+type Defaults struct {
+	// Theme is the theme to convert with (--theme)
+	Theme string `mapstructure:"theme"`
+	// PDFEngine is the PDF engine to convert with (--engine)
+	PDFEngine string `mapstructure:"pdf_engine"`
+	// OutputDir is the directory PDFs are written to (--output-dir)
+	OutputDir string `mapstructure:"output_dir"`
+	// SignKey is the key outputs are signed with (--sign-key)
+	SignKey string `mapstructure:"sign_key"`
+	// RemoteImages configures remote image downloads
+	RemoteImages RemoteImageDefaults `mapstructure:"remote_images"`
+}
+
+// RemoteImageDefaults are the defaults of the --remote-images-* flags.
+type RemoteImageDefaults struct {
+	// Enabled downloads and embeds remote images (--enable-remote-images)
+	Enabled *bool `mapstructure:"enabled"`
+	// Timeout is the timeout in seconds for each image (--remote-images-timeout)
+	Timeout *int `mapstructure:"timeout"`
+	// MaxRetries is the retries for a failed image (--remote-images-max-retries)
+	MaxRetries *int `mapstructure:"max_retries"`
+	// MaxCount is the most remote images a document may reference
+	// (--remote-images-max-count; 0 for no limit)
+	MaxCount *int `mapstructure:"max_count"`
+	// StrictType accepts only images served as image/* (--remote-images-strict-type)
+	StrictType *bool `mapstructure:"strict_type"`
+	// TempDir is the directory images are downloaded to (--remote-images-temp-dir)
+	TempDir string `mapstructure:"temp_dir"`
+	// Cache keeps images across conversions (--image-cache)
+	Cache *bool `mapstructure:"cache"`
+}
+
+// MergeDefaults returns base with the fields over sets replacing its own.
+func MergeDefaults(base, over Defaults) Defaults {
+	merged := base
+	if over.Theme != "" {
+		merged.Theme = over.Theme
+	}
+	if over.PDFEngine != "" {
+		merged.PDFEngine = over.PDFEngine
+	}
+	if over.OutputDir != "" {
+		merged.OutputDir = over.OutputDir
+	}
+	if over.SignKey != "" {
+		merged.SignKey = over.SignKey
+	}
+	images, overImages := &merged.RemoteImages, over.RemoteImages
+	if overImages.Enabled != nil {
+		images.Enabled = overImages.Enabled
+	}
+	if overImages.Timeout != nil {
+		images.Timeout = overImages.Timeout
+	}
+	if overImages.MaxRetries != nil {
+		images.MaxRetries = overImages.MaxRetries
+	}
+	if overImages.MaxCount != nil {
+		images.MaxCount = overImages.MaxCount
+	}
+	if overImages.StrictType != nil {
+		images.StrictType = overImages.StrictType
+	}
+	if overImages.TempDir != "" {
+		images.TempDir = overImages.TempDir
+	}
+	if overImages.Cache != nil {
+		images.Cache = overImages.Cache
+	}
+	return merged
+}
+
+// Validate checks the values of the defaults that are set.
+func (d Defaults) Validate() error {
+	images := d.RemoteImages
+	if images.Timeout != nil && *images.Timeout <= 0 {
+		return fmt.Errorf("defaults: remote_images.timeout must be at least 1 second")
+	}
+	if images.MaxRetries != nil && *images.MaxRetries < 0 {
+		return fmt.Errorf("defaults: remote_images.max_retries must not be negative")
+	}
+	if images.MaxCount != nil && *images.MaxCount < 0 {
+		return fmt.Errorf("defaults: remote_images.max_count must be 0 (no limit) or more")
+	}
+	return nil
+}
+
+// settings returns the defaults that are set, as they are written in a
+// configuration file.
+func (d Defaults) settings() map[string]any {
+	settings := make(map[string]any)
+	if d.Theme != "" {
+		settings["theme"] = d.Theme
+	}
+	if d.PDFEngine != "" {
+		settings["pdf_engine"] = d.PDFEngine
+	}
+	if d.OutputDir != "" {
+		settings["output_dir"] = d.OutputDir
+	}
+	if d.SignKey != "" {
+		settings["sign_key"] = d.SignKey
+	}
+	images := make(map[string]any)
+	if d.RemoteImages.Enabled != nil {
+		images["enabled"] = *d.RemoteImages.Enabled
+	}
+	if d.RemoteImages.Timeout != nil {
+		images["timeout"] = *d.RemoteImages.Timeout
+	}
+	if d.RemoteImages.MaxRetries != nil {
+		images["max_retries"] = *d.RemoteImages.MaxRetries
+	}
+	if d.RemoteImages.MaxCount != nil {
+		images["max_count"] = *d.RemoteImages.MaxCount
+	}
+	if d.RemoteImages.StrictType != nil {
+		images["strict_type"] = *d.RemoteImages.StrictType
+	}
+	if d.RemoteImages.TempDir != "" {
+		images["temp_dir"] = d.RemoteImages.TempDir
+	}
+	if d.RemoteImages.Cache != nil {
+		images["cache"] = *d.RemoteImages.Cache
+	}
+	if len(images) > 0 {
+		settings["remote_images"] = images
+	}
+	return settings
+}
+
+// resolve returns the defaults with their relative directories resolved
+// against dir.
+func (d Defaults) resolve(dir string) Defaults {
+	if d.OutputDir != "" && !filepath.IsAbs(d.OutputDir) {
+		d.OutputDir = filepath.Join(dir, filepath.FromSlash(d.OutputDir))
+	}
+	if d.RemoteImages.TempDir != "" && !filepath.IsAbs(d.RemoteImages.TempDir) {
+		d.RemoteImages.TempDir = filepath.Join(dir, filepath.FromSlash(d.RemoteImages.TempDir))
+	}
+	return d
+}
+
+// LoadDefaults returns the conversion defaults for a document in
+// projectDir: the user's from veve.toml, with those of the project's
+// .veve.yaml, if there is one, taking precedence. Unless the user trusts the
+// project, it may only set the defaults SafeDefaults keeps; the keys of those
+// it sets and SafeDefaults leaves out are returned, so they can be reported.
+func LoadDefaults(configFile, projectDir string, trusted bool) (Defaults, []string, error) {
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		return Defaults{}, nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	project, err := FindProject(projectDir)
+	if err != nil {
+		return Defaults{}, nil, err
+	}
+	if project == nil {
+		return cfg.Defaults, nil, nil
+	}
+	if trusted {
+		return MergeDefaults(cfg.Defaults, project.Defaults), nil, nil
+	}
+	safe, ignored := project.SafeDefaults()
+	return MergeDefaults(cfg.Defaults, safe), ignored, nil
+}
+
+// SafeDefaults returns the project's defaults without those an untrusted
+// project mustn't choose, and the keys of the ones it left out: the PDF
+// engine (which can name any docker image to run), the signing key, an output
+// directory outside the project, and whether and where remote images are
+// downloaded. The theme and the other remote image limits are kept.
+func (p *ProjectConfig) SafeDefaults() (Defaults, []string) {
+	d := p.Defaults
+	var ignored []string
+	if d.PDFEngine != "" {
+		ignored = append(ignored, "pdf_engine")
+		d.PDFEngine = ""
+	}
+	if d.SignKey != "" {
+		ignored = append(ignored, "sign_key")
+		d.SignKey = ""
+	}
+	if d.OutputDir != "" {
+		if rel, err := filepath.Rel(p.Root, d.OutputDir); err != nil || !filepath.IsLocal(rel) {
+			ignored = append(ignored, "output_dir")
+			d.OutputDir = ""
+		}
+	}
+	if d.RemoteImages.Enabled != nil {
+		ignored = append(ignored, "remote_images.enabled")
+		d.RemoteImages.Enabled = nil
+	}
+	if d.RemoteImages.TempDir != "" {
+		ignored = append(ignored, "remote_images.temp_dir")
+		d.RemoteImages.TempDir = ""
+	}
+	return d, ignored
+}
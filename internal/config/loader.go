@@ -1,6 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
 	"github.com/spf13/viper"
 )
 
@@ -12,6 +17,34 @@ type Config struct {
 	DefaultTheme string `mapstructure:"default_theme"`
 	// Verbose enables verbose output
 	Verbose bool `mapstructure:"verbose"`
+	// Metrics records each conversion in a local metrics log (see 'veve stats')
+	Metrics bool `mapstructure:"metrics"`
+	// Hooks runs the hooks and command and plugin transformers in a project's
+	// .veve.yaml without --hooks. Only veve.toml sets it, so a cloned project
+	// can't turn its own code on
+	Hooks bool `mapstructure:"hooks"`
+	// ThemeSources holds credentials for hosts that serve private themes
+	ThemeSources []ThemeSource `mapstructure:"theme_sources"`
+	// ThemeDownloadLimit is the largest theme download accepted, e.g. "200M"
+	// (default: 50M)
+	ThemeDownloadLimit string `mapstructure:"theme_download_limit"`
+	// Aliases name bundles of arguments: 'veve <name> ...' runs veve with
+	// the alias's arguments in place of its name (see ParseAlias)
+	Aliases map[string]string `mapstructure:"aliases"`
+	// Defaults are the user's conversion defaults, used when the flags for
+	// them aren't given
+	Defaults Defaults `mapstructure:"defaults"`
+}
+
+// ThemeSource authenticates 'veve theme add' downloads from a host, with a
+// bearer token or a username and password. Values may reference environment
+// variables ("$THEMES_TOKEN"), to keep secrets out of the file.
+type ThemeSource struct {
+	// Host is the host name; the credentials also apply to its subdomains
+	Host     string `mapstructure:"host"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	Token    string `mapstructure:"token"`
 }
 
 // DefaultConfig returns the default configuration.
@@ -20,6 +53,7 @@ func DefaultConfig() Config {
 		PDFEngine:    "pdflatex",
 		DefaultTheme: "default",
 		Verbose:      false,
+		Metrics:      false,
 	}
 }
 
@@ -38,11 +72,13 @@ func LoadConfig(configFile string) (Config, error) {
 	v.SetDefault("pdf_engine", cfg.PDFEngine)
 	v.SetDefault("default_theme", cfg.DefaultTheme)
 	v.SetDefault("verbose", cfg.Verbose)
+	v.SetDefault("metrics", cfg.Metrics)
 
 	// Try to read the config file (it's okay if it doesn't exist)
 	if err := v.ReadInConfig(); err != nil {
 		// It's fine if the file doesn't exist; we'll use defaults
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) && !errors.Is(err, fs.ErrNotExist) {
 			// Real error occurred
 			return cfg, err
 		}
@@ -53,6 +89,21 @@ func LoadConfig(configFile string) (Config, error) {
 		return cfg, err
 	}
 
+	for i, source := range cfg.ThemeSources {
+		if source.Host == "" {
+			return cfg, fmt.Errorf("theme_sources entry %d has no host", i+1)
+		}
+		cfg.ThemeSources[i].Username = os.ExpandEnv(source.Username)
+		cfg.ThemeSources[i].Password = os.ExpandEnv(source.Password)
+		cfg.ThemeSources[i].Token = os.ExpandEnv(source.Token)
+	}
+	if err := validateAliases(cfg.Aliases); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Defaults.Validate(); err != nil {
+		return cfg, err
+	}
+
 	return cfg, nil
 }
 
@@ -65,6 +116,31 @@ func SaveConfig(configFile string, cfg Config) error {
 	v.Set("pdf_engine", cfg.PDFEngine)
 	v.Set("default_theme", cfg.DefaultTheme)
 	v.Set("verbose", cfg.Verbose)
+	v.Set("metrics", cfg.Metrics)
+	if cfg.Hooks {
+		v.Set("hooks", cfg.Hooks)
+	}
+	if cfg.ThemeDownloadLimit != "" {
+		v.Set("theme_download_limit", cfg.ThemeDownloadLimit)
+	}
+	if len(cfg.ThemeSources) > 0 {
+		sources := make([]map[string]any, len(cfg.ThemeSources))
+		for i, source := range cfg.ThemeSources {
+			sources[i] = map[string]any{
+				"host":     source.Host,
+				"username": source.Username,
+				"password": source.Password,
+				"token":    source.Token,
+			}
+		}
+		v.Set("theme_sources", sources)
+	}
+	if len(cfg.Aliases) > 0 {
+		v.Set("aliases", cfg.Aliases)
+	}
+	if defaults := cfg.Defaults.settings(); len(defaults) > 0 {
+		v.Set("defaults", defaults)
+	}
 
 	return v.WriteConfigAs(configFile)
 }
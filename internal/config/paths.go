@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 )
 
 // Paths represents XDG Base Directory paths for veve configuration and data.
@@ -11,7 +12,7 @@ type Paths struct {
 	ConfigDir string
 	// DataDir is the user's data directory (~/.local/share/veve on Unix, %APPDATA%/veve on Windows)
 	DataDir string
-	// CacheDir is the user's cache directory (~/.cache/veve on Unix, %TEMP%/veve on Windows)
+	// CacheDir is the user's cache directory (~/.cache/veve on Unix, %LOCALAPPDATA%/veve on Windows)
 	CacheDir string
 	// ThemesDir is the directory containing user themes
 	ThemesDir string
@@ -21,45 +22,19 @@ type Paths struct {
 
 // GetPaths returns XDG Base Directory paths for the current platform.
 // On Unix systems, it respects XDG_CONFIG_HOME, XDG_DATA_HOME, and XDG_CACHE_HOME environment variables.
-// On Windows, it uses %APPDATA%.
+// On Windows, it uses %APPDATA% and %LOCALAPPDATA% unless those variables are set.
 func GetPaths() (Paths, error) {
-	var (
-		configDir string
-		dataDir   string
-		cacheDir  string
-	)
-
-	// Determine config directory
-	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
-		configDir = filepath.Join(xdgConfig, "veve")
-	} else {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return Paths{}, err
-		}
-		configDir = filepath.Join(home, ".config", "veve")
+	configDir, err := userDir("XDG_CONFIG_HOME", os.UserConfigDir, ".config")
+	if err != nil {
+		return Paths{}, err
 	}
-
-	// Determine data directory
-	if xdgData := os.Getenv("XDG_DATA_HOME"); xdgData != "" {
-		dataDir = filepath.Join(xdgData, "veve")
-	} else {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return Paths{}, err
-		}
-		dataDir = filepath.Join(home, ".local", "share", "veve")
+	dataDir, err := userDir("XDG_DATA_HOME", os.UserConfigDir, ".local", "share")
+	if err != nil {
+		return Paths{}, err
 	}
-
-	// Determine cache directory
-	if xdgCache := os.Getenv("XDG_CACHE_HOME"); xdgCache != "" {
-		cacheDir = filepath.Join(xdgCache, "veve")
-	} else {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return Paths{}, err
-		}
-		cacheDir = filepath.Join(home, ".cache", "veve")
+	cacheDir, err := userDir("XDG_CACHE_HOME", os.UserCacheDir, ".cache")
+	if err != nil {
+		return Paths{}, err
 	}
 
 	themesDir := filepath.Join(configDir, "themes")
@@ -84,3 +59,30 @@ func (p *Paths) EnsureDirectories() error {
 	}
 	return nil
 }
+
+// userDir returns the veve directory below the XDG directory in xdgVar if it is
+// set, otherwise below windowsDir on Windows, or ~/unixDir elsewhere.
+func userDir(xdgVar string, windowsDir func() (string, error), unixDir ...string) (string, error) {
+	if xdg := os.Getenv(xdgVar); xdg != "" {
+		return filepath.Join(xdg, "veve"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	unixPath := filepath.Join(append(append([]string{home}, unixDir...), "veve")...)
+	if runtime.GOOS != "windows" {
+		return unixPath, nil
+	}
+
+	// Earlier releases used the Unix layout on Windows too; keep using it if present
+	if info, err := os.Stat(unixPath); err == nil && info.IsDir() {
+		return unixPath, nil
+	}
+	base, err := windowsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "veve"), nil
+}
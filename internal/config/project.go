@@ -0,0 +1,244 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// ProjectConfigFile is the per-project configuration file, looked up from the
+	// input file's directory upwards.
+	ProjectConfigFile = ".veve.yaml"
+
+	// ProjectDir is the per-project directory for vendored veve resources.
+	ProjectDir = ".veve"
+)
+
+// ProjectConfig represents a project's .veve.yaml (or a bare .veve directory).
+type ProjectConfig struct {
+	// Root is the directory containing .veve.yaml or .veve
+	Root string `mapstructure:"-"`
+	// ConfigFile is the path to .veve.yaml; empty if the project only has a .veve directory
+	ConfigFile string `mapstructure:"-"`
+	// ThemesDir is the project themes directory, relative to Root (default: .veve/themes)
+	ThemesDir string `mapstructure:"themes_dir"`
+	// Lint configures 'veve check --lint'
+	Lint LintConfig `mapstructure:"lint"`
+	// Hooks are commands run around each conversion
+	Hooks HooksConfig `mapstructure:"hooks"`
+	// Transformers modify the markdown before it is converted, in order
+	Transformers []TransformerConfig `mapstructure:"transformers"`
+	// Vault is the Obsidian vault wiki links resolve against, relative to Root
+	// (default: the nearest directory with an .obsidian directory)
+	Vault string `mapstructure:"vault"`
+	// ResourceDirs are searched for images, bibliographies, CSL styles, and
+	// other files documents name, relative to Root
+	ResourceDirs []string `mapstructure:"resource_dirs"`
+	// ImageExtensions set the extension remote images of a content type are
+	// saved with, adding to or replacing veve's own mappings
+	ImageExtensions []ImageExtension `mapstructure:"image_extensions"`
+	// PrivateMarker marks the HTML comments removed before converting
+	// (default: veve:private)
+	PrivateMarker string `mapstructure:"private_marker"`
+	// Audiences are the div classes --audience chooses between
+	// (default: internal, external)
+	Audiences []string `mapstructure:"audiences"`
+	// Glossary expands acronyms on first use and can list the terms used
+	Glossary GlossaryConfig `mapstructure:"glossary"`
+	// Themes are themes pinned by URL and checksum, which 'veve sync'
+	// installs into the project themes directory
+	Themes []PinnedTheme `mapstructure:"themes"`
+	// Filters are files, such as transformer scripts and plugins, pinned by
+	// URL and checksum, which 'veve sync' downloads into the project
+	Filters []PinnedFilter `mapstructure:"filters"`
+	// Defaults are the project's conversion defaults, which take precedence
+	// over the user's; their directories are relative to Root
+	Defaults Defaults `mapstructure:"defaults"`
+}
+
+// PinnedTheme is a theme downloaded from URL, verified against SHA256: a
+// CSS file, a .vevetheme package, or a zip archive.
+type PinnedTheme struct {
+	// Name is the theme name the project uses
+	Name   string `mapstructure:"name"`
+	URL    string `mapstructure:"url"`
+	SHA256 string `mapstructure:"sha256"`
+	// File selects the theme in a zip archive holding several
+	File string `mapstructure:"file"`
+}
+
+// PinnedFilter is a file downloaded from URL, verified against SHA256.
+type PinnedFilter struct {
+	// Path is where the file is saved, relative to the project root
+	Path   string `mapstructure:"path"`
+	URL    string `mapstructure:"url"`
+	SHA256 string `mapstructure:"sha256"`
+	// Executable marks the file executable, for scripts run as commands
+	Executable bool `mapstructure:"executable"`
+}
+
+// ImageExtension maps a remote image content type to a file extension.
+type ImageExtension struct {
+	// Type is the content type, e.g. image/x-portable-pixmap
+	Type string `mapstructure:"type"`
+	// Extension is the extension engines recognize the format by, e.g. .ppm
+	Extension string `mapstructure:"extension"`
+}
+
+// HooksConfig holds the project's conversion hooks. Each entry is a shell
+// command, run from the project root.
+type HooksConfig struct {
+	// PreConvert commands run before the input is read; a failure stops the conversion
+	PreConvert []string `mapstructure:"pre_convert"`
+	// PostConvert commands run after the PDF is written
+	PostConvert []string `mapstructure:"post_convert"`
+}
+
+// TransformerConfig configures one content transformer. Exactly one of
+// Command, Plugin, and Builtin is set.
+type TransformerConfig struct {
+	// Name identifies the transformer in messages (default: its command, plugin, or builtin)
+	Name string `mapstructure:"name"`
+	// Command is a shell command that reads markdown on stdin and writes it to stdout
+	Command string `mapstructure:"command"`
+	// Plugin is a Go plugin (.so) exporting Transform, relative to the project root
+	Plugin string `mapstructure:"plugin"`
+	// Builtin is a transformer compiled into veve
+	Builtin string `mapstructure:"builtin"`
+}
+
+// GlossaryConfig configures the project's glossary.
+type GlossaryConfig struct {
+	// File is the glossary, relative to Root
+	File string `mapstructure:"file"`
+	// Section appends a section defining the glossary terms a document uses
+	Section bool `mapstructure:"section"`
+	// Title is the section's heading (default: Glossary)
+	Title string `mapstructure:"title"`
+}
+
+// LintConfig holds the project's markdown lint settings.
+type LintConfig struct {
+	// Disable lists rules that are not checked
+	Disable []string `mapstructure:"disable"`
+}
+
+// FindProject searches startDir and its parents for a .veve.yaml file or a .veve
+// directory and loads the project configuration from the nearest one.
+// Returns nil if startDir is not inside a veve project.
+func FindProject(startDir string) (*ProjectConfig, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+
+	for {
+		configFile := filepath.Join(dir, ProjectConfigFile)
+		if info, err := os.Stat(configFile); err == nil && !info.IsDir() {
+			return LoadProjectConfig(configFile)
+		}
+		if info, err := os.Stat(filepath.Join(dir, ProjectDir)); err == nil && info.IsDir() {
+			return &ProjectConfig{Root: dir, ThemesDir: defaultProjectThemesDir()}, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectConfig loads a .veve.yaml file.
+func LoadProjectConfig(configFile string) (*ProjectConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	v.SetConfigType("yaml")
+	v.SetDefault("themes_dir", defaultProjectThemesDir())
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("failed to read %s: %w", configFile, err)
+		}
+	}
+
+	cfg := &ProjectConfig{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configFile, err)
+	}
+	if err := cfg.Defaults.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	cfg.Root = filepath.Dir(configFile)
+	cfg.ConfigFile = configFile
+	cfg.Defaults = cfg.Defaults.resolve(cfg.Root)
+	return cfg, nil
+}
+
+// VaultPath returns the absolute path of the project's Obsidian vault, or ""
+// if .veve.yaml doesn't set one.
+func (p *ProjectConfig) VaultPath() string {
+	if p.Vault == "" || filepath.IsAbs(p.Vault) {
+		return p.Vault
+	}
+	return filepath.Join(p.Root, filepath.FromSlash(p.Vault))
+}
+
+// ResourcePaths returns the absolute paths of the project's resource
+// directories.
+func (p *ProjectConfig) ResourcePaths() []string {
+	paths := make([]string, 0, len(p.ResourceDirs))
+	for _, dir := range p.ResourceDirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(p.Root, filepath.FromSlash(dir))
+		}
+		paths = append(paths, dir)
+	}
+	return paths
+}
+
+// ImageExtensionMap returns the project's image extensions by content type.
+func (p *ProjectConfig) ImageExtensionMap() map[string]string {
+	extensions := make(map[string]string, len(p.ImageExtensions))
+	for _, e := range p.ImageExtensions {
+		extensions[e.Type] = e.Extension
+	}
+	return extensions
+}
+
+// FindVault searches startDir and its parents for an Obsidian vault (a
+// directory containing .obsidian) and returns the nearest one's path.
+// Returns "" if startDir is not inside a vault.
+func FindVault(startDir string) string {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".obsidian")); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ThemesPath returns the absolute path of the project themes directory.
+func (p *ProjectConfig) ThemesPath() string {
+	if filepath.IsAbs(p.ThemesDir) {
+		return p.ThemesDir
+	}
+	return filepath.Join(p.Root, filepath.FromSlash(p.ThemesDir))
+}
+
+func defaultProjectThemesDir() string {
+	return ProjectDir + "/themes"
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Setting is a persistent default in veve.toml, as 'veve config' gets and
+// sets it.
+type Setting struct {
+	// Name is the setting's name on the command line, e.g. pdf-engine
+	Name string
+	// Key is its key in veve.toml, e.g. defaults.pdf_engine
+	Key string
+	// Kind is the type of its value: "string", "bool", or "int"
+	Kind string
+	// Description says what it sets, for 'veve config list --all'
+	Description string
+}
+
+// Settings are the settings 'veve config' manages, in the order it lists them.
+var Settings = []Setting{
+	{"theme", "defaults.theme", "string", "theme to convert with (--theme)"},
+	{"pdf-engine", "defaults.pdf_engine", "string", "PDF engine to convert with (--engine)"},
+	{"output-dir", "defaults.output_dir", "string", "directory PDFs are written to (--output-dir)"},
+	{"sign-key", "defaults.sign_key", "string", "key outputs are signed with (--sign-key)"},
+	{"enable-remote-images", "defaults.remote_images.enabled", "bool", "download and embed remote images (--enable-remote-images)"},
+	{"remote-images-timeout", "defaults.remote_images.timeout", "int", "timeout in seconds for each remote image (--remote-images-timeout)"},
+	{"remote-images-max-retries", "defaults.remote_images.max_retries", "int", "retries for a failed remote image (--remote-images-max-retries)"},
+	{"remote-images-max-count", "defaults.remote_images.max_count", "int", "most remote images a document may reference (--remote-images-max-count)"},
+	{"remote-images-strict-type", "defaults.remote_images.strict_type", "bool", "only accept images served as image/* (--remote-images-strict-type)"},
+	{"remote-images-temp-dir", "defaults.remote_images.temp_dir", "string", "directory remote images are downloaded to (--remote-images-temp-dir)"},
+	{"image-cache", "defaults.remote_images.cache", "bool", "keep remote images across conversions (--image-cache)"},
+	{"metrics", "metrics", "bool", "record conversions in the local metrics log (see 'veve stats')"},
+	{"hooks", "hooks", "bool", "run the hooks and transformers in a project's .veve.yaml without --hooks"},
+}
+
+// LookupSetting returns the setting named name.
+func LookupSetting(name string) (Setting, error) {
+	for _, s := range Settings {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	names := make([]string, len(Settings))
+	for i, s := range Settings {
+		names[i] = s.Name
+	}
+	return Setting{}, fmt.Errorf("unknown setting %q (settings: %s)", name, strings.Join(names, ", "))
+}
+
+// Parse converts value to the setting's type.
+func (s Setting) Parse(value string) (any, error) {
+	switch s.Kind {
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, not %q", s.Name, value)
+		}
+		return b, nil
+	case "int":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number, not %q", s.Name, value)
+		}
+		return n, nil
+	}
+	return value, nil
+}
+
+// ReadSettings returns the values of the settings set in configFile, by
+// name. A missing file has none.
+func ReadSettings(configFile string) (map[string]string, error) {
+	v, err := readConfigFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, s := range Settings {
+		if v.IsSet(s.Key) {
+			values[s.Name] = fmt.Sprint(v.Get(s.Key))
+		}
+	}
+	return values, nil
+}
+
+// SetSetting sets the setting name to value in configFile, creating the
+// file if needed. Only the setting's line is changed: the rest of the file,
+// comments and all, is kept as written. The file is replaced only if the
+// result loads, so an invalid value leaves it as it was.
+func SetSetting(configFile, name, value string) error {
+	s, err := LookupSetting(name)
+	if err != nil {
+		return err
+	}
+	parsed, err := s.Parse(value)
+	if err != nil {
+		return err
+	}
+	return updateConfigFile(configFile, func(doc *tomlDocument) error {
+		return doc.Set(strings.Split(s.Key, "."), formatTOMLValue(parsed))
+	})
+}
+
+// UnsetSetting removes the setting name's line from configFile.
+func UnsetSetting(configFile, name string) error {
+	s, err := LookupSetting(name)
+	if err != nil {
+		return err
+	}
+	return updateConfigFile(configFile, func(doc *tomlDocument) error {
+		_, err := doc.Unset(strings.Split(s.Key, "."))
+		return err
+	})
+}
+
+// readConfigFile reads configFile's own settings, without defaults.
+func readConfigFile(configFile string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %w", configFile, err)
+		}
+	}
+	return v, nil
+}
+
+// updateConfigFile applies update to configFile and replaces the file with
+// the result, once LoadConfig accepts it.
+func updateConfigFile(configFile string, update func(doc *tomlDocument) error) error {
+	content, err := os.ReadFile(configFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", configFile, err)
+	}
+	doc := parseTOMLDocument(string(content))
+	if err := update(doc); err != nil {
+		return fmt.Errorf("failed to update %s: %w", configFile, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(configFile), ".veve-*.toml")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", configFile, err)
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	_, err = f.WriteString(doc.String())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", configFile, err)
+	}
+	if _, err := LoadConfig(tmp); err != nil {
+		return err
+	}
+	if info, err := os.Stat(configFile); err == nil {
+		if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", configFile, err)
+		}
+	}
+	if err := os.Rename(tmp, configFile); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configFile, err)
+	}
+	return nil
+}
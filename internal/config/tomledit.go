@@ -0,0 +1,405 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tomlDocument is a TOML file as lines, edited one key at a time so that
+// everything else in it (comments, blank lines, the order and case of other
+// keys) stays as written. It understands as much TOML as finding a key
+// needs: table headers, dotted and quoted keys, multi-line strings and
+// arrays, which are skipped over, and inline tables, whose keys are left to
+// be edited by hand.
+type tomlDocument struct {
+	lines []string
+}
+
+// tomlEntry is a key/value line of a tomlDocument.
+type tomlEntry struct {
+	line   int      // Index in lines
+	table  []string // Path of the table the line is in
+	key    []string // Key, relative to table
+	value  int      // Offset of the value in the line
+	inline bool     // The value is an inline table, holding keys below key
+}
+
+// bareTOMLKey matches keys that need no quotes.
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// parseTOMLDocument splits content into a tomlDocument.
+func parseTOMLDocument(content string) *tomlDocument {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return &tomlDocument{}
+	}
+	return &tomlDocument{lines: strings.Split(content, "\n")}
+}
+
+// String returns the document as a file.
+func (d *tomlDocument) String() string {
+	if len(d.lines) == 0 {
+		return ""
+	}
+	return strings.Join(d.lines, "\n") + "\n"
+}
+
+// scan returns the document's key/value lines, and the index of each table
+// header, by path. The lines of a value spanning several are skipped.
+func (d *tomlDocument) scan() ([]tomlEntry, map[string]int) {
+	var entries []tomlEntry
+	headers := make(map[string]int)
+	var table []string
+	open := "" // Closes the multi-line string or array a line is in
+	depth := 0
+	for i, line := range d.lines {
+		if open != "" {
+			if open == "]" {
+				depth += bracketDepth(line)
+				if depth <= 0 {
+					open = ""
+				}
+			} else if strings.Count(line, open)%2 == 1 {
+				open = ""
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "[["):
+			// An array of tables; its keys are never a setting's
+			end := strings.Index(trimmed, "]]")
+			if end < 0 {
+				continue
+			}
+			table = append(splitTOMLKey(trimmed[2:end]), "[]")
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				continue
+			}
+			table = splitTOMLKey(trimmed[1:end])
+			headers[strings.Join(table, ".")] = i
+			continue
+		}
+
+		eq := keyEnd(line)
+		if eq < 0 {
+			continue
+		}
+		value := eq + 1
+		for value < len(line) && (line[value] == ' ' || line[value] == '\t') {
+			value++
+		}
+		rest := line[value:]
+		entries = append(entries, tomlEntry{line: i, table: table, key: splitTOMLKey(line[:eq]), value: value, inline: strings.HasPrefix(rest, "{")})
+
+		switch {
+		case strings.HasPrefix(rest, `"""`) && strings.Count(rest, `"""`) == 1:
+			open = `"""`
+		case strings.HasPrefix(rest, `'''`) && strings.Count(rest, `'''`) == 1:
+			open = `'''`
+		case strings.HasPrefix(rest, "["):
+			if depth = bracketDepth(rest); depth > 0 {
+				open = "]"
+			}
+		}
+	}
+	return entries, headers
+}
+
+// find returns the entry for path, if the document has one. A path inside
+// an inline table has no line of its own, so it is an error.
+func (d *tomlDocument) find(path []string) (tomlEntry, bool, error) {
+	entries, _ := d.scan()
+	want := strings.Join(path, ".")
+	for _, entry := range entries {
+		full := strings.Join(append(append([]string{}, entry.table...), entry.key...), ".")
+		switch {
+		case full == want:
+			return entry, true, nil
+		case entry.inline && strings.HasPrefix(want, full+"."):
+			return tomlEntry{}, false, fmt.Errorf("%s: in the inline table %s, not a single value; edit it by hand", want, full)
+		}
+	}
+	return tomlEntry{}, false, nil
+}
+
+// Set sets the key at path to value, a TOML value as written in a file.
+// An existing key keeps its place and any comment after its value; a new
+// one is added at the end of its table, which is added if needed.
+func (d *tomlDocument) Set(path []string, value string) error {
+	entry, ok, err := d.find(path)
+	if err != nil {
+		return err
+	}
+	if ok {
+		line := d.lines[entry.line]
+		end, err := valueEnd(line, entry.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+		}
+		d.lines[entry.line] = line[:entry.value] + value + line[end:]
+		return nil
+	}
+
+	table, key := path[:len(path)-1], path[len(path)-1]
+	newLine := formatTOMLKey(key) + " = " + value
+	entries, headers := d.scan()
+
+	// After the table's last key, or its header
+	at := -1
+	if len(table) == 0 {
+		for _, entry := range entries {
+			if len(entry.table) == 0 {
+				at = entry.line + 1
+			}
+		}
+		if at < 0 {
+			d.insertRootKey(newLine)
+			return nil
+		}
+	} else {
+		header, ok := headers[strings.Join(table, ".")]
+		if !ok {
+			d.appendTable(table, newLine)
+			return nil
+		}
+		at = header + 1
+		for _, entry := range entries {
+			if entry.line > header && strings.Join(entry.table, ".") == strings.Join(table, ".") {
+				at = entry.line + 1
+			}
+		}
+	}
+	d.insert(at, newLine)
+	return nil
+}
+
+// Unset removes the key at path, and its table's header if the table is
+// left with nothing but blank lines. It reports whether the key was there.
+func (d *tomlDocument) Unset(path []string) (bool, error) {
+	entry, ok, err := d.find(path)
+	if !ok || err != nil {
+		return false, err
+	}
+	if _, err := valueEnd(d.lines[entry.line], entry.value); err != nil {
+		return false, fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+	}
+	d.lines = append(d.lines[:entry.line], d.lines[entry.line+1:]...)
+
+	if len(entry.table) == 0 {
+		return true, nil
+	}
+	_, headers := d.scan()
+	header, ok := headers[strings.Join(entry.table, ".")]
+	if !ok {
+		return true, nil
+	}
+	end := header + 1
+	for end < len(d.lines) && strings.TrimSpace(d.lines[end]) == "" {
+		end++
+	}
+	if end == len(d.lines) || strings.HasPrefix(strings.TrimSpace(d.lines[end]), "[") {
+		// The blank line before the header separated it from what came before
+		start := header
+		if start > 0 && strings.TrimSpace(d.lines[start-1]) == "" {
+			start--
+		}
+		d.lines = append(d.lines[:start], d.lines[end:]...)
+		if start > 0 && start < len(d.lines) {
+			d.insert(start, "")
+		}
+	}
+	return true, nil
+}
+
+// insert adds line before index at.
+func (d *tomlDocument) insert(at int, line string) {
+	d.lines = append(d.lines[:at], append([]string{line}, d.lines[at:]...)...)
+}
+
+// insertRootKey adds line as the first key outside any table: before the
+// first header and the comments directly above it.
+func (d *tomlDocument) insertRootKey(line string) {
+	_, headers := d.scan()
+	first := len(d.lines)
+	for _, i := range headers {
+		first = min(first, i)
+	}
+	for i, l := range d.lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "[[") {
+			first = min(first, i)
+			break
+		}
+	}
+	if first == len(d.lines) {
+		d.lines = append(d.lines, line)
+		return
+	}
+	at := first
+	for at > 0 && strings.HasPrefix(strings.TrimSpace(d.lines[at-1]), "#") {
+		at--
+	}
+	d.insert(at, line)
+	d.insert(at+1, "")
+}
+
+// appendTable adds a table with one key at the end of the document.
+func (d *tomlDocument) appendTable(table []string, line string) {
+	if len(d.lines) > 0 && strings.TrimSpace(d.lines[len(d.lines)-1]) != "" {
+		d.lines = append(d.lines, "")
+	}
+	keys := make([]string, len(table))
+	for i, key := range table {
+		keys[i] = formatTOMLKey(key)
+	}
+	d.lines = append(d.lines, "["+strings.Join(keys, ".")+"]", line)
+}
+
+// keyEnd returns the index of the = ending the key of a key/value line, or
+// -1 if line isn't one.
+func keyEnd(line string) int {
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case '"', '\'':
+			end := strings.IndexByte(line[i+1:], c)
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '=':
+			return i
+		case '#':
+			return -1
+		}
+	}
+	return -1
+}
+
+// valueEnd returns the end of the single-line value starting at start in
+// line, before any whitespace and comment after it.
+func valueEnd(line string, start int) (int, error) {
+	rest := line[start:]
+	switch {
+	case strings.HasPrefix(rest, `"""`), strings.HasPrefix(rest, `'''`),
+		strings.HasPrefix(rest, "["), strings.HasPrefix(rest, "{"):
+		return 0, fmt.Errorf("not a single value; edit it by hand")
+	case strings.HasPrefix(rest, `"`):
+		for i := 1; i < len(rest); i++ {
+			switch rest[i] {
+			case '\\':
+				i++
+			case '"':
+				return start + i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated string")
+	case strings.HasPrefix(rest, "'"):
+		end := strings.IndexByte(rest[1:], '\'')
+		if end < 0 {
+			return 0, fmt.Errorf("unterminated string")
+		}
+		return start + end + 2, nil
+	}
+	end := strings.IndexAny(rest, " \t#")
+	if end < 0 {
+		return len(line), nil
+	}
+	return start + end, nil
+}
+
+// bracketDepth returns the brackets s opens and doesn't close, outside
+// strings and comments.
+func bracketDepth(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return depth
+			}
+			i += end + 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '#':
+			return depth
+		}
+	}
+	return depth
+}
+
+// splitTOMLKey splits a dotted key into its parts, unquoting quoted ones.
+func splitTOMLKey(key string) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; c {
+		case '"', '\'':
+			end := strings.IndexByte(key[i+1:], c)
+			if end < 0 {
+				end = len(key) - i - 1
+			}
+			part.WriteString(key[i+1 : i+1+end])
+			i += end + 1
+		case '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	return append(parts, strings.TrimSpace(part.String()))
+}
+
+// formatTOMLKey returns key as written in a file, quoted unless it is bare.
+func formatTOMLKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return formatTOMLString(key)
+}
+
+// formatTOMLValue returns a setting's value as written in a file.
+func formatTOMLValue(value any) string {
+	if s, ok := value.(string); ok {
+		return formatTOMLString(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// formatTOMLString returns s as a TOML basic string.
+func formatTOMLString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package converter
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultAudiences are the audiences a project has unless it lists its own.
+var DefaultAudiences = []string{"internal", "external"}
+
+// FilterAudience keeps the fenced divs meant for audience and removes those
+// meant only for other audiences, so one source produces a document for
+// each. A div is meant for the audiences among its classes:
+//
+//	::: {.internal}
+//	Margins are below target in two regions.
+//	:::
+//
+// Divs without an audience class, and nested divs of a kept div, are
+// decided on their own; divs in fenced code blocks are left alone. An empty
+// audience keeps everything.
+func FilterAudience(content, audience string, audiences []string) string {
+	if audience == "" || !strings.Contains(content, ":::") {
+		return content
+	}
+
+	var b strings.Builder
+	b.Grow(len(content))
+	var fence string
+	depth, skip := 0, 0 // open divs, and the depth of the div being removed (0 for none)
+	for _, line := range strings.SplitAfter(content, "\n") {
+		keep := skip == 0
+		switch {
+		case fence != "":
+			if closesFence(line, fence) {
+				fence = ""
+			}
+		case openingFence(line) != "":
+			fence = openingFence(line)
+		default:
+			if classes, ok := divOpener(line); ok {
+				depth++
+				if skip == 0 && !forAudience(classes, audience, audiences) {
+					skip, keep = depth, false
+				}
+			} else if depth > 0 && divCloser(line) {
+				if skip == depth {
+					skip = 0
+				}
+				depth--
+			}
+		}
+		if keep {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// forAudience reports whether a div with classes is meant for audience: it
+// has no audience class, or audience is one of them.
+func forAudience(classes []string, audience string, audiences []string) bool {
+	targeted := false
+	for _, class := range classes {
+		if slices.Contains(audiences, class) {
+			if class == audience {
+				return true
+			}
+			targeted = true
+		}
+	}
+	return !targeted
+}
+
+// divOpener returns the classes of a fenced div opened on line, such as
+// "::: {.internal .note}" or "::: internal".
+func divOpener(line string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimRight(line, " \t\r\n"), ":::")
+	if !ok {
+		return nil, false
+	}
+	rest = strings.TrimSpace(strings.Trim(rest, ":"))
+	if rest == "" {
+		return nil, false
+	}
+
+	if attrs, ok := strings.CutPrefix(rest, "{"); ok {
+		attrs, ok = strings.CutSuffix(attrs, "}")
+		if !ok {
+			return nil, false
+		}
+		var classes []string
+		for _, field := range strings.Fields(attrs) {
+			if class, ok := strings.CutPrefix(field, "."); ok {
+				classes = append(classes, class)
+			}
+		}
+		return classes, true
+	}
+	if strings.ContainsAny(rest, " \t") {
+		return nil, false
+	}
+	return []string{rest}, true
+}
+
+// divCloser reports whether line closes a fenced div: three or more colons
+// and nothing else.
+func divCloser(line string) bool {
+	trimmed := strings.TrimRight(line, " \t\r\n")
+	return len(trimmed) >= 3 && strings.Trim(trimmed, ":") == ""
+}
@@ -0,0 +1,53 @@
+package converter
+
+// MemoryBudget is the memory veve itself aims to stay within, in bytes, so
+// it can run in small CI containers; 0 means no budget. It scales down the
+// work veve keeps in memory at once: concurrent image downloads, copy
+// buffers, and the sections large documents are prepared in, and makes PDFs
+// written to stdout stream from disk instead of being read whole. Pandoc and
+// the PDF engine are capped separately, with Limits.
+type MemoryBudget int64
+
+// Defaults without a budget, and what each unit of work is assumed to need
+const (
+	defaultImageConcurrency = 5
+	defaultCopyBufferSize   = 256 << 10
+	minCopyBufferSize       = 32 << 10
+	minSectionSize          = 64 << 10
+
+	// Memory per concurrent image download (HTTP and TLS state, buffers)
+	imageDownloadMemory = 16 << 20
+	// Syntax trees are built many times the size of the markdown they parse
+	sectionMemoryFactor = 64
+)
+
+// ImageConcurrency returns how many remote images to download at once.
+func (b MemoryBudget) ImageConcurrency() int {
+	if b <= 0 {
+		return defaultImageConcurrency
+	}
+	return int(min(max(int64(b)/imageDownloadMemory, 1), defaultImageConcurrency))
+}
+
+// CopyBufferSize returns the buffer size for copying downloads and output.
+func (b MemoryBudget) CopyBufferSize() int {
+	if b <= 0 {
+		return defaultCopyBufferSize
+	}
+	return int(min(max(int64(b)/1024, minCopyBufferSize), defaultCopyBufferSize))
+}
+
+// SectionSize returns the size to split large documents into for
+// preparation (see SplitSections).
+func (b MemoryBudget) SectionSize() int {
+	if b <= 0 {
+		return SectionSize
+	}
+	return int(min(max(int64(b)/sectionMemoryFactor, minSectionSize), SectionSize))
+}
+
+// StreamOutput reports whether a PDF written to stdout is copied from disk
+// in CopyBufferSize pieces rather than read into memory first.
+func (b MemoryBudget) StreamOutput() bool {
+	return b > 0
+}
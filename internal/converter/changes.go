@@ -0,0 +1,204 @@
+package converter
+
+import (
+	"strings"
+)
+
+// Change bars are drawn by the changebar package in LaTeX and by .changed in
+// CSS; themes can restyle either (\cbcolor, .changed).
+const (
+	changeLaTeX = `\usepackage{changebar}\cbcolor{red}\setlength{\changebarsep}{1.5em}`
+	changeCSS   = `.changed { border-left: 3px solid #c00; margin-left: -0.9em; padding-left: calc(0.9em - 3px); }
+`
+)
+
+// maxDiffCells caps the blocks compared by MarkChanges' longest common
+// subsequence (old blocks times new ones, after common ends are trimmed);
+// larger documents are compared by which blocks appear in old at all.
+const maxDiffCells = 1 << 24
+
+// MarkChanges marks the blocks of content (paragraphs, headings, lists,
+// tables, code blocks) that are new or changed since old with change bars
+// in the margin, and reports whether any are. Blocks are compared with
+// whitespace collapsed, so rewrapping a paragraph doesn't mark it; removed
+// blocks are not marked. Consecutive changed blocks share one bar, drawn
+// by a `::: {.changed}` div for HTML engines and \cbstart and \cbend for
+// LaTeX. Front matter is never marked.
+func MarkChanges(content, old string) (string, bool) {
+	bodyStart := frontMatterEnd(content)
+	blocks := splitBlocks(content[bodyStart:])
+	changed := changedBlocks(blockKeys(blocks), blockKeys(splitBlocks(old[frontMatterEnd(old):])))
+
+	var sb strings.Builder
+	sb.WriteString(content[:bodyStart])
+	found := false
+	for i := 0; i < len(blocks); {
+		if !changed[i] {
+			sb.WriteString(blocks[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(blocks) && changed[j] {
+			j++
+		}
+		run := strings.Join(blocks[i:j], "")
+		body := strings.TrimRight(run, " \t\r\n")
+		sb.WriteString("::: {.changed}\n```{=latex}\n\\cbstart\n```\n\n")
+		sb.WriteString(body)
+		sb.WriteString("\n\n```{=latex}\n\\cbend\n```\n:::\n")
+		// Keep the blank lines after the run
+		if _, blankLines, ok := strings.Cut(run[len(body):], "\n"); ok {
+			sb.WriteString(blankLines)
+		}
+		found = true
+		i = j
+	}
+	return sb.String(), found
+}
+
+// splitBlocks splits markdown into blocks, which concatenate back to
+// content, each with the blank lines after it. A block ends at a blank line
+// followed by an unindented line, outside fenced code and fenced divs; the
+// items of a list, and the lines indented under them, stay one block.
+func splitBlocks(content string) []string {
+	var blocks []string
+	start, offset := 0, 0
+	var fence string
+	depth := 0       // open fenced divs
+	blank := false   // the previous line is blank
+	started := false // the block has a non-blank line
+	list := false    // the block is a list
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		if fence != "" {
+			if closesFence(line, fence) {
+				fence = ""
+			}
+			offset += len(line)
+			continue
+		}
+
+		isBlank := strings.TrimSpace(line) == ""
+		if !isBlank && blank && depth == 0 && started && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") &&
+			!(list && isListItem(line)) {
+			blocks = append(blocks, content[start:offset])
+			start, started = offset, false
+		}
+		if !isBlank && !started {
+			started, list = true, isListItem(line)
+		}
+
+		if f := openingFence(line); f != "" {
+			fence = f
+		} else if _, ok := divOpener(line); ok {
+			depth++
+		} else if depth > 0 && divCloser(line) {
+			depth--
+		}
+		blank = isBlank
+		offset += len(line)
+	}
+	if start < len(content) {
+		blocks = append(blocks, content[start:])
+	}
+	return blocks
+}
+
+// isListItem reports whether line starts a list item: "-", "*", or "+", or
+// a number followed by "." or ")", then a space.
+func isListItem(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	n := 0
+	for n < len(trimmed) && n < 9 && trimmed[n] >= '0' && trimmed[n] <= '9' {
+		n++
+	}
+	switch {
+	case n == 0 && len(trimmed) > 0 && strings.ContainsRune("-*+", rune(trimmed[0])):
+		n = 1
+	case n > 0 && n < len(trimmed) && (trimmed[n] == '.' || trimmed[n] == ')'):
+		n++
+	default:
+		return false
+	}
+	return n == len(trimmed) || strings.ContainsRune(" \t\r\n", rune(trimmed[n]))
+}
+
+// blockKeys returns blocks with their whitespace collapsed, for comparing.
+func blockKeys(blocks []string) []string {
+	keys := make([]string, len(blocks))
+	for i, block := range blocks {
+		keys[i] = strings.Join(strings.Fields(block), " ")
+	}
+	return keys
+}
+
+// changedBlocks reports which of keys are not in old: those outside a
+// longest common subsequence of the two.
+func changedBlocks(keys, old []string) []bool {
+	changed := make([]bool, len(keys))
+
+	// Trim the unchanged start and end
+	prefix := 0
+	for prefix < len(keys) && prefix < len(old) && keys[prefix] == old[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(keys)-prefix && suffix < len(old)-prefix && keys[len(keys)-1-suffix] == old[len(old)-1-suffix] {
+		suffix++
+	}
+	a, b := keys[prefix:len(keys)-suffix], old[prefix:len(old)-suffix]
+	for i, key := range a {
+		changed[prefix+i] = key != ""
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return changed
+	}
+
+	if len(a)*len(b) > maxDiffCells {
+		seen := make(map[string]bool, len(b))
+		for _, key := range b {
+			seen[key] = true
+		}
+		for i, key := range a {
+			if seen[key] {
+				changed[prefix+i] = false
+			}
+		}
+		return changed
+	}
+
+	// lengths[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:]
+	lengths := make([][]int32, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			changed[prefix+i] = false
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return changed
+}
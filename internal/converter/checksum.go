@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Checksum algorithms for --checksum.
+const (
+	ChecksumOff    = ""
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+// ValidateChecksum checks a checksum algorithm.
+func ValidateChecksum(algorithm string) error {
+	switch algorithm {
+	case ChecksumOff, ChecksumSHA256, ChecksumSHA512:
+		return nil
+	}
+	return fmt.Errorf("invalid checksum algorithm %q: use %q or %q", algorithm, ChecksumSHA256, ChecksumSHA512)
+}
+
+// WriteChecksum writes the checksum of the file at path to a sidecar file
+// named after it with the algorithm as extension (report.pdf.sha256), in the
+// format sha256sum and sha512sum check with -c. Returns the checksum, in hex.
+func WriteChecksum(path, algorithm string) (string, error) {
+	var h hash.Hash
+	switch algorithm {
+	case ChecksumSHA256:
+		h = sha256.New()
+	case ChecksumSHA512:
+		h = sha512.New()
+	default:
+		return "", ValidateChecksum(algorithm)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s for its checksum: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s for its checksum: %w", path, err)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	// The sidecar names the file without its directory, so it checks from
+	// wherever the two are published together
+	line := sum + "  " + filepath.Base(path) + "\n"
+	if err := os.WriteFile(path+"."+algorithm, []byte(line), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write checksum file: %w", err)
+	}
+	return sum, nil
+}
@@ -148,3 +148,20 @@ func randomString(length int) string {
 	}
 	return string(b)
 }
+
+// TestPdfEngineOpts tests that engine arguments keep their leading dashes.
+func TestPdfEngineOpts(t *testing.T) {
+	got := pdfEngineOpts([]string{"-shell-escape", "--presentational-hints"})
+	want := []string{"--pdf-engine-opt=-shell-escape", "--pdf-engine-opt=--presentational-hints"}
+	if len(got) != len(want) {
+		t.Fatalf("pdfEngineOpts = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("pdfEngineOpts[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if got := pdfEngineOpts(nil); len(got) != 0 {
+		t.Errorf("pdfEngineOpts(nil) = %v, want none", got)
+	}
+}
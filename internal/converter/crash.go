@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// crashStderrLines is how many of the last lines of stderr a CrashError keeps.
+const crashStderrLines = 10
+
+// CrashError is returned when pandoc is killed by a signal (on Windows, ends
+// with a fatal exception) instead of exiting with an error, as when it runs
+// out of memory or crashes on an unusually large or deeply nested document.
+type CrashError struct {
+	Program string   // The program that crashed, e.g. "pandoc"
+	Signal  string   // What ended it, e.g. "SIGKILL"
+	Cause   string   // Its likely cause, e.g. "out of memory", or ""
+	Stderr  []string // The last lines it wrote to stderr
+	Engine  string   // The PDF engine it ran, or "" for other formats
+	Limits  Limits   // The resource limits it ran with
+	Err     error    // The error from running it
+}
+
+// Error names the signal and its likely cause, followed by the last lines
+// of stderr and what to try instead.
+func (e *CrashError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s crashed: killed by %s", e.Program, e.Signal)
+	if e.Cause != "" {
+		fmt.Fprintf(&b, ", likely %s", e.Cause)
+	}
+	if len(e.Stderr) > 0 {
+		fmt.Fprintf(&b, "\nLast lines of %s stderr:", e.Program)
+		for _, line := range e.Stderr {
+			b.WriteString("\n  " + line)
+		}
+	}
+	b.WriteString("\nTo convert it anyway:")
+	for _, hint := range e.Hints() {
+		b.WriteString("\n  - " + hint)
+	}
+	return b.String()
+}
+
+// Hints suggests how to convert the document anyway.
+func (e *CrashError) Hints() []string {
+	var hints []string
+	if !e.Limits.IsZero() {
+		hints = append(hints, fmt.Sprintf("raise --cpu-limit or --memory-limit (limits: %s)", e.Limits))
+	}
+	hints = append(hints, "reduce the document's size: split it into parts, or shrink large images and tables")
+	switch {
+	case engines.IsLaTeXEngine(e.Engine):
+		hints = append(hints, "switch engines, e.g. --engine weasyprint, which needs less memory than LaTeX")
+	case e.Engine != "":
+		hints = append(hints, "switch engines with --engine")
+	}
+	return hints
+}
+
+// Unwrap returns the error from running the program.
+func (e *CrashError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKind classifies the error as a conversion error.
+func (e *CrashError) ErrorKind() internal.Kind {
+	return internal.KindPandoc
+}
+
+// newCrashError returns a CrashError for err, from running program with
+// limits, if it was killed by a signal, and nil otherwise. stderr is what
+// the program wrote to stderr, and engine the PDF engine it ran, or "" if
+// another engine can't be used.
+func newCrashError(program string, err error, stderr, engine string, limits Limits) *CrashError {
+	signal, cause, ok := crashSignal(err)
+	if !ok {
+		return nil
+	}
+	return &CrashError{
+		Program: program,
+		Signal:  signal,
+		Cause:   cause,
+		Stderr:  lastLines(stderr, crashStderrLines),
+		Engine:  engine,
+		Limits:  limits,
+		Err:     err,
+	}
+}
+
+// lastLines returns the last n non-blank lines of s.
+func lastLines(s string, n int) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
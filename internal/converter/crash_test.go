@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewCrashError verifies a program killed by a signal is reported as a
+// crash, with the end of its stderr, and one exiting with an error is not.
+func TestNewCrashError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals are not delivered on Windows")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	run := func(script string) (string, error) {
+		cmd := exec.Command("sh", "-c", script)
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		return stderr.String(), err
+	}
+
+	stderr, err := run(`for i in 1 2 3 4 5 6 7 8 9 10 11 12; do echo "line $i" >&2; done; kill -SEGV $$`)
+	crash := newCrashError("pandoc", err, stderr, "xelatex", Limits{CPUTime: time.Minute})
+	if crash == nil {
+		t.Fatalf("expected a crash for %v", err)
+	}
+	if crash.Signal != "SIGSEGV" {
+		t.Errorf("Signal = %q, want SIGSEGV", crash.Signal)
+	}
+	if len(crash.Stderr) != crashStderrLines || crash.Stderr[0] != "line 3" || crash.Stderr[len(crash.Stderr)-1] != "line 12" {
+		t.Errorf("Stderr = %q, want lines 3 to 12", crash.Stderr)
+	}
+	message := crash.Error()
+	for _, want := range []string{"pandoc crashed: killed by SIGSEGV", "Last lines of pandoc stderr:\n  line 3", "--cpu-limit", "--engine weasyprint"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message missing %q:\n%s", want, message)
+		}
+	}
+	if !errors.Is(crash, err) {
+		t.Error("expected the crash to wrap the run error")
+	}
+
+	stderr, err = run(`echo "pandoc: could not parse" >&2; exit 1`)
+	if crash := newCrashError("pandoc", err, stderr, "", Limits{}); crash != nil {
+		t.Errorf("expected no crash for %v, got %v", err, crash)
+	}
+
+	stderr, err = run(`kill -TERM $$`)
+	if crash := newCrashError("pandoc", err, stderr, "", Limits{}); crash != nil {
+		t.Errorf("expected no crash for a terminated program, got %v", crash)
+	}
+}
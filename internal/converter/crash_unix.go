@@ -0,0 +1,44 @@
+//go:build !windows
+
+package converter
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// signalCauses are the likely causes of a program being killed by a signal.
+var signalCauses = map[syscall.Signal]string{
+	syscall.SIGKILL: "out of memory (the system's out-of-memory killer)",
+	syscall.SIGXCPU: "its CPU time limit",
+	syscall.SIGSEGV: "a crash in pandoc, which can run out of stack on deeply nested documents",
+	syscall.SIGBUS:  "a crash in pandoc",
+	syscall.SIGABRT: "a crash in pandoc",
+}
+
+// crashSignal returns the name of the signal that killed the program err
+// is from, and its likely cause. Interrupts and termination requests are
+// not crashes.
+func crashSignal(err error) (signal, cause string, ok bool) {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return "", "", false
+	}
+	status, isStatus := exitErr.Sys().(syscall.WaitStatus)
+	if !isStatus || !status.Signaled() {
+		return "", "", false
+	}
+	sig := status.Signal()
+	switch sig {
+	case syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP:
+		return "", "", false
+	}
+	name := unix.SignalName(sig)
+	if name == "" {
+		name = sig.String()
+	}
+	return name, signalCauses[sig], true
+}
@@ -0,0 +1,31 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// exceptionCauses names the fatal exceptions a Windows program's exit code
+// reports, and their likely causes.
+var exceptionCauses = map[uint32]struct{ name, cause string }{
+	0xC0000005: {"STATUS_ACCESS_VIOLATION", "a crash in pandoc"},
+	0xC0000017: {"STATUS_NO_MEMORY", "out of memory"},
+	0xC00000FD: {"STATUS_STACK_OVERFLOW", "a crash in pandoc, which can run out of stack on deeply nested documents"},
+	0xC0000409: {"STATUS_STACK_BUFFER_OVERRUN", "a crash in pandoc"},
+}
+
+// crashSignal returns the fatal exception the program err is from ended
+// with, and its likely cause.
+func crashSignal(err error) (signal, cause string, ok bool) {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return "", "", false
+	}
+	code := uint32(exitErr.ExitCode())
+	exception, known := exceptionCauses[code]
+	if !known {
+		return "", "", false
+	}
+	return fmt.Sprintf("%s (0x%X)", exception.name, code), exception.cause, true
+}
@@ -0,0 +1,178 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// dockerCommand returns the command that runs pandoc with args inside image.
+// The working directory and the directories the conversion reads and writes
+// are mounted into the container at the same paths, so args need no rewriting
+// (other than drive letters on Windows).
+func dockerCommand(image string, args []string, opts ConversionOptions, outputPath string, interactive bool) (*exec.Cmd, error) {
+	if err := engines.ValidateDockerImage(image); err != nil {
+		return nil, err
+	}
+	dockerPath, err := exec.LookPath("docker")
+	if err != nil {
+		return nil, fmt.Errorf("docker not found in PATH: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	user := ""
+	if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 && gid >= 0 {
+		// Write the PDF as the invoking user rather than root
+		user = fmt.Sprintf("%d:%d", uid, gid)
+	}
+
+	run := dockerRun{
+		Image:       image,
+		Mounts:      mountDirs(cwd, opts, outputPath),
+		Workdir:     cwd,
+		User:        user,
+		Interactive: interactive,
+		NoNetwork:   opts.Sandbox != SandboxOff,
+		Limits:      opts.Limits,
+	}
+	return exec.Command(dockerPath, run.args(containerArgs(args))...), nil
+}
+
+// dockerRun describes a container running pandoc.
+type dockerRun struct {
+	Image       string
+	Mounts      []string // Host directories, mounted at their container paths
+	Workdir     string
+	User        string // uid:gid to run as (optional)
+	Interactive bool   // Pass stdin to pandoc
+	NoNetwork   bool   // Disable networking (sandboxed conversions)
+	Limits      Limits // CPU time and memory limits for the container
+}
+
+// args builds the docker run arguments for running pandoc with pandocArgs.
+func (r dockerRun) args(pandocArgs []string) []string {
+	args := []string{"run", "--rm"}
+	if r.Interactive {
+		args = append(args, "-i")
+	}
+	if r.NoNetwork {
+		args = append(args, "--network", "none")
+	}
+	if r.Limits.CPUTime > 0 {
+		seconds := r.Limits.cpuSeconds()
+		args = append(args, "--ulimit", fmt.Sprintf("cpu=%d:%d", seconds, seconds))
+	}
+	if r.Limits.Memory > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%d", r.Limits.Memory))
+	}
+	if r.User != "" {
+		args = append(args, "--user", r.User)
+	}
+	for _, dir := range r.Mounts {
+		args = append(args, "-v", dir+":"+containerPath(dir))
+	}
+	// "--" ends docker's options, so the image is never read as one
+	args = append(args, "-w", containerPath(r.Workdir), "--", r.Image)
+	return append(args, pandocArgs...)
+}
+
+// mountDirs returns the directories the container needs: the working
+// directory, the temp directory (workspace and include files), and the
+// directories of every file the conversion reads or writes. Directories
+// inside another mounted directory are left out.
+func mountDirs(cwd string, opts ConversionOptions, outputPath string) []string {
+	paths := []string{cwd, os.TempDir()}
+	for _, file := range []string{opts.InputFile, outputPath, opts.Theme, opts.Template} {
+		if file != "" && file != "-" {
+			paths = append(paths, filepath.Dir(file))
+		}
+	}
+	paths = append(paths, opts.ResourcePath...)
+	for _, value := range opts.Variables {
+		if filepath.IsAbs(value) {
+			paths = append(paths, filepath.Dir(value))
+		}
+	}
+
+	var dirs []string
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		dirs = append(dirs, filepath.Clean(path))
+	}
+
+	// Shortest first, so parents are kept before the directories inside them
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) < len(dirs[j]) })
+	var mounts []string
+	for _, dir := range dirs {
+		covered := false
+		for _, mount := range mounts {
+			if dir == mount || strings.HasPrefix(dir, strings.TrimSuffix(mount, string(filepath.Separator))+string(filepath.Separator)) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			mounts = append(mounts, dir)
+		}
+	}
+	return mounts
+}
+
+// containerArgs maps the host paths in pandoc args to container paths.
+func containerArgs(args []string) []string {
+	mapped := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case i > 0 && args[i-1] == "--resource-path":
+			dirs := strings.Split(arg, string(os.PathListSeparator))
+			for j, dir := range dirs {
+				dirs[j] = containerPath(dir)
+			}
+			mapped[i] = strings.Join(dirs, ":")
+		case filepath.IsAbs(arg) || (i > 0 && pathFlags[args[i-1]]):
+			mapped[i] = containerPath(arg)
+		default:
+			// Template variables holding paths, e.g. cover-image=/abs/path
+			if name, value, ok := strings.Cut(arg, "="); ok && filepath.IsAbs(value) {
+				mapped[i] = name + "=" + containerPath(value)
+			} else {
+				mapped[i] = arg
+			}
+		}
+	}
+	return mapped
+}
+
+// pathFlags are the pandoc flags whose value is a file path.
+var pathFlags = map[string]bool{
+	"-o":                   true,
+	"--css":                true,
+	"--template":           true,
+	"--include-in-header":  true,
+	"--include-after-body": true,
+}
+
+// containerPath returns the path a host path is mounted at in the container.
+// Paths are unchanged except on Windows, where C:\dir becomes /c/dir.
+func containerPath(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	volume := filepath.VolumeName(path)
+	drive := strings.ToLower(strings.TrimSuffix(volume, ":"))
+	return "/" + drive + filepath.ToSlash(strings.TrimPrefix(path, volume))
+}
@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+// TestDockerRunArgs verifies the container is run with the mounts, working
+// directory, user, and limits, followed by the pandoc arguments.
+func TestDockerRunArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("container paths differ on Windows")
+	}
+
+	run := dockerRun{
+		Image:       "pandoc/latex:3.5",
+		Mounts:      []string{"/work", "/tmp"},
+		Workdir:     "/work",
+		User:        "1000:1000",
+		Interactive: true,
+		NoNetwork:   true,
+		Limits:      Limits{CPUTime: 90 * time.Second, Memory: 1 << 30},
+	}
+	got := run.args([]string{"in.md", "-o", "out.pdf"})
+	want := []string{
+		"run", "--rm", "-i", "--network", "none",
+		"--ulimit", "cpu=90:90", "--memory", "1073741824",
+		"--user", "1000:1000",
+		"-v", "/work:/work", "-v", "/tmp:/tmp",
+		"-w", "/work", "--", "pandoc/latex:3.5",
+		"in.md", "-o", "out.pdf",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dockerRunArgs() = %v, want %v", got, want)
+	}
+}
+
+// TestMountDirs verifies every directory the conversion uses is mounted once,
+// leaving out directories inside another mount.
+func TestMountDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("container paths differ on Windows")
+	}
+
+	tempDir := filepath.Clean(os.TempDir())
+	opts := ConversionOptions{
+		InputFile:    filepath.Join(tempDir, "veve-run-1", "processed.md"),
+		Theme:        filepath.Join(tempDir, "veve-run-1", "theme.css"),
+		ResourcePath: []string{".", "images", "/assets"},
+		Variables:    map[string]string{"logo": "/assets/brand/logo.png", "fontsize": "11pt"},
+	}
+
+	got := mountDirs("/work", opts, "/out/doc.pdf")
+	sort.Strings(got)
+	want := []string{"/assets", "/out", tempDir, "/work"}
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mountDirs() = %v, want %v", got, want)
+	}
+}
+
+// TestContainerArgs verifies resource paths are joined for the container.
+func TestContainerArgs(t *testing.T) {
+	args := []string{"in.md", "--resource-path", "." + string(os.PathListSeparator) + "/assets", "-V", "logo=/assets/logo.png"}
+	got := containerArgs(args)
+	if got[2] != ".:/assets" && runtime.GOOS != "windows" {
+		t.Errorf("unexpected resource path %q", got[2])
+	}
+	if got[4] != "logo=/assets/logo.png" && runtime.GOOS != "windows" {
+		t.Errorf("unexpected variable %q", got[4])
+	}
+}
+
+// TestDockerCommandRejectsOptionImage verifies an image that docker would
+// read as an option is refused before docker is run.
+func TestDockerCommandRejectsOptionImage(t *testing.T) {
+	for _, image := range []string{"", "--privileged", "-v=/:/host"} {
+		if _, err := dockerCommand(image, []string{"in.md"}, ConversionOptions{}, "out.pdf", false); err == nil {
+			t.Errorf("dockerCommand(%q) succeeded, want an error", image)
+		}
+	}
+}
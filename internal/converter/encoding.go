@@ -0,0 +1,146 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Legacy encodings recognized by DetectEncoding, by their IANA names.
+const (
+	EncodingShiftJIS    = "Shift_JIS"
+	EncodingWindows1252 = "windows-1252"
+	EncodingLatin1      = "ISO-8859-1"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// NormalizeText returns markdown as UTF-8 with LF line endings, the form
+// the rest of the pipeline expects. Files saved on Windows often start with
+// a byte order mark, use CRLF line endings, or are UTF-16 (Notepad's
+// "Unicode", PowerShell's > redirection), which would otherwise show up as
+// stray characters in the PDF or stop line-based patterns from matching.
+// UTF-16 is recognized by its byte order mark, or without one by the zero
+// bytes of its ASCII characters.
+func NormalizeText(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		data = data[len(utf8BOM):]
+	case bytes.HasPrefix(data, utf16LEBOM):
+		data = decodeUTF16(data[len(utf16LEBOM):], binary.LittleEndian)
+	case bytes.HasPrefix(data, utf16BEBOM):
+		data = decodeUTF16(data[len(utf16BEBOM):], binary.BigEndian)
+	default:
+		if order := guessUTF16(data); order != nil {
+			data = decodeUTF16(data, order)
+		}
+	}
+
+	if bytes.Contains(data, []byte("\r\n")) {
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	}
+	return data
+}
+
+// decodeUTF16 transcodes UTF-16 in the given byte order to UTF-8, dropping
+// a trailing odd byte.
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	runes := utf16.Decode(units)
+
+	out := make([]byte, 0, len(runes))
+	for _, r := range runes {
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
+
+// guessUTF16 returns the byte order of UTF-16 text without a byte order
+// mark, or nil if data doesn't look like UTF-16. Text is taken for UTF-16
+// when most of its leading code units are ASCII, with the zero byte on the
+// same side; UTF-8 markdown has no zero bytes.
+func guessUTF16(data []byte) binary.ByteOrder {
+	sample := data[:min(len(data), 1024)&^1]
+	if len(sample) < 4 || utf8.Valid(data) && bytes.IndexByte(data, 0) < 0 {
+		return nil
+	}
+
+	var evenZeros, oddZeros int
+	for i := 0; i < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	units := len(sample) / 2
+	switch {
+	case oddZeros*2 > units && evenZeros*4 < oddZeros:
+		return binary.LittleEndian
+	case evenZeros*2 > units && oddZeros*4 < evenZeros:
+		return binary.BigEndian
+	}
+	return nil
+}
+
+// DetectEncoding returns the legacy encoding text that isn't valid UTF-8
+// most likely uses, or "" for valid UTF-8. Text that decodes cleanly as
+// Shift_JIS to Japanese characters is taken as Shift_JIS; anything else as
+// windows-1252, or ISO-8859-1 (its subset) if it has none of the bytes
+// where the two differ.
+func DetectEncoding(data []byte) string {
+	if utf8.Valid(data) {
+		return ""
+	}
+
+	if decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(data); err == nil &&
+		!bytes.ContainsRune(decoded, utf8.RuneError) && containsJapanese(decoded) {
+		return EncodingShiftJIS
+	}
+
+	for _, b := range data {
+		if b >= 0x80 && b <= 0x9F {
+			return EncodingWindows1252
+		}
+	}
+	return EncodingLatin1
+}
+
+// containsJapanese reports whether text has kana or kanji.
+func containsJapanese(text []byte) bool {
+	return bytes.ContainsFunc(text, func(r rune) bool {
+		return unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han)
+	})
+}
+
+// Transcode converts data from the named encoding (one of DetectEncoding's)
+// to UTF-8.
+func Transcode(data []byte, name string) ([]byte, error) {
+	var enc encoding.Encoding
+	switch name {
+	case EncodingShiftJIS:
+		enc = japanese.ShiftJIS
+	case EncodingWindows1252:
+		enc = charmap.Windows1252
+	case EncodingLatin1:
+		enc = charmap.ISO8859_1
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	return enc.NewDecoder().Bytes(data)
+}
@@ -0,0 +1,47 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// EPUBOptions holds options for markdown-to-EPUB conversion.
+type EPUBOptions struct {
+	InputFile  string // Path to markdown file
+	OutputFile string // Path to output EPUB
+	Stylesheet string // Path to CSS file (optional)
+	Limits     Limits // Caps the CPU time and memory of pandoc (optional)
+	NoSmart    bool   // Keep straight quotes, double hyphens, and three dots as typed
+}
+
+// ConvertEPUB converts a markdown file to EPUB. Pandoc splits the book into
+// chapters at level-1 headings and embeds its images and stylesheet.
+func (pc *PandocConverter) ConvertEPUB(opts EPUBOptions) error {
+	if err := ValidateInputFile(opts.InputFile); err != nil {
+		return fmt.Errorf("input validation failed: %w", err)
+	}
+	if err := EnsureOutputDirectory(opts.OutputFile); err != nil {
+		return err
+	}
+
+	args := []string{opts.InputFile, "-o", opts.OutputFile, "--to", "epub3", "--standalone"}
+	args = append(args, readerArgs(opts.NoSmart)...)
+	if opts.Stylesheet != "" {
+		args = append(args, "--css", opts.Stylesheet)
+	}
+
+	cmd := exec.Command(pc.PandocPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := runLimited(cmd, opts.Limits); err != nil {
+		if crash := newCrashError("pandoc", err, stderr.String(), "", opts.Limits); crash != nil {
+			return crash
+		}
+		if stderr.Len() > 0 {
+			return fmt.Errorf("pandoc conversion failed: %w\nPandoc stderr: %s", err, stderr.String())
+		}
+		return fmt.Errorf("pandoc conversion failed: %w", err)
+	}
+	return nil
+}
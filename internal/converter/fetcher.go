@@ -0,0 +1,420 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/madstone-tech/veve-cli/internal"
+)
+
+// FetchMeta describes a resource returned by a Fetcher.
+type FetchMeta struct {
+	// ContentType is the declared media type of the resource (may be empty)
+	ContentType string
+
+	// ContentLength is the size in bytes, or -1 if unknown
+	ContentLength int64
+
+	// ETag identifies the version of the resource, if the server sent one
+	ETag string
+
+	// LastModified is when the resource last changed, if the server said
+	LastModified string
+}
+
+// CacheValidators identify the version of a resource a cache holds, for a
+// conditional request to confirm it is still current.
+type CacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// IsZero reports whether there is no validator to send.
+func (v CacheValidators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// ErrNotModified is returned by a ConditionalFetcher when the resource is
+// still the version the validators identify.
+var ErrNotModified = errors.New("not modified")
+
+// Fetcher retrieves the content of an image URL.
+//
+// Implementations are responsible for a single transport (HTTP, local files,
+// object storage, ...). The ImageProcessor only talks to this interface, so new
+// protocols can be supported by registering another Fetcher instead of changing
+// the download pipeline. Callers must close the returned reader.
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (io.ReadCloser, FetchMeta, error)
+}
+
+// ConditionalFetcher is implemented by Fetchers whose transport can confirm a
+// cached copy is current without sending the content again. FetchIfChanged
+// returns ErrNotModified if the resource still matches the validators, and
+// otherwise behaves like Fetch.
+type ConditionalFetcher interface {
+	FetchIfChanged(ctx context.Context, rawURL string, validators CacheValidators) (io.ReadCloser, FetchMeta, error)
+}
+
+// HTTPStatusError is returned when a remote server answers with a non-200 status.
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// ErrorKind classifies the error as a network error.
+func (e *HTTPStatusError) ErrorKind() internal.Kind {
+	return internal.KindNetwork
+}
+
+// ============================================================================
+// SCHEME ROUTING
+// ============================================================================
+
+// SchemeFetcher dispatches requests to the Fetcher registered for the URL scheme.
+// Thread-safe: fetchers may be registered while downloads are running.
+type SchemeFetcher struct {
+	mu       sync.RWMutex
+	fetchers map[string]Fetcher
+}
+
+// NewSchemeFetcher creates an empty SchemeFetcher.
+func NewSchemeFetcher() *SchemeFetcher {
+	return &SchemeFetcher{
+		fetchers: make(map[string]Fetcher),
+	}
+}
+
+// NewDefaultFetcher returns a SchemeFetcher with the HTTP transport
+// registered for http and https, using client. The optional schemes are
+// left out until EnableSchemes adds them.
+func NewDefaultFetcher(client *http.Client) *SchemeFetcher {
+	httpFetcher := NewHTTPFetcher(client)
+	return NewSchemeFetcher().
+		Register("http", httpFetcher).
+		Register("https", httpFetcher)
+}
+
+// OptionalSchemes are the built-in transports that are off by default: a
+// document could use them to read what its reader can but its author
+// can't, any local file with file:// and any bucket with s3://.
+var OptionalSchemes = []string{"file", "s3"}
+
+// CheckSchemes returns an error if a scheme isn't one of OptionalSchemes.
+func CheckSchemes(schemes []string) error {
+	for _, scheme := range schemes {
+		if !slices.Contains(OptionalSchemes, strings.ToLower(scheme)) {
+			return fmt.Errorf("unknown image URL scheme %q (optional schemes: %s)", scheme, strings.Join(OptionalSchemes, ", "))
+		}
+	}
+	return nil
+}
+
+// EnableSchemes registers the built-in transports for schemes, using client
+// for s3. A scheme not in OptionalSchemes is ignored (see CheckSchemes).
+func (sf *SchemeFetcher) EnableSchemes(client *http.Client, schemes ...string) *SchemeFetcher {
+	for _, scheme := range schemes {
+		switch strings.ToLower(scheme) {
+		case "file":
+			sf.Register("file", NewFileFetcher())
+		case "s3":
+			sf.Register("s3", NewS3Fetcher(client))
+		}
+	}
+	return sf
+}
+
+// Register associates a Fetcher with a URL scheme (case-insensitive).
+// Registering a scheme twice replaces the previous Fetcher.
+func (sf *SchemeFetcher) Register(scheme string, fetcher Fetcher) *SchemeFetcher {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.fetchers[strings.ToLower(scheme)] = fetcher
+	return sf
+}
+
+// Supports reports whether a Fetcher is registered for the scheme of rawURL.
+func (sf *SchemeFetcher) Supports(rawURL string) bool {
+	return sf.lookup(rawURL) != nil
+}
+
+// Fetch retrieves rawURL using the Fetcher registered for its scheme.
+func (sf *SchemeFetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, FetchMeta, error) {
+	fetcher := sf.lookup(rawURL)
+	if fetcher == nil {
+		return nil, FetchMeta{}, fmt.Errorf("unsupported URL scheme: %s", rawURL)
+	}
+	return fetcher.Fetch(ctx, rawURL)
+}
+
+// FetchIfChanged retrieves rawURL unless it still matches validators, using
+// the Fetcher registered for its scheme. Fetchers that can't make conditional
+// requests fetch it unconditionally.
+func (sf *SchemeFetcher) FetchIfChanged(ctx context.Context, rawURL string, validators CacheValidators) (io.ReadCloser, FetchMeta, error) {
+	fetcher := sf.lookup(rawURL)
+	if fetcher == nil {
+		return nil, FetchMeta{}, fmt.Errorf("unsupported URL scheme: %s", rawURL)
+	}
+	return fetchIfChanged(ctx, fetcher, rawURL, validators)
+}
+
+// fetchIfChanged fetches rawURL with fetcher, conditionally if it supports
+// that and there are validators to send.
+func fetchIfChanged(ctx context.Context, fetcher Fetcher, rawURL string, validators CacheValidators) (io.ReadCloser, FetchMeta, error) {
+	if cf, ok := fetcher.(ConditionalFetcher); ok && !validators.IsZero() {
+		return cf.FetchIfChanged(ctx, rawURL, validators)
+	}
+	return fetcher.Fetch(ctx, rawURL)
+}
+
+// lookup returns the Fetcher for the scheme of rawURL, or nil if none is registered.
+func (sf *SchemeFetcher) lookup(rawURL string) Fetcher {
+	scheme, _, found := strings.Cut(rawURL, "://")
+	if !found || scheme == "" {
+		return nil
+	}
+
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.fetchers[strings.ToLower(scheme)]
+}
+
+// ============================================================================
+// HTTP
+// ============================================================================
+
+// HTTPFetcher fetches images over HTTP(S).
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher creates an HTTPFetcher. A nil client uses http.DefaultClient.
+func NewHTTPFetcher(client *http.Client) *HTTPFetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPFetcher{client: client}
+}
+
+// Fetch performs a GET request. Non-200 responses are returned as *HTTPStatusError.
+func (hf *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, FetchMeta, error) {
+	return hf.FetchIfChanged(ctx, rawURL, CacheValidators{})
+}
+
+// FetchIfChanged performs a GET request with If-None-Match and
+// If-Modified-Since set from validators. A 304 response is returned as
+// ErrNotModified.
+func (hf *HTTPFetcher) FetchIfChanged(ctx context.Context, rawURL string, validators CacheValidators) (io.ReadCloser, FetchMeta, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, FetchMeta{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	resp, err := hf.client.Do(req)
+	if err != nil {
+		return nil, FetchMeta{}, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && !validators.IsZero() {
+		resp.Body.Close()
+		return nil, FetchMeta{}, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, FetchMeta{}, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+
+	return resp.Body, FetchMeta{
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// ============================================================================
+// LOCAL FILES
+// ============================================================================
+
+// FileFetcher reads images referenced with file:// URLs.
+type FileFetcher struct{}
+
+// NewFileFetcher creates a FileFetcher.
+func NewFileFetcher() *FileFetcher {
+	return &FileFetcher{}
+}
+
+// Fetch opens the file named by a file:// URL.
+// The content type is derived from the file extension, falling back to content sniffing.
+func (ff *FileFetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, FetchMeta, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, FetchMeta{}, fmt.Errorf("invalid file URL: %w", err)
+	}
+
+	path := filepath.FromSlash(u.Path)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, FetchMeta{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, FetchMeta{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, FetchMeta{}, fmt.Errorf("%s is a directory", path)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(f, head)
+		contentType = http.DetectContentType(head[:n])
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, FetchMeta{}, fmt.Errorf("failed to rewind %s: %w", path, err)
+		}
+	}
+
+	return f, FetchMeta{ContentType: contentType, ContentLength: info.Size()}, nil
+}
+
+// ============================================================================
+// S3
+// ============================================================================
+
+// S3Fetcher fetches objects referenced as s3://bucket/key.
+//
+// Objects are retrieved anonymously over HTTPS from the virtual-hosted endpoint,
+// so they must be publicly readable. The region is taken from AWS_REGION
+// (default us-east-1); AWS_ENDPOINT_URL_S3 overrides the endpoint for
+// S3-compatible stores such as MinIO, using path-style addressing.
+type S3Fetcher struct {
+	fetcher  *HTTPFetcher
+	region   string
+	endpoint string
+}
+
+// NewS3Fetcher creates an S3Fetcher using the given HTTP client.
+func NewS3Fetcher(client *http.Client) *S3Fetcher {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Fetcher{
+		fetcher:  NewHTTPFetcher(client),
+		region:   region,
+		endpoint: strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL_S3"), "/"),
+	}
+}
+
+// Fetch translates the s3:// URL into its HTTPS form and downloads it.
+func (s3 *S3Fetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, FetchMeta, error) {
+	httpURL, err := s3.objectURL(rawURL)
+	if err != nil {
+		return nil, FetchMeta{}, err
+	}
+	return s3.fetcher.Fetch(ctx, httpURL)
+}
+
+// FetchIfChanged downloads the object unless it still matches validators.
+func (s3 *S3Fetcher) FetchIfChanged(ctx context.Context, rawURL string, validators CacheValidators) (io.ReadCloser, FetchMeta, error) {
+	httpURL, err := s3.objectURL(rawURL)
+	if err != nil {
+		return nil, FetchMeta{}, err
+	}
+	return s3.fetcher.FetchIfChanged(ctx, httpURL, validators)
+}
+
+// objectURL maps s3://bucket/key to the HTTPS URL of the object.
+func (s3 *S3Fetcher) objectURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid S3 URL: %w", err)
+	}
+
+	bucket := u.Host
+	key := strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return "", fmt.Errorf("invalid S3 URL %s: expected s3://bucket/key", rawURL)
+	}
+
+	if s3.endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", s3.endpoint, bucket, key), nil
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, s3.region, key), nil
+}
+
+// ============================================================================
+// IN-MEMORY
+// ============================================================================
+
+// MemoryFetcher serves images from memory. It is intended for tests and for
+// integrations that already hold image data, avoiding a real HTTP server.
+type MemoryFetcher struct {
+	mu        sync.RWMutex
+	resources map[string]memoryResource
+}
+
+type memoryResource struct {
+	contentType string
+	data        []byte
+}
+
+// NewMemoryFetcher creates an empty MemoryFetcher.
+func NewMemoryFetcher() *MemoryFetcher {
+	return &MemoryFetcher{
+		resources: make(map[string]memoryResource),
+	}
+}
+
+// Add registers data to be returned for rawURL.
+func (mf *MemoryFetcher) Add(rawURL, contentType string, data []byte) *MemoryFetcher {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	mf.resources[rawURL] = memoryResource{contentType: contentType, data: data}
+	return mf
+}
+
+// Fetch returns the registered data for rawURL, or a 404 *HTTPStatusError.
+func (mf *MemoryFetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, FetchMeta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, FetchMeta{}, err
+	}
+
+	mf.mu.RLock()
+	res, ok := mf.resources[rawURL]
+	mf.mu.RUnlock()
+	if !ok {
+		return nil, FetchMeta{}, &HTTPStatusError{StatusCode: http.StatusNotFound}
+	}
+
+	return io.NopCloser(bytes.NewReader(res.data)), FetchMeta{
+		ContentType:   res.contentType,
+		ContentLength: int64(len(res.data)),
+	}, nil
+}
@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"strings"
+)
+
+// figureNumberingCSS numbers figure captions for HTML-based engines
+// (weasyprint, prince). LaTeX engines number figures natively.
+const figureNumberingCSS = `<style>
+body { counter-reset: figure; }
+figure { counter-increment: figure; }
+figcaption::before { content: "Figure " counter(figure) ": "; font-weight: bold; }
+</style>
+`
+
+// PrepareFigures prepares images on a line of their own to be rendered as
+// captioned figures.
+//
+// Pandoc turns an image that is alone in its paragraph into a figure and uses the
+// alt text as its caption. An image on a line of its own in a longer paragraph
+// is given blank lines around it, so it becomes a paragraph, and a figure, of
+// its own. Images that have no alt text but do have a title
+// (![](diagram.png "Architecture")) get the title copied into the alt text so they
+// are captioned too. Images with neither, images sharing a line with text, and
+// images in lists, block quotes, and tables are left unchanged.
+func PrepareFigures(content string) string {
+	var sb strings.Builder
+	last := 0
+
+	for _, ref := range findImageRefs(content) {
+		alt := strings.TrimSpace(content[ref.AltStart:ref.AltEnd])
+		caption := ""
+		if alt == "" && ref.TitleStart >= 0 {
+			caption = strings.TrimSpace(content[ref.TitleStart+1 : ref.TitleEnd-1])
+		}
+		if alt == "" && caption == "" {
+			continue
+		}
+
+		// The line holding the image, and the lines around it
+		start := ref.AltStart - len("![")
+		lineStart := strings.LastIndexByte(content[:start], '\n') + 1
+		lineEnd := len(content)
+		if i := strings.IndexByte(content[ref.End:], '\n'); i >= 0 {
+			lineEnd = ref.End + i + 1
+		}
+		separate := false
+		if !ref.Standalone {
+			if !ref.TopLevel || strings.TrimSpace(content[lineStart:start]) != "" || !isAttributeBlock(content[ref.End:lineEnd]) {
+				continue
+			}
+			separate = true
+		}
+
+		if separate && lineStart > last && strings.TrimSpace(previousLine(content, lineStart)) != "" {
+			sb.WriteString(content[last:lineStart])
+			sb.WriteString("\n")
+			last = lineStart
+		}
+		if caption != "" {
+			sb.WriteString(content[last:ref.AltStart])
+			sb.WriteString(escapeAltText(caption))
+			last = ref.AltEnd
+		}
+		if separate && lineEnd < len(content) && strings.TrimSpace(nextLine(content, lineEnd)) != "" {
+			sb.WriteString(content[last:lineEnd])
+			sb.WriteString("\n")
+			last = lineEnd
+		}
+	}
+	sb.WriteString(content[last:])
+
+	return sb.String()
+}
+
+// isAttributeBlock reports whether rest, the rest of an image's line, is
+// blank or a pandoc attribute block such as {width=50%}.
+func isAttributeBlock(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return rest == "" || strings.HasPrefix(rest, "{") && strings.HasSuffix(rest, "}")
+}
+
+// previousLine returns the line of content ending just before lineStart.
+func previousLine(content string, lineStart int) string {
+	before := content[:lineStart-1]
+	return before[strings.LastIndexByte(before, '\n')+1:]
+}
+
+// nextLine returns the line of content starting at lineEnd.
+func nextLine(content string, lineEnd int) string {
+	after := content[lineEnd:]
+	if i := strings.IndexByte(after, '\n'); i >= 0 {
+		return after[:i]
+	}
+	return after
+}
+
+// escapeAltText escapes brackets so text can be placed inside ![...].
+func escapeAltText(text string) string {
+	replacer := strings.NewReplacer("[", `\[`, "]", `\]`)
+	return replacer.Replace(text)
+}
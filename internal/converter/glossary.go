@@ -0,0 +1,202 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"go.yaml.in/yaml/v3"
+)
+
+// DefaultGlossaryTitle is the heading of the section GlossarySection writes.
+const DefaultGlossaryTitle = "Glossary"
+
+// GlossaryTerm is a glossary entry: an acronym with its expansion, a term
+// with its definition, or both.
+type GlossaryTerm struct {
+	Term       string `yaml:"term"`
+	Expansion  string `yaml:"expansion"`
+	Definition string `yaml:"definition"`
+}
+
+// Glossary holds the terms of a glossary file.
+type Glossary struct {
+	Terms []GlossaryTerm
+}
+
+// LoadGlossary reads a YAML glossary file:
+//
+//	terms:
+//	  - term: SLA
+//	    expansion: Service Level Agreement
+//	    definition: The availability a service commits to, per month.
+//	  - term: idempotent
+//	    definition: Giving the same result however many times it is applied.
+func LoadGlossary(path string) (*Glossary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary: %w", err)
+	}
+	var file struct {
+		Terms []GlossaryTerm `yaml:"terms"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(file.Terms))
+	for i, term := range file.Terms {
+		switch {
+		case strings.TrimSpace(term.Term) == "":
+			return nil, fmt.Errorf("%s: term %d has no name", path, i+1)
+		case seen[term.Term]:
+			return nil, fmt.Errorf("%s: %s is listed twice", path, term.Term)
+		case term.Expansion == "" && term.Definition == "":
+			return nil, fmt.Errorf("%s: %s needs an expansion or a definition", path, term.Term)
+		case strings.Contains(term.Expansion, "\n"):
+			return nil, fmt.Errorf("%s: %s: expansion must be a single line", path, term.Term)
+		}
+		seen[term.Term] = true
+	}
+	return &Glossary{Terms: file.Terms}, nil
+}
+
+// Expand writes out each term with an expansion on its first use in
+// content, as "Service Level Agreement (SLA)", and returns the result with
+// the terms content uses, in glossary order. Terms match case-sensitively
+// as whole words in body text; headings, links, images, code, and front
+// matter are left alone, and a first use already written out is kept.
+func (g *Glossary) Expand(content string) (string, []GlossaryTerm) {
+	if len(g.Terms) == 0 {
+		return content, nil
+	}
+	source := []byte(content)
+	doc := markdownParser.Parse(text.NewReader(source))
+	bodyStart := frontMatterEnd(content)
+
+	used := make([]bool, len(g.Terms))
+	var sb strings.Builder
+	last := 0
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindHeading, ast.KindLink, ast.KindAutoLink, ast.KindImage, ast.KindCodeSpan,
+			ast.KindCodeBlock, ast.KindFencedCodeBlock, ast.KindHTMLBlock, ast.KindRawHTML:
+			return ast.WalkSkipChildren, nil
+		case ast.KindText:
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		segment := n.(*ast.Text).Segment
+		if segment.Start < bodyStart {
+			return ast.WalkContinue, nil
+		}
+		for offset := segment.Start; offset < segment.Stop; {
+			i, at := g.nextTerm(content, offset, segment.Stop)
+			if i < 0 {
+				break
+			}
+			term := g.Terms[i]
+			end := at + len(term.Term)
+			if !used[i] && term.Expansion != "" && !strings.HasSuffix(content[:at], term.Expansion+" (") {
+				sb.WriteString(content[last:at])
+				sb.WriteString(term.Expansion + " (" + term.Term + ")")
+				last = end
+			}
+			used[i] = true
+			offset = end
+		}
+		return ast.WalkContinue, nil
+	})
+	sb.WriteString(content[last:])
+
+	var terms []GlossaryTerm
+	for i, term := range g.Terms {
+		if used[i] {
+			terms = append(terms, term)
+		}
+	}
+	return sb.String(), terms
+}
+
+// nextTerm returns the index of the term at the earliest whole-word match
+// in content[from:to], preferring the longest term at a position, and the
+// match's offset; or -1.
+func (g *Glossary) nextTerm(content string, from, to int) (int, int) {
+	best, bestAt := -1, to
+	for i, term := range g.Terms {
+		for offset := from; offset < to; {
+			j := strings.Index(content[offset:to], term.Term)
+			if j < 0 {
+				break
+			}
+			at := offset + j
+			if at > bestAt {
+				break
+			}
+			if wholeWord(content, at, at+len(term.Term)) {
+				if at < bestAt || len(term.Term) > len(g.Terms[best].Term) {
+					best, bestAt = i, at
+				}
+				break
+			}
+			offset = at + 1
+		}
+	}
+	return best, bestAt
+}
+
+// wholeWord reports whether content[start:end] isn't part of a longer word.
+func wholeWord(content string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(content[:start])
+	after, _ := utf8.DecodeRuneInString(content[end:])
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	return (start == 0 || !isWord(before)) && (end == len(content) || !isWord(after))
+}
+
+// frontMatterEnd returns the offset after content's YAML front matter, or 0.
+func frontMatterEnd(content string) int {
+	if !strings.HasPrefix(content, "---\n") {
+		return 0
+	}
+	for _, closing := range []string{"\n---\n", "\n...\n"} {
+		if end := strings.Index(content[4:], closing); end >= 0 {
+			return 4 + end + len(closing)
+		}
+	}
+	return 0
+}
+
+// GlossarySection returns an unnumbered section defining terms, sorted, as
+// a definition list headed title (default: DefaultGlossaryTitle).
+func GlossarySection(title string, terms []GlossaryTerm) string {
+	if title == "" {
+		title = DefaultGlossaryTitle
+	}
+	sorted := append([]GlossaryTerm(nil), terms...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].Term) < strings.ToLower(sorted[j].Term)
+	})
+
+	var sb strings.Builder
+	sb.WriteString("# " + title + " {#glossary .unnumbered}\n\n")
+	for _, term := range sorted {
+		body := term.Expansion
+		if definition := strings.TrimSpace(term.Definition); definition != "" {
+			if body != "" {
+				body += " — "
+			}
+			body += definition
+		}
+		sb.WriteString(term.Term + "\n:   " + strings.ReplaceAll(body, "\n", "\n    ") + "\n\n")
+	}
+	return sb.String()
+}
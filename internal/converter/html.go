@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// HTMLOptions holds options for markdown-to-HTML conversion.
+type HTMLOptions struct {
+	InputFile    string   // Path to markdown file
+	OutputFile   string   // Path to output HTML
+	Stylesheet   string   // Path to CSS file (optional)
+	ResourcePath []string // Directories searched for images (optional; pandoc defaults to ".")
+	Limits       Limits   // Caps the CPU time and memory of pandoc (optional)
+	NoSmart      bool     // Keep straight quotes, double hyphens, and three dots as typed
+}
+
+// ConvertHTML converts a markdown file to a standalone HTML file, with its
+// images and stylesheet embedded so it can be opened or sent on its own. It
+// needs no PDF engine.
+func (pc *PandocConverter) ConvertHTML(opts HTMLOptions) error {
+	if err := ValidateInputFile(opts.InputFile); err != nil {
+		return fmt.Errorf("input validation failed: %w", err)
+	}
+	if err := EnsureOutputDirectory(opts.OutputFile); err != nil {
+		return err
+	}
+
+	args := []string{opts.InputFile, "-o", opts.OutputFile, "--to", "html5", "--standalone", "--embed-resources"}
+	args = append(args, readerArgs(opts.NoSmart)...)
+	if opts.Stylesheet != "" {
+		args = append(args, "--css", opts.Stylesheet)
+	}
+	if len(opts.ResourcePath) > 0 {
+		args = append(args, "--resource-path", strings.Join(opts.ResourcePath, string(os.PathListSeparator)))
+	}
+
+	cmd := exec.Command(pc.PandocPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := runLimited(cmd, opts.Limits); err != nil {
+		if crash := newCrashError("pandoc", err, stderr.String(), "", opts.Limits); crash != nil {
+			return crash
+		}
+		if stderr.Len() > 0 {
+			return fmt.Errorf("pandoc conversion failed: %w\nPandoc stderr: %s", err, stderr.String())
+		}
+		return fmt.Errorf("pandoc conversion failed: %w", err)
+	}
+	return nil
+}
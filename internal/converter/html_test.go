@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestConvertHTML tests the pandoc arguments for standalone HTML output.
+func TestConvertHTML(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as pandoc")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "args")
+	pandoc := filepath.Join(dir, "pandoc")
+	if err := os.WriteFile(pandoc, []byte("#!/bin/sh\necho \"$@\" > "+log+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(input, []byte("# Doc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pc := &PandocConverter{PandocPath: pandoc}
+	output := filepath.Join(dir, "out", "doc.html")
+	err := pc.ConvertHTML(HTMLOptions{InputFile: input, OutputFile: output, Stylesheet: "theme.css", ResourcePath: []string{".", "images"}})
+	if err != nil {
+		t.Fatalf("ConvertHTML failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(log)
+	want := input + " -o " + output + " --to html5 --standalone --embed-resources --css theme.css --resource-path ." + string(os.PathListSeparator) + "images"
+	if got := strings.TrimSpace(string(data)); got != want {
+		t.Errorf("pandoc args = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Dir(output)); err != nil {
+		t.Errorf("output directory not created: %v", err)
+	}
+}
+
+// TestConvertHTMLNoSmart tests that NoSmart reads markdown without the smart extension.
+func TestConvertHTMLNoSmart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as pandoc")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "args")
+	pandoc := filepath.Join(dir, "pandoc")
+	if err := os.WriteFile(pandoc, []byte("#!/bin/sh\necho \"$@\" > "+log+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(input, []byte("# Doc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pc := &PandocConverter{PandocPath: pandoc}
+	output := filepath.Join(dir, "doc.html")
+	if err := pc.ConvertHTML(HTMLOptions{InputFile: input, OutputFile: output, NoSmart: true}); err != nil {
+		t.Fatalf("ConvertHTML failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(log)
+	if got := strings.TrimSpace(string(data)); !strings.Contains(got, "--from markdown-smart") {
+		t.Errorf("pandoc args = %q, want --from markdown-smart", got)
+	}
+}
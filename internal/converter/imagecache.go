@@ -0,0 +1,188 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/madstone-tech/veve-cli/internal/filelock"
+)
+
+// ImageCacheDirName is the directory, in the user cache directory, holding
+// the remote image cache.
+const ImageCacheDirName = "images"
+
+// imageCacheURLs is the directory, in the cache, holding an entry per URL.
+const imageCacheURLs = "urls"
+
+// ImageCache keeps remote images across conversions, so an image that hasn't
+// changed isn't downloaded again by the next run.
+//
+// Images are stored by the SHA-256 of their content, so URLs serving the same
+// image share a file. Each URL has an entry naming its image and the ETag and
+// Last-Modified date it was served with. These are sent with the next request
+// for the URL, and the cached image is used while the server answers that it
+// hasn't changed (304 Not Modified) or sends the same ETag; images served with
+// neither are not cached.
+//
+// The cache is safe for use by parallel runs: files are written atomically,
+// and a missing or damaged entry is a miss.
+type ImageCache struct {
+	dir string
+}
+
+// imageCacheEntry is what the cache records for a URL.
+type imageCacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// File is the image's name in the cache: its checksum and extension
+	File string `json:"file"`
+}
+
+// CachedImage is an image the cache holds for a URL.
+type CachedImage struct {
+	// Path is the cached image
+	Path string
+	// Validators identify the version of the image cached, for a
+	// conditional request
+	Validators CacheValidators
+}
+
+// NewImageCache creates an ImageCache in dir, which is created when an
+// image is first stored.
+func NewImageCache(dir string) *ImageCache {
+	return &ImageCache{dir: dir}
+}
+
+// Dir returns the cache's directory.
+func (c *ImageCache) Dir() string {
+	return c.dir
+}
+
+// Lookup returns the image cached for rawURL, if there is one.
+func (c *ImageCache) Lookup(rawURL string) (CachedImage, bool) {
+	entry, ok := c.entry(rawURL)
+	if !ok {
+		return CachedImage{}, false
+	}
+	path := filepath.Join(c.dir, entry.File)
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return CachedImage{}, false
+	}
+	return CachedImage{
+		Path:       path,
+		Validators: CacheValidators{ETag: entry.ETag, LastModified: entry.LastModified},
+	}, true
+}
+
+// Store caches the image at path as rawURL's, served with validators.
+// Without validators there is nothing to tell a later change by, so nothing
+// is stored.
+func (c *ImageCache) Store(rawURL string, validators CacheValidators, path string) error {
+	if validators.IsZero() {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(c.dir, imageCacheURLs), 0o755); err != nil {
+		return fmt.Errorf("failed to create image cache: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s for the image cache: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	file := hex.EncodeToString(sum[:]) + filepath.Ext(path)
+	if _, err := os.Stat(filepath.Join(c.dir, file)); err != nil {
+		if err := filelock.WriteFile(filepath.Join(c.dir, file), data, 0o644); err != nil {
+			return fmt.Errorf("failed to cache %s: %w", rawURL, err)
+		}
+	}
+
+	entry, err := json.Marshal(imageCacheEntry{
+		URL:          rawURL,
+		ETag:         validators.ETag,
+		LastModified: validators.LastModified,
+		File:         file,
+	})
+	if err != nil {
+		return err
+	}
+	if err := filelock.WriteFile(c.entryPath(rawURL), entry, 0o644); err != nil {
+		return fmt.Errorf("failed to cache %s: %w", rawURL, err)
+	}
+	return nil
+}
+
+// Clear removes every cached image, returning how many there were and the
+// bytes they took.
+func (c *ImageCache) Clear() (images int, size int64, err error) {
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read image cache: %w", err)
+	}
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			if info, err := e.Info(); err == nil {
+				images++
+				size += info.Size()
+			}
+		}
+	}
+	if err := os.RemoveAll(c.dir); err != nil {
+		return 0, 0, fmt.Errorf("failed to clear image cache: %w", err)
+	}
+	return images, size, nil
+}
+
+// entry reads rawURL's entry.
+func (c *ImageCache) entry(rawURL string) (imageCacheEntry, bool) {
+	data, err := os.ReadFile(c.entryPath(rawURL))
+	if err != nil {
+		return imageCacheEntry{}, false
+	}
+	var entry imageCacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.URL != rawURL || entry.File != filepath.Base(entry.File) {
+		return imageCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// entryPath returns the path of rawURL's entry, named by the URL's checksum.
+func (c *ImageCache) entryPath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(c.dir, imageCacheURLs, hex.EncodeToString(sum[:])+".json")
+}
+
+// copyCachedImage copies the cached image at src to a new file in dir named
+// by pattern, as os.CreateTemp does, and returns its path.
+func copyCachedImage(src, dir, pattern string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
@@ -0,0 +1,139 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // Register the GIF decoder for convertToPNG
+	_ "image/jpeg" // Register the JPEG decoder for convertToPNG
+	"image/png"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// unknownImageExtension is the extension of an image whose type has no
+// mapping; no engine can load it, so it is converted to PNG.
+const unknownImageExtension = ".img"
+
+// imageExtensions maps image content types to the extension a downloaded
+// image is saved with. Engines choose how to load an image by its extension.
+var imageExtensions = map[string]string{
+	"image/jpeg":               ".jpg",
+	"image/jpg":                ".jpg",
+	"image/png":                ".png",
+	"image/gif":                ".gif",
+	"image/webp":               ".webp",
+	"image/svg+xml":            ".svg",
+	"image/bmp":                ".bmp",
+	"image/tiff":               ".tiff",
+	"image/avif":               ".avif",
+	"image/heic":               ".heic",
+	"image/heif":               ".heif",
+	"image/jxl":                ".jxl",
+	"image/x-icon":             ".ico",
+	"image/vnd.microsoft.icon": ".ico",
+}
+
+// WithImageExtensions adds content type to extension mappings, or replaces
+// the default ones, e.g. {"image/x-portable-pixmap": ".ppm"}. Images of a
+// type without a mapping are converted to PNG.
+func (ip *ImageProcessor) WithImageExtensions(extensions map[string]string) *ImageProcessor {
+	if len(extensions) == 0 {
+		return ip
+	}
+	if ip.extensions == nil {
+		ip.extensions = make(map[string]string, len(extensions))
+	}
+	for contentType, ext := range extensions {
+		if ext = normalizeExtension(ext); ext != "" {
+			ip.extensions[mediaType(contentType)] = ext
+		}
+	}
+	return ip
+}
+
+// extensionFor returns the extension for contentType, or
+// unknownImageExtension if it has no mapping.
+func (ip *ImageProcessor) extensionFor(contentType string) string {
+	if ext, ok := ip.extensions[mediaType(contentType)]; ok {
+		return ext
+	}
+	return getExtensionFromContentType(contentType)
+}
+
+// mediaType returns contentType without parameters, in lower case.
+func mediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// normalizeExtension returns ext in lower case with a leading dot.
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// convertToPNG converts the image at path, of a type no engine can load, to
+// a PNG file beside it and returns the PNG's path. Formats Go decodes are
+// converted directly; others with ImageMagick, if it is installed.
+func convertToPNG(path string) (string, error) {
+	pngPath := strings.TrimSuffix(path, unknownImageExtension) + ".png"
+
+	err := decodeToPNG(path, pngPath)
+	if err == nil {
+		return pngPath, nil
+	}
+	magick := findImageMagick()
+	if magick == "" {
+		return "", fmt.Errorf("unsupported image format (%v); install ImageMagick to convert it to PNG, or map its content type to an extension with image_extensions in .veve.yaml", err)
+	}
+	if out, err := exec.Command(magick, path+"[0]", "png:"+pngPath).CombinedOutput(); err != nil {
+		os.Remove(pngPath)
+		return "", fmt.Errorf("unsupported image format: ImageMagick could not convert it to PNG: %s", strings.TrimSpace(string(out)))
+	}
+	return pngPath, nil
+}
+
+// decodeToPNG decodes the image at path with Go's decoders and writes it
+// to pngPath as a PNG.
+func decodeToPNG(path, pngPath string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	img, _, err := image.Decode(in)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(pngPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(out, img); err != nil {
+		out.Close()
+		os.Remove(pngPath)
+		return err
+	}
+	return out.Close()
+}
+
+// findImageMagick returns the path of ImageMagick's command-line tool, or
+// "" if it is not installed. ImageMagick 7 installs magick; 6 only convert,
+// a name Windows also uses for its own, unrelated tool.
+func findImageMagick() string {
+	if path, err := exec.LookPath("magick"); err == nil {
+		return path
+	}
+	if runtime.GOOS != "windows" {
+		if path, err := exec.LookPath("convert"); err == nil {
+			return path
+		}
+	}
+	return ""
+}
@@ -1,7 +1,10 @@
 package converter
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -9,11 +12,12 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"regexp"
-	"strconv"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/workspace"
 )
 
 // ImageProcessor handles downloading remote images and processing markdown content.
@@ -31,8 +35,11 @@ import (
 //   - Concurrent downloads with semaphore pattern
 //   - Retry logic with exponential backoff for transient errors
 //   - Graceful degradation: failed images don't block conversion
-//   - Resource limits: per-image (100MB) and per-session (500MB)
+//   - Resource limits: per-image (100MB) and per-session (500MB), with bytes
+//     reserved before they are written so concurrent downloads can't overshoot
 //   - Best-effort cleanup of temporary files
+//   - Optional persistent cache (see WithImageCache), so images unchanged
+//     since an earlier run aren't downloaded again
 //
 // Thread Safety:
 //   - All shared state protected by mu (sync.Mutex)
@@ -54,17 +61,24 @@ type ImageProcessor struct {
 	tempDir    string
 	imageMap   map[string]string // URL -> local path mapping
 	httpClient *http.Client
+	fetcher    Fetcher     // Transport used to retrieve image content
+	cache      *ImageCache // Images kept across runs; nil if disabled
 
 	// Configuration fields
 	maxConcurrentDownloads int
+	copyBufferSize         int
 	maxBytesPerSession     int64
 	timeoutSeconds         int
 	maxRetries             int
+	strictContentType      bool              // Trust only the declared content type
+	extensions             map[string]string // Content type -> extension, overriding imageExtensions
 
 	// Runtime state
 	downloadErrors       map[string]string // URL -> error message
 	totalBytesDownloaded int64
-	mu                   sync.Mutex // Protects shared state: imageMap, downloadErrors, totalBytesDownloaded
+	reservedBytes        int64      // Bytes held by downloads in progress (see ReserveBytes)
+	cachedImages         int        // Images served from the cache
+	mu                   sync.Mutex // Protects shared state: imageMap, downloadErrors, totalBytesDownloaded, reservedBytes, cachedImages
 }
 
 // NewImageProcessor creates a new ImageProcessor instance with default configuration.
@@ -81,6 +95,10 @@ type ImageProcessor struct {
 // The returned processor can be further configured using:
 //   - WithTimeoutSeconds() to set per-request timeout
 //   - WithMaxRetries() to set retry attempts
+//   - WithFetcher() to replace the transport (HTTP(S) by default)
+//   - WithSchemes() to also fetch file:// or s3:// images
+//   - WithMemoryBudget() to download fewer images at once, with smaller buffers
+//   - WithImageCache() to keep images across runs
 //
 // Example:
 //
@@ -89,12 +107,15 @@ type ImageProcessor struct {
 //		WithMaxRetries(5)
 //	defer processor.Cleanup()
 func NewImageProcessor(tempDir string) *ImageProcessor {
+	httpClient := &http.Client{} // Per-request timeout will be set in context
 	return &ImageProcessor{
 		tempDir:                tempDir,
 		imageMap:               make(map[string]string),
 		downloadErrors:         make(map[string]string),
-		httpClient:             &http.Client{}, // Per-request timeout will be set in context
-		maxConcurrentDownloads: 5,
+		httpClient:             httpClient,
+		fetcher:                NewDefaultFetcher(httpClient),
+		maxConcurrentDownloads: defaultImageConcurrency,
+		copyBufferSize:         defaultCopyBufferSize,
 		maxBytesPerSession:     500 * 1024 * 1024, // 500MB per spec
 		timeoutSeconds:         10,                // Per request timeout
 		maxRetries:             3,                 // Per spec
@@ -117,6 +138,49 @@ func (ip *ImageProcessor) WithMaxRetries(retries int) *ImageProcessor {
 	return ip
 }
 
+// WithStrictContentType sets whether images must be declared as image/*.
+// By default an image sent as text/plain or application/octet-stream is
+// accepted if its content is recognized as an image.
+func (ip *ImageProcessor) WithStrictContentType(strict bool) *ImageProcessor {
+	ip.strictContentType = strict
+	return ip
+}
+
+// WithMemoryBudget sets download concurrency and buffer sizes for a memory budget.
+func (ip *ImageProcessor) WithMemoryBudget(budget MemoryBudget) *ImageProcessor {
+	ip.maxConcurrentDownloads = budget.ImageConcurrency()
+	ip.copyBufferSize = budget.CopyBufferSize()
+	return ip
+}
+
+// WithFetcher replaces the transport used to retrieve images.
+// If the fetcher implements Supports(url string) bool, it also decides which
+// image references are treated as remote; otherwise only HTTP(S) URLs are.
+func (ip *ImageProcessor) WithFetcher(fetcher Fetcher) *ImageProcessor {
+	if fetcher != nil {
+		ip.fetcher = fetcher
+	}
+	return ip
+}
+
+// WithSchemes also fetches images from the optional schemes named (see
+// OptionalSchemes), if the processor's fetcher is a SchemeFetcher.
+func (ip *ImageProcessor) WithSchemes(schemes ...string) *ImageProcessor {
+	if sf, ok := ip.fetcher.(*SchemeFetcher); ok {
+		sf.EnableSchemes(ip.httpClient, schemes...)
+	}
+	return ip
+}
+
+// WithImageCache keeps downloaded images in cache, and serves an image from
+// it when the server answers a conditional request for it with 304 Not
+// Modified, or still sends the ETag it was cached with. A nil cache disables
+// caching.
+func (ip *ImageProcessor) WithImageCache(cache *ImageCache) *ImageProcessor {
+	ip.cache = cache
+	return ip
+}
+
 // ============================================================================
 // PHASE 2 FOUNDATIONAL FUNCTIONS
 // ============================================================================
@@ -132,6 +196,14 @@ func (ip *ImageProcessor) IsRemoteURL(imageURL string) bool {
 	return isRemoteURL(imageURL)
 }
 
+// isFetchable reports whether the processor's fetcher can retrieve imageURL.
+func (ip *ImageProcessor) isFetchable(imageURL string) bool {
+	if s, ok := ip.fetcher.(interface{ Supports(string) bool }); ok {
+		return s.Supports(imageURL)
+	}
+	return isRemoteURL(imageURL)
+}
+
 // hashURL creates a simple hash from the URL string.
 // This is not cryptographically secure but sufficient for filename uniqueness.
 func hashURL(imageURL string) string {
@@ -163,21 +235,46 @@ func (ip *ImageProcessor) IsTransientError(err error, statusCode int) bool {
 	return isTransientError(err, statusCode)
 }
 
-// validateHTTPRequest validates an HTTP request and response.
-// Checks status code and content type.
-func validateHTTPRequest(resp *http.Response) error {
-	// Check HTTP status
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d %s", resp.StatusCode, resp.Status)
+// validateFetchMeta validates the metadata reported by a Fetcher.
+// Checks that the content type is an image type.
+func validateFetchMeta(meta FetchMeta) error {
+	if !isImageContentType(meta.ContentType) {
+		return fmt.Errorf("invalid content type: %s (expected image/*)", meta.ContentType)
+	}
+
+	return nil
+}
+
+// sniffLen is how many leading bytes are examined to recognize an image.
+const sniffLen = 512
+
+// resolveContentType returns the image type of a download from its declared
+// type and its first bytes, head: the declared type if it is an image type,
+// otherwise the type recognized from head. An error is returned if neither
+// is an image type.
+func resolveContentType(declared string, head []byte) (string, error) {
+	if isImageContentType(declared) {
+		return declared, nil
+	}
+	if sniffed := sniffImageType(head); sniffed != "" {
+		return sniffed, nil
 	}
+	return "", fmt.Errorf("invalid content type: %s (expected image/*, and the content is not a recognized image)", declared)
+}
 
-	// Validate content type
-	contentType := resp.Header.Get("Content-Type")
-	if !isImageContentType(contentType) {
-		return fmt.Errorf("invalid content type: %s (expected image/*)", contentType)
+// sniffImageType returns the image type recognized from head, the first
+// bytes of a file, or "" if it is not an image.
+func sniffImageType(head []byte) string {
+	detected := http.DetectContentType(head)
+	if isImageContentType(detected) {
+		return strings.TrimSpace(strings.Split(detected, ";")[0])
 	}
 
-	return nil
+	// DetectContentType reports SVG as XML or plain text
+	if strings.HasPrefix(detected, "text/") && bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+		return "image/svg+xml"
+	}
+	return ""
 }
 
 // isImageContentType checks if the content type is an image type.
@@ -194,10 +291,13 @@ func (ip *ImageProcessor) IsImageContentType(contentType string) bool {
 	return isImageContentType(contentType)
 }
 
+// maxImageSize is the largest single image that will be downloaded.
+const maxImageSize = 100 * 1024 * 1024 // 100MB per image
+
 // ValidateImageSize checks if the image size is within limits.
-// Returns error if size exceeds per-image limit (100MB).
+// Returns error if size exceeds per-image limit (100MB), or if it would not
+// fit in the session limit alongside completed and in-progress downloads.
 func (ip *ImageProcessor) ValidateImageSize(contentLength int64) error {
-	const maxImageSize = 100 * 1024 * 1024 // 100MB per image
 	if contentLength > maxImageSize {
 		return fmt.Errorf("image too large: %d bytes (max %d)", contentLength, maxImageSize)
 	}
@@ -205,45 +305,92 @@ func (ip *ImageProcessor) ValidateImageSize(contentLength int64) error {
 	// Check session limit
 	ip.mu.Lock()
 	defer ip.mu.Unlock()
-	if ip.totalBytesDownloaded+contentLength > ip.maxBytesPerSession {
-		return fmt.Errorf("session size limit exceeded: %d + %d > %d",
-			ip.totalBytesDownloaded, contentLength, ip.maxBytesPerSession)
+	return ip.checkSessionLimit(contentLength)
+}
+
+// checkSessionLimit reports whether n more bytes fit in the session limit.
+// The caller must hold ip.mu.
+func (ip *ImageProcessor) checkSessionLimit(n int64) error {
+	if ip.totalBytesDownloaded+ip.reservedBytes+n > ip.maxBytesPerSession {
+		return fmt.Errorf("session size limit exceeded: %d + %d reserved + %d > %d",
+			ip.totalBytesDownloaded, ip.reservedBytes, n, ip.maxBytesPerSession)
 	}
+	return nil
+}
 
+// ReserveBytes holds n bytes of the session limit for a download in progress,
+// so concurrent downloads can't together overshoot it. Returns an error, and
+// reserves nothing, if n bytes don't fit. Every reservation must be ended with
+// CommitBytes or ReleaseBytes.
+func (ip *ImageProcessor) ReserveBytes(n int64) error {
+	ip.mu.Lock()
+	defer ip.mu.Unlock()
+	if err := ip.checkSessionLimit(n); err != nil {
+		return err
+	}
+	ip.reservedBytes += n
 	return nil
 }
 
-// generateFileName generates a unique filename for the downloaded image.
-// Uses the URL hash to create a unique name and appends the appropriate extension.
-func generateFileName(imageURL string, contentType string) string {
-	ext := getExtensionFromContentType(contentType)
+// CommitBytes ends a reservation of reserved bytes for a completed download
+// of used bytes, counting used towards the session total.
+func (ip *ImageProcessor) CommitBytes(reserved, used int64) {
+	ip.mu.Lock()
+	defer ip.mu.Unlock()
+	ip.reservedBytes -= reserved
+	ip.totalBytesDownloaded += used
+}
+
+// ReleaseBytes ends a reservation of n bytes for a failed download.
+func (ip *ImageProcessor) ReleaseBytes(n int64) {
+	ip.mu.Lock()
+	defer ip.mu.Unlock()
+	ip.reservedBytes -= n
+}
+
+// reservingReader reads an image body, reserving session bytes as it goes
+// for whatever the initial reservation didn't cover. Bytes that can't be
+// reserved, or that take the image past maxImageSize, are not returned.
+type reservingReader struct {
+	ip       *ImageProcessor
+	r        io.Reader
+	reserved int64 // Bytes reserved so far
+	read     int64 // Bytes read so far
+}
+
+func (rr *reservingReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if n == 0 {
+		return n, err
+	}
+	if rr.read+int64(n) > maxImageSize {
+		return 0, fmt.Errorf("image too large: more than %d bytes", int64(maxImageSize))
+	}
+	if need := rr.read + int64(n) - rr.reserved; need > 0 {
+		if rerr := rr.ip.ReserveBytes(need); rerr != nil {
+			return 0, rerr
+		}
+		rr.reserved += need
+	}
+	rr.read += int64(n)
+	return n, err
+}
+
+// generateFileName generates the os.CreateTemp pattern for the downloaded image.
+// Uses the URL hash to create a unique name and appends the appropriate extension,
+// after the random part CreateTemp substitutes for "*", so engines see the extension.
+func generateFileName(imageURL string, ext string) string {
 	hash := hashURL(imageURL)
-	return fmt.Sprintf("veve-image-%s%s", hash, ext)
+	return fmt.Sprintf("veve-image-%s-*%s", hash, ext)
 }
 
-// getExtensionFromContentType returns the file extension based on content type.
+// getExtensionFromContentType returns the file extension based on content type,
+// from imageExtensions, or unknownImageExtension if the type has no mapping.
 func getExtensionFromContentType(contentType string) string {
-	contentType = strings.Split(contentType, ";")[0] // Remove charset info
-	contentType = strings.TrimSpace(contentType)
-
-	switch contentType {
-	case "image/jpeg", "image/jpg":
-		return ".jpg"
-	case "image/png":
-		return ".png"
-	case "image/gif":
-		return ".gif"
-	case "image/webp":
-		return ".webp"
-	case "image/svg+xml":
-		return ".svg"
-	case "image/bmp":
-		return ".bmp"
-	case "image/tiff":
-		return ".tiff"
-	default:
-		return ".img" // fallback extension
+	if ext, ok := imageExtensions[mediaType(contentType)]; ok {
+		return ext
 	}
+	return unknownImageExtension
 }
 
 // GetExtensionFromContentType returns file extension based on content type. Public for testing.
@@ -251,27 +398,67 @@ func (ip *ImageProcessor) GetExtensionFromContentType(contentType string) string
 	return getExtensionFromContentType(contentType)
 }
 
+// localImageExtensions lists the image formats accepted for cover images and logos.
+// Both LaTeX and HTML engines can embed these.
+var localImageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".pdf": true,
+}
+
+// ValidateLocalImage checks that path is a readable image file in a supported
+// format and returns its absolute path.
+func ValidateLocalImage(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve image path: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(absPath))
+	if !localImageExtensions[ext] {
+		return "", fmt.Errorf("unsupported image format %q (supported: png, jpg, gif, svg, pdf)", ext)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("image not found: %s", path)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("image is not a regular file: %s", path)
+	}
+
+	// Check raster images really are images; SVG and PDF are checked by the engine
+	if ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif" {
+		f, err := os.Open(absPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read image: %w", err)
+		}
+		defer f.Close()
+
+		header := make([]byte, 512)
+		n, _ := io.ReadFull(f, header)
+		if !isImageContentType(http.DetectContentType(header[:n])) {
+			return "", fmt.Errorf("file is not a valid %s image: %s", strings.TrimPrefix(ext, "."), path)
+		}
+	}
+
+	return absPath, nil
+}
+
 // ============================================================================
 // MARKDOWN PROCESSING (T008)
 // ============================================================================
 
 // DetectRemoteImages extracts all remote image URLs from markdown content.
 // Returns a list of unique remote URLs, ignoring duplicates and local paths.
+// Images inside code blocks and code spans are not considered (see findImageRefs).
 func (ip *ImageProcessor) DetectRemoteImages(content string) []string {
-	imageRegex := regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
-	matches := imageRegex.FindAllStringSubmatch(content, -1)
-
 	seen := make(map[string]bool)
 	var urls []string
 
-	for _, match := range matches {
-		if len(match) < 3 {
-			continue
-		}
-		imageURL := match[2]
+	for _, ref := range findImageRefs(content) {
+		imageURL := ref.Destination
 
 		// Only include remote URLs, avoid duplicates
-		if isRemoteURL(imageURL) && !seen[imageURL] {
+		if ip.isFetchable(imageURL) && !seen[imageURL] {
 			urls = append(urls, imageURL)
 			seen[imageURL] = true
 		}
@@ -280,6 +467,38 @@ func (ip *ImageProcessor) DetectRemoteImages(content string) []string {
 	return urls
 }
 
+// CountImages returns the number of inline images in markdown content, and how
+// many of them are remote: ones the processor would fetch, as
+// DetectRemoteImages finds them. Images in code are not counted.
+func (ip *ImageProcessor) CountImages(content string) (total, remote int) {
+	for _, ref := range findImageRefs(content) {
+		total++
+		if ip.isFetchable(ref.Destination) {
+			remote++
+		}
+	}
+	return total, remote
+}
+
+// RebaseImages rewrites relative local image paths in content as absolute
+// paths under dir, for markdown moved out of the directory it was written in.
+// Remote images, data URIs, and absolute paths are left unchanged.
+func RebaseImages(content, dir string) string {
+	var sb strings.Builder
+	last := 0
+	for _, ref := range findImageRefs(content) {
+		dest := ref.Destination
+		if dest == "" || filepath.IsAbs(dest) || strings.HasPrefix(dest, "/") || strings.Contains(dest, ":") {
+			continue
+		}
+		sb.WriteString(content[last:ref.DestStart])
+		sb.WriteString(formatDestination(filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(dest)))))
+		last = ref.DestEnd
+	}
+	sb.WriteString(content[last:])
+	return sb.String()
+}
+
 // ============================================================================
 // CONCURRENCY & CLEANUP INFRASTRUCTURE (T009, T010)
 // ============================================================================
@@ -335,6 +554,14 @@ func (ip *ImageProcessor) GetDownloadStats() (successful, failed, total int) {
 	return
 }
 
+// CachedImages returns how many of the images were served from the image
+// cache rather than downloaded.
+func (ip *ImageProcessor) CachedImages() int {
+	ip.mu.Lock()
+	defer ip.mu.Unlock()
+	return ip.cachedImages
+}
+
 // GetErrorSummary returns a formatted error summary for user output.
 // Format: "[WARN] Failed to download N images:\n  - URL1: reason1\n  - URL2: reason2"
 func (ip *ImageProcessor) GetErrorSummary() string {
@@ -358,13 +585,13 @@ func (ip *ImageProcessor) GetErrorSummary() string {
 }
 
 // Cleanup removes all temporary image files created by this processor.
-// Uses best-effort approach: logs warnings but doesn't block on errors.
+// Uses best-effort approach: removes what it can and reports the rest.
 //
 // Behavior:
 //   - Removes all downloaded image files from imageMap
 //   - Removes the temporary directory
-//   - Logs warnings to stderr if files can't be deleted
-//   - Always returns nil (cleanup failures don't block conversion)
+//   - Returns a report of what was removed and what couldn't be, for the
+//     caller to warn about (cleanup failures don't block conversion)
 //
 // Recommended Usage:
 //
@@ -373,7 +600,7 @@ func (ip *ImageProcessor) GetErrorSummary() string {
 // Thread Safety:
 //   - Safe to call concurrently
 //   - Snapshots imageMap before cleanup (doesn't hold locks during file removal)
-func (ip *ImageProcessor) Cleanup() error {
+func (ip *ImageProcessor) Cleanup() workspace.CleanupReport {
 	ip.mu.Lock()
 	imagesToClean := make([]string, 0, len(ip.imageMap))
 	for _, localPath := range ip.imageMap {
@@ -382,19 +609,16 @@ func (ip *ImageProcessor) Cleanup() error {
 	ip.mu.Unlock()
 
 	// Remove all downloaded image files
+	var report workspace.CleanupReport
 	for _, localPath := range imagesToClean {
-		if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "[WARN] Failed to remove temp image file %s: %v\n", localPath, err)
-		}
+		report.Remove(localPath)
 	}
 
-	// Try to remove the temp directory itself
-	if err := os.RemoveAll(ip.tempDir); err != nil && !os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "[WARN] Failed to remove temp image directory: %v\n", err)
+	// Try to remove the temp directory itself, with anything left in it
+	if ip.tempDir != "" {
+		report.RemoveTree(ip.tempDir)
 	}
-
-	// Always return nil: cleanup failures don't block conversion
-	return nil
+	return report
 }
 
 // ============================================================================
@@ -510,29 +734,59 @@ func (ip *ImageProcessor) DownloadImageOnce(imageURL string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(ip.timeoutSeconds)*time.Second)
 	defer cancel()
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
-	if err != nil {
-		errMsg := fmt.Sprintf("failed to create request: %v", err)
-		ip.mu.Lock()
-		ip.downloadErrors[imageURL] = errMsg
-		ip.mu.Unlock()
-		return "", fmt.Errorf("failed to create request for %s: %w", imageURL, err)
+	// Fetch the image through the configured transport, asking only for a
+	// changed image if the cache holds one
+	var cached CachedImage
+	var isCached bool
+	if ip.cache != nil {
+		cached, isCached = ip.cache.Lookup(imageURL)
+	}
+	body, meta, err := fetchIfChanged(ctx, ip.fetcher, imageURL, cached.Validators)
+	if errors.Is(err, ErrNotModified) && isCached {
+		if localPath, err := ip.useCachedImage(imageURL, cached.Path); err == nil {
+			return localPath, nil
+		}
+		// The cached copy can't be used; download the image after all
+		body, meta, err = ip.fetcher.Fetch(ctx, imageURL)
 	}
-
-	// Execute request
-	resp, err := ip.httpClient.Do(req)
 	if err != nil {
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) {
+			errMsg := fmt.Sprintf("invalid HTTP response from %s: %v", imageURL, err)
+			ip.mu.Lock()
+			ip.downloadErrors[imageURL] = errMsg
+			ip.mu.Unlock()
+			return "", fmt.Errorf("invalid HTTP response from %s: %w", imageURL, err)
+		}
 		errMsg := fmt.Sprintf("failed to download: %v", err)
 		ip.mu.Lock()
 		ip.downloadErrors[imageURL] = errMsg
 		ip.mu.Unlock()
 		return "", fmt.Errorf("failed to download %s: %w", imageURL, err)
 	}
-	defer resp.Body.Close()
+	defer body.Close()
 
-	// Validate response
-	if err := validateHTTPRequest(resp); err != nil {
+	// A server that ignores the conditional request may still send the ETag
+	// of the cached image, whose body then needn't be read
+	if isCached && meta.ETag != "" && meta.ETag == cached.Validators.ETag {
+		if localPath, err := ip.useCachedImage(imageURL, cached.Path); err == nil {
+			return localPath, nil
+		}
+	}
+
+	// Validate response, recognizing images sent with a non-image type
+	// from their content unless the declared type must be trusted
+	contentType := meta.ContentType
+	var content io.Reader = body
+	if ip.strictContentType {
+		err = validateFetchMeta(meta)
+	} else {
+		buffered := bufio.NewReaderSize(body, sniffLen)
+		head, _ := buffered.Peek(sniffLen)
+		contentType, err = resolveContentType(meta.ContentType, head)
+		content = buffered
+	}
+	if err != nil {
 		errMsg := fmt.Sprintf("invalid HTTP response from %s: %v", imageURL, err)
 		ip.mu.Lock()
 		ip.downloadErrors[imageURL] = errMsg
@@ -540,25 +794,33 @@ func (ip *ImageProcessor) DownloadImageOnce(imageURL string) (string, error) {
 		return "", fmt.Errorf("%s", errMsg)
 	}
 
-	// Validate size
-	contentLength := resp.ContentLength
-	if contentLength == -1 {
-		// If Content-Length is not set, we need to read the body to determine size
-		// For now, allow it and check during write
-		contentLength = 0
-	}
-	if contentLength > 0 {
-		if err := ip.ValidateImageSize(contentLength); err != nil {
+	// Reserve the declared size up front; the rest, if the size is unknown
+	// or understated, is reserved as the body is read
+	reader := &reservingReader{ip: ip, r: content}
+	if contentLength := meta.ContentLength; contentLength > 0 {
+		err := ip.ValidateImageSize(contentLength)
+		if err == nil {
+			err = ip.ReserveBytes(contentLength)
+		}
+		if err != nil {
 			errMsg := fmt.Sprintf("image size validation failed: %v", err)
 			ip.mu.Lock()
 			ip.downloadErrors[imageURL] = errMsg
 			ip.mu.Unlock()
 			return "", fmt.Errorf("image size validation failed for %s: %w", imageURL, err)
 		}
+		reader.reserved = contentLength
 	}
+	committed := false
+	defer func() {
+		if !committed {
+			ip.ReleaseBytes(reader.reserved)
+		}
+	}()
 
 	// Generate filename and create temp file
-	fileName := generateFileName(imageURL, resp.Header.Get("Content-Type"))
+	ext := ip.extensionFor(contentType)
+	fileName := generateFileName(imageURL, ext)
 	tempFile, err := os.CreateTemp(ip.tempDir, fileName)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to create temp file: %v", err)
@@ -570,7 +832,7 @@ func (ip *ImageProcessor) DownloadImageOnce(imageURL string) (string, error) {
 	defer tempFile.Close()
 
 	// Copy response body to file with size tracking
-	writtenBytes, err := io.Copy(tempFile, resp.Body)
+	writtenBytes, err := io.CopyBuffer(tempFile, reader, make([]byte, ip.copyBufferSize))
 	if err != nil {
 		// Clean up failed download
 		os.Remove(tempFile.Name())
@@ -581,26 +843,49 @@ func (ip *ImageProcessor) DownloadImageOnce(imageURL string) (string, error) {
 		return "", fmt.Errorf("failed to write image from %s: %w", imageURL, err)
 	}
 
-	// Validate size after download if not provided in header
-	if contentLength == 0 {
-		if err := ip.ValidateImageSize(writtenBytes); err != nil {
-			os.Remove(tempFile.Name())
-			errMsg := fmt.Sprintf("image too large: %v", err)
+	localPath := tempFile.Name()
+
+	// No engine can load an image of an unknown type; convert it to PNG
+	if ext == unknownImageExtension {
+		tempFile.Close()
+		pngPath, err := convertToPNG(localPath)
+		os.Remove(localPath)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to convert image: %v", err)
 			ip.mu.Lock()
 			ip.downloadErrors[imageURL] = errMsg
 			ip.mu.Unlock()
-			return "", fmt.Errorf("image too large from %s: %w", imageURL, err)
+			return "", fmt.Errorf("failed to convert image from %s: %w", imageURL, err)
 		}
+		localPath = pngPath
 	}
 
-	localPath := tempFile.Name()
-
-	// Update state
+	// Update state, swapping the reservation for the bytes written
+	ip.CommitBytes(reader.reserved, writtenBytes)
+	committed = true
 	ip.mu.Lock()
 	ip.imageMap[imageURL] = localPath
-	ip.totalBytesDownloaded += writtenBytes
 	ip.mu.Unlock()
 
+	// The cache only saves a later download, so failing to write it isn't an error
+	if ip.cache != nil {
+		_ = ip.cache.Store(imageURL, CacheValidators{ETag: meta.ETag, LastModified: meta.LastModified}, localPath)
+	}
+
+	return localPath, nil
+}
+
+// useCachedImage copies the cached image at cachedPath into the temp
+// directory as imageURL's, since Cleanup removes the images it maps.
+func (ip *ImageProcessor) useCachedImage(imageURL, cachedPath string) (string, error) {
+	localPath, err := copyCachedImage(cachedPath, ip.tempDir, generateFileName(imageURL, filepath.Ext(cachedPath)))
+	if err != nil {
+		return "", err
+	}
+	ip.mu.Lock()
+	ip.imageMap[imageURL] = localPath
+	ip.cachedImages++
+	ip.mu.Unlock()
 	return localPath, nil
 }
 
@@ -618,19 +903,10 @@ func (ip *ImageProcessor) downloadWithRetry(imageURL string) (string, error) {
 		}
 
 		// Check if error is transient
-		// Extract status code from error message if possible
 		statusCode := 0
-		if errMsg := err.Error(); strings.Contains(errMsg, "HTTP") {
-			// Try to extract status code from error message
-			parts := strings.Fields(errMsg)
-			for i, part := range parts {
-				if part == "HTTP" && i+1 < len(parts) {
-					// Next field should be status code
-					if code, parseErr := strconv.Atoi(parts[i+1]); parseErr == nil {
-						statusCode = code
-					}
-				}
-			}
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) {
+			statusCode = statusErr.StatusCode
 		}
 
 		lastErr = err
@@ -655,12 +931,10 @@ func (ip *ImageProcessor) DownloadWithRetry(imageURL string) (string, error) {
 }
 
 // RewriteMarkdownImageURLs rewrites markdown image references to use local paths.
-// For each markdown image ![alt](url), if url is in the imageMap, replaces it with the local path.
+// For each markdown image ![alt](url "title"), if url is in the imageMap, replaces the
+// destination with the local path, leaving alt text and title untouched.
 // Otherwise, leaves the original URL unchanged.
 func (ip *ImageProcessor) RewriteMarkdownImageURLs(content string) string {
-	// Regex to match markdown image syntax: ![alt text](url)
-	imageRegex := regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
-
 	// Get a snapshot of the image map
 	ip.mu.Lock()
 	imageMapSnapshot := make(map[string]string)
@@ -669,25 +943,19 @@ func (ip *ImageProcessor) RewriteMarkdownImageURLs(content string) string {
 	}
 	ip.mu.Unlock()
 
-	// Replace matched URLs with local paths if available
-	result := imageRegex.ReplaceAllStringFunc(content, func(match string) string {
-		// Extract the URL from the match
-		submatches := imageRegex.FindStringSubmatch(match)
-		if len(submatches) < 3 {
-			return match
-		}
-
-		altText := submatches[1]
-		imageURL := submatches[2]
-
-		// Check if we have a local path for this URL
-		if localPath, exists := imageMapSnapshot[imageURL]; exists {
-			return fmt.Sprintf("![%s](%s)", altText, localPath)
+	// Replace destinations with local paths if available, copying everything else verbatim
+	var sb strings.Builder
+	last := 0
+	for _, ref := range findImageRefs(content) {
+		localPath, exists := imageMapSnapshot[ref.Destination]
+		if !exists {
+			continue
 		}
+		sb.WriteString(content[last:ref.DestStart])
+		sb.WriteString(formatDestination(localPath))
+		last = ref.DestEnd
+	}
+	sb.WriteString(content[last:])
 
-		// Return original if not in map
-		return match
-	})
-
-	return result
+	return sb.String()
 }
@@ -0,0 +1,107 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ImposeEngines are the engines that can impose pages (with the pdfpages
+// package), in order of preference.
+var ImposeEngines = []string{"pdflatex", "xelatex", "lualatex"}
+
+// ImposeOptions holds options for rearranging a PDF's pages for printing.
+type ImposeOptions struct {
+	Booklet    bool   // Reorder pages for a folded, duplex-printed booklet, two to a sheet side
+	NUp        int    // Pages per sheet side, 2 or 4 (ignored for a booklet)
+	Paper      string // Sheet paper: a4, letter, or legal (default: a4)
+	EnginePath string // Path to a LaTeX engine with the pdfpages package
+	Limits     Limits // Caps the CPU time and memory of the engine (optional)
+}
+
+// ValidateNUp checks a pages-per-sheet count.
+func ValidateNUp(n int) error {
+	if n != 0 && n != 2 && n != 4 {
+		return fmt.Errorf("pages per sheet must be 2 or 4, not %d", n)
+	}
+	return nil
+}
+
+// ImposeLaTeX returns the LaTeX document that imposes input, a PDF in the
+// directory the document is compiled in, as opts asks.
+func ImposeLaTeX(input string, opts ImposeOptions) string {
+	paper := opts.Paper
+	if paper == "" {
+		paper = "a4"
+	}
+	// Landscape turns each sheet side so two portrait pages sit side by side
+	layout := "nup=1x2,landscape"
+	switch {
+	case opts.Booklet:
+		layout = "booklet=true,landscape"
+	case opts.NUp == 4:
+		layout = "nup=2x2"
+	}
+	return fmt.Sprintf(`\documentclass[%spaper]{article}
+\usepackage{pdfpages}
+\begin{document}
+\includepdf[pages=-,%s]{%s}
+\end{document}
+`, paper, layout, input)
+}
+
+// Impose rearranges the pages of the PDF at path in place, for booklet or
+// n-up printing, by compiling ImposeLaTeX with a LaTeX engine.
+func Impose(path string, opts ImposeOptions) error {
+	dir, err := os.MkdirTemp("", "veve-impose-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory for imposition: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The engine reads a copy with a name LaTeX can't misread
+	if err := copyFile(path, filepath.Join(dir, "pages.pdf")); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "impose.tex"), []byte(ImposeLaTeX("pages.pdf", opts)), 0o600); err != nil {
+		return fmt.Errorf("failed to write imposition document: %w", err)
+	}
+
+	cmd := exec.Command(opts.EnginePath, "-interaction=nonstopmode", "-halt-on-error", "impose.tex")
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := runLimited(cmd, opts.Limits); err != nil {
+		if !opts.Limits.IsZero() {
+			err = fmt.Errorf("%w (limits: %s)", err, opts.Limits)
+		}
+		return fmt.Errorf("imposing pages failed (the pdfpages package is needed): %w\n%s", err, output.String())
+	}
+	return copyFile(filepath.Join(dir, "impose.pdf"), path)
+}
+
+// copyFile copies the file at src to dst, replacing dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return nil
+}
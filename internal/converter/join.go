@@ -0,0 +1,73 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// JoinTools are the tools that can join PDFs into one, in order of
+// preference: qpdf, and poppler's pdfunite.
+var JoinTools = []string{"qpdf", "pdfunite"}
+
+// qpdfWarnings is qpdf's exit code for a file written despite warnings,
+// such as a damaged outline in one of the inputs.
+const qpdfWarnings = 3
+
+// JoinArgs returns the arguments for the join tool named tool to write the
+// pages of inputs, in order, to output.
+func JoinArgs(tool string, inputs []string, output string) []string {
+	if tool == "qpdf" {
+		args := append([]string{"--empty", "--pages"}, inputs...)
+		return append(args, "--", output)
+	}
+	return append(append([]string{}, inputs...), output)
+}
+
+// JoinPDFs writes the pages of the PDFs inputs, in order, to output with the
+// join tool at toolPath. The joined file is written beside output and renamed
+// over it, so a failed join leaves an earlier output as it was.
+func JoinPDFs(inputs []string, output, toolPath string) error {
+	if len(inputs) == 0 {
+		return errors.New("no PDFs to join")
+	}
+	if dir := filepath.Dir(output); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	f, err := os.CreateTemp(filepath.Dir(output), ".veve-join-*.pdf")
+	if err != nil {
+		return fmt.Errorf("failed to create joined PDF: %w", err)
+	}
+	// Only the random name is kept: the tool writes the file itself
+	tmp := f.Name()
+	f.Close()
+	os.Remove(tmp)
+	defer os.Remove(tmp)
+
+	tool := strings.TrimSuffix(filepath.Base(toolPath), filepath.Ext(toolPath))
+	var stderr bytes.Buffer
+	cmd := exec.Command(toolPath, JoinArgs(tool, inputs, tmp)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if tool != "qpdf" || !errors.As(err, &exitErr) || exitErr.ExitCode() != qpdfWarnings {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = errors.New(msg)
+			}
+			return fmt.Errorf("%s could not join the PDFs: %w", tool, err)
+		}
+	}
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	if err := os.Rename(tmp, output); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/madstone-tech/veve-cli/internal/theme"
+)
+
+// LaTeXThemeSettings is the subset of a CSS theme that LaTeX engines can apply.
+// LaTeX engines ignore --css, so the theme is translated into pandoc variables
+// and preamble snippets instead.
+type LaTeXThemeSettings struct {
+	// Variables are passed to pandoc as -V key=value
+	Variables map[string]string
+
+	// HeaderIncludes are LaTeX preamble snippets
+	HeaderIncludes []string
+
+	// Ignored lists declarations with no LaTeX equivalent
+	Ignored []IgnoredDeclaration
+}
+
+// IgnoredDeclaration is a theme declaration a LaTeX engine cannot apply.
+type IgnoredDeclaration struct {
+	Selector string
+	Property string
+	Line     int
+	Column   int
+}
+
+func (d IgnoredDeclaration) String() string {
+	return fmt.Sprintf("%s { %s }", d.Selector, d.Property)
+}
+
+// latexHeadingCommands maps heading selectors to their sectsty font commands.
+// Pandoc maps level-1 headings to \section by default.
+var latexHeadingCommands = map[string]string{
+	"h1": `\sectionfont`,
+	"h2": `\subsectionfont`,
+	"h3": `\subsubsectionfont`,
+	"h4": `\paragraphfont`,
+	"h5": `\subparagraphfont`,
+}
+
+// latexTaskCommands maps task list box selectors to the commands drawing them
+// (see PrepareTasks).
+var latexTaskCommands = map[string]string{
+	".task-todo::before": `\vevetasktodo`,
+	".task-todo:before":  `\vevetasktodo`,
+	".task-done::before": `\vevetaskdone`,
+	".task-done:before":  `\vevetaskdone`,
+}
+
+// latexNoteSelectors are the selectors whose font and color declarations style
+// footnotes and endnotes. Pandoc collects notes in section.footnotes.
+var latexNoteSelectors = map[string]bool{
+	".footnotes": true, "section.footnotes": true, ".footnotes li": true, ".footnotes p": true,
+}
+
+// latexEscaper escapes characters with special meaning in LaTeX text.
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, `{`, `\{`, `}`, `\}`, `$`, `\$`, `&`, `\&`,
+	`#`, `\#`, `%`, `\%`, `_`, `\_`, `^`, `\^{}`, `~`, `\~{}`,
+)
+
+// genericFontFamilies are CSS generic families, which have no system font name.
+var genericFontFamilies = map[string]bool{
+	"serif": true, "sans-serif": true, "monospace": true, "cursive": true,
+	"fantasy": true, "system-ui": true, "ui-monospace": true, "ui-sans-serif": true,
+	"ui-serif": true,
+}
+
+// TranslateCSSToLaTeX translates the parts of css that have LaTeX equivalents:
+// body and @page fonts, colors, font size, line height and margins; heading
+// colors, sizes and weights; link colors; code fonts; note colors, sizes and
+// weights (see Endnotes); widows, orphans, and
+// break-after: avoid on headings (see Pagination); and task list box glyphs
+// (see PrepareTasks).
+//
+// fontAvailable reports whether a font family is installed. Font families are
+// only translated when it is non-nil (i.e. for fontspec engines such as xelatex
+// and lualatex); the first installed family in a CSS font stack is used.
+func TranslateCSSToLaTeX(css string, fontAvailable func(family string) bool) LaTeXThemeSettings {
+	settings := LaTeXThemeSettings{Variables: map[string]string{}}
+	t := &latexTranslator{settings: &settings, fontAvailable: fontAvailable, headings: map[string][]string{}}
+
+	sheet, _ := theme.ParseCSS(css)
+	for _, rule := range sheet.Rules {
+		selectors := rule.Selectors
+		switch rule.AtRule {
+		case "":
+		case "page":
+			selectors = []string{"@page"}
+		default:
+			continue // @media, @font-face, etc. have no LaTeX equivalent
+		}
+
+		for _, selector := range selectors {
+			for _, decl := range rule.Declarations {
+				if !t.apply(selector, decl.Property, decl.Value) {
+					t.ignore(selector, decl)
+				}
+			}
+		}
+	}
+
+	t.finish()
+	return settings
+}
+
+// latexTranslator accumulates settings while walking CSS declarations.
+type latexTranslator struct {
+	settings      *LaTeXThemeSettings
+	fontAvailable func(string) bool
+	headings      map[string][]string // heading font command -> font switches
+	notes         []string            // footnote and endnote font switches
+	colors        []string            // \definecolor lines
+	preamble      []string
+	pagination    Pagination
+	ignored       map[string]bool // "selector { property }" already reported
+}
+
+// apply translates one declaration, returning false if it has no LaTeX equivalent.
+func (t *latexTranslator) apply(selector, property, value string) bool {
+	switch property {
+	case "widows", "orphans":
+		if selector != "body" && selector != "html" && selector != "p" {
+			return false
+		}
+		return t.applyLines(property, value)
+	case "break-after", "page-break-after":
+		if !isHeadingSelector(selector) || value != "avoid" {
+			return false
+		}
+		t.pagination.KeepHeadings = true
+		return true
+	}
+
+	switch {
+	case selector == "body" || selector == "html" || selector == "@page":
+		return t.applyPage(property, value)
+	case latexHeadingCommands[selector] != "":
+		return t.applyHeading(latexHeadingCommands[selector], property, value)
+	case selector == "a" || selector == "a:link":
+		if property != "color" {
+			return false
+		}
+		hex, ok := parseCSSColor(value)
+		if !ok {
+			return false
+		}
+		t.colors = append(t.colors, fmt.Sprintf(`\definecolor{veveLink}{HTML}{%s}`, hex))
+		t.settings.Variables["colorlinks"] = "true"
+		t.settings.Variables["linkcolor"] = "veveLink"
+		t.settings.Variables["urlcolor"] = "veveLink"
+		return true
+	case latexNoteSelectors[selector]:
+		switchCmd, ok := fontSwitch(property, value)
+		if !ok {
+			return false
+		}
+		t.notes = append(t.notes, switchCmd)
+		return true
+	case latexTaskCommands[selector] != "":
+		if property != "content" {
+			return false
+		}
+		glyph, ok := cssStringValue(value)
+		if !ok || glyph == "" {
+			return false
+		}
+		t.preamble = append(t.preamble, fmt.Sprintf(`\newcommand{%s}{%s}`, latexTaskCommands[selector], latexEscaper.Replace(glyph)))
+		return true
+	case selector == "code" || selector == "pre" || selector == "pre code" || selector == "kbd" || selector == "samp":
+		if property != "font-family" || t.fontAvailable == nil {
+			return false
+		}
+		if font := t.firstAvailableFont(value); font != "" {
+			t.settings.Variables["monofont"] = font
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// applyPage translates document-wide declarations from body, html, or @page.
+func (t *latexTranslator) applyPage(property, value string) bool {
+	switch property {
+	case "font-family":
+		if t.fontAvailable == nil {
+			return false
+		}
+		if font := t.firstAvailableFont(value); font != "" {
+			t.settings.Variables["mainfont"] = font
+			return true
+		}
+		if strings.Contains(value, "sans-serif") {
+			t.preamble = append(t.preamble, `\renewcommand{\familydefault}{\sfdefault}`)
+			return true
+		}
+		return false
+	case "font-size":
+		// The standard LaTeX classes only provide these sizes
+		if value == "10pt" || value == "11pt" || value == "12pt" {
+			t.settings.Variables["fontsize"] = value
+			return true
+		}
+		return false
+	case "line-height":
+		if n, err := strconv.ParseFloat(value, 64); err == nil && n > 0 {
+			t.settings.Variables["linestretch"] = strconv.FormatFloat(n, 'f', -1, 64)
+			return true
+		}
+		return false
+	case "color":
+		hex, ok := parseCSSColor(value)
+		if !ok {
+			return false
+		}
+		t.colors = append(t.colors, fmt.Sprintf(`\definecolor{veveText}{HTML}{%s}`, hex))
+		t.preamble = append(t.preamble, `\AtBeginDocument{\color{veveText}}`)
+		return true
+	case "background-color", "background":
+		hex, ok := parseCSSColor(value)
+		if !ok {
+			return false
+		}
+		if hex != "FFFFFF" {
+			t.colors = append(t.colors, fmt.Sprintf(`\definecolor{vevePage}{HTML}{%s}`, hex))
+			t.preamble = append(t.preamble, `\pagecolor{vevePage}`)
+		}
+		return true
+	case "margin":
+		geometry, ok := cssMarginToGeometry(value)
+		if ok {
+			t.settings.Variables["geometry"] = geometry
+		}
+		return ok
+	case "size":
+		switch strings.ToLower(value) {
+		case "a4", "a5", "letter", "legal":
+			t.settings.Variables["papersize"] = strings.ToLower(value)
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// applyHeading translates a heading declaration into a sectsty font switch.
+func (t *latexTranslator) applyHeading(command, property, value string) bool {
+	switchCmd, ok := fontSwitch(property, value)
+	if !ok {
+		return false
+	}
+	t.headings[command] = append(t.headings[command], switchCmd)
+	return true
+}
+
+// fontSwitch translates a color or font declaration to a LaTeX font switch.
+func fontSwitch(property, value string) (string, bool) {
+	switch property {
+	case "color":
+		hex, ok := parseCSSColor(value)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf(`\color[HTML]{%s}`, hex), true
+	case "font-size":
+		size, ok := strings.CutSuffix(value, "pt")
+		n, err := strconv.ParseFloat(size, 64)
+		if !ok || err != nil || n <= 0 {
+			return "", false
+		}
+		return fmt.Sprintf(`\fontsize{%spt}{%spt}\selectfont`, size, strconv.FormatFloat(n*1.2, 'f', 1, 64)), true
+	case "font-weight":
+		switch value {
+		case "bold", "bolder", "600", "700", "800", "900":
+			return `\bfseries`, true
+		case "normal", "lighter", "100", "200", "300", "400", "500":
+			return `\mdseries`, true
+		}
+	case "font-style":
+		switch value {
+		case "italic", "oblique":
+			return `\itshape`, true
+		case "normal":
+			return `\upshape`, true
+		}
+	}
+	return "", false
+}
+
+// firstAvailableFont returns the first installed family of a CSS font stack.
+func (t *latexTranslator) firstAvailableFont(value string) string {
+	for _, family := range strings.Split(value, ",") {
+		family = strings.Trim(strings.TrimSpace(family), `"'`)
+		if family == "" || genericFontFamilies[strings.ToLower(family)] {
+			continue
+		}
+		if t.fontAvailable(family) {
+			return family
+		}
+	}
+	return ""
+}
+
+// ignore records a declaration that has no LaTeX equivalent.
+func (t *latexTranslator) ignore(selector string, decl theme.Declaration) {
+	if t.ignored == nil {
+		t.ignored = map[string]bool{}
+	}
+	ignored := IgnoredDeclaration{Selector: selector, Property: decl.Property, Line: decl.Line, Column: decl.Column}
+	if key := ignored.String(); !t.ignored[key] {
+		t.ignored[key] = true
+		t.settings.Ignored = append(t.settings.Ignored, ignored)
+	}
+}
+
+// applyLines translates the widows and orphans properties.
+func (t *latexTranslator) applyLines(property, value string) bool {
+	lines, err := strconv.Atoi(value)
+	if err != nil || lines < 1 {
+		return false
+	}
+	if property == "widows" {
+		t.pagination.Widows = lines
+	} else {
+		t.pagination.Orphans = lines
+	}
+	return true
+}
+
+// finish assembles the preamble snippets.
+func (t *latexTranslator) finish() {
+	if len(t.colors) > 0 || len(t.preamble) > 0 || len(t.headings) > 0 || len(t.notes) > 0 {
+		t.settings.HeaderIncludes = append(t.settings.HeaderIncludes, t.stylePreamble())
+	}
+	if !t.pagination.IsZero() {
+		t.settings.HeaderIncludes = append(t.settings.HeaderIncludes, paginationLaTeX(t.pagination))
+	}
+}
+
+// stylePreamble returns the color, font, and heading style commands.
+func (t *latexTranslator) stylePreamble() string {
+	lines := []string{`\usepackage{xcolor}`}
+	lines = append(lines, t.colors...)
+	lines = append(lines, t.preamble...)
+
+	if len(t.headings) > 0 {
+		commands := make([]string, 0, len(t.headings))
+		for command := range t.headings {
+			commands = append(commands, command)
+		}
+		sort.Strings(commands)
+
+		// sectsty is not part of every TeX installation; skip heading styles without it
+		var headings []string
+		for _, command := range commands {
+			headings = append(headings, fmt.Sprintf(`%s{%s}`, command, strings.Join(t.headings[command], "")))
+		}
+		lines = append(lines, fmt.Sprintf(`\IfFileExists{sectsty.sty}{\usepackage{sectsty}%s}{}`, strings.Join(headings, "")))
+	}
+
+	// \vevenotestyle also styles endnotes (see endnotesLaTeX); footmisc applies
+	// it to footnotes and, like sectsty, may be missing
+	if len(t.notes) > 0 {
+		lines = append(lines,
+			fmt.Sprintf(`\newcommand{\vevenotestyle}{%s}`, strings.Join(t.notes, "")),
+			`\IfFileExists{footmisc.sty}{\usepackage{footmisc}\renewcommand*{\footnotelayout}{\vevenotestyle}}{}`)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// isHeadingSelector reports whether selector is one of h1 to h6.
+func isHeadingSelector(selector string) bool {
+	return len(selector) == 2 && selector[0] == 'h' && selector[1] >= '1' && selector[1] <= '6'
+}
+
+var cssRGBPattern = regexp.MustCompile(`^rgba?\(\s*(\d{1,3})\s*,\s*(\d{1,3})\s*,\s*(\d{1,3})\s*(,\s*[\d.]+\s*)?\)$`)
+
+// cssStringValue returns the text of a quoted CSS string without escapes.
+func cssStringValue(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') || value[len(value)-1] != value[0] {
+		return "", false
+	}
+	inner := value[1 : len(value)-1]
+	if strings.ContainsAny(inner, `\"'`) {
+		return "", false
+	}
+	return inner, true
+}
+
+// parseCSSColor converts #rgb, #rrggbb, or rgb() colors to an uppercase hex triplet.
+func parseCSSColor(value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if hex, ok := strings.CutPrefix(value, "#"); ok {
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if _, err := strconv.ParseUint(hex, 16, 32); err != nil || len(hex) != 6 {
+			return "", false
+		}
+		return strings.ToUpper(hex), true
+	}
+
+	if m := cssRGBPattern.FindStringSubmatch(value); m != nil {
+		var hex strings.Builder
+		for _, component := range m[1:4] {
+			n, _ := strconv.Atoi(component)
+			if n > 255 {
+				return "", false
+			}
+			fmt.Fprintf(&hex, "%02X", n)
+		}
+		return hex.String(), true
+	}
+
+	switch value {
+	case "black":
+		return "000000", true
+	case "white":
+		return "FFFFFF", true
+	}
+	return "", false
+}
+
+// cssMarginToGeometry converts a CSS margin shorthand into a geometry option string.
+func cssMarginToGeometry(value string) (string, bool) {
+	parts := strings.Fields(value)
+	for _, part := range parts {
+		if !isLaTeXLength(part) {
+			return "", false
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		return "margin=" + parts[0], true
+	case 2:
+		return fmt.Sprintf("top=%s,bottom=%s,left=%s,right=%s", parts[0], parts[0], parts[1], parts[1]), true
+	case 3:
+		return fmt.Sprintf("top=%s,bottom=%s,left=%s,right=%s", parts[0], parts[2], parts[1], parts[1]), true
+	case 4:
+		return fmt.Sprintf("top=%s,right=%s,bottom=%s,left=%s", parts[0], parts[1], parts[2], parts[3]), true
+	}
+	return "", false
+}
+
+// isLaTeXLength reports whether a CSS length uses a unit LaTeX understands.
+func isLaTeXLength(value string) bool {
+	for _, unit := range []string{"in", "cm", "mm", "pt"} {
+		if number, ok := strings.CutSuffix(value, unit); ok {
+			_, err := strconv.ParseFloat(number, 64)
+			return err == nil
+		}
+	}
+	return false
+}
+
+var (
+	installedFontsOnce sync.Once
+	installedFonts     map[string]bool
+)
+
+// SystemFontAvailable reports whether fontconfig knows a font family.
+// It returns false for every family if fc-list is not installed.
+func SystemFontAvailable(family string) bool {
+	installedFontsOnce.Do(func() {
+		installedFonts = map[string]bool{}
+		out, err := exec.Command("fc-list", ":", "family").Output()
+		if err != nil {
+			return
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			for _, name := range strings.Split(line, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					installedFonts[strings.ToLower(name)] = true
+				}
+			}
+		}
+	})
+	return installedFonts[strings.ToLower(family)]
+}
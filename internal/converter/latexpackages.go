@@ -0,0 +1,95 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// missingFilePatterns match the LaTeX errors for a missing package, class,
+// or font file; the first group is the file name.
+var missingFilePatterns = []*regexp.Regexp{
+	regexp.MustCompile("! LaTeX Error: File `([^']+)' not found"),
+	regexp.MustCompile("! I can't find file `([^']+)'"),
+	regexp.MustCompile(`! Font \\[^=]+=(\S+) at \S+ not loadable: Metric \(TFM\) file not found`),
+}
+
+// MissingLaTeXFiles returns the files LaTeX reported missing in its output,
+// in order and without duplicates.
+func MissingLaTeXFiles(output string) []string {
+	var files []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		for _, pattern := range missingFilePatterns {
+			match := pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			file := match[1]
+			if pattern == missingFilePatterns[2] {
+				file += ".tfm"
+			}
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// missingPackagesError returns a *engines.MissingLaTeXPackagesError if the
+// LaTeX output of the conversion reports missing files, or nil.
+func missingPackagesError(output string, opts ConversionOptions, err error) error {
+	files := MissingLaTeXFiles(output)
+	if len(files) == 0 {
+		return nil
+	}
+	enginePath := opts.PDFEnginePath
+	if enginePath == "" {
+		enginePath, _ = engines.LookupEngine(opts.PDFEngine)
+	}
+	return engines.NewMissingLaTeXPackagesError(files, enginePath, err)
+}
+
+// maxInstallRounds bounds the conversions installMissingPackages retries:
+// LaTeX stops at the first missing file, so each run may report another.
+const maxInstallRounds = 10
+
+// installMissingPackages installs the packages err reports missing and runs
+// convert again, until it succeeds, fails otherwise, or reports packages
+// that are already installed (a file the guessed package doesn't provide).
+func installMissingPackages(err error, convert func() error) error {
+	installed := map[string]bool{}
+	for round := 0; round < maxInstallRounds; round++ {
+		var missing *engines.MissingLaTeXPackagesError
+		if !errors.As(err, &missing) {
+			return err
+		}
+		var packages []string
+		for _, pkg := range missing.Packages {
+			if !installed[pkg] {
+				installed[pkg] = true
+				packages = append(packages, pkg)
+			}
+		}
+		if len(packages) == 0 {
+			return err
+		}
+
+		noun := "packages"
+		if len(packages) == 1 {
+			noun = "package"
+		}
+		fmt.Fprintf(os.Stderr, "Installing LaTeX %s: %s\n", noun, strings.Join(packages, ", "))
+		if installErr := engines.InstallLaTeXPackages(missing.Distribution, packages, os.Stderr); installErr != nil {
+			return fmt.Errorf("%w\nautomatic installation failed: %v", err, installErr)
+		}
+		err = convert()
+	}
+	return err
+}
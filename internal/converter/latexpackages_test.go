@@ -0,0 +1,77 @@
+package converter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// TestMissingLaTeXFiles tests finding missing files in LaTeX output.
+func TestMissingLaTeXFiles(t *testing.T) {
+	output := "Error producing PDF.\n" +
+		"! LaTeX Error: File `fontspec.sty' not found.\n" +
+		"Type X to quit or <RETURN> to proceed,\n" +
+		"! LaTeX Error: File `fontspec.sty' not found.\n" +
+		"! I can't find file `scrartcl.cls'.\n" +
+		"! Font \\T1/lmr/m/n/10=ec-lmr10 at 10.0pt not loadable: Metric (TFM) file not found.\n"
+
+	want := []string{"fontspec.sty", "scrartcl.cls", "ec-lmr10.tfm"}
+	if got := MissingLaTeXFiles(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("MissingLaTeXFiles = %v, want %v", got, want)
+	}
+	if got := MissingLaTeXFiles("! Undefined control sequence."); got != nil {
+		t.Errorf("MissingLaTeXFiles of another error = %v, want none", got)
+	}
+}
+
+// TestInstallMissingPackages tests installing packages and converting again
+// until the conversion succeeds or asks for a package already installed.
+func TestInstallMissingPackages(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as tlmgr")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "installed")
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "tlmgr"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	missingErr := func(packages ...string) error {
+		return &engines.MissingLaTeXPackagesError{Files: packages, Packages: packages, Distribution: engines.TeXLive}
+	}
+
+	// Each run reports the next missing package
+	runs := []error{missingErr("b"), nil}
+	err := installMissingPackages(missingErr("a"), func() error {
+		err := runs[0]
+		runs = runs[1:]
+		return err
+	})
+	if err != nil {
+		t.Fatalf("installMissingPackages failed: %v", err)
+	}
+	data, _ := os.ReadFile(log)
+	if got := string(data); got != "install a\ninstall b\n" {
+		t.Errorf("installed %q", got)
+	}
+
+	// A package that doesn't provide the file isn't installed twice
+	var missing *engines.MissingLaTeXPackagesError
+	err = installMissingPackages(missingErr("c"), func() error { return missingErr("c") })
+	if !errors.As(err, &missing) {
+		t.Errorf("installMissingPackages = %v, want the missing packages error", err)
+	}
+
+	// Other errors are returned as they are
+	other := errors.New("undefined control sequence")
+	if err := installMissingPackages(other, nil); err != other {
+		t.Errorf("installMissingPackages = %v, want %v", err, other)
+	}
+}
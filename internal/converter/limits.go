@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Limits caps the resources pandoc and the PDF engine it runs may use, so a
+// pathological document cannot exhaust a shared machine. The limits are
+// inherited by the engine process (setrlimit on Unix, a job object on Windows).
+type Limits struct {
+	CPUTime time.Duration // CPU time (0 = unlimited)
+	Memory  int64         // Memory in bytes (0 = unlimited)
+
+	// Deadline is when a run still going is killed (zero = never), as for a
+	// batch job's --job-timeout. It bounds the wall-clock time of the
+	// conversion rather than the resources a process uses, so IsZero and
+	// String leave it out.
+	Deadline time.Time
+}
+
+// ErrDeadline is returned when a run is killed at its Limits.Deadline.
+var ErrDeadline = errors.New("killed at its deadline")
+
+// IsZero reports whether no CPU time or memory limit is set.
+func (l Limits) IsZero() bool {
+	return l.CPUTime <= 0 && l.Memory <= 0
+}
+
+// String describes the limits for error messages.
+func (l Limits) String() string {
+	var parts []string
+	if l.CPUTime > 0 {
+		parts = append(parts, "CPU time "+l.CPUTime.String())
+	}
+	if l.Memory > 0 {
+		parts = append(parts, "memory "+FormatMemorySize(l.Memory))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cpuSeconds returns the CPU time limit in whole seconds, rounded up.
+func (l Limits) cpuSeconds() int64 {
+	return int64((l.CPUTime + time.Second - 1) / time.Second)
+}
+
+// runUntil runs cmd, killing it if it is still running at deadline (if set).
+func runUntil(cmd *exec.Cmd, deadline time.Time) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return waitUntil(cmd, deadline)
+}
+
+// waitUntil waits for cmd, started, to exit, killing it if it is still
+// running at deadline (if set).
+func waitUntil(cmd *exec.Cmd, deadline time.Time) error {
+	if deadline.IsZero() {
+		return cmd.Wait()
+	}
+	var killed atomic.Bool
+	timer := time.AfterFunc(time.Until(deadline), func() {
+		killed.Store(true)
+		cmd.Process.Kill()
+	})
+	err := cmd.Wait()
+	timer.Stop()
+	if killed.Load() {
+		return fmt.Errorf("%s %w", filepath.Base(cmd.Path), ErrDeadline)
+	}
+	return err
+}
+
+// memorySizeUnits are the suffixes ParseMemorySize accepts, largest first.
+var memorySizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+}
+
+// ParseMemorySize parses a memory size such as "512M" or "2G" (binary units;
+// a plain number is bytes).
+func ParseMemorySize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "IB"), "B")
+	multiplier := int64(1)
+	for _, unit := range memorySizeUnits {
+		if trimmed, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = trimmed, unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid memory size %q: use a number with an optional K, M, or G suffix (e.g. 512M)", s)
+	}
+	return n * multiplier, nil
+}
+
+// FormatMemorySize formats bytes in the largest unit that divides it.
+func FormatMemorySize(bytes int64) string {
+	for _, unit := range memorySizeUnits {
+		if bytes >= unit.bytes && bytes%unit.bytes == 0 {
+			return strconv.FormatInt(bytes/unit.bytes, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
@@ -0,0 +1,92 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMemorySize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"1048576", 1 << 20, false},
+		{"512M", 512 << 20, false},
+		{"2G", 2 << 30, false},
+		{"2gb", 2 << 30, false},
+		{"64KiB", 64 << 10, false},
+		{"", 0, true},
+		{"12X", 0, true},
+		{"-1G", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMemorySize(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMemorySize(%q) = %d, %v; want %d, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLimitsString(t *testing.T) {
+	limits := Limits{CPUTime: 90 * time.Second, Memory: 3 << 29}
+	if got := limits.String(); got != "CPU time 1m30s, memory 1536M" {
+		t.Errorf("unexpected description %q", got)
+	}
+	if !(Limits{}).IsZero() {
+		t.Error("expected zero limits")
+	}
+}
+
+// TestRunLimited verifies the limits are applied to the command.
+func TestRunLimited(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("job object limits are not visible to the process")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	cmd := exec.Command("sh", "-c", "ulimit -t")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := runLimited(cmd, Limits{CPUTime: 1500 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "2" {
+		t.Errorf("CPU time limit = %q, want 2 seconds", got)
+	}
+}
+
+// TestRunLimitedDeadline verifies a command still running at the deadline is
+// killed, with or without other limits.
+func TestRunLimitedDeadline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	for _, limits := range []Limits{{}, {CPUTime: time.Minute}} {
+		limits.Deadline = time.Now().Add(200 * time.Millisecond)
+		start := time.Now()
+		err := runLimited(exec.Command("sleep", "30"), limits)
+		if !errors.Is(err, ErrDeadline) {
+			t.Errorf("runLimited(%v) = %v, want ErrDeadline", limits, err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("runLimited(%v) took %s, want it killed at the deadline", limits, elapsed)
+		}
+	}
+
+	limits := Limits{Deadline: time.Now().Add(time.Minute)}
+	if err := runLimited(exec.Command("sleep", "0"), limits); err != nil {
+		t.Errorf("runLimited() = %v, want a command done before the deadline to succeed", err)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// runLimited runs cmd with limits. The command is started through sh, which
+// sets the limits with ulimit and then execs it, so they apply from its first
+// instruction and are inherited by the engine it runs.
+func runLimited(cmd *exec.Cmd, limits Limits) error {
+	if limits.IsZero() {
+		return runUntil(cmd, limits.Deadline)
+	}
+
+	script := ""
+	if limits.CPUTime > 0 {
+		script += fmt.Sprintf("ulimit -t %d && ", limits.cpuSeconds())
+	}
+	if limits.Memory > 0 {
+		// macOS accepts but does not enforce address space limits
+		if runtime.GOOS == "darwin" {
+			return fmt.Errorf("memory limits are not supported on macOS; use a CPU time limit only")
+		}
+		script += fmt.Sprintf("ulimit -v %d && ", (limits.Memory+1023)/1024)
+	}
+	script += `exec "$@"`
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return fmt.Errorf("sh not found in PATH, needed to apply resource limits: %w", err)
+	}
+	cmd.Args = append([]string{sh, "-c", script, "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = sh
+	return runUntil(cmd, limits.Deadline)
+}
@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// runLimited runs cmd with limits, in a job object that also holds the engine
+// processes it starts. The job is closed, killing anything left in it, when
+// the command exits.
+func runLimited(cmd *exec.Cmd, limits Limits) error {
+	if limits.IsZero() {
+		return runUntil(cmd, limits.Deadline)
+	}
+
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create job object for resource limits: %w", err)
+	}
+	defer windows.CloseHandle(job)
+
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if limits.CPUTime > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_JOB_TIME
+		// In 100-nanosecond intervals
+		info.BasicLimitInformation.PerJobUserTimeLimit = int64(limits.CPUTime / 100)
+	}
+	if limits.Memory > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_JOB_MEMORY
+		info.JobMemoryLimit = uintptr(limits.Memory)
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		return fmt.Errorf("failed to set resource limits: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err == nil {
+		err = windows.AssignProcessToJobObject(job, process)
+		windows.CloseHandle(process)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("failed to apply resource limits: %w", err)
+	}
+	return waitUntil(cmd, limits.Deadline)
+}
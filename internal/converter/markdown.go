@@ -0,0 +1,250 @@
+package converter
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// markdownParser parses markdown into a goldmark AST.
+// Only the parser is used; veve never renders with goldmark (pandoc does that).
+var markdownParser = goldmark.New().Parser()
+
+// imageRef is an inline markdown image located in the source text.
+type imageRef struct {
+	// Destination is the image URL as interpreted by the markdown parser
+	Destination string
+
+	// DestStart and DestEnd delimit the destination in the source,
+	// including surrounding angle brackets if the author used them
+	DestStart int
+	DestEnd   int
+
+	// AltStart and AltEnd delimit the alt text (between the brackets)
+	AltStart int
+	AltEnd   int
+
+	// TitleStart and TitleEnd delimit the title including its quotes;
+	// both are -1 if the image has no title
+	TitleStart int
+	TitleEnd   int
+
+	// End is the offset just past the closing parenthesis
+	End int
+
+	// Standalone is true if the image is alone in its paragraph
+	// (optionally followed by a pandoc attribute block), i.e. a figure candidate
+	Standalone bool
+
+	// TopLevel is true if the image's paragraph is a top-level block, not
+	// in a list, block quote, or table
+	TopLevel bool
+}
+
+// findImageRefs returns all inline images in content, in document order.
+//
+// The document is parsed into an AST, so image syntax inside fenced or indented
+// code blocks, code spans, and HTML is never reported. Because goldmark does not
+// record source offsets for image nodes, each image is located by scanning forward
+// from the end of the preceding text; the located destination must match the
+// parsed one, otherwise the image is skipped rather than rewritten incorrectly.
+// Reference-style images (![alt][ref]) are not reported.
+func findImageRefs(content string) []imageRef {
+	source := []byte(content)
+	doc := markdownParser.Parse(text.NewReader(source))
+
+	var refs []imageRef
+	cursor := 0
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Text:
+			cursor = max(cursor, node.Segment.Stop)
+		case *ast.RawHTML:
+			if node.Segments.Len() > 0 {
+				cursor = max(cursor, node.Segments.At(node.Segments.Len()-1).Stop)
+			}
+		case *ast.Image:
+			start := bytes.Index(source[cursor:], []byte("!["))
+			if start < 0 {
+				return ast.WalkContinue, nil
+			}
+			start += cursor
+
+			ref, end, ok := parseInlineImage(source, start)
+			if !ok || unescapeDestination(source[ref.DestStart:ref.DestEnd]) != string(node.Destination) {
+				return ast.WalkContinue, nil
+			}
+
+			ref.Destination = string(node.Destination)
+			ref.End = end
+			ref.Standalone = isStandaloneImage(node, source)
+			if paragraph, ok := node.Parent().(*ast.Paragraph); ok {
+				_, ref.TopLevel = paragraph.Parent().(*ast.Document)
+			}
+			refs = append(refs, ref)
+			cursor = end
+			return ast.WalkSkipChildren, nil
+		default:
+			if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+				cursor = max(cursor, n.Lines().At(0).Start)
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return refs
+}
+
+// parseInlineImage parses `![alt](destination "title")` starting at start,
+// which must point at the "!". Returns the destination location and the offset
+// just past the closing parenthesis.
+func parseInlineImage(source []byte, start int) (imageRef, int, bool) {
+	i := start + 2 // skip "!["
+	ref := imageRef{AltStart: i, TitleStart: -1, TitleEnd: -1}
+
+	// Alt text: balanced brackets, honoring backslash escapes
+	depth := 1
+	for ; i < len(source) && depth > 0; i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+	}
+	if depth != 0 || i >= len(source) || source[i] != '(' {
+		return imageRef{}, 0, false
+	}
+	ref.AltEnd = i - 1
+	i = skipMarkdownSpace(source, i+1)
+
+	ref.DestStart = i
+	if i < len(source) && source[i] == '<' {
+		// <destination with spaces>
+		for i++; i < len(source) && source[i] != '>'; i++ {
+			if source[i] == '\\' {
+				i++
+			} else if source[i] == '\n' {
+				return imageRef{}, 0, false
+			}
+		}
+		if i >= len(source) {
+			return imageRef{}, 0, false
+		}
+		i++
+	} else {
+		// Bare destination: no spaces, balanced parentheses
+		parens := 0
+	scan:
+		for ; i < len(source); i++ {
+			switch c := source[i]; {
+			case c == '\\':
+				i++
+			case c <= ' ':
+				break scan
+			case c == '(':
+				parens++
+			case c == ')':
+				if parens == 0 {
+					break scan
+				}
+				parens--
+			}
+		}
+	}
+	ref.DestEnd = min(i, len(source))
+
+	// Optional title in "...", '...' or (...)
+	i = skipMarkdownSpace(source, i)
+	if i < len(source) && (source[i] == '"' || source[i] == '\'' || source[i] == '(') {
+		ref.TitleStart = i
+		closing := source[i]
+		if closing == '(' {
+			closing = ')'
+		}
+		for i++; i < len(source) && source[i] != closing; i++ {
+			if source[i] == '\\' {
+				i++
+			}
+		}
+		ref.TitleEnd = min(i+1, len(source))
+		i = skipMarkdownSpace(source, i+1)
+	}
+
+	if i >= len(source) || source[i] != ')' {
+		return imageRef{}, 0, false
+	}
+	return ref, i + 1, true
+}
+
+// isStandaloneImage reports whether image is the only content of its paragraph.
+// A trailing pandoc attribute block such as {width=50%} is allowed, since the
+// CommonMark parser sees it as plain text.
+func isStandaloneImage(image *ast.Image, source []byte) bool {
+	if _, ok := image.Parent().(*ast.Paragraph); !ok || image.PreviousSibling() != nil {
+		return false
+	}
+
+	for sibling := image.NextSibling(); sibling != nil; sibling = sibling.NextSibling() {
+		textNode, ok := sibling.(*ast.Text)
+		if !ok {
+			return false
+		}
+		value := strings.TrimSpace(string(textNode.Segment.Value(source)))
+		if value != "" && !(strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}")) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipMarkdownSpace skips spaces, tabs, and newlines starting at i.
+func skipMarkdownSpace(source []byte, i int) int {
+	for i < len(source) && (source[i] == ' ' || source[i] == '\t' || source[i] == '\n' || source[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// unescapeDestination converts a raw link destination into the form reported by the
+// parser: angle brackets removed, backslash escapes and entities resolved.
+func unescapeDestination(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == '<' && raw[len(raw)-1] == '>' {
+		raw = raw[1 : len(raw)-1]
+	}
+
+	var sb bytes.Buffer
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) && isASCIIPunct(raw[i+1]) {
+			i++
+		}
+		sb.WriteByte(raw[i])
+	}
+	return html.UnescapeString(sb.String())
+}
+
+// isASCIIPunct reports whether c is an ASCII punctuation character (escapable in markdown).
+func isASCIIPunct(c byte) bool {
+	return bytes.IndexByte([]byte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"), c) >= 0
+}
+
+// formatDestination formats a local path for use as a markdown link destination,
+// wrapping it in angle brackets when it contains spaces or parentheses.
+func formatDestination(path string) string {
+	if strings.ContainsAny(path, " \t()") {
+		return "<" + path + ">"
+	}
+	return path
+}
@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"testing"
+)
+
+// TestFindImageRefs tests AST-based image location for edge cases the old regex missed.
+func TestFindImageRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "simple image",
+			content: "![alt](https://example.com/a.png)",
+			want:    []string{"https://example.com/a.png"},
+		},
+		{
+			name:    "image with title",
+			content: `![alt](https://example.com/a.png "A title")`,
+			want:    []string{"https://example.com/a.png"},
+		},
+		{
+			name:    "nested parentheses in destination",
+			content: "![wiki](https://example.com/File_(1).png)",
+			want:    []string{"https://example.com/File_(1).png"},
+		},
+		{
+			name:    "angle bracket destination",
+			content: "![alt](<https://example.com/my image.png>)",
+			want:    []string{"https://example.com/my image.png"},
+		},
+		{
+			name:    "brackets in alt text",
+			content: "![see [1]](https://example.com/a.png)",
+			want:    []string{"https://example.com/a.png"},
+		},
+		{
+			name:    "image inside link",
+			content: "[![badge](https://example.com/badge.svg)](https://example.com)",
+			want:    []string{"https://example.com/badge.svg"},
+		},
+		{
+			name:    "fenced code block",
+			content: "```markdown\n![alt](https://example.com/code.png)\n```\n\n![real](https://example.com/real.png)",
+			want:    []string{"https://example.com/real.png"},
+		},
+		{
+			name:    "code span",
+			content: "Use `![alt](https://example.com/code.png)` syntax, like ![real](https://example.com/real.png)",
+			want:    []string{"https://example.com/real.png"},
+		},
+		{
+			name:    "reference style is not reported",
+			content: "![alt][logo]\n\n[logo]: https://example.com/logo.png",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := findImageRefs(tt.content)
+			if len(refs) != len(tt.want) {
+				t.Fatalf("findImageRefs() found %d images, want %d: %+v", len(refs), len(tt.want), refs)
+			}
+			for i, ref := range refs {
+				if ref.Destination != tt.want[i] {
+					t.Errorf("image %d: got %q, want %q", i, ref.Destination, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestRewritePreservesTitle tests that rewriting only replaces the destination.
+func TestRewritePreservesTitle(t *testing.T) {
+	ip := NewImageProcessor(t.TempDir())
+	ip.SetImageMap("https://example.com/a.png", "/tmp/veve-image-1.png")
+	ip.SetImageMap("https://example.com/b.png", "/tmp/my images/b.png")
+
+	content := `![first](https://example.com/a.png "Title (1)") and ![second](https://example.com/b.png)`
+	want := `![first](/tmp/veve-image-1.png "Title (1)") and ![second](</tmp/my images/b.png>)`
+
+	if got := ip.RewriteMarkdownImageURLs(content); got != want {
+		t.Errorf("RewriteMarkdownImageURLs():\ngot:  %s\nwant: %s", got, want)
+	}
+}
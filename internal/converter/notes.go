@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"fmt"
+)
+
+// Endnote placements for UnicodeConversionOptions.Endnotes.
+const (
+	EndnotesOff      = ""         // Footnotes at the bottom of the page (LaTeX engines)
+	EndnotesDocument = "document" // All notes at the end of the document
+	EndnotesChapter  = "chapter"  // Notes at the end of each level-1 section
+)
+
+// ValidateEndnotes checks an endnote placement.
+func ValidateEndnotes(placement string) error {
+	switch placement {
+	case EndnotesOff, EndnotesDocument, EndnotesChapter:
+		return nil
+	}
+	return fmt.Errorf("invalid endnote placement %q: use %q or %q", placement, EndnotesDocument, EndnotesChapter)
+}
+
+// endnotesLaTeXHeader turns footnotes into endnotes with the endnotes package.
+// In chapter mode the notes collected so far are printed, and numbering
+// restarts, before each level-1 heading. Notes are styled by \vevenotestyle,
+// which themes define (see TranslateCSSToLaTeX).
+const endnotesLaTeXHeader = `\usepackage{endnotes}
+\let\footnote=\endnote
+\providecommand{\vevenotestyle}{}
+\renewcommand{\enotesize}{\footnotesize\vevenotestyle}
+\makeatletter
+\newcommand{\veveflushendnotes}{\if@enotesopen\theendnotes\fi}
+\makeatother`
+
+// endnotesLaTeXChapter flushes endnotes at each level-1 heading. The hook also
+// runs for the starred \section in \theendnotes, when no notes are open.
+const endnotesLaTeXChapter = `\usepackage{etoolbox}
+\pretocmd{\section}{\veveflushendnotes\setcounter{endnote}{0}}{}{}
+\renewcommand{\enoteheading}{\subsection*{\notesname}\mbox{}\par\vskip-\baselineskip}`
+
+// endnotesLaTeXAfterBody prints the remaining endnotes.
+const endnotesLaTeXAfterBody = `\veveflushendnotes`
+
+// applyEndnotes configures opts to place notes as endnotes.
+//
+// LaTeX engines use the endnotes package. HTML engines (weasyprint, prince)
+// always collect notes in section.footnotes; chapter mode moves that section to
+// the end of each level-1 section.
+func applyEndnotes(opts *ConversionOptions, placement string, latex bool) {
+	if placement == EndnotesOff {
+		return
+	}
+	if !latex {
+		if placement == EndnotesChapter {
+			opts.ReferenceLocation = "section"
+		}
+		return
+	}
+
+	header := endnotesLaTeXHeader
+	if placement == EndnotesChapter {
+		header += "\n" + endnotesLaTeXChapter
+	}
+	opts.HeaderIncludes = append(opts.HeaderIncludes, header)
+	opts.IncludeAfterBody = append(opts.IncludeAfterBody, endnotesLaTeXAfterBody)
+}
@@ -0,0 +1,49 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateEndnotes(t *testing.T) {
+	for _, placement := range []string{EndnotesOff, EndnotesDocument, EndnotesChapter} {
+		if err := ValidateEndnotes(placement); err != nil {
+			t.Errorf("ValidateEndnotes(%q) = %v", placement, err)
+		}
+	}
+	if err := ValidateEndnotes("page"); err == nil {
+		t.Error("expected an error for an unknown placement")
+	}
+}
+
+func TestApplyEndnotes(t *testing.T) {
+	var off ConversionOptions
+	applyEndnotes(&off, EndnotesOff, true)
+	if len(off.HeaderIncludes) != 0 || len(off.IncludeAfterBody) != 0 || off.ReferenceLocation != "" {
+		t.Errorf("footnotes should be left alone, got %+v", off)
+	}
+
+	var document ConversionOptions
+	applyEndnotes(&document, EndnotesDocument, true)
+	if len(document.HeaderIncludes) != 1 || !strings.Contains(document.HeaderIncludes[0], `\let\footnote=\endnote`) {
+		t.Errorf("expected the endnotes header, got %v", document.HeaderIncludes)
+	}
+	if strings.Contains(document.HeaderIncludes[0], `\pretocmd{\section}`) {
+		t.Error("document endnotes should not be flushed per section")
+	}
+	if len(document.IncludeAfterBody) != 1 {
+		t.Errorf("expected the endnotes to be printed after the body, got %v", document.IncludeAfterBody)
+	}
+
+	var chapter ConversionOptions
+	applyEndnotes(&chapter, EndnotesChapter, true)
+	if !strings.Contains(chapter.HeaderIncludes[0], `\pretocmd{\section}{\veveflushendnotes`) {
+		t.Errorf("chapter endnotes should be flushed per section:\n%s", chapter.HeaderIncludes[0])
+	}
+
+	var html ConversionOptions
+	applyEndnotes(&html, EndnotesChapter, false)
+	if html.ReferenceLocation != "section" || len(html.HeaderIncludes) != 0 {
+		t.Errorf("HTML chapter endnotes should use --reference-location section, got %+v", html)
+	}
+}
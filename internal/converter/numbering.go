@@ -0,0 +1,46 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxNumberDepth is the deepest heading level that can be numbered.
+const maxNumberDepth = 6
+
+// ValidateNumberDepth checks a section numbering depth; 0 numbers every level.
+func ValidateNumberDepth(depth int) error {
+	if depth < 0 || depth > maxNumberDepth {
+		return fmt.Errorf("invalid section numbering depth %d: use 1 to %d, or 0 for every level", depth, maxNumberDepth)
+	}
+	return nil
+}
+
+// applySectionNumbering numbers headings down to level depth (0 = every
+// level). Headings with the {.unnumbered} or {-} class are skipped by pandoc.
+//
+// LaTeX engines limit numbering with secnumdepth, since pandoc maps level-1
+// headings to \section. HTML engines (weasyprint, prince) get a number span in
+// every heading, so spans below depth are hidden.
+func applySectionNumbering(opts *ConversionOptions, depth int, latex bool) {
+	opts.NumberSections = true
+	if depth <= 0 || depth >= maxNumberDepth {
+		return
+	}
+
+	if latex {
+		if opts.Variables == nil {
+			opts.Variables = map[string]string{}
+		}
+		opts.Variables["secnumdepth"] = strconv.Itoa(depth)
+		return
+	}
+
+	var selectors []string
+	for level := depth + 1; level <= maxNumberDepth; level++ {
+		selectors = append(selectors, fmt.Sprintf("h%d .header-section-number", level))
+	}
+	opts.HeaderIncludes = append(opts.HeaderIncludes,
+		fmt.Sprintf("<style>\n%s { display: none; }\n</style>\n", strings.Join(selectors, ", ")))
+}
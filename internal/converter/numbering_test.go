@@ -0,0 +1,46 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplySectionNumbering(t *testing.T) {
+	var all ConversionOptions
+	applySectionNumbering(&all, 0, true)
+	if !all.NumberSections || len(all.Variables) != 0 || len(all.HeaderIncludes) != 0 {
+		t.Errorf("every level should be numbered without limits, got %+v", all)
+	}
+
+	var latex ConversionOptions
+	applySectionNumbering(&latex, 2, true)
+	if got := latex.Variables["secnumdepth"]; got != "2" {
+		t.Errorf("secnumdepth = %q, want 2", got)
+	}
+
+	var html ConversionOptions
+	applySectionNumbering(&html, 2, false)
+	if len(html.HeaderIncludes) != 1 {
+		t.Fatalf("expected one header include, got %v", html.HeaderIncludes)
+	}
+	header := html.HeaderIncludes[0]
+	if !strings.Contains(header, "h3 .header-section-number") || !strings.Contains(header, "h6 .header-section-number") {
+		t.Errorf("levels below 2 should be hidden:\n%s", header)
+	}
+	if strings.Contains(header, "h2 .header-section-number") {
+		t.Errorf("level 2 should stay numbered:\n%s", header)
+	}
+}
+
+func TestValidateNumberDepth(t *testing.T) {
+	for _, depth := range []int{0, 1, 6} {
+		if err := ValidateNumberDepth(depth); err != nil {
+			t.Errorf("ValidateNumberDepth(%d) = %v", depth, err)
+		}
+	}
+	for _, depth := range []int{-1, 7} {
+		if err := ValidateNumberDepth(depth); err == nil {
+			t.Errorf("ValidateNumberDepth(%d) should fail", depth)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StagedOutput is a temporary file beside an output file that the output is
+// written to, then renamed over it once complete. An interrupted or failed
+// conversion leaves the previous output as it was, never a truncated one.
+type StagedOutput struct {
+	Path  string // The temporary file to write to
+	Final string // The output file it replaces
+}
+
+// StageOutput creates a hidden temporary file in outputPath's directory,
+// creating the directory if needed. The file keeps outputPath's extension,
+// which pandoc chooses the output format by.
+func StageOutput(outputPath string) (*StagedOutput, error) {
+	if err := EnsureOutputDirectory(outputPath); err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(outputPath)
+	base := strings.TrimSuffix(filepath.Base(outputPath), ext)
+	f, err := os.CreateTemp(filepath.Dir(outputPath), "."+base+"-*"+ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary output file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to create temporary output file: %w", err)
+	}
+	return &StagedOutput{Path: f.Name(), Final: outputPath}, nil
+}
+
+// Commit renames the temporary file over the output file. The output keeps
+// the permissions of the file it replaces; a new one is readable by all, as
+// a file written directly would be.
+func (s *StagedOutput) Commit() error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(s.Final); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(s.Path, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.Final, err)
+	}
+	if err := os.Rename(s.Path, s.Final); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.Final, err)
+	}
+	return nil
+}
+
+// Discard removes the temporary file, if Commit hasn't renamed it.
+func (s *StagedOutput) Discard() {
+	os.Remove(s.Path)
+}
@@ -0,0 +1,73 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// pageBreakMarkdown replaces page-break markers. Pandoc keeps only the raw block
+// matching its output format, so the same markdown works with every engine:
+// LaTeX engines get \newpage, HTML engines get a page-breaking div.
+const pageBreakMarkdown = "```{=latex}\n\\newpage\n```\n\n" +
+	"```{=html}\n<div class=\"pagebreak\" style=\"break-after: page; page-break-after: always;\"></div>\n```"
+
+// pageBreakComment matches the <!-- pagebreak --> marker.
+var pageBreakComment = regexp.MustCompile(`(?i)^<!--\s*page-?break\s*-->$`)
+
+// PreparePageBreaks translates explicit page-break markers into breaks every
+// engine understands. A marker must be alone in its block at the top level of
+// the document:
+//
+//	\newpage
+//	<!-- pagebreak -->
+//	---pagebreak---
+//
+// Markers inside code blocks, lists, and block quotes are left unchanged.
+func PreparePageBreaks(content string) string {
+	source := []byte(content)
+	doc := markdownParser.Parse(text.NewReader(source))
+
+	var sb strings.Builder
+	last := 0
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		start, end, ok := pageBreakMarker(n, source)
+		if !ok {
+			continue
+		}
+		sb.WriteString(content[last:start])
+		sb.WriteString(pageBreakMarkdown)
+		last = end
+	}
+	sb.WriteString(content[last:])
+
+	return sb.String()
+}
+
+// pageBreakMarker reports whether the block n is a page-break marker and
+// returns the marker's location in source, excluding the trailing newline.
+func pageBreakMarker(n ast.Node, source []byte) (int, int, bool) {
+	lines := n.Lines()
+	if lines.Len() == 0 {
+		return 0, 0, false
+	}
+	start := lines.At(0).Start
+	end := lines.At(lines.Len() - 1).Stop
+	marker := strings.TrimRight(string(source[start:end]), " \t\r\n")
+	end = start + len(marker)
+	marker = strings.TrimSpace(marker)
+
+	switch n.Kind() {
+	case ast.KindParagraph:
+		if lines.Len() == 1 && (marker == `\newpage` || marker == "---pagebreak---") {
+			return start, end, true
+		}
+	case ast.KindHTMLBlock:
+		if pageBreakComment.MatchString(marker) {
+			return start, end, true
+		}
+	}
+	return 0, 0, false
+}
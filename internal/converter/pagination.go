@@ -0,0 +1,81 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pagination controls how text flows across page boundaries.
+type Pagination struct {
+	Widows       int  // Minimum lines of a paragraph at the top of a page (0 = engine default)
+	Orphans      int  // Minimum lines of a paragraph at the bottom of a page (0 = engine default)
+	KeepHeadings bool // Keep headings on the same page as the text that follows them
+}
+
+// IsZero reports whether p leaves pagination to the engine.
+func (p Pagination) IsZero() bool {
+	return p == Pagination{}
+}
+
+// paginationHeader returns the header snippet applying p, as LaTeX for LaTeX
+// engines or as a style block for HTML-based engines.
+func paginationHeader(p Pagination, latex bool) string {
+	if p.IsZero() {
+		return ""
+	}
+	if latex {
+		return paginationLaTeX(p)
+	}
+	return paginationCSS(p)
+}
+
+// paginationCSS uses the CSS widows, orphans, and break-after properties.
+// The snippet follows the theme stylesheet, so it overrides the theme.
+func paginationCSS(p Pagination) string {
+	var rules []string
+	var lines []string
+	if p.Widows > 0 {
+		lines = append(lines, fmt.Sprintf("widows: %d;", p.Widows))
+	}
+	if p.Orphans > 0 {
+		lines = append(lines, fmt.Sprintf("orphans: %d;", p.Orphans))
+	}
+	if len(lines) > 0 {
+		rules = append(rules, fmt.Sprintf("body, p, li, dd, blockquote { %s }", strings.Join(lines, " ")))
+	}
+	if p.KeepHeadings {
+		rules = append(rules, "h1, h2, h3, h4, h5, h6 { break-after: avoid; page-break-after: avoid; }")
+	}
+	return "<style>\n" + strings.Join(rules, "\n") + "\n</style>\n"
+}
+
+// paginationLaTeX uses TeX penalties. TeX can only forbid single widow and club
+// (orphan) lines, so any count above one forbids them outright. LaTeX already
+// keeps a heading with the first lines of a following paragraph; KeepHeadings
+// also reserves space before headings so they are not stranded above lists,
+// code blocks, or tables.
+func paginationLaTeX(p Pagination) string {
+	var lines []string
+	if p.Widows > 0 {
+		penalty := latexLinePenalty(p.Widows)
+		lines = append(lines, fmt.Sprintf(`\widowpenalty=%d \displaywidowpenalty=%d`, penalty, penalty))
+	}
+	if p.Orphans > 0 {
+		lines = append(lines, fmt.Sprintf(`\clubpenalty=%d`, latexLinePenalty(p.Orphans)))
+	}
+	if p.KeepHeadings {
+		lines = append(lines, `\IfFileExists{needspace.sty}{\usepackage{needspace}\usepackage{etoolbox}`+
+			`\pretocmd{\section}{\needspace{5\baselineskip}}{}{}`+
+			`\pretocmd{\subsection}{\needspace{4\baselineskip}}{}{}`+
+			`\pretocmd{\subsubsection}{\needspace{4\baselineskip}}{}{}}{}`)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// latexLinePenalty converts a CSS widows/orphans line count to a TeX penalty.
+func latexLinePenalty(lines int) int {
+	if lines <= 1 {
+		return 0
+	}
+	return 10000
+}
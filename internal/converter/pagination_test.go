@@ -0,0 +1,32 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPaginationHeader(t *testing.T) {
+	if got := paginationHeader(Pagination{}, false); got != "" {
+		t.Errorf("zero pagination should add no header, got %q", got)
+	}
+
+	p := Pagination{Widows: 2, Orphans: 3, KeepHeadings: true}
+
+	css := paginationHeader(p, false)
+	for _, want := range []string{"<style>", "widows: 2;", "orphans: 3;", "break-after: avoid;", "page-break-after: avoid;"} {
+		if !strings.Contains(css, want) {
+			t.Errorf("HTML header missing %q:\n%s", want, css)
+		}
+	}
+
+	latex := paginationHeader(p, true)
+	for _, want := range []string{`\widowpenalty=10000`, `\displaywidowpenalty=10000`, `\clubpenalty=10000`, `\needspace`} {
+		if !strings.Contains(latex, want) {
+			t.Errorf("LaTeX header missing %q:\n%s", want, latex)
+		}
+	}
+
+	if latex := paginationHeader(Pagination{Widows: 1}, true); latex != `\widowpenalty=0 \displaywidowpenalty=0` {
+		t.Errorf("one widow line should allow widows, got %q", latex)
+	}
+}
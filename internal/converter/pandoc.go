@@ -2,13 +2,16 @@ package converter
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"math/rand"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
-	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
 )
 
 // PandocConverter wraps Pandoc for markdown-to-PDF conversion.
@@ -38,6 +41,69 @@ type ConversionOptions struct {
 	Standalone bool   // Generate standalone PDF
 	Quiet      bool   // Suppress output messages
 	Verbose    bool   // Enable verbose output
+
+	// PDFEnginePath is the engine's full path, passed instead of PDFEngine when
+	// the engine is not on PATH (optional)
+	PDFEnginePath string
+
+	// DockerImage runs pandoc and the engine inside this container image
+	// instead of locally (optional)
+	DockerImage string
+
+	// Sandbox runs pandoc with reduced privileges for untrusted input:
+	// SandboxOff, SandboxRestricted, or SandboxFirejail
+	Sandbox string
+
+	// Limits caps the CPU time and memory of pandoc and the engine (optional)
+	Limits Limits
+
+	// MemoryBudget is the memory veve itself aims to stay within (optional)
+	MemoryBudget MemoryBudget
+
+	// Latexmk runs the LaTeX engine through latexmk at this path, which
+	// reruns it until page numbers and references settle (optional)
+	Latexmk string
+
+	// TempDir holds the intermediate files of pandoc and the engine instead
+	// of the system's temporary directory, for a local pandoc (optional)
+	TempDir string
+
+	// EngineArgs are passed to the PDF engine as they are, via
+	// --pdf-engine-opt (optional)
+	EngineArgs []string
+
+	// NumberSections numbers headings via --number-sections
+	NumberSections bool
+
+	// NoSmart reads markdown without pandoc's smart extension, keeping
+	// straight quotes, double hyphens, and three dots as typed
+	NoSmart bool
+
+	// BaseStyles are CSS rules (HTML engines) linked before the theme, so the
+	// theme's rules take precedence
+	BaseStyles []string
+
+	// HeaderIncludes are raw snippets (LaTeX or HTML, matching the engine)
+	// added to the document header via --include-in-header
+	HeaderIncludes []string
+
+	// IncludeAfterBody are raw snippets added at the end of the document body
+	// via --include-after-body
+	IncludeAfterBody []string
+
+	// ReferenceLocation places footnotes in HTML output at the end of each
+	// "block", "section", or the "document" (optional; pandoc defaults to document)
+	ReferenceLocation string
+
+	// Variables are template variables passed via -V key=value
+	Variables map[string]string
+
+	// Template is a custom pandoc template (optional)
+	Template string
+
+	// ResourcePath lists directories pandoc searches for images and other
+	// resources, passed via --resource-path (optional; pandoc defaults to ".")
+	ResourcePath []string
 }
 
 // ValidateInputFile checks if the input markdown file exists and is readable.
@@ -83,6 +149,17 @@ func ResolveOutputPath(inputPath, outputPath string) string {
 	return inputPath + ".pdf"
 }
 
+// pdfEngineOpts returns the pandoc options passing args to the PDF engine.
+// The value is attached with = so pandoc doesn't read an argument starting
+// with a dash, such as -shell-escape, as an option of its own.
+func pdfEngineOpts(args []string) []string {
+	opts := make([]string, 0, len(args))
+	for _, arg := range args {
+		opts = append(opts, "--pdf-engine-opt="+arg)
+	}
+	return opts
+}
+
 // EnsureOutputDirectory creates all parent directories for the output file if they don't exist.
 func EnsureOutputDirectory(outputPath string) error {
 	outputDir := filepath.Dir(outputPath)
@@ -120,8 +197,21 @@ func (pc *PandocConverter) Convert(opts ConversionOptions) error {
 			return err
 		}
 	} else {
-		// For stdout, use a temp file that we'll read and output
-		outputPath = filepath.Join(os.TempDir(), "veve-stdout-"+tempRandString()+".pdf")
+		// For stdout, render into a private temp directory that we'll read and output
+		stdoutDir, err := os.MkdirTemp("", "veve-stdout-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory for stdout output: %w", err)
+		}
+		defer os.RemoveAll(stdoutDir)
+		outputPath = filepath.Join(stdoutDir, "output.pdf")
+	}
+
+	// A sandboxed pandoc runs in its own working directory
+	if opts.Sandbox != SandboxOff {
+		var err error
+		if outputPath, err = sandboxPaths(&opts, outputPath); err != nil {
+			return err
+		}
 	}
 
 	// Build pandoc command
@@ -135,15 +225,45 @@ func (pc *PandocConverter) Convert(opts ConversionOptions) error {
 		args = append(args, opts.InputFile)
 	}
 
+	args = append(args, readerArgs(opts.NoSmart)...)
+
 	// Add output argument
 	args = append(args, "-o", outputPath)
-	args = append(args, "--pdf-engine", opts.PDFEngine)
+	pdfEngine := opts.PDFEngine
+	if opts.PDFEnginePath != "" {
+		pdfEngine = opts.PDFEnginePath
+	}
+	if opts.Latexmk != "" && opts.DockerImage == "" {
+		args = append(args, latexmkArgs(opts.Latexmk, opts.PDFEngine, opts.EngineArgs)...)
+	} else {
+		args = append(args, "--pdf-engine", pdfEngine)
+		args = append(args, pdfEngineOpts(opts.EngineArgs)...)
+	}
+
+	// Stop pandoc's readers and writers from reading files other than its inputs
+	if opts.Sandbox != SandboxOff {
+		args = append(args, "--sandbox")
+	}
 
 	// Add standalone flag for better PDF output
 	if opts.Standalone {
 		args = append(args, "--standalone")
 	}
 
+	// Add default styles ahead of the theme
+	if len(opts.BaseStyles) > 0 {
+		baseFile, err := writeTempFile("veve-base-*.css", opts.BaseStyles)
+		if err != nil {
+			return fmt.Errorf("failed to write base styles: %w", err)
+		}
+		defer os.Remove(baseFile)
+		args = append(args, "--css", baseFile)
+	}
+
+	if opts.NumberSections {
+		args = append(args, "--number-sections")
+	}
+
 	// Add theme/CSS if provided
 	if opts.Theme != "" {
 		// Check if it looks like a file path (contains / or \)
@@ -156,8 +276,74 @@ func (pc *PandocConverter) Convert(opts ConversionOptions) error {
 		}
 	}
 
-	// Create command
+	// Add custom template if provided
+	if opts.Template != "" {
+		args = append(args, "--template", opts.Template)
+	}
+
+	// Add template variables in a stable order
+	variableNames := make([]string, 0, len(opts.Variables))
+	for name := range opts.Variables {
+		variableNames = append(variableNames, name)
+	}
+	sort.Strings(variableNames)
+	for _, name := range variableNames {
+		args = append(args, "-V", name+"="+opts.Variables[name])
+	}
+
+	// Add resource search path
+	if len(opts.ResourcePath) > 0 {
+		args = append(args, "--resource-path", strings.Join(opts.ResourcePath, string(os.PathListSeparator)))
+	}
+
+	// Add header snippets (e.g. figure numbering styles)
+	if len(opts.HeaderIncludes) > 0 {
+		headerFile, err := writeHeaderIncludes(opts.HeaderIncludes)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(headerFile)
+		args = append(args, "--include-in-header", headerFile)
+	}
+
+	// Add end-of-body snippets (e.g. endnotes)
+	if len(opts.IncludeAfterBody) > 0 {
+		afterFile, err := writeTempFile("veve-after-*", opts.IncludeAfterBody)
+		if err != nil {
+			return fmt.Errorf("failed to write after-body include file: %w", err)
+		}
+		defer os.Remove(afterFile)
+		args = append(args, "--include-after-body", afterFile)
+	}
+
+	if opts.ReferenceLocation != "" {
+		args = append(args, "--reference-location", opts.ReferenceLocation)
+	}
+
+	// Create command, in a container if requested
 	cmd := exec.Command(pc.PandocPath, args...)
+	if opts.DockerImage != "" {
+		if opts.Sandbox == SandboxFirejail {
+			return fmt.Errorf("the firejail sandbox cannot be used with a docker engine, which already runs pandoc in a container")
+		}
+		var err error
+		if cmd, err = dockerCommand(opts.DockerImage, args, opts, outputPath, isStdin); err != nil {
+			return err
+		}
+	} else if opts.Sandbox != SandboxOff {
+		cleanup, err := applySandbox(cmd, opts.Sandbox)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+	if opts.TempDir != "" && opts.DockerImage == "" {
+		cmd.Env = withTempDir(cmd.Env, opts.TempDir)
+	}
+	// latexmk runs the engine by name, so it must find the one selected
+	if opts.Latexmk != "" && opts.PDFEnginePath != "" && opts.DockerImage == "" {
+		cmd.Env = withPathDir(cmd.Env, filepath.Dir(opts.PDFEnginePath))
+	}
 
 	// If reading from stdin, connect standard input
 	if isStdin {
@@ -174,42 +360,115 @@ func (pc *PandocConverter) Convert(opts ConversionOptions) error {
 		cmd.Stdout = &stdout
 	}
 
-	// Run conversion
-	if err := cmd.Run(); err != nil {
+	// Run conversion; a container is limited by docker itself
+	run := func() error { return runUntil(cmd, opts.Limits.Deadline) }
+	if opts.DockerImage == "" {
+		run = func() error { return runLimited(cmd, opts.Limits) }
+	}
+	if err := run(); err != nil {
+		if errors.Is(err, ErrDeadline) {
+			return fmt.Errorf("pandoc conversion failed: %w", err)
+		}
+		if crash := newCrashError("pandoc", err, stderr.String(), opts.PDFEngine, opts.Limits); crash != nil {
+			return crash
+		}
+		if !opts.Limits.IsZero() {
+			err = fmt.Errorf("%w (limits: %s; raise --cpu-limit or --memory-limit if the document needs more)", err, opts.Limits)
+		}
 		stderrMsg := stderr.String()
 		if stderrMsg != "" {
-			return fmt.Errorf("pandoc conversion failed: %w\nPandoc stderr: %s", err, stderrMsg)
+			err = fmt.Errorf("pandoc conversion failed: %w\nPandoc stderr: %s", err, stderrMsg)
+		} else {
+			err = fmt.Errorf("pandoc conversion failed: %w", err)
+		}
+		// Name the TeX packages to install, or what is wrong with WeasyPrint's
+		// Python environment; a container's engines are its image's
+		if opts.DockerImage == "" && engines.IsLaTeXEngine(opts.PDFEngine) {
+			if missing := missingPackagesError(stderrMsg, opts, err); missing != nil {
+				return missing
+			}
+		}
+		if opts.DockerImage == "" && opts.PDFEngine == "weasyprint" {
+			path := opts.PDFEnginePath
+			if path == "" {
+				path, _ = engines.LookupEngine("weasyprint")
+			}
+			if diagnosis := engines.DiagnoseWeasyPrintOutput(path, stderrMsg); diagnosis != nil {
+				return diagnosis
+			}
 		}
-		return fmt.Errorf("pandoc conversion failed: %w", err)
+		return err
 	}
 
 	// If outputting to stdout, read the temp file and write to os.Stdout
 	if isStdout {
-		pdfContent, err := os.ReadFile(outputPath)
+		if err := writeStdout(outputPath, opts.MemoryBudget); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeStdout writes the PDF at path to stdout, streaming it from disk if
+// the memory budget asks for it.
+func writeStdout(path string, budget MemoryBudget) error {
+	if !budget.StreamOutput() {
+		pdfContent, err := os.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to read PDF from temp file: %w", err)
 		}
-		_, err = os.Stdout.Write(pdfContent)
-		if err != nil {
+		if _, err := os.Stdout.Write(pdfContent); err != nil {
 			return fmt.Errorf("failed to write PDF to stdout: %w", err)
 		}
-		// Clean up temp file
-		os.Remove(outputPath)
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF from temp file: %w", err)
+	}
+	defer f.Close()
+	// Hide the file's WriterTo and stdout's ReaderFrom, so the buffer is used
+	buf := make([]byte, budget.CopyBufferSize())
+	if _, err := io.CopyBuffer(struct{ io.Writer }{os.Stdout}, struct{ io.Reader }{f}, buf); err != nil {
+		return fmt.Errorf("failed to write PDF to stdout: %w", err)
 	}
+	return nil
+}
 
+// readerArgs returns the arguments selecting pandoc's markdown reader: its
+// default, or without the smart extension if noSmart is set.
+func readerArgs(noSmart bool) []string {
+	if noSmart {
+		return []string{"--from", "markdown-smart"}
+	}
 	return nil
 }
 
-// tempRandString generates a random string for temp file names.
-func tempRandString() string {
-	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, 8)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+// writeHeaderIncludes writes header snippets to a temporary file for --include-in-header.
+// The caller is responsible for removing the file.
+func writeHeaderIncludes(snippets []string) (string, error) {
+	name, err := writeTempFile("veve-header-*", snippets)
+	if err != nil {
+		return "", fmt.Errorf("failed to write header include file: %w", err)
 	}
-	return string(b)
+	return name, nil
 }
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// writeTempFile writes snippets, one per line, to a new temporary file named
+// after pattern and returns its path.
+func writeTempFile(pattern string, snippets []string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(strings.Join(snippets, "\n")); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
 }
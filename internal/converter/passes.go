@@ -0,0 +1,108 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"go.yaml.in/yaml/v3"
+)
+
+// passesVariables are the pandoc variables adding a table of contents or a
+// list of figures or tables.
+var passesVariables = []string{"toc", "lof", "lot"}
+
+// passesCommands matches the LaTeX commands whose page numbers or labels
+// come from an earlier run: lists, and references to labels.
+var passesCommands = regexp.MustCompile(`\\(?:tableofcontents|listoffigures|listoftables|pageref|ref|autoref|cref|Cref|nameref|vref)\b`)
+
+// NeedsLaTeXPasses reports whether content has a table of contents, a list
+// of figures or tables, or references to labels, which LaTeX only numbers
+// correctly once earlier runs have written them to its auxiliary files.
+// variables are the template variables it is converted with.
+func NeedsLaTeXPasses(content string, variables map[string]string) bool {
+	for _, name := range passesVariables {
+		if value, ok := variables[name]; ok && value != "false" && value != "" {
+			return true
+		}
+	}
+
+	var meta map[string]any
+	if end := frontMatterEnd(content); end > 0 {
+		_ = yaml.Unmarshal([]byte(content[4:end]), &meta)
+	}
+	for _, name := range passesVariables {
+		if value, ok := meta[name]; ok && value != false && value != nil {
+			return true
+		}
+	}
+	return passesCommands.MatchString(content)
+}
+
+// contentNeedsLaTeXPasses reports whether the input of opts needs several
+// LaTeX runs, reading it if its content wasn't given.
+func contentNeedsLaTeXPasses(opts UnicodeConversionOptions, variables map[string]string) bool {
+	content := opts.Content
+	if content == "" {
+		data, err := os.ReadFile(opts.InputFile)
+		if err != nil {
+			return false
+		}
+		content = string(data)
+	}
+	return NeedsLaTeXPasses(content, variables)
+}
+
+// latexmkEngineArgs are the latexmk options selecting each LaTeX engine.
+var latexmkEngineArgs = map[string]string{
+	"xelatex":  "-xelatex",
+	"lualatex": "-lualatex",
+	"pdflatex": "-pdf",
+}
+
+// findLatexmk returns the path of latexmk for engine, at enginePath if
+// set: beside the engine first, since a TeX distribution that isn't on
+// PATH has it in the same directory, or "" if there is none. latexmk
+// reruns the engine until its auxiliary files stop changing.
+func findLatexmk(engine, enginePath string) string {
+	if _, ok := latexmkEngineArgs[engine]; !ok {
+		return ""
+	}
+	if enginePath != "" {
+		if path, err := engines.SearchEngineInDirs("latexmk", []string{filepath.Dir(enginePath)}); err == nil {
+			return path
+		}
+	}
+	path, err := engines.LookupEngine("latexmk")
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// latexmkArgs returns the pandoc options running engine through latexmk,
+// at path, with engineArgs passed on to the engine.
+func latexmkArgs(path, engine string, engineArgs []string) []string {
+	args := []string{"--pdf-engine", path, "--pdf-engine-opt=" + latexmkEngineArgs[engine]}
+	for _, arg := range engineArgs {
+		args = append(args, "--pdf-engine-opt=-latexoption="+arg)
+	}
+	return args
+}
+
+// withPathDir returns env, or the current environment if nil, with dir
+// ahead of the directories in PATH.
+func withPathDir(env []string, dir string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+	path := dir
+	for _, entry := range env {
+		if name, value, ok := strings.Cut(entry, "="); ok && strings.EqualFold(name, "PATH") && value != "" {
+			path = dir + string(os.PathListSeparator) + value
+		}
+	}
+	return append(env, "PATH="+path)
+}
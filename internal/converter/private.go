@@ -0,0 +1,97 @@
+package converter
+
+import (
+	"strings"
+)
+
+// DefaultPrivateMarker marks the HTML comments StripPrivateComments removes.
+const DefaultPrivateMarker = "veve:private"
+
+// StripPrivateComments removes the HTML comments whose text starts with
+// marker, such as <!-- veve:private check these numbers with Sam -->, so
+// notes kept in a shared source file never reach the output. Comments may
+// span lines; those in fenced code blocks and code spans are left alone. A
+// line holding nothing but private comments is removed with them, so it
+// doesn't split a paragraph.
+func StripPrivateComments(content, marker string) string {
+	if marker == "" || !strings.Contains(content, marker) {
+		return content
+	}
+
+	var b strings.Builder
+	b.Grow(len(content))
+	var fence string
+	offset := 0
+	lineStart := true // offset is at the start of a line
+	for offset < len(content) {
+		line := content[offset:]
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i+1]
+		}
+
+		if lineStart {
+			if fence != "" {
+				if closesFence(line, fence) {
+					fence = ""
+				}
+				b.WriteString(line)
+				offset += len(line)
+				continue
+			}
+			if fence = openingFence(line); fence != "" {
+				b.WriteString(line)
+				offset += len(line)
+				continue
+			}
+		}
+
+		start := privateComment(line, marker)
+		if start < 0 {
+			b.WriteString(line)
+			offset += len(line)
+			lineStart = true
+			continue
+		}
+
+		// An unclosed comment runs to the end of the document
+		end := len(content)
+		if i := strings.Index(content[offset+start:], "-->"); i >= 0 {
+			end = offset + start + i + len("-->")
+		}
+		lineEnd := len(content)
+		if i := strings.IndexByte(content[end:], '\n'); i >= 0 {
+			lineEnd = end + i + 1
+		}
+
+		before, after := line[:start], content[end:lineEnd]
+		if lineStart && strings.TrimSpace(before) == "" && strings.TrimSpace(after) == "" {
+			offset, lineStart = lineEnd, true
+			continue
+		}
+		b.WriteString(before)
+		offset, lineStart = end, false
+	}
+	return b.String()
+}
+
+// privateComment returns the index in line of the first comment starting
+// with marker outside a code span, or -1.
+func privateComment(line, marker string) int {
+	from := 0
+	for {
+		i := strings.Index(line[from:], "<!--")
+		if i < 0 {
+			return -1
+		}
+		i += from
+		text := strings.TrimLeft(line[i+len("<!--"):], " \t")
+		inCodeSpan := strings.Count(line[:i], "`")%2 == 1
+		if !inCodeSpan && strings.HasPrefix(text, marker) {
+			rest := text[len(marker):]
+			if rest == "" || strings.ContainsRune(" \t\r\n-", rune(rest[0])) {
+				return i
+			}
+		}
+		from = i + len("<!--")
+	}
+}
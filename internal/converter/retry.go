@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/logging"
+)
+
+// latexRunFailure is how pandoc reports that the LaTeX engine failed.
+const latexRunFailure = "Error producing PDF"
+
+// RetryLaTeX converts once more when err is a failure of the LaTeX engine,
+// which races on auxiliary files or a font cache being rebuilt can cause
+// and which may not recur. convert is called with a new, empty directory
+// for pandoc's intermediate files. A retry that succeeds is reported to
+// warn, since the failure may come back; otherwise the retry's error is
+// returned. Missing packages and crashes are not retried.
+func RetryLaTeX(err error, engine string, convert func(tempDir string) error, warn func(category logging.Category, msg string)) error {
+	if err == nil || !engines.IsLaTeXEngine(engine) || !strings.Contains(err.Error(), latexRunFailure) {
+		return err
+	}
+	var missing *engines.MissingLaTeXPackagesError
+	var crash *CrashError
+	if errors.As(err, &missing) || errors.As(err, &crash) {
+		return err
+	}
+
+	tempDir, tempErr := os.MkdirTemp("", "veve-retry-*")
+	if tempErr != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	fmt.Fprintf(os.Stderr, "%s failed; retrying once with a clean temporary directory\n", engine)
+	if retryErr := convert(tempDir); retryErr != nil {
+		fmt.Fprintf(os.Stderr, "%s failed again on retry\n", engine)
+		return retryErr
+	}
+	if warn != nil {
+		warn(logging.CategoryEngine, fmt.Sprintf("%s failed and then succeeded on a retry; if this recurs, clear the TeX font cache (luaotfload-tool --update --force, or fc-cache -f)", engine))
+	}
+	return nil
+}
+
+// withTempDir returns env, or the current environment if nil, with the
+// temporary directory set to dir, for pandoc's intermediate files.
+func withTempDir(env []string, dir string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+	return append(env, "TMPDIR="+dir, "TMP="+dir, "TEMP="+dir)
+}
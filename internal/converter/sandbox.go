@@ -0,0 +1,130 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Sandbox modes for ConversionOptions.Sandbox.
+const (
+	SandboxOff        = ""
+	SandboxRestricted = "restricted" // pandoc --sandbox, an empty working directory, and a minimal environment
+	SandboxFirejail   = "firejail"   // restricted, inside firejail with seccomp and no network (Linux)
+)
+
+// ValidateSandbox checks a sandbox mode.
+func ValidateSandbox(mode string) error {
+	switch mode {
+	case SandboxOff, SandboxRestricted, SandboxFirejail:
+		return nil
+	}
+	return fmt.Errorf("invalid sandbox mode %q: use %q or %q", mode, SandboxRestricted, SandboxFirejail)
+}
+
+// sandboxEnvVars are the environment variables pandoc and the PDF engines
+// need. Everything else, such as credentials and tokens, is dropped.
+var sandboxEnvVars = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "LANG", "LANGUAGE", "TZ",
+	"TMPDIR", "TMP", "TEMP", "SOURCE_DATE_EPOCH", "FONTCONFIG_FILE", "FONTCONFIG_PATH",
+	// Windows
+	"SystemRoot", "SystemDrive", "windir", "USERPROFILE", "APPDATA", "LOCALAPPDATA",
+	"ProgramFiles", "ProgramFiles(x86)", "ProgramW6432", "ProgramData", "PATHEXT", "COMSPEC",
+}
+
+// sandboxEnvPrefixes are prefixes of further variables the engines need
+// (locale, TeX search paths).
+var sandboxEnvPrefixes = []string{"LC_", "TEXMF", "TEXINPUTS", "MIKTEX_"}
+
+// sandboxEnv returns the entries of environ kept in the sandbox.
+func sandboxEnv(environ []string) []string {
+	var env []string
+	for _, entry := range environ {
+		name, _, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		keep := false
+		for _, allowed := range sandboxEnvVars {
+			// Windows variable names are case-insensitive
+			if name == allowed || (runtime.GOOS == "windows" && strings.EqualFold(name, allowed)) {
+				keep = true
+				break
+			}
+		}
+		for _, prefix := range sandboxEnvPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				keep = true
+				break
+			}
+		}
+		if keep {
+			env = append(env, entry)
+		}
+	}
+	return env
+}
+
+// firejailArgs are the firejail options for running pandoc: no profile (so
+// the workspace and output directories stay reachable), no network, no
+// capabilities or privilege escalation, and the default seccomp filter.
+var firejailArgs = []string{"--quiet", "--noprofile", "--net=none", "--caps.drop=all", "--nonewprivs", "--noroot", "--seccomp"}
+
+// sandboxPaths makes the paths in opts absolute, since a sandboxed pandoc runs
+// in its own working directory. Relative resources keep resolving against the
+// current directory.
+func sandboxPaths(opts *ConversionOptions, outputPath string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	abs := func(path string) string {
+		if path == "" || path == "-" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(cwd, path)
+	}
+
+	opts.InputFile = abs(opts.InputFile)
+	opts.Theme = abs(opts.Theme)
+	opts.Template = abs(opts.Template)
+	if len(opts.ResourcePath) == 0 {
+		// pandoc's default resource path is the working directory
+		opts.ResourcePath = []string{cwd}
+	} else {
+		resourcePath := make([]string, len(opts.ResourcePath))
+		for i, dir := range opts.ResourcePath {
+			resourcePath[i] = abs(dir)
+		}
+		opts.ResourcePath = resourcePath
+	}
+	return abs(outputPath), nil
+}
+
+// applySandbox runs cmd, a local pandoc command, in the sandbox mode: in an
+// empty working directory, with a minimal environment and, for firejail,
+// inside firejail. The returned function removes the working directory.
+func applySandbox(cmd *exec.Cmd, mode string) (func(), error) {
+	if mode == SandboxFirejail {
+		if runtime.GOOS != "linux" {
+			return nil, fmt.Errorf("the firejail sandbox is only available on Linux; use --sandbox=%s", SandboxRestricted)
+		}
+		firejail, err := exec.LookPath("firejail")
+		if err != nil {
+			return nil, fmt.Errorf("firejail not found in PATH (install it, or use --sandbox=%s): %w", SandboxRestricted, err)
+		}
+		cmd.Args = append(append(append([]string{firejail}, firejailArgs...), "--"), cmd.Args...)
+		cmd.Path = firejail
+	}
+
+	dir, err := os.MkdirTemp("", "veve-sandbox-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	cmd.Dir = dir
+	cmd.Env = sandboxEnv(os.Environ())
+	return func() { os.RemoveAll(dir) }, nil
+}
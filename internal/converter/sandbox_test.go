@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidateSandbox(t *testing.T) {
+	for _, mode := range []string{SandboxOff, SandboxRestricted, SandboxFirejail} {
+		if err := ValidateSandbox(mode); err != nil {
+			t.Errorf("ValidateSandbox(%q) = %v", mode, err)
+		}
+	}
+	if err := ValidateSandbox("chroot"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+// TestSandboxEnv verifies credentials are dropped and what the engines need is kept.
+func TestSandboxEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/user",
+		"LC_ALL=C.UTF-8",
+		"TEXMFHOME=/home/user/texmf",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"GITHUB_TOKEN=token",
+		"SOURCE_DATE_EPOCH=0",
+	}
+	got := sandboxEnv(environ)
+	want := []string{"PATH=/usr/bin", "HOME=/home/user", "LC_ALL=C.UTF-8", "TEXMFHOME=/home/user/texmf", "SOURCE_DATE_EPOCH=0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sandboxEnv() = %v, want %v", got, want)
+	}
+}
+
+// TestSandboxPaths verifies relative paths are made absolute and the working
+// directory stays on the resource path.
+func TestSandboxPaths(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ConversionOptions{InputFile: "doc.md", Theme: filepath.Join(cwd, "theme.css")}
+	output, err := sandboxPaths(&opts, filepath.Join("out", "doc.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != filepath.Join(cwd, "out", "doc.pdf") {
+		t.Errorf("unexpected output path %s", output)
+	}
+	if opts.InputFile != filepath.Join(cwd, "doc.md") || opts.Theme != filepath.Join(cwd, "theme.css") {
+		t.Errorf("unexpected paths %+v", opts)
+	}
+	if !reflect.DeepEqual(opts.ResourcePath, []string{cwd}) {
+		t.Errorf("unexpected resource path %v", opts.ResourcePath)
+	}
+
+	opts = ConversionOptions{InputFile: "-", ResourcePath: []string{".", "images"}}
+	if _, err := sandboxPaths(&opts, "-"); err != nil {
+		t.Fatal(err)
+	}
+	if opts.InputFile != "-" || !reflect.DeepEqual(opts.ResourcePath, []string{cwd, filepath.Join(cwd, "images")}) {
+		t.Errorf("unexpected paths %+v", opts)
+	}
+}
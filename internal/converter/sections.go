@@ -0,0 +1,156 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SectionSize is the size SplitSections aims for. Each preparation stage
+// parses its input into a syntax tree many times its size, so a document
+// larger than this is prepared a section at a time.
+const SectionSize = 1 << 20
+
+// sectionReferences introduces the link reference definitions SplitSections
+// copies into a section, which JoinSections removes.
+const sectionReferences = "\n\n<!-- veve:references -->\n"
+
+// linkReferenceDefinition matches a single-line link reference definition,
+// e.g. [logo]: images/logo.png "Logo". Footnotes ([^1]: ...) don't match.
+var linkReferenceDefinition = regexp.MustCompile(`^ {0,3}\[[^\]^][^\]]*\]:[ \t]*\S`)
+
+// SplitSections splits markdown content into sections of at least size
+// bytes, each starting at a top-level ATX heading, which JoinSections joins
+// back to content. Headings in front matter, fenced code blocks, and HTML
+// comments don't start sections. Content no larger than size, or without
+// headings to split at, is returned as one section.
+//
+// The document's link reference definitions apply anywhere in it, so each
+// section ends with a copy of those of the other sections: ![chart][q3]
+// parses as an image in its section wherever [q3]: is defined. Only
+// single-line definitions are copied.
+//
+// Preparation stages that only change top-level blocks (figures, page
+// breaks, tables, task lists, inline images) give the same result on each
+// section as on the whole document.
+func SplitSections(content string, size int) []string {
+	if len(content) <= size {
+		return []string{content}
+	}
+
+	var sections []string
+	var definitions [][]string // Of each section
+	var sectionDefinitions []string
+	start, offset := 0, 0
+	var fence string
+	inComment := false
+	inParagraph := false // A definition can't interrupt a paragraph
+
+	// Skip YAML front matter
+	if strings.HasPrefix(content, "---\n") {
+		if end := strings.Index(content[4:], "\n---\n"); end >= 0 {
+			offset = 4 + end + len("\n---\n")
+		}
+	}
+
+	for offset < len(content) {
+		line := content[offset:]
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i+1]
+		}
+
+		blank := strings.TrimSpace(line) == ""
+		switch {
+		case inComment:
+			inComment = !strings.Contains(line, "-->")
+		case fence != "":
+			if closesFence(line, fence) {
+				fence = ""
+			}
+		default:
+			if f := openingFence(line); f != "" {
+				fence = f
+			} else if strings.HasPrefix(line, "<!--") {
+				inComment = !strings.Contains(line[4:], "-->")
+			} else if offset-start >= size && isATXHeading(line) {
+				sections = append(sections, content[start:offset])
+				definitions = append(definitions, sectionDefinitions)
+				sectionDefinitions = nil
+				start = offset
+			} else if !inParagraph && linkReferenceDefinition.MatchString(line) {
+				sectionDefinitions = append(sectionDefinitions, strings.TrimRight(line, "\r\n"))
+				offset += len(line)
+				continue
+			}
+		}
+		inParagraph = !blank && fence == "" && !inComment && !isATXHeading(line)
+		offset += len(line)
+	}
+	sections = append(sections, content[start:])
+	definitions = append(definitions, sectionDefinitions)
+
+	for i := range sections {
+		var others []string
+		for j, defs := range definitions {
+			if j != i {
+				others = append(others, defs...)
+			}
+		}
+		if len(others) > 0 {
+			sections[i] += sectionReferences + strings.Join(others, "\n") + "\n"
+		}
+	}
+	return sections
+}
+
+// JoinSections joins sections from SplitSections, after preparation, back
+// into one document, without the link reference definitions copied into them.
+func JoinSections(sections []string) string {
+	var sb strings.Builder
+	for _, section := range sections {
+		if i := strings.LastIndex(section, sectionReferences); i >= 0 {
+			section = section[:i]
+		}
+		sb.WriteString(section)
+	}
+	return sb.String()
+}
+
+// openingFence returns the fence (``` or ~~~, possibly longer) a line opens
+// a fenced code block with, or "".
+func openingFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+	char := trimmed[0]
+	if char != '`' && char != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == char {
+		n++
+	}
+	if n < 3 || char == '`' && strings.Contains(trimmed[n:], "`") {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// closesFence reports whether line closes a code block opened with fence.
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || !strings.HasPrefix(trimmed, fence) {
+		return false
+	}
+	rest := strings.TrimLeft(trimmed, fence[:1])
+	return strings.TrimSpace(rest) == ""
+}
+
+// isATXHeading reports whether line is an unindented ATX heading (# to ######).
+func isATXHeading(line string) bool {
+	n := 0
+	for n < len(line) && line[n] == '#' {
+		n++
+	}
+	return n >= 1 && n <= 6 && (n == len(line) || line[n] == ' ' || line[n] == '\t' || line[n] == '\n' || line[n] == '\r')
+}
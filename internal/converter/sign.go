@@ -0,0 +1,80 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+)
+
+// Signing tools for --sign.
+const (
+	SignerOff      = ""
+	SignerGPG      = "gpg"
+	SignerMinisign = "minisign"
+)
+
+// ValidateSigner checks a signing tool.
+func ValidateSigner(tool string) error {
+	switch tool {
+	case SignerOff, SignerGPG, SignerMinisign:
+		return nil
+	}
+	return fmt.Errorf("invalid signing tool %q: use %q or %q", tool, SignerGPG, SignerMinisign)
+}
+
+// SignatureFile returns the path of the detached signature tool writes for
+// the file at path: path.asc for gpg, which armors it, or path.minisig.
+func SignatureFile(path, tool string) string {
+	if tool == SignerMinisign {
+		return path + ".minisig"
+	}
+	return path + ".asc"
+}
+
+// Sign writes a detached signature of the file at path beside it with tool,
+// and returns the signature's path. key selects the signing key, gpg's
+// --local-user or minisign's secret key file; empty uses the tool's default.
+// The tool may ask for the key's passphrase on the terminal.
+func Sign(path, tool, key string) (string, error) {
+	if err := ValidateSigner(tool); err != nil || tool == SignerOff {
+		return "", err
+	}
+	toolPath, err := exec.LookPath(tool)
+	if err != nil {
+		return "", internal.SignerNotFound(tool)
+	}
+
+	signature := SignatureFile(path, tool)
+	var args []string
+	switch tool {
+	case SignerGPG:
+		args = []string{"--batch", "--yes", "--armor", "--detach-sign", "--output", signature}
+		if key != "" {
+			args = append(args, "--local-user", key)
+		}
+		args = append(args, path)
+	case SignerMinisign:
+		args = []string{"-S", "-m", path, "-x", signature}
+		if key != "" {
+			args = append(args, "-s", key)
+		}
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(toolPath, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(signature)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.New(msg)
+		}
+		return "", internal.SigningFailed(tool, path, err)
+	}
+	return signature, nil
+}
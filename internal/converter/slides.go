@@ -0,0 +1,286 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/theme"
+)
+
+// SlideEngines are the engines that can render slides, in order of preference.
+var SlideEngines = []string{"xelatex", "lualatex", "pdflatex"}
+
+// SlidesOptions holds options for markdown-to-slides conversion.
+type SlidesOptions struct {
+	InputFile     string            // Path to markdown file
+	OutputFile    string            // Path to output PDF (or "-" for stdout)
+	PDFEngine     string            // LaTeX engine (one of SlideEngines)
+	PDFEnginePath string            // Absolute path to the engine binary (optional)
+	ThemeCSS      string            // CSS of the veve theme, whose colors and fonts style the slides (optional)
+	BeamerTheme   string            // Beamer theme, e.g. metropolis, unless the document sets one (optional)
+	AspectRatio   string            // Beamer aspect ratio, e.g. 169 or 43, unless the document sets one (default: 169)
+	ResourcePath  []string          // Directories pandoc searches for images (optional)
+	Variables     map[string]string // Template variables passed via -V key=value (optional)
+	Limits        Limits            // Caps the CPU time and memory of pandoc and the engine (optional)
+	MemoryBudget  MemoryBudget      // Memory veve itself aims to stay within (optional)
+	NoSmart       bool              // Keep straight quotes, double hyphens, and three dots as typed
+	TempDir       string            // Directory for the intermediate files of pandoc and the engine (optional)
+
+	// Handout, if set, prints this many slides per page (1 to MaxHandoutSlides),
+	// each next to a ruled area for notes, with overlays collapsed
+	Handout int
+}
+
+// MaxHandoutSlides is the most slides a handout page holds.
+const MaxHandoutSlides = 4
+
+// ConvertSlides converts a markdown file to a PDF slide deck with pandoc's
+// beamer writer. Level-1 headings become section title slides and level-2
+// headings start slides (pandoc picks the slide level from the document's
+// structure), and a horizontal rule starts a slide without a title.
+func (pc *PandocConverter) ConvertSlides(opts SlidesOptions) error {
+	if err := ValidateInputFile(opts.InputFile); err != nil {
+		return fmt.Errorf("input validation failed: %w", err)
+	}
+	if !engines.IsLaTeXEngine(opts.PDFEngine) {
+		return fmt.Errorf("slides need a LaTeX engine (%s), not %s", strings.Join(SlideEngines, ", "), opts.PDFEngine)
+	}
+	if opts.Handout < 0 || opts.Handout > MaxHandoutSlides {
+		return fmt.Errorf("handouts hold 1 to %d slides per page, not %d", MaxHandoutSlides, opts.Handout)
+	}
+
+	outputPath := opts.OutputFile
+	if outputPath == "-" {
+		stdoutDir, err := os.MkdirTemp("", "veve-stdout-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory for stdout output: %w", err)
+		}
+		defer os.RemoveAll(stdoutDir)
+		outputPath = filepath.Join(stdoutDir, "slides.pdf")
+	} else if err := EnsureOutputDirectory(outputPath); err != nil {
+		return err
+	}
+
+	pdfEngine := opts.PDFEngine
+	if opts.PDFEnginePath != "" {
+		pdfEngine = opts.PDFEnginePath
+	}
+	args := []string{opts.InputFile, "-o", outputPath, "--to", "beamer", "--pdf-engine", pdfEngine}
+	args = append(args, readerArgs(opts.NoSmart)...)
+
+	// The aspect ratio and beamer theme are defaults in a metadata file,
+	// which the document's own front matter overrides (-V would not)
+	aspectRatio := opts.AspectRatio
+	if aspectRatio == "" {
+		aspectRatio = "169"
+	}
+	defaults := []string{"aspectratio: " + yamlQuote(aspectRatio)}
+	if opts.BeamerTheme != "" {
+		defaults = append(defaults, "theme: "+yamlQuote(opts.BeamerTheme))
+	}
+	metadataFile, err := writeTempFile("veve-metadata-*.yaml", defaults)
+	if err != nil {
+		return fmt.Errorf("failed to write slide metadata file: %w", err)
+	}
+	defer os.Remove(metadataFile)
+	args = append(args, "--metadata-file", metadataFile)
+	if len(opts.ResourcePath) > 0 {
+		args = append(args, "--resource-path", strings.Join(opts.ResourcePath, string(os.PathListSeparator)))
+	}
+
+	// Only fontspec engines can use system fonts by family name
+	var fontAvailable func(string) bool
+	if opts.PDFEngine == "xelatex" || opts.PDFEngine == "lualatex" {
+		fontAvailable = SystemFontAvailable
+	}
+	settings := TranslateCSSToBeamer(opts.ThemeCSS, fontAvailable)
+	variables := make(map[string]string, len(settings.Variables)+len(opts.Variables))
+	for name, value := range settings.Variables {
+		variables[name] = value
+	}
+	for name, value := range opts.Variables {
+		variables[name] = value
+	}
+	if opts.Handout > 0 {
+		variables["classoption"] = "handout"
+		settings.HeaderIncludes = append(settings.HeaderIncludes, HandoutLayout(opts.Handout, ThemePaper(opts.ThemeCSS)))
+	}
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		args = append(args, "-V", name+"="+variables[name])
+	}
+	if len(settings.HeaderIncludes) > 0 {
+		headerFile, err := writeHeaderIncludes(settings.HeaderIncludes)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(headerFile)
+		args = append(args, "--include-in-header", headerFile)
+	}
+
+	cmd := exec.Command(pc.PandocPath, args...)
+	if opts.TempDir != "" {
+		cmd.Env = withTempDir(nil, opts.TempDir)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := runLimited(cmd, opts.Limits); err != nil {
+		if crash := newCrashError("pandoc", err, stderr.String(), "", opts.Limits); crash != nil {
+			return crash
+		}
+		if !opts.Limits.IsZero() {
+			err = fmt.Errorf("%w (limits: %s; raise --cpu-limit or --memory-limit if the document needs more)", err, opts.Limits)
+		}
+		if stderr.Len() > 0 {
+			return fmt.Errorf("pandoc conversion failed: %w\nPandoc stderr: %s", err, stderr.String())
+		}
+		return fmt.Errorf("pandoc conversion failed: %w", err)
+	}
+
+	if opts.OutputFile == "-" {
+		return writeStdout(outputPath, opts.MemoryBudget)
+	}
+	return nil
+}
+
+// TranslateCSSToBeamer translates the parts of a veve theme that carry over
+// to slides: the body's text and background colors, the heading color (for
+// frame titles and other structure), the link color, and the body and code
+// fonts. fontAvailable works as for TranslateCSSToLaTeX.
+func TranslateCSSToBeamer(css string, fontAvailable func(family string) bool) LaTeXThemeSettings {
+	settings := LaTeXThemeSettings{Variables: map[string]string{}}
+	t := &latexTranslator{settings: &settings, fontAvailable: fontAvailable}
+
+	colors := map[string]string{}
+	sheet, _ := theme.ParseCSS(theme.BlankFrontMatter(css))
+	for _, rule := range sheet.Rules {
+		if rule.AtRule != "" {
+			continue
+		}
+		for _, selector := range rule.Selectors {
+			for _, decl := range rule.Declarations {
+				var name string
+				switch {
+				case (selector == "body" || selector == "html") && decl.Property == "color":
+					name = "vevetext"
+				case (selector == "body" || selector == "html") && (decl.Property == "background-color" || decl.Property == "background"):
+					name = "vevebackground"
+				case (selector == "h1" || selector == "h2") && decl.Property == "color":
+					// Frame titles are level-2 headings; prefer their color
+					if selector == "h1" && colors["veveheading"] != "" {
+						continue
+					}
+					name = "veveheading"
+				case selector == "a" && decl.Property == "color":
+					name = "vevelink"
+				case (selector == "body" || selector == "html") && decl.Property == "font-family" && fontAvailable != nil:
+					if family := t.firstAvailableFont(decl.Value); family != "" {
+						settings.Variables["mainfont"] = family
+						settings.Variables["sansfont"] = family
+					}
+				case (selector == "code" || selector == "pre") && decl.Property == "font-family" && fontAvailable != nil:
+					if family := t.firstAvailableFont(decl.Value); family != "" {
+						settings.Variables["monofont"] = family
+					}
+				}
+				if name == "" {
+					continue
+				}
+				if hex, ok := parseCSSColor(decl.Value); ok {
+					colors[name] = hex
+				}
+			}
+		}
+	}
+	if len(colors) == 0 {
+		return settings
+	}
+
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(colors)) {
+		lines = append(lines, fmt.Sprintf(`\definecolor{%s}{HTML}{%s}`, name, colors[name]))
+	}
+	if colors["vevetext"] != "" {
+		lines = append(lines, `\setbeamercolor{normal text}{fg=vevetext}`)
+	}
+	if colors["vevebackground"] != "" {
+		lines = append(lines, `\setbeamercolor{background canvas}{bg=vevebackground}`)
+	}
+	if colors["veveheading"] != "" {
+		lines = append(lines,
+			`\setbeamercolor{structure}{fg=veveheading}`,
+			`\setbeamercolor{frametitle}{fg=veveheading}`,
+			`\setbeamercolor{title}{fg=veveheading}`)
+	}
+	if colors["vevelink"] != "" {
+		lines = append(lines, `\hypersetup{colorlinks=true,linkcolor=vevelink,urlcolor=vevelink}`)
+	}
+	settings.HeaderIncludes = append(settings.HeaderIncludes, strings.Join(lines, "\n"))
+	return settings
+}
+
+// yamlQuote quotes s as a YAML string (a JSON string is valid YAML).
+func yamlQuote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// ThemePaper returns the paper handouts and imposed pages are printed on:
+// the theme's @page size if it is letter or legal, and A4 otherwise (so
+// A5 pages print two to an A4 sheet).
+func ThemePaper(css string) string {
+	sheet, _ := theme.ParseCSS(theme.BlankFrontMatter(css))
+	paper := "a4"
+	for _, rule := range sheet.Rules {
+		if rule.AtRule != "page" {
+			continue
+		}
+		for _, decl := range rule.Declarations {
+			if size := strings.ToLower(decl.Value); decl.Property == "size" && (size == "letter" || size == "legal") {
+				paper = size
+			}
+		}
+	}
+	return paper
+}
+
+// HandoutLayout returns the preamble that prints perPage slides per
+// portrait page of paper (a4, letter, or legal) with pgfpages: the slides
+// down the left half, each with a frame and ruled lines for notes beside it.
+func HandoutLayout(perPage int, paper string) string {
+	const margin = 0.06 // Of the page's width and height
+	row := (1 - 2*margin) / float64(perPage)
+
+	// The border code draws in the slide's own coordinates, so the note
+	// lines are placed relative to the slide's size, saved before shipout
+	var notes strings.Builder
+	notes.WriteString(`\pgfsetlinewidth{0.4pt}\pgfusepath{stroke}\pgfsetstrokecolor{gray}`)
+	const lines = 8
+	for i := 1; i <= lines; i++ {
+		y := fmt.Sprintf(`%.3f\veveslideheight`, float64(i)/(lines+1))
+		fmt.Fprintf(&notes, `\pgfpathmoveto{\pgfpoint{1.06\veveslidewidth}{%s}}\pgfpathlineto{\pgfpoint{1.96\veveslidewidth}{%s}}`, y, y)
+	}
+	notes.WriteString(`\pgfusepath{stroke}`)
+
+	var b strings.Builder
+	b.WriteString("\\usepackage{pgfpages}\n")
+	b.WriteString("\\newdimen\\veveslidewidth \\veveslidewidth=\\paperwidth\n")
+	b.WriteString("\\newdimen\\veveslideheight \\veveslideheight=\\paperheight\n")
+	b.WriteString("\\newcommand{\\vevenotes}{" + notes.String() + "}\n")
+	b.WriteString("\\pgfpagesdeclarelayout{veve handout}{\\edef\\pgfpageoptionheight{\\the\\paperheight}\\edef\\pgfpageoptionwidth{\\the\\paperwidth}}{%\n")
+	fmt.Fprintf(&b, "  \\pgfpagesphysicalpageoptions{logical pages=%d, physical height=\\pgfpageoptionheight, physical width=\\pgfpageoptionwidth}%%\n", perPage)
+	for i := 1; i <= perPage; i++ {
+		fmt.Fprintf(&b, "  \\pgfpageslogicalpageoptions{%d}{resized width=.46\\pgfphysicalwidth, resized height=%.3f\\pgfphysicalheight, center=\\pgfpoint{%.3f\\pgfphysicalwidth}{%.3f\\pgfphysicalheight}, border code=\\vevenotes}%%\n",
+			i, 0.9*row, margin+0.23, 1-margin-(float64(i)-0.5)*row)
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "\\pgfpagesuselayout{veve handout}[%spaper]", paper)
+	return b.String()
+}
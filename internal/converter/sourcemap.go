@@ -0,0 +1,169 @@
+package converter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+)
+
+// SourceError is a conversion error located in the markdown source, rather
+// than in the prepared copy pandoc was given.
+type SourceError struct {
+	File    string // Markdown source, as the user named it
+	Line    int    // Line in File, from 1
+	Message string // The error, e.g. "Undefined control sequence."
+	Err     error  // The conversion error
+
+	prepared string // Prepared copy named in Err
+}
+
+// Error returns "file:line: message", followed by the conversion error with
+// the prepared copy's path replaced by the source's.
+func (e *SourceError) Error() string {
+	detail := e.Err.Error()
+	if e.prepared != "" {
+		detail = strings.ReplaceAll(detail, e.prepared, e.File)
+	}
+	return fmt.Sprintf("%s:%d: %s\n%s", e.File, e.Line, e.Message, detail)
+}
+
+// Unwrap returns the conversion error.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKind classifies the error as a conversion error.
+func (e *SourceError) ErrorKind() internal.Kind {
+	return internal.KindPandoc
+}
+
+// latexErrorPattern and latexLinePattern match a TeX error and the line
+// it stopped at: "! Undefined control sequence." and "l.42 \foo".
+var (
+	latexErrorPattern = regexp.MustCompile(`(?m)^! (.+)$`)
+	latexLinePattern  = regexp.MustCompile(`(?m)^l\.\d+ (.*)$`)
+)
+
+// LocateError locates a conversion error in the markdown source: pandoc's
+// errors at a line of preparedFile, the prepared copy of file, and LaTeX
+// errors by the text of the line they stopped at. source is file as read
+// and prepared the content of preparedFile; lines are mapped between the
+// two by matching their text, so lines veve rewrote (images, figures,
+// tables) map to the source lines around them. err is returned unchanged
+// if it can't be located.
+func LocateError(err error, file, preparedFile, source, prepared string) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	lines := newLineMap(source, prepared)
+
+	// Pandoc: Error parsing YAML metadata at "processed-1.md" (line 3, column 1):
+	position := regexp.MustCompile(`(?m)^(.*?)(?: at)? "[^"]*` + regexp.QuoteMeta(filepath.Base(preparedFile)) +
+		`" \(line (\d+), column \d+\):?\s*$`)
+	if m := position.FindStringSubmatch(message); m != nil {
+		if line, convErr := strconv.Atoi(m[2]); convErr == nil {
+			return &SourceError{File: file, Line: lines.source(line), Message: strings.TrimSpace(strings.TrimPrefix(m[1], "Pandoc stderr: ")), Err: err, prepared: preparedFile}
+		}
+	}
+
+	// LaTeX: the line of the .tex file pandoc wrote is no use; find its text
+	if m := latexLinePattern.FindStringSubmatch(message); m != nil {
+		if line := lines.find(m[1]); line > 0 {
+			text := "LaTeX error"
+			if e := latexErrorPattern.FindStringSubmatch(message); e != nil {
+				text = strings.TrimSpace(e[1])
+			}
+			return &SourceError{File: file, Line: lines.source(line), Message: text, Err: err, prepared: preparedFile}
+		}
+	}
+	return err
+}
+
+// lineMapWindow is how many source lines ahead newLineMap looks for a
+// prepared line; text veve adds or rewrites is rarely longer.
+const lineMapWindow = 200
+
+// lineMap maps the lines of prepared content to the source lines they came
+// from.
+type lineMap struct {
+	prepared []string
+	lines    []int // lines[i] is the source line of prepared line i+1, or 0 where unknown
+	count    int   // Source lines
+}
+
+// newLineMap matches each non-blank prepared line to the next identical
+// source line within lineMapWindow, in order.
+func newLineMap(source, prepared string) *lineMap {
+	sourceLines := strings.Split(source, "\n")
+	m := &lineMap{prepared: strings.Split(prepared, "\n"), count: len(sourceLines)}
+	m.lines = make([]int, len(m.prepared))
+	next := 0
+	for i, line := range m.prepared {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		for j := next; j < len(sourceLines) && j < next+lineMapWindow; j++ {
+			if sourceLines[j] == line {
+				m.lines[i] = j + 1
+				next = j + 1
+				break
+			}
+		}
+	}
+	return m
+}
+
+// source returns the source line of prepared line n (from 1): its match,
+// or else counted back from the nearest matched line after it, since veve
+// adds lines before those it rewrites (a figure's caption, a table's
+// wrapper), kept after the nearest matched line before it.
+func (m *lineMap) source(n int) int {
+	if n < 1 || n > len(m.lines) {
+		return max(1, min(n, m.count))
+	}
+	if m.lines[n-1] > 0 {
+		return m.lines[n-1]
+	}
+
+	prevPrepared, prevSource := 0, 0
+	for i := n - 2; i >= 0; i-- {
+		if m.lines[i] > 0 {
+			prevPrepared, prevSource = i+1, m.lines[i]
+			break
+		}
+	}
+	for i := n; i < len(m.lines); i++ {
+		if m.lines[i] > 0 {
+			line := m.lines[i] - (i + 1 - n)
+			return max(1, prevSource+1, min(line, m.lines[i]-1))
+		}
+	}
+	return max(1, min(prevSource+n-prevPrepared, m.count))
+}
+
+// find returns the first prepared line (from 1) containing text, or 0. TeX
+// shows the line up to where it stopped, perhaps shortened with "...", so
+// failing the whole text, the last word of it is looked for.
+func (m *lineMap) find(text string) int {
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "..."))
+	candidates := []string{text}
+	if fields := strings.Fields(text); len(fields) > 1 {
+		candidates = append(candidates, fields[len(fields)-1])
+	}
+	for _, candidate := range candidates {
+		if len(candidate) < 2 {
+			continue
+		}
+		for i, line := range m.prepared {
+			if strings.Contains(line, candidate) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
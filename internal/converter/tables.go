@@ -0,0 +1,189 @@
+package converter
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	// Tables with at least wideTableColumns columns, or rows longer than
+	// wideTableWidth characters, are set in a smaller font.
+	wideTableColumns = 6
+	wideTableWidth   = 100
+
+	// Tables with at least landscapeTableColumns columns, or rows longer than
+	// landscapeTableWidth characters, are also placed on landscape pages.
+	landscapeTableColumns = 9
+	landscapeTableWidth   = 160
+)
+
+// tableParser parses markdown with pipe tables.
+var tableParser = goldmark.New(goldmark.WithExtensions(extension.Table)).Parser()
+
+// tableCSS keeps tables inside the page for HTML-based engines (weasyprint,
+// prince): long cell content wraps, and wide tables get a smaller font or a
+// landscape page.
+const tableCSS = `<style>
+table { max-width: 100%; }
+th, td { overflow-wrap: anywhere; }
+.wide-table table { width: 100%; table-layout: fixed; font-size: 85%; }
+.wide-table.landscape { page: landscape; }
+@page landscape { size: landscape; }
+</style>
+`
+
+// tableLaTeX defines the landscape environment used for the widest tables.
+// pdflscape is not part of every TeX installation; without it the tables stay
+// in portrait with a smaller font.
+const tableLaTeX = `\IfFileExists{pdflscape.sty}{\usepackage{pdflscape}` +
+	`\newenvironment{vevelandscape}{\begin{landscape}}{\end{landscape}}}` +
+	`{\newenvironment{vevelandscape}{}{}}`
+
+// tableHeader returns the header snippet supporting tables prepared by
+// PrepareTables.
+func tableHeader(latex bool) string {
+	if latex {
+		return tableLaTeX
+	}
+	return tableCSS
+}
+
+// PrepareTables marks wide pipe tables so they fit on the page. Pandoc already
+// breaks long tables across pages (longtable in LaTeX); wide tables are
+// wrapped in a .wide-table div with raw LaTeX that sets them in a smaller
+// font, and the widest also get the .landscape class and a landscape page.
+// A caption paragraph ("Table: ..." or ": ...") next to the table moves with it.
+//
+// Only top-level tables separated from surrounding text by blank lines are
+// changed. It reports whether content has any pipe tables, in which case the
+// conversion needs the header from tableHeader.
+func PrepareTables(content string) (string, bool) {
+	source := []byte(content)
+	doc := tableParser.Parse(text.NewReader(source))
+
+	found := false
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n.Kind() == extast.KindTable {
+			found = true
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	if !found {
+		return content, false
+	}
+
+	var sb strings.Builder
+	last := 0
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		table, ok := n.(*extast.Table)
+		if !ok {
+			continue
+		}
+
+		start, end, ok := tableSpan(table, source)
+		if !ok || !startsBlock(content, start) {
+			continue
+		}
+
+		width := 0
+		for _, line := range strings.Split(content[start:end], "\n") {
+			width = max(width, len(strings.TrimSpace(line)))
+		}
+		columns := len(table.Alignments)
+		if columns < wideTableColumns && width <= wideTableWidth {
+			continue
+		}
+		landscape := columns >= landscapeTableColumns || width > landscapeTableWidth
+
+		if prev, ok := n.PreviousSibling().(*ast.Paragraph); ok && isTableCaption(prev, source) && prev.Lines().At(0).Start >= last {
+			start = prev.Lines().At(0).Start
+		}
+		if next, ok := n.NextSibling().(*ast.Paragraph); ok && isTableCaption(next, source) {
+			end = blockEnd(next, source)
+			n = next
+		}
+
+		sb.WriteString(content[last:start])
+		sb.WriteString(wideTableMarkdown(content[start:end], landscape))
+		last = end
+	}
+	sb.WriteString(content[last:])
+
+	return sb.String(), true
+}
+
+// wideTableMarkdown wraps a table and its caption for a smaller font and,
+// optionally, a landscape page.
+func wideTableMarkdown(table string, landscape bool) string {
+	begin, end, class := `\begingroup`, `\endgroup`, ".wide-table"
+	if landscape {
+		begin, end, class = `\begin{vevelandscape}`, `\end{vevelandscape}`, ".wide-table .landscape"
+	}
+	return "```{=latex}\n" + begin + `\footnotesize\setlength{\tabcolsep}{3pt}` + "\n```\n\n" +
+		"::: {" + class + "}\n" + table + "\n:::\n\n" +
+		"```{=latex}\n" + end + "\n```"
+}
+
+// tableSpan returns the location of a table in source, from the start of its
+// header line to the end of its last row, excluding the trailing newline.
+func tableSpan(table *extast.Table, source []byte) (int, int, bool) {
+	start, end := -1, -1
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			lines := cell.Lines()
+			if lines.Len() == 0 {
+				continue
+			}
+			if start < 0 {
+				start = lines.At(0).Start
+			}
+			end = max(end, lines.At(lines.Len()-1).Stop)
+		}
+	}
+	if start < 0 {
+		return 0, 0, false
+	}
+
+	// Widen to whole lines, so leading and trailing pipes are included
+	for start > 0 && source[start-1] != '\n' {
+		start--
+	}
+	for end < len(source) && source[end] != '\n' {
+		end++
+	}
+	if end > start && source[end-1] == '\r' {
+		end--
+	}
+	return start, end, true
+}
+
+// startsBlock reports whether offset is at the start of the content or
+// follows a blank line.
+func startsBlock(content string, offset int) bool {
+	before := strings.TrimRight(content[:offset], " \t")
+	return before == "" || strings.HasSuffix(strings.ReplaceAll(before, "\r\n", "\n"), "\n\n")
+}
+
+// isTableCaption reports whether a paragraph is a Pandoc table caption.
+func isTableCaption(p *ast.Paragraph, source []byte) bool {
+	lines := p.Lines()
+	if lines.Len() == 0 {
+		return false
+	}
+	segment := lines.At(0)
+	first := strings.TrimSpace(string(segment.Value(source)))
+	return strings.HasPrefix(first, "Table:") || strings.HasPrefix(first, ": ")
+}
+
+// blockEnd returns the end of a block's last line, excluding the newline.
+func blockEnd(n ast.Node, source []byte) int {
+	lines := n.Lines()
+	end := lines.At(lines.Len() - 1).Stop
+	return len(strings.TrimRight(string(source[:end]), " \t\r\n"))
+}
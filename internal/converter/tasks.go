@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Task list boxes are drawn by \vevetaskdone and \vevetasktodo in LaTeX and by
+// .task-done::before and .task-todo::before in CSS; themes override either.
+const (
+	taskDoneMarkdown = "`\\vevetaskdone{}`{=latex}[]{.task .task-done}"
+	taskTodoMarkdown = "`\\vevetasktodo{}`{=latex}[]{.task .task-todo}"
+)
+
+// taskCSS draws task list boxes for HTML-based engines (weasyprint, prince).
+// It is linked before the theme, so theme rules take precedence.
+const taskCSS = `.task::before { display: inline-block; width: 1.2em; font-family: "DejaVu Sans", "Segoe UI Symbol", sans-serif; }
+.task-todo::before { content: "\2610"; }
+.task-done::before { content: "\2611"; }
+`
+
+// taskLaTeX draws task list boxes for LaTeX engines. \providecommand keeps
+// boxes defined by the theme (see TranslateCSSToLaTeX).
+const taskLaTeX = `\usepackage{amssymb}` +
+	`\providecommand{\vevetasktodo}{\ensuremath{\square}}` +
+	`\providecommand{\vevetaskdone}{\ensuremath{\boxtimes}}`
+
+// PrepareTasks replaces GitHub task list markers ("- [ ] todo", "- [x] done")
+// with boxes every engine can draw. It reports whether content has any task
+// list items. Markers inside code blocks are left unchanged.
+func PrepareTasks(content string) (string, bool) {
+	source := []byte(content)
+	doc := markdownParser.Parse(text.NewReader(source))
+
+	var sb strings.Builder
+	last := 0
+	found := false
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Kind() != ast.KindListItem {
+			return ast.WalkContinue, nil
+		}
+		block := n.FirstChild()
+		if block == nil || block.Lines().Len() == 0 {
+			return ast.WalkContinue, nil
+		}
+
+		start := block.Lines().At(0).Start
+		marker := string(source[start:min(start+4, len(source))])
+		var replacement string
+		switch marker {
+		case "[ ] ", "[ ]\t":
+			replacement = taskTodoMarkdown
+		case "[x] ", "[X] ", "[x]\t", "[X]\t":
+			replacement = taskDoneMarkdown
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		found = true
+		sb.WriteString(content[last:start])
+		sb.WriteString(replacement)
+		last = start + 3
+		return ast.WalkContinue, nil
+	})
+	sb.WriteString(content[last:])
+
+	return sb.String(), found
+}
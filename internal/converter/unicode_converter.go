@@ -2,25 +2,76 @@
 package converter
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
 
+	"github.com/madstone-tech/veve-cli/internal"
 	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/logging"
+	"github.com/madstone-tech/veve-cli/internal/theme"
 )
 
 // UnicodeConversionOptions extends ConversionOptions with unicode-aware settings
 type UnicodeConversionOptions struct {
 	// Base conversion options
-	InputFile  string // Path to markdown file (or "-" for stdin)
-	OutputFile string // Path to output PDF (or "-" for stdout)
-	PDFEngine  string // PDF engine to use (empty = auto-detect)
-	Theme      string // Path to CSS theme file (optional)
-	Standalone bool   // Generate standalone PDF
+	InputFile    string       // Path to markdown file (or "-" for stdin)
+	Content      string       // InputFile's content, if already read (saves reading a large file again)
+	OutputFile   string       // Path to output PDF (or "-" for stdout)
+	PDFEngine    string       // PDF engine to use (empty = auto-detect)
+	Theme        string       // Path to CSS theme file (optional)
+	Standalone   bool         // Generate standalone PDF
+	Sandbox      string       // Run pandoc with reduced privileges (see ConversionOptions.Sandbox)
+	Limits       Limits       // CPU time and memory limits for pandoc and the engine
+	MemoryBudget MemoryBudget // Memory veve itself aims to stay within
+
+	// Theme requirements
+	ThemeName     string   // Theme name or path, for error messages
+	ThemeEngines  []string // Engines the theme supports (empty = any)
+	LaTeXTemplate string   // Pandoc template used instead of the CSS theme with LaTeX engines
 
 	// Unicode settings
 	ValidateUnicode bool // Whether to validate unicode support before conversion
 	AllowFallback   bool // Whether to allow fallback to different engine
 	Verbose         bool // Enable verbose output
+
+	// Warn receives warnings about the conversion (e.g. theme rules an engine
+	// cannot apply, or an engine used instead of another); nil discards them
+	Warn func(category logging.Category, msg string)
+
+	// OnEngine receives the name of the selected engine; nil ignores it
+	OnEngine func(name string)
+
+	// Layout settings
+	Figures    bool       // Number and caption standalone images (see PrepareFigures)
+	Tables     bool       // Content has tables prepared by PrepareTables
+	Tasks      bool       // Content has task lists prepared by PrepareTasks
+	Changes    bool       // Content has change bars marked by MarkChanges
+	Endnotes   string     // Where notes are collected: EndnotesOff, EndnotesDocument, or EndnotesChapter
+	Pagination Pagination // Widow, orphan, and heading break control; overrides the theme
+
+	// Section numbering
+	NumberSections bool // Number headings
+	NumberDepth    int  // Deepest numbered heading level (0 = every level)
+
+	// NoSmart keeps straight quotes, double hyphens, and three dots as typed
+	NoSmart bool
+
+	// EngineArgs are passed to the selected PDF engine as they are
+	EngineArgs []string
+
+	// AutoInstallPackages installs the TeX packages a LaTeX engine reports
+	// missing, with tlmgr or mpm, and converts again
+	AutoInstallPackages bool
+
+	// RetryLaTeX converts once more, with clean intermediate files, when a
+	// LaTeX engine fails (see RetryLaTeX)
+	RetryLaTeX bool
+
+	// Template settings
+	Variables    map[string]string // Template variables (e.g. cover-image, logo)
+	ResourcePath []string          // Directories searched for images and other resources
 }
 
 // ConvertWithUnicodeSupport converts markdown to PDF with automatic engine selection
@@ -34,36 +85,141 @@ type UnicodeConversionOptions struct {
 //
 // Returns error with actionable message if conversion fails
 func ConvertWithUnicodeSupport(opts UnicodeConversionOptions) error {
-	// Select engine based on options and content
-	selectedEngine, err := selectEngineForConversion(opts)
+	selectedEngine, err := SelectEngine(opts)
+	if opts.AutoInstallPackages {
+		// Engines are detected again once the packages are installed
+		err = installMissingPackages(err, func() error {
+			engines.ResetGlobalSelector()
+			var selectErr error
+			selectedEngine, selectErr = SelectEngine(opts)
+			return selectErr
+		})
+	}
 	if err != nil {
 		return err
 	}
 
 	if opts.Verbose {
-		fmt.Fprintf(os.Stderr, "Selected PDF engine: %s\n", selectedEngine.Name)
+		fmt.Fprintf(os.Stderr, "Selected PDF engine: %s\n", selectedEngine.DisplayLabel)
+	}
+	if opts.OnEngine != nil {
+		opts.OnEngine(selectedEngine.Name)
 	}
 
 	// Prepare base conversion options
 	convertOpts := ConversionOptions{
-		InputFile:  opts.InputFile,
-		OutputFile: opts.OutputFile,
-		PDFEngine:  selectedEngine.Name,
-		Theme:      opts.Theme,
-		Standalone: opts.Standalone,
+		InputFile:     opts.InputFile,
+		OutputFile:    opts.OutputFile,
+		PDFEngine:     selectedEngine.Name,
+		PDFEnginePath: selectedEngine.Path,
+		DockerImage:   selectedEngine.Image,
+		Sandbox:       opts.Sandbox,
+		Limits:        opts.Limits,
+		MemoryBudget:  opts.MemoryBudget,
+		EngineArgs:    opts.EngineArgs,
+		Theme:         opts.Theme,
+		Standalone:    opts.Standalone,
+		ResourcePath:  opts.ResourcePath,
+		NoSmart:       opts.NoSmart,
+	}
+	if len(opts.Variables) > 0 {
+		convertOpts.Variables = make(map[string]string, len(opts.Variables))
+		for name, value := range opts.Variables {
+			convertOpts.Variables[name] = value
+		}
 	}
 
-	// Create converter
-	converter, err := NewPandocConverter()
-	if err != nil {
-		return fmt.Errorf("failed to initialize converter: %w", err)
+	// LaTeX engines ignore --css: use the theme's LaTeX template if it has one,
+	// otherwise translate what we can of the CSS
+	if engines.IsLaTeXEngine(selectedEngine.Name) {
+		if opts.LaTeXTemplate != "" {
+			convertOpts.Template = opts.LaTeXTemplate
+		} else if opts.Theme != "" {
+			applyThemeToLaTeX(&convertOpts, opts)
+		}
+	}
+
+	// Rerun LaTeX until the table of contents and references settle
+	if engines.IsLaTeXEngine(selectedEngine.Name) && selectedEngine.Image == "" && contentNeedsLaTeXPasses(opts, convertOpts.Variables) {
+		convertOpts.Latexmk = findLatexmk(selectedEngine.Name, selectedEngine.Path)
+		if convertOpts.Latexmk == "" && opts.Verbose {
+			fmt.Fprintf(os.Stderr, "latexmk not found; page numbers in the table of contents and references may be off (install latexmk)\n")
+		}
+	}
+
+	// LaTeX numbers figures itself; HTML engines need caption counters
+	if opts.Figures && !engines.IsLaTeXEngine(selectedEngine.Name) {
+		convertOpts.HeaderIncludes = append(convertOpts.HeaderIncludes, figureNumberingCSS)
+	}
+
+	// Keep tables inside the page
+	if opts.Tables {
+		convertOpts.HeaderIncludes = append(convertOpts.HeaderIncludes, tableHeader(engines.IsLaTeXEngine(selectedEngine.Name)))
+	}
+
+	// Draw task list boxes; themes can replace the glyphs
+	if opts.Tasks {
+		if engines.IsLaTeXEngine(selectedEngine.Name) {
+			convertOpts.HeaderIncludes = append(convertOpts.HeaderIncludes, taskLaTeX)
+		} else {
+			convertOpts.BaseStyles = append(convertOpts.BaseStyles, taskCSS)
+		}
+	}
+
+	// Draw change bars in the margin
+	if opts.Changes {
+		if engines.IsLaTeXEngine(selectedEngine.Name) {
+			convertOpts.HeaderIncludes = append(convertOpts.HeaderIncludes, changeLaTeX)
+		} else {
+			convertOpts.BaseStyles = append(convertOpts.BaseStyles, changeCSS)
+		}
+	}
+
+	// Number headings, down to NumberDepth
+	if opts.NumberSections {
+		applySectionNumbering(&convertOpts, opts.NumberDepth, engines.IsLaTeXEngine(selectedEngine.Name))
+	}
+
+	// Move footnotes to the end of the document or of each chapter
+	applyEndnotes(&convertOpts, opts.Endnotes, engines.IsLaTeXEngine(selectedEngine.Name))
+
+	// Added after the theme so the flags take precedence over it
+	if header := paginationHeader(opts.Pagination, engines.IsLaTeXEngine(selectedEngine.Name)); header != "" {
+		convertOpts.HeaderIncludes = append(convertOpts.HeaderIncludes, header)
+	}
+
+	// Create converter; pandoc runs in the container for a docker engine
+	converter := &PandocConverter{}
+	if selectedEngine.Image == "" {
+		if converter, err = NewPandocConverter(); err != nil {
+			return fmt.Errorf("failed to initialize converter: %w", err)
+		}
 	}
 
 	// Perform conversion
-	if err := converter.Convert(convertOpts); err != nil {
+	err = converter.Convert(convertOpts)
+	if opts.AutoInstallPackages {
+		err = installMissingPackages(err, func() error { return converter.Convert(convertOpts) })
+	}
+	if opts.RetryLaTeX && selectedEngine.Image == "" {
+		err = RetryLaTeX(err, selectedEngine.Name, func(tempDir string) error {
+			retryOpts := convertOpts
+			retryOpts.TempDir = tempDir
+			return converter.Convert(retryOpts)
+		}, opts.Warn)
+	}
+	if err != nil {
+		// Missing packages are reported as such, whatever the content
+		var missing *engines.MissingLaTeXPackagesError
+		if errors.As(err, &missing) {
+			return err
+		}
 		// If conversion failed and unicode was involved, provide actionable error
 		if opts.ValidateUnicode {
-			contentHasUnicode, _ := detectUnicodeInFile(opts.InputFile)
+			contentHasUnicode := engines.DetectUnicodeInContent(opts.Content)
+			if opts.Content == "" {
+				contentHasUnicode, _ = detectUnicodeInFile(opts.InputFile)
+			}
 			if contentHasUnicode {
 				return formatUnicodeError(selectedEngine, err)
 			}
@@ -74,24 +230,119 @@ func ConvertWithUnicodeSupport(opts UnicodeConversionOptions) error {
 	return nil
 }
 
+// SelectEngine returns the engine ConvertWithUnicodeSupport would use: the
+// requested one, or one chosen for the input's content, checked against the
+// engines the theme supports.
+func SelectEngine(opts UnicodeConversionOptions) (*engines.PDFEngine, error) {
+	// Select engine based on options and content
+	selectedEngine, err := selectEngineForConversion(opts)
+	if err != nil {
+		// Offer a container when nothing is installed locally
+		if opts.PDFEngine == "" && engines.DockerAvailable() {
+			if installed, _ := engines.DetectInstalledEngines(); len(installed) == 0 {
+				return nil, internal.NoEngineDockerAvailable(engines.DefaultDockerImage)
+			}
+		}
+		return nil, internal.WithKind(err, internal.KindEngine)
+	}
+
+	// Make sure the theme can be rendered by the selected engine
+	return selectThemeCompatibleEngine(selectedEngine, opts)
+}
+
+// applyThemeToLaTeX translates the CSS theme into pandoc variables and LaTeX
+// preamble snippets, warning about declarations that have no LaTeX equivalent.
+func applyThemeToLaTeX(convertOpts *ConversionOptions, opts UnicodeConversionOptions) {
+	css, err := os.ReadFile(opts.Theme)
+	if err != nil {
+		return // Convert reports the missing theme file
+	}
+
+	// Only fontspec engines can use system fonts by family name
+	var fontAvailable func(string) bool
+	if convertOpts.PDFEngine == "xelatex" || convertOpts.PDFEngine == "lualatex" {
+		fontAvailable = SystemFontAvailable
+	}
+
+	settings := TranslateCSSToLaTeX(string(css), fontAvailable)
+	if convertOpts.Variables == nil {
+		convertOpts.Variables = map[string]string{}
+	}
+	for name, value := range settings.Variables {
+		convertOpts.Variables[name] = value
+	}
+	convertOpts.HeaderIncludes = append(convertOpts.HeaderIncludes, settings.HeaderIncludes...)
+
+	if opts.Warn == nil || len(settings.Ignored) == 0 {
+		return
+	}
+	if opts.Verbose {
+		for _, ignored := range settings.Ignored {
+			opts.Warn(logging.CategoryTheme, fmt.Sprintf("%s cannot apply theme rule %s (line %d); ignored", convertOpts.PDFEngine, ignored, ignored.Line))
+		}
+		return
+	}
+	opts.Warn(logging.CategoryTheme, fmt.Sprintf("%s cannot apply %d theme rule(s); they were ignored (use --verbose for details, or an HTML engine such as weasyprint for full CSS support)",
+		convertOpts.PDFEngine, len(settings.Ignored)))
+}
+
+// selectThemeCompatibleEngine checks the selected engine against the engines the
+// theme declares. An explicitly requested engine must be supported by the theme;
+// an auto-selected one is replaced by the first installed engine the theme supports.
+func selectThemeCompatibleEngine(selected *engines.PDFEngine, opts UnicodeConversionOptions) (*engines.PDFEngine, error) {
+	if theme.SupportsEngine(opts.ThemeEngines, selected.Name) {
+		return selected, nil
+	}
+
+	if opts.PDFEngine == "" {
+		for _, name := range opts.ThemeEngines {
+			if engine, err := engines.SelectEngineForConversion(name); err == nil {
+				if opts.Warn != nil {
+					opts.Warn(logging.CategoryEngine, fmt.Sprintf("Theme %s requires %s; using it instead of %s", opts.ThemeName, engine.Name, selected.Name))
+				}
+				return engine, nil
+			}
+		}
+	}
+
+	return nil, internal.ThemeEngineIncompatible(opts.ThemeName, selected.Name, opts.ThemeEngines)
+}
+
 // selectEngineForConversion selects the appropriate PDF engine
 // Respects explicit engine selection; auto-detects if needed
 // Prefers emoji-capable engines (WeasyPrint/Prince) for emoji-heavy content
 func selectEngineForConversion(opts UnicodeConversionOptions) (*engines.PDFEngine, error) {
+	// Run pandoc and the engine in a container
+	if image, ok := engines.ParseDockerEngine(opts.PDFEngine); ok {
+		if image == "" {
+			return nil, fmt.Errorf("engine %q names no image: use --engine docker or --engine docker:<image>", opts.PDFEngine)
+		}
+		if err := engines.ValidateDockerImage(image); err != nil {
+			return nil, fmt.Errorf("engine %q: %w", opts.PDFEngine, err)
+		}
+		if !engines.DockerAvailable() {
+			return nil, internal.DockerNotAvailable(opts.PDFEngine)
+		}
+		return engines.DockerEngine(image), nil
+	}
+
 	// If user explicitly specified engine, use it (FR-001.1)
 	if opts.PDFEngine != "" {
 		return engines.SelectEngineForConversion(opts.PDFEngine)
 	}
 
 	// Read file content for intelligent engine selection
-	content, err := os.ReadFile(opts.InputFile)
-	if err != nil {
-		// If we can't read, use default
-		return engines.GetDefaultEngine()
+	contentStr := opts.Content
+	if contentStr == "" {
+		content, err := os.ReadFile(opts.InputFile)
+		if err != nil {
+			// If we can't read, use default
+			return engines.GetDefaultEngine()
+		}
+		contentStr = string(content)
 	}
 
 	// Analyze content to determine best engine
-	contentStr := string(content)
 	hasEmoji := engines.ContainsEmoji(contentStr)
 	hasCJK := engines.ContainsCJK(contentStr)
 	hasHighComplexity := hasEmoji || (hasCJK && len(contentStr) > 5000) // CJK with lots of text
@@ -166,32 +417,17 @@ func formatUnicodeError(engine *engines.PDFEngine, originalErr error) error {
 	platform := getPlatform()
 	instructions := getPlatformInstallInstructions(engine.Name, platform)
 
-	return fmt.Errorf(
+	return internal.WithKind(fmt.Errorf(
 		"PDF conversion failed - unicode rendering not supported by engine '%s'\n"+
 			"Error: %v\n"+
 			"Solution: Install a unicode-capable engine\n%s",
 		engine.Name, originalErr, instructions,
-	)
+	), internal.KindEngine)
 }
 
 // getPlatform returns the current platform (darwin, linux, windows)
 func getPlatform() string {
-	switch os.Getenv("GOOS") {
-	case "darwin":
-		return "darwin"
-	case "linux":
-		return "linux"
-	case "windows":
-		return "windows"
-	default:
-		// Try to detect from runtime
-		switch len(os.Getenv("HOME")) > 0 {
-		case true:
-			return "linux" // Likely Unix-like
-		default:
-			return "windows"
-		}
-	}
+	return runtime.GOOS
 }
 
 // getPlatformInstallInstructions returns platform-specific installation help
@@ -200,18 +436,23 @@ func getPlatformInstallInstructions(engineName, platform string) string {
 		"xelatex": {
 			"darwin":  "\nOn macOS:\n  brew install mactex\n  # This may take 10+ minutes",
 			"linux":   "\nOn Ubuntu/Debian:\n  sudo apt-get update\n  sudo apt-get install texlive-xetex\n\nOn Fedora:\n  sudo dnf install texlive-xetex",
-			"windows": "\nOn Windows:\n  Download MiKTeX from https://miktex.org/\n  Run installer and select xelatex during setup",
+			"windows": "\nOn Windows:\n  winget install MiKTeX.MiKTeX\n  # or download MiKTeX from https://miktex.org/\n  veve finds MiKTeX and TeX Live in their default folders even if they are not on PATH",
 		},
 		"lualatex": {
 			"darwin":  "\nOn macOS:\n  brew install mactex",
 			"linux":   "\nOn Ubuntu/Debian:\n  sudo apt-get update\n  sudo apt-get install texlive-luatex\n\nOn Fedora:\n  sudo dnf install texlive-luatex",
-			"windows": "\nOn Windows:\n  Download MiKTeX from https://miktex.org/\n  Run installer and select luatex during setup",
+			"windows": "\nOn Windows:\n  winget install MiKTeX.MiKTeX\n  # or download MiKTeX from https://miktex.org/",
 		},
 		"weasyprint": {
 			"darwin":  "\nOn macOS:\n  brew install weasyprint",
 			"linux":   "\nOn Ubuntu/Debian:\n  sudo apt-get update\n  sudo apt-get install weasyprint\n\nOn Fedora:\n  sudo dnf install weasyprint",
 			"windows": "\nOn Windows:\n  pip install weasyprint",
 		},
+		"wkhtmltopdf": {
+			"darwin":  "\nOn macOS:\n  brew install --cask wkhtmltopdf",
+			"linux":   "\nOn Ubuntu/Debian:\n  sudo apt-get update\n  sudo apt-get install wkhtmltopdf\n\nOn Fedora:\n  sudo dnf install wkhtmltopdf",
+			"windows": "\nOn Windows:\n  Download the installer from https://wkhtmltopdf.org/downloads.html\n  veve finds it in Program Files even if it is not on PATH",
+		},
 	}
 
 	if insts, ok := instructions[engineName]; ok {
@@ -0,0 +1,319 @@
+package converter
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// wikiLinkPattern matches Obsidian wiki links and embeds:
+// [[target#heading|alias]] and ![[target|size]].
+var wikiLinkPattern = regexp.MustCompile(`(!?)\[\[([^\[\]\n|#]*)(#[^\[\]\n|]*)?(?:\|([^\[\]\n]*))?\]\]`)
+
+// wikiImageExtensions are the files an embed (![[...]]) shows as an image.
+var wikiImageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true,
+	".webp": true, ".bmp": true, ".pdf": true,
+}
+
+// wikiSizePattern matches an embed size: a width, or width x height, in pixels.
+var wikiSizePattern = regexp.MustCompile(`^\s*(\d+)(?:\s*x\s*(\d+))?\s*$`)
+
+// WikiLinkResolver rewrites Obsidian wiki links to standard markdown. Targets
+// are found anywhere in the vault by file name, as Obsidian does.
+type WikiLinkResolver struct {
+	vaultRoot string
+	docPath   string              // Absolute path of the document (empty for stdin)
+	files     map[string][]string // Lowercase file name -> vault-relative slash paths
+}
+
+// NewWikiLinkResolver indexes the files in vaultRoot for links in the document
+// at docPath (empty when reading stdin; links are then relative to the vault
+// root). Hidden directories such as .obsidian and .trash are skipped.
+func NewWikiLinkResolver(vaultRoot, docPath string) (*WikiLinkResolver, error) {
+	root, err := filepath.Abs(vaultRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve vault root: %w", err)
+	}
+	r := &WikiLinkResolver{vaultRoot: root, files: make(map[string][]string)}
+	if docPath != "" {
+		if r.docPath, err = filepath.Abs(docPath); err != nil {
+			return nil, fmt.Errorf("failed to resolve document path: %w", err)
+		}
+	}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := strings.ToLower(d.Name())
+		r.files[name] = append(r.files[name], filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index vault %s: %w", root, err)
+	}
+	return r, nil
+}
+
+// Prepare replaces wiki links in content: links to notes become links to the
+// notes' PDFs (as veve names them), links to headings point at the heading's
+// anchor, and image embeds become images, sized if the embed gives a size.
+// Links that don't resolve become plain text. It returns a warning for each of
+// those and for note embeds, which are exported as links. Links inside code
+// are left unchanged.
+func (r *WikiLinkResolver) Prepare(content string) (string, []string) {
+	code := codeRanges(content)
+
+	var sb strings.Builder
+	var warnings []string
+	last := 0
+	for _, match := range wikiLinkPattern.FindAllStringSubmatchIndex(content, -1) {
+		start, end := match[0], match[1]
+		if inRanges(code, start) {
+			continue
+		}
+		group := func(i int) string {
+			if match[2*i] < 0 {
+				return ""
+			}
+			return content[match[2*i]:match[2*i+1]]
+		}
+		embed := group(1) == "!"
+		target := strings.TrimSpace(group(2))
+		heading := strings.TrimSpace(strings.TrimPrefix(group(3), "#"))
+		alias := strings.TrimSpace(group(4))
+
+		var replacement, warning string
+		if embed && wikiImageExtensions[strings.ToLower(path.Ext(target))] {
+			replacement, warning = r.image(target, alias)
+		} else {
+			replacement, warning = r.link(target, heading, alias)
+			if embed && warning == "" {
+				warning = fmt.Sprintf("Note embed %s is exported as a link", content[start:end])
+			}
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+
+		sb.WriteString(content[last:start])
+		sb.WriteString(replacement)
+		last = end
+	}
+	sb.WriteString(content[last:])
+	return sb.String(), warnings
+}
+
+// link returns the markdown for a link to target's heading.
+func (r *WikiLinkResolver) link(target, heading, alias string) (string, string) {
+	display := alias
+	if display == "" {
+		display = strings.TrimSuffix(path.Base(target), ".md")
+		if target == "" {
+			display = heading
+		} else if heading != "" {
+			display += " > " + heading
+		}
+	}
+	display = escapeLinkText(display)
+
+	// Block references (#^id) have no anchor in the output
+	anchor := ""
+	if heading != "" && !strings.HasPrefix(heading, "^") {
+		anchor = "#" + headingIdentifier(heading)
+	}
+
+	if target == "" {
+		if anchor == "" {
+			return display, ""
+		}
+		return fmt.Sprintf("[%s](%s)", display, anchor), ""
+	}
+
+	note := target
+	if path.Ext(note) == "" {
+		note += ".md"
+	}
+	file, ok := r.resolve(note)
+	if !ok {
+		return display, fmt.Sprintf("Unresolved wiki link [[%s]] is exported as text", target)
+	}
+
+	// Links within the document need only the anchor
+	if r.docPath != "" && file == r.docPath {
+		if anchor == "" {
+			return display, ""
+		}
+		return fmt.Sprintf("[%s](%s)", display, anchor), ""
+	}
+
+	if strings.EqualFold(filepath.Ext(file), ".md") {
+		file = strings.TrimSuffix(file, filepath.Ext(file)) + ".pdf"
+	}
+	rel, err := filepath.Rel(r.baseDir(), file)
+	if err != nil {
+		rel = file
+	}
+	return fmt.Sprintf("[%s](%s%s)", display, (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath(), anchor), ""
+}
+
+// image returns the markdown for an image embed. A size ("300" or "300x200")
+// sets the width and height in pixels; anything else is the alt text.
+func (r *WikiLinkResolver) image(target, option string) (string, string) {
+	file, ok := r.resolve(target)
+	if !ok {
+		return escapeLinkText(target), fmt.Sprintf("Unresolved image embed ![[%s]] is exported as text", target)
+	}
+
+	alt, attributes := "", ""
+	if size := wikiSizePattern.FindStringSubmatch(option); size != nil {
+		attributes = "{width=" + size[1] + "px"
+		if size[2] != "" {
+			attributes += " height=" + size[2] + "px"
+		}
+		attributes += "}"
+	} else {
+		alt = escapeLinkText(option)
+	}
+	// An absolute path, since pandoc runs on a copy of the document
+	return fmt.Sprintf("![%s](<%s>)%s", alt, filepath.ToSlash(file), attributes), ""
+}
+
+// resolve finds the vault file a link target names: by path if it has one,
+// otherwise by file name, preferring the document's directory and then the
+// file nearest the vault root.
+func (r *WikiLinkResolver) resolve(target string) (string, bool) {
+	target = strings.TrimPrefix(path.Clean(filepath.ToSlash(target)), "/")
+	candidates := r.files[strings.ToLower(path.Base(target))]
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.Contains(target, "/") {
+			lower, want := strings.ToLower(candidate), strings.ToLower(target)
+			if lower != want && !strings.HasSuffix(lower, "/"+want) {
+				continue
+			}
+		}
+		matches = append(matches, candidate)
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	docDir := ""
+	if rel, err := filepath.Rel(r.vaultRoot, r.baseDir()); err == nil {
+		docDir = filepath.ToSlash(rel)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		iLocal, jLocal := path.Dir(matches[i]) == docDir, path.Dir(matches[j]) == docDir
+		if iLocal != jLocal {
+			return iLocal
+		}
+		iDepth, jDepth := strings.Count(matches[i], "/"), strings.Count(matches[j], "/")
+		if iDepth != jDepth {
+			return iDepth < jDepth
+		}
+		return matches[i] < matches[j]
+	})
+	return filepath.Join(r.vaultRoot, filepath.FromSlash(matches[0])), true
+}
+
+// baseDir is the directory links are relative to: the document's, or the
+// vault root for stdin.
+func (r *WikiLinkResolver) baseDir() string {
+	if r.docPath == "" {
+		return r.vaultRoot
+	}
+	return filepath.Dir(r.docPath)
+}
+
+// headingIdentifier returns the identifier pandoc gives a heading: lowercase,
+// spaces as hyphens, punctuation other than _ - . removed, and anything before
+// the first letter dropped.
+func headingIdentifier(heading string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.':
+			sb.WriteRune(r)
+		case unicode.IsSpace(r):
+			sb.WriteRune('-')
+		}
+	}
+	id := strings.TrimLeftFunc(sb.String(), func(r rune) bool { return !unicode.IsLetter(r) })
+	if id == "" {
+		return "section"
+	}
+	return id
+}
+
+// escapeLinkText escapes the characters that would end or nest link text.
+func escapeLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
+
+// codeRanges returns the byte ranges of content inside code spans, code
+// blocks, and raw HTML, where markdown syntax is not interpreted.
+func codeRanges(content string) [][2]int {
+	source := []byte(content)
+	doc := markdownParser.Parse(text.NewReader(source))
+
+	var ranges [][2]int
+	addLines := func(lines *text.Segments) {
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			ranges = append(ranges, [2]int{segment.Start, segment.Stop})
+		}
+	}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock:
+			addLines(node.Lines())
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeSpan:
+			for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+				if t, ok := child.(*ast.Text); ok {
+					ranges = append(ranges, [2]int{t.Segment.Start, t.Segment.Stop})
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML:
+			addLines(node.Segments)
+		}
+		return ast.WalkContinue, nil
+	})
+	return ranges
+}
+
+// inRanges reports whether offset is inside one of ranges.
+func inRanges(ranges [][2]int, offset int) bool {
+	for _, r := range ranges {
+		if offset >= r[0] && offset < r[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -88,13 +88,13 @@ func GetAvailableEnginesForCompletion() []string {
 
 	if selectorErr != nil {
 		// If engine detection fails, return hardcoded list for completion
-		return []string{"xelatex", "lualatex", "weasyprint", "prince"}
+		return []string{"xelatex", "lualatex", "weasyprint", "prince", "wkhtmltopdf"}
 	}
 
 	available := globalSelector.GetAvailableEngines()
 	if len(available) == 0 {
 		// Fallback to all known engines if none detected as unicode-capable
-		return []string{"xelatex", "lualatex", "weasyprint", "prince"}
+		return []string{"xelatex", "lualatex", "weasyprint", "prince", "wkhtmltopdf"}
 	}
 
 	return available
@@ -0,0 +1,110 @@
+// Package engines provides PDF engine detection, validation, and selection logic.
+package engines
+
+import (
+	"strings"
+	"unicode"
+)
+
+// BenchmarkDocument returns the document engines are benchmarked with when
+// none is given: a few pages of typical content (headings, paragraphs, lists,
+// a table, code, and quotes) with the unicode the engines differ on
+func BenchmarkDocument() string {
+	var sb strings.Builder
+	sb.WriteString(`---
+title: Engine Benchmark
+author: veve
+---
+
+# Introduction
+
+This document exercises the content most conversions contain, so engines can
+be compared on realistic work. It is repeated over several sections to give
+the engines a few pages to lay out.
+
+`)
+	for i := 1; i <= 4; i++ {
+		sb.WriteString(benchmarkSection)
+	}
+	sb.WriteString(`# Unicode
+
+Emoji: 🎉 📄 ✅ 🚀 ❤️
+
+Chinese: 世界 中国. Japanese: こんにちは カタカナ. Korean: 안녕하세요.
+
+Mathematics: ∑ Σ ± ∫ ∈ ∪ ∩ ≤ ≥ ∞
+
+Diacritics: Café naïve, Español niño, Zürich über, São Paulo Ação.
+
+Greek and Cyrillic: αβγδ ΩΨΦ, Привет мир.
+
+Typography: “quotes” ‘single’ — em dash – en dash … ellipsis € £ ¥ © ®
+`)
+	return sb.String()
+}
+
+// benchmarkSection is one section of the benchmark document
+const benchmarkSection = `# Section
+
+## Body text
+
+Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor
+incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis
+nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat.
+Text may be **bold**, *italic*, ` + "`code`" + `, or a [link](https://example.com).
+
+Duis aute irure dolor in reprehenderit in voluptate velit esse cillum dolore eu
+fugiat nulla pariatur. Excepteur sint occaecat cupidatat non proident, sunt in
+culpa qui officia deserunt mollit anim id est laborum.
+
+## Lists
+
+- First item
+- Second item with a longer description that wraps onto the next line when
+  the page is narrow enough
+  - Nested item
+- Third item
+
+1. Step one
+2. Step two
+3. Step three
+
+## Table
+
+| Engine     | Type  | Notes                    |
+|------------|-------|--------------------------|
+| xelatex    | LaTeX | System fonts             |
+| weasyprint | HTML  | CSS paged media          |
+| prince     | HTML  | Commercial               |
+
+## Code
+
+` + "```go" + `
+func main() {
+	fmt.Println("hello, world")
+}
+` + "```" + `
+
+> A quotation, set apart from the body text, that runs long enough to wrap
+> across more than one line.
+
+`
+
+// UnicodeFidelity reports how many of the distinct non-ASCII characters in
+// source appear in rendered, text extracted from its PDF. Combining marks,
+// joiners, and variation selectors are not counted, since they do not survive
+// text extraction on their own.
+func UnicodeFidelity(source, rendered string) (found, total int) {
+	seen := make(map[rune]bool)
+	for _, r := range source {
+		if r <= unicode.MaxASCII || seen[r] || !unicode.IsGraphic(r) || unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		seen[r] = true
+		total++
+		if strings.ContainsRune(rendered, r) {
+			found++
+		}
+	}
+	return found, total
+}
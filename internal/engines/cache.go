@@ -0,0 +1,195 @@
+// Package engines provides PDF engine detection, validation, and selection logic.
+package engines
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/madstone-tech/veve-cli/internal/filelock"
+)
+
+// The detection cache, configured with SetDetectionCache
+var (
+	detectionCacheMu   sync.Mutex
+	detectionCacheFile string
+	refreshDetection   bool
+)
+
+// SetDetectionCache persists engine versions and unicode test results in file,
+// so conversions don't run every engine at startup. Entries are keyed by the
+// engine binary's path, size, and modification time (and the pandoc binary's,
+// for unicode tests), so upgrading either re-runs detection. With refresh,
+// cached results are ignored and replaced. An empty file disables the cache.
+func SetDetectionCache(file string, refresh bool) {
+	detectionCacheMu.Lock()
+	defer detectionCacheMu.Unlock()
+	detectionCacheFile = file
+	refreshDetection = refresh
+}
+
+// detectionCacheEntry is the cached detection result for one engine.
+type detectionCacheEntry struct {
+	// Binary identifies the engine binary the results are for
+	Binary string `json:"binary"`
+
+	// Version is the detected version, empty if detection failed
+	Version string `json:"version,omitempty"`
+
+	// Pandoc identifies the pandoc binary the unicode test ran with
+	Pandoc string `json:"pandoc,omitempty"`
+
+	// Unicode is the unicode test result, if the test ran
+	Unicode *cachedTestResult `json:"unicode,omitempty"`
+}
+
+// cachedTestResult is the part of a TestResult that is cached.
+type cachedTestResult struct {
+	Success      bool   `json:"success"`
+	ErrorMessage string `json:"error,omitempty"`
+}
+
+// detectionCache holds the cached entries for one detection run. A nil
+// detectionCache caches nothing.
+type detectionCache struct {
+	file    string
+	refresh bool
+	entries map[string]detectionCacheEntry
+	binary  map[string]string // engine name -> binary identity, for this run
+	pandoc  string
+	changed map[string]bool
+}
+
+// openDetectionCache loads the configured detection cache, or returns nil if
+// none is configured. With refresh, cached results are replaced.
+func openDetectionCache(refresh bool) *detectionCache {
+	detectionCacheMu.Lock()
+	file := detectionCacheFile
+	refresh = refresh || refreshDetection
+	detectionCacheMu.Unlock()
+	if file == "" {
+		return nil
+	}
+
+	cache := &detectionCache{
+		file:    file,
+		refresh: refresh,
+		binary:  make(map[string]string),
+		changed: make(map[string]bool),
+	}
+	cache.entries, _ = readDetectionCache(file)
+	if pandoc, err := exec.LookPath("pandoc"); err == nil {
+		cache.pandoc, _ = binaryIdentity(pandoc)
+	}
+	return cache
+}
+
+// readDetectionCache reads the entries in file.
+func readDetectionCache(file string) (map[string]detectionCacheEntry, error) {
+	entries := make(map[string]detectionCacheEntry)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return entries, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return make(map[string]detectionCacheEntry), err
+	}
+	return entries, nil
+}
+
+// binaryIdentity identifies the binary at path by its path, size, and
+// modification time.
+func binaryIdentity(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// entry returns the cached entry for engine name, if it is for the binary
+// detected in this run and the cache isn't being refreshed.
+func (c *detectionCache) entry(name string) (detectionCacheEntry, bool) {
+	entry, ok := c.entries[name]
+	if !ok || c.refresh && !c.changed[name] || entry.Binary != c.binary[name] {
+		return detectionCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// version returns the version of engine, found at path, from the cache or by
+// running it.
+func (c *detectionCache) version(engine PDFEngine, path string) (string, error) {
+	if c == nil {
+		return getEngineVersion(engine)
+	}
+
+	binary, err := binaryIdentity(path)
+	if err != nil {
+		return getEngineVersion(engine)
+	}
+	c.binary[engine.Name] = binary
+	if entry, ok := c.entry(engine.Name); ok {
+		if entry.Version == "" {
+			return "", fmt.Errorf("could not detect version for %s", engine.Name)
+		}
+		return entry.Version, nil
+	}
+
+	version, err := getEngineVersion(engine)
+	c.entries[engine.Name] = detectionCacheEntry{Binary: binary, Version: version}
+	c.changed[engine.Name] = true
+	return version, err
+}
+
+// unicodeSupport returns the unicode test result for engine from the cache,
+// or by running the test.
+func (c *detectionCache) unicodeSupport(engine PDFEngine) *TestResult {
+	if c == nil || c.binary[engine.Name] == "" || c.pandoc == "" {
+		return testUnicodeSupport(engine)
+	}
+
+	entry, ok := c.entry(engine.Name)
+	if ok && entry.Unicode != nil && entry.Pandoc == c.pandoc {
+		return &TestResult{Success: entry.Unicode.Success, ErrorMessage: entry.Unicode.ErrorMessage}
+	}
+
+	result := testUnicodeSupport(engine)
+	// Only cache results the engine itself produced; a timeout, a missing
+	// binary, or missing packages may not happen next time
+	if !ok || (!result.Success && result.ExitCode <= 0) {
+		return result
+	}
+	entry.Pandoc = c.pandoc
+	entry.Unicode = &cachedTestResult{Success: result.Success, ErrorMessage: result.ErrorMessage}
+	c.entries[engine.Name] = entry
+	c.changed[engine.Name] = true
+	return result
+}
+
+// save writes the changed entries to the cache file, keeping the other
+// entries as another veve process may have written them. The cache is only an
+// optimization, so failures are ignored.
+func (c *detectionCache) save() {
+	if c == nil || len(c.changed) == 0 {
+		return
+	}
+
+	lock, err := filelock.Acquire(c.file+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return
+	}
+	defer lock.Unlock()
+
+	entries, _ := readDetectionCache(c.file)
+	for name := range c.changed {
+		entries[name] = c.entries[name]
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = filelock.WriteFile(c.file, data, 0o644)
+}
@@ -12,6 +12,13 @@ import (
 // DetectInstalledEngines searches PATH for available PDF engines
 // Returns a slice of PDFEngine with IsInstalled set based on availability
 func DetectInstalledEngines() ([]PDFEngine, error) {
+	cache := openDetectionCache(false)
+	defer cache.save()
+	return detectInstalledEngines(cache)
+}
+
+// detectInstalledEngines detects installed engines, taking versions from cache
+func detectInstalledEngines(cache *detectionCache) ([]PDFEngine, error) {
 	definitions := DefaultEngineDefinitions()
 	var installed []PDFEngine
 
@@ -21,12 +28,15 @@ func DetectInstalledEngines() ([]PDFEngine, error) {
 			continue
 		}
 
-		// Check if engine binary is in PATH
-		if _, err := exec.LookPath(name); err == nil {
+		// Check if engine binary is in PATH or its default install directory
+		if path, err := LookupEngine(name); err == nil {
 			def.IsInstalled = true
+			if _, err := exec.LookPath(name); err != nil {
+				def.Path = path
+			}
 
 			// Try to detect version
-			if version, err := getEngineVersion(name); err == nil {
+			if version, err := cache.version(def, path); err == nil {
 				def.Version = version
 			}
 
@@ -37,7 +47,7 @@ func DetectInstalledEngines() ([]PDFEngine, error) {
 	// If no engines found, return error with helpful message
 	if len(installed) == 0 {
 		return nil, fmt.Errorf("no PDF rendering engines found in PATH; " +
-			"please install one of: xelatex, lualatex, weasyprint, or prince")
+			"please install one of: xelatex, lualatex, weasyprint, prince, or wkhtmltopdf")
 	}
 
 	return installed, nil
@@ -45,36 +55,19 @@ func DetectInstalledEngines() ([]PDFEngine, error) {
 
 // getEngineVersion attempts to detect engine version
 // Returns version string or error if detection fails
-func getEngineVersion(engineName string) (string, error) {
-	switch engineName {
-	case "xelatex", "lualatex":
-		// LaTeX engines: try --version
-		cmd := exec.Command(engineName, "--version")
-		output, err := cmd.CombinedOutput()
-		if err == nil {
-			// Extract version from first line
+func getEngineVersion(engine PDFEngine) (string, error) {
+	// All supported engines print their version with --version
+	cmd := exec.Command(engine.Command(), "--version")
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		switch engine.Name {
+		case "xelatex", "lualatex":
+			// LaTeX engines: extract version from first line
 			lines := strings.Split(string(output), "\n")
 			if len(lines) > 0 && lines[0] != "" {
-				return lines[0], nil
+				return strings.TrimSpace(lines[0]), nil
 			}
-		}
-
-	case "weasyprint":
-		// WeasyPrint: try --version
-		cmd := exec.Command("weasyprint", "--version")
-		output, err := cmd.CombinedOutput()
-		if err == nil {
-			version := strings.TrimSpace(string(output))
-			if version != "" {
-				return version, nil
-			}
-		}
-
-	case "prince":
-		// Prince: try --version
-		cmd := exec.Command("prince", "--version")
-		output, err := cmd.CombinedOutput()
-		if err == nil {
+		default:
 			version := strings.TrimSpace(string(output))
 			if version != "" {
 				return version, nil
@@ -82,13 +75,14 @@ func getEngineVersion(engineName string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("could not detect version for %s", engineName)
+	return "", fmt.Errorf("could not detect version for %s", engine.Name)
 }
 
-// FindEngineInPath searches for a specific engine binary in system PATH
-// Returns the full path to the engine or error if not found
+// FindEngineInPath searches for a specific engine binary in system PATH, or
+// its default install directory. Returns the full path to the engine or error
+// if not found
 func FindEngineInPath(engineName string) (string, error) {
-	path, err := exec.LookPath(engineName)
+	path, err := LookupEngine(engineName)
 	if err != nil {
 		return "", fmt.Errorf("engine '%s' not found in PATH", engineName)
 	}
@@ -122,7 +116,6 @@ func SearchEngineInDirs(engineName string, dirs []string) (string, error) {
 	for _, dir := range dirs {
 		enginePath := filepath.Join(dir, engineName)
 
-		// On Windows, try with .exe extension
 		if _, err := os.Stat(enginePath); err == nil {
 			return enginePath, nil
 		}
@@ -0,0 +1,92 @@
+// Package engines provides PDF engine detection, validation, and selection logic.
+package engines
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DockerEngineName selects an engine run inside a container: "docker" uses
+	// DefaultDockerImage, "docker:<image>" another image
+	DockerEngineName = "docker"
+
+	// DefaultDockerImage is the pinned image "docker" runs: pandoc with TeX Live
+	DefaultDockerImage = "pandoc/latex:3.5"
+
+	// DockerPDFEngine is the engine run inside the container. Images must have
+	// pandoc as their entrypoint and provide this engine.
+	DockerPDFEngine = "xelatex"
+)
+
+// ParseDockerEngine reports whether engineName selects a container engine
+// ("docker" or "docker:<image>") and returns the image it runs.
+func ParseDockerEngine(engineName string) (string, bool) {
+	if engineName == DockerEngineName {
+		return DefaultDockerImage, true
+	}
+	if image, ok := strings.CutPrefix(engineName, DockerEngineName+":"); ok {
+		return image, true
+	}
+	return "", false
+}
+
+// dockerImageRef matches an image reference as docker parses one:
+// [registry[:port]/]name[/name...][:tag][@digest]. Nothing it matches can
+// start with "-", so an image is never taken for a docker option.
+var dockerImageRef = func() *regexp.Regexp {
+	const (
+		domain    = `(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)(?:\.(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?))*(?::[0-9]+)?`
+		component = `[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*`
+		tag       = `[\w][\w.-]{0,127}`
+		digest    = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}`
+	)
+	return regexp.MustCompile(`^(?:` + domain + `/)?` + component + `(?:/` + component + `)*(?::` + tag + `)?(?:@` + digest + `)?$`)
+}()
+
+// ValidateDockerImage returns an error unless image is a valid docker image
+// reference, e.g. pandoc/latex:3.5 or ghcr.io/org/pandoc@sha256:<digest>.
+func ValidateDockerImage(image string) error {
+	switch {
+	case image == "":
+		return fmt.Errorf("no docker image given")
+	case strings.HasPrefix(image, "-"):
+		return fmt.Errorf("docker image %q starts with '-'", image)
+	case len(image) > 255 || !dockerImageRef.MatchString(image):
+		return fmt.Errorf("%q is not a valid docker image reference", image)
+	}
+	return nil
+}
+
+// DockerEngine returns the engine for running pandoc and xelatex in image.
+func DockerEngine(image string) *PDFEngine {
+	def := DefaultEngineDefinitions()[DockerPDFEngine]
+	def.DisplayLabel += " (docker: " + image + ")"
+	def.IsInstalled = true
+	def.Image = image
+	return &def
+}
+
+var (
+	dockerOnce      sync.Once
+	dockerAvailable bool
+)
+
+// DockerAvailable reports whether the docker CLI is installed and its daemon
+// is running. The result is cached for the CLI invocation.
+func DockerAvailable() bool {
+	dockerOnce.Do(func() {
+		if _, err := exec.LookPath("docker"); err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		dockerAvailable = exec.CommandContext(ctx, "docker", "info", "--format", "{{.ServerVersion}}").Run() == nil
+	})
+	return dockerAvailable
+}
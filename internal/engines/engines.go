@@ -22,8 +22,17 @@ type PDFEngine struct {
 	EmojiSupport bool
 
 	// IsInstalled indicates whether engine is present in system PATH
+	// or in its default install directory
 	IsInstalled bool
 
+	// Path is the full path of the engine binary when it was found outside
+	// PATH; empty if it is on PATH
+	Path string
+
+	// Image is the container image pandoc and the engine run in (see
+	// DockerEngine); empty for a local engine
+	Image string
+
 	// Version is the detected engine version (for debugging)
 	Version string
 
@@ -31,6 +40,15 @@ type PDFEngine struct {
 	InstallationInstructions string
 }
 
+// Command returns what to run the engine as: its full path if it is not on
+// PATH, otherwise its name.
+func (e *PDFEngine) Command() string {
+	if e.Path != "" {
+		return e.Path
+	}
+	return e.Name
+}
+
 // AvailableEngine represents a runtime representation of detected engines with capabilities
 type AvailableEngine struct {
 	// Engine is a reference to base engine definition
@@ -62,6 +80,10 @@ type TestResult struct {
 
 	// ErrorMessage if test failed
 	ErrorMessage string
+
+	// Problem is set when the engine wasn't tested because its installation
+	// is incomplete: a *MissingLaTeXPackagesError or a *WeasyPrintError
+	Problem error
 }
 
 // CanHandle checks if engine can handle the given text content
@@ -85,10 +107,11 @@ func (ae *AvailableEngine) GetErrorMessage() string {
 
 // PriorityOrder defines the engine selection priority (highest to lowest)
 var PriorityOrder = []string{
-	"xelatex",    // Priority 1: Native UTF-8 support, widely available
-	"lualatex",   // Priority 2: Similar capabilities, slightly slower
-	"weasyprint", // Priority 3: For users without LaTeX, requires Python
-	"prince",     // Priority 4: Commercial option, excellent support
+	"xelatex",     // Priority 1: Native UTF-8 support, widely available
+	"lualatex",    // Priority 2: Similar capabilities, slightly slower
+	"weasyprint",  // Priority 3: For users without LaTeX, requires Python
+	"prince",      // Priority 4: Commercial option, excellent support
+	"wkhtmltopdf", // Priority 5: HTML engine, no emoji support
 }
 
 // DefaultEngineDefinitions provides the set of supported engines
@@ -148,5 +171,29 @@ func DefaultEngineDefinitions() map[string]PDFEngine {
 				"Download from https://www.princexml.com/download/\n" +
 				"Prince is a commercial tool with a free trial.",
 		},
+		"wkhtmltopdf": {
+			Name:           "wkhtmltopdf",
+			DisplayLabel:   "wkhtmltopdf",
+			Priority:       5,
+			UnicodeSupport: true,
+			EmojiSupport:   false,
+			IsInstalled:    false,
+			Version:        "",
+			InstallationInstructions: "" +
+				"macOS: brew install --cask wkhtmltopdf\n" +
+				"Ubuntu/Debian: sudo apt-get install wkhtmltopdf\n" +
+				"Fedora: sudo dnf install wkhtmltopdf\n" +
+				"Windows: Download from https://wkhtmltopdf.org/downloads.html",
+		},
+	}
+}
+
+// IsLaTeXEngine reports whether the named engine renders through LaTeX,
+// as opposed to the HTML/CSS engines (weasyprint, prince)
+func IsLaTeXEngine(engineName string) bool {
+	switch engineName {
+	case "xelatex", "lualatex", "pdflatex", "tectonic", "latexmk":
+		return true
 	}
+	return false
 }
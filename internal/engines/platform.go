@@ -0,0 +1,103 @@
+// Package engines provides PDF engine detection, validation, and selection logic.
+package engines
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// LookupEngine returns the path of an engine binary: from PATH, or from the
+// directories its installer uses by default when it is not on PATH (MiKTeX,
+// TeX Live, Prince, and wkhtmltopdf on Windows often are not).
+func LookupEngine(engineName string) (string, error) {
+	path, err := exec.LookPath(engineName)
+	if err == nil {
+		return path, nil
+	}
+	if found, searchErr := SearchEngineInDirs(engineName, InstallDirs(engineName, runtime.GOOS, os.Getenv)); searchErr == nil {
+		return found, nil
+	}
+	return "", err
+}
+
+// InstallDirs returns the directories an engine's installer uses by default on
+// goos, for engines that may not be added to PATH. Wildcards are expanded and
+// only existing directories are returned, newest version first.
+func InstallDirs(engineName, goos string, getenv func(string) string) []string {
+	if goos != "windows" {
+		return nil
+	}
+
+	// Both Program Files directories, and the per-user install locations
+	programFiles := nonEmpty(getenv("ProgramFiles"), getenv("ProgramFiles(x86)"), getenv("ProgramW6432"))
+	localPrograms := nonEmpty(joinIfSet(getenv("LOCALAPPDATA"), "Programs"))
+	systemDrive := getenv("SystemDrive")
+	if systemDrive == "" {
+		systemDrive = "C:"
+	}
+
+	var patterns []string
+	switch engineName {
+	case "xelatex", "lualatex", "pdflatex", "latexmk":
+		for _, base := range append(programFiles, localPrograms...) {
+			patterns = append(patterns,
+				filepath.Join(base, "MiKTeX", "miktex", "bin", "x64"),
+				filepath.Join(base, "MiKTeX", "miktex", "bin"),
+			)
+		}
+		patterns = append(patterns, filepath.Join(systemDrive+string(filepath.Separator), "texlive", "*", "bin", "*"))
+	case "wkhtmltopdf":
+		for _, base := range programFiles {
+			patterns = append(patterns, filepath.Join(base, "wkhtmltopdf", "bin"))
+		}
+	case "prince":
+		for _, base := range programFiles {
+			patterns = append(patterns, filepath.Join(base, "Prince", "engine", "bin"))
+		}
+	case "weasyprint":
+		// pip installs console scripts next to the Python it runs under
+		for _, base := range append(localPrograms, programFiles...) {
+			patterns = append(patterns, filepath.Join(base, "Python", "Python3*", "Scripts"))
+		}
+		if appData := getenv("APPDATA"); appData != "" {
+			patterns = append(patterns, filepath.Join(appData, "Python", "Python3*", "Scripts"))
+		}
+	}
+
+	var dirs []string
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		// Versioned directories (texlive\2024, Python312) sort newest last
+		sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+		for _, dir := range matches {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() && !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// nonEmpty returns the non-empty values.
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, value := range values {
+		if value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// joinIfSet joins elem to base, or returns "" if base is empty.
+func joinIfSet(base string, elem ...string) string {
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(append([]string{base}, elem...)...)
+}
@@ -18,15 +18,19 @@ type EngineSelector struct {
 func NewEngineSelector() (*EngineSelector, error) {
 	selector := &EngineSelector{}
 
+	// Detection results are cached between runs (see SetDetectionCache)
+	cache := openDetectionCache(false)
+	defer cache.save()
+
 	// Detect installed engines
-	installed, err := DetectInstalledEngines()
+	installed, err := detectInstalledEngines(cache)
 	if err != nil {
 		return nil, err
 	}
 
 	// Validate each engine's unicode support
 	for _, engine := range installed {
-		testResult := ValidateUnicodeSupport(engine)
+		testResult := cache.unicodeSupport(engine)
 
 		available := AvailableEngine{
 			Engine:             engine,
@@ -43,11 +47,17 @@ func NewEngineSelector() (*EngineSelector, error) {
 		}
 	}
 
-	// If no unicode-capable engine found, return error
+	// If no unicode-capable engine found, return error; an incomplete
+	// installation is the likely cause, and can be fixed
 	if selector.defaultEngine == nil {
+		for _, available := range selector.availableEngines {
+			if problem := available.UnicodeTestResult.Problem; problem != nil {
+				return nil, problem
+			}
+		}
 		return nil, fmt.Errorf(
 			"no unicode-capable PDF engine found; " +
-				"please install one of: xelatex, lualatex, weasyprint, or prince",
+				"please install one of: xelatex, lualatex, weasyprint, prince, or wkhtmltopdf",
 		)
 	}
 
@@ -75,6 +85,9 @@ func (es *EngineSelector) SelectEngine(engineName string) (*PDFEngine, error) {
 
 	for _, available := range es.availableEngines {
 		if available.Engine.Name == engineName {
+			if problem := available.UnicodeTestResult.Problem; problem != nil {
+				return nil, problem
+			}
 			if !available.IsCapableOfUnicode {
 				return nil, fmt.Errorf(
 					"engine '%s' does not support unicode: %s",
@@ -85,6 +98,12 @@ func (es *EngineSelector) SelectEngine(engineName string) (*PDFEngine, error) {
 		}
 	}
 
+	// Tell a missing WeasyPrint from one whose command isn't on PATH
+	if engineName == "weasyprint" {
+		if diagnosis := DiagnoseWeasyPrint(); diagnosis != nil {
+			return nil, diagnosis
+		}
+	}
 	return nil, fmt.Errorf("engine '%s' not found or not installed", engineName)
 }
 
@@ -183,15 +202,17 @@ func (es *EngineSelector) RefreshAvailability() error {
 	es.availableEngines = nil
 	es.defaultEngine = nil
 
-	// Re-detect
-	installed, err := DetectInstalledEngines()
+	// Re-detect, replacing any cached results
+	cache := openDetectionCache(true)
+	defer cache.save()
+	installed, err := detectInstalledEngines(cache)
 	if err != nil {
 		return err
 	}
 
 	// Re-validate
 	for _, engine := range installed {
-		testResult := ValidateUnicodeSupport(engine)
+		testResult := cache.unicodeSupport(engine)
 
 		available := AvailableEngine{
 			Engine:             engine,
@@ -0,0 +1,240 @@
+package engines
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal"
+)
+
+// TeX distributions, which install packages with different tools.
+const (
+	TeXLive = "texlive"
+	MiKTeX  = "miktex"
+)
+
+// RequiredLaTeXFiles are the files pandoc's default LaTeX template loads with
+// xelatex and lualatex; without any of them every conversion fails.
+var RequiredLaTeXFiles = []string{
+	"iftex.sty", "amsmath.sty", "fontspec.sty", "unicode-math.sty",
+	"geometry.sty", "xcolor.sty", "hyperref.sty",
+}
+
+// probeTimeout bounds the kpsewhich run checking for RequiredLaTeXFiles.
+const probeTimeout = 5 * time.Second
+
+// latexFilePackages maps files to the TeX Live package providing them, for
+// files whose package isn't named after them. MiKTeX uses the same names.
+var latexFilePackages = map[string]string{
+	"amsmath.sty":   "amsmath",
+	"amssymb.sty":   "amsfonts",
+	"amsthm.sty":    "amscls",
+	"array.sty":     "tools",
+	"calc.sty":      "tools",
+	"longtable.sty": "tools",
+	"multicol.sty":  "tools",
+	"color.sty":     "graphics",
+	"graphicx.sty":  "graphics",
+	"ifluatex.sty":  "iftex",
+	"ifxetex.sty":   "iftex",
+	"lmodern.sty":   "lm",
+	"ec-lmr10.tfm":  "lm",
+	"footnote.sty":  "mdwtools",
+	"pzdr.tfm":      "zapfding",
+	"scrartcl.cls":  "koma-script",
+	"scrbook.cls":   "koma-script",
+	"scrreprt.cls":  "koma-script",
+	"tikz.sty":      "pgf",
+	"xeCJK.sty":     "xecjk",
+}
+
+// MissingLaTeXPackagesError reports LaTeX files that aren't installed, with
+// the packages providing them.
+type MissingLaTeXPackagesError struct {
+	// Engine is set when the files were found missing before converting
+	Engine   string
+	Files    []string
+	Packages []string
+	// Distribution is TeXLive, MiKTeX, or "" if neither was found
+	Distribution string
+	Err          error
+}
+
+// NewMissingLaTeXPackagesError describes files missing from the TeX
+// installation of the engine at enginePath.
+func NewMissingLaTeXPackagesError(files []string, enginePath string, err error) *MissingLaTeXPackagesError {
+	return &MissingLaTeXPackagesError{
+		Files:        files,
+		Packages:     LaTeXPackages(files),
+		Distribution: DetectTeXDistribution(enginePath),
+		Err:          err,
+	}
+}
+
+func (e *MissingLaTeXPackagesError) Error() string {
+	msg := "LaTeX is missing "
+	if e.Engine != "" {
+		msg = e.Engine + " is installed, but LaTeX is missing "
+	}
+	msg += fmt.Sprintf("%s (%s %s); install %s with:\n  %s\nor rerun with --auto-install-packages",
+		strings.Join(e.Files, ", "), plural(len(e.Packages), "package", "packages"), strings.Join(e.Packages, ", "),
+		plural(len(e.Packages), "it", "them"), e.InstallCommand())
+	if e.Distribution == "" {
+		msg += "\n(a TeX Live installed from Debian or Ubuntu packages has no tlmgr: find the package to install with apt-file search " + e.Files[0] + ")"
+	}
+	return msg
+}
+
+func (e *MissingLaTeXPackagesError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKind classifies the error as an engine error.
+func (e *MissingLaTeXPackagesError) ErrorKind() internal.Kind {
+	return internal.KindEngine
+}
+
+// InstallCommand returns the command line installing the missing packages.
+func (e *MissingLaTeXPackagesError) InstallCommand() string {
+	return strings.Join(latexInstallCommand(e.Distribution, e.Packages), " ")
+}
+
+// LaTeXPackageFor returns the TeX package providing file: a known mapping,
+// or else the file's name without its extension, which is right for most
+// packages and classes.
+func LaTeXPackageFor(file string) string {
+	if pkg, ok := latexFilePackages[file]; ok {
+		return pkg
+	}
+	return strings.ToLower(strings.TrimSuffix(file, filepath.Ext(file)))
+}
+
+// LaTeXPackages returns the packages providing files, sorted and without duplicates.
+func LaTeXPackages(files []string) []string {
+	seen := map[string]bool{}
+	var packages []string
+	for _, file := range files {
+		if pkg := LaTeXPackageFor(file); !seen[pkg] {
+			seen[pkg] = true
+			packages = append(packages, pkg)
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// texTool returns the path of a TeX tool, preferring the one installed next
+// to the engine at enginePath.
+func texTool(name, enginePath string) (string, error) {
+	if enginePath != "" {
+		if path, err := SearchEngineInDirs(name, []string{filepath.Dir(enginePath)}); err == nil {
+			return path, nil
+		}
+	}
+	return LookupEngine(name)
+}
+
+// DetectTeXDistribution returns the distribution of the LaTeX engine at
+// enginePath (TeXLive or MiKTeX) from the package manager installed with it,
+// or on PATH; "" if neither is found.
+func DetectTeXDistribution(enginePath string) string {
+	for _, tool := range []struct{ name, distribution string }{
+		{"mpm", MiKTeX}, {"tlmgr", TeXLive},
+	} {
+		if _, err := texTool(tool.name, enginePath); err == nil {
+			return tool.distribution
+		}
+	}
+	return ""
+}
+
+// latexInstallCommand returns the command installing packages with the
+// distribution's package manager; TeX Live's if the distribution is unknown.
+func latexInstallCommand(distribution string, packages []string) []string {
+	if distribution == MiKTeX {
+		args := []string{"mpm"}
+		for _, pkg := range packages {
+			args = append(args, "--install="+pkg)
+		}
+		return args
+	}
+	return append([]string{"tlmgr", "install"}, packages...)
+}
+
+// InstallLaTeXPackages installs packages with the distribution's package
+// manager, writing its output to w.
+func InstallLaTeXPackages(distribution string, packages []string, w io.Writer) error {
+	if distribution == "" {
+		return fmt.Errorf("no TeX package manager (tlmgr or mpm) found; install %s yourself", strings.Join(packages, ", "))
+	}
+	args := latexInstallCommand(distribution, packages)
+	path, err := LookupEngine(args[0])
+	if err != nil {
+		return fmt.Errorf("%s not found: %w", args[0], err)
+	}
+
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w (a system-wide TeX installation may need administrator rights)", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// ProbeLaTeXPackages checks, with kpsewhich, that the TeX installation of
+// engine, at enginePath, has RequiredLaTeXFiles. It returns the missing
+// ones as a *MissingLaTeXPackagesError, or nil if all are found or
+// kpsewhich can't tell.
+func ProbeLaTeXPackages(engine PDFEngine, enginePath string) *MissingLaTeXPackagesError {
+	kpsewhich, err := texTool("kpsewhich", enginePath)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	// kpsewhich prints the path of each file it finds, and fails if any is
+	// missing. LaTeX's own article class tells whether it can find anything.
+	args := append([]string{"article.cls"}, RequiredLaTeXFiles...)
+	output, _ := exec.CommandContext(ctx, kpsewhich, args...).Output()
+	if ctx.Err() != nil {
+		return nil
+	}
+	found := map[string]bool{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			found[filepath.Base(line)] = true
+		}
+	}
+	if !found["article.cls"] {
+		return nil
+	}
+
+	var missing []string
+	for _, file := range RequiredLaTeXFiles {
+		if !found[file] {
+			missing = append(missing, file)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	missingErr := NewMissingLaTeXPackagesError(missing, enginePath, nil)
+	missingErr.Engine = engine.Name
+	return missingErr
+}
+
+// plural returns one if n is 1, else many.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
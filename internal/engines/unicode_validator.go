@@ -10,6 +10,26 @@ import (
 	"time"
 )
 
+// testUnicodeSupport runs the unicode test, after checking that a LaTeX
+// engine has the packages pandoc's template needs: otherwise the test would
+// fail, after seconds, for a reason it can't tell. When WeasyPrint fails the
+// test, its Python environment is diagnosed instead.
+func testUnicodeSupport(engine PDFEngine) *TestResult {
+	if IsLaTeXEngine(engine.Name) {
+		path, _ := LookupEngine(engine.Name)
+		if missing := ProbeLaTeXPackages(engine, path); missing != nil {
+			return &TestResult{ErrorMessage: missing.Error(), Problem: missing}
+		}
+	}
+	result := ValidateUnicodeSupport(engine)
+	if !result.Success && engine.Name == "weasyprint" {
+		if diagnosis := DiagnoseWeasyPrint(); diagnosis != nil {
+			return &TestResult{ErrorMessage: diagnosis.Error(), Problem: diagnosis}
+		}
+	}
+	return result
+}
+
 // ValidateUnicodeSupport tests if an engine can handle unicode/emoji content
 // Uses test-based detection by attempting conversion of sample unicode document
 // Returns TestResult with success/failure status
@@ -48,7 +68,7 @@ func ValidateUnicodeSupport(engine PDFEngine) *TestResult {
 	cmd := exec.CommandContext(ctx, "pandoc",
 		"--from", "markdown",
 		"--to", "pdf",
-		"--pdf-engine", engine.Name,
+		"--pdf-engine", engine.Command(),
 		"--output", testPDFFile,
 		testMDFile,
 	)
@@ -159,7 +179,7 @@ func ValidateEngineInstalled(engine PDFEngine) error {
 		return fmt.Errorf("engine '%s' is not installed", engine.Name)
 	}
 
-	// Double-check that engine is actually in PATH
-	_, err := exec.LookPath(engine.Name)
+	// Double-check that engine is actually in PATH, or at its install path
+	_, err := exec.LookPath(engine.Command())
 	return err
 }
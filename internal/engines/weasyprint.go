@@ -0,0 +1,178 @@
+package engines
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+)
+
+// WeasyPrint problems found by DiagnoseWeasyPrint.
+const (
+	// WeasyPrintNotInstalled: neither the command nor the Python module exists
+	WeasyPrintNotInstalled = "not-installed"
+	// WeasyPrintNotOnPath: the Python module is installed, its command isn't on PATH
+	WeasyPrintNotOnPath = "not-on-path"
+	// WeasyPrintBrokenInterpreter: the command's Python no longer exists
+	WeasyPrintBrokenInterpreter = "broken-interpreter"
+	// WeasyPrintMissingModule: the command's Python can't import a dependency
+	WeasyPrintMissingModule = "missing-module"
+	// WeasyPrintMissingLibrary: a system library (Pango and friends) is missing
+	WeasyPrintMissingLibrary = "missing-library"
+)
+
+var (
+	moduleNotFoundPattern = regexp.MustCompile(`ModuleNotFoundError: No module named '([^']+)'`)
+	importErrorPattern    = regexp.MustCompile(`ImportError: cannot import name '[^']+' from '([^']+)'`)
+	libraryPattern        = regexp.MustCompile(`(?:OSError|cannot load library)[^\n]*?'([^']*(?:pango|gobject|harfbuzz|fontconfig|glib|cairo)[^']*)'`)
+)
+
+// WeasyPrintError explains why WeasyPrint can't run and how to fix its
+// Python environment.
+type WeasyPrintError struct {
+	Problem string
+	// Path is the weasyprint command, if found
+	Path string
+	// Interpreter is the Python the command runs, if known
+	Interpreter string
+	// Detail is the missing module or library
+	Detail string
+}
+
+func (e *WeasyPrintError) Error() string {
+	python := e.Interpreter
+	if python == "" {
+		python = "python3"
+	}
+	switch e.Problem {
+	case WeasyPrintNotOnPath:
+		return "WeasyPrint is installed as a Python module, but its weasyprint command is not on PATH; " +
+			"add the directory pip installs commands to (the bin or Scripts folder under `" + python + " -m site --user-base`) to PATH, " +
+			"or install it with pipx install weasyprint"
+	case WeasyPrintBrokenInterpreter:
+		return fmt.Sprintf("the weasyprint command at %s runs %s, which no longer exists (a deleted or moved virtual environment, or an upgraded Python); "+
+			"reinstall it with pipx reinstall weasyprint, or recreate its virtual environment and pip install weasyprint", e.Path, e.Interpreter)
+	case WeasyPrintMissingModule:
+		return fmt.Sprintf("the weasyprint command at %s can't import the Python module '%s', so its environment is incomplete or mixes versions; "+
+			"reinstall WeasyPrint into the same environment with %s -m pip install --force-reinstall weasyprint (or pipx reinstall weasyprint)", e.Path, e.Detail, python)
+	case WeasyPrintMissingLibrary:
+		return fmt.Sprintf("WeasyPrint is installed, but can't load the system library '%s' it renders text with; install Pango: %s", e.Detail, pangoInstallHint(runtime.GOOS))
+	default:
+		return "WeasyPrint is not installed; install it with pipx install weasyprint, or pip install weasyprint in a virtual environment " +
+			"(see https://doc.courtbouillon.org/weasyprint/stable/first_steps.html)"
+	}
+}
+
+// ErrorKind classifies the error as an engine error.
+func (e *WeasyPrintError) ErrorKind() internal.Kind {
+	return internal.KindEngine
+}
+
+// pangoInstallHint returns how to install Pango on goos.
+func pangoInstallHint(goos string) string {
+	switch goos {
+	case "darwin":
+		return "brew install pango"
+	case "windows":
+		return "install GTK with MSYS2 (pacman -S mingw-w64-x86_64-pango) and add its bin folder to PATH"
+	default:
+		return "sudo apt-get install libpango-1.0-0 libpangoft2-1.0-0 on Debian/Ubuntu, or sudo dnf install pango on Fedora"
+	}
+}
+
+// DiagnoseWeasyPrint checks that WeasyPrint can run, telling a missing
+// installation from a broken Python environment. It returns nil if
+// weasyprint --version succeeds.
+func DiagnoseWeasyPrint() *WeasyPrintError {
+	path, err := LookupEngine("weasyprint")
+	if err != nil {
+		if pythonHasModule("weasyprint") {
+			return &WeasyPrintError{Problem: WeasyPrintNotOnPath}
+		}
+		return &WeasyPrintError{Problem: WeasyPrintNotInstalled}
+	}
+
+	interpreter := scriptInterpreter(path)
+	if filepath.IsAbs(interpreter) {
+		if _, err := os.Stat(interpreter); err != nil {
+			return &WeasyPrintError{Problem: WeasyPrintBrokenInterpreter, Path: path, Interpreter: interpreter}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if err == nil || ctx.Err() != nil {
+		return nil
+	}
+	return DiagnoseWeasyPrintOutput(path, string(output))
+}
+
+// DiagnoseWeasyPrintOutput recognizes a broken Python environment in the
+// output of a failed run of the weasyprint command at path, or returns nil.
+func DiagnoseWeasyPrintOutput(path, output string) *WeasyPrintError {
+	diagnosis := &WeasyPrintError{Path: path, Interpreter: scriptInterpreter(path)}
+	switch {
+	case strings.Contains(output, "bad interpreter"):
+		diagnosis.Problem = WeasyPrintBrokenInterpreter
+	case libraryPattern.MatchString(output):
+		diagnosis.Problem = WeasyPrintMissingLibrary
+		diagnosis.Detail = libraryPattern.FindStringSubmatch(output)[1]
+	case moduleNotFoundPattern.MatchString(output):
+		diagnosis.Problem = WeasyPrintMissingModule
+		diagnosis.Detail = moduleNotFoundPattern.FindStringSubmatch(output)[1]
+	case importErrorPattern.MatchString(output):
+		diagnosis.Problem = WeasyPrintMissingModule
+		diagnosis.Detail = importErrorPattern.FindStringSubmatch(output)[1]
+	default:
+		return nil
+	}
+	return diagnosis
+}
+
+// scriptInterpreter returns the interpreter named by the #! line of the
+// script at path, or "" for a binary (such as pip's Windows launchers).
+func scriptInterpreter(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return ""
+	}
+	// #!/usr/bin/env python3 runs python3 from PATH
+	if filepath.Base(fields[0]) == "env" && len(fields) > 1 {
+		return fields[len(fields)-1]
+	}
+	return fields[0]
+}
+
+// pythonHasModule reports whether the Python on PATH can import module.
+func pythonHasModule(module string) bool {
+	for _, python := range []string{"python3", "python"} {
+		path, err := exec.LookPath(python)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		err = exec.CommandContext(ctx, path, "-c", "import "+module).Run()
+		cancel()
+		return err == nil
+	}
+	return false
+}
@@ -3,15 +3,94 @@ package internal
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
-// Error codes used throughout veve-cli
+// Exit codes used throughout veve-cli, one per error Kind
 const (
 	ExitSuccess = 0
 	ExitError   = 1
 	ExitUsage   = 2
+	ExitInput   = 3
+	ExitTheme   = 4
+	ExitEngine  = 5
+	ExitNetwork = 6
+	ExitPandoc  = 7
+	ExitConfig  = 8
 )
 
+// Kind is the category of an error. It decides veve's exit code and is
+// reported as the error's category in JSON output.
+type Kind string
+
+// Error kinds
+const (
+	KindGeneral Kind = "error"
+	KindUsage   Kind = "usage"   // Invalid flags or arguments
+	KindInput   Kind = "input"   // Unreadable or invalid input: markdown, data, images
+	KindTheme   Kind = "theme"   // Missing, invalid, or incompatible theme
+	KindEngine  Kind = "engine"  // Missing or unusable PDF engine or tool
+	KindNetwork Kind = "network" // Failed download
+	KindPandoc  Kind = "pandoc"  // Missing pandoc, or a failed conversion
+	KindConfig  Kind = "config"  // Invalid configuration, or failing project hooks and filters
+)
+
+// ExitCode returns the exit code for errors of kind k.
+func (k Kind) ExitCode() int {
+	switch k {
+	case KindUsage:
+		return ExitUsage
+	case KindInput:
+		return ExitInput
+	case KindTheme:
+		return ExitTheme
+	case KindEngine:
+		return ExitEngine
+	case KindNetwork:
+		return ExitNetwork
+	case KindPandoc:
+		return ExitPandoc
+	case KindConfig:
+		return ExitConfig
+	}
+	return ExitError
+}
+
+// kinded is implemented by errors that know their kind, such as VeveError
+// and the errors WithKind returns.
+type kinded interface {
+	ErrorKind() Kind
+}
+
+// KindOf returns the kind of err: that of the first error in its chain that
+// knows it, or KindGeneral.
+func KindOf(err error) Kind {
+	var k kinded
+	if errors.As(err, &k) {
+		return k.ErrorKind()
+	}
+	return KindGeneral
+}
+
+// kindError gives an error a kind, keeping its message.
+type kindError struct {
+	kind Kind
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() error   { return e.err }
+func (e *kindError) ErrorKind() Kind { return e.kind }
+
+// WithKind returns err with kind, unless it already has one, keeping its
+// message. It returns nil for a nil err.
+func WithKind(err error, kind Kind) error {
+	if err == nil || KindOf(err) != KindGeneral {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
 // VeveError represents a veve-specific error with formatted output.
 type VeveError struct {
 	Command    string // The command that failed (e.g., "convert", "theme")
@@ -19,6 +98,8 @@ type VeveError struct {
 	Reason     string // The underlying reason for failure
 	Suggestion string // A helpful suggestion for the user
 	Err        error  // The underlying error (for logging)
+	Kind       Kind   // The error's category (default: that of Err, or KindGeneral)
+	Code       string // Identifies the error for scripts, e.g. "input-not-found" (default: the kind)
 }
 
 func (e *VeveError) Error() string {
@@ -33,6 +114,22 @@ func (e *VeveError) Unwrap() error {
 	return e.Err
 }
 
+// ErrorKind returns the error's kind: Kind, or else the kind of Err.
+func (e *VeveError) ErrorKind() Kind {
+	if e.Kind != "" {
+		return e.Kind
+	}
+	return KindOf(e.Err)
+}
+
+// ErrorCode returns Code, or else the error's kind.
+func (e *VeveError) ErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return string(e.ErrorKind())
+}
+
 // NewVeveError creates a new VeveError with the given parameters.
 func NewVeveError(command, action, reason, suggestion string, err error) *VeveError {
 	return &VeveError{
@@ -44,6 +141,72 @@ func NewVeveError(command, action, reason, suggestion string, err error) *VeveEr
 	}
 }
 
+// newKindError creates a VeveError of kind, identified by code.
+func newKindError(kind Kind, code, command, action, reason, suggestion string, err error) *VeveError {
+	e := NewVeveError(command, action, reason, suggestion, err)
+	e.Kind, e.Code = kind, code
+	return e
+}
+
+// InputError creates an error for unreadable or invalid input.
+func InputError(command, action, reason, suggestion string, err error) *VeveError {
+	return newKindError(KindInput, "", command, action, reason, suggestion, err)
+}
+
+// ThemeError creates an error for a missing, invalid, or incompatible theme.
+func ThemeError(command, action, reason, suggestion string, err error) *VeveError {
+	return newKindError(KindTheme, "", command, action, reason, suggestion, err)
+}
+
+// EngineError creates an error for a missing or unusable PDF engine or tool.
+func EngineError(command, action, reason, suggestion string, err error) *VeveError {
+	return newKindError(KindEngine, "", command, action, reason, suggestion, err)
+}
+
+// NetworkError creates an error for a failed download.
+func NetworkError(command, action, reason, suggestion string, err error) *VeveError {
+	return newKindError(KindNetwork, "", command, action, reason, suggestion, err)
+}
+
+// PandocError creates an error for missing pandoc or a failed conversion.
+func PandocError(command, action, reason, suggestion string, err error) *VeveError {
+	return newKindError(KindPandoc, "", command, action, reason, suggestion, err)
+}
+
+// ConfigError creates an error for invalid configuration, or a failing
+// project hook or filter.
+func ConfigError(command, action, reason, suggestion string, err error) *VeveError {
+	return newKindError(KindConfig, "", command, action, reason, suggestion, err)
+}
+
+// UsageError creates an error for invalid flags or arguments.
+func UsageError(command, action, reason, suggestion string, err error) *VeveError {
+	return newKindError(KindUsage, "", command, action, reason, suggestion, err)
+}
+
+// ErrorReport is the machine-readable form of an error, for JSON output.
+type ErrorReport struct {
+	Category   Kind   `json:"category"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Command    string `json:"command,omitempty"`
+	Action     string `json:"action,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Report returns the machine-readable form of err.
+func Report(err error) ErrorReport {
+	report := ErrorReport{Category: KindOf(err), Message: err.Error()}
+	report.Code = string(report.Category)
+	var ve *VeveError
+	if errors.As(err, &ve) {
+		report.Code = ve.ErrorCode()
+		report.Command, report.Action, report.Reason, report.Suggestion = ve.Command, ve.Action, ve.Reason, ve.Suggestion
+	}
+	return report
+}
+
 // IsVeveError checks if an error is a VeveError.
 func IsVeveError(err error) bool {
 	var ve *VeveError
@@ -54,7 +217,9 @@ func IsVeveError(err error) bool {
 
 // InputFileNotFound creates an error for missing input files.
 func InputFileNotFound(command string, filePath string) *VeveError {
-	return NewVeveError(
+	return newKindError(
+		KindInput,
+		"input-not-found",
 		command,
 		"read input file",
 		"file not found: "+filePath,
@@ -63,9 +228,52 @@ func InputFileNotFound(command string, filePath string) *VeveError {
 	)
 }
 
+// InputNotUTF8 creates an error for input in a legacy encoding, refused
+// under --strict.
+func InputNotUTF8(command, filePath, encoding string) *VeveError {
+	return newKindError(
+		KindInput,
+		"input-not-utf8",
+		command,
+		"read input file",
+		fmt.Sprintf("%s is not UTF-8 (it looks like %s)", filePath, encoding),
+		fmt.Sprintf("save it as UTF-8, e.g. iconv -f %s -t UTF-8, or drop --strict to have veve convert it", encoding),
+		nil,
+	)
+}
+
+// InputTooLarge creates an error for input over the --max-input-size limit.
+func InputTooLarge(command, filePath, limit string) *VeveError {
+	return newKindError(
+		KindInput,
+		"input-too-large",
+		command,
+		"read input file",
+		fmt.Sprintf("%s is larger than the %s input limit", filePath, limit),
+		"raise the limit with --max-input-size (0 for none), or split the document",
+		nil,
+	)
+}
+
+// TooManyRemoteImages creates an error for input referencing more remote
+// images than the --remote-images-max-count limit.
+func TooManyRemoteImages(command, filePath string, count, limit int) *VeveError {
+	return newKindError(
+		KindInput,
+		"too-many-remote-images",
+		command,
+		"download remote images",
+		fmt.Sprintf("%s references %d remote images, more than the limit of %d", filePath, count, limit),
+		"raise the limit with --remote-images-max-count (0 for none), or pass --enable-remote-images=false to leave them as links",
+		nil,
+	)
+}
+
 // ThemeNotFound creates an error for missing themes.
 func ThemeNotFound(command string, themeName string, availableThemes string) *VeveError {
-	return NewVeveError(
+	return newKindError(
+		KindTheme,
+		"theme-not-found",
 		command,
 		"apply theme",
 		fmt.Sprintf("theme not found: %s", themeName),
@@ -76,7 +284,9 @@ func ThemeNotFound(command string, themeName string, availableThemes string) *Ve
 
 // PandocNotFound creates an error for missing Pandoc installation.
 func PandocNotFound() *VeveError {
-	return NewVeveError(
+	return newKindError(
+		KindPandoc,
+		"pandoc-not-found",
 		"main",
 		"initialize converter",
 		"pandoc not found in PATH",
@@ -85,9 +295,50 @@ func PandocNotFound() *VeveError {
 	)
 }
 
+// PopplerNotFound creates an error for a missing poppler tool (pdftotext, pdftoppm).
+func PopplerNotFound(command, tool string) *VeveError {
+	return newKindError(
+		KindEngine,
+		"poppler-not-found",
+		command,
+		"read PDF",
+		fmt.Sprintf("%s not found in PATH", tool),
+		"install poppler (macOS: brew install poppler; Ubuntu/Debian: sudo apt-get install poppler-utils)",
+		nil,
+	)
+}
+
+// SignerNotFound creates an error for a missing signing tool (gpg, minisign).
+func SignerNotFound(tool string) *VeveError {
+	return newKindError(
+		KindEngine,
+		"signer-not-found",
+		"convert",
+		"sign output",
+		fmt.Sprintf("%s not found in PATH", tool),
+		fmt.Sprintf("install %s, or sign with the other tool (--sign=gpg or --sign=minisign)", tool),
+		nil,
+	)
+}
+
+// SigningFailed creates an error for a signing tool that couldn't sign the output.
+func SigningFailed(tool, outputFile string, err error) *VeveError {
+	return newKindError(
+		KindEngine,
+		"signing-failed",
+		"convert",
+		"sign output",
+		fmt.Sprintf("%s could not sign %s: %v", tool, outputFile, err),
+		"check the key given with --sign-key (or sign_key in veve.toml) and that it is unlocked",
+		err,
+	)
+}
+
 // ConversionFailed creates an error for conversion failures.
 func ConversionFailed(command, inputFile string, err error) *VeveError {
-	return NewVeveError(
+	return newKindError(
+		KindPandoc,
+		"conversion-failed",
 		command,
 		"convert markdown",
 		fmt.Sprintf("pandoc conversion failed for %s", inputFile),
@@ -98,7 +349,9 @@ func ConversionFailed(command, inputFile string, err error) *VeveError {
 
 // ConfigLoadFailed creates an error for configuration loading failures.
 func ConfigLoadFailed(filePath string, err error) *VeveError {
-	return NewVeveError(
+	return newKindError(
+		KindConfig,
+		"config-invalid",
 		"main",
 		"load configuration",
 		fmt.Sprintf("failed to load config file: %s", filePath),
@@ -109,7 +362,9 @@ func ConfigLoadFailed(filePath string, err error) *VeveError {
 
 // PDFEngineNotFound creates an error for missing PDF engine.
 func PDFEngineNotFound(engineName string) *VeveError {
-	return NewVeveError(
+	return newKindError(
+		KindEngine,
+		"engine-not-found",
 		"convert",
 		"select PDF engine",
 		fmt.Sprintf("engine '%s' not found in PATH", engineName),
@@ -123,7 +378,9 @@ func PDFEngineNotFound(engineName string) *VeveError {
 func UnicodeNotSupported(engineName, platform string) *VeveError {
 	instructions := getPlatformInstallInstructions(engineName, platform)
 
-	return NewVeveError(
+	return newKindError(
+		KindEngine,
+		"engine-no-unicode",
 		"convert",
 		"render unicode/emoji",
 		fmt.Sprintf("engine '%s' does not support unicode characters", engineName),
@@ -132,9 +389,24 @@ func UnicodeNotSupported(engineName, platform string) *VeveError {
 	)
 }
 
+// ThemeEngineIncompatible creates an error for a theme that does not support the selected engine.
+func ThemeEngineIncompatible(themeName, engineName string, supported []string) *VeveError {
+	return newKindError(
+		KindTheme,
+		"theme-engine-incompatible",
+		"convert",
+		"apply theme",
+		fmt.Sprintf("theme '%s' supports only %s, but engine '%s' was selected", themeName, strings.Join(supported, ", "), engineName),
+		fmt.Sprintf("use --engine %s, or choose a theme that supports %s (e.g. --theme default)", supported[0], engineName),
+		nil,
+	)
+}
+
 // NoUnicodeEngineAvailable creates an error when no unicode-capable engine is found.
 func NoUnicodeEngineAvailable() *VeveError {
-	return NewVeveError(
+	return newKindError(
+		KindEngine,
+		"no-engine",
 		"convert",
 		"select PDF engine",
 		"no unicode-capable PDF engine found in PATH",
@@ -143,6 +415,61 @@ func NoUnicodeEngineAvailable() *VeveError {
 	)
 }
 
+// NoEngineDockerAvailable creates an error when no PDF engine is installed but
+// Docker could run one in a container.
+func NoEngineDockerAvailable(image string) *VeveError {
+	return newKindError(
+		KindEngine,
+		"no-engine",
+		"convert",
+		"select PDF engine",
+		"no PDF engine is installed, but Docker is available",
+		fmt.Sprintf("convert in a container with --engine docker (pandoc and xelatex from %s), or install xelatex or weasyprint", image),
+		nil,
+	)
+}
+
+// PandocNotFoundDockerAvailable creates an error for missing Pandoc when Docker
+// could run it in a container.
+func PandocNotFoundDockerAvailable(image string) *VeveError {
+	return newKindError(
+		KindPandoc,
+		"pandoc-not-found",
+		"main",
+		"initialize converter",
+		"pandoc not found in PATH, but Docker is available",
+		fmt.Sprintf("convert in a container with --engine docker (pandoc and xelatex from %s), or install pandoc (https://pandoc.org/installing.html)", image),
+		nil,
+	)
+}
+
+// DockerNotAvailable creates an error for a docker engine when Docker is not
+// installed or its daemon is not running.
+func DockerNotAvailable(engineName string) *VeveError {
+	return newKindError(
+		KindEngine,
+		"docker-not-available",
+		"convert",
+		"select PDF engine",
+		fmt.Sprintf("engine '%s' needs Docker, which is not installed or not running", engineName),
+		"install Docker (https://docs.docker.com/get-docker/) and start it, or install a local engine: xelatex or weasyprint",
+		nil,
+	)
+}
+
+// HookFailed creates an error for a failed pre-convert or post-convert hook.
+func HookFailed(stage, command string, err error) *VeveError {
+	return newKindError(
+		KindConfig,
+		"hook-failed",
+		"convert",
+		"run "+stage+" hook",
+		fmt.Sprintf("'%s': %v", command, err),
+		"fix the hook in .veve.yaml, or skip hooks with --no-hooks",
+		err,
+	)
+}
+
 // getPlatformInstallInstructions returns platform-specific install instructions
 func getPlatformInstallInstructions(engineName, platform string) string {
 	instructions := map[string]map[string]string{
@@ -0,0 +1,91 @@
+// Package filelock provides advisory file locks and atomic file writes for the
+// state veve processes share (caches and indexes under the user cache
+// directory), so parallel invocations do not corrupt it.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTimeout is how long Lock waits for another process to release a lock.
+const DefaultTimeout = time.Minute
+
+// pollInterval is how often Lock retries a held lock.
+const pollInterval = 25 * time.Millisecond
+
+// errLocked is returned by tryLock when another process holds the lock.
+var errLocked = errors.New("file is locked")
+
+// Lock is an exclusive advisory lock held on a lock file. The operating
+// system releases it if the process exits without unlocking.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire locks path, creating the lock file and its directory if needed, and
+// waits up to timeout for another process holding it.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(file)
+		if err == nil {
+			return &Lock{file: file}, nil
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			file.Close()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("timed out after %s waiting for lock %s", timeout, path)
+			}
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Unlock releases the lock. The lock file is left in place, since removing it
+// would race with a process about to lock it.
+func (l *Lock) Unlock() error {
+	if l == nil {
+		return nil
+	}
+	err := unlock(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// WriteFile writes data to path atomically: readers, locked or not, see either
+// the old content or the new, never a partial file.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,71 @@
+// Package hooks runs the external commands a project configures around each
+// conversion (for example a prose linter before, or a metadata tool after),
+// so teams can extend the pipeline without changing veve.
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/madstone-tech/veve-cli/internal/shell"
+)
+
+// Hook stages, passed to commands as VEVE_HOOK.
+const (
+	PreConvert  = "pre-convert"
+	PostConvert = "post-convert"
+)
+
+// Conversion describes the conversion a hook runs for. Its fields are passed
+// to the commands as environment variables.
+type Conversion struct {
+	Input   string // Input file, VEVE_INPUT (empty when reading stdin)
+	Output  string // Output PDF, VEVE_OUTPUT (empty when writing stdout)
+	Theme   string // Theme name or path, VEVE_THEME
+	Engine  string // PDF engine, VEVE_ENGINE (empty before selection)
+	Project string // Project root, VEVE_PROJECT_ROOT
+}
+
+// Env returns the environment variables for a hook at stage.
+func (c Conversion) Env(stage string) []string {
+	return []string{
+		"VEVE_HOOK=" + stage,
+		"VEVE_INPUT=" + c.Input,
+		"VEVE_OUTPUT=" + c.Output,
+		"VEVE_THEME=" + c.Theme,
+		"VEVE_ENGINE=" + c.Engine,
+		"VEVE_PROJECT_ROOT=" + c.Project,
+	}
+}
+
+// Error reports a hook command that failed.
+type Error struct {
+	Stage   string
+	Command string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s hook '%s' failed: %v", e.Stage, e.Command, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Run runs commands in order through the shell, in the project root, with the
+// conversion in the environment. It stops at the first command that fails.
+func Run(stage string, commands []string, conversion Conversion, stdout, stderr io.Writer) error {
+	for _, command := range commands {
+		cmd := shell.Command(command)
+		cmd.Dir = conversion.Project
+		cmd.Env = append(os.Environ(), conversion.Env(stage)...)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			return &Error{Stage: stage, Command: command, Err: err}
+		}
+	}
+	return nil
+}
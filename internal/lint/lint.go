@@ -0,0 +1,237 @@
+// Package lint checks markdown sources for common style problems.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Issue is a lint problem with its 1-based position.
+type Issue struct {
+	Rule    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Rule, i.Message)
+}
+
+// Rule is a lint rule.
+type Rule struct {
+	Name        string
+	Description string
+	check       func(d *document) []Issue
+}
+
+// rules are the available rules, in the order they are listed.
+var rules = []Rule{
+	{"heading-increment", "heading levels increase by one at a time", checkHeadingIncrement},
+	{"trailing-spaces", "lines do not end with spaces, other than a two-space line break", checkTrailingSpaces},
+	{"bare-urls", "URLs are written as links or <autolinks>", checkBareURLs},
+}
+
+// Rules returns the available rules.
+func Rules() []Rule {
+	return append([]Rule(nil), rules...)
+}
+
+// ValidateRuleNames checks that every name is a known rule.
+func ValidateRuleNames(names []string) error {
+	for _, name := range names {
+		if !isRule(name) {
+			known := make([]string, len(rules))
+			for i, rule := range rules {
+				known[i] = rule.Name
+			}
+			return fmt.Errorf("unknown lint rule %q: available rules are %s", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+func isRule(name string) bool {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks content with every rule not in disabled and returns the issues
+// sorted by position.
+func Lint(content string, disabled []string) []Issue {
+	d := parse(content)
+
+	var issues []Issue
+	for _, rule := range rules {
+		if slices.Contains(disabled, rule.Name) {
+			continue
+		}
+		issues = append(issues, rule.check(d)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+	return issues
+}
+
+// document is a parsed markdown source.
+type document struct {
+	source     []byte
+	root       ast.Node
+	lineStarts []int        // offset of each line
+	codeLines  map[int]bool // 0-based lines inside code and HTML blocks
+}
+
+var markdownParser = goldmark.New().Parser()
+
+func parse(content string) *document {
+	d := &document{
+		source:     []byte(content),
+		lineStarts: []int{0},
+		codeLines:  map[int]bool{},
+	}
+	for i, c := range content {
+		if c == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+	d.root = markdownParser.Parse(text.NewReader(d.source))
+
+	_ = ast.Walk(d.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindFencedCodeBlock, ast.KindCodeBlock, ast.KindHTMLBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				d.codeLines[d.line(lines.At(i).Start)] = true
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return d
+}
+
+// line returns the 0-based line of offset.
+func (d *document) line(offset int) int {
+	return sort.Search(len(d.lineStarts), func(i int) bool { return d.lineStarts[i] > offset }) - 1
+}
+
+// position returns the 1-based line and column of offset.
+func (d *document) position(offset int) (int, int) {
+	line := d.line(offset)
+	return line + 1, offset - d.lineStarts[line] + 1
+}
+
+func checkHeadingIncrement(d *document) []Issue {
+	var issues []Issue
+	previous := 0
+	_ = ast.Walk(d.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if previous > 0 && heading.Level > previous+1 {
+			line, column := headingPosition(d, heading)
+			issues = append(issues, Issue{
+				Rule:    "heading-increment",
+				Line:    line,
+				Column:  column,
+				Message: fmt.Sprintf("heading level %d follows level %d; expected level %d or lower", heading.Level, previous, previous+1),
+			})
+		}
+		previous = heading.Level
+		return ast.WalkSkipChildren, nil
+	})
+	return issues
+}
+
+// headingPosition returns the position of a heading's first line.
+func headingPosition(d *document, heading *ast.Heading) (int, int) {
+	if heading.Lines().Len() == 0 {
+		return 1, 1
+	}
+	line := d.line(heading.Lines().At(0).Start)
+	return line + 1, 1
+}
+
+func checkTrailingSpaces(d *document) []Issue {
+	var issues []Issue
+	content := string(d.source)
+	for i, start := range d.lineStarts {
+		if d.codeLines[i] {
+			continue
+		}
+		end := len(content)
+		if i+1 < len(d.lineStarts) {
+			end = d.lineStarts[i+1] - 1
+		}
+		line := strings.TrimSuffix(content[start:end], "\r")
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed == line || trimmed == "" {
+			continue
+		}
+		// Exactly two spaces after text is a hard line break
+		if strings.HasSuffix(line, "  ") && len(line)-len(trimmed) == 2 && !strings.Contains(line[len(trimmed):], "\t") {
+			continue
+		}
+		issues = append(issues, Issue{
+			Rule:    "trailing-spaces",
+			Line:    i + 1,
+			Column:  len(trimmed) + 1,
+			Message: "trailing whitespace",
+		})
+	}
+	return issues
+}
+
+// bareURLPattern matches http(s) URLs in text.
+var bareURLPattern = regexp.MustCompile(`https?://[^\s<>()\[\]]+[^\s<>()\[\].,;:!?'"]`)
+
+func checkBareURLs(d *document) []Issue {
+	var issues []Issue
+	_ = ast.Walk(d.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindLink, ast.KindAutoLink, ast.KindCodeSpan, ast.KindImage, ast.KindRawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		textNode, ok := n.(*ast.Text)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		segment := textNode.Segment
+		value := string(segment.Value(d.source))
+		for _, loc := range bareURLPattern.FindAllStringIndex(value, -1) {
+			line, column := d.position(segment.Start + loc[0])
+			issues = append(issues, Issue{
+				Rule:    "bare-urls",
+				Line:    line,
+				Column:  column,
+				Message: fmt.Sprintf("bare URL %s is not a link; write <%s>", value[loc[0]:loc[1]], value[loc[0]:loc[1]]),
+			})
+		}
+		return ast.WalkContinue, nil
+	})
+	return issues
+}
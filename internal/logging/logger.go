@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,12 +20,35 @@ const (
 	LevelDebug
 )
 
-// Logger handles all logging for veve-cli.
+// Category groups warnings in the summary printed at the end of a run.
+type Category string
+
+// Warning categories
+const (
+	CategoryGeneral Category = "general"
+	CategoryInput   Category = "input"   // Input read in a legacy encoding, unresolved links
+	CategoryImages  Category = "images"  // Images that failed to download
+	CategoryTheme   Category = "theme"   // Theme problems and rules an engine ignored
+	CategoryEngine  Category = "engine"  // Engine substitutions and fallbacks
+	CategoryCleanup Category = "cleanup" // Temporary files that could not be removed
+)
+
+// Warning is a warning collected during a run.
+type Warning struct {
+	Category Category `json:"category"`
+	Message  string   `json:"message"`
+}
+
+// Logger handles all logging for veve-cli. Warnings are collected rather
+// than printed, for a summary at the end of the run (see WriteSummary).
 type Logger struct {
 	level     Level
 	out       io.Writer
 	errOut    io.Writer
 	timestamp bool
+
+	mu       sync.Mutex
+	warnings []Warning
 }
 
 // NewLogger creates a new logger with the given level.
@@ -58,10 +83,55 @@ func (l *Logger) Error(msg string, args ...interface{}) {
 	}
 }
 
-// Warn logs a warning message.
+// Warn collects a general warning.
 func (l *Logger) Warn(msg string, args ...interface{}) {
-	if l.level >= LevelWarn {
-		fmt.Fprintf(l.out, "[WARN] "+msg+"\n", args...)
+	l.WarnIn(CategoryGeneral, msg, args...)
+}
+
+// WarnIn collects a warning in category. A warning already collected is
+// not collected again.
+func (l *Logger) WarnIn(category Category, msg string, args ...interface{}) {
+	warning := Warning{Category: category, Message: strings.TrimSpace(fmt.Sprintf(msg, args...))}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, w := range l.warnings {
+		if w == warning {
+			return
+		}
+	}
+	l.warnings = append(l.warnings, warning)
+}
+
+// Warnings returns the warnings collected so far, in order.
+func (l *Logger) Warnings() []Warning {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Warning(nil), l.warnings...)
+}
+
+// WriteSummary writes the collected warnings to w, grouped by category in
+// the order their first warning came, unless the logger is quiet.
+func (l *Logger) WriteSummary(w io.Writer) {
+	warnings := l.Warnings()
+	if len(warnings) == 0 || l.level < LevelWarn {
+		return
+	}
+
+	var categories []Category
+	byCategory := make(map[Category][]string)
+	for _, warning := range warnings {
+		if _, ok := byCategory[warning.Category]; !ok {
+			categories = append(categories, warning.Category)
+		}
+		byCategory[warning.Category] = append(byCategory[warning.Category], warning.Message)
+	}
+
+	fmt.Fprintf(w, "\n%d warning(s):\n", len(warnings))
+	for _, category := range categories {
+		fmt.Fprintf(w, "  %s:\n", category)
+		for _, msg := range byCategory[category] {
+			fmt.Fprintf(w, "    - %s\n", strings.ReplaceAll(msg, "\n", "\n      "))
+		}
 	}
 }
 
@@ -100,11 +170,21 @@ func Error(msg string, args ...interface{}) {
 	globalLogger.Error(msg, args...)
 }
 
-// Warn logs a warning message to the global logger.
+// Warn collects a general warning in the global logger.
 func Warn(msg string, args ...interface{}) {
 	globalLogger.Warn(msg, args...)
 }
 
+// WarnIn collects a warning in category in the global logger.
+func WarnIn(category Category, msg string, args ...interface{}) {
+	globalLogger.WarnIn(category, msg, args...)
+}
+
+// Warnings returns the warnings the global logger collected.
+func Warnings() []Warning {
+	return globalLogger.Warnings()
+}
+
 // Info logs an info message to the global logger.
 func Info(msg string, args ...interface{}) {
 	globalLogger.Info(msg, args...)
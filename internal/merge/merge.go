@@ -0,0 +1,218 @@
+// Package merge renders a markdown document as a Go template once per data
+// record (a mail merge), for letters, invoices, and similar documents.
+package merge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Record is one entry of a data file.
+type Record = map[string]any
+
+// LoadRecords reads the records in a YAML or JSON data file: a list of
+// mappings, or a single mapping for one record.
+func LoadRecords(path string) ([]Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(node.Content) == 0 {
+		return nil, fmt.Errorf("%s has no records", path)
+	}
+
+	var records []Record
+	switch root := node.Content[0]; root.Kind {
+	case yaml.SequenceNode:
+		if err := root.Decode(&records); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: records must be mappings: %w", path, err)
+		}
+	case yaml.MappingNode:
+		var record Record
+		if err := root.Decode(&record); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		records = []Record{record}
+	default:
+		return nil, fmt.Errorf("%s must contain a list of records or a single record", path)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no records", path)
+	}
+	return records, nil
+}
+
+// Parse parses text as a template with the merge functions. Executing it
+// fails on fields a record doesn't have, rather than printing "<no value>".
+func Parse(name, text string) (*template.Template, error) {
+	t, err := template.New(filepath.Base(name)).Funcs(Funcs()).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return t, nil
+}
+
+// Render executes t for record, the index-th (from 1) in its data file.
+func Render(t *template.Template, record Record, index int) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, record); err != nil {
+		return "", fmt.Errorf("record %d: %w", index, err)
+	}
+	return buf.String(), nil
+}
+
+// StripFrontMatter removes a YAML front matter block from a rendered
+// document, for records merged after the first, whose metadata would
+// otherwise become part of the body.
+func StripFrontMatter(content string) string {
+	if !strings.HasPrefix(content, "---\n") && !strings.HasPrefix(content, "---\r\n") {
+		return content
+	}
+	lines := strings.SplitAfter(content, "\n")
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		offset += len(line)
+		if trimmed := strings.TrimRight(line, " \t\r\n"); trimmed == "---" || trimmed == "..." {
+			return content[offset:]
+		}
+	}
+	return content
+}
+
+// Funcs returns the functions available to merge templates, besides Go's
+// builtins:
+//
+//	money 1234.5              "1,234.50"
+//	add 2 3, mul 2 1.5        arithmetic on numbers from the data
+//	sum .items "amount"       the sum of a field over a list of mappings
+//	sum .items "qty" "price"  the sum of the fields' products
+//	default "n/a" .x          .x, or "n/a" if it is empty (a missing field is an error)
+//	upper, lower              change case
+//	today "2006-01-02"        today's date in a Go time layout
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"money":   money,
+		"add":     func(a, b any) (float64, error) { return arithmetic(a, b, func(x, y float64) float64 { return x + y }) },
+		"mul":     func(a, b any) (float64, error) { return arithmetic(a, b, func(x, y float64) float64 { return x * y }) },
+		"sum":     sum,
+		"default": defaultValue,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"today":   func(layout string) string { return time.Now().Format(layout) },
+	}
+}
+
+// number converts a value from a data file to a float.
+func number(v any) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(n), ",", ""), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", n)
+		}
+		return f, nil
+	case nil:
+		return 0, errors.New("missing number")
+	default:
+		return 0, fmt.Errorf("%v is not a number", v)
+	}
+}
+
+// arithmetic applies op to two numbers.
+func arithmetic(a, b any, op func(x, y float64) float64) (float64, error) {
+	x, err := number(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := number(b)
+	if err != nil {
+		return 0, err
+	}
+	return op(x, y), nil
+}
+
+// money formats a number with two decimals and thousands separators.
+func money(v any) (string, error) {
+	f, err := number(v)
+	if err != nil {
+		return "", err
+	}
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, cents, _ := strings.Cut(s, ".")
+	var sb strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(digit)
+	}
+	return sign + sb.String() + "." + cents, nil
+}
+
+// sum adds, over a list of mappings, the product of fields (usually one
+// field, or a quantity and a price).
+func sum(items any, fields ...string) (float64, error) {
+	list, ok := items.([]any)
+	if !ok {
+		return 0, fmt.Errorf("sum needs a list, not %T", items)
+	}
+	if len(fields) == 0 {
+		return 0, errors.New("sum needs a field name")
+	}
+	total := 0.0
+	for i, item := range list {
+		mapping, ok := item.(map[string]any)
+		if !ok {
+			return 0, fmt.Errorf("sum: item %d is not a mapping", i+1)
+		}
+		product := 1.0
+		for _, field := range fields {
+			f, err := number(mapping[field])
+			if err != nil {
+				return 0, fmt.Errorf("sum: item %d %s: %w", i+1, field, err)
+			}
+			product *= f
+		}
+		total += product
+	}
+	return total, nil
+}
+
+// defaultValue returns value, or fallback if value is empty.
+func defaultValue(fallback, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return fallback
+	case string:
+		if v == "" {
+			return fallback
+		}
+	}
+	return value
+}
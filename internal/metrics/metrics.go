@@ -0,0 +1,167 @@
+// Package metrics keeps an opt-in log of conversions (duration, engine, input
+// size, image counts) in the user's data directory, for tracking build
+// performance over time. The log is strictly local: veve never sends it
+// anywhere.
+package metrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/filelock"
+)
+
+// FileName is the name of the metrics log in the data directory.
+const FileName = "metrics.jsonl"
+
+// Record is one conversion in the metrics log.
+type Record struct {
+	Time         time.Time     `json:"time"`
+	Input        string        `json:"input"`
+	Engine       string        `json:"engine,omitempty"`
+	Theme        string        `json:"theme,omitempty"`
+	Duration     time.Duration `json:"duration_ns"`
+	InputBytes   int64         `json:"input_bytes"`
+	OutputBytes  int64         `json:"output_bytes,omitempty"`
+	Images       int           `json:"images"`
+	RemoteImages int           `json:"remote_images"`
+	FailedImages int           `json:"failed_images,omitempty"`
+	CachedImages int           `json:"cached_images,omitempty"` // Remote images served from the image cache
+	Checksum     string        `json:"checksum,omitempty"`      // With --checksum: "<algorithm>:<hex>" of the output
+	Signature    string        `json:"signature,omitempty"`     // With --sign: the path of the output's detached signature
+	Error        string        `json:"error,omitempty"`
+}
+
+// Append adds record to the log at path, creating it if needed. The log is
+// locked while it is written, so parallel conversions don't interleave lines.
+func Append(path string, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics record: %w", err)
+	}
+
+	lock, err := filelock.Acquire(path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write metrics log: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns the records in the log at path, oldest first. A missing log has
+// no records; lines that cannot be parsed (e.g. from a newer veve) are skipped.
+func Read(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metrics log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return records, fmt.Errorf("failed to read metrics log: %w", err)
+	}
+	return records, nil
+}
+
+// Summary aggregates the records of one group.
+type Summary struct {
+	Group       string        `json:"group"`
+	Conversions int           `json:"conversions"`
+	Failed      int           `json:"failed"`
+	Median      time.Duration `json:"median_ns"`
+	P95         time.Duration `json:"p95_ns"`
+	InputBytes  int64         `json:"input_bytes"`
+	Images      int           `json:"images"`
+}
+
+// ByDay groups records by their local date.
+func ByDay(record Record) string {
+	return record.Time.Local().Format("2006-01-02")
+}
+
+// ByEngine groups records by engine.
+func ByEngine(record Record) string {
+	if record.Engine == "" {
+		return "(none)"
+	}
+	return record.Engine
+}
+
+// Summarize aggregates records by group, in group order. Times are of the
+// successful conversions; input sizes and images are totals.
+func Summarize(records []Record, group func(Record) string) []Summary {
+	summaries := make(map[string]*Summary)
+	durations := make(map[string][]time.Duration)
+	for _, record := range records {
+		key := group(record)
+		summary, ok := summaries[key]
+		if !ok {
+			summary = &Summary{Group: key}
+			summaries[key] = summary
+		}
+		summary.Conversions++
+		summary.InputBytes += record.InputBytes
+		summary.Images += record.Images
+		if record.Error != "" {
+			summary.Failed++
+			continue
+		}
+		durations[key] = append(durations[key], record.Duration)
+	}
+
+	result := make([]Summary, 0, len(summaries))
+	for key, summary := range summaries {
+		times := durations[key]
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		summary.Median = percentile(times, 50)
+		summary.P95 = percentile(times, 95)
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Group < result[j].Group })
+	return result
+}
+
+// percentile returns the p-th percentile of sorted times (nearest rank), or
+// 0 if there are none.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,76 @@
+package pdfdiff
+
+import (
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// Options controls how text is compared.
+type Options struct {
+	// IgnoreWhitespace collapses runs of spaces and drops blank lines, so
+	// small layout shifts between engine versions are not reported
+	IgnoreWhitespace bool
+}
+
+// Result is the comparison of two PDFs.
+type Result struct {
+	OldPages     int
+	NewPages     int
+	OldText      []string // Text of each page of the old PDF
+	NewText      []string // Text of each page of the new PDF
+	TextDiff     string   // Unified diff of the page text ("" if equal)
+	ChangedLines int      // Lines removed plus lines added
+}
+
+// Changed reports whether the page count or text differs.
+func (r *Result) Changed() bool {
+	return r.OldPages != r.NewPages || r.TextDiff != ""
+}
+
+// Compare compares the page count and text of two PDFs. oldName and newName
+// label the diff.
+func Compare(oldPDF, newPDF, oldName, newName string, opts Options) (*Result, error) {
+	oldText, err := ExtractText(oldPDF)
+	if err != nil {
+		return nil, err
+	}
+	newText, err := ExtractText(newPDF)
+	if err != nil {
+		return nil, err
+	}
+
+	oldLines, newLines := Lines(oldText), Lines(newText)
+	if opts.IgnoreWhitespace {
+		oldLines, newLines = normalizeWhitespace(oldLines), normalizeWhitespace(newLines)
+	}
+
+	ops := diffLines(oldLines, newLines)
+	changed := 0
+	for _, o := range ops {
+		if o.kind != opEqual {
+			changed++
+		}
+	}
+
+	return &Result{
+		OldPages:     len(oldText),
+		NewPages:     len(newText),
+		OldText:      oldText,
+		NewText:      newText,
+		TextDiff:     unifiedDiff(oldName, newName, ops, diffContext),
+		ChangedLines: changed,
+	}, nil
+}
+
+// normalizeWhitespace collapses spaces within lines and drops blank lines.
+func normalizeWhitespace(lines []string) []string {
+	var out []string
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package pdfdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells bounds the memory used by the line diff (len(a) * len(b)).
+// Inputs beyond it are compared after trimming the common prefix and suffix,
+// and reported as a single replaced block if still too large.
+const maxDiffCells = 25_000_000
+
+// opKind is the kind of a diff operation.
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+// op is one line of a diff.
+type op struct {
+	kind opKind
+	line string
+	a, b int // 0-based line numbers in a and b
+}
+
+// diffLines returns the edit script turning a into b, from the longest common
+// subsequence of lines.
+func diffLines(a, b []string) []op {
+	// Trim the common prefix and suffix, which is most of a typical document
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{opEqual, a[i], i, i})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix)...)
+	for i := 0; i < suffix; i++ {
+		ai, bi := len(a)-suffix+i, len(b)-suffix+i
+		ops = append(ops, op{opEqual, a[ai], ai, bi})
+	}
+	return ops
+}
+
+// diffMiddle diffs the differing middle of two inputs that start at line offset.
+func diffMiddle(a, b []string, offset int) []op {
+	var ops []op
+	if len(a)*len(b) > maxDiffCells {
+		for i, line := range a {
+			ops = append(ops, op{opDelete, line, offset + i, offset})
+		}
+		for j, line := range b {
+			ops = append(ops, op{opInsert, line, offset + len(a), offset + j})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{opEqual, a[i], offset + i, offset + j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{opDelete, a[i], offset + i, offset + j})
+			i++
+		default:
+			ops = append(ops, op{opInsert, b[j], offset + i, offset + j})
+			j++
+		}
+	}
+	return ops
+}
+
+// UnifiedDiff returns a unified diff of a and b with context lines around each
+// change, or "" if they are equal.
+func UnifiedDiff(aName, bName string, a, b []string, context int) string {
+	return unifiedDiff(aName, bName, diffLines(a, b), context)
+}
+
+// unifiedDiff formats an edit script as a unified diff.
+func unifiedDiff(aName, bName string, ops []op, context int) string {
+	var sb strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk until context*2 equal lines separate it from the next change
+		first := max(0, start-context)
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+		}
+		writeHunk(&sb, ops[first:end])
+		start = end
+	}
+	return sb.String()
+}
+
+// writeHunk writes one hunk with its @@ header.
+func writeHunk(sb *strings.Builder, ops []op) {
+	aCount, bCount := 0, 0
+	for _, o := range ops {
+		if o.kind != opInsert {
+			aCount++
+		}
+		if o.kind != opDelete {
+			bCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(ops[0].a, aCount), hunkRange(ops[0].b, bCount))
+	for _, o := range ops {
+		fmt.Fprintf(sb, "%c%s\n", o.kind, o.line)
+	}
+}
+
+// hunkRange formats a hunk's start line and length, as diff -u does.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
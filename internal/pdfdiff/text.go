@@ -0,0 +1,69 @@
+// Package pdfdiff compares rendered PDFs by page count, extracted text, and
+// rasterized pages. It uses the poppler command-line tools.
+package pdfdiff
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// MissingToolError reports that a poppler tool is not installed.
+type MissingToolError struct {
+	Tool string
+}
+
+func (e *MissingToolError) Error() string {
+	return e.Tool + " not found in PATH"
+}
+
+// ExtractText returns the text of each page of a PDF, using pdftotext with its
+// layout mode so columns and indentation survive. Trailing spaces are removed.
+func ExtractText(pdfPath string) ([]string, error) {
+	tool, err := exec.LookPath("pdftotext")
+	if err != nil {
+		return nil, &MissingToolError{Tool: "pdftotext"}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(tool, "-layout", "-enc", "UTF-8", pdfPath, "-")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to extract text from %s: %w: %s", pdfPath, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return splitPages(stdout.String()), nil
+}
+
+// splitPages splits pdftotext output, which ends every page with a form feed.
+func splitPages(text string) []string {
+	text = strings.TrimSuffix(text, "\f")
+	if text == "" {
+		return nil
+	}
+
+	pages := strings.Split(text, "\f")
+	for i, page := range pages {
+		lines := strings.Split(page, "\n")
+		for j, line := range lines {
+			lines[j] = strings.TrimRight(line, " \t")
+		}
+		pages[i] = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	}
+	return pages
+}
+
+// Lines returns the lines of all pages, each prefixed with its page marker
+// line, for diffing.
+func Lines(pages []string) []string {
+	var lines []string
+	for i, page := range pages {
+		lines = append(lines, fmt.Sprintf("[page %d]", i+1))
+		if page != "" {
+			lines = append(lines, strings.Split(page, "\n")...)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,197 @@
+package pdfdiff
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+// pixelTolerance is the per-channel difference (0-255) below which pixels
+// count as equal, so antialiasing noise is not reported.
+const pixelTolerance = 16
+
+// PageDiff describes the visual difference of one page.
+type PageDiff struct {
+	Page      int     // 1-based page number
+	Changed   float64 // Fraction of pixels that differ (0-1); 1 if the page exists in only one PDF
+	ImagePath string  // Diff image, with changed pixels in red (empty if the page is unchanged)
+}
+
+// VisualDiff rasterizes both PDFs at dpi with pdftoppm and compares them page
+// by page. A diff image is written to outDir for every page that changed.
+func VisualDiff(oldPDF, newPDF, outDir string, dpi int) ([]PageDiff, error) {
+	tool, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		return nil, &MissingToolError{Tool: "pdftoppm"}
+	}
+
+	workDir, err := os.MkdirTemp("", "veve-diff-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	oldPages, err := rasterize(tool, oldPDF, filepath.Join(workDir, "old"), dpi)
+	if err != nil {
+		return nil, err
+	}
+	newPages, err := rasterize(tool, newPDF, filepath.Join(workDir, "new"), dpi)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create diff directory: %w", err)
+	}
+
+	var diffs []PageDiff
+	for i := 0; i < max(len(oldPages), len(newPages)); i++ {
+		diff := PageDiff{Page: i + 1, Changed: 1}
+		var marked image.Image
+		switch {
+		case i >= len(oldPages):
+			marked, err = markAll(newPages[i])
+		case i >= len(newPages):
+			marked, err = markAll(oldPages[i])
+		default:
+			diff.Changed, marked, err = comparePages(oldPages[i], newPages[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if diff.Changed > 0 {
+			diff.ImagePath = filepath.Join(outDir, fmt.Sprintf("page-%03d.png", diff.Page))
+			if err := writePNG(diff.ImagePath, marked); err != nil {
+				return nil, err
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// rasterize renders every page of pdfPath to PNG files and returns them in page order.
+func rasterize(tool, pdfPath, prefix string, dpi int) ([]string, error) {
+	out, err := exec.Command(tool, "-r", fmt.Sprint(dpi), "-png", pdfPath, prefix).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rasterize %s: %w: %s", pdfPath, err, out)
+	}
+
+	// pdftoppm zero-pads page numbers to the width of the page count
+	files, err := filepath.Glob(prefix + "-*.png")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if len(files[i]) != len(files[j]) {
+			return len(files[i]) < len(files[j])
+		}
+		return files[i] < files[j]
+	})
+	return files, nil
+}
+
+// comparePages returns the fraction of differing pixels and an image of the new
+// page, faded, with the differing pixels in red.
+func comparePages(oldPath, newPath string) (float64, image.Image, error) {
+	oldImg, err := readPNG(oldPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	newImg, err := readPNG(newPath)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	bounds := newImg.Bounds()
+	if oldImg.Bounds() != bounds {
+		marked, err := markAll(newPath)
+		return 1, marked, err
+	}
+
+	marked := image.NewRGBA(bounds)
+	changed := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if pixelsDiffer(oldImg.At(x, y), newImg.At(x, y)) {
+				changed++
+				marked.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				marked.Set(x, y, fade(newImg.At(x, y)))
+			}
+		}
+	}
+	return float64(changed) / float64(bounds.Dx()*bounds.Dy()), marked, nil
+}
+
+// markAll returns the page at path tinted red, for pages only one PDF has.
+func markAll(path string) (image.Image, error) {
+	img, err := readPNG(path)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	marked := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := fade(img.At(x, y))
+			c.G, c.B = c.G/2, c.B/2
+			marked.Set(x, y, c)
+		}
+	}
+	return marked, nil
+}
+
+// pixelsDiffer reports whether two colors differ beyond pixelTolerance.
+func pixelsDiffer(a, b color.Color) bool {
+	ar, ag, ab, _ := a.RGBA()
+	br, bg, bb, _ := b.RGBA()
+	return channelDiff(ar, br) > pixelTolerance || channelDiff(ag, bg) > pixelTolerance || channelDiff(ab, bb) > pixelTolerance
+}
+
+// channelDiff returns the difference of two 16-bit channels on a 0-255 scale.
+func channelDiff(a, b uint32) uint32 {
+	if a > b {
+		return (a - b) >> 8
+	}
+	return (b - a) >> 8
+}
+
+// fade lightens a color so changes stand out against it.
+func fade(c color.Color) color.RGBA {
+	gray := color.GrayModel.Convert(c).(color.Gray).Y
+	light := 255 - (255-gray)/3
+	return color.RGBA{R: light, G: light, B: light, A: 255}
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write diff image: %w", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write diff image: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,160 @@
+package preflight
+
+import (
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// fieldKind is the YAML shape a front matter field must have.
+type fieldKind int
+
+const (
+	kindString     fieldKind = iota // A scalar, used as text
+	kindBool                        // true or false
+	kindInt                         // An integer
+	kindStringList                  // A scalar or a list of scalars
+	kindAuthors                     // A scalar, or a list of scalars or maps with a name
+)
+
+// frontMatterSchema lists the pandoc metadata fields veve's engines and
+// templates read. Other fields are passed through to templates unchecked.
+var frontMatterSchema = map[string]fieldKind{
+	"title":           kindString,
+	"subtitle":        kindString,
+	"date":            kindString,
+	"abstract":        kindString,
+	"lang":            kindString,
+	"dir":             kindString,
+	"documentclass":   kindString,
+	"papersize":       kindString,
+	"fontsize":        kindString,
+	"mainfont":        kindString,
+	"sansfont":        kindString,
+	"monofont":        kindString,
+	"linestretch":     kindString,
+	"author":          kindAuthors,
+	"keywords":        kindStringList,
+	"geometry":        kindStringList,
+	"header-includes": kindStringList,
+	"toc":             kindBool,
+	"lof":             kindBool,
+	"lot":             kindBool,
+	"numbersections":  kindBool,
+	"toc-depth":       kindInt,
+}
+
+// CheckFrontMatter validates the YAML metadata block at the start of content
+// against the fields pandoc and veve's templates expect.
+func CheckFrontMatter(content string) []Result {
+	block, ok := frontMatter(content)
+	if !ok {
+		return []Result{{Check: "front-matter", Status: StatusPass, Message: "no front matter"}}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(block), &doc); err != nil {
+		return []Result{{Check: "front-matter", Status: StatusFail, Message: fmt.Sprintf("invalid YAML: %v", err)}}
+	}
+	if len(doc.Content) == 0 {
+		return []Result{{Check: "front-matter", Status: StatusPass, Message: "front matter is empty"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []Result{{Check: "front-matter", Status: StatusFail, Message: "front matter must be a mapping of field names to values"}}
+	}
+
+	var results []Result
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		kind, known := frontMatterSchema[key.Value]
+		if !known {
+			continue
+		}
+		if problem := checkField(kind, value); problem != "" {
+			// The block starts on the line after the opening ---
+			results = append(results, Result{
+				Check:   "front-matter",
+				Status:  StatusFail,
+				Subject: key.Value,
+				Message: fmt.Sprintf("line %d: %s", value.Line+1, problem),
+			})
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, Result{Check: "front-matter", Status: StatusPass, Message: "front matter is valid"})
+	}
+	return results
+}
+
+// frontMatter returns the YAML between the opening and closing --- lines.
+// Pandoc also accepts ... as the closing line.
+func frontMatter(content string) (string, bool) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || strings.TrimRight(lines[0], " \t") != "---" {
+		return "", false
+	}
+	for i := 1; i < len(lines); i++ {
+		if line := strings.TrimRight(lines[i], " \t"); line == "---" || line == "..." {
+			return strings.Join(lines[1:i], "\n"), true
+		}
+	}
+	return "", false
+}
+
+// checkField describes how value fails to match kind, or returns "".
+func checkField(kind fieldKind, value *yaml.Node) string {
+	switch kind {
+	case kindString:
+		if value.Kind != yaml.ScalarNode {
+			return "must be text, not a list or mapping"
+		}
+	case kindBool:
+		if value.Kind != yaml.ScalarNode || value.Tag != "!!bool" {
+			return "must be true or false"
+		}
+	case kindInt:
+		if value.Kind != yaml.ScalarNode || value.Tag != "!!int" {
+			return "must be a whole number"
+		}
+	case kindStringList:
+		if value.Kind == yaml.ScalarNode {
+			return ""
+		}
+		if value.Kind != yaml.SequenceNode {
+			return "must be text or a list of text"
+		}
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return "must be text or a list of text"
+			}
+		}
+	case kindAuthors:
+		if value.Kind == yaml.ScalarNode {
+			return ""
+		}
+		if value.Kind != yaml.SequenceNode {
+			return "must be a name or a list of names"
+		}
+		for _, item := range value.Content {
+			if item.Kind == yaml.MappingNode && hasKey(item, "name") {
+				continue
+			}
+			if item.Kind != yaml.ScalarNode {
+				return "entries must be names, or mappings with a name field"
+			}
+		}
+	}
+	return ""
+}
+
+// hasKey reports whether a mapping node has the given key.
+func hasKey(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
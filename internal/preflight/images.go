@@ -0,0 +1,118 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+var markdownParser = goldmark.New().Parser()
+
+// ImageDestinations returns the distinct image URLs and paths in content, in
+// document order. Images in code blocks and code spans are not included.
+func ImageDestinations(content string) []string {
+	source := []byte(content)
+	doc := markdownParser.Parse(text.NewReader(source))
+
+	var destinations []string
+	seen := map[string]bool{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		image, ok := n.(*ast.Image)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		destination := string(image.Destination)
+		if destination != "" && !seen[destination] {
+			seen[destination] = true
+			destinations = append(destinations, destination)
+		}
+		return ast.WalkContinue, nil
+	})
+	return destinations
+}
+
+// CheckImages checks that every image in content can be reached. Remote images
+// are checked with a HEAD request; local paths are looked up in resourcePath,
+// the directories pandoc searches.
+func CheckImages(ctx context.Context, client *http.Client, content string, resourcePath []string) []Result {
+	var results []Result
+	for _, destination := range ImageDestinations(content) {
+		status, message := checkImage(ctx, client, destination, resourcePath)
+		results = append(results, Result{Check: "image", Status: status, Subject: destination, Message: message})
+	}
+	return results
+}
+
+func checkImage(ctx context.Context, client *http.Client, destination string, resourcePath []string) (Status, string) {
+	u, err := url.Parse(destination)
+	if err != nil || len(u.Scheme) < 2 {
+		// Not a URL (or a Windows drive letter): a local path
+		return checkLocalImage(destination, resourcePath)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return checkRemoteImage(ctx, client, destination)
+	case "file":
+		return checkLocalImage(u.Path, nil)
+	case "data":
+		return StatusPass, "embedded image"
+	default:
+		return StatusWarn, fmt.Sprintf("%s URLs are only fetched during conversion; not checked", u.Scheme)
+	}
+}
+
+func checkRemoteImage(ctx context.Context, client *http.Client, imageURL string) (Status, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, imageURL, nil)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return StatusFail, fmt.Sprintf("unreachable: %v", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented:
+		return StatusWarn, "the server does not answer HEAD requests; not checked"
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return StatusFail, fmt.Sprintf("HTTP %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(strings.ToLower(contentType), "image/") {
+		return StatusWarn, fmt.Sprintf("reachable, but served as %s rather than an image", contentType)
+	}
+	return StatusPass, "reachable"
+}
+
+func checkLocalImage(path string, resourcePath []string) (Status, string) {
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		candidates = candidates[:0]
+		for _, dir := range resourcePath {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			return StatusFail, fmt.Sprintf("%s is a directory", candidate)
+		}
+		return StatusPass, fmt.Sprintf("found at %s", candidate)
+	}
+	return StatusFail, "file not found"
+}
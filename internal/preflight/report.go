@@ -0,0 +1,56 @@
+// Package preflight checks a document and its resources before conversion, so
+// problems are reported without running pandoc or producing output.
+package preflight
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	// Check names the kind of check (input, theme, engine, image, front-matter)
+	Check string `json:"check"`
+	// Status is pass, warn, or fail
+	Status Status `json:"status"`
+	// Subject is what was checked, such as an image URL (may be empty)
+	Subject string `json:"subject,omitempty"`
+	// Message describes the outcome
+	Message string `json:"message"`
+}
+
+// Report collects the results of checking one document.
+type Report struct {
+	Input   string   `json:"input"`
+	Results []Result `json:"results"`
+}
+
+// Add records a result.
+func (r *Report) Add(check string, status Status, subject, message string) {
+	r.Results = append(r.Results, Result{Check: check, Status: status, Subject: subject, Message: message})
+}
+
+// Failed reports whether any check failed.
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of results with status.
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
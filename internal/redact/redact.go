@@ -0,0 +1,230 @@
+// Package redact masks sensitive text (email addresses, keys, names) in
+// markdown before it is converted, and reports what it masked, so a
+// redacted PDF can be audited against its source.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Box is the character masked text is replaced with.
+const Box = '█'
+
+// Rule masks the matches of a regular expression.
+type Rule struct {
+	// Name identifies the rule in the report (default: "rule N")
+	Name string `yaml:"name"`
+	// Pattern is a Go regular expression (RE2 syntax); (?i) ignores case
+	Pattern string `yaml:"pattern"`
+	// Replace is the text matches are replaced with, which may refer to
+	// groups as $1 or ${name}; empty masks each character with a Box
+	Replace string `yaml:"replace"`
+
+	re *regexp.Regexp
+}
+
+// Rules are the rules of a pattern file, applied in order.
+type Rules struct {
+	Path   string // Pattern file the rules were loaded from
+	SHA256 string // Checksum of the pattern file
+	Rules  []Rule
+}
+
+// Load reads a YAML pattern file:
+//
+//	rules:
+//	  - name: email
+//	    pattern: '[\w.+-]+@[\w-]+(\.[\w-]+)+'
+//	  - name: api-key
+//	    pattern: 'sk_(live|test)_[0-9a-zA-Z]{24}'
+//	    replace: '[API KEY]'
+func Load(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pattern file: %w", err)
+	}
+	var file struct {
+		Rules []Rule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("%s has no rules", path)
+	}
+
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("%s: %s has no pattern", path, rule.Name)
+		}
+		if rule.re, err = regexp.Compile(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("%s: %s: invalid pattern: %w", path, rule.Name, err)
+		}
+		if strings.Contains(rule.Replace, "\n") {
+			return nil, fmt.Errorf("%s: %s: replacement must be a single line", path, rule.Name)
+		}
+	}
+	sum := sha256.Sum256(data)
+	return &Rules{Path: path, SHA256: hex.EncodeToString(sum[:]), Rules: file.Rules}, nil
+}
+
+// Redaction is one masked match.
+type Redaction struct {
+	Rule   string `json:"rule"`
+	Line   int    `json:"line"`   // Line of the match in the input, from 1
+	Column int    `json:"column"` // Column of the match, in characters from 1
+	Length int    `json:"length"` // Length of the matched text, in characters
+	SHA256 string `json:"sha256"` // Checksum of the matched text, to check a value was masked
+}
+
+// match is a rule's match in the input, as byte offsets.
+type match struct {
+	start, end int
+	rule       *Rule
+	loc        []int
+}
+
+// Apply masks the rules' matches in content and returns the result with
+// the redactions, in the order they appear. Matches are found in the input
+// as given; where matches overlap, the earlier rule wins. Masking keeps
+// whitespace, so masked text wraps and keeps its lines.
+func (r *Rules) Apply(content string) (string, []Redaction) {
+	var matches []match
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		for _, loc := range rule.re.FindAllStringSubmatchIndex(content, -1) {
+			if loc[1] > loc[0] && !overlaps(matches, loc[0], loc[1]) {
+				matches = append(matches, match{start: loc[0], end: loc[1], rule: rule, loc: loc})
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return content, nil
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	var b strings.Builder
+	b.Grow(len(content))
+	redactions := make([]Redaction, 0, len(matches))
+	line, lineStart, last := 1, 0, 0
+	for _, m := range matches {
+		// Count the lines up to the match
+		if n := strings.Count(content[last:m.start], "\n"); n > 0 {
+			line += n
+			lineStart = last + strings.LastIndexByte(content[last:m.start], '\n') + 1
+		}
+		text := content[m.start:m.end]
+		sum := sha256.Sum256([]byte(text))
+		redactions = append(redactions, Redaction{
+			Rule:   m.rule.Name,
+			Line:   line,
+			Column: utf8.RuneCountInString(content[lineStart:m.start]) + 1,
+			Length: utf8.RuneCountInString(text),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+
+		b.WriteString(content[last:m.start])
+		if m.rule.Replace != "" {
+			b.Write(m.rule.re.ExpandString(nil, m.rule.Replace, content, m.loc))
+		} else {
+			b.WriteString(mask(text))
+		}
+		last = m.end
+
+		// A match may span lines
+		if n := strings.Count(text, "\n"); n > 0 {
+			line += n
+			lineStart = m.start + strings.LastIndexByte(text, '\n') + 1
+		}
+	}
+	b.WriteString(content[last:])
+	return b.String(), redactions
+}
+
+// overlaps reports whether [start, end) overlaps one of matches.
+func overlaps(matches []match, start, end int) bool {
+	for _, m := range matches {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// mask replaces each character of text but whitespace with a Box.
+func mask(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return r
+		}
+		return Box
+	}, text)
+}
+
+// Report records a conversion's redactions.
+type Report struct {
+	Input          string         `json:"input"`
+	Output         string         `json:"output"`
+	Patterns       string         `json:"patterns"`
+	PatternsSHA256 string         `json:"patternsSha256"`
+	Time           time.Time      `json:"time"`
+	Counts         map[string]int `json:"counts"` // Redactions per rule, including rules with none
+	Redactions     []Redaction    `json:"redactions"`
+}
+
+// NewReport returns the report of redactions made with rules.
+func NewReport(rules *Rules, redactions []Redaction) *Report {
+	report := &Report{
+		Patterns:       rules.Path,
+		PatternsSHA256: rules.SHA256,
+		Time:           time.Now().UTC(),
+		Counts:         make(map[string]int, len(rules.Rules)),
+		Redactions:     redactions,
+	}
+	if report.Redactions == nil {
+		report.Redactions = []Redaction{}
+	}
+	for _, rule := range rules.Rules {
+		report.Counts[rule.Name] = 0
+	}
+	for _, redaction := range redactions {
+		report.Counts[redaction.Rule]++
+	}
+	return report
+}
+
+// Write writes the report to path as JSON, readable only by its owner,
+// since checksums of short values can be guessed.
+func (r *Report) Write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode redaction report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write redaction report: %w", err)
+	}
+	return nil
+}
+
+// ReportPath returns the default report path for an output file:
+// report.pdf gets report.redactions.json.
+func ReportPath(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ".redactions.json"
+}
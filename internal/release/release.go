@@ -0,0 +1,215 @@
+// Package release generates package manager metadata (Homebrew, Scoop, and
+// Debian) for a published veve release from its archive checksums.
+package release
+
+import (
+	"bufio"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templates holds one <format>.tmpl per manifest format.
+//
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Format is a package manager manifest veve can generate.
+type Format struct {
+	Name        string
+	Description string
+	// requires returns an error if the release lacks an archive the format needs
+	requires func(r *Release) error
+}
+
+// formats are the supported manifest formats.
+var formats = []Format{
+	{"homebrew", "Homebrew formula (Ruby)", requireHomebrew},
+	{"scoop", "Scoop manifest (JSON)", requireScoop},
+	{"deb", "Debian control file", requireDeb},
+}
+
+// Formats returns the supported manifest formats.
+func Formats() []Format {
+	return append([]Format(nil), formats...)
+}
+
+// Artifact is one release archive.
+type Artifact struct {
+	Name   string // File name, e.g. veve-cli_Darwin_all.tar.gz
+	OS     string // darwin, linux, or windows
+	Arch   string // amd64, arm64, 386, or universal (macOS universal binary)
+	SHA256 string
+	URL    string
+}
+
+// Release describes a published release.
+type Release struct {
+	Version     string // Version without a leading v
+	Description string
+	Homepage    string
+	License     string
+	Maintainer  string
+	Arch        string // Debian architecture the deb control file is for
+	Artifacts   []Artifact
+}
+
+// Find returns the archive for os and arch, or nil.
+func (r *Release) Find(os, arch string) *Artifact {
+	for i := range r.Artifacts {
+		if r.Artifacts[i].OS == os && r.Artifacts[i].Arch == arch {
+			return &r.Artifacts[i]
+		}
+	}
+	return nil
+}
+
+// ParseChecksums reads a checksums file as written by goreleaser and sha256sum
+// ("<hex digest>  <file name>" per line) and returns the archives it lists, with
+// download URLs below baseURL. Files that are not platform archives are skipped.
+func ParseChecksums(r io.Reader, baseURL string) ([]Artifact, error) {
+	var artifacts []Artifact
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || len(fields[0]) != 64 {
+			return nil, fmt.Errorf("checksums line %d: expected \"<sha256>  <file>\"", line)
+		}
+
+		// sha256sum marks binary-mode files with a leading *
+		name := strings.TrimPrefix(fields[1], "*")
+		os, arch, ok := archivePlatform(name)
+		if !ok {
+			continue
+		}
+		artifacts = append(artifacts, Artifact{
+			Name:   name,
+			OS:     os,
+			Arch:   arch,
+			SHA256: strings.ToLower(fields[0]),
+			URL:    strings.TrimSuffix(baseURL, "/") + "/" + name,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Name < artifacts[j].Name })
+	return artifacts, nil
+}
+
+// archivePlatform extracts the OS and architecture from an archive named like
+// <project>_<Os>_<arch>.tar.gz (the goreleaser name template).
+func archivePlatform(name string) (string, string, bool) {
+	base, ok := "", false
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			base, ok = strings.TrimSuffix(name, ext), true
+			break
+		}
+	}
+	if !ok {
+		return "", "", false
+	}
+
+	// x86_64 itself contains the separator
+	base = strings.Replace(base, "_x86_64", "_amd64", 1)
+	parts := strings.Split(base, "_")
+	if len(parts) < 3 {
+		return "", "", false
+	}
+	os := strings.ToLower(parts[len(parts)-2])
+	if os != "darwin" && os != "linux" && os != "windows" {
+		return "", "", false
+	}
+
+	arch := parts[len(parts)-1]
+	switch arch {
+	case "amd64", "386", "arm64":
+	case "i386":
+		arch = "386"
+	case "aarch64":
+		arch = "arm64"
+	case "all":
+		arch = "universal"
+	default:
+		return "", "", false
+	}
+	return os, arch, true
+}
+
+// Render writes the manifest in the named format.
+func Render(w io.Writer, format string, r *Release) error {
+	var selected *Format
+	for i := range formats {
+		if formats[i].Name == format {
+			selected = &formats[i]
+		}
+	}
+	if selected == nil {
+		names := make([]string, len(formats))
+		for i, f := range formats {
+			names[i] = f.Name
+		}
+		return fmt.Errorf("unknown manifest format %q: available formats are %s", format, strings.Join(names, ", "))
+	}
+	if err := selected.requires(r); err != nil {
+		return err
+	}
+
+	tmpl, err := template.New(format+".tmpl").Funcs(template.FuncMap{
+		"json": jsonString,
+	}).ParseFS(templates, "templates/"+format+".tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to parse %s template: %w", format, err)
+	}
+	return tmpl.Execute(w, r)
+}
+
+// jsonString quotes s as a JSON string.
+func jsonString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+func requireHomebrew(r *Release) error {
+	if r.Find("darwin", "universal") == nil && r.Find("darwin", "amd64") == nil && r.Find("darwin", "arm64") == nil {
+		return fmt.Errorf("no macOS archive in the checksums; the Homebrew formula needs one")
+	}
+	return nil
+}
+
+func requireScoop(r *Release) error {
+	if r.Find("windows", "amd64") == nil && r.Find("windows", "386") == nil {
+		return fmt.Errorf("no Windows archive in the checksums; the Scoop manifest needs one")
+	}
+	return nil
+}
+
+func requireDeb(r *Release) error {
+	arch, ok := debianArchs[r.Arch]
+	if !ok {
+		return fmt.Errorf("unsupported Debian architecture %q: use amd64, arm64, or i386", r.Arch)
+	}
+	if r.Find("linux", arch) == nil {
+		return fmt.Errorf("no Linux %s archive in the checksums; the Debian package is built from it", arch)
+	}
+	if r.Maintainer == "" {
+		return fmt.Errorf("the Debian control file needs a maintainer")
+	}
+	return nil
+}
+
+// debianArchs maps Debian architecture names to archive architectures.
+var debianArchs = map[string]string{
+	"amd64": "amd64",
+	"arm64": "arm64",
+	"i386":  "386",
+}
@@ -0,0 +1,17 @@
+// Package shell runs the shell commands a project configures, as hooks and
+// transformers, through the system shell.
+package shell
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// Command returns a command that runs command through the system shell:
+// sh -c, or cmd /C on Windows.
+func Command(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,170 @@
+// Package skeleton writes starter markdown documents (reports, letters,
+// ADRs, RFCs, meeting minutes) with front matter and structure suited to a
+// built-in theme, for veve new.
+package skeleton
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templates holds one <kind>.md.tmpl per document kind.
+//
+//go:embed templates/*.md.tmpl
+var templates embed.FS
+
+// Kind is a type of document veve new can start.
+type Kind struct {
+	Name        string
+	Description string
+	Theme       string // Built-in theme the document is laid out for
+	Title       string // Title used when none is given
+	Status      string // Initial status, for kinds with one
+
+	// Prefix, if set, makes documents numbered: their title is "<Prefix>
+	// <number>: <title>" and their file name "<number>-<title>.md"
+	Prefix string
+}
+
+// kinds are the supported document kinds.
+var kinds = []Kind{
+	{Name: "report", Description: "Report with summary, findings, and recommendations", Theme: "default", Title: "Report"},
+	{Name: "letter", Description: "Business letter with sender, recipient, date, and signature blocks", Theme: "letter", Title: "Subject"},
+	{Name: "adr", Description: "Architecture decision record: context, decision, consequences", Theme: "default", Title: "Decision", Status: "Proposed", Prefix: "ADR"},
+	{Name: "rfc", Description: "Request for comments: motivation, proposal, alternatives", Theme: "academic", Title: "Proposal", Status: "Draft", Prefix: "RFC"},
+	{Name: "minutes", Description: "Meeting minutes with agenda, decisions, and action items", Theme: "default", Title: "Meeting Minutes"},
+}
+
+// Kinds returns the supported document kinds.
+func Kinds() []Kind {
+	return append([]Kind(nil), kinds...)
+}
+
+// Find returns the kind named name, or an error listing the kinds.
+func Find(name string) (Kind, error) {
+	for _, kind := range kinds {
+		if kind.Name == name {
+			return kind, nil
+		}
+	}
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = kind.Name
+	}
+	return Kind{}, fmt.Errorf("unknown document type %q: available types are %s", name, strings.Join(names, ", "))
+}
+
+// Document holds the values filled into a skeleton.
+type Document struct {
+	Title  string
+	Author string
+	Date   time.Time
+	Status string // Default: the kind's status
+	Number int    // Number of a numbered kind's document
+}
+
+// Render writes the skeleton for kind to w. An empty title is the kind's
+// default title, and a zero date is today.
+func Render(w io.Writer, kind Kind, doc Document) error {
+	if doc.Title == "" {
+		doc.Title = kind.Title
+	}
+	if doc.Date.IsZero() {
+		doc.Date = time.Now()
+	}
+	if doc.Status == "" {
+		doc.Status = kind.Status
+	}
+	if kind.Prefix != "" && doc.Number > 0 {
+		doc.Title = fmt.Sprintf("%s %04d: %s", kind.Prefix, doc.Number, doc.Title)
+	}
+
+	tmpl, err := template.New(kind.Name+".md.tmpl").Funcs(template.FuncMap{
+		"yaml": yamlString,
+	}).ParseFS(templates, "templates/"+kind.Name+".md.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to parse %s template: %w", kind.Name, err)
+	}
+	return tmpl.Execute(w, struct {
+		Title, Author, Date, LongDate, Status string
+		Number                                int
+	}{
+		Title:    doc.Title,
+		Author:   doc.Author,
+		Date:     doc.Date.Format("2006-01-02"),
+		LongDate: doc.Date.Format("January 2, 2006"),
+		Status:   doc.Status,
+		Number:   doc.Number,
+	})
+}
+
+// FileName returns the default file name of a document: <kind>.md, or
+// <number>-<title>.md for a numbered kind, as adr-tools names records.
+func FileName(kind Kind, doc Document) string {
+	if kind.Prefix == "" || doc.Number <= 0 {
+		return kind.Name + ".md"
+	}
+	title := doc.Title
+	if title == "" {
+		title = kind.Title
+	}
+	return fmt.Sprintf("%04d-%s.md", doc.Number, slug(title))
+}
+
+// numberedFile matches the file names FileName gives numbered documents;
+// datedFile matches dated ones (2025-03-04-notes.md), which aren't numbered.
+var (
+	numberedFile = regexp.MustCompile(`^(\d+)-.*\.md$`)
+	datedFile    = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+)
+
+// NextNumber returns the number after the highest of the numbered
+// documents in dir, or 1 if it has none (or doesn't exist yet).
+func NextNumber(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	highest := 0
+	for _, entry := range entries {
+		m := numberedFile.FindStringSubmatch(entry.Name())
+		if m == nil || entry.IsDir() || datedFile.MatchString(entry.Name()) {
+			continue
+		}
+		if n, err := strconv.Atoi(m[1]); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest + 1, nil
+}
+
+// nonSlugChars are the runs of characters replaced in file name slugs.
+var nonSlugChars = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// slug turns a title into a lowercase, hyphenated file name part.
+func slug(title string) string {
+	s := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if s == "" {
+		return "untitled"
+	}
+	return s
+}
+
+// yamlString quotes s as a YAML scalar (a JSON string is valid YAML).
+func yamlString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
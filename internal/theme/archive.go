@@ -0,0 +1,209 @@
+package theme
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal"
+)
+
+// DownloadedTheme is a theme picked out of an archive or repository: either
+// CSS or a theme package archive for InstallPackage.
+type DownloadedTheme struct {
+	CSS     string
+	Package []byte
+	// Commit is the abbreviated commit of a theme downloaded from GitHub
+	Commit string
+}
+
+// AmbiguousThemeError reports an archive holding several themes. Choices
+// are their paths, relative to the directory searched; installing one of
+// them selects it.
+type AmbiguousThemeError struct {
+	Choices []string
+}
+
+func (e *AmbiguousThemeError) Error() string {
+	return fmt.Sprintf("found several themes (%s); choose one with --file", strings.Join(e.Choices, ", "))
+}
+
+// ErrorKind classifies the error as a theme error.
+func (e *AmbiguousThemeError) ErrorKind() internal.Kind {
+	return internal.KindTheme
+}
+
+// selectTheme picks the theme at dir (a file or directory path, "" for the
+// root) out of files, keyed by slash-separated path:
+//
+//   - a .css file or .vevetheme package named by dir;
+//   - the theme package whose manifest.json is in dir, or the only one below it;
+//   - the only CSS file below dir (or, without CSS, the only LaTeX or
+//     Markdown file).
+//
+// Several packages or stylesheets give an *AmbiguousThemeError. Hidden
+// files and directories are ignored.
+func selectTheme(files map[string][]byte, dir string) (*DownloadedTheme, error) {
+	if data, ok := files[dir]; ok && dir != "" {
+		switch strings.ToLower(path.Ext(dir)) {
+		case PackageExtension:
+			return &DownloadedTheme{Package: data}, nil
+		case ".css":
+			return fileTheme(dir, data)
+		default:
+			return nil, fmt.Errorf("%s is not a CSS file or %s package", dir, PackageExtension)
+		}
+	}
+
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	var names []string
+	for name := range files {
+		if rel, ok := strings.CutPrefix(name, prefix); ok && !isHiddenPath(rel) {
+			names = append(names, rel)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%q not found", dir)
+	}
+	sort.Strings(names)
+
+	// A theme package takes precedence over loose stylesheets, which are
+	// usually its own
+	var packages []string
+	for _, name := range names {
+		if name == ManifestFile {
+			packages = []string{"."}
+			break
+		}
+		if path.Base(name) == ManifestFile {
+			packages = append(packages, path.Dir(name))
+		}
+	}
+	switch len(packages) {
+	case 0:
+	case 1:
+		packagePrefix := prefix
+		if packages[0] != "." {
+			packagePrefix += packages[0] + "/"
+		}
+		data, err := zipFiles(files, packagePrefix)
+		if err != nil {
+			return nil, err
+		}
+		return &DownloadedTheme{Package: data}, nil
+	default:
+		return nil, &AmbiguousThemeError{Choices: packages}
+	}
+
+	for _, ext := range []string{".css", ".tex", ".md"} {
+		var candidates []string
+		for _, name := range names {
+			if strings.EqualFold(path.Ext(name), ext) {
+				candidates = append(candidates, name)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return fileTheme(candidates[0], files[prefix+candidates[0]])
+		default:
+			return nil, &AmbiguousThemeError{Choices: candidates}
+		}
+	}
+	return nil, fmt.Errorf("no %s or CSS file found", ManifestFile)
+}
+
+// fileTheme validates a single theme file.
+func fileTheme(name string, data []byte) (*DownloadedTheme, error) {
+	content := string(data)
+	switch strings.ToLower(path.Ext(name)) {
+	case ".css":
+		if err := ValidateCSS(content); err != nil {
+			return nil, fmt.Errorf("%s doesn't appear to be valid CSS: %w", name, err)
+		}
+	case ".tex":
+		if err := ValidateLaTeX(content); err != nil {
+			return nil, fmt.Errorf("%s doesn't appear to be valid LaTeX: %w", name, err)
+		}
+	}
+	// Metadata is kept for the installer to read
+	return &DownloadedTheme{CSS: content}, nil
+}
+
+// isHiddenPath reports whether a slash-separated path is, or is inside, a
+// hidden file or directory.
+func isHiddenPath(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.Contains(name, "/.")
+}
+
+// readZipFiles reads the regular files of a zip archive, keyed by path,
+// after checking every entry.
+func readZipFiles(zr *zip.Reader) (map[string][]byte, error) {
+	if len(zr.File) > maxPackageFiles {
+		return nil, fmt.Errorf("archive has too many files (%d, limit %d)", len(zr.File), maxPackageFiles)
+	}
+
+	files := make(map[string][]byte)
+	var total int64
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		if err := checkArchiveEntry(file); err != nil {
+			return nil, fmt.Errorf("archive %w", err)
+		}
+		total += int64(file.UncompressedSize64)
+		if total > maxPackageSize {
+			return nil, fmt.Errorf("archive is too large (limit %d MB)", maxPackageSize/1024/1024)
+		}
+
+		r, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", file.Name, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(r, int64(file.UncompressedSize64)))
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", file.Name, err)
+		}
+		files[path.Clean(file.Name)] = data
+	}
+	return files, nil
+}
+
+// zipFiles packs the files under prefix into a theme package archive,
+// leaving out hidden files as Pack does.
+func zipFiles(files map[string][]byte, prefix string) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		rel, ok := strings.CutPrefix(name, prefix)
+		if ok && !isHiddenPath(rel) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(strings.TrimPrefix(name, prefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to package theme: %w", err)
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, fmt.Errorf("failed to package theme: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to package theme: %w", err)
+	}
+	return buf.Bytes(), nil
+}
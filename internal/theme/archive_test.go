@@ -0,0 +1,136 @@
+package theme
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestSelectTheme tests picking a theme out of an archive's files.
+func TestSelectTheme(t *testing.T) {
+	manifest := `{"name": "brand", "css": "theme.css"}`
+	tests := []struct {
+		name        string
+		files       map[string]string
+		dir         string
+		wantCSS     string
+		wantPackage []string // Files in the package
+		wantChoices []string
+	}{
+		{
+			name:    "single stylesheet in a folder",
+			files:   map[string]string{"brand-main/theme.css": "a { }", "brand-main/README.md": "# Brand"},
+			wantCSS: "a { }",
+		},
+		{
+			name:        "package in a folder, with its fonts",
+			files:       map[string]string{"brand-main/manifest.json": manifest, "brand-main/theme.css": "a { }", "brand-main/fonts/x.woff2": "font", "brand-main/.DS_Store": "junk"},
+			wantPackage: []string{"fonts/x.woff2", "manifest.json", "theme.css"},
+		},
+		{
+			name:        "root package wins over nested ones",
+			files:       map[string]string{"manifest.json": manifest, "theme.css": "a { }", "examples/manifest.json": manifest, "examples/theme.css": "b { }"},
+			wantPackage: []string{"examples/manifest.json", "examples/theme.css", "manifest.json", "theme.css"},
+		},
+		{
+			name:        "several packages",
+			files:       map[string]string{"light/manifest.json": manifest, "light/theme.css": "a { }", "dark/manifest.json": manifest, "dark/theme.css": "b { }"},
+			wantChoices: []string{"dark", "light"},
+		},
+		{
+			name:        "several stylesheets",
+			files:       map[string]string{"b.css": "b { }", "a/a.css": "a { }", ".hidden/c.css": "c { }"},
+			wantChoices: []string{"a/a.css", "b.css"},
+		},
+		{
+			name:    "chosen stylesheet",
+			files:   map[string]string{"b.css": "b { }", "a/a.css": "a { }"},
+			dir:     "a/a.css",
+			wantCSS: "a { }",
+		},
+		{
+			name:        "chosen package",
+			files:       map[string]string{"light/manifest.json": manifest, "light/theme.css": "a { }", "dark/manifest.json": manifest, "dark/theme.css": "b { }"},
+			dir:         "dark",
+			wantPackage: []string{"manifest.json", "theme.css"},
+		},
+		{
+			name:    "LaTeX without CSS",
+			files:   map[string]string{"template.tex": `\documentclass{article}`, "notes.txt": "x"},
+			wantCSS: `\documentclass{article}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := make(map[string][]byte)
+			for name, content := range test.files {
+				files[name] = []byte(content)
+			}
+
+			got, err := selectTheme(files, test.dir)
+			if test.wantChoices != nil {
+				var ambiguous *AmbiguousThemeError
+				if !errors.As(err, &ambiguous) {
+					t.Fatalf("selectTheme error = %v, want an AmbiguousThemeError", err)
+				}
+				if !reflect.DeepEqual(ambiguous.Choices, test.wantChoices) {
+					t.Errorf("Choices = %v, want %v", ambiguous.Choices, test.wantChoices)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectTheme failed: %v", err)
+			}
+			if got.CSS != test.wantCSS {
+				t.Errorf("CSS = %q, want %q", got.CSS, test.wantCSS)
+			}
+			if test.wantPackage == nil {
+				if got.Package != nil {
+					t.Error("unexpected package")
+				}
+				return
+			}
+			zr, err := zip.NewReader(bytes.NewReader(got.Package), int64(len(got.Package)))
+			if err != nil {
+				t.Fatalf("invalid package: %v", err)
+			}
+			var names []string
+			for _, file := range zr.File {
+				names = append(names, file.Name)
+			}
+			if !reflect.DeepEqual(names, test.wantPackage) {
+				t.Errorf("package files = %v, want %v", names, test.wantPackage)
+			}
+		})
+	}
+}
+
+// TestDownloadArchiveLocal tests installing from a local zip archive, choosing a theme.
+func TestDownloadArchiveLocal(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "themes.zip")
+	archive := zipArchive(t, map[string]string{"light.css": "a { }", "dark.css": "b { }"})
+	if err := os.WriteFile(archivePath, archive, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDownloader()
+	var ambiguous *AmbiguousThemeError
+	if _, err := d.DownloadArchive(archivePath, ""); !errors.As(err, &ambiguous) {
+		t.Fatalf("DownloadArchive error = %v, want an AmbiguousThemeError", err)
+	}
+	got, err := d.DownloadArchive(archivePath, "dark.css")
+	if err != nil {
+		t.Fatalf("DownloadArchive failed: %v", err)
+	}
+	if got.CSS != "b { }" {
+		t.Errorf("CSS = %q", got.CSS)
+	}
+	if _, err := d.DownloadArchive(archivePath, "missing.css"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
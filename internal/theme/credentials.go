@@ -0,0 +1,97 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Credential authenticates theme downloads from a host, with a bearer token
+// or, without one, HTTP basic auth. It is only sent over HTTPS.
+type Credential struct {
+	// Host the credential is sent to; it also applies to the host's
+	// subdomains. A credential without one is never sent.
+	Host     string
+	Username string
+	Password string
+	Token    string
+}
+
+// githubHosts serve files from GitHub repositories.
+var githubHosts = []string{"github.com", "raw.githubusercontent.com", "api.github.com"}
+
+// CredentialsFromEnv returns the theme credentials set in the environment:
+// VEVE_THEME_TOKEN, or VEVE_THEME_USERNAME and VEVE_THEME_PASSWORD, for the
+// host VEVE_THEME_HOST names, and GITHUB_TOKEN (or GH_TOKEN) for GitHub.
+// Setting the first without VEVE_THEME_HOST is an error, so a credential
+// meant for one host is never sent to whichever serves a theme.
+func CredentialsFromEnv() ([]Credential, error) {
+	var creds []Credential
+	host := os.Getenv("VEVE_THEME_HOST")
+	cred := Credential{Host: host}
+	if token := os.Getenv("VEVE_THEME_TOKEN"); token != "" {
+		cred.Token = token
+	} else if username := os.Getenv("VEVE_THEME_USERNAME"); username != "" {
+		cred.Username, cred.Password = username, os.Getenv("VEVE_THEME_PASSWORD")
+	}
+	if cred.Token != "" || cred.Username != "" {
+		if host == "" {
+			return nil, errors.New("VEVE_THEME_TOKEN or VEVE_THEME_USERNAME is set without VEVE_THEME_HOST: set it to the host the credentials are for")
+		}
+		if strings.ContainsAny(host, "/:") {
+			return nil, fmt.Errorf("VEVE_THEME_HOST must be a host name, such as themes.example.com, not %q", host)
+		}
+		creds = append(creds, cred)
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token != "" {
+		for _, host := range githubHosts {
+			creds = append(creds, Credential{Host: host, Token: token})
+		}
+	}
+	return creds, nil
+}
+
+// matches reports whether c applies to host.
+func (c Credential) matches(host string) bool {
+	if c.Host == "" {
+		return false
+	}
+	want := strings.ToLower(c.Host)
+	host = strings.ToLower(host)
+	return host == want || strings.HasSuffix(host, "."+want)
+}
+
+// apply adds c's authorization to req.
+func (c Credential) apply(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// githubRawURL rewrites the URL of a file viewed on GitHub
+// (github.com/owner/repo/blob/ref/path, or .../raw/ref/path) to its
+// raw.githubusercontent.com URL. github.com redirects raw downloads there
+// itself, but drops the credentials on the way, so private files must be
+// requested from raw.githubusercontent.com directly. Other URLs are returned
+// unchanged.
+func githubRawURL(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || !strings.EqualFold(u.Host, "github.com") {
+		return source
+	}
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 5)
+	if len(parts) != 5 || (parts[2] != "blob" && parts[2] != "raw") {
+		return source
+	}
+	return "https://raw.githubusercontent.com/" + parts[0] + "/" + parts[1] + "/" + parts[3] + "/" + parts[4]
+}
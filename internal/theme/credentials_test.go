@@ -0,0 +1,168 @@
+package theme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestGitHubRawURL tests rewriting GitHub file URLs to raw.githubusercontent.com.
+func TestGitHubRawURL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"https://github.com/acme/themes/blob/main/brand/brand.css", "https://raw.githubusercontent.com/acme/themes/main/brand/brand.css"},
+		{"https://github.com/acme/themes/raw/v1.2/brand.vevetheme", "https://raw.githubusercontent.com/acme/themes/v1.2/brand.vevetheme"},
+		{"https://raw.githubusercontent.com/acme/themes/main/brand.css", "https://raw.githubusercontent.com/acme/themes/main/brand.css"},
+		{"https://github.com/acme/themes/tree/main/brand", "https://github.com/acme/themes/tree/main/brand"},
+		{"https://example.com/acme/themes/blob/main/brand.css", "https://example.com/acme/themes/blob/main/brand.css"},
+	}
+
+	for _, test := range tests {
+		if got := githubRawURL(test.input); got != test.want {
+			t.Errorf("githubRawURL(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+// TestCredentialMatches tests that credentials apply to their host and its subdomains.
+func TestCredentialMatches(t *testing.T) {
+	cred := Credential{Host: "example.com"}
+	for host, want := range map[string]bool{
+		"example.com":        true,
+		"themes.example.com": true,
+		"EXAMPLE.COM":        true,
+		"badexample.com":     false,
+		"example.com.evil":   false,
+	} {
+		if got := cred.matches(host); got != want {
+			t.Errorf("matches(%q) = %v, want %v", host, got, want)
+		}
+	}
+	if (Credential{}).matches("anything.test") {
+		t.Error("a credential without a host should match no host")
+	}
+}
+
+// TestCredentialsFromEnv tests reading credentials from the environment.
+func TestCredentialsFromEnv(t *testing.T) {
+	t.Setenv("VEVE_THEME_HOST", "themes.example.com")
+	t.Setenv("VEVE_THEME_TOKEN", "")
+	t.Setenv("VEVE_THEME_USERNAME", "ci")
+	t.Setenv("VEVE_THEME_PASSWORD", "secret")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh")
+
+	want := []Credential{
+		{Host: "themes.example.com", Username: "ci", Password: "secret"},
+		{Host: "github.com", Token: "gh"},
+		{Host: "raw.githubusercontent.com", Token: "gh"},
+		{Host: "api.github.com", Token: "gh"},
+	}
+	got, err := CredentialsFromEnv()
+	if err != nil {
+		t.Fatalf("CredentialsFromEnv failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CredentialsFromEnv() = %+v, want %+v", got, want)
+	}
+
+	// A token for no host in particular is refused, not sent everywhere
+	t.Setenv("VEVE_THEME_HOST", "")
+	t.Setenv("VEVE_THEME_TOKEN", "abc")
+	if _, err := CredentialsFromEnv(); err == nil || !strings.Contains(err.Error(), "VEVE_THEME_HOST") {
+		t.Errorf("CredentialsFromEnv() without a host: error = %v, want one naming VEVE_THEME_HOST", err)
+	}
+	t.Setenv("VEVE_THEME_HOST", "https://themes.example.com")
+	if _, err := CredentialsFromEnv(); err == nil {
+		t.Error("CredentialsFromEnv() accepted a URL as the host")
+	}
+}
+
+// TestDownloaderSendsCredentials tests that HTTPS requests carry the
+// credential for their host, and that no credential is sent to another host
+// or over plain HTTP, including by a redirect.
+func TestDownloaderSendsCredentials(t *testing.T) {
+	var got string
+	record := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		if got == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	server := httptest.NewTLSServer(record)
+	defer server.Close()
+	plain := httptest.NewServer(record)
+	defer plain.Close()
+	redirect := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	local := []Credential{{Host: "127.0.0.1", Token: "abc"}}
+	tests := []struct {
+		name  string
+		url   string
+		creds []Credential
+		want  string
+	}{
+		{"token", server.URL, local, "Bearer abc"},
+		{"basic", server.URL, []Credential{{Host: "127.0.0.1", Username: "ci", Password: "pw"}}, "Basic Y2k6cHc="},
+		{"first match", server.URL, []Credential{{Host: "example.com", Token: "other"}, {Host: "127.0.0.1", Token: "local"}}, "Bearer local"},
+		{"other host", server.URL, []Credential{{Host: "example.com", Token: "other"}}, ""},
+		{"no host", server.URL, []Credential{{Token: "any"}}, ""},
+		{"plain http", plain.URL, local, ""},
+		{"redirect to plain http", redirect.URL, local, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got = ""
+			d := NewDownloader().WithCredentials(test.creds)
+			d.transport = server.Client().Transport
+			resp, err := d.get(test.url + "/theme.css")
+			if err != nil {
+				t.Fatalf("get failed: %v", err)
+			}
+			resp.Body.Close()
+			if got != test.want {
+				t.Errorf("Authorization = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestDownloaderStatusError tests the hints for rejected downloads.
+func TestDownloaderStatusError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		creds []Credential
+		want  string
+	}{
+		{"anonymous", nil, "may need credentials"},
+		{"rejected", []Credential{{Host: "127.0.0.1", Token: "bad"}}, "were rejected"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDownloader().WithCredentials(test.creds)
+			d.transport = server.Client().Transport
+			resp, err := d.get(server.URL)
+			if err != nil {
+				t.Fatalf("get failed: %v", err)
+			}
+			resp.Body.Close()
+			if err := d.statusError(resp); !strings.Contains(err.Error(), test.want) {
+				t.Errorf("statusError() = %v, want it to mention %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,487 @@
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stylesheet is a parsed CSS stylesheet.
+type Stylesheet struct {
+	Rules []Rule
+}
+
+// Rule is a style rule (AtRule empty) or an at-rule such as @page or @media.
+type Rule struct {
+	AtRule       string        // At-rule name without "@" (e.g. "page"); empty for style rules
+	Prelude      string        // Selector list, or the at-rule prelude (e.g. "print" for @media print)
+	Selectors    []string      // Selectors of a style rule, whitespace-normalized
+	Declarations []Declaration // Declarations in the rule's block
+	Rules        []Rule        // Nested rules (e.g. inside @media)
+	HasBlock     bool          // Whether the rule has a {} block (false for e.g. @import)
+	Line         int
+	Column       int
+}
+
+// Declaration is a single "property: value" pair.
+type Declaration struct {
+	Property  string // Property name, lowercased unless it is a custom property
+	Value     string // Value without !important
+	Important bool
+	Line      int
+	Column    int
+}
+
+// Severity classifies a stylesheet issue.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found in a stylesheet, with its 1-based position.
+type Issue struct {
+	Severity Severity
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Severity, i.Message)
+}
+
+// knownAtRules are the at-rules veve recognizes. Vendor-prefixed at-rules are always accepted.
+var knownAtRules = map[string]bool{
+	"charset": true, "import": true, "namespace": true, "media": true, "supports": true,
+	"page": true, "font-face": true, "keyframes": true, "counter-style": true,
+	"font-feature-values": true, "property": true, "layer": true, "container": true,
+	"document": true, "footnote": true,
+	// Page-margin boxes, valid inside @page
+	"top-left-corner": true, "top-left": true, "top-center": true, "top-right": true,
+	"top-right-corner": true, "bottom-left-corner": true, "bottom-left": true,
+	"bottom-center": true, "bottom-right": true, "bottom-right-corner": true,
+	"left-top": true, "left-middle": true, "left-bottom": true, "right-top": true,
+	"right-middle": true, "right-bottom": true,
+}
+
+// ruleListAtRules contain nested rules rather than declarations.
+var ruleListAtRules = map[string]bool{
+	"media": true, "supports": true, "layer": true, "container": true, "document": true,
+	"keyframes": true,
+}
+
+// printIgnoredProperties have no effect in paged output, whatever the engine.
+var printIgnoredProperties = []string{
+	"animation", "caret-color", "cursor", "pointer-events", "resize",
+	"scroll-behavior", "transition", "user-select",
+}
+
+// engineUnsupportedProperties lists properties HTML-based engines do not render.
+// LaTeX engines support only the subset veve translates (see converter.TranslateCSSToLaTeX).
+var engineUnsupportedProperties = map[string][]string{
+	"weasyprint": {"backdrop-filter", "box-shadow", "clip-path", "filter", "mix-blend-mode", "text-shadow"},
+	"prince":     {"backdrop-filter"},
+}
+
+// ParseCSS parses css into a stylesheet. Parsing recovers from errors the way
+// browsers do (skipping the malformed declaration or rule), so the stylesheet
+// is usable even if syntax errors are reported.
+func ParseCSS(css string) (*Stylesheet, []Issue) {
+	p := newCSSParser(css)
+	sheet := &Stylesheet{Rules: p.parseRules(false)}
+	return sheet, p.issues
+}
+
+// CheckCSS parses css and reports syntax errors, unknown at-rules, and, if engine
+// is non-empty, properties that engine does not support.
+func CheckCSS(css, engine string) []Issue {
+	sheet, issues := ParseCSS(css)
+
+	var walk func(rules []Rule)
+	walk = func(rules []Rule) {
+		for _, rule := range rules {
+			if rule.AtRule != "" && !knownAtRules[rule.AtRule] && !strings.HasPrefix(rule.AtRule, "-") {
+				issues = append(issues, Issue{SeverityWarning, rule.Line, rule.Column, fmt.Sprintf("unknown at-rule @%s", rule.AtRule)})
+			}
+			if engine != "" {
+				for _, decl := range rule.Declarations {
+					if reason := unsupportedReason(decl.Property, engine); reason != "" {
+						issues = append(issues, Issue{SeverityWarning, decl.Line, decl.Column, reason})
+					}
+				}
+			}
+			walk(rule.Rules)
+		}
+	}
+	walk(sheet.Rules)
+
+	SortIssues(issues)
+	return issues
+}
+
+// CheckTheme checks a theme file's content, which may start with YAML front matter.
+// Positions refer to lines of the original file.
+func CheckTheme(content, engine string) []Issue {
+	css := BlankFrontMatter(content)
+	if strings.TrimSpace(css) == "" {
+		return []Issue{{SeverityError, 1, 1, "theme contains no CSS"}}
+	}
+	return CheckCSS(css, engine)
+}
+
+// SortIssues orders issues by position.
+func SortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+}
+
+// unsupportedReason explains why engine ignores property, or returns "".
+func unsupportedReason(property, engine string) string {
+	for _, p := range printIgnoredProperties {
+		if property == p || strings.HasPrefix(property, p+"-") {
+			return fmt.Sprintf("property '%s' has no effect in PDF output", property)
+		}
+	}
+	for _, p := range engineUnsupportedProperties[strings.ToLower(engine)] {
+		if property == p {
+			return fmt.Sprintf("property '%s' is not supported by %s", property, engine)
+		}
+	}
+	return ""
+}
+
+// BlankFrontMatter replaces YAML front matter with empty lines, keeping line numbers intact.
+func BlankFrontMatter(content string) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[0]) != "---" {
+		return content
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			for j := 0; j <= i; j++ {
+				lines[j] = ""
+			}
+			return strings.Join(lines, "\n")
+		}
+	}
+	return content
+}
+
+// cssParser is a recursive-descent parser following the CSS Syntax Level 3
+// consumption rules closely enough for validation and translation.
+type cssParser struct {
+	src        string
+	pos        int
+	lineStarts []int
+	issues     []Issue
+}
+
+func newCSSParser(src string) *cssParser {
+	p := &cssParser{src: src, lineStarts: []int{0}}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			p.lineStarts = append(p.lineStarts, i+1)
+		}
+	}
+	return p
+}
+
+// position converts a byte offset into a 1-based line and column.
+func (p *cssParser) position(offset int) (int, int) {
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > offset }) - 1
+	return line + 1, offset - p.lineStarts[line] + 1
+}
+
+func (p *cssParser) errorAt(offset int, format string, args ...any) {
+	line, col := p.position(offset)
+	p.issues = append(p.issues, Issue{SeverityError, line, col, fmt.Sprintf(format, args...)})
+}
+
+func (p *cssParser) eof() bool { return p.pos >= len(p.src) }
+
+// skipSpace skips whitespace, comments, and HTML comment markers.
+func (p *cssParser) skipSpace() {
+	for !p.eof() {
+		switch {
+		case strings.ContainsRune(" \t\r\n\f", rune(p.src[p.pos])):
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			p.skipComment()
+		case strings.HasPrefix(p.src[p.pos:], "<!--"):
+			p.pos += 4
+		case strings.HasPrefix(p.src[p.pos:], "-->"):
+			p.pos += 3
+		default:
+			return
+		}
+	}
+}
+
+func (p *cssParser) skipComment() {
+	start := p.pos
+	end := strings.Index(p.src[p.pos+2:], "*/")
+	if end < 0 {
+		p.errorAt(start, "unterminated comment")
+		p.pos = len(p.src)
+		return
+	}
+	p.pos += end + 4
+}
+
+// skipString skips a quoted string starting at p.pos.
+func (p *cssParser) skipString() {
+	start, quote := p.pos, p.src[p.pos]
+	for p.pos++; !p.eof(); p.pos++ {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos++
+		case quote:
+			p.pos++
+			return
+		case '\n':
+			p.errorAt(start, "unterminated string")
+			return
+		}
+	}
+	p.errorAt(start, "unterminated string")
+}
+
+// consumeUntil consumes component values until one of the stop bytes appears at
+// nesting depth zero, and returns the consumed text. Strings, comments, and
+// (), [] nesting are honored; mismatched brackets are reported.
+func (p *cssParser) consumeUntil(stops string) string {
+	start := p.pos
+	var stack []byte
+	var text strings.Builder
+	for !p.eof() {
+		c := p.src[p.pos]
+		if len(stack) == 0 && strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+		switch {
+		case c == '"' || c == '\'':
+			s := p.pos
+			p.skipString()
+			text.WriteString(p.src[s:p.pos])
+			continue
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			p.skipComment()
+			text.WriteByte(' ')
+			continue
+		case c == '\\':
+			text.WriteString(p.src[p.pos:min(p.pos+2, len(p.src))])
+			p.pos = min(p.pos+2, len(p.src))
+			continue
+		case c == '(' || c == '[':
+			stack = append(stack, map[byte]byte{'(': ')', '[': ']'}[c])
+		case c == ')' || c == ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				p.errorAt(p.pos, "unexpected '%c'", c)
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		case c == '{' || c == '}':
+			// Braces inside parentheses (rare) are treated as the end of the value
+			if len(stack) > 0 {
+				p.errorAt(start, "unclosed '%c'", matchingOpen(stack[len(stack)-1]))
+				stack = nil
+				continue
+			}
+		}
+		text.WriteByte(c)
+		p.pos++
+	}
+	if len(stack) > 0 {
+		p.errorAt(start, "unclosed '%c'", matchingOpen(stack[len(stack)-1]))
+	}
+	return strings.TrimSpace(text.String())
+}
+
+func matchingOpen(closing byte) byte {
+	if closing == ')' {
+		return '('
+	}
+	return '['
+}
+
+// parseRules parses a list of rules until EOF or, if nested, a closing brace.
+func (p *cssParser) parseRules(nested bool) []Rule {
+	var rules []Rule
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return rules
+		}
+		switch p.src[p.pos] {
+		case '}':
+			if nested {
+				return rules
+			}
+			p.errorAt(p.pos, "unexpected '}'")
+			p.pos++
+		case '@':
+			rules = append(rules, p.parseAtRule())
+		default:
+			if rule, ok := p.parseStyleRule(); ok {
+				rules = append(rules, rule)
+			}
+		}
+	}
+}
+
+// parseAtRule parses an at-rule starting at '@'.
+func (p *cssParser) parseAtRule() Rule {
+	start := p.pos
+	p.pos++
+	nameStart := p.pos
+	for !p.eof() && isNameByte(p.src[p.pos]) {
+		p.pos++
+	}
+	rule := Rule{AtRule: strings.ToLower(p.src[nameStart:p.pos])}
+	rule.Line, rule.Column = p.position(start)
+	if rule.AtRule == "" {
+		p.errorAt(start, "expected at-rule name after '@'")
+	}
+
+	rule.Prelude = p.consumeUntil("{;}")
+	if p.eof() || p.src[p.pos] != '{' {
+		// Statement at-rule such as @import; a stray '}' is left for the caller
+		if !p.eof() && p.src[p.pos] == ';' {
+			p.pos++
+		}
+		return rule
+	}
+
+	rule.HasBlock = true
+	blockStart := p.pos
+	p.pos++
+	if ruleListAtRules[rule.AtRule] {
+		rule.Rules = p.parseRules(true)
+	} else {
+		rule.Declarations, rule.Rules = p.parseDeclarations()
+	}
+	p.closeBlock(blockStart)
+	return rule
+}
+
+// parseStyleRule parses a selector list and its declaration block.
+func (p *cssParser) parseStyleRule() (Rule, bool) {
+	start := p.pos
+	rule := Rule{HasBlock: true}
+	rule.Line, rule.Column = p.position(start)
+
+	rule.Prelude = p.consumeUntil("{}")
+	if p.eof() || p.src[p.pos] != '{' {
+		p.errorAt(start, "expected '{' after selector '%s'", rule.Prelude)
+		return rule, false
+	}
+	if rule.Prelude == "" {
+		p.errorAt(start, "missing selector before '{'")
+	}
+	for _, selector := range strings.Split(rule.Prelude, ",") {
+		if selector = strings.Join(strings.Fields(selector), " "); selector != "" {
+			rule.Selectors = append(rule.Selectors, selector)
+		} else if rule.Prelude != "" {
+			p.errorAt(start, "empty selector in '%s'", rule.Prelude)
+		}
+	}
+
+	blockStart := p.pos
+	p.pos++
+	rule.Declarations, rule.Rules = p.parseDeclarations()
+	p.closeBlock(blockStart)
+	return rule, true
+}
+
+// closeBlock consumes the closing brace of a block opened at blockStart.
+func (p *cssParser) closeBlock(blockStart int) {
+	if p.eof() {
+		p.errorAt(blockStart, "unclosed block (missing '}')")
+		return
+	}
+	p.pos++
+}
+
+// parseDeclarations parses declarations (and nested at-rules such as @page
+// margin boxes) up to, but not including, the closing brace.
+func (p *cssParser) parseDeclarations() ([]Declaration, []Rule) {
+	var decls []Declaration
+	var rules []Rule
+	for {
+		p.skipSpace()
+		if p.eof() || p.src[p.pos] == '}' {
+			return decls, rules
+		}
+
+		start := p.pos
+		switch c := p.src[p.pos]; {
+		case c == ';':
+			p.pos++
+			continue
+		case c == '@':
+			rules = append(rules, p.parseAtRule())
+			continue
+		case !isNameByte(c):
+			p.errorAt(start, "unexpected '%c' in declaration block", c)
+			p.consumeUntil(";}")
+			continue
+		}
+
+		for !p.eof() && isNameByte(p.src[p.pos]) {
+			p.pos++
+		}
+		property := p.src[start:p.pos]
+		p.skipSpace()
+		if p.eof() || p.src[p.pos] != ':' {
+			if !p.eof() && p.src[p.pos] == '{' {
+				p.errorAt(start, "nested rule '%s' is not supported here", property)
+				p.pos++
+				p.parseDeclarations()
+				p.closeBlock(start)
+				continue
+			}
+			p.errorAt(start, "expected ':' after property '%s'", property)
+			p.consumeUntil(";}")
+			continue
+		}
+		p.pos++
+
+		value := p.consumeUntil(";}")
+		// Custom properties are case-sensitive
+		if !strings.HasPrefix(property, "--") {
+			property = strings.ToLower(property)
+		}
+		decl := Declaration{Property: property}
+		decl.Line, decl.Column = p.position(start)
+		if v, ok := cutImportant(value); ok {
+			value, decl.Important = v, true
+		}
+		if value == "" {
+			p.errorAt(start, "property '%s' has no value", property)
+			continue
+		}
+		decl.Value = value
+		decls = append(decls, decl)
+	}
+}
+
+// cutImportant strips a trailing !important.
+func cutImportant(value string) (string, bool) {
+	i := strings.LastIndexByte(value, '!')
+	if i < 0 || !strings.EqualFold(strings.TrimSpace(value[i+1:]), "important") {
+		return value, false
+	}
+	return strings.TrimSpace(value[:i]), true
+}
+
+// isNameByte reports whether c can appear in a CSS identifier.
+func isNameByte(c byte) bool {
+	return c == '-' || c == '_' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
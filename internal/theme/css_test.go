@@ -0,0 +1,115 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseCSSStructure tests rules, at-rules, and declaration positions.
+func TestParseCSSStructure(t *testing.T) {
+	css := `/* header */
+body, h1 {
+  color: #333;
+  font-family: "A; B", serif !important;
+}
+@media print {
+  a { color: black; }
+}
+@page { margin: 1in; @top-center { content: "Title"; } }
+@import url("base.css");
+`
+	sheet, issues := ParseCSS(css)
+	if len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+	if len(sheet.Rules) != 4 {
+		t.Fatalf("expected 4 rules, got %d: %+v", len(sheet.Rules), sheet.Rules)
+	}
+
+	body := sheet.Rules[0]
+	if len(body.Selectors) != 2 || body.Selectors[1] != "h1" {
+		t.Errorf("unexpected selectors: %v", body.Selectors)
+	}
+	font := body.Declarations[1]
+	if font.Property != "font-family" || font.Value != `"A; B", serif` || !font.Important {
+		t.Errorf("unexpected declaration: %+v", font)
+	}
+	if font.Line != 4 || font.Column != 3 {
+		t.Errorf("expected font-family at 4:3, got %d:%d", font.Line, font.Column)
+	}
+
+	media := sheet.Rules[1]
+	if media.AtRule != "media" || media.Prelude != "print" || len(media.Rules) != 1 {
+		t.Errorf("unexpected @media rule: %+v", media)
+	}
+
+	page := sheet.Rules[2]
+	if page.AtRule != "page" || len(page.Declarations) != 1 || len(page.Rules) != 1 || page.Rules[0].AtRule != "top-center" {
+		t.Errorf("unexpected @page rule: %+v", page)
+	}
+
+	if imp := sheet.Rules[3]; imp.AtRule != "import" || imp.HasBlock {
+		t.Errorf("unexpected @import rule: %+v", imp)
+	}
+}
+
+// TestParseCSSErrors tests that syntax errors are reported with positions.
+func TestParseCSSErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+		want string
+	}{
+		{"unclosed block", "body {\n  color: red;\n", "1:6: error: unclosed block"},
+		{"stray brace", "body { }\n}", "2:1: error: unexpected '}'"},
+		{"missing colon", "body {\n  color red;\n}", "2:3: error: expected ':' after property 'color'"},
+		{"empty value", "body { color: ; }", "1:8: error: property 'color' has no value"},
+		{"unterminated string", "body {\n  content: \"oops;\n}", "2:12: error: unterminated string"},
+		{"unterminated comment", "body { } /* never closed", "1:10: error: unterminated comment"},
+		{"unbalanced paren", "body { color: rgb(1, 2, 3; }", "error: unclosed '('"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, issues := ParseCSS(tt.css)
+			if len(issues) == 0 {
+				t.Fatalf("expected an issue containing %q, got none", tt.want)
+			}
+			if got := issues[0].String(); !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckCSSWarnings tests unknown at-rules and per-engine property checks.
+func TestCheckCSSWarnings(t *testing.T) {
+	css := "@frobnicate foo;\n@-webkit-keyframes spin { }\nh1 { box-shadow: 0 0 1px; cursor: pointer; }\n"
+
+	issues := CheckCSS(css, "")
+	if len(issues) != 1 || issues[0].Message != "unknown at-rule @frobnicate" {
+		t.Errorf("expected only the unknown at-rule warning, got %v", issues)
+	}
+
+	issues = CheckCSS(css, "weasyprint")
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 warnings for weasyprint, got %v", issues)
+	}
+	if !strings.Contains(issues[1].Message, "'box-shadow' is not supported by weasyprint") {
+		t.Errorf("unexpected warning: %v", issues[1])
+	}
+
+	if issues := CheckCSS(css, "prince"); len(issues) != 2 {
+		t.Errorf("expected box-shadow to be accepted by prince, got %v", issues)
+	}
+}
+
+// TestCheckThemeKeepsLineNumbers tests that front matter does not shift positions.
+func TestCheckThemeKeepsLineNumbers(t *testing.T) {
+	content := "---\nname: quoted 'value\n---\nbody { color: ; }\n"
+
+	issues := CheckTheme(content, "")
+	if len(issues) != 1 || issues[0].Line != 4 {
+		t.Errorf("expected a single issue on line 4, got %v", issues)
+	}
+}
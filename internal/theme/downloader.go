@@ -2,28 +2,57 @@ package theme
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/madstone-tech/veve-cli/internal"
 )
 
+// DefaultMaxDownloadSize is the largest theme download accepted by default.
+const DefaultMaxDownloadSize = 50 * 1024 * 1024
+
 // Downloader handles downloading and extracting theme files from URLs or local paths.
 type Downloader struct {
-	timeout time.Duration
+	timeout     time.Duration
+	maxSize     int64
+	credentials []Credential
+	githubAPI   string            // Overrides the GitHub API URL in tests
+	transport   http.RoundTripper // Overrides the HTTP transport in tests
 }
 
-// NewDownloader creates a new downloader with default timeout.
+// NewDownloader creates a new downloader with default timeout and size limit.
 func NewDownloader() *Downloader {
 	return &Downloader{
 		timeout: 30 * time.Second,
+		maxSize: DefaultMaxDownloadSize,
 	}
 }
 
+// WithMaxSize sets the largest download accepted, in bytes.
+func (d *Downloader) WithMaxSize(maxSize int64) *Downloader {
+	d.maxSize = maxSize
+	return d
+}
+
+// WithCredentials sets the credentials sent with downloads. Each HTTPS request
+// uses the first credential for its host; requests to other hosts, and over
+// plain HTTP, are anonymous.
+func (d *Downloader) WithCredentials(creds []Credential) *Downloader {
+	d.credentials = creds
+	return d
+}
+
 // Download downloads a theme from a URL or local file path.
 // Returns the CSS content if successful.
 func (d *Downloader) Download(source string) (string, error) {
@@ -33,19 +62,101 @@ func (d *Downloader) Download(source string) (string, error) {
 	}
 
 	// Validate URL
+	source = githubRawURL(source)
 	if err := validateURL(source); err != nil {
 		return "", err
 	}
 
 	// Determine file type from URL
-	if strings.HasSuffix(strings.ToLower(source), ".zip") {
-		return d.downloadAndExtractZip(source)
+	if IsArchive(source) {
+		t, err := d.downloadArchive(source, "")
+		if err != nil {
+			return "", err
+		}
+		if t.Package != nil {
+			return "", fmt.Errorf("%s contains a theme package; install it with 'veve theme add'", source)
+		}
+		return t.CSS, nil
 	}
 
 	// Default to CSS file
 	return d.downloadCSSFile(source)
 }
 
+// Fetch downloads the file at an HTTPS URL, within the download limit and
+// with the credentials for its host, whatever its type.
+func (d *Downloader) Fetch(urlStr string) ([]byte, error) {
+	urlStr = githubRawURL(urlStr)
+	if err := validateURL(urlStr); err != nil {
+		return nil, err
+	}
+
+	resp, err := d.get(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", urlStr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, d.statusError(resp)
+	}
+	data, err := d.readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", urlStr, err)
+	}
+	return data, nil
+}
+
+// DownloadPackage reads a theme package (.vevetheme) from a URL or local file path.
+// Returns the raw archive; use InstallPackage to install it.
+func (d *Downloader) DownloadPackage(source string) ([]byte, error) {
+	if !isURL(source) {
+		// Expand ~ to home directory
+		if strings.HasPrefix(source, "~") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand home directory: %w", err)
+			}
+			source = filepath.Join(home, source[1:])
+		}
+
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read theme package: %w", err)
+		}
+		return data, nil
+	}
+
+	source = githubRawURL(source)
+	if err := validateURL(source); err != nil {
+		return nil, err
+	}
+
+	resp, err := d.get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download theme package: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, d.statusError(resp)
+	}
+	if err := checkContentType(resp, true); err != nil {
+		return nil, err
+	}
+
+	data, err := d.readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme package: %w", err)
+	}
+	return data, nil
+}
+
+// IsArchive reports whether source names a zip archive of themes.
+func IsArchive(source string) bool {
+	return strings.HasSuffix(strings.ToLower(source), ".zip")
+}
+
 // isURL checks if a string looks like a URL.
 func isURL(source string) bool {
 	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
@@ -76,6 +187,144 @@ func validateURL(urlStr string) error {
 	return nil
 }
 
+// get requests urlStr with the credential for its host, if any. The HTTP
+// client drops the credential if the server redirects to another domain, and
+// keepCredentialsSecure if it redirects to plain HTTP.
+func (d *Downloader) get(urlStr string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cred, ok := d.credentialFor(req.URL); ok {
+		cred.apply(req)
+	}
+
+	client := &http.Client{
+		Timeout:       d.timeout,
+		Transport:     d.transport,
+		CheckRedirect: keepCredentialsSecure,
+	}
+	resp, err := client.Do(req)
+	return resp, internal.WithKind(err, internal.KindNetwork)
+}
+
+// credentialFor returns the first credential for u's host, if u is HTTPS.
+func (d *Downloader) credentialFor(u *url.URL) (Credential, bool) {
+	if u.Scheme != "https" {
+		return Credential{}, false
+	}
+	for _, cred := range d.credentials {
+		if cred.matches(u.Hostname()) {
+			return cred, true
+		}
+	}
+	return Credential{}, false
+}
+
+// keepCredentialsSecure is the HTTP client's redirect policy: Go's default,
+// except that credentials aren't sent on to a plain HTTP URL.
+func keepCredentialsSecure(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Scheme != "https" {
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// readBody reads a response body, up to the download limit.
+func (d *Downloader) readBody(resp *http.Response) ([]byte, error) {
+	if resp.ContentLength > d.maxSize {
+		return nil, d.tooLarge()
+	}
+	return io.ReadAll(d.limitReader(resp))
+}
+
+// limitReader returns a reader of resp's body that fails once more than the
+// download limit has been read.
+func (d *Downloader) limitReader(resp *http.Response) io.Reader {
+	return &limitedReader{r: resp.Body, n: d.maxSize, err: d.tooLarge()}
+}
+
+// tooLarge describes a download over the limit.
+func (d *Downloader) tooLarge() error {
+	return fmt.Errorf("download is larger than the %s limit (raise theme_download_limit in veve.toml)", megabytes(d.maxSize))
+}
+
+// megabytes formats a size in MB.
+func megabytes(size int64) string {
+	return strconv.FormatFloat(float64(size)/(1024*1024), 'f', -1, 64) + " MB"
+}
+
+// limitedReader reads from r until more than n bytes have been read, then
+// fails with err.
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, l.err
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, l.err
+	}
+	return n, err
+}
+
+// checkContentType rejects responses whose Content-Type can't be a theme,
+// such as the HTML login page of a host that needs credentials. Archives
+// mustn't be text; stylesheets must be text or untyped binary. A missing
+// Content-Type is left to the content checks.
+func checkContentType(resp *http.Response, archive bool) error {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return fmt.Errorf("server returned an HTML page instead of a theme (a login or error page?)")
+	case archive && strings.HasPrefix(mediaType, "text/"):
+		return fmt.Errorf("server returned %s instead of an archive", mediaType)
+	case !archive && !strings.HasPrefix(mediaType, "text/") && mediaType != "application/octet-stream" && mediaType != "binary/octet-stream":
+		return fmt.Errorf("server returned %s instead of a stylesheet", mediaType)
+	}
+	return nil
+}
+
+// statusError describes a failed download. Private hosts answer 401 or 403
+// without credentials, and GitHub answers 404 for private repositories.
+func (d *Downloader) statusError(resp *http.Response) error {
+	err := internal.WithKind(fmt.Errorf("download failed with status %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode)), internal.KindNetwork)
+	host := resp.Request.URL.Hostname()
+	_, authenticated := d.credentialFor(resp.Request.URL)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		if authenticated {
+			return fmt.Errorf("%w (the credentials for %s were rejected)", err, host)
+		}
+		return fmt.Errorf("%w (%s may need credentials: set VEVE_THEME_HOST and VEVE_THEME_TOKEN, or add a [[theme_sources]] entry to veve.toml)", err, host)
+	case resp.StatusCode == http.StatusNotFound && !authenticated && isGitHubHost(host):
+		return fmt.Errorf("%w (for a private repository, set GITHUB_TOKEN)", err)
+	}
+	return err
+}
+
+// isGitHubHost reports whether host serves GitHub repository files.
+func isGitHubHost(host string) bool {
+	for _, h := range githubHosts {
+		if strings.EqualFold(host, h) {
+			return true
+		}
+	}
+	return false
+}
+
 // downloadFromFile downloads a theme from a local file path.
 func (d *Downloader) downloadFromFile(filePath string) (string, error) {
 	// Expand ~ to home directory
@@ -116,11 +365,7 @@ func (d *Downloader) downloadFromFile(filePath string) (string, error) {
 
 // downloadCSSFile downloads a single CSS file from a URL.
 func (d *Downloader) downloadCSSFile(urlStr string) (string, error) {
-	client := &http.Client{
-		Timeout: d.timeout,
-	}
-
-	resp, err := client.Get(urlStr)
+	resp, err := d.get(urlStr)
 	if err != nil {
 		return "", fmt.Errorf("failed to download file: %w", err)
 	}
@@ -128,11 +373,14 @@ func (d *Downloader) downloadCSSFile(urlStr string) (string, error) {
 
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download failed with status %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		return "", d.statusError(resp)
+	}
+	if err := checkContentType(resp, false); err != nil {
+		return "", err
 	}
 
 	// Read content
-	content, err := io.ReadAll(resp.Body)
+	content, err := d.readBody(resp)
 	if err != nil {
 		return "", fmt.Errorf("failed to read downloaded content: %w", err)
 	}
@@ -153,104 +401,92 @@ func (d *Downloader) downloadCSSFile(urlStr string) (string, error) {
 	return css, nil
 }
 
-// downloadAndExtractZip downloads a zip file and extracts the first CSS/LaTeX file found.
-func (d *Downloader) downloadAndExtractZip(urlStr string) (string, error) {
-	client := &http.Client{
-		Timeout: d.timeout,
-	}
-
-	resp, err := client.Get(urlStr)
-	if err != nil {
-		return "", fmt.Errorf("failed to download zip file: %w", err)
+// DownloadArchive picks a theme out of a zip archive at a URL or local path:
+// the CSS file or theme package (with its fonts and assets) at file, or, if
+// file is empty, as described for selectTheme. An archive holding several
+// themes gives an *AmbiguousThemeError listing them.
+func (d *Downloader) DownloadArchive(source, file string) (*DownloadedTheme, error) {
+	if isURL(source) {
+		source = githubRawURL(source)
+		if err := validateURL(source); err != nil {
+			return nil, err
+		}
+	} else if strings.HasPrefix(source, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand home directory: %w", err)
+		}
+		source = filepath.Join(home, source[1:])
 	}
-	defer resp.Body.Close()
+	return d.downloadArchive(source, file)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download failed with status %d", resp.StatusCode)
+// downloadArchive opens the archive at source and picks the theme at file.
+func (d *Downloader) downloadArchive(source, file string) (*DownloadedTheme, error) {
+	var f *os.File
+	var err error
+	if isURL(source) {
+		if f, err = d.spool(source); err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+	} else if f, err = os.Open(source); err != nil {
+		return nil, fmt.Errorf("failed to read zip file: %w", err)
 	}
+	defer f.Close()
 
-	// Read entire response into memory
-	zipContent, err := io.ReadAll(resp.Body)
+	info, err := f.Stat()
 	if err != nil {
-		return "", fmt.Errorf("failed to read zip file: %w", err)
+		return nil, fmt.Errorf("failed to read zip file: %w", err)
 	}
+	return archiveTheme(f, info.Size(), file)
+}
 
-	// Extract CSS or LaTeX files from zip
-	return d.extractFromZip(zipContent)
+// ArchiveTheme picks the theme at file out of a zip archive's content, as
+// DownloadArchive does.
+func ArchiveTheme(data []byte, file string) (*DownloadedTheme, error) {
+	return archiveTheme(bytes.NewReader(data), int64(len(data)), file)
 }
 
-// extractFromZip extracts theme content from a zip archive.
-func (d *Downloader) extractFromZip(zipContent []byte) (string, error) {
-	// Create a reader for the zip content
-	reader := strings.NewReader(string(zipContent))
-	zr, err := zip.NewReader(reader, int64(len(zipContent)))
+// archiveTheme reads a zip archive and picks the theme at file.
+func archiveTheme(r io.ReaderAt, size int64, file string) (*DownloadedTheme, error) {
+	zr, err := zip.NewReader(r, size)
 	if err != nil {
-		return "", fmt.Errorf("invalid zip file: %w", err)
+		return nil, fmt.Errorf("invalid zip file: %w", err)
 	}
-
-	// Look for CSS, LaTeX, or Markdown files
-	validExtensions := map[string]bool{
-		".css": true,
-		".tex": true,
-		".md":  true,
+	files, err := readZipFiles(zr)
+	if err != nil {
+		return nil, err
 	}
+	return selectTheme(files, strings.Trim(path.Clean("/"+file), "/"))
+}
 
-	for _, file := range zr.File {
-		// Skip directories
-		if file.FileInfo().IsDir() {
-			continue
-		}
-
-		// Check extension
-		ext := filepath.Ext(strings.ToLower(file.Name))
-		if !validExtensions[ext] {
-			continue
-		}
-
-		// Read file content
-		f, err := file.Open()
-		if err != nil {
-			continue
-		}
-
-		content, err := io.ReadAll(f)
-		f.Close()
-
-		if err != nil {
-			continue
-		}
-
-		contentStr := string(content)
-
-		// For CSS, validate it
-		if ext == ".css" {
-			if err := ValidateCSS(contentStr); err != nil {
-				continue
-			}
-
-			// Parse metadata and return CSS
-			_, css, err := ParseMetadata(contentStr)
-			if err != nil {
-				css = contentStr
-			}
-			return css, nil
-		}
-
-		// For LaTeX and Markdown, return as-is after validation
-		if ext == ".tex" {
-			if err := ValidateLaTeX(contentStr); err != nil {
-				continue
-			}
-			return contentStr, nil
-		}
+// spool downloads an archive to a temporary file, which the caller removes;
+// zip needs random access, and the archive needn't fit in memory.
+func (d *Downloader) spool(urlStr string) (*os.File, error) {
+	resp, err := d.get(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download zip file: %w", err)
+	}
+	defer resp.Body.Close()
 
-		// For Markdown, just return it
-		if ext == ".md" {
-			return contentStr, nil
-		}
+	if resp.StatusCode != http.StatusOK {
+		return nil, d.statusError(resp)
+	}
+	if err := checkContentType(resp, true); err != nil {
+		return nil, err
 	}
 
-	return "", fmt.Errorf("no valid CSS, LaTeX, or Markdown files found in zip archive")
+	tmp, err := os.CreateTemp("", "veve-theme-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download zip file: %w", err)
+	}
+	if _, err := io.Copy(tmp, d.limitReader(resp)); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to read zip file: %w", err)
+	}
+	return tmp, nil
 }
 
 // ValidateFileContent checks if content looks like valid CSS, LaTeX, or Markdown.
@@ -1,6 +1,10 @@
 package theme
 
 import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -218,3 +222,117 @@ func TestDownloadExtensionDetection(t *testing.T) {
 		t.Errorf("failed to load Markdown file: %v", err)
 	}
 }
+
+// zipArchive builds a zip archive of files, compressed.
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serve starts a server answering every request with body as contentType.
+func serve(t *testing.T, contentType string, body []byte) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// TestDownloadSizeLimit tests that downloads over the limit are rejected.
+func TestDownloadSizeLimit(t *testing.T) {
+	css := []byte("body { color: blue; }" + strings.Repeat(" ", 2000))
+	url := serve(t, "text/css", css)
+
+	if _, err := NewDownloader().WithMaxSize(int64(len(css))).downloadCSSFile(url); err != nil {
+		t.Errorf("download at the limit failed: %v", err)
+	}
+	_, err := NewDownloader().WithMaxSize(1024).downloadCSSFile(url)
+	if err == nil || !strings.Contains(err.Error(), "theme_download_limit") {
+		t.Errorf("download over the limit: error = %v, want a limit error", err)
+	}
+
+	archive := zipArchive(t, map[string]string{"theme.css": string(css)})
+	_, err = NewDownloader().WithMaxSize(100).downloadArchive(serve(t, "application/zip", archive), "")
+	if err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("zip over the limit: error = %v, want a limit error", err)
+	}
+}
+
+// TestDownloadContentType tests rejecting responses that can't be themes.
+func TestDownloadContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		archive     bool
+		wantErr     bool
+	}{
+		{"text/css; charset=utf-8", false, false},
+		{"text/plain", false, false},
+		{"application/octet-stream", false, false},
+		{"", false, false},
+		{"text/html; charset=utf-8", false, true},
+		{"application/json", false, true},
+		{"image/png", false, true},
+		{"application/zip", true, false},
+		{"application/octet-stream", true, false},
+		{"text/html", true, true},
+		{"text/plain", true, true},
+	}
+
+	for _, test := range tests {
+		resp := &http.Response{Header: http.Header{"Content-Type": []string{test.contentType}}}
+		if err := checkContentType(resp, test.archive); (err != nil) != test.wantErr {
+			t.Errorf("checkContentType(%q, archive=%v) = %v, wantErr %v", test.contentType, test.archive, err, test.wantErr)
+		}
+	}
+}
+
+// TestDownloadZip tests extracting a theme from a downloaded zip archive.
+func TestDownloadZip(t *testing.T) {
+	archive := zipArchive(t, map[string]string{"theme/theme.css": "body { color: blue; }"})
+	got, err := NewDownloader().downloadArchive(serve(t, "application/zip", archive), "")
+	if err != nil {
+		t.Fatalf("downloadArchive failed: %v", err)
+	}
+	if got.CSS != "body { color: blue; }" {
+		t.Errorf("CSS = %q", got.CSS)
+	}
+}
+
+// TestDownloadZipRejectsHostileArchives tests zip bomb and path traversal defenses.
+func TestDownloadZipRejectsHostileArchives(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"zip bomb", map[string]string{"theme.css": strings.Repeat("a", 8*1024*1024)}, "expands too much"},
+		{"traversal", map[string]string{"../../theme.css": "body { }"}, "escapes the archive root"},
+		{"absolute", map[string]string{"/etc/theme.css": "body { }"}, "escapes the archive root"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			url := serve(t, "application/zip", zipArchive(t, test.files))
+			_, err := NewDownloader().downloadArchive(url, "")
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("downloadArchive error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
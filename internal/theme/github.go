@@ -0,0 +1,173 @@
+package theme
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitHubPrefix introduces a theme source in a GitHub repository:
+// github:owner/repo[@ref][/path].
+const GitHubPrefix = "github:"
+
+// GitHubSource is a theme in a GitHub repository: a CSS file, a .vevetheme
+// package, or a directory holding a theme package (with a manifest.json) or a
+// single CSS file. An empty Ref means the default branch and an empty Path
+// the repository root.
+type GitHubSource struct {
+	Owner string
+	Repo  string
+	Ref   string
+	Path  string
+}
+
+// IsGitHubSource reports whether source uses the github: shorthand.
+func IsGitHubSource(source string) bool {
+	return strings.HasPrefix(source, GitHubPrefix)
+}
+
+// ParseGitHubSource parses github:owner/repo[@ref][/path]. The ref ends at
+// the first slash after it, so branches with slashes in their names can't be
+// named; use a tag or commit instead.
+func ParseGitHubSource(source string) (GitHubSource, error) {
+	rest, ok := strings.CutPrefix(source, GitHubPrefix)
+	if !ok {
+		return GitHubSource{}, fmt.Errorf("%q is not a github: source", source)
+	}
+
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return GitHubSource{}, fmt.Errorf("invalid GitHub source %q: expected github:owner/repo[@ref][/path]", source)
+	}
+
+	src := GitHubSource{Owner: parts[0], Repo: parts[1]}
+	if len(parts) == 3 {
+		src.Path = parts[2]
+	}
+	if repo, ref, found := strings.Cut(src.Repo, "@"); found {
+		src.Repo, src.Ref = repo, ref
+		if ref == "" {
+			return GitHubSource{}, fmt.Errorf("invalid GitHub source %q: empty ref after @", source)
+		}
+	}
+
+	src.Path = strings.Trim(src.Path, "/")
+	if src.Path != "" && !isSafePackagePath(src.Path) {
+		return GitHubSource{}, fmt.Errorf("invalid GitHub source %q: path must stay inside the repository", source)
+	}
+	for _, name := range []string{src.Owner, src.Repo} {
+		if strings.ContainsAny(name, `@:?#\ `) || name == "." || name == ".." {
+			return GitHubSource{}, fmt.Errorf("invalid GitHub source %q: bad owner or repository name", source)
+		}
+	}
+	return src, nil
+}
+
+// String returns the source in github: form.
+func (s GitHubSource) String() string {
+	source := GitHubPrefix + s.Owner + "/" + s.Repo
+	if s.Ref != "" {
+		source += "@" + s.Ref
+	}
+	if s.Path != "" {
+		source += "/" + s.Path
+	}
+	return source
+}
+
+// githubAPI is the base URL of the GitHub REST API.
+const githubAPI = "https://api.github.com"
+
+// DownloadGitHub downloads the repository's tarball through the GitHub API
+// and picks out the theme at src.Path, as described for selectTheme. Private repositories need a token, from
+// GITHUB_TOKEN or a theme source for api.github.com.
+func (d *Downloader) DownloadGitHub(src GitHubSource) (*DownloadedTheme, error) {
+	apiURL := d.githubAPI
+	if apiURL == "" {
+		apiURL = githubAPI
+	}
+	tarballURL := fmt.Sprintf("%s/repos/%s/%s/tarball", apiURL, url.PathEscape(src.Owner), url.PathEscape(src.Repo))
+	if src.Ref != "" {
+		tarballURL += "/" + url.PathEscape(src.Ref)
+	}
+
+	resp, err := d.get(tarballURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", src, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %w", src, d.statusError(resp))
+	}
+	if err := checkContentType(resp, true); err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", src, err)
+	}
+
+	files, commit, err := readTarball(d.limitReader(resp), src.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	t, err := selectTheme(files, src.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+	t.Commit = commit
+	return t, nil
+}
+
+// readTarball reads the files at or under dir (a file or directory path, ""
+// for everything) from a GitHub tarball, keyed by their path in the
+// repository. The tarball's top-level directory is named
+// owner-repo-<commit>; the commit is returned.
+func readTarball(r io.Reader, dir string) (map[string][]byte, string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid tarball: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	commit := ""
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid tarball: %w", err)
+		}
+
+		top, name, _ := strings.Cut(header.Name, "/")
+		if commit == "" {
+			if i := strings.LastIndex(top, "-"); i >= 0 {
+				commit = top[i+1:]
+			}
+		}
+		if header.Typeflag != tar.TypeReg || !isSafePackagePath(name) {
+			continue
+		}
+		if dir != "" && name != dir && !strings.HasPrefix(name, dir+"/") {
+			continue
+		}
+
+		total += header.Size
+		if total > maxPackageSize {
+			return nil, "", fmt.Errorf("theme is too large (limit %d MB)", maxPackageSize/1024/1024)
+		}
+		if len(files) >= maxPackageFiles {
+			return nil, "", fmt.Errorf("theme has too many files (limit %d)", maxPackageFiles)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, header.Size))
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid tarball: %w", err)
+		}
+		files[name] = data
+	}
+	return files, commit, nil
+}
@@ -0,0 +1,198 @@
+package theme
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseGitHubSource tests parsing github:owner/repo[@ref][/path].
+func TestParseGitHubSource(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    GitHubSource
+		wantErr bool
+	}{
+		{"github:acme/themes", GitHubSource{Owner: "acme", Repo: "themes"}, false},
+		{"github:acme/themes@v2.1", GitHubSource{Owner: "acme", Repo: "themes", Ref: "v2.1"}, false},
+		{"github:acme/themes/brand/", GitHubSource{Owner: "acme", Repo: "themes", Path: "brand"}, false},
+		{"github:acme/themes@main/brand/brand.css", GitHubSource{Owner: "acme", Repo: "themes", Ref: "main", Path: "brand/brand.css"}, false},
+		{"github:acme", GitHubSource{}, true},
+		{"github:/themes", GitHubSource{}, true},
+		{"github:acme/themes@", GitHubSource{}, true},
+		{"github:acme/themes/../secrets", GitHubSource{}, true},
+		{"github:ac me/themes", GitHubSource{}, true},
+		{"https://github.com/acme/themes", GitHubSource{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := ParseGitHubSource(test.input)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseGitHubSource(%q) error = %v, wantErr %v", test.input, err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("ParseGitHubSource(%q) = %+v, want %+v", test.input, got, test.want)
+			}
+			if err == nil && !strings.HasPrefix(got.String(), GitHubPrefix+"acme/themes") {
+				t.Errorf("String() = %q", got.String())
+			}
+		})
+	}
+}
+
+// githubTarball builds a tarball laid out as GitHub serves them.
+func githubTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "acme-themes-abc1234/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		header := &tar.Header{Name: "acme-themes-abc1234/" + name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestDownloadGitHub tests picking a theme out of a repository tarball.
+func TestDownloadGitHub(t *testing.T) {
+	tarball := githubTarball(t, map[string]string{
+		"README.md":             "# Themes",
+		"brand/manifest.json":   `{"name": "brand", "version": "2.0.0", "css": "theme.css"}`,
+		"brand/theme.css":       "body { color: navy; }",
+		"brand/.github/ci.yml":  "on: push",
+		"report/report.css":     "h1 { color: gray; }",
+		"pair/a.css":            "a { color: red; }",
+		"pair/b.css":            "b { color: blue; }",
+		"packages/x.vevetheme":  "PK",
+		"docs/notes/readme.txt": "not a theme",
+	})
+
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.Write(tarball)
+	}))
+	defer server.Close()
+
+	d := NewDownloader()
+	d.githubAPI = server.URL
+
+	t.Run("package directory", func(t *testing.T) {
+		got, err := d.DownloadGitHub(GitHubSource{Owner: "acme", Repo: "themes", Ref: "v2", Path: "brand"})
+		if err != nil {
+			t.Fatalf("DownloadGitHub failed: %v", err)
+		}
+		if requested != "/repos/acme/themes/tarball/v2" {
+			t.Errorf("requested %s", requested)
+		}
+		if got.Commit != "abc1234" || got.Package == nil {
+			t.Fatalf("DownloadGitHub = %+v, want a package at abc1234", got)
+		}
+		manifest, err := InstallPackage(got.Package, filepath.Join(t.TempDir(), "brand"))
+		if err != nil {
+			t.Fatalf("InstallPackage failed: %v", err)
+		}
+		if manifest.Version != "2.0.0" {
+			t.Errorf("manifest version = %q", manifest.Version)
+		}
+	})
+
+	t.Run("directory with one stylesheet", func(t *testing.T) {
+		got, err := d.DownloadGitHub(GitHubSource{Owner: "acme", Repo: "themes", Path: "report"})
+		if err != nil {
+			t.Fatalf("DownloadGitHub failed: %v", err)
+		}
+		if requested != "/repos/acme/themes/tarball" {
+			t.Errorf("requested %s", requested)
+		}
+		if got.CSS != "h1 { color: gray; }" {
+			t.Errorf("CSS = %q", got.CSS)
+		}
+	})
+
+	t.Run("stylesheet", func(t *testing.T) {
+		got, err := d.DownloadGitHub(GitHubSource{Owner: "acme", Repo: "themes", Path: "pair/b.css"})
+		if err != nil {
+			t.Fatalf("DownloadGitHub failed: %v", err)
+		}
+		if got.CSS != "b { color: blue; }" {
+			t.Errorf("CSS = %q", got.CSS)
+		}
+	})
+
+	t.Run("package file", func(t *testing.T) {
+		got, err := d.DownloadGitHub(GitHubSource{Owner: "acme", Repo: "themes", Path: "packages/x.vevetheme"})
+		if err != nil {
+			t.Fatalf("DownloadGitHub failed: %v", err)
+		}
+		if string(got.Package) != "PK" {
+			t.Errorf("Package = %q", got.Package)
+		}
+	})
+
+	for _, test := range []struct{ path, want string }{
+		{"pair", "found several themes (a.css, b.css)"},
+		{"docs", "no manifest.json or CSS file"},
+		{"missing", "not found"},
+		{"README.md", "not a CSS file"},
+	} {
+		t.Run("error "+test.path, func(t *testing.T) {
+			_, err := d.DownloadGitHub(GitHubSource{Owner: "acme", Repo: "themes", Path: test.path})
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("DownloadGitHub(%s) error = %v, want %q", test.path, err, test.want)
+			}
+		})
+	}
+}
+
+// TestSources tests recording theme sources.
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	sources, err := LoadSources(dir)
+	if err != nil || len(sources) != 0 {
+		t.Fatalf("LoadSources of an empty directory = %v, %v", sources, err)
+	}
+
+	installed := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	sources["company/brand"] = Source{Source: "github:acme/themes/brand", Commit: "abc1234", Installed: installed}
+	if err := sources.Save(dir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadSources(dir)
+	if err != nil {
+		t.Fatalf("LoadSources failed: %v", err)
+	}
+	if loaded["company/brand"] != sources["company/brand"] {
+		t.Errorf("loaded %+v, want %+v", loaded["company/brand"], sources["company/brand"])
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, SourcesFile), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSources(dir); err == nil {
+		t.Error("expected an error for a corrupt sources file")
+	}
+}
@@ -0,0 +1,316 @@
+package theme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/filelock"
+)
+
+const (
+	// maxImportDepth limits nested @import chains.
+	maxImportDepth = 10
+
+	// importCacheTTL is how long downloaded @import stylesheets are reused.
+	importCacheTTL = 24 * time.Hour
+)
+
+// ImportResolver inlines @import rules in theme CSS, so HTML engines that block
+// network access and the LaTeX translation both see the full stylesheet.
+// Local paths are resolved against the importing file; HTTPS imports are
+// downloaded and cached.
+type ImportResolver struct {
+	cacheDir  string
+	fetch     func(url string) (string, error)
+	localOnly bool
+}
+
+// NewImportResolver creates a resolver that caches HTTPS imports in cacheDir.
+// An empty cacheDir disables caching.
+func NewImportResolver(cacheDir string) *ImportResolver {
+	return &ImportResolver{
+		cacheDir: cacheDir,
+		fetch:    NewDownloader().Download,
+	}
+}
+
+// LocalOnly makes r leave HTTPS imports in place, nested ones included,
+// rather than download them: for CSS that is sanitized, whose external
+// imports are removed. It returns r.
+func (r *ImportResolver) LocalOnly() *ImportResolver {
+	r.localOnly = true
+	return r
+}
+
+// Resolve inlines the @import rules of css, whose relative imports resolve against
+// baseDir. Imports with a media query are wrapped in @media. Imports that cannot be
+// resolved are left in place and described in the returned problems.
+// Inlined stylesheets are joined onto the line of their @import, so line numbers
+// in the importing stylesheet are unchanged.
+func (r *ImportResolver) Resolve(css, baseDir string) (string, []string) {
+	var problems []string
+	out := r.resolve(css, baseDir, 0, map[string]bool{}, &problems)
+	return out, problems
+}
+
+// resolve inlines the imports of css; base is a directory or an HTTPS URL.
+func (r *ImportResolver) resolve(css, base string, depth int, stack map[string]bool, problems *[]string) string {
+	var out strings.Builder
+	p := newCSSParser(css)
+	last := 0
+	braces := 0
+
+	for !p.eof() {
+		switch c := p.src[p.pos]; {
+		case c == '/' && strings.HasPrefix(p.src[p.pos:], "/*"):
+			p.skipComment()
+		case c == '"' || c == '\'':
+			p.skipString()
+		case c == '{':
+			braces++
+			p.pos++
+		case c == '}':
+			braces--
+			p.pos++
+		case c == '@' && braces == 0 && isImportKeyword(p.src[p.pos:]):
+			start := p.pos
+			p.pos += len("@import")
+			prelude := p.consumeUntil(";{}")
+			if !p.eof() && p.src[p.pos] == ';' {
+				p.pos++
+			}
+			statement := p.src[start:p.pos]
+
+			inlined, ok := r.inline(prelude, base, depth, stack, problems)
+			if !ok {
+				continue
+			}
+			out.WriteString(p.src[last:start])
+			out.WriteString(inlined)
+			out.WriteString(strings.Repeat("\n", strings.Count(statement, "\n")))
+			last = p.pos
+		default:
+			p.pos++
+		}
+	}
+	out.WriteString(p.src[last:])
+	return out.String()
+}
+
+// inline loads and resolves the stylesheet named by an @import prelude.
+func (r *ImportResolver) inline(prelude, base string, depth int, stack map[string]bool, problems *[]string) (string, bool) {
+	target, condition := splitImportPrelude(prelude)
+	if target == "" {
+		*problems = append(*problems, fmt.Sprintf("cannot resolve @import%s: missing URL", prelude))
+		return "", false
+	}
+	if depth >= maxImportDepth {
+		*problems = append(*problems, fmt.Sprintf("cannot resolve @import of %s: imports nested more than %d levels", target, maxImportDepth))
+		return "", false
+	}
+	lowerCondition := strings.ToLower(condition)
+	if strings.HasPrefix(lowerCondition, "layer") || strings.Contains(lowerCondition, "supports(") {
+		*problems = append(*problems, fmt.Sprintf("cannot inline @import of %s: layer() and supports() conditions are not supported", target))
+		return "", false
+	}
+
+	location, remote, err := resolveImportLocation(target, base)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("cannot resolve @import of %s: %v", target, err))
+		return "", false
+	}
+	if remote && r.localOnly {
+		return "", false
+	}
+	if stack[location] {
+		*problems = append(*problems, fmt.Sprintf("cannot resolve @import of %s: circular import", target))
+		return "", false
+	}
+
+	var content string
+	var opts ProcessOptions
+	if remote {
+		content, err = r.download(location)
+		opts.BaseURL = location
+	} else {
+		var data []byte
+		data, err = os.ReadFile(location)
+		content = string(data)
+		opts.BaseDir = filepath.Dir(location)
+	}
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("cannot resolve @import of %s: %v", target, err))
+		return "", false
+	}
+	if _, body, err := ParseMetadata(content); err == nil {
+		content = body
+	}
+
+	stack[location] = true
+	nestedBase := opts.BaseURL
+	if !remote {
+		nestedBase = opts.BaseDir
+	}
+	content = r.resolve(content, nestedBase, depth+1, stack, problems)
+	delete(stack, location)
+
+	// Make the imported stylesheet's relative URLs absolute and join it onto one line
+	content, _ = ProcessCSS(stripCharset(content), opts)
+	content = strings.ReplaceAll(content, "\n", " ")
+
+	if condition != "" {
+		content = fmt.Sprintf("@media %s{%s}", condition, content)
+	}
+	return content, true
+}
+
+// download returns an HTTPS stylesheet, from the cache if it was fetched recently.
+// A stale cached copy is used if the download fails, so conversions work offline.
+// The cache entry is locked while it is refreshed, so parallel veve processes
+// download it once.
+func (r *ImportResolver) download(location string) (string, error) {
+	var cachePath string
+	if r.cacheDir != "" {
+		sum := sha256.Sum256([]byte(location))
+		cachePath = filepath.Join(r.cacheDir, hex.EncodeToString(sum[:])+".css")
+		if content, ok := readFreshCache(cachePath); ok {
+			return content, nil
+		}
+
+		// Without the lock the cache still works, it may just download twice
+		if lock, err := filelock.Acquire(cachePath+".lock", filelock.DefaultTimeout); err == nil {
+			defer lock.Unlock()
+			// Another process may have refreshed it while we waited
+			if content, ok := readFreshCache(cachePath); ok {
+				return content, nil
+			}
+		}
+	}
+
+	content, err := r.fetch(location)
+	if err != nil {
+		if cachePath != "" {
+			if data, readErr := os.ReadFile(cachePath); readErr == nil {
+				return string(data), nil
+			}
+		}
+		return "", err
+	}
+
+	// The cache is best-effort; a failed write only costs a download next time
+	if cachePath != "" {
+		if err := os.MkdirAll(r.cacheDir, 0o755); err == nil {
+			_ = filelock.WriteFile(cachePath, []byte(content), 0o644)
+		}
+	}
+	return content, nil
+}
+
+// readFreshCache returns a cached import fetched within importCacheTTL.
+func readFreshCache(cachePath string) (string, bool) {
+	info, err := os.Stat(cachePath)
+	if err != nil || time.Since(info.ModTime()) >= importCacheTTL {
+		return "", false
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// resolveImportLocation returns the file path or HTTPS URL an import target refers to.
+func resolveImportLocation(target, base string) (string, bool, error) {
+	if strings.HasPrefix(base, "https://") {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return "", false, err
+		}
+		ref, err := url.Parse(target)
+		if err != nil {
+			return "", false, err
+		}
+		target = baseURL.ResolveReference(ref).String()
+
+		// Downloaded stylesheets must not pull in local files
+		if urlScheme(target) != "https" {
+			return "", false, fmt.Errorf("a downloaded stylesheet can only import HTTPS URLs")
+		}
+	} else if strings.HasPrefix(target, "//") {
+		target = "https:" + target
+	}
+
+	switch urlScheme(target) {
+	case "https":
+		if err := validateURL(target); err != nil {
+			return "", false, err
+		}
+		return target, true, nil
+	case "":
+		if filepath.IsAbs(target) {
+			return filepath.Clean(target), false, nil
+		}
+		return filepath.Join(base, filepath.FromSlash(target)), false, nil
+	case "file":
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", false, err
+		}
+		return filepath.FromSlash(u.Path), false, nil
+	default:
+		return "", false, fmt.Errorf("only local files and HTTPS URLs can be imported")
+	}
+}
+
+// splitImportPrelude splits an @import prelude into its URL and the media
+// query (or other condition) that follows it.
+func splitImportPrelude(prelude string) (string, string) {
+	prelude = strings.TrimSpace(prelude)
+	target := importTarget(prelude)
+
+	rest := prelude
+	switch {
+	case strings.HasPrefix(strings.ToLower(rest), "url("):
+		if end := strings.IndexByte(rest, ')'); end >= 0 {
+			rest = rest[end+1:]
+		} else {
+			rest = ""
+		}
+	case len(rest) > 0 && (rest[0] == '"' || rest[0] == '\''):
+		if end := strings.IndexByte(rest[1:], rest[0]); end >= 0 {
+			rest = rest[end+2:]
+		} else {
+			rest = ""
+		}
+	default:
+		rest = ""
+	}
+	return target, strings.TrimSpace(rest)
+}
+
+// isImportKeyword reports whether s starts with the @import keyword.
+func isImportKeyword(s string) bool {
+	const keyword = "@import"
+	if len(s) < len(keyword) || !strings.EqualFold(s[:len(keyword)], keyword) {
+		return false
+	}
+	return len(s) == len(keyword) || !isNameByte(s[len(keyword)])
+}
+
+// stripCharset removes a leading @charset rule, which is only valid at the
+// start of a stylesheet.
+func stripCharset(css string) string {
+	trimmed := strings.TrimLeft(css, " \t\r\n\ufeff")
+	if strings.HasPrefix(strings.ToLower(trimmed), "@charset") {
+		if end := strings.IndexByte(trimmed, ';'); end >= 0 {
+			return trimmed[end+1:]
+		}
+	}
+	return css
+}
@@ -0,0 +1,152 @@
+package theme
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestResolveLocalImports tests that local imports are inlined with their URLs
+// resolved against the imported file and line numbers preserved.
+func TestResolveLocalImports(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "parts"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"parts/fonts.css": "@charset \"utf-8\";\n@import \"base.css\";\n@font-face { src: url(../fonts/a.woff2); }\n",
+		"parts/base.css":  "/* base */\np { margin: 0; }\n",
+		"print.css":       "h1 { color: red; }",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	css := "@import url(\"parts/fonts.css\");\n@import 'print.css' print;\nbody { color: black; }\n"
+	got, problems := NewImportResolver("").Resolve(css, dir)
+	if len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	lines := strings.Split(got, "\n")
+	if len(lines) != 4 || lines[2] != "body { color: black; }" {
+		t.Fatalf("expected line numbers to be preserved, got %q", got)
+	}
+	if want := "p{margin:0}"; !strings.Contains(lines[0], want) {
+		t.Errorf("expected nested import %q inlined, got %q", want, lines[0])
+	}
+	if want := `url("file://` + filepath.ToSlash(filepath.Join(dir, "fonts", "a.woff2")) + `")`; !strings.Contains(lines[0], want) {
+		t.Errorf("expected font URL %s, got %q", want, lines[0])
+	}
+	if strings.Contains(got, "@charset") {
+		t.Errorf("expected @charset of imported file to be dropped, got %q", got)
+	}
+	if want := "@media print{h1{color:red}}"; lines[1] != want {
+		t.Errorf("expected media import %q, got %q", want, lines[1])
+	}
+}
+
+// TestResolveImportProblems tests that unresolvable imports are left in place.
+func TestResolveImportProblems(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "loop.css"), []byte(`@import "loop.css"; p { color: red; }`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		css     string
+		problem string
+	}{
+		{"missing file", `@import "missing.css";`, "missing.css"},
+		{"plain http", `@import url(http://example.com/a.css);`, "HTTPS"},
+		{"circular", `@import "loop.css";`, "circular"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, problems := NewImportResolver("").Resolve(tt.css, dir)
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.problem) {
+				t.Errorf("problems = %v, want one containing %q", problems, tt.problem)
+			}
+			if !strings.Contains(got, "@import") {
+				t.Errorf("expected unresolved @import to be kept, got %q", got)
+			}
+		})
+	}
+}
+
+// TestResolveRemoteImportsCached tests that HTTPS imports are downloaded once,
+// cached, and reused when offline.
+func TestResolveRemoteImportsCached(t *testing.T) {
+	resolver := NewImportResolver(t.TempDir())
+	downloads := 0
+	resolver.fetch = func(url string) (string, error) {
+		downloads++
+		if url != "https://fonts.example.com/css/brand.css" {
+			t.Errorf("unexpected URL %s", url)
+		}
+		return "@font-face { src: url(../files/brand.woff2); }", nil
+	}
+
+	css := `@import url(https://fonts.example.com/css/brand.css);`
+	want := `@font-face{src:url("https://fonts.example.com/files/brand.woff2")}`
+	for i := 0; i < 2; i++ {
+		got, problems := resolver.Resolve(css, "")
+		if len(problems) != 0 {
+			t.Fatalf("unexpected problems: %v", problems)
+		}
+		if got != want {
+			t.Errorf("Resolve() = %q, want %q", got, want)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("expected 1 download, got %d", downloads)
+	}
+
+	// Offline: a fresh resolver falls back to the cached copy
+	offline := NewImportResolver(resolver.cacheDir)
+	offline.fetch = func(string) (string, error) { return "", errors.New("offline") }
+	if got, problems := offline.Resolve(css, ""); len(problems) != 0 || got != want {
+		t.Errorf("offline Resolve() = %q, %v", got, problems)
+	}
+}
+
+// TestResolveLocalOnly tests that a local-only resolver inlines local
+// imports but downloads nothing, including the HTTPS imports of an imported
+// file, leaving them for sanitizing to remove.
+func TestResolveLocalOnly(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "base.css"), []byte("@import \"https://evil.example.com/nested.css\";\np { margin: 0; }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewImportResolver("").LocalOnly()
+	resolver.fetch = func(url string) (string, error) {
+		t.Errorf("unexpected download of %s", url)
+		return "", errors.New("offline")
+	}
+
+	// As processThemeCSS does for an untrusted theme
+	css := "@import \"https://evil.example.com/top.css\";\n@import \"base.css\";\nbody { color: black; }\n"
+	sanitized, removed := ProcessCSS(css, ProcessOptions{Sanitize: true})
+	resolved, problems := resolver.Resolve(sanitized, dir)
+	if len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	got, more := ProcessCSS(resolved, ProcessOptions{Sanitize: true, BaseDir: dir})
+	removed = append(removed, more...)
+
+	if !strings.Contains(got, "p{margin:0}") || !strings.Contains(got, "body{color:black}") {
+		t.Errorf("expected the local import inlined, got %q", got)
+	}
+	if strings.Contains(got, "evil.example.com") {
+		t.Errorf("expected external imports removed, got %q", got)
+	}
+	if len(removed) != 2 {
+		t.Errorf("expected both external imports reported removed, got %v", removed)
+	}
+}
@@ -0,0 +1,197 @@
+package theme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/filelock"
+)
+
+// indexVersion is bumped whenever the cached fields change, invalidating old indexes.
+const indexVersion = 3
+
+// IndexEntry is the cached front matter of one theme CSS file.
+type IndexEntry struct {
+	Name        string    `json:"name,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	Engines     []string  `json:"engines,omitempty"`
+	BeamerTheme string    `json:"beamerTheme,omitempty"`
+	NoSmart     bool      `json:"noSmart,omitempty"`
+	Checksum    string    `json:"checksum"` // SHA-256 of the file content
+	ModTime     time.Time `json:"modTime"`
+	Size        int64     `json:"size"`
+}
+
+// Index caches parsed theme metadata keyed by file path, so discovery only
+// re-reads theme files whose modification time or size changed.
+// A nil *Index is valid and parses every file.
+type Index struct {
+	path    string
+	entries map[string]IndexEntry
+	seen    map[string]bool
+	pruned  map[string]bool
+	dirty   bool
+}
+
+type indexFile struct {
+	Version int                   `json:"version"`
+	Themes  map[string]IndexEntry `json:"themes"`
+}
+
+// LoadIndex loads the index at path. A missing, unreadable, or outdated index
+// yields an empty one that is rebuilt on the next Save.
+func LoadIndex(path string) *Index {
+	idx := &Index{
+		path:    path,
+		entries: make(map[string]IndexEntry),
+		seen:    make(map[string]bool),
+		pruned:  make(map[string]bool),
+	}
+
+	entries, ok := readIndexFile(path)
+	if !ok {
+		idx.dirty = true
+	}
+	if entries != nil {
+		idx.entries = entries
+	}
+	return idx
+}
+
+// readIndexFile reads the entries of the index file at path. ok is false if
+// the file exists but is unreadable or outdated.
+func readIndexFile(path string) (map[string]IndexEntry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, os.IsNotExist(err)
+	}
+	var file indexFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != indexVersion {
+		return nil, false
+	}
+	return file.Themes, true
+}
+
+// Metadata returns the metadata of the theme file at filePath, from the index
+// if the file is unchanged, otherwise by reading and parsing it.
+func (idx *Index) Metadata(filePath string, info fs.FileInfo) (*ThemeMetadata, error) {
+	if idx != nil {
+		idx.seen[filePath] = true
+		if entry, ok := idx.entries[filePath]; ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+			return entry.metadata(), nil
+		}
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	meta, _, err := ParseMetadata(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		meta = &ThemeMetadata{}
+	}
+
+	if idx != nil {
+		sum := sha256.Sum256(content)
+		idx.entries[filePath] = IndexEntry{
+			Name:        meta.Name,
+			Author:      meta.Author,
+			Description: meta.Description,
+			Version:     meta.Version,
+			Engines:     meta.Engines,
+			BeamerTheme: meta.BeamerTheme,
+			NoSmart:     meta.NoSmart,
+			Checksum:    hex.EncodeToString(sum[:]),
+			ModTime:     info.ModTime(),
+			Size:        info.Size(),
+		}
+		idx.dirty = true
+	}
+	return meta, nil
+}
+
+// Prune drops entries under dir that were not looked up since the index was loaded,
+// i.e. themes that have been removed.
+func (idx *Index) Prune(dir string) {
+	if idx == nil || dir == "" {
+		return
+	}
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	for filePath := range idx.entries {
+		if strings.HasPrefix(filePath, prefix) && !idx.seen[filePath] {
+			delete(idx.entries, filePath)
+			idx.pruned[filePath] = true
+			idx.dirty = true
+		}
+	}
+}
+
+// Save writes the index if it changed. The index is locked while it is
+// updated, and entries other veve processes saved since it was loaded are
+// kept. The file is replaced atomically so readers never see a partial index.
+func (idx *Index) Save() error {
+	if idx == nil || !idx.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create theme index directory: %w", err)
+	}
+	lock, err := filelock.Acquire(idx.path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock theme index: %w", err)
+	}
+	defer lock.Unlock()
+
+	// Keep what other processes indexed, unless this one found it removed
+	if current, _ := readIndexFile(idx.path); current != nil {
+		for filePath, entry := range current {
+			if _, ok := idx.entries[filePath]; !ok && !idx.pruned[filePath] {
+				idx.entries[filePath] = entry
+			}
+		}
+	}
+
+	data, err := json.Marshal(indexFile{Version: indexVersion, Themes: idx.entries})
+	if err != nil {
+		return fmt.Errorf("failed to encode theme index: %w", err)
+	}
+	if err := filelock.WriteFile(idx.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write theme index: %w", err)
+	}
+	idx.dirty = false
+	return nil
+}
+
+// Entry returns the cached entry for filePath.
+func (idx *Index) Entry(filePath string) (IndexEntry, bool) {
+	if idx == nil {
+		return IndexEntry{}, false
+	}
+	entry, ok := idx.entries[filePath]
+	return entry, ok
+}
+
+func (e IndexEntry) metadata() *ThemeMetadata {
+	return &ThemeMetadata{
+		Name:        e.Name,
+		Author:      e.Author,
+		Description: e.Description,
+		Version:     e.Version,
+		Engines:     e.Engines,
+		BeamerTheme: e.BeamerTheme,
+		NoSmart:     e.NoSmart,
+	}
+}
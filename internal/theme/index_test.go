@@ -0,0 +1,170 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestIndexCachesMetadata tests that unchanged themes are served from the index
+// and changed themes are re-parsed.
+func TestIndexCachesMetadata(t *testing.T) {
+	themesDir := t.TempDir()
+	indexPath := filepath.Join(t.TempDir(), "theme-index.json")
+	themePath := filepath.Join(themesDir, "brand.css")
+
+	writeTheme := func(version string, modTime time.Time) {
+		content := "---\nname: Brand\nversion: " + version + "\nengines: [weasyprint]\n---\nbody { color: red; }\n"
+		if err := os.WriteFile(themePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(themePath, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	discover := func() Theme {
+		loader := NewLoader(themesDir)
+		loader.SetIndexFile(indexPath)
+		if err := loader.DiscoverThemes(); err != nil {
+			t.Fatalf("DiscoverThemes failed: %v", err)
+		}
+		theme, exists := loader.GetRegistry().GetTheme("brand")
+		if !exists {
+			t.Fatal("theme brand not found")
+		}
+		return theme
+	}
+
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeTheme("1.0.0", modTime)
+
+	theme := discover()
+	if theme.DisplayName != "Brand" || theme.Version != "1.0.0" || !SupportsEngine(theme.Engines, "weasyprint") {
+		t.Fatalf("unexpected metadata: %+v", theme)
+	}
+
+	entry, ok := LoadIndex(indexPath).Entry(themePath)
+	if !ok {
+		t.Fatal("expected theme to be cached in the index")
+	}
+	if entry.Checksum == "" || !entry.ModTime.Equal(modTime) {
+		t.Errorf("unexpected index entry: %+v", entry)
+	}
+
+	// Same size and mtime: the cached metadata is used without re-reading the file
+	writeTheme("2.0.0", modTime)
+	if theme := discover(); theme.Version != "1.0.0" {
+		t.Errorf("expected cached version 1.0.0, got %s", theme.Version)
+	}
+
+	// A new mtime invalidates the entry
+	writeTheme("2.0.0", modTime.Add(time.Minute))
+	if theme := discover(); theme.Version != "2.0.0" {
+		t.Errorf("expected re-parsed version 2.0.0, got %s", theme.Version)
+	}
+}
+
+// TestIndexPrunesRemovedThemes tests that removed themes are dropped from the index.
+func TestIndexPrunesRemovedThemes(t *testing.T) {
+	themesDir := t.TempDir()
+	indexPath := filepath.Join(t.TempDir(), "theme-index.json")
+	themePath := filepath.Join(themesDir, "brand.css")
+	if err := os.WriteFile(themePath, []byte("body { color: red; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader(themesDir)
+	loader.SetIndexFile(indexPath)
+	if err := loader.DiscoverThemes(); err != nil {
+		t.Fatalf("DiscoverThemes failed: %v", err)
+	}
+	if _, ok := LoadIndex(indexPath).Entry(themePath); !ok {
+		t.Fatal("expected theme to be cached in the index")
+	}
+
+	if err := os.Remove(themePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.DiscoverThemes(); err != nil {
+		t.Fatalf("DiscoverThemes failed: %v", err)
+	}
+	if _, ok := LoadIndex(indexPath).Entry(themePath); ok {
+		t.Error("expected removed theme to be pruned from the index")
+	}
+}
+
+// TestLoadIndexCorrupt tests that a corrupt index is ignored.
+func TestLoadIndexCorrupt(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "theme-index.json")
+	if err := os.WriteFile(indexPath, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	index := LoadIndex(indexPath)
+	if _, ok := index.Entry("anything"); ok {
+		t.Error("expected empty index")
+	}
+	if err := index.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Errorf("expected index to be rewritten: %v", err)
+	}
+}
+
+// TestIndexSaveKeepsConcurrentEntries tests that saving an index keeps the
+// entries another process saved since it was loaded, except pruned ones.
+func TestIndexSaveKeepsConcurrentEntries(t *testing.T) {
+	themesDir := t.TempDir()
+	indexPath := filepath.Join(t.TempDir(), "theme-index.json")
+
+	writeTheme := func(name string) (string, os.FileInfo) {
+		path := filepath.Join(themesDir, name+".css")
+		if err := os.WriteFile(path, []byte("---\nname: "+name+"\n---\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path, info
+	}
+	oldPath, oldInfo := writeTheme("old")
+	seed := LoadIndex(indexPath)
+	if _, err := seed.Metadata(oldPath, oldInfo); err != nil {
+		t.Fatal(err)
+	}
+	if err := seed.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two processes load the index, each indexes a different theme, and the
+	// second finds the old theme removed
+	first, second := LoadIndex(indexPath), LoadIndex(indexPath)
+	aPath, aInfo := writeTheme("a")
+	bPath, bInfo := writeTheme("b")
+	if _, err := first.Metadata(aPath, aInfo); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Metadata(bPath, bInfo); err != nil {
+		t.Fatal(err)
+	}
+	second.Prune(themesDir)
+	if err := second.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := LoadIndex(indexPath)
+	for _, path := range []string{aPath, bPath} {
+		if _, ok := saved.Entry(path); !ok {
+			t.Errorf("expected %s in the index", path)
+		}
+	}
+	if _, ok := saved.Entry(oldPath); ok {
+		t.Error("expected the pruned theme to be dropped")
+	}
+}
@@ -6,13 +6,17 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/madstone-tech/veve-cli/themes"
 )
 
 // Loader handles loading themes from built-in and user-installed locations.
 type Loader struct {
-	builtInThemes map[string]Theme
-	userThemesDir string
-	registry      *Registry
+	builtInThemes    map[string]Theme
+	userThemesDir    string
+	projectThemesDir string
+	indexFile        string
+	registry         *Registry
 }
 
 // NewLoader creates a new theme loader.
@@ -24,6 +28,18 @@ func NewLoader(userThemesDir string) *Loader {
 	}
 }
 
+// SetProjectThemesDir sets a project-local themes directory (e.g. ./.veve/themes).
+// Project themes take precedence over user-installed and built-in themes.
+func (l *Loader) SetProjectThemesDir(dir string) {
+	l.projectThemesDir = dir
+}
+
+// SetIndexFile enables caching of parsed theme metadata in the given index file,
+// so discovery only re-reads themes that changed since the last run.
+func (l *Loader) SetIndexFile(path string) {
+	l.indexFile = path
+}
+
 // AddBuiltInTheme registers a built-in theme.
 func (l *Loader) AddBuiltInTheme(theme Theme) {
 	l.builtInThemes[theme.Name] = theme
@@ -40,78 +56,135 @@ func (l *Loader) DiscoverThemes() error {
 		// This is not fatal since built-in themes will still work
 	}
 
-	// Add built-in themes with metadata
-	builtInThemeMetadata := map[string]Theme{
-		"default": {
-			Name:        "default",
-			DisplayName: "Default",
-			Description: "Clean, professional default theme with blue accents",
-			Author:      "veve-cli",
-			Version:     "1.0.0",
-			FilePath:    "", // Embedded
-			IsBuiltIn:   true,
-		},
-		"dark": {
-			Name:        "dark",
-			DisplayName: "Dark",
-			Description: "Dark theme with blue accents, easy on the eyes",
+	// Add built-in themes registered in the themes package
+	for _, builtIn := range themes.List() {
+		l.registry.AddTheme(Theme{
+			Name:        builtIn.Name,
+			DisplayName: builtIn.DisplayName,
+			Description: builtIn.Description,
 			Author:      "veve-cli",
 			Version:     "1.0.0",
 			FilePath:    "", // Embedded
 			IsBuiltIn:   true,
-		},
-		"academic": {
-			Name:        "academic",
-			DisplayName: "Academic",
-			Description: "Formal academic paper style with Times New Roman",
-			Author:      "veve-cli",
-			Version:     "1.0.0",
-			FilePath:    "", // Embedded
-			IsBuiltIn:   true,
-		},
+		})
 	}
 
-	for _, theme := range builtInThemeMetadata {
-		l.registry.AddTheme(theme)
+	var index *Index
+	if l.indexFile != "" {
+		index = LoadIndex(l.indexFile)
 	}
 
 	// Discover user-installed themes (overrides built-in)
-	if _, err := os.Stat(l.userThemesDir); err == nil {
-		entries, err := os.ReadDir(l.userThemesDir)
+	if err := l.discoverDir(l.userThemesDir, false, index); err != nil {
+		return err
+	}
+
+	// Discover project themes (overrides user-installed and built-in)
+	if l.projectThemesDir != "" {
+		if err := l.discoverDir(l.projectThemesDir, true, index); err != nil {
+			return err
+		}
+	}
+
+	// The index is only a cache; failing to update it is not fatal
+	index.Prune(l.userThemesDir)
+	index.Prune(l.projectThemesDir)
+	_ = index.Save()
+
+	return nil
+}
+
+// discoverDir registers the themes in dir, including themes in subdirectories,
+// which are namespaced by their relative path (company/brand).
+func (l *Loader) discoverDir(themesDir string, isProject bool, index *Index) error {
+	if _, err := os.Stat(themesDir); err != nil {
+		return nil
+	}
+
+	return filepath.WalkDir(themesDir, func(filePath string, entry os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
+		if entry.IsDir() {
+			// Skip hidden directories such as .git
+			if filePath != themesDir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
 			}
 
-			// Only process .css files
-			if !strings.HasSuffix(entry.Name(), ".css") {
-				continue
+			// A directory with a manifest is an installed theme package
+			if filePath != themesDir && IsPackageDir(filePath) {
+				relPath, err := filepath.Rel(themesDir, filePath)
+				if err != nil {
+					return err
+				}
+				if manifest, err := ReadManifest(filePath); err == nil {
+					theme := manifest.Theme(filepath.ToSlash(relPath), filePath)
+					theme.IsProject = isProject
+					l.registry.AddTheme(theme)
+				}
+				return filepath.SkipDir
 			}
+			return nil
+		}
 
-			// Extract theme name from filename (without .css extension)
-			themeName := strings.TrimSuffix(entry.Name(), ".css")
-			filePath := filepath.Join(l.userThemesDir, entry.Name())
-
-			theme := Theme{
-				Name:        themeName,
-				DisplayName: themeName,
-				Description: "Custom user theme",
-				Author:      "Unknown",
-				Version:     "1.0.0",
-				FilePath:    filePath,
-				IsBuiltIn:   false,
-			}
+		// Only process .css files
+		if !strings.HasSuffix(entry.Name(), ".css") {
+			return nil
+		}
 
-			// User themes override built-in themes with the same name
-			l.registry.AddTheme(theme)
+		// Extract theme name from the relative path (without .css extension)
+		relPath, err := filepath.Rel(themesDir, filePath)
+		if err != nil {
+			return err
 		}
-	}
+		themeName := strings.TrimSuffix(filepath.ToSlash(relPath), ".css")
 
-	return nil
+		description := "Custom user theme"
+		if isProject {
+			description = "Project theme"
+		}
+		theme := Theme{
+			Name:        themeName,
+			DisplayName: themeName,
+			Description: description,
+			Author:      "Unknown",
+			Version:     "1.0.0",
+			FilePath:    filePath,
+			IsBuiltIn:   false,
+			IsProject:   isProject,
+		}
+
+		// Metadata and engine requirements are declared in the front matter
+		if info, err := entry.Info(); err == nil {
+			if meta, err := index.Metadata(filePath, info); err == nil {
+				applyThemeMetadata(&theme, meta)
+			}
+		}
+
+		// Later directories override earlier ones with the same theme name
+		l.registry.AddTheme(theme)
+		return nil
+	})
+}
+
+// applyThemeMetadata copies the fields set in a theme's front matter onto its registry entry.
+func applyThemeMetadata(theme *Theme, meta *ThemeMetadata) {
+	if meta.Name != "" {
+		theme.DisplayName = meta.Name
+	}
+	if meta.Author != "" {
+		theme.Author = meta.Author
+	}
+	if meta.Description != "" {
+		theme.Description = meta.Description
+	}
+	if meta.Version != "" {
+		theme.Version = meta.Version
+	}
+	theme.Engines = meta.Engines
+	theme.BeamerTheme = meta.BeamerTheme
+	theme.NoSmart = meta.NoSmart
 }
 
 // LoadTheme loads a theme by name, checking built-in and user-installed themes.
@@ -133,21 +206,21 @@ func (l *Loader) LoadTheme(name string) (Theme, error) {
 // LoadThemeCSS loads the CSS content for a theme.
 // For built-in themes, returns the embedded CSS.
 // For user-installed themes, reads from the file system.
-// If the theme name looks like a file path (contains / or \), loads from that path.
+// If the theme name looks like a file path and is not a namespaced theme, loads from that path.
 func (l *Loader) LoadThemeCSS(themeName string) (string, error) {
 	// Check if the theme name is a file path
-	if strings.ContainsAny(themeName, "/\\") {
+	if l.IsThemePath(themeName) {
 		return l.LoadThemeFromPath(themeName)
 	}
 
-	// First check built-in themes via embed.go
-	builtInCSS := l.loadBuiltInThemeCSS(themeName)
-	if builtInCSS != "" {
-		return builtInCSS, nil
-	}
-
-	// Then check user-installed themes
+	// Check installed themes first, since project and user themes override built-ins
 	theme, exists := l.registry.GetTheme(themeName)
+	if !exists || theme.IsBuiltIn {
+		// Built-in themes via embed.go
+		if builtInCSS := l.loadBuiltInThemeCSS(themeName); builtInCSS != "" {
+			return builtInCSS, nil
+		}
+	}
 	if !exists {
 		return "", fmt.Errorf("theme not found: %s", themeName)
 	}
@@ -172,9 +245,93 @@ func (l *Loader) LoadThemeCSS(themeName string) (string, error) {
 	return "", fmt.Errorf("theme CSS not found: %s", themeName)
 }
 
+// IsThemePath reports whether themeName refers to a CSS file rather than an
+// installed theme. Names containing a path separator (/ or \), starting with a
+// Windows drive letter, or ending in .css (in any case) are paths, unless they
+// match a namespaced theme such as company/brand.
+func (l *Loader) IsThemePath(themeName string) bool {
+	if _, exists := l.registry.GetTheme(themeName); exists {
+		return false
+	}
+	return strings.ContainsAny(themeName, "/\\") ||
+		hasDriveLetter(themeName) ||
+		strings.EqualFold(filepath.Ext(themeName), ".css")
+}
+
+// hasDriveLetter reports whether path starts with a Windows drive such as C:.
+// Checked on every platform, since theme names never contain a colon.
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	c := path[0] | 0x20 // lower case
+	return c >= 'a' && c <= 'z'
+}
+
+// ValidateThemeName checks that a theme name is safe to use as a path below the
+// themes directory: slash-separated segments without empty, "." or ".." parts.
+func ValidateThemeName(name string) error {
+	if name == "" {
+		return fmt.Errorf("theme name cannot be empty")
+	}
+	if strings.ContainsAny(name, "\\:") || strings.HasSuffix(name, ".css") {
+		return fmt.Errorf("invalid theme name %q: use slash-separated names without the .css extension (e.g. company/brand)", name)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.HasPrefix(segment, ".") {
+			return fmt.Errorf("invalid theme name %q: empty or dot segment in path", name)
+		}
+	}
+	return nil
+}
+
+// ThemeFilePath returns where a user theme with the given (possibly namespaced) name is stored.
+func (l *Loader) ThemeFilePath(name string) string {
+	return l.PackageDir(name) + ".css"
+}
+
+// PackageDir returns where a theme package with the given (possibly namespaced) name is installed.
+func (l *Loader) PackageDir(name string) string {
+	return filepath.Join(l.userThemesDir, filepath.FromSlash(name))
+}
+
 // LoadThemeFromPath loads a theme CSS file from a file system path.
 // This allows using themes from arbitrary locations via --theme /path/to/theme.css
 func (l *Loader) LoadThemeFromPath(filePath string) (string, error) {
+	content, err := readThemeFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse metadata if present
+	_, css, err := ParseMetadata(string(content))
+	if err != nil {
+		// Continue even if metadata parsing fails, use full content
+		css = string(content)
+	}
+
+	// Validate CSS
+	if err := ValidateCSS(css); err != nil {
+		return "", fmt.Errorf("theme validation failed for %s: %w", filePath, err)
+	}
+
+	return css, nil
+}
+
+// LoadMetadataFromPath reads the front matter of a theme file.
+// Returns nil metadata if the file has none.
+func (l *Loader) LoadMetadataFromPath(filePath string) (*ThemeMetadata, error) {
+	content, err := readThemeFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, _, err := ParseMetadata(string(content))
+	return meta, err
+}
+
+// ResolveThemePath returns the absolute path of a theme file path, expanding ~.
+func ResolveThemePath(filePath string) (string, error) {
 	// Expand ~ to home directory
 	if strings.HasPrefix(filePath, "~") {
 		home, err := os.UserHomeDir()
@@ -189,26 +346,23 @@ func (l *Loader) LoadThemeFromPath(filePath string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve theme path: %w", err)
 	}
+	return absPath, nil
+}
 
-	// Read the file
-	content, err := os.ReadFile(absPath)
+// readThemeFile reads a theme file, expanding ~ and resolving relative paths.
+func readThemeFile(filePath string) ([]byte, error) {
+	absPath, err := ResolveThemePath(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read theme file %s: %w", filePath, err)
+		return nil, err
 	}
 
-	// Parse metadata if present
-	_, css, err := ParseMetadata(string(content))
+	// Read the file
+	content, err := os.ReadFile(absPath)
 	if err != nil {
-		// Continue even if metadata parsing fails, use full content
-		css = string(content)
+		return nil, fmt.Errorf("failed to read theme file %s: %w", filePath, err)
 	}
 
-	// Validate CSS
-	if err := ValidateCSS(css); err != nil {
-		return "", fmt.Errorf("theme validation failed for %s: %w", filePath, err)
-	}
-
-	return css, nil
+	return content, nil
 }
 
 // ValidateTheme validates a theme CSS file for correctness.
@@ -239,34 +393,9 @@ func (l *Loader) ValidateTheme(filePath string) error {
 }
 
 // loadBuiltInThemeCSS loads CSS from the embedded themes (themes/embed.go).
-// This is a simplified implementation; actual themes need to be exported from themes package.
 func (l *Loader) loadBuiltInThemeCSS(themeName string) string {
-	// Map of theme names to embedded CSS content
-	// In a real implementation, this would import from themes package
-	switch themeName {
-	case "default":
-		return `
-body { font-family: "Segoe UI", Tahoma, sans-serif; }
-h1 { color: #2c3e50; border-bottom: 3px solid #3498db; }
-h2 { color: #2c3e50; border-bottom: 2px solid #bdc3c7; }
-code { background-color: #f4f4f4; padding: 2px 6px; border-radius: 3px; }
-`
-	case "dark":
-		return `
-body { font-family: "Segoe UI", Tahoma, sans-serif; color: #e0e0e0; background-color: #1e1e1e; }
-h1 { color: #64b5f6; border-bottom: 3px solid #64b5f6; }
-h2 { color: #64b5f6; border-bottom: 2px solid #424242; }
-code { background-color: #2d2d2d; color: #81c784; }
-`
-	case "academic":
-		return `
-body { font-family: "Times New Roman", Times, serif; }
-h1 { font-size: 18pt; text-align: center; border-bottom: 1px solid #000; }
-h2 { font-size: 14pt; border-bottom: 1px solid #000; }
-code { font-family: "Courier New", monospace; }
-`
-	}
-	return ""
+	css, _ := themes.GetBuiltInTheme(themeName)
+	return css
 }
 
 // ListThemes returns all available themes, sorted by name.
@@ -172,6 +172,101 @@ func TestDiscoverUserThemes(t *testing.T) {
 	}
 }
 
+// TestDiscoverUserThemeEngines tests that declared engines are read during discovery.
+func TestDiscoverUserThemeEngines(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	content := "---\nname: print\nengines: [weasyprint]\n---\nbody { color: red; }\n"
+	themePath := filepath.Join(tmpDir, "print.css")
+	if err := os.WriteFile(themePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to create test theme: %v", err)
+	}
+
+	loader := NewLoader(tmpDir)
+	if err := loader.DiscoverThemes(); err != nil {
+		t.Fatalf("DiscoverThemes failed: %v", err)
+	}
+
+	printTheme, err := loader.LoadTheme("print")
+	if err != nil {
+		t.Fatalf("LoadTheme failed: %v", err)
+	}
+	if len(printTheme.Engines) != 1 || printTheme.Engines[0] != "weasyprint" {
+		t.Errorf("expected engines [weasyprint], got %v", printTheme.Engines)
+	}
+
+	meta, err := loader.LoadMetadataFromPath(themePath)
+	if err != nil {
+		t.Fatalf("LoadMetadataFromPath failed: %v", err)
+	}
+	if meta == nil || len(meta.Engines) != 1 {
+		t.Errorf("expected engines from path metadata, got %+v", meta)
+	}
+}
+
+// TestDiscoverNestedThemes tests that themes in subdirectories are namespaced by path.
+func TestDiscoverNestedThemes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	css := `body { color: navy; }`
+	for _, rel := range []string{"company/brand.css", "company/print/report.css", ".git/ignored.css"} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(css), 0o644); err != nil {
+			t.Fatalf("failed to create test theme: %v", err)
+		}
+	}
+
+	loader := NewLoader(tmpDir)
+	if err := loader.DiscoverThemes(); err != nil {
+		t.Fatalf("DiscoverThemes failed: %v", err)
+	}
+
+	for _, name := range []string{"company/brand", "company/print/report"} {
+		if _, err := loader.LoadTheme(name); err != nil {
+			t.Errorf("expected namespaced theme %s: %v", name, err)
+		}
+		if loader.IsThemePath(name) {
+			t.Errorf("%s should resolve to an installed theme, not a path", name)
+		}
+		loaded, err := loader.LoadThemeCSS(name)
+		if err != nil || loaded != css {
+			t.Errorf("LoadThemeCSS(%s) = %q, %v", name, loaded, err)
+		}
+	}
+
+	if _, exists := loader.GetRegistry().GetTheme(".git/ignored"); exists {
+		t.Error("themes in hidden directories should not be discovered")
+	}
+	if !loader.IsThemePath("company/missing") || !loader.IsThemePath("brand.css") {
+		t.Error("unknown names with separators or .css should be treated as paths")
+	}
+	for _, path := range []string{`themes\brand`, "C:brand", "Brand.CSS"} {
+		if !loader.IsThemePath(path) {
+			t.Errorf("%s should be treated as a path", path)
+		}
+	}
+	if got, want := loader.ThemeFilePath("company/brand"), filepath.Join(tmpDir, "company", "brand.css"); got != want {
+		t.Errorf("ThemeFilePath = %s, want %s", got, want)
+	}
+}
+
+// TestValidateThemeName tests theme name validation for namespaced names.
+func TestValidateThemeName(t *testing.T) {
+	for _, name := range []string{"brand", "company/brand", "a/b/c"} {
+		if err := ValidateThemeName(name); err != nil {
+			t.Errorf("ValidateThemeName(%q) unexpected error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "../evil", "company//brand", "/abs", "company/", `a\b`, "brand.css", ".hidden"} {
+		if err := ValidateThemeName(name); err == nil {
+			t.Errorf("ValidateThemeName(%q) expected error", name)
+		}
+	}
+}
+
 // TestLoadUserThemeCSS tests loading CSS from a user theme file.
 func TestLoadUserThemeCSS(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -310,3 +405,50 @@ func TestThemeTimestampFormat(t *testing.T) {
 		}
 	}
 }
+
+// TestDiscoverProjectThemes tests that project themes override user and built-in themes.
+func TestDiscoverProjectThemes(t *testing.T) {
+	userDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(userDir, "brand.css"), []byte("body { color: red; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "brand.css"), []byte("body { color: blue; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "dark.css"), []byte("body { color: green; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader(userDir)
+	loader.SetProjectThemesDir(projectDir)
+	if err := loader.DiscoverThemes(); err != nil {
+		t.Fatalf("DiscoverThemes failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"brand", "body { color: blue; }"},
+		{"dark", "body { color: green; }"},
+	}
+	for _, tt := range tests {
+		theme, exists := loader.GetRegistry().GetTheme(tt.name)
+		if !exists {
+			t.Fatalf("theme %s not found", tt.name)
+		}
+		if !theme.IsProject || theme.IsBuiltIn {
+			t.Errorf("theme %s should be a project theme", tt.name)
+		}
+
+		css, err := loader.LoadThemeCSS(tt.name)
+		if err != nil {
+			t.Fatalf("LoadThemeCSS(%s) failed: %v", tt.name, err)
+		}
+		if css != tt.want {
+			t.Errorf("LoadThemeCSS(%s) = %q, want %q", tt.name, css, tt.want)
+		}
+	}
+}
@@ -11,6 +11,9 @@ type ThemeMetadata struct {
 	Author      string
 	Description string
 	Version     string
+	Engines     []string // PDF engines the theme is designed for; empty means any
+	BeamerTheme string   // Beamer theme for --format slides (optional)
+	NoSmart     bool     // smart: false turns off curly quotes, dashes, and ellipses
 }
 
 // ParseMetadata extracts YAML front matter from a CSS file content.
@@ -21,6 +24,9 @@ type ThemeMetadata struct {
 //	author: Author Name
 //	description: Theme description
 //	version: 1.0.0
+//	engines: [weasyprint, prince]
+//	beamer-theme: metropolis
+//	smart: false
 //	---
 //	/* CSS content here */
 //
@@ -80,6 +86,12 @@ func ParseMetadata(content string) (*ThemeMetadata, string, error) {
 			metadata.Description = value
 		case "version":
 			metadata.Version = value
+		case "engines":
+			metadata.Engines = parseList(value)
+		case "beamer-theme":
+			metadata.BeamerTheme = value
+		case "smart":
+			metadata.NoSmart = value == "false" || value == "no" || value == "off"
 		}
 	}
 
@@ -91,22 +103,46 @@ func ParseMetadata(content string) (*ThemeMetadata, string, error) {
 	return metadata, css, nil
 }
 
-// ValidateCSS performs basic validation of CSS content.
-// Checks for:
-// - Non-empty content
-// - Balanced braces
-// - No obviously malformed syntax
+// parseList parses a YAML flow sequence ([a, b]) or a comma-separated list.
+func parseList(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.Trim(strings.TrimSpace(item), "\"'")
+		if item != "" {
+			items = append(items, strings.ToLower(item))
+		}
+	}
+	return items
+}
+
+// SupportsEngine reports whether a theme declaring engines can be rendered by engine.
+// Themes that declare no engines support all of them.
+func SupportsEngine(engines []string, engine string) bool {
+	if len(engines) == 0 {
+		return true
+	}
+	for _, e := range engines {
+		if strings.EqualFold(e, engine) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCSS parses CSS content (optionally preceded by YAML front matter)
+// and returns the first syntax error, with its line and column.
+// Warnings such as unknown at-rules are not treated as errors; use CheckTheme for those.
 func ValidateCSS(css string) error {
 	if strings.TrimSpace(css) == "" {
 		return fmt.Errorf("CSS content is empty")
 	}
 
-	// Count braces
-	openBraces := strings.Count(css, "{")
-	closeBraces := strings.Count(css, "}")
-
-	if openBraces != closeBraces {
-		return fmt.Errorf("unbalanced braces: %d { and %d }", openBraces, closeBraces)
+	for _, issue := range CheckTheme(css, "") {
+		if issue.Severity == SeverityError {
+			return fmt.Errorf("line %d, column %d: %s", issue.Line, issue.Column, issue.Message)
+		}
 	}
 
 	return nil
@@ -279,6 +279,46 @@ body { }
 	}
 }
 
+// TestParseMetadataEngines tests parsing the engines list in both supported forms.
+func TestParseMetadataEngines(t *testing.T) {
+	for _, value := range []string{"[weasyprint, prince]", "WeasyPrint, 'prince'"} {
+		meta, _, err := ParseMetadata("---\nname: print\nengines: " + value + "\n---\nbody { }\n")
+		if err != nil {
+			t.Fatalf("ParseMetadata failed: %v", err)
+		}
+
+		if len(meta.Engines) != 2 || meta.Engines[0] != "weasyprint" || meta.Engines[1] != "prince" {
+			t.Errorf("engines: %q parsed as %v", value, meta.Engines)
+		}
+	}
+}
+
+// TestParseMetadataSmart tests the theme-level smart punctuation default.
+func TestParseMetadataSmart(t *testing.T) {
+	for value, noSmart := range map[string]bool{"false": true, "off": true, "true": false, "": false} {
+		meta, _, err := ParseMetadata("---\nname: code\nsmart: " + value + "\n---\nbody { }\n")
+		if err != nil {
+			t.Fatalf("ParseMetadata failed: %v", err)
+		}
+		if meta.NoSmart != noSmart {
+			t.Errorf("smart: %q parsed as NoSmart = %v, want %v", value, meta.NoSmart, noSmart)
+		}
+	}
+}
+
+// TestSupportsEngine tests engine compatibility checks.
+func TestSupportsEngine(t *testing.T) {
+	if !SupportsEngine(nil, "xelatex") {
+		t.Error("themes without engine requirements should support any engine")
+	}
+	if !SupportsEngine([]string{"weasyprint"}, "WeasyPrint") {
+		t.Error("engine names should match case-insensitively")
+	}
+	if SupportsEngine([]string{"weasyprint", "prince"}, "xelatex") {
+		t.Error("xelatex should not be supported by a weasyprint/prince theme")
+	}
+}
+
 // Helper function to check if string contains substring
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
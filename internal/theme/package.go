@@ -0,0 +1,317 @@
+package theme
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// PackageExtension is the file extension of packaged themes.
+	PackageExtension = ".vevetheme"
+
+	// ManifestFile is the name of the manifest at the root of a theme package.
+	ManifestFile = "manifest.json"
+
+	// maxPackageSize limits the uncompressed size of a theme package.
+	maxPackageSize = 100 * 1024 * 1024
+
+	// maxPackageFiles limits the number of files in a theme package.
+	maxPackageFiles = 1000
+
+	// maxCompressionRatio limits how much an archive entry larger than
+	// compressionCheckSize may expand, rejecting zip bombs; stylesheets and
+	// fonts compress far less.
+	maxCompressionRatio  = 100
+	compressionCheckSize = 1024 * 1024
+)
+
+// Manifest describes a packaged theme. A package is a zip archive with the
+// manifest at its root; all paths in the manifest are relative to the root.
+//
+//	{
+//	  "name": "brand",
+//	  "version": "1.2.0",
+//	  "author": "Design Team",
+//	  "description": "Company brand theme",
+//	  "engines": ["weasyprint", "prince"],
+//	  "css": "theme.css",
+//	  "latexTemplate": "template.tex",
+//	  "fonts": ["fonts/"],
+//	  "assets": ["logo.svg"]
+//	}
+type Manifest struct {
+	Name          string   `json:"name"`
+	Version       string   `json:"version,omitempty"`
+	Author        string   `json:"author,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Engines       []string `json:"engines,omitempty"`
+	CSS           string   `json:"css"`                     // Stylesheet used with HTML engines (required)
+	LaTeXTemplate string   `json:"latexTemplate,omitempty"` // Pandoc template used with LaTeX engines
+	BeamerTheme   string   `json:"beamerTheme,omitempty"`   // Beamer theme used for slides
+	NoSmart       bool     `json:"noSmart,omitempty"`       // Turn off smart punctuation by default
+	Fonts         []string `json:"fonts,omitempty"`         // Font files or directories
+	Assets        []string `json:"assets,omitempty"`        // Images and other files referenced by the theme
+}
+
+// IsPackage reports whether source names a theme package.
+func IsPackage(source string) bool {
+	return strings.HasSuffix(strings.ToLower(source), PackageExtension)
+}
+
+// IsPackageDir reports whether dir is an unpacked theme package (contains a manifest).
+func IsPackageDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ManifestFile))
+	return err == nil && !info.IsDir()
+}
+
+// ReadManifest reads and validates the manifest of an unpacked theme package.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid theme manifest %s: %w", filepath.Join(dir, ManifestFile), err)
+	}
+	for i, engine := range manifest.Engines {
+		manifest.Engines[i] = strings.ToLower(engine)
+	}
+
+	if err := manifest.validate(dir); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// validate checks the manifest's required fields and that every referenced
+// file exists inside dir. The stylesheet must also be valid CSS.
+func (m *Manifest) validate(dir string) error {
+	if m.Name == "" {
+		return fmt.Errorf("theme manifest is missing \"name\"")
+	}
+	if m.CSS == "" {
+		return fmt.Errorf("theme manifest is missing \"css\"")
+	}
+
+	refs := append([]string{m.CSS}, m.Fonts...)
+	refs = append(refs, m.Assets...)
+	if m.LaTeXTemplate != "" {
+		refs = append(refs, m.LaTeXTemplate)
+	}
+	for _, ref := range refs {
+		if !isSafePackagePath(ref) {
+			return fmt.Errorf("theme manifest path %q must be relative to the package root", ref)
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(ref))); err != nil {
+			return fmt.Errorf("theme manifest references missing file %q", ref)
+		}
+	}
+
+	css, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(m.CSS)))
+	if err != nil {
+		return fmt.Errorf("failed to read theme stylesheet: %w", err)
+	}
+	if err := ValidateCSS(string(css)); err != nil {
+		return fmt.Errorf("theme stylesheet %s is invalid: %w", m.CSS, err)
+	}
+	return nil
+}
+
+// Theme returns the registry entry for a package unpacked in dir and installed as name.
+func (m *Manifest) Theme(name, dir string) Theme {
+	theme := Theme{
+		Name:        name,
+		DisplayName: m.Name,
+		Description: m.Description,
+		Author:      m.Author,
+		Version:     m.Version,
+		FilePath:    filepath.Join(dir, filepath.FromSlash(m.CSS)),
+		Engines:     m.Engines,
+		PackageDir:  dir,
+		BeamerTheme: m.BeamerTheme,
+		NoSmart:     m.NoSmart,
+	}
+	if m.LaTeXTemplate != "" {
+		theme.LaTeXTemplate = filepath.Join(dir, filepath.FromSlash(m.LaTeXTemplate))
+	}
+
+	meta := &ThemeMetadata{Author: theme.Author, Description: theme.Description, Version: theme.Version}
+	ApplyMetadataDefaults(meta, name)
+	theme.Author, theme.Description, theme.Version = meta.Author, meta.Description, meta.Version
+	return theme
+}
+
+// Pack creates a theme package at outputPath from the theme directory dir,
+// which must contain a manifest. Every non-hidden file under dir is included.
+func Pack(dir, outputPath string) (*Manifest, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create package: %w", err)
+	}
+	defer out.Close()
+
+	absOutput, _ := filepath.Abs(outputPath)
+	zw := zip.NewWriter(out)
+	err = filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+		if absPath, _ := filepath.Abs(filePath); absPath == absOutput {
+			return nil // Don't pack the package into itself
+		}
+
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(relPath))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	})
+	if err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("failed to pack theme: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("failed to write package: %w", err)
+	}
+	return manifest, nil
+}
+
+// InstallPackage extracts a theme package into destDir, replacing any previous
+// installation, and returns its manifest. The package is validated before
+// anything in destDir is changed.
+func InstallPackage(data []byte, destDir string) (*Manifest, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme package: %w", err)
+	}
+	if len(zr.File) > maxPackageFiles {
+		return nil, fmt.Errorf("theme package has too many files (%d, limit %d)", len(zr.File), maxPackageFiles)
+	}
+
+	// Extract next to destDir so the final rename stays on one filesystem
+	if err := os.MkdirAll(filepath.Dir(destDir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create themes directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(destDir), ".install-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	var total int64
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		if err := checkArchiveEntry(file); err != nil {
+			return nil, fmt.Errorf("theme package %w", err)
+		}
+		total += int64(file.UncompressedSize64)
+		if total > maxPackageSize {
+			return nil, fmt.Errorf("theme package is too large (limit %d MB)", maxPackageSize/1024/1024)
+		}
+		if err := extractPackageFile(file, filepath.Join(staging, filepath.FromSlash(file.Name))); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, err := ReadManifest(staging)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(destDir); err != nil {
+		return nil, fmt.Errorf("failed to replace existing theme: %w", err)
+	}
+	if err := os.Rename(staging, destDir); err != nil {
+		return nil, fmt.Errorf("failed to install theme: %w", err)
+	}
+	return manifest, nil
+}
+
+// extractPackageFile writes one zip entry to dest, enforcing its declared size.
+func extractPackageFile(file *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+
+	r, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s from package: %w", file.Name, err)
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+	defer w.Close()
+
+	if _, err := io.Copy(w, io.LimitReader(r, int64(file.UncompressedSize64))); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// checkArchiveEntry rejects archive entries that aren't regular files, escape
+// the archive root, or expand suspiciously. Reading an entry past its
+// declared size fails, so checking declared sizes bounds the real ones.
+func checkArchiveEntry(file *zip.File) error {
+	if !file.Mode().IsRegular() {
+		return fmt.Errorf("entry %q is not a regular file", file.Name)
+	}
+	if !isSafePackagePath(file.Name) {
+		return fmt.Errorf("entry %q escapes the archive root", file.Name)
+	}
+	if file.UncompressedSize64 > compressionCheckSize &&
+		(file.CompressedSize64 == 0 || file.UncompressedSize64/file.CompressedSize64 > maxCompressionRatio) {
+		return fmt.Errorf("entry %q expands too much (%d bytes compressed to %d)", file.Name, file.UncompressedSize64, file.CompressedSize64)
+	}
+	return nil
+}
+
+// isSafePackagePath reports whether name is a relative, slash-separated path
+// that stays inside the package root.
+func isSafePackagePath(name string) bool {
+	if name == "" || strings.Contains(name, `\`) || path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return false
+	}
+	clean := path.Clean(name)
+	return clean != ".." && !strings.HasPrefix(clean, "../")
+}
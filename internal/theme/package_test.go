@@ -0,0 +1,156 @@
+package theme
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePackageDir creates an unpacked theme package in a temp directory.
+func writePackageDir(t *testing.T, manifest string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		ManifestFile:      manifest,
+		"theme.css":       `@font-face { font-family: Brand; src: url("fonts/brand.ttf"); } body { font-family: Brand; }`,
+		"template.tex":    `\documentclass{article}\begin{document}$body$\end{document}`,
+		"fonts/brand.ttf": "font-data",
+		".git/config":     "ignored",
+		"assets/logo.svg": "<svg/>",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+const testManifest = `{
+  "name": "brand",
+  "version": "2.0.0",
+  "author": "Design Team",
+  "engines": ["WeasyPrint"],
+  "css": "theme.css",
+  "latexTemplate": "template.tex",
+  "fonts": ["fonts/"],
+  "assets": ["assets/logo.svg"]
+}`
+
+// TestPackAndInstall tests a full pack, install, and discovery round trip.
+func TestPackAndInstall(t *testing.T) {
+	src := writePackageDir(t, testManifest)
+	pkgPath := filepath.Join(t.TempDir(), "brand"+PackageExtension)
+
+	manifest, err := Pack(src, pkgPath)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if manifest.Name != "brand" || manifest.Engines[0] != "weasyprint" {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+
+	data, err := os.ReadFile(pkgPath)
+	if err != nil {
+		t.Fatalf("failed to read package: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("package is not a zip: %v", err)
+	}
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, ".git") {
+			t.Errorf("hidden file %s should not be packed", f.Name)
+		}
+	}
+
+	themesDir := t.TempDir()
+	loader := NewLoader(themesDir)
+	if _, err := InstallPackage(data, loader.PackageDir("company/brand")); err != nil {
+		t.Fatalf("InstallPackage failed: %v", err)
+	}
+	// Reinstalling replaces the previous installation
+	if _, err := InstallPackage(data, loader.PackageDir("company/brand")); err != nil {
+		t.Fatalf("reinstall failed: %v", err)
+	}
+
+	if err := loader.DiscoverThemes(); err != nil {
+		t.Fatalf("DiscoverThemes failed: %v", err)
+	}
+	installed, err := loader.LoadTheme("company/brand")
+	if err != nil {
+		t.Fatalf("installed package not discovered: %v", err)
+	}
+	if installed.PackageDir == "" || installed.Version != "2.0.0" || installed.Author != "Design Team" {
+		t.Errorf("unexpected theme entry: %+v", installed)
+	}
+	if filepath.Base(installed.LaTeXTemplate) != "template.tex" {
+		t.Errorf("expected LaTeX template path, got %q", installed.LaTeXTemplate)
+	}
+	if _, err := os.Stat(filepath.Join(installed.PackageDir, "fonts", "brand.ttf")); err != nil {
+		t.Errorf("fonts were not installed: %v", err)
+	}
+	if _, exists := loader.GetRegistry().GetTheme("company/brand/theme"); exists {
+		t.Error("package stylesheet should not also be discovered as a separate theme")
+	}
+}
+
+// TestPackRejectsInvalidManifest tests manifest validation.
+func TestPackRejectsInvalidManifest(t *testing.T) {
+	tests := map[string]string{
+		"missing css":    `{"name": "x"}`,
+		"missing file":   `{"name": "x", "css": "missing.css"}`,
+		"escaping path":  `{"name": "x", "css": "theme.css", "assets": ["../secret"]}`,
+		"malformed json": `{"name": `,
+	}
+	for name, manifest := range tests {
+		t.Run(name, func(t *testing.T) {
+			src := writePackageDir(t, manifest)
+			if _, err := Pack(src, filepath.Join(t.TempDir(), "x"+PackageExtension)); err == nil {
+				t.Error("expected Pack to fail")
+			}
+		})
+	}
+}
+
+// TestInstallPackageRejectsZipSlip tests that entries escaping the package root are refused.
+func TestInstallPackageRejectsZipSlip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{ManifestFile, "../../evil.css"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(`{"name": "x", "css": "theme.css"}`))
+	}
+	zw.Close()
+
+	themesDir := t.TempDir()
+	if _, err := InstallPackage(buf.Bytes(), filepath.Join(themesDir, "x")); err == nil {
+		t.Fatal("expected zip slip entry to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(themesDir, "x")); !os.IsNotExist(err) {
+		t.Error("nothing should be installed when the package is rejected")
+	}
+}
+
+// TestInstallPackageRejectsZipBomb tests that entries expanding suspiciously are refused.
+func TestInstallPackageRejectsZipBomb(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create(ManifestFile)
+	w.Write([]byte(`{"name": "x", "css": "theme.css"}`))
+	w, _ = zw.Create("theme.css")
+	w.Write(bytes.Repeat([]byte{' '}, 16*1024*1024))
+	zw.Close()
+
+	_, err := InstallPackage(buf.Bytes(), filepath.Join(t.TempDir(), "x"))
+	if err == nil || !strings.Contains(err.Error(), "expands too much") {
+		t.Fatalf("InstallPackage error = %v, want a zip bomb error", err)
+	}
+}
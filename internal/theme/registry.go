@@ -8,14 +8,20 @@ import (
 
 // Theme represents metadata about a theme.
 type Theme struct {
-	Name        string    `json:"name"`        // Theme identifier (e.g., "dark")
-	DisplayName string    `json:"displayName"` // Human-readable name
-	Description string    `json:"description"` // Short description
-	Author      string    `json:"author"`      // Theme author
-	Version     string    `json:"version"`     // Theme version
-	FilePath    string    `json:"filePath"`    // Path to the CSS file
-	IsBuiltIn   bool      `json:"isBuiltIn"`   // Whether this is a built-in theme
-	CreatedAt   time.Time `json:"createdAt"`   // When the theme was added
+	Name          string    `json:"name"`                    // Theme identifier (e.g., "dark")
+	DisplayName   string    `json:"displayName"`             // Human-readable name
+	Description   string    `json:"description"`             // Short description
+	Author        string    `json:"author"`                  // Theme author
+	Version       string    `json:"version"`                 // Theme version
+	FilePath      string    `json:"filePath"`                // Path to the CSS file
+	IsBuiltIn     bool      `json:"isBuiltIn"`               // Whether this is a built-in theme
+	IsProject     bool      `json:"isProject,omitempty"`     // Whether this theme comes from the project themes directory
+	Engines       []string  `json:"engines,omitempty"`       // PDF engines the theme supports (empty = any)
+	PackageDir    string    `json:"packageDir,omitempty"`    // Directory of an installed .vevetheme package
+	LaTeXTemplate string    `json:"latexTemplate,omitempty"` // Pandoc template for LaTeX engines (packages only)
+	BeamerTheme   string    `json:"beamerTheme,omitempty"`   // Beamer theme for --format slides (optional)
+	NoSmart       bool      `json:"noSmart,omitempty"`       // Whether smart punctuation is off by default
+	CreatedAt     time.Time `json:"createdAt"`               // When the theme was added
 }
 
 // Registry manages all available themes (built-in + user-installed).
@@ -0,0 +1,336 @@
+package theme
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ProcessOptions controls how ProcessCSS prepares theme CSS for a PDF engine.
+type ProcessOptions struct {
+	// Sanitize removes constructs a theme should not need and that could leak data
+	// or run code: external @import, javascript:/vbscript: URLs, and expression().
+	Sanitize bool
+
+	// BaseDir, if set, resolves relative url() references against this directory,
+	// for CSS that is written somewhere other than its source directory.
+	BaseDir string
+
+	// BaseURL, if set, resolves relative url() references against this URL,
+	// for CSS downloaded from a server (e.g. an inlined @import).
+	BaseURL string
+}
+
+// ProcessCSS minifies css (comments stripped, whitespace collapsed) and applies
+// opts. Line breaks are kept so engine and veve diagnostics still point at the
+// theme's source lines. Returns the processed CSS and a description of each
+// construct removed by sanitizing.
+func ProcessCSS(css string, opts ProcessOptions) (string, []string) {
+	m := &cssMinifier{src: css, opts: opts}
+	m.run()
+	return strings.TrimRight(m.out.String(), " \n"), m.removed
+}
+
+// cssMinifier rewrites CSS token by token. It only needs to tell strings,
+// comments, identifiers, and url() apart, so it does not build a stylesheet.
+type cssMinifier struct {
+	src      string
+	pos      int
+	opts     ProcessOptions
+	out      strings.Builder
+	removed  []string
+	space    bool // whitespace seen since the last token
+	newlines int  // line breaks seen since the last token
+}
+
+func (m *cssMinifier) run() {
+	for m.pos < len(m.src) {
+		c := m.src[m.pos]
+		switch {
+		case c == '/' && strings.HasPrefix(m.src[m.pos:], "/*"):
+			end := strings.Index(m.src[m.pos+2:], "*/")
+			if end < 0 {
+				end = len(m.src) - m.pos - 2
+			}
+			m.newlines += strings.Count(m.src[m.pos:m.pos+2+end], "\n")
+			m.space = true
+			m.pos = min(m.pos+2+end+2, len(m.src))
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			if c == '\n' {
+				m.newlines++
+			}
+			m.space = true
+			m.pos++
+		case c == '"' || c == '\'':
+			m.emit(m.readString())
+		case c == '@':
+			m.atRule()
+		case isNameByte(c) || c == '\\':
+			m.ident()
+		default:
+			m.punct(c)
+			m.pos++
+		}
+	}
+}
+
+// emit writes token, preceded by any pending line breaks or a single space
+// where one is needed to separate it from the previous token.
+func (m *cssMinifier) emit(token string) {
+	if m.newlines > 0 {
+		m.out.WriteString(strings.Repeat("\n", m.newlines))
+	} else if m.space && m.out.Len() > 0 && !strings.ContainsRune("{};,:", m.lastByte()) && !strings.ContainsRune("{};,", rune(token[0])) {
+		m.out.WriteByte(' ')
+	}
+	m.out.WriteString(token)
+	m.space, m.newlines = false, 0
+}
+
+func (m *cssMinifier) punct(c byte) {
+	// The last declaration in a block needs no semicolon
+	if c == '}' && m.newlines == 0 && m.out.Len() > 0 && m.lastByte() == ';' {
+		s := m.out.String()
+		m.out.Reset()
+		m.out.WriteString(s[:len(s)-1])
+	}
+	m.emit(string(c))
+}
+
+func (m *cssMinifier) lastByte() rune {
+	s := m.out.String()
+	return rune(s[len(s)-1])
+}
+
+// readString reads a quoted string, including its quotes and escapes.
+func (m *cssMinifier) readString() string {
+	start := m.pos
+	quote := m.src[m.pos]
+	m.pos++
+	for m.pos < len(m.src) {
+		c := m.src[m.pos]
+		if c == '\\' {
+			m.pos += 2
+			continue
+		}
+		m.pos++
+		if c == quote || c == '\n' {
+			break
+		}
+	}
+	m.pos = min(m.pos, len(m.src))
+	return m.src[start:m.pos]
+}
+
+func (m *cssMinifier) readIdent() string {
+	start := m.pos
+	for m.pos < len(m.src) {
+		c := m.src[m.pos]
+		if c == '\\' && m.pos+1 < len(m.src) {
+			m.pos += 2
+			continue
+		}
+		if !isNameByte(c) {
+			break
+		}
+		m.pos++
+	}
+	return m.src[start:m.pos]
+}
+
+// ident handles identifiers and the functions that need rewriting: url() and expression().
+func (m *cssMinifier) ident() {
+	name := m.readIdent()
+	if name == "" {
+		m.punct(m.src[m.pos])
+		m.pos++
+		return
+	}
+	if m.pos >= len(m.src) || m.src[m.pos] != '(' {
+		m.emit(name)
+		return
+	}
+
+	switch strings.ToLower(name) {
+	case "url":
+		m.url(name)
+	case "expression":
+		if !m.opts.Sanitize {
+			m.emit(name)
+			return
+		}
+		m.skipBalanced()
+		m.emit("none")
+		m.removed = append(m.removed, "expression()")
+	default:
+		m.emit(name)
+	}
+}
+
+// url rewrites a url() token, whose name has been read.
+func (m *cssMinifier) url(name string) {
+	start := m.pos
+	m.pos++ // (
+	// Unquoted URLs may not contain parentheses, but malicious ones do
+	for depth := 1; m.pos < len(m.src); {
+		switch m.src[m.pos] {
+		case '"', '\'':
+			m.readString()
+			continue
+		case '\\':
+			m.pos += 2
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		m.pos++
+		if depth == 0 {
+			break
+		}
+	}
+	m.pos = min(m.pos, len(m.src))
+	raw := m.src[start:m.pos]
+
+	target := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(raw, "("), ")"))
+	target = strings.Trim(target, `"'`)
+
+	switch {
+	case m.opts.Sanitize && isScriptURL(target):
+		m.emit("none")
+		m.removed = append(m.removed, fmt.Sprintf("url(%s)", target))
+	case m.opts.BaseURL != "" && target != "" && urlScheme(target) == "" && !strings.HasPrefix(target, "#"):
+		base, err := url.Parse(m.opts.BaseURL)
+		ref, refErr := url.Parse(target)
+		if err != nil || refErr != nil {
+			m.emit(name + strings.TrimSpace(raw))
+			return
+		}
+		m.emit(fmt.Sprintf(`url("%s")`, strings.ReplaceAll(base.ResolveReference(ref).String(), `"`, `\"`)))
+	case m.opts.BaseDir != "" && isRelativeURL(target):
+		resolved := filepath.ToSlash(filepath.Join(m.opts.BaseDir, filepath.FromSlash(target)))
+		if !strings.HasPrefix(resolved, "/") {
+			resolved = "/" + resolved
+		}
+		m.emit(fmt.Sprintf(`url("file://%s")`, strings.ReplaceAll(resolved, `"`, `\"`)))
+	default:
+		m.emit(name + strings.TrimSpace(raw))
+	}
+}
+
+// atRule handles at-rules; only @import needs rewriting.
+func (m *cssMinifier) atRule() {
+	start := m.pos
+	m.pos++
+	name := m.readIdent()
+	if !m.opts.Sanitize || strings.ToLower(name) != "import" {
+		m.emit(m.src[start:m.pos])
+		return
+	}
+
+	// Find the end of the @import statement
+	end := m.pos
+	for end < len(m.src) && m.src[end] != ';' {
+		switch m.src[end] {
+		case '"', '\'':
+			save := m.pos
+			m.pos = end
+			m.readString()
+			end, m.pos = m.pos, save
+		default:
+			end++
+		}
+	}
+	statement := m.src[start:min(end+1, len(m.src))]
+
+	target := importTarget(m.src[m.pos:end])
+	if !isExternalURL(target) && !isScriptURL(target) {
+		m.emit(m.src[start:m.pos])
+		return
+	}
+
+	m.removed = append(m.removed, strings.TrimSuffix(strings.TrimSpace(statement), ";"))
+	m.newlines += strings.Count(statement, "\n")
+	m.space = true
+	m.pos = min(end+1, len(m.src))
+}
+
+// skipBalanced skips a parenthesized group starting at the current "(".
+func (m *cssMinifier) skipBalanced() {
+	depth := 0
+	for m.pos < len(m.src) {
+		switch m.src[m.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '"', '\'':
+			m.readString()
+			continue
+		case '\n':
+			m.newlines++
+		}
+		m.pos++
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// importTarget extracts the URL from an @import prelude ("url(x) print" or "'x'").
+func importTarget(prelude string) string {
+	prelude = strings.TrimSpace(prelude)
+	if strings.HasPrefix(strings.ToLower(prelude), "url(") {
+		prelude = prelude[4:]
+		if end := strings.IndexByte(prelude, ')'); end >= 0 {
+			prelude = prelude[:end]
+		}
+		return strings.Trim(strings.TrimSpace(prelude), `"'`)
+	}
+	if len(prelude) > 0 && (prelude[0] == '"' || prelude[0] == '\'') {
+		if end := strings.IndexByte(prelude[1:], prelude[0]); end >= 0 {
+			return prelude[1 : end+1]
+		}
+	}
+	return prelude
+}
+
+// urlScheme returns the lowercased scheme of target, ignoring the whitespace,
+// control characters, and escapes that browsers and engines also ignore.
+func urlScheme(target string) string {
+	var b strings.Builder
+	for _, r := range target {
+		if r <= ' ' || r == '\\' {
+			continue
+		}
+		if r == ':' {
+			return strings.ToLower(b.String())
+		}
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.') {
+			return ""
+		}
+		b.WriteRune(r)
+	}
+	return ""
+}
+
+func isScriptURL(target string) bool {
+	scheme := urlScheme(target)
+	return scheme == "javascript" || scheme == "vbscript"
+}
+
+func isExternalURL(target string) bool {
+	if strings.HasPrefix(target, "//") {
+		return true
+	}
+	switch urlScheme(target) {
+	case "http", "https", "ftp":
+		return true
+	}
+	return false
+}
+
+func isRelativeURL(target string) bool {
+	return target != "" && urlScheme(target) == "" && !strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "#") && !filepath.IsAbs(target)
+}
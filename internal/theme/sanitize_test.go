@@ -0,0 +1,87 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestProcessCSSMinify tests that comments and whitespace are removed without
+// changing the meaning of the stylesheet.
+func TestProcessCSSMinify(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"comments", "/* header */ body { color: red; } /* trailing */", "body{color:red}"},
+		{"selector list", "h1 ,  h2 > p {  margin : 0 ;  }", "h1,h2 > p{margin :0}"},
+		{"descendant pseudo-class", "a :hover { color: red }", "a :hover{color:red}"},
+		{"calc keeps operator spacing", "p { width: calc(100%  -  2em); }", "p{width:calc(100% - 2em)}"},
+		{"strings untouched", `p::before { content: "a  /* b */  c"; }`, `p::before{content:"a  /* b */  c"}`},
+		{"media query", "@media print and (min-width: 10cm) { p { color: red; } }", "@media print and (min-width:10cm){p{color:red}}"},
+		{"line breaks kept", "h1 {\n  color: red;\n}\n", "h1{\ncolor:red;\n}"},
+		{"important", "p { color: red  !important; }", "p{color:red !important}"},
+		{"leading comment lines kept", "/* a\n b */\nh1 { }", "\n\nh1{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := ProcessCSS(tt.input, ProcessOptions{Sanitize: true})
+			if got != tt.want {
+				t.Errorf("ProcessCSS(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if len(removed) != 0 {
+				t.Errorf("unexpected removals: %v", removed)
+			}
+		})
+	}
+}
+
+// TestProcessCSSSanitize tests that dangerous constructs are removed only when sanitizing.
+func TestProcessCSSSanitize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		removed string
+	}{
+		{"external import", `@import url("https://fonts.example.com/a.css"); body { color: red; }`, "body{color:red}", "@import"},
+		{"protocol-relative import", `@import "//example.com/a.css" print;`, "", "@import"},
+		{"local import", `@import "base.css";`, `@import "base.css";`, ""},
+		{"javascript url", `body { background: url(javascript:alert(1)); }`, "body{background:none}", "javascript"},
+		{"obfuscated javascript url", `body { background: url(" JaVa\Script:alert(1)"); }`, "body{background:none}", "Script"},
+		{"expression", `p { width: expression(alert(1)); }`, "p{width:none}", "expression()"},
+		{"image url", `body { background: url(bg.png); }`, "body{background:url(bg.png)}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := ProcessCSS(tt.input, ProcessOptions{Sanitize: true})
+			if got != tt.want {
+				t.Errorf("ProcessCSS(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if tt.removed == "" && len(removed) != 0 {
+				t.Errorf("unexpected removals: %v", removed)
+			}
+			if tt.removed != "" && (len(removed) != 1 || !strings.Contains(removed[0], tt.removed)) {
+				t.Errorf("removed = %v, want one containing %q", removed, tt.removed)
+			}
+
+			// Trusted themes pass through unchanged apart from minification
+			if unsanitized, removed := ProcessCSS(tt.input, ProcessOptions{}); len(removed) != 0 || (tt.removed != "" && unsanitized == got) {
+				t.Errorf("expected %q to be kept without sanitizing, got %q", tt.input, unsanitized)
+			}
+		})
+	}
+}
+
+// TestProcessCSSBaseDir tests that relative URLs are resolved against the base directory.
+func TestProcessCSSBaseDir(t *testing.T) {
+	input := `@font-face { src: url("fonts/brand.woff2"); } body { background: url(#grad) url(data:image/png;base64,AA==) url(/abs.png); }`
+	got, _ := ProcessCSS(input, ProcessOptions{BaseDir: "/themes/brand"})
+
+	want := `@font-face{src:url("file:///themes/brand/fonts/brand.woff2")}body{background:url(#grad) url(data:image/png;base64,AA==) url(/abs.png)}`
+	if got != want {
+		t.Errorf("ProcessCSS() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package theme
+
+import "strings"
+
+// ScopeCSS limits css to elements inside scope (a selector such as
+// ".theme-technical"), so several themes can style parts of one document.
+// Selectors on html, body, and :root apply to the scope element itself.
+//
+// @page rules are dropped, since pages can't be scoped, as are @import and
+// @charset rules; resolve imports first. @font-face and @keyframes are kept
+// unchanged.
+func ScopeCSS(css, scope string) string {
+	sheet, _ := ParseCSS(css)
+	var sb strings.Builder
+	writeScopedRules(&sb, sheet.Rules, scope)
+	return sb.String()
+}
+
+// writeScopedRules writes rules to sb with their selectors scoped.
+func writeScopedRules(sb *strings.Builder, rules []Rule, scope string) {
+	for _, rule := range rules {
+		switch rule.AtRule {
+		case "":
+			selectors := make([]string, len(rule.Selectors))
+			for i, selector := range rule.Selectors {
+				selectors[i] = scopeSelector(selector, scope)
+			}
+			sb.WriteString(strings.Join(selectors, ","))
+			writeBlock(sb, rule)
+		case "page", "import", "charset":
+			continue
+		case "media", "supports", "layer", "container", "document":
+			if !rule.HasBlock {
+				writeRule(sb, rule)
+				continue
+			}
+			sb.WriteString("@" + rule.AtRule + " " + rule.Prelude + "{")
+			writeScopedRules(sb, rule.Rules, scope)
+			sb.WriteString("}\n")
+		default:
+			writeRule(sb, rule)
+		}
+	}
+}
+
+// writeRule writes rule to sb unchanged.
+func writeRule(sb *strings.Builder, rule Rule) {
+	if rule.AtRule == "" {
+		sb.WriteString(strings.Join(rule.Selectors, ","))
+		writeBlock(sb, rule)
+		return
+	}
+	sb.WriteString("@" + rule.AtRule)
+	if rule.Prelude != "" {
+		sb.WriteString(" " + rule.Prelude)
+	}
+	if !rule.HasBlock {
+		sb.WriteString(";\n")
+		return
+	}
+	if ruleListAtRules[rule.AtRule] {
+		sb.WriteString("{")
+		for _, nested := range rule.Rules {
+			writeRule(sb, nested)
+		}
+		sb.WriteString("}\n")
+		return
+	}
+	writeBlock(sb, rule)
+}
+
+// writeBlock writes rule's declarations and nested at-rules as a {} block.
+func writeBlock(sb *strings.Builder, rule Rule) {
+	sb.WriteString("{")
+	for _, decl := range rule.Declarations {
+		sb.WriteString(decl.Property + ":" + decl.Value)
+		if decl.Important {
+			sb.WriteString(" !important")
+		}
+		sb.WriteString(";")
+	}
+	for _, nested := range rule.Rules {
+		writeRule(sb, nested)
+	}
+	sb.WriteString("}\n")
+}
+
+// scopeSelector prefixes selector with scope, replacing a leading html, body,
+// or :root compound with the scope itself.
+func scopeSelector(selector, scope string) string {
+	parts := strings.Fields(selector)
+	rest := parts[:0:0]
+	for i, part := range parts {
+		if part != "html" && part != "body" && part != ":root" {
+			rest = parts[i:]
+			break
+		}
+		if i == len(parts)-1 {
+			return scope
+		}
+	}
+	// body.dark or :root[data-x] style the scope element with a condition
+	first := rest[0]
+	for _, root := range []string{"html", "body", ":root"} {
+		if strings.HasPrefix(first, root) && len(first) > len(root) && strings.ContainsRune(".#[:", rune(first[len(root)])) {
+			rest[0] = scope + first[len(root):]
+			return strings.Join(rest, " ")
+		}
+	}
+	return scope + " " + strings.Join(rest, " ")
+}
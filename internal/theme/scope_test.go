@@ -0,0 +1,30 @@
+package theme
+
+import "testing"
+
+// TestScopeCSS tests that style rules are limited to the scope and rules that
+// can't be scoped are kept or dropped.
+func TestScopeCSS(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"selector list", "h1, p a { color: red }", ".ch h1,.ch p a{color:red;}\n"},
+		{"body", "body { margin: 0 }", ".ch{margin:0;}\n"},
+		{"body with class", "html body.dark p { color: white }", ".ch.dark p{color:white;}\n"},
+		{"custom property", ":root { --Accent: #00f }", ".ch{--Accent:#00f;}\n"},
+		{"important", "p { color: red !important }", ".ch p{color:red !important;}\n"},
+		{"media", "@media print { p { color: black } }", "@media print{.ch p{color:black;}\n}\n"},
+		{"page dropped", "@page { size: A4 } p { color: red }", ".ch p{color:red;}\n"},
+		{"font face kept", "@font-face { font-family: X; src: url(x.woff) }", "@font-face{font-family:X;src:url(x.woff);}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopeCSS(tt.input, ".ch"); got != tt.want {
+				t.Errorf("ScopeCSS(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
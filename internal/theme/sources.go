@@ -0,0 +1,59 @@
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/filelock"
+)
+
+// SourcesFile records, in the user themes directory, where each installed
+// theme came from, so 'veve theme update' can install it again.
+const SourcesFile = ".sources.json"
+
+// Source is where an installed theme came from.
+type Source struct {
+	// Source is the path, URL, or github: source the theme was installed from
+	Source string `json:"source"`
+	// File selects the theme in a zip archive or repository holding several
+	File string `json:"file,omitempty"`
+	// Commit is the commit of a github: source at installation
+	Commit    string    `json:"commit,omitempty"`
+	Installed time.Time `json:"installed"`
+}
+
+// Sources maps installed theme names to their sources.
+type Sources map[string]Source
+
+// LoadSources reads the sources recorded in themesDir. A missing file yields
+// no sources.
+func LoadSources(themesDir string) (Sources, error) {
+	data, err := os.ReadFile(filepath.Join(themesDir, SourcesFile))
+	if os.IsNotExist(err) {
+		return Sources{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme sources: %w", err)
+	}
+
+	sources := Sources{}
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("invalid theme sources %s: %w", filepath.Join(themesDir, SourcesFile), err)
+	}
+	return sources, nil
+}
+
+// Save writes the sources to themesDir.
+func (s Sources) Save(themesDir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := filelock.WriteFile(filepath.Join(themesDir, SourcesFile), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save theme sources: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package transform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/madstone-tech/veve-cli/internal/shell"
+)
+
+// Command is a transformer that runs a shell command, in Dir, with the
+// markdown on stdin; its stdout is the transformed markdown. The document is
+// in the environment as VEVE_INPUT, VEVE_THEME, and VEVE_PROJECT_ROOT.
+//
+// Any language works, including WASM modules through a runtime's CLI (for
+// example "wasmtime run filter.wasm").
+type Command struct {
+	Command string
+	Dir     string
+}
+
+// Transform runs the command.
+func (c Command) Transform(doc Document, content string) (string, error) {
+	cmd := shell.Command(c.Command)
+	cmd.Dir = c.Dir
+	cmd.Env = append(os.Environ(),
+		"VEVE_INPUT="+doc.Path,
+		"VEVE_THEME="+doc.Theme,
+		"VEVE_PROJECT_ROOT="+doc.Project,
+	)
+	cmd.Stdin = strings.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	// An empty document is almost certainly a broken command, not an edit
+	if stdout.Len() == 0 && strings.TrimSpace(content) != "" {
+		return "", errors.New("command wrote no output (transformers must write the markdown to stdout)")
+	}
+	return stdout.String(), nil
+}
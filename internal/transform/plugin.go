@@ -0,0 +1,35 @@
+//go:build goplugin
+
+package transform
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// PluginSupported reports whether this veve can load Go plugins.
+const PluginSupported = true
+
+// pluginFunc is the signature of a plugin's Transform function. It only uses
+// builtin types, so plugins need not import veve.
+type pluginFunc = func(content string, meta map[string]string) (string, error)
+
+// openPlugin loads the Go plugin at path. The plugin must be built with the
+// same Go version and dependency versions as veve.
+func openPlugin(path string) (Transformer, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := p.Lookup("Transform")
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := symbol.(pluginFunc)
+	if !ok {
+		return nil, fmt.Errorf("plugin Transform is a %T, want func(string, map[string]string) (string, error)", symbol)
+	}
+	return Func(func(doc Document, content string) (string, error) {
+		return fn(content, doc.Meta())
+	}), nil
+}
@@ -0,0 +1,14 @@
+//go:build !goplugin
+
+package transform
+
+import "errors"
+
+// PluginSupported reports whether this veve can load Go plugins.
+const PluginSupported = false
+
+// openPlugin reports that plugins are not supported. Loading them needs cgo
+// and links veve dynamically, so it is opt-in.
+func openPlugin(path string) (Transformer, error) {
+	return nil, errors.New("this veve was built without Go plugin support (rebuild with CGO_ENABLED=1 and -tags goplugin, or use a command transformer)")
+}
@@ -0,0 +1,146 @@
+// Package transform runs content transformers: custom steps that receive a
+// document's markdown and return modified markdown before veve converts it
+// (for example to expand corporate boilerplate or redact internal names).
+//
+// Transformers are configured in a project's .veve.yaml and are one of:
+//
+//   - a command, which reads markdown on stdin and writes it to stdout
+//   - a Go plugin (.so) exporting Transform (builds with the goplugin tag)
+//   - a builtin, compiled into veve with Register
+package transform
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+)
+
+// Document describes the document being transformed.
+type Document struct {
+	Path    string // Input file (empty when reading stdin)
+	Theme   string // Theme name or path
+	Project string // Project root
+}
+
+// Meta returns the document as the metadata map passed to Go plugins.
+func (d Document) Meta() map[string]string {
+	return map[string]string{
+		"input":        d.Path,
+		"theme":        d.Theme,
+		"project_root": d.Project,
+	}
+}
+
+// Transformer modifies markdown before it is converted.
+type Transformer interface {
+	Transform(doc Document, content string) (string, error)
+}
+
+// Func adapts a function to Transformer.
+type Func func(doc Document, content string) (string, error)
+
+// Transform calls f.
+func (f Func) Transform(doc Document, content string) (string, error) {
+	return f(doc, content)
+}
+
+// Builtin transformers, registered with Register
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Transformer)
+)
+
+// Register makes a transformer available to .veve.yaml as a builtin. It is
+// meant to be called from an init function, and panics if name is taken.
+func Register(name string, t Transformer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		panic("transform: Register called twice for " + name)
+	}
+	registry[name] = t
+}
+
+// Builtins returns the names of the registered builtins, sorted.
+func Builtins() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Step is a configured transformer.
+type Step struct {
+	Name        string
+	Transformer Transformer
+	External    bool // Runs code from the project (a command or plugin), not veve's own
+}
+
+// New creates the transformer cfg describes, resolving plugin paths and
+// running commands relative to projectRoot.
+func New(cfg config.TransformerConfig, projectRoot string) (Step, error) {
+	set := 0
+	for _, value := range []string{cfg.Command, cfg.Plugin, cfg.Builtin} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return Step{}, fmt.Errorf("transformer %q must set exactly one of command, plugin, or builtin", cfg.Name)
+	}
+
+	step := Step{Name: cfg.Name}
+	switch {
+	case cfg.Command != "":
+		step.Transformer = Command{Command: cfg.Command, Dir: projectRoot}
+		step.External = true
+		if step.Name == "" {
+			step.Name = cfg.Command
+		}
+	case cfg.Plugin != "":
+		path := cfg.Plugin
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectRoot, filepath.FromSlash(path))
+		}
+		t, err := openPlugin(path)
+		if err != nil {
+			return Step{}, fmt.Errorf("transformer %q: %w", cfg.Plugin, err)
+		}
+		step.Transformer = t
+		step.External = true
+		if step.Name == "" {
+			step.Name = cfg.Plugin
+		}
+	default:
+		registryMu.RLock()
+		t, ok := registry[cfg.Builtin]
+		registryMu.RUnlock()
+		if !ok {
+			return Step{}, fmt.Errorf("unknown builtin transformer %q (available: %v)", cfg.Builtin, Builtins())
+		}
+		step.Transformer = t
+		if step.Name == "" {
+			step.Name = cfg.Builtin
+		}
+	}
+	return step, nil
+}
+
+// Apply runs steps in order, each on the previous one's output.
+func Apply(steps []Step, doc Document, content string) (string, error) {
+	for _, step := range steps {
+		transformed, err := step.Transformer.Transform(doc, content)
+		if err != nil {
+			return "", fmt.Errorf("transformer %q failed: %w", step.Name, err)
+		}
+		content = transformed
+	}
+	return content, nil
+}
@@ -0,0 +1,95 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CleanupReport lists the temporary files and directories a cleanup removed,
+// and those it couldn't.
+type CleanupReport struct {
+	Removed []string         // Paths removed
+	Failed  []CleanupFailure // Paths left behind, with why
+}
+
+// CleanupFailure is a path a cleanup couldn't remove.
+type CleanupFailure struct {
+	Path string
+	Err  error
+}
+
+// Remove removes path, recording the outcome. A path that doesn't exist is
+// neither removed nor a failure.
+func (r *CleanupReport) Remove(path string) {
+	err := os.Remove(path)
+	switch {
+	case err == nil:
+		r.Removed = append(r.Removed, path)
+	case !errors.Is(err, fs.ErrNotExist):
+		r.fail(path, err)
+	}
+}
+
+// fail records that path couldn't be removed. The path is dropped from a
+// *fs.PathError, since the failure names it.
+func (r *CleanupReport) fail(path string, err error) {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	r.Failed = append(r.Failed, CleanupFailure{Path: path, Err: err})
+}
+
+// RemoveTree removes dir and everything in it, deepest paths first,
+// recording the outcome for each.
+func (r *CleanupReport) RemoveTree(dir string) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				r.fail(path, err)
+			}
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		r.fail(dir, err)
+	}
+	// WalkDir visits directories before their contents. A directory holding
+	// a path that failed is left, rather than failing again as not empty.
+	for _, path := range slices.Backward(paths) {
+		if !r.failedWithin(path) {
+			r.Remove(path)
+		}
+	}
+}
+
+// failedWithin reports whether a path inside dir failed to be removed.
+func (r *CleanupReport) failedWithin(dir string) bool {
+	prefix := dir + string(filepath.Separator)
+	return slices.ContainsFunc(r.Failed, func(failure CleanupFailure) bool {
+		return strings.HasPrefix(failure.Path, prefix)
+	})
+}
+
+// Merge adds other's outcomes to r.
+func (r *CleanupReport) Merge(other CleanupReport) {
+	r.Removed = append(r.Removed, other.Removed...)
+	r.Failed = append(r.Failed, other.Failed...)
+}
+
+// Err returns an error listing the paths that couldn't be removed, or nil.
+func (r CleanupReport) Err() error {
+	errs := make([]error, len(r.Failed))
+	for i, failure := range r.Failed {
+		errs[i] = fmt.Errorf("failed to remove %s: %w", failure.Path, failure.Err)
+	}
+	return errors.Join(errs...)
+}
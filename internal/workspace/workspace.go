@@ -0,0 +1,66 @@
+// Package workspace provides the private per-run directory veve uses for
+// intermediate files (theme CSS, processed markdown, downloaded images).
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Workspace is a private temporary directory owned by a single veve run.
+//
+// The directory is created with 0700 permissions and a random name, and files
+// are created with random names and 0600 permissions, so concurrent runs never
+// collide and other users cannot read or pre-create (symlink) intermediate files.
+type Workspace struct {
+	Dir string
+}
+
+// New creates a workspace under the system temp directory.
+func New() (*Workspace, error) {
+	dir, err := os.MkdirTemp("", "veve-run-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return &Workspace{Dir: dir}, nil
+}
+
+// WriteFile writes data to a new file in the workspace and returns its path.
+// The pattern follows os.CreateTemp: the last "*" is replaced by a random string.
+func (w *Workspace) WriteFile(pattern string, data []byte) (string, error) {
+	f, err := os.CreateTemp(w.Dir, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s in workspace: %w", pattern, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write %s: %w", f.Name(), err)
+	}
+
+	return f.Name(), nil
+}
+
+// Subdir returns the path of a subdirectory of the workspace, creating it with 0700 permissions.
+func (w *Workspace) Subdir(name string) (string, error) {
+	dir := filepath.Join(w.Dir, name)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create workspace directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// Remove deletes the workspace and everything in it.
+func (w *Workspace) Remove() error {
+	return os.RemoveAll(w.Dir)
+}
+
+// Cleanup deletes the workspace and everything in it, reporting what was and
+// wasn't removed. It keeps going past files it can't remove.
+func (w *Workspace) Cleanup() CleanupReport {
+	var report CleanupReport
+	report.RemoveTree(w.Dir)
+	return report
+}
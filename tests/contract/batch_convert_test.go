@@ -0,0 +1,154 @@
+package contract_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBatchConvert_Failures tests that every input is attempted and the
+// failures are summarized, with the exit code of their kind.
+func TestBatchConvert_Failures(t *testing.T) {
+	vevePath := buildVeve(t)
+	if vevePath == "" {
+		t.Skip("veve binary not available")
+	}
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not available")
+	}
+
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "first.md")
+	second := filepath.Join(tmpDir, "second.md")
+
+	t.Run("missing inputs", func(t *testing.T) {
+		cmd := exec.Command(vevePath, "convert", first, second)
+		output, _ := cmd.CombinedOutput()
+
+		if code := cmd.ProcessState.ExitCode(); code != 3 {
+			t.Errorf("expected exit code 3 (input error), got %d\n%s", code, output)
+		}
+		for _, want := range []string{"Converted 0 of 2 file(s)", first, second} {
+			if !strings.Contains(string(output), want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("directory without markdown", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "empty")
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(vevePath, "convert", dir, "--output-dir", filepath.Join(tmpDir, "pdf"))
+		output, _ := cmd.CombinedOutput()
+
+		if code := cmd.ProcessState.ExitCode(); code != 3 {
+			t.Errorf("expected exit code 3 (input error), got %d\n%s", code, output)
+		}
+		if !strings.Contains(string(output), "no markdown files") {
+			t.Errorf("expected the directory to be reported empty, got:\n%s", output)
+		}
+	})
+
+	t.Run("output and output-dir", func(t *testing.T) {
+		cmd := exec.Command(vevePath, "convert", first, "-o", filepath.Join(tmpDir, "out.pdf"), "--output-dir", tmpDir)
+		output, _ := cmd.CombinedOutput()
+
+		if code := cmd.ProcessState.ExitCode(); code != 2 {
+			t.Errorf("expected exit code 2 (usage error), got %d\n%s", code, output)
+		}
+	})
+
+	t.Run("output names one file", func(t *testing.T) {
+		cmd := exec.Command(vevePath, "convert", first, second, "-o", filepath.Join(tmpDir, "out.pdf"))
+		output, _ := cmd.CombinedOutput()
+
+		if code := cmd.ProcessState.ExitCode(); code != 2 {
+			t.Errorf("expected exit code 2 (usage error), got %d\n%s", code, output)
+		}
+	})
+}
+
+// jobsPandoc wraps the stub pandoc: each conversion records how many were
+// running at once in $JOBS_DIR/counts, and one of a document containing
+// "Slow" takes far longer than any test waits.
+const jobsPandoc = `#!/bin/sh
+case "$*" in *--version*) exec pandoc-stub "$@";; esac
+for arg in "$@"; do
+  case "$arg" in *.md) grep -q Slow "$arg" 2>/dev/null && exec sleep 60;; esac
+done
+touch "$JOBS_DIR/running.$$"
+sleep 0.5
+ls "$JOBS_DIR" | grep -c '^running\.' >> "$JOBS_DIR/counts"
+rm "$JOBS_DIR/running.$$"
+exec pandoc-stub "$@"
+`
+
+// TestBatchConvert_Jobs tests that --jobs bounds how many inputs convert at
+// once, and that --job-timeout stops an input that takes too long and
+// reports it as failed, while the others convert.
+func TestBatchConvert_Jobs(t *testing.T) {
+	veve, env := stubToolchain(t, map[string]string{"pandoc": jobsPandoc, "pandoc-stub": stubPandoc})
+	jobsDir := t.TempDir()
+	env = append(env, "JOBS_DIR="+jobsDir)
+
+	dir := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"a.md", "b.md", "c.md", "d.md", "e.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("# "+name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, name)
+	}
+
+	t.Run("jobs", func(t *testing.T) {
+		out, code := runVeve(t, veve, env, dir, append(inputs, "--jobs", "2")...)
+		if code != 0 {
+			t.Fatalf("veve exited %d: %s", code, out)
+		}
+		counts, err := os.ReadFile(filepath.Join(jobsDir, "counts"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		most := 0
+		for _, count := range strings.Fields(string(counts)) {
+			n, err := strconv.Atoi(count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			most = max(most, n)
+		}
+		if most != 2 {
+			t.Errorf("at most %d conversions ran at once, want 2 (counts: %s)", most, strings.Fields(string(counts)))
+		}
+		for _, name := range inputs {
+			if _, err := os.Stat(filepath.Join(dir, strings.TrimSuffix(name, ".md")+".pdf")); err != nil {
+				t.Errorf("%s wasn't converted: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("job timeout", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "slow.md"), []byte("# Slow\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		out, code := runVeve(t, veve, env, dir, "a.md", "slow.md", "--jobs", "2", "--job-timeout", "2s")
+		if elapsed := time.Since(start); elapsed > 20*time.Second {
+			t.Errorf("veve took %s, want the slow input stopped after 2s", elapsed)
+		}
+		if code == 0 {
+			t.Fatalf("veve succeeded, want the slow input to fail: %s", out)
+		}
+		for _, want := range []string{"Converted 1 of 2 file(s)", "slow.md: timed out after 2s"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+}
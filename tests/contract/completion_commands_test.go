@@ -169,33 +169,77 @@ func TestCompletionCommand_Fish(t *testing.T) {
 	})
 }
 
-// TestCompletionCommand_InstallScript tests that the install-completion.sh script exists and is executable
-func TestCompletionInstallScript(t *testing.T) {
-	scriptPath := "scripts/install-completion.sh"
+// TestCompletionCommand_PowerShell tests that 'veve completion powershell' generates a PowerShell completion script
+func TestCompletionCommand_PowerShell(t *testing.T) {
+	vevePath := buildVeve(t)
+	if vevePath == "" {
+		t.Skip("veve binary not available")
+	}
 
-	// Check if script exists (relative to repo root)
-	// For now, we just verify the file can be found
-	repoRoot := getRepoRoot(t)
-	fullPath := filepath.Join(repoRoot, scriptPath)
+	cmd := exec.Command(vevePath, "completion", "powershell")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
 
-	t.Run("install-completion.sh exists", func(t *testing.T) {
-		info, err := os.Stat(fullPath)
-		if err != nil {
-			// Script may not exist yet; this is expected in early phases
-			t.Skipf("install-completion.sh not found at %s (will be created in implementation)", fullPath)
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("completion powershell command failed: %v", err)
+	}
+
+	output := stdout.String()
+	if !strings.Contains(output, "Register-ArgumentCompleter") {
+		t.Errorf("powershell completion should register an argument completer, output starts with: %s", truncate(output, 200))
+	}
+	// Values come from veve itself, through the hidden __complete command
+	if !strings.Contains(output, "__complete") {
+		t.Errorf("powershell completion should call veve __complete for flag values")
+	}
+}
+
+// TestCompletionInstall tests that 'veve completion install' writes the script
+// where the detected shell finds it
+func TestCompletionInstall(t *testing.T) {
+	vevePath := buildVeve(t)
+	if vevePath == "" {
+		t.Skip("veve binary not available")
+	}
+
+	home := t.TempDir()
+	env := append(os.Environ(), "HOME="+home, "USERPROFILE="+home, "XDG_CONFIG_HOME=", "XDG_DATA_HOME=", "ZDOTDIR=", "BASH_COMPLETION_USER_DIR=")
+
+	t.Run("detects the shell", func(t *testing.T) {
+		cmd := exec.Command(vevePath, "completion", "install")
+		cmd.Env = append(env, "SHELL=/usr/bin/fish")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("completion install failed: %v\n%s", err, output)
 		}
 
-		// Verify it's a regular file
-		if !info.Mode().IsRegular() {
-			t.Error("install-completion.sh is not a regular file")
+		script, err := os.ReadFile(filepath.Join(home, ".config", "fish", "completions", "veve.fish"))
+		if err != nil {
+			t.Fatalf("fish completion not installed: %v", err)
+		}
+		if !strings.Contains(string(script), "complete -c veve") {
+			t.Errorf("installed script is not a fish completion: %s", truncate(string(script), 200))
 		}
+	})
 
-		// Verify it's executable
-		if (info.Mode() & 0111) == 0 {
-			t.Error("install-completion.sh is not executable")
+	t.Run("adds the script to the startup file once", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			cmd := exec.Command(vevePath, "completion", "install", "zsh")
+			cmd.Env = env
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("completion install zsh failed: %v\n%s", err, output)
+			}
 		}
 
-		t.Logf("install-completion.sh exists and is executable")
+		if _, err := os.Stat(filepath.Join(home, ".zsh", "completions", "_veve")); err != nil {
+			t.Fatalf("zsh completion not installed: %v", err)
+		}
+		zshrc, err := os.ReadFile(filepath.Join(home, ".zshrc"))
+		if err != nil {
+			t.Fatalf(".zshrc not written: %v", err)
+		}
+		if n := strings.Count(string(zshrc), "fpath="); n != 1 {
+			t.Errorf(".zshrc sets fpath %d times, want 1:\n%s", n, zshrc)
+		}
 	})
 }
 
@@ -233,20 +277,55 @@ func TestEngineFlagCompletion(t *testing.T) {
 	})
 
 	t.Run("veve convert --engine 'w' filters to weasyprint", func(t *testing.T) {
-		// This test may be skipped if completion support not implemented yet
 		cmd := exec.Command(vevePath, "__complete", "convert", "--engine", "w")
 		var stdout bytes.Buffer
 		cmd.Stdout = &stdout
 
-		_ = cmd.Run() // Ignore error for now
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("completion failed: %v", err)
+		}
 
 		output := stdout.String()
-
-		// Output should be minimal or contain filtered results
-		t.Logf("Filtered completion output for 'w': %s", truncate(output, 300))
+		for _, engine := range []string{"weasyprint\t", "wkhtmltopdf\t"} {
+			if !strings.Contains(output, engine) {
+				t.Errorf("completion for 'w' missing %q: %s", engine, truncate(output, 300))
+			}
+		}
+		if strings.Contains(output, "xelatex") {
+			t.Errorf("completion for 'w' should not offer xelatex: %s", truncate(output, 300))
+		}
 	})
 }
 
+// TestThemeFlagCompletion tests that --theme completes the built-in themes
+func TestThemeFlagCompletion(t *testing.T) {
+	vevePath := buildVeve(t)
+	if vevePath == "" {
+		t.Skip("veve binary not available")
+	}
+
+	home := t.TempDir()
+	cmd := exec.Command(vevePath, "__complete", "convert", "--theme", "d")
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+filepath.Join(home, ".config"), "XDG_CACHE_HOME="+filepath.Join(home, ".cache"))
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("completion failed: %v", err)
+	}
+
+	output := stdout.String()
+	for _, theme := range []string{"default\t", "dark\t"} {
+		if !strings.Contains(output, theme) {
+			t.Errorf("completion for 'd' missing %q: %s", theme, truncate(output, 300))
+		}
+	}
+	if strings.Contains(output, "academic") {
+		t.Errorf("completion for 'd' should not offer academic: %s", truncate(output, 300))
+	}
+}
+
 // Helper functions
 
 // truncate returns first n characters of string
@@ -257,23 +336,14 @@ func truncate(s string, n int) string {
 	return s[:n] + "..."
 }
 
-// getRepoRoot returns the repository root directory for this test
-func getRepoRoot(t *testing.T) string {
-	// Try to find the repo root by looking for go.mod
-	cwd, err := os.Getwd()
-	if err != nil {
-		t.Fatalf("failed to get current directory: %v", err)
-	}
-
-	for {
-		if _, err := os.Stat(filepath.Join(cwd, "go.mod")); err == nil {
-			return cwd
-		}
+// TestCompletionWithoutPandoc tests that flag values complete on a machine
+// without pandoc, where converting would fail.
+func TestCompletionWithoutPandoc(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+	env = append(env, "PATH="+filepath.Join(t.TempDir(), "empty"))
 
-		parent := filepath.Dir(cwd)
-		if parent == cwd {
-			t.Fatalf("could not find repository root (go.mod not found)")
-		}
-		cwd = parent
+	out, code := runVeve(t, veve, env, t.TempDir(), "__complete", "convert", "--theme", "d")
+	if code != 0 || !strings.Contains(out, "default\t") {
+		t.Errorf("completion exited %d, want 0 and the default theme: %s", code, out)
 	}
 }
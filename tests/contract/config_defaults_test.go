@@ -0,0 +1,47 @@
+package contract_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestConfigDefaultsApplyToEveryConversion tests that the commands that
+// convert documents for their own ends use the defaults in veve.toml, as
+// veve and veve convert do: a configured theme that doesn't exist fails each.
+func TestConfigDefaultsApplyToEveryConversion(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+	writeUserConfig(t, env, "[defaults]\ntheme = \"configured-theme\"\n")
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"old.md":       "Old text.\n",
+		"new.md":       "New text.\n",
+		"CHANGELOG.md": "# Changelog\n\n## [1.1.0]\n\n- more\n\n## [1.0.0]\n\n- first\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"convert", []string{"convert", "new.md"}},
+		{"verify", []string{"verify", "new.md", "--golden", "golden.pdf", "--update"}},
+		{"diff", []string{"diff", "old.md", "new.md"}},
+		{"changelog", []string{"changelog", "--from", "v1.0.0", "--to", "v1.1.0", "--source", "changelog"}},
+		{"engine bench", []string{"engine", "bench", "new.md", "--engines", "weasyprint", "--json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := runVeve(t, veve, env, dir, tt.args...)
+			if !strings.Contains(out, "configured-theme") {
+				t.Errorf("veve %s didn't use the configured theme:\n%s", strings.Join(tt.args, " "), out)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package contract_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubGPG stands in for gpg --detach-sign: it writes a fake signature to the
+// --output file.
+const stubGPG = `#!/bin/sh
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --output) out="$2"; shift;;
+  esac
+  shift
+done
+echo "-----BEGIN PGP SIGNATURE-----" > "$out"
+`
+
+// TestConversionReport tests that --json prints a report of each output
+// with its checksum and signature, and each input that failed, with nothing
+// else on stdout.
+func TestConversionReport(t *testing.T) {
+	veve, env := stubToolchain(t, map[string]string{"gpg": stubGPG})
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte("# Doc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Input        string `json:"input"`
+		Output       string `json:"output"`
+		Checksum     string `json:"checksum"`
+		ChecksumFile string `json:"checksum_file"`
+		Signature    string `json:"signature"`
+		Signer       string `json:"signer"`
+		Error        string `json:"error"`
+	}
+	var report struct {
+		Conversions []result `json:"conversions"`
+	}
+
+	t.Run("single input", func(t *testing.T) {
+		out, code := runVeve(t, veve, env, dir, "doc.md", "--checksum", "--sign", "--json")
+		if code != 0 {
+			t.Fatalf("veve exited %d: %s", code, out)
+		}
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("stdout isn't a JSON report: %v\n%s", err, out)
+		}
+		if len(report.Conversions) != 1 {
+			t.Fatalf("got %d conversions, want 1: %s", len(report.Conversions), out)
+		}
+		got := report.Conversions[0]
+		sidecar, err := os.ReadFile(filepath.Join(dir, "doc.pdf.sha256"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum, _, _ := strings.Cut(string(sidecar), " ")
+		want := result{
+			Input: "doc.md", Output: "doc.pdf",
+			Checksum: "sha256:" + sum, ChecksumFile: "doc.pdf.sha256",
+			Signature: "doc.pdf.asc", Signer: "gpg",
+		}
+		if got != want {
+			t.Errorf("report = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("failed input", func(t *testing.T) {
+		cmd := exec.Command(veve, "doc.md", "missing.md", "--checksum", "--json")
+		cmd.Env, cmd.Dir = env, dir
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err == nil {
+			t.Fatalf("veve succeeded, want missing.md to fail: %s", out)
+		}
+		if err := json.Unmarshal(out, &report); err != nil {
+			t.Fatalf("stdout isn't a JSON report: %v\n%s\n%s", err, out, stderr.String())
+		}
+		if len(report.Conversions) != 2 {
+			t.Fatalf("got %d conversions, want 2: %s", len(report.Conversions), out)
+		}
+		if got := report.Conversions[0]; got.Input != "doc.md" || got.Checksum == "" || got.Error != "" {
+			t.Errorf("report of doc.md = %+v, want it converted with a checksum", got)
+		}
+		if got := report.Conversions[1]; got.Input != "missing.md" || got.Output != "" || got.Error == "" {
+			t.Errorf("report of missing.md = %+v, want its error", got)
+		}
+	})
+
+	t.Run("stdout output", func(t *testing.T) {
+		out, code := runVeve(t, veve, env, dir, "doc.md", "-o", "-", "--json")
+		if code != 2 {
+			t.Errorf("veve exited %d, want 2 (usage error): %s", code, out)
+		}
+	})
+}
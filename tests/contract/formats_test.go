@@ -0,0 +1,45 @@
+package contract_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestFormats_UsageErrors tests that outputs which can't be written
+// together are rejected before converting.
+func TestFormats_UsageErrors(t *testing.T) {
+	vevePath := buildVeve(t)
+	if vevePath == "" {
+		t.Skip("veve binary not available")
+	}
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not available")
+	}
+
+	tmpDir := t.TempDir()
+	input := filepath.Join(tmpDir, "doc.md")
+	if err := os.WriteFile(input, []byte("# Doc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]string{
+		"unknown format":        {input, "--formats", "pdf,docx"},
+		"two outputs of a kind": {input, "-o", "a.pdf", "-o", "b.pdf"},
+		"formats and outputs":   {input, "-o", "a.pdf", "-o", "a.html", "--formats", "epub"},
+		"several to stdout":     {input, "-o", "-", "-o", "a.html"},
+		"booklet without pdf":   {input, "--booklet", "--formats", "html"},
+		"outputs of two inputs": {input, input, "-o", "a.pdf", "-o", "a.html"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := exec.Command(vevePath, append([]string{"convert"}, args...)...)
+			cmd.Dir = tmpDir
+			output, _ := cmd.CombinedOutput()
+			if code := cmd.ProcessState.ExitCode(); code != 2 {
+				t.Errorf("expected exit code 2 (usage error), got %d\n%s", code, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package contract_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHelpTopics tests that veve help renders its topics without pandoc,
+// and that an unknown topic is a usage error.
+func TestHelpTopics(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+	// Help must not need pandoc: the last PATH in env wins
+	env = append(env, "PATH="+filepath.Join(t.TempDir(), "empty"))
+	dir := t.TempDir()
+
+	tests := []struct {
+		topic string
+		want  []string
+	}{
+		{"themes", []string{"Where themes are found:", "veve theme list"}},
+		{"engines", []string{"Choosing an engine:", "Detection:"}},
+		{"images", []string{"Remote images:", "Figures:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			out, code := runVeve(t, veve, env, dir, "help", tt.topic)
+			if code != 0 {
+				t.Fatalf("veve help %s exited %d: %s", tt.topic, code, out)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("veve help %s does not show %q:\n%s", tt.topic, want, out)
+				}
+			}
+		})
+	}
+
+	out, code := runVeve(t, veve, env, dir, "help")
+	if code != 0 || !strings.Contains(out, "Additional help topics") || !strings.Contains(out, "images") {
+		t.Errorf("veve help exited %d, want 0 and a list of topics: %s", code, out)
+	}
+
+	for _, args := range [][]string{{"help", "fonts"}, {"help", "theme", "fonts"}} {
+		out, code := runVeve(t, veve, env, dir, args...)
+		if code != 2 || !strings.Contains(out, "unknown help topic") {
+			t.Errorf("veve %s exited %d, want 2 and an unknown topic error: %s", strings.Join(args, " "), code, out)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package contract_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubQPDF joins its inputs by concatenating them, which the stub pandoc's
+// output allows checking the order of.
+const stubQPDF = `#!/bin/sh
+shift 2
+files=""
+while [ "$1" != -- ]; do files="$files $1"; shift; done
+cat $files > "$2"
+`
+
+// TestMergeBatch tests that --merge joins the PDFs of several inputs, in
+// order, and is refused for a single input.
+func TestMergeBatch(t *testing.T) {
+	veve, env := stubToolchain(t, map[string]string{"qpdf": stubQPDF})
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.md": "First document.\n", "b.md": "Second document.\n"} {
+		if err := os.WriteFile(filepath.Join(dir, "docs", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, code := runVeve(t, veve, env, dir, "convert", "docs", "--output-dir", "pdf", "--merge", "all.pdf")
+	if code != 0 {
+		t.Fatalf("veve exited %d: %s", code, out)
+	}
+	joined, err := os.ReadFile(filepath.Join(dir, "all.pdf"))
+	if err != nil {
+		t.Fatalf("joined PDF not written: %v\n%s", err, out)
+	}
+	first, second := strings.Index(string(joined), "First document."), strings.Index(string(joined), "Second document.")
+	if first < 0 || second < first {
+		t.Errorf("joined PDF doesn't hold both documents in order:\n%s", joined)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pdf", "a.pdf")); err != nil {
+		t.Errorf("individual PDFs not kept: %v", err)
+	}
+
+	if out, code := runVeve(t, veve, env, dir, filepath.Join("docs", "a.md"), "--merge", "one.pdf"); code != 2 {
+		t.Errorf("--merge with one input exited %d, want 2: %s", code, out)
+	}
+}
+
+// TestMergeBook tests that veve book --merge converts each chapter on its
+// own and joins them, in place of the manifest's outputs.
+func TestMergeBook(t *testing.T) {
+	veve, env := stubToolchain(t, map[string]string{"qpdf": stubQPDF})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"intro.md":       "Intro chapter.\n",
+		"usage.md":       "Usage chapter.\n",
+		"veve.book.yaml": "title: Guide\nchapters:\n  - intro.md\n  - usage.md\noutputs:\n  - path: build/guide.pdf\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, code := runVeve(t, veve, env, dir, "book", "--merge", "joined.pdf")
+	if code != 0 {
+		t.Fatalf("veve book exited %d: %s", code, out)
+	}
+	joined, err := os.ReadFile(filepath.Join(dir, "joined.pdf"))
+	if err != nil {
+		t.Fatalf("joined PDF not written: %v\n%s", err, out)
+	}
+	if strings.Count(string(joined), "%PDF-") != 2 {
+		t.Errorf("joined PDF isn't built from one PDF per chapter:\n%s", joined)
+	}
+	intro, usage := strings.Index(string(joined), "Intro chapter."), strings.Index(string(joined), "Usage chapter.")
+	if intro < 0 || usage < intro {
+		t.Errorf("joined PDF doesn't hold the chapters in order:\n%s", joined)
+	}
+	if strings.Count(string(joined), "title: Guide") != 1 {
+		t.Errorf("book title not given to the first chapter only:\n%s", joined)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "build", "guide.pdf")); err == nil {
+		t.Error("manifest output built alongside --merge")
+	}
+}
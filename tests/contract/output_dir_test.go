@@ -0,0 +1,54 @@
+package contract_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOutputDirectory tests that --output naming a directory ("build/")
+// writes each PDF there under its input's name, and that stdin is only
+// converted into one with --stdin-filename to name the PDF after.
+func TestOutputDirectory(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+		want  []string // Files expected in build/
+		code  int
+	}{
+		{"single file", "", []string{"a.md", "-o", "build/"}, []string{"a.pdf"}, 0},
+		{"several files", "", []string{"a.md", "b.md", "-o", "build/"}, []string{"a.pdf", "b.pdf"}, 0},
+		{"stdin", "# From stdin\n", []string{"-", "-o", "build/"}, nil, 2},
+		{"stdin with a file name", "# From stdin\n", []string{"-", "--stdin-filename", "notes/piped.md", "-o", "build/"}, []string{"piped.pdf"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range []string{"a.md", "b.md"} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("# "+name+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			out, code := runVeveWithInput(t, veve, env, dir, tt.stdin, tt.args...)
+			if code != tt.code {
+				t.Fatalf("veve %s exited %d, want %d: %s", strings.Join(tt.args, " "), code, tt.code, out)
+			}
+			var got []string
+			entries, _ := os.ReadDir(filepath.Join(dir, "build"))
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("build/ holds %v, want %v", got, tt.want)
+			}
+			if tt.code != 0 && !strings.Contains(out, "--stdin-filename") {
+				t.Errorf("expected the error to suggest --stdin-filename:\n%s", out)
+			}
+		})
+	}
+}
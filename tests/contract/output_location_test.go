@@ -0,0 +1,39 @@
+package contract_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDefaultOutputBesideInput tests that without -o the PDF is written
+// beside the original input, not in the working directory or the run's
+// private workspace, and that no intermediate files are left beside it.
+func TestDefaultOutputBesideInput(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+
+	docs := t.TempDir()
+	input := filepath.Join(docs, "doc.md")
+	if err := os.WriteFile(input, []byte("# Doc\n\nBody.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cwd := t.TempDir()
+
+	out, code := runVeve(t, veve, env, cwd, input)
+	if code != 0 {
+		t.Fatalf("veve exited %d: %s", code, out)
+	}
+	if _, err := os.Stat(filepath.Join(docs, "doc.pdf")); err != nil {
+		t.Errorf("PDF not written beside the input: %v\n%s", err, out)
+	}
+	if entries, _ := os.ReadDir(cwd); len(entries) != 0 {
+		t.Errorf("working directory has %d unexpected entries", len(entries))
+	}
+	if entries, _ := os.ReadDir(docs); len(entries) != 2 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("input directory holds %v, want only doc.md and doc.pdf", names)
+	}
+}
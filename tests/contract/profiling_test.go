@@ -0,0 +1,71 @@
+package contract_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestProfilingFlags tests that --cpuprofile, --memprofile, and --trace write
+// files pprof and go tool trace can read, whether or not the command
+// succeeds, and that the flags are hidden from --help.
+func TestProfilingFlags(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+
+	tests := []struct {
+		name  string
+		input string
+		code  int
+	}{
+		{"conversion", "doc.md", 0},
+		{"failed conversion", "missing.md", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte("# Profiled\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			out, code := runVeve(t, veve, env, dir, tt.input,
+				"--cpuprofile", "cpu.pprof", "--memprofile", "mem.pprof", "--trace", "run.trace")
+			if code != tt.code {
+				t.Fatalf("veve exited %d, want %d: %s", code, tt.code, out)
+			}
+
+			// Profiles are gzipped protocol buffers; traces start with their Go version
+			for file, magic := range map[string][]byte{
+				"cpu.pprof": {0x1f, 0x8b},
+				"mem.pprof": {0x1f, 0x8b},
+				"run.trace": []byte("go 1."),
+			} {
+				data, err := os.ReadFile(filepath.Join(dir, file))
+				if err != nil {
+					t.Errorf("%s not written: %v", file, err)
+					continue
+				}
+				if !bytes.HasPrefix(data, magic) {
+					t.Errorf("%s starts with %q, want %q", file, data[:min(len(data), 8)], magic)
+				}
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte("# Profiled\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, code := runVeve(t, veve, env, dir, "doc.md", "--cpuprofile", filepath.Join(dir, "missing", "cpu.pprof"))
+	if code != 0 || !strings.Contains(out, "Failed to start CPU profile") {
+		t.Errorf("with an unwritable profile, veve exited %d, want 0 and a warning: %s", code, out)
+	}
+
+	out, _ = runVeve(t, veve, env, dir, "--help")
+	for _, flag := range []string{"--cpuprofile", "--memprofile", "--trace"} {
+		if strings.Contains(out, flag) {
+			t.Errorf("%s should be hidden from --help", flag)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package contract_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestProjectHooksOptIn tests that the hooks in a project's .veve.yaml run
+// only with --hooks or hooks set in veve.toml, and that skipping them
+// unasked is reported.
+func TestProjectHooksOptIn(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+
+	tests := []struct {
+		name   string
+		config string // veve.toml
+		args   []string
+		ran    bool
+		warned bool
+	}{
+		{"default", "", nil, false, true},
+		{"--hooks", "", []string{"--hooks"}, true, false},
+		{"veve.toml", "hooks = true\n", nil, true, false},
+		{"--no-hooks over veve.toml", "hooks = true\n", []string{"--no-hooks"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeUserConfig(t, env, tt.config)
+
+			dir := t.TempDir()
+			project := "hooks:\n  pre_convert:\n    - touch pre-ran\n  post_convert:\n    - touch post-ran\n"
+			if err := os.WriteFile(filepath.Join(dir, ".veve.yaml"), []byte(project), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte("# Hooked\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			out, code := runVeve(t, veve, env, dir, append([]string{"doc.md"}, tt.args...)...)
+			if code != 0 {
+				t.Fatalf("veve exited %d: %s", code, out)
+			}
+			for _, marker := range []string{"pre-ran", "post-ran"} {
+				_, err := os.Stat(filepath.Join(dir, marker))
+				if ran := err == nil; ran != tt.ran {
+					t.Errorf("%s hook ran = %v, want %v", strings.TrimSuffix(marker, "-ran"), ran, tt.ran)
+				}
+			}
+			if warned := strings.Contains(out, "Skipping"); warned != tt.warned {
+				t.Errorf("warned of skipped hooks = %v, want %v:\n%s", warned, tt.warned, out)
+			}
+		})
+	}
+}
+
+// TestProjectTransformersOptIn tests that command transformers in a
+// project's .veve.yaml, which run the project's code like hooks, run only
+// when hooks would.
+func TestProjectTransformersOptIn(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+
+	tests := []struct {
+		name   string
+		config string // veve.toml
+		args   []string
+		ran    bool
+	}{
+		{"default", "", nil, false},
+		{"--hooks", "", []string{"--hooks"}, true},
+		{"veve.toml", "hooks = true\n", nil, true},
+		{"--no-hooks over veve.toml", "hooks = true\n", []string{"--no-hooks"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeUserConfig(t, env, tt.config)
+
+			dir := t.TempDir()
+			project := "transformers:\n  - name: finalize\n    command: \"touch transformer-ran; sed s/Draft/Final/\"\n"
+			if err := os.WriteFile(filepath.Join(dir, ".veve.yaml"), []byte(project), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte("# Draft\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			out, code := runVeve(t, veve, env, dir, append([]string{"doc.md"}, tt.args...)...)
+			if code != 0 {
+				t.Fatalf("veve exited %d: %s", code, out)
+			}
+			_, err := os.Stat(filepath.Join(dir, "transformer-ran"))
+			if ran := err == nil; ran != tt.ran {
+				t.Errorf("transformer ran = %v, want %v", ran, tt.ran)
+			}
+			pdf, err := os.ReadFile(filepath.Join(dir, "doc.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if transformed := strings.Contains(string(pdf), "# Final"); transformed != tt.ran {
+				t.Errorf("converted content transformed = %v, want %v:\n%s", transformed, tt.ran, pdf)
+			}
+			if warned := strings.Contains(out, "Skipping transformer 'finalize'"); warned == tt.ran {
+				t.Errorf("warned of the skipped transformer = %v, want %v:\n%s", warned, !tt.ran, out)
+			}
+		})
+	}
+}
+
+// TestProjectDefaultsTrust tests that a project's .veve.yaml can't send the
+// output outside the project unless the project is trusted, as for hooks.
+func TestProjectDefaultsTrust(t *testing.T) {
+	veve, env := stubToolchain(t, nil)
+
+	tests := []struct {
+		name    string
+		config  string // veve.toml
+		args    []string
+		outside bool
+	}{
+		{"default", "", nil, false},
+		{"--hooks", "", []string{"--hooks"}, true},
+		{"veve.toml", "hooks = true\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeUserConfig(t, env, tt.config)
+
+			dir, outside := t.TempDir(), t.TempDir()
+			project := "defaults:\n  theme: default\n  output_dir: " + outside + "\n"
+			if err := os.WriteFile(filepath.Join(dir, ".veve.yaml"), []byte(project), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte("# Doc\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			out, code := runVeve(t, veve, env, dir, append([]string{"doc.md"}, tt.args...)...)
+			if code != 0 {
+				t.Fatalf("veve exited %d: %s", code, out)
+			}
+			_, err := os.Stat(filepath.Join(outside, "doc.pdf"))
+			if wrote := err == nil; wrote != tt.outside {
+				t.Errorf("wrote outside the project = %v, want %v:\n%s", wrote, tt.outside, out)
+			}
+			if warned := strings.Contains(out, "Ignoring output_dir"); warned == tt.outside {
+				t.Errorf("warned of the ignored output_dir = %v, want %v:\n%s", warned, !tt.outside, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package contract_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStdinFilename tests that stdin named with --stdin-filename is
+// converted as that file would be: its PDF is named after it and written
+// beside it, and its relative images are found in its directory.
+func TestStdinFilename(t *testing.T) {
+	// pandoc's arguments are recorded, one per line, before it converts
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "pandoc-args")
+	recording := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsFile + "'\n" + strings.TrimPrefix(stubPandoc, "#!/bin/sh\n")
+	veve, env := stubToolchain(t, map[string]string{"pandoc": recording})
+
+	if err := os.MkdirAll(filepath.Join(dir, "notes", "img"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes", "img", "chart.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := "# From stdin\n\n![Chart](img/chart.png)\n"
+	out, code := runVeveWithInput(t, veve, env, dir, input, "-", "--stdin-filename", "notes/piped.md")
+	if code != 0 {
+		t.Fatalf("veve exited %d: %s", code, out)
+	}
+
+	pdf, err := os.ReadFile(filepath.Join(dir, "notes", "piped.pdf"))
+	if err != nil {
+		t.Fatalf("expected notes/piped.pdf, named after --stdin-filename: %v", err)
+	}
+	if !strings.Contains(string(pdf), "# From stdin") {
+		t.Errorf("notes/piped.pdf was not converted from stdin:\n%s", pdf)
+	}
+	for _, stray := range []string{"-.pdf", "stdin.pdf", "piped.pdf"} {
+		if _, err := os.Stat(filepath.Join(dir, stray)); err == nil {
+			t.Errorf("unexpected %s in the working directory", stray)
+		}
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(args), "\n")
+	var resourcePath string
+	for i, arg := range lines {
+		if arg == "--resource-path" && i+1 < len(lines) {
+			resourcePath = lines[i+1]
+		}
+	}
+	found := false
+	for _, resourceDir := range filepath.SplitList(resourcePath) {
+		if !filepath.IsAbs(resourceDir) {
+			resourceDir = filepath.Join(dir, resourceDir)
+		}
+		if _, err := os.Stat(filepath.Join(resourceDir, "img", "chart.png")); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("img/chart.png is not found on pandoc's resource path %q", resourcePath)
+	}
+}
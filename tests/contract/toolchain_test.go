@@ -0,0 +1,142 @@
+package contract_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubPandoc stands in for pandoc: it writes a PDF header followed by the
+// markdown it was given to the output path, so tests can check where output
+// lands and what it was built from without a TeX or WeasyPrint install.
+const stubPandoc = `#!/bin/sh
+out=""; input=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --version) echo "pandoc 3.1.11"; exit 0;;
+    -o|--output) out="$2"; shift;;
+    --output=*) out="${1#--output=}";;
+    -f|--from|-t|--to|--pdf-engine|--css|-c|--template|-V|--resource-path|--metadata-file|--include-in-header|--include-after-body|--reference-location) shift;;
+    -*) ;;
+    *) [ -z "$input" ] && input="$1";;
+  esac
+  shift
+done
+if [ -z "$input" ]; then input=-; fi
+if [ -z "$out" ] || [ "$out" = "-" ]; then printf '%%PDF-1.4\n'; cat "$input"; exit 0; fi
+{ printf '%%PDF-1.4\n'; cat "$input"; } > "$out"
+`
+
+// stubWeasyPrint lets engine detection find WeasyPrint.
+const stubWeasyPrint = `#!/bin/sh
+echo "WeasyPrint version 61.0"
+`
+
+var (
+	builtVeve     string
+	builtVeveErr  error
+	buildVeveOnce sync.Once
+	builtVeveDir  string
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if builtVeveDir != "" {
+		os.RemoveAll(builtVeveDir)
+	}
+	os.Exit(code)
+}
+
+// stubToolchain builds veve from this tree and returns it with an
+// environment whose PATH finds stub pandoc and weasyprint scripts first, and
+// whose config and cache directories are private to the test. extra names
+// further stub scripts to install, by name.
+func stubToolchain(t *testing.T, extra map[string]string) (string, []string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("stub toolchain uses sh scripts")
+	}
+
+	buildVeveOnce.Do(func() {
+		builtVeveDir, builtVeveErr = os.MkdirTemp("", "veve-contract-")
+		if builtVeveErr != nil {
+			return
+		}
+		builtVeve = filepath.Join(builtVeveDir, "veve")
+		out, err := exec.Command("go", "build", "-o", builtVeve, "../../cmd/veve").CombinedOutput()
+		if err != nil {
+			builtVeveErr = fmt.Errorf("%v: %s", err, out)
+		}
+	})
+	if builtVeveErr != nil {
+		t.Fatalf("failed to build veve: %v", builtVeveErr)
+	}
+
+	bin := t.TempDir()
+	scripts := map[string]string{"pandoc": stubPandoc, "weasyprint": stubWeasyPrint}
+	for name, script := range extra {
+		scripts[name] = script
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	home := t.TempDir()
+	env := append(os.Environ(),
+		"PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"HOME="+home,
+		"XDG_CONFIG_HOME="+filepath.Join(home, "config"),
+		"XDG_CACHE_HOME="+filepath.Join(home, "cache"),
+		"XDG_DATA_HOME="+filepath.Join(home, "data"),
+	)
+	return builtVeve, env
+}
+
+// writeUserConfig writes content to the veve.toml of env's config directory.
+func writeUserConfig(t *testing.T, env []string, content string) {
+	t.Helper()
+	var configHome string
+	for _, kv := range env {
+		if value, ok := strings.CutPrefix(kv, "XDG_CONFIG_HOME="); ok {
+			configHome = value
+		}
+	}
+	configFile := filepath.Join(configHome, "veve", "veve.toml")
+	if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runVeve runs veve in dir with env, returning its combined output and exit
+// code.
+func runVeve(t *testing.T, veve string, env []string, dir string, args ...string) (string, int) {
+	t.Helper()
+	return runVeveWithInput(t, veve, env, dir, "", args...)
+}
+
+// runVeveWithInput runs veve as runVeve does, with stdin reading input.
+func runVeveWithInput(t *testing.T, veve string, env []string, dir, input string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(veve, args...)
+	cmd.Env = env
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return string(out), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("failed to run veve: %v", err)
+	}
+	return string(out), 0
+}
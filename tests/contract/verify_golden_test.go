@@ -0,0 +1,63 @@
+package contract_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubPdftotext extracts the "text" of a stub pandoc PDF: the markdown
+// after its header line. The PDF is the argument before "-", for stdout.
+const stubPdftotext = `#!/bin/sh
+for arg; do [ "$arg" = - ] && break; pdf="$arg"; done
+tail -n +2 "$pdf"
+`
+
+// TestVerifyGolden tests veve verify's exit codes: 0 when the output matches
+// the golden PDF or is within tolerance, 1 when it differs by more, and 3
+// when the golden PDF is missing.
+func TestVerifyGolden(t *testing.T) {
+	veve, env := stubToolchain(t, map[string]string{"pdftotext": stubPdftotext})
+	dir := t.TempDir()
+	input := filepath.Join(dir, "report.md")
+	golden := filepath.Join(dir, "testdata", "report.pdf")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("# Report\n\nAll figures are final.\n")
+	out, code := runVeve(t, veve, env, dir, "verify", "report.md", "--golden", golden)
+	if code != 3 || !strings.Contains(out, "--update") {
+		t.Errorf("with no golden PDF, exited %d, want 3 and a hint to --update: %s", code, out)
+	}
+
+	if out, code := runVeve(t, veve, env, dir, "verify", "report.md", "--golden", golden, "--update"); code != 0 {
+		t.Fatalf("--update exited %d: %s", code, out)
+	}
+	if _, err := os.Stat(golden); err != nil {
+		t.Fatalf("--update did not write the golden PDF: %v", err)
+	}
+
+	out, code = runVeve(t, veve, env, dir, "verify", "report.md", "--golden", golden)
+	if code != 0 || !strings.Contains(out, "matches") {
+		t.Errorf("matching output exited %d, want 0: %s", code, out)
+	}
+
+	write("# Report\n\nSome figures are provisional.\n")
+	out, code = runVeve(t, veve, env, dir, "verify", "report.md", "--golden", golden)
+	if code != 1 || !strings.Contains(out, "differ") {
+		t.Errorf("changed output exited %d, want 1: %s", code, out)
+	}
+	if !strings.Contains(out, "-All figures are final.") || !strings.Contains(out, "+Some figures are provisional.") {
+		t.Errorf("expected the changed line in the diff:\n%s", out)
+	}
+
+	out, code = runVeve(t, veve, env, dir, "verify", "report.md", "--golden", golden, "--max-changed-lines", "2")
+	if code != 0 || !strings.Contains(out, "within tolerance") {
+		t.Errorf("output within tolerance exited %d, want 0: %s", code, out)
+	}
+}
@@ -0,0 +1,70 @@
+package assets_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/assets"
+)
+
+// TestVerify tests checking content against a pinned checksum.
+func TestVerify(t *testing.T) {
+	data := []byte("body { color: navy; }")
+	sum := assets.Checksum(data)
+
+	if err := assets.Verify("https://example.com/a.css", data, sum); err != nil {
+		t.Errorf("Verify with the right checksum failed: %v", err)
+	}
+	if err := assets.Verify("https://example.com/a.css", data, " "+strings.ToUpper(sum)+"\n"); err != nil {
+		t.Errorf("Verify should ignore case and spaces: %v", err)
+	}
+
+	var mismatch *assets.ChecksumError
+	err := assets.Verify("https://example.com/a.css", data, strings.Repeat("0", 64))
+	if !errors.As(err, &mismatch) || mismatch.Got != sum {
+		t.Fatalf("Verify error = %v, want a ChecksumError with the actual checksum", err)
+	}
+
+	err = assets.Verify("https://example.com/a.css", data, "")
+	if err == nil || !strings.Contains(err.Error(), "sha256: "+sum) {
+		t.Errorf("Verify without a checksum = %v, want the checksum to pin", err)
+	}
+}
+
+// TestFetcherCache tests that verified downloads are reused by checksum.
+func TestFetcherCache(t *testing.T) {
+	data := []byte("#!/bin/sh\ncat\n")
+	sum := assets.Checksum(data)
+	downloads := 0
+	fetcher := &assets.Fetcher{
+		Download: func(url string) ([]byte, error) {
+			downloads++
+			return data, nil
+		},
+		CacheDir: t.TempDir(),
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := fetcher.Fetch("https://example.com/filter.sh", sum)
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		if string(got) != string(data) {
+			t.Errorf("Fetch = %q, want %q", got, data)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("downloaded %d times, want 1", downloads)
+	}
+
+	// A bad download is neither returned nor cached
+	fetcher.Download = func(url string) ([]byte, error) { return []byte("tampered"), nil }
+	if _, err := fetcher.Fetch("https://example.com/other.sh", strings.Repeat("1", 64)); err == nil {
+		t.Error("expected a checksum error for tampered content")
+	}
+	fetcher.Download = func(url string) ([]byte, error) { return nil, errors.New("offline") }
+	if _, err := fetcher.Fetch("https://example.com/other.sh", strings.Repeat("1", 64)); err == nil || err.Error() != "offline" {
+		t.Errorf("Fetch error = %v, want the download error", err)
+	}
+}
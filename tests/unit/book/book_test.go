@@ -0,0 +1,143 @@
+package book_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/book"
+)
+
+// writeFiles creates files under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"intro.md":   "# Intro\n",
+		"api/ref.md": "# Reference\n",
+		book.ManifestFile: "title: Handbook\n" +
+			"chapters:\n  - intro.md\n  - file: api/ref.md\n    theme: technical\n" +
+			"outputs:\n  - path: build/handbook.pdf\n  - path: build/handbook.epub\n",
+	})
+
+	m, err := book.Load(filepath.Join(dir, book.ManifestFile))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(m.Chapters) != 2 || m.Chapters[0].File != "intro.md" || m.Chapters[1].Theme != "technical" {
+		t.Errorf("unexpected chapters: %+v", m.Chapters)
+	}
+	if len(m.Outputs) != 2 || m.Outputs[0].Format != book.FormatPDF || m.Outputs[1].Format != book.FormatEPUB {
+		t.Errorf("unexpected outputs: %+v", m.Outputs)
+	}
+	if want := filepath.Join(dir, "build", "handbook.pdf"); m.Outputs[0].Path != want {
+		t.Errorf("output path = %q, want %q", m.Outputs[0].Path, want)
+	}
+	if got := m.ChapterThemes(); len(got) != 1 || got[0] != "technical" {
+		t.Errorf("ChapterThemes() = %v", got)
+	}
+}
+
+func TestLoadManifestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{"no chapters", "title: Empty\n", "no chapters"},
+		{"missing chapter", "chapters:\n  - missing.md\n", "chapter 1"},
+		{"unknown field", "chapters:\n  - intro.md\nchapter_themes: {}\n", "chapter_themes"},
+		{"unknown format", "chapters:\n  - intro.md\noutputs:\n  - path: book.docx\n", "unsupported format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"intro.md": "# Intro\n", book.ManifestFile: tt.manifest})
+			_, err := book.Load(filepath.Join(dir, book.ManifestFile))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"intro.md":   "---\ntitle: Introduction\nauthor: Someone\n---\nWelcome.\n",
+		"api/ref.md": "# Reference\n\n![diagram](img/flow.png)\n",
+		book.ManifestFile: "title: Handbook\nmetadata:\n  author: Docs Team\n" +
+			"chapters:\n  - intro.md\n  - file: api/ref.md\n    theme: Technical.css\n",
+	})
+
+	m, err := book.Load(filepath.Join(dir, book.ManifestFile))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	got, err := m.Assemble(nil)
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+
+	image := filepath.ToSlash(filepath.Join(dir, "api", "img", "flow.png"))
+	for _, want := range []string{
+		"---\nauthor: Docs Team\ntitle: Handbook\n---\n",
+		"# Introduction\n\nWelcome.\n",
+		"<!-- pagebreak -->\n\n::: {.theme-technical}\n\n# Reference\n",
+		"![diagram](" + image + ")",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("assembled book missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Someone") {
+		t.Errorf("chapter front matter kept:\n%s", got)
+	}
+}
+
+// TestAssembleChapters tests that each chapter is assembled on its own, with
+// the shared metadata, and the book's title only on the first.
+func TestAssembleChapters(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"intro.md": "---\ntitle: Introduction\n---\nWelcome.\n",
+		"ref.md":   "# Reference\n",
+		book.ManifestFile: "title: Handbook\nmetadata:\n  author: Docs Team\n" +
+			"chapters:\n  - intro.md\n  - file: ref.md\n    theme: technical\n",
+	})
+
+	m, err := book.Load(filepath.Join(dir, book.ManifestFile))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	chapters, err := m.AssembleChapters(nil)
+	if err != nil {
+		t.Fatalf("AssembleChapters failed: %v", err)
+	}
+	want := []string{
+		"---\nauthor: Docs Team\ntitle: Handbook\n---\n\n# Introduction\n\nWelcome.\n\n",
+		"---\nauthor: Docs Team\n---\n\n::: {.theme-technical}\n\n# Reference\n\n:::\n\n",
+	}
+	if len(chapters) != len(want) {
+		t.Fatalf("got %d chapters, want %d", len(chapters), len(want))
+	}
+	for i := range want {
+		if chapters[i] != want[i] {
+			t.Errorf("chapter %d = %q, want %q", i+1, chapters[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package changelog_test
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/changelog"
+)
+
+func TestCategorize(t *testing.T) {
+	commits := []changelog.Commit{
+		{Hash: "1111111aaaa", Subject: "feat(cli): add book command"},
+		{Hash: "2222222bbbb", Subject: "fix: handle empty input"},
+		{Hash: "3333333cccc", Subject: "refactor!: rename flags"},
+		{Hash: "4444444dddd", Subject: "feat: new config", Body: "BREAKING CHANGE: config moved"},
+		{Hash: "5555555eeee", Subject: "chore: bump deps"},
+		{Hash: "6666666ffff", Subject: "Update README"},
+		{Hash: "7777777aaaa", Subject: "refactor: tidy"},
+	}
+
+	want := []changelog.Group{
+		{Title: "Breaking Changes", Entries: []string{"rename flags (3333333)", "new config (4444444)"}},
+		{Title: "Features", Entries: []string{"**cli:** add book command (1111111)"}},
+		{Title: "Bug Fixes", Entries: []string{"handle empty input (2222222)"}},
+		{Title: "Other Changes", Entries: []string{"Update README (6666666)", "tidy (7777777)"}},
+	}
+	if got := changelog.Categorize(commits); !reflect.DeepEqual(got, want) {
+		t.Errorf("Categorize() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSections(t *testing.T) {
+	content := "# Changelog\n\n## [Unreleased]\n\n- next\n\n" +
+		"## [1.2.0] - 2025-12-01\n\n### Added\n- books\n\n" +
+		"## v1.1.0 (November 2025)\n\n- wiki links\n\n```\n## 1.0.0 in code\n```\n\n" +
+		"## [1.0.0]\n\n- first\n"
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{"unreleased", "", "Unreleased", "## [Unreleased]\n\n- next\n"},
+		{"single version", "", "v1.2.0", "## [1.2.0] - 2025-12-01\n\n### Added\n- books\n"},
+		{"range", "1.0.0", "1.2.0", "## [1.2.0] - 2025-12-01\n\n### Added\n- books\n\n## v1.1.0 (November 2025)\n\n- wiki links\n\n```\n## 1.0.0 in code\n```\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := changelog.Sections(content, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Sections failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Sections(%q, %q) =\n%q\nwant\n%q", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := changelog.Sections(content, "", "2.0.0"); err == nil {
+		t.Error("expected an error for a missing version")
+	}
+}
+
+func TestNotesMarkdown(t *testing.T) {
+	notes := changelog.FromCommits(`Release "Notes"`, "2025-12-01", []changelog.Group{{Title: "Features", Entries: []string{"books"}}})
+	want := "---\ntitle: \"Release \\\"Notes\\\"\"\ndate: \"2025-12-01\"\n---\n\n## Features\n\n- books\n"
+	if got := notes.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(cmd.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com",
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	run("commit", "-q", "--allow-empty", "-m", "feat: first")
+	run("tag", "v1.0")
+	run("commit", "-q", "--allow-empty", "-m", "fix: second\n\nDetails here.")
+	run("commit", "-q", "--allow-empty", "-m", "feat: third")
+	run("tag", "v1.1")
+
+	if got := changelog.PreviousTag(dir, "v1.1"); got != "v1.0" {
+		t.Errorf("PreviousTag() = %q, want v1.0", got)
+	}
+	commits, err := changelog.Log(dir, "v1.0", "v1.1")
+	if err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	var subjects []string
+	for _, commit := range commits {
+		subjects = append(subjects, commit.Subject)
+	}
+	if want := []string{"feat: third", "fix: second"}; !reflect.DeepEqual(subjects, want) {
+		t.Errorf("subjects = %v, want %v", subjects, want)
+	}
+	if commits[1].Body != "Details here." || len(commits[1].Hash) != 40 {
+		t.Errorf("unexpected commit: %+v", commits[1])
+	}
+	if _, err := changelog.Log(dir, "v9", "v1.1"); err == nil || !strings.Contains(err.Error(), "git log") {
+		t.Errorf("expected a git log error, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package completion_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/completion"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"report.md", "notes.MARKDOWN", "lab.ipynb", "data.csv", "reply.pdf", ".draft.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"chapters", "refs", ".git"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sep := string(filepath.Separator)
+	prefix := dir + sep
+
+	tests := []struct {
+		name       string
+		toComplete string
+		want       []string
+	}{
+		{"all", prefix, []string{"chapters" + sep, "lab.ipynb", "notes.MARKDOWN", "refs" + sep, "report.md"}},
+		{"by prefix", prefix + "re", []string{"refs" + sep, "report.md"}},
+		{"hidden when asked", prefix + ".", []string{".draft.md", ".git" + sep}},
+		{"no match", prefix + "x", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, prefix+name)
+			}
+			if got := completion.Files(tt.toComplete, completion.MarkdownExtensions); !slices.Equal(got, want) {
+				t.Errorf("Files(%q) = %v, want %v", tt.toComplete, got, want)
+			}
+		})
+	}
+
+	if got := completion.Files(prefix+"rep", []string{".pdf"}); !slices.Equal(got, []string{prefix + "reply.pdf"}) {
+		t.Errorf("Files with .pdf = %v", got)
+	}
+	if got := completion.Files(filepath.Join(dir, "missing")+sep, completion.MarkdownExtensions); got != nil {
+		t.Errorf("expected no completions in a missing directory, got %v", got)
+	}
+}
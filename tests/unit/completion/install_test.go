@@ -0,0 +1,119 @@
+package completion_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/completion"
+)
+
+// environment returns an Environment with home and the variables in vars.
+func environment(home, goos string, vars map[string]string) completion.Environment {
+	return completion.Environment{
+		Home:   home,
+		GOOS:   goos,
+		Getenv: func(name string) string { return vars[name] },
+	}
+}
+
+func TestDetectShell(t *testing.T) {
+	tests := []struct {
+		shell string
+		goos  string
+		want  string
+	}{
+		{"/bin/bash", "linux", "bash"},
+		{"/usr/local/bin/zsh", "darwin", "zsh"},
+		{"/usr/bin/fish", "linux", "fish"},
+		{"/usr/bin/pwsh", "linux", "powershell"},
+		{"", "windows", "powershell"},
+	}
+	for _, tt := range tests {
+		got, err := completion.DetectShell(environment("/home/u", tt.goos, map[string]string{"SHELL": tt.shell}))
+		if err != nil || got != tt.want {
+			t.Errorf("DetectShell(%q on %s) = %q, %v; want %q", tt.shell, tt.goos, got, err, tt.want)
+		}
+	}
+
+	for _, shell := range []string{"", "/bin/tcsh"} {
+		if _, err := completion.DetectShell(environment("/home/u", "linux", map[string]string{"SHELL": shell})); err == nil {
+			t.Errorf("expected an error for SHELL=%q", shell)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	home := filepath.FromSlash("/home/u")
+	tests := []struct {
+		shell   string
+		goos    string
+		vars    map[string]string
+		script  string
+		profile string
+	}{
+		{"bash", "linux", nil, "/home/u/.local/share/bash-completion/completions/veve", "/home/u/.bashrc"},
+		{"bash", "darwin", map[string]string{"XDG_DATA_HOME": "/data"}, "/data/bash-completion/completions/veve", "/home/u/.bash_profile"},
+		{"bash", "linux", map[string]string{"BASH_COMPLETION_USER_DIR": "/bc"}, "/bc/completions/veve", "/home/u/.bashrc"},
+		{"zsh", "linux", nil, "/home/u/.zsh/completions/_veve", "/home/u/.zshrc"},
+		{"zsh", "linux", map[string]string{"ZDOTDIR": "/z"}, "/z/.zsh/completions/_veve", "/z/.zshrc"},
+		{"fish", "linux", map[string]string{"XDG_CONFIG_HOME": "/cfg"}, "/cfg/fish/completions/veve.fish", ""},
+		{"powershell", "linux", nil, "/home/u/.config/powershell/veve.ps1", "/home/u/.config/powershell/Microsoft.PowerShell_profile.ps1"},
+		{"powershell", "windows", nil, "/home/u/Documents/PowerShell/veve.ps1", "/home/u/Documents/PowerShell/Microsoft.PowerShell_profile.ps1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell+"/"+tt.goos, func(t *testing.T) {
+			location, err := completion.Locate(tt.shell, environment(home, tt.goos, tt.vars))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if location.Script != filepath.FromSlash(tt.script) {
+				t.Errorf("Script = %q, want %q", location.Script, tt.script)
+			}
+			if location.Profile != filepath.FromSlash(tt.profile) {
+				t.Errorf("Profile = %q, want %q", location.Profile, tt.profile)
+			}
+			if location.Profile != "" && !strings.Contains(strings.Join(location.Lines, "\n"), filepath.Dir(location.Script)) {
+				t.Errorf("Lines %q don't load %s", location.Lines, location.Script)
+			}
+		})
+	}
+
+	if _, err := completion.Locate("tcsh", environment(home, "linux", nil)); err == nil {
+		t.Error("expected an error for an unknown shell")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	home := t.TempDir()
+	location, err := completion.Locate("bash", environment(home, "linux", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(location.Profile, []byte("export EDITOR=vi"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := completion.Install(location, []byte("# old script\n"))
+	if err != nil || !changed {
+		t.Fatalf("Install() = %v, %v; want the profile changed", changed, err)
+	}
+	changed, err = completion.Install(location, []byte("# new script\n"))
+	if err != nil || changed {
+		t.Fatalf("second Install() = %v, %v; want the profile left alone", changed, err)
+	}
+
+	script, err := os.ReadFile(location.Script)
+	if err != nil || string(script) != "# new script\n" {
+		t.Errorf("script = %q, %v; want the new script", script, err)
+	}
+	profile, err := os.ReadFile(location.Profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export EDITOR=vi\n\n# veve shell completion\n" + location.Lines[0] + "\n"
+	if string(profile) != want {
+		t.Errorf("profile = %q, want %q", profile, want)
+	}
+}
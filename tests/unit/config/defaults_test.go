@@ -0,0 +1,169 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+)
+
+// TestLoadDefaults tests that a project's defaults take precedence over the
+// user's, field by field, with its directories relative to the project.
+func TestLoadDefaults(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "veve.toml")
+	user := `
+[defaults]
+theme = "dark"
+pdf_engine = "xelatex"
+
+[defaults.remote_images]
+enabled = false
+timeout = 30
+`
+	if err := os.WriteFile(configFile, []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	projectRoot := filepath.Join(dir, "project")
+	docs := filepath.Join(projectRoot, "docs")
+	if err := os.MkdirAll(docs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	project := "defaults:\n  theme: academic\n  output_dir: pdf\n  remote_images:\n    enabled: true\n"
+	if err := os.WriteFile(filepath.Join(projectRoot, config.ProjectConfigFile), []byte(project), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	defaults, _, err := config.LoadDefaults(configFile, docs, true)
+	if err != nil {
+		t.Fatalf("LoadDefaults failed: %v", err)
+	}
+	if defaults.Theme != "academic" || defaults.PDFEngine != "xelatex" {
+		t.Errorf("theme, engine = %q, %q; want academic, xelatex", defaults.Theme, defaults.PDFEngine)
+	}
+	if want := filepath.Join(projectRoot, "pdf"); defaults.OutputDir != want {
+		t.Errorf("OutputDir = %q, want %q", defaults.OutputDir, want)
+	}
+	images := defaults.RemoteImages
+	if images.Enabled == nil || !*images.Enabled {
+		t.Error("expected the project to enable remote images")
+	}
+	if images.Timeout == nil || *images.Timeout != 30 {
+		t.Errorf("Timeout = %v, want the user's 30", images.Timeout)
+	}
+	if images.MaxRetries != nil {
+		t.Errorf("MaxRetries = %d, want unset", *images.MaxRetries)
+	}
+
+	// Outside the project, only the user's defaults apply
+	defaults, _, err = config.LoadDefaults(configFile, dir, true)
+	if err != nil {
+		t.Fatalf("LoadDefaults failed: %v", err)
+	}
+	if defaults.Theme != "dark" || defaults.OutputDir != "" {
+		t.Errorf("theme, output dir = %q, %q; want dark and none", defaults.Theme, defaults.OutputDir)
+	}
+}
+
+// TestLoadDefaultsUntrusted tests that an untrusted project may set only the
+// safe defaults, and that the others it sets are reported.
+func TestLoadDefaultsUntrusted(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "veve.toml")
+	user := "[defaults]\npdf_engine = \"weasyprint\"\nsign_key = \"mine.pem\"\n"
+	if err := os.WriteFile(configFile, []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	projectRoot := filepath.Join(dir, "project")
+	if err := os.MkdirAll(projectRoot, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	project := `defaults:
+  theme: academic
+  pdf_engine: docker:evil/image
+  sign_key: /tmp/their.pem
+  output_dir: ../outside
+  remote_images:
+    enabled: true
+    temp_dir: /tmp/images
+    max_count: 3
+`
+	if err := os.WriteFile(filepath.Join(projectRoot, config.ProjectConfigFile), []byte(project), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	defaults, ignored, err := config.LoadDefaults(configFile, projectRoot, false)
+	if err != nil {
+		t.Fatalf("LoadDefaults failed: %v", err)
+	}
+	if defaults.Theme != "academic" {
+		t.Errorf("Theme = %q, want the project's academic", defaults.Theme)
+	}
+	if defaults.PDFEngine != "weasyprint" || defaults.SignKey != "mine.pem" {
+		t.Errorf("engine, sign key = %q, %q; want the user's", defaults.PDFEngine, defaults.SignKey)
+	}
+	if defaults.OutputDir != "" || defaults.RemoteImages.Enabled != nil || defaults.RemoteImages.TempDir != "" {
+		t.Errorf("Defaults = %+v, want no output dir, remote images or temp dir", defaults)
+	}
+	if got := defaults.RemoteImages.MaxCount; got == nil || *got != 3 {
+		t.Errorf("MaxCount = %v, want the project's 3", got)
+	}
+	want := []string{"pdf_engine", "sign_key", "output_dir", "remote_images.enabled", "remote_images.temp_dir"}
+	if !reflect.DeepEqual(ignored, want) {
+		t.Errorf("ignored = %v, want %v", ignored, want)
+	}
+
+	// An output directory inside the project is safe
+	project = "defaults:\n  output_dir: build/pdf\n"
+	if err := os.WriteFile(filepath.Join(projectRoot, config.ProjectConfigFile), []byte(project), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defaults, ignored, err = config.LoadDefaults(configFile, projectRoot, false)
+	if err != nil {
+		t.Fatalf("LoadDefaults failed: %v", err)
+	}
+	if want := filepath.Join(projectRoot, "build", "pdf"); defaults.OutputDir != want || len(ignored) > 0 {
+		t.Errorf("OutputDir = %q, ignored %v; want %q and nothing ignored", defaults.OutputDir, ignored, want)
+	}
+}
+
+// TestDefaultsValidate tests that out-of-range defaults are rejected.
+func TestDefaultsValidate(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "veve.toml")
+	for _, content := range []string{
+		"[defaults.remote_images]\ntimeout = 0\n",
+		"[defaults.remote_images]\nmax_retries = -1\n",
+		"[defaults.remote_images]\nmax_count = -5\n",
+	} {
+		if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.LoadConfig(configFile); err == nil {
+			t.Errorf("LoadConfig(%q) succeeded, want an error", content)
+		}
+	}
+}
+
+// TestSaveConfigDefaults tests that saved defaults load back as they were.
+func TestSaveConfigDefaults(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "veve.toml")
+	cfg := config.DefaultConfig()
+	retries := 5
+	cfg.Defaults = config.Defaults{Theme: "dark", RemoteImages: config.RemoteImageDefaults{MaxRetries: &retries}}
+	if err := config.SaveConfig(configFile, cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	loaded, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if loaded.Defaults.Theme != "dark" || loaded.Defaults.PDFEngine != "" {
+		t.Errorf("Defaults = %+v, want only the theme set", loaded.Defaults)
+	}
+	if got := loaded.Defaults.RemoteImages.MaxRetries; got == nil || *got != 5 {
+		t.Errorf("MaxRetries = %v, want 5", got)
+	}
+}
@@ -0,0 +1,135 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+)
+
+// TestLoadConfigMissingFile tests that a missing veve.toml gives the defaults.
+func TestLoadConfigMissingFile(t *testing.T) {
+	cfg, err := config.LoadConfig(filepath.Join(t.TempDir(), "veve.toml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, config.DefaultConfig()) {
+		t.Errorf("LoadConfig() = %+v, want defaults", cfg)
+	}
+}
+
+// TestLoadConfigMetrics tests that metrics are opted in to from veve.toml.
+func TestLoadConfigMetrics(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "veve.toml")
+	if err := os.WriteFile(configFile, []byte("metrics = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !cfg.Metrics {
+		t.Error("expected metrics to be enabled")
+	}
+	if cfg.DefaultTheme != "default" {
+		t.Errorf("DefaultTheme = %q, want the default", cfg.DefaultTheme)
+	}
+}
+
+// TestLoadConfigThemeSources tests theme credentials, with secrets from the environment.
+func TestLoadConfigThemeSources(t *testing.T) {
+	t.Setenv("TEST_THEMES_TOKEN", "s3cret")
+	configFile := filepath.Join(t.TempDir(), "veve.toml")
+	content := `
+[[theme_sources]]
+host = "themes.example.com"
+token = "$TEST_THEMES_TOKEN"
+
+[[theme_sources]]
+host = "git.example.com"
+username = "ci"
+password = "plain"
+`
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	want := []config.ThemeSource{
+		{Host: "themes.example.com", Token: "s3cret"},
+		{Host: "git.example.com", Username: "ci", Password: "plain"},
+	}
+	if !reflect.DeepEqual(cfg.ThemeSources, want) {
+		t.Errorf("ThemeSources = %+v, want %+v", cfg.ThemeSources, want)
+	}
+}
+
+// TestLoadConfigThemeSourceWithoutHost tests that every theme source names its host.
+func TestLoadConfigThemeSourceWithoutHost(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "veve.toml")
+	if err := os.WriteFile(configFile, []byte("[[theme_sources]]\ntoken = \"x\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.LoadConfig(configFile); err == nil {
+		t.Error("expected an error for a theme source without a host")
+	}
+}
+
+// TestParseAlias tests splitting aliases into arguments.
+func TestParseAlias(t *testing.T) {
+	tests := map[string][]string{
+		"--theme academic -N":            {"--theme", "academic", "-N"},
+		"  book   --engine weasyprint ":  {"book", "--engine", "weasyprint"},
+		`--theme 'company/brand guide'`:  {"--theme", "company/brand guide"},
+		`--engine-arg "-V x=\"y z\""`:    {"--engine-arg", `-V x="y z"`},
+		`--output a\ b.pdf --title ''`:   {"--output", "a b.pdf", "--title", ""},
+		`--engine-arg 'C:\TeX\bin' -t x`: {"--engine-arg", `C:\TeX\bin`, "-t", "x"},
+	}
+	for value, want := range tests {
+		got, err := config.ParseAlias(value)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseAlias(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{`--theme "dark`, `--theme dark\`} {
+		if _, err := config.ParseAlias(value); err == nil {
+			t.Errorf("ParseAlias(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+// TestLoadConfigAliases tests reading aliases and rejecting broken ones.
+func TestLoadConfigAliases(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "veve.toml")
+	content := "[aliases]\nreport = \"--theme academic -N\"\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.Aliases["report"]; got != "--theme academic -N" {
+		t.Errorf("report alias = %q", got)
+	}
+
+	for _, content := range []string{
+		"[aliases]\nreport = \"\"\n",
+		"[aliases]\nreport = \"--theme 'dark\"\n",
+		"[aliases]\n\"-r\" = \"--theme dark\"\n",
+	} {
+		if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.LoadConfig(configFile); err == nil {
+			t.Errorf("LoadConfig(%q) succeeded, want an error", content)
+		}
+	}
+}
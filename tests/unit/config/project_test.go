@@ -0,0 +1,208 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+)
+
+// TestFindProjectDirectory tests that a .veve directory marks the project root.
+func TestFindProjectDirectory(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".veve", "themes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(root, "docs", "guides")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.FindProject(nested)
+	if err != nil {
+		t.Fatalf("FindProject failed: %v", err)
+	}
+	if project == nil {
+		t.Fatal("expected project to be found")
+	}
+
+	want := filepath.Join(root, ".veve", "themes")
+	if got := project.ThemesPath(); got != want {
+		t.Errorf("ThemesPath() = %q, want %q", got, want)
+	}
+}
+
+// TestFindProjectConfigFile tests that themes_dir in .veve.yaml is resolved against the project root.
+func TestFindProjectConfigFile(t *testing.T) {
+	root := t.TempDir()
+	configFile := filepath.Join(root, config.ProjectConfigFile)
+	if err := os.WriteFile(configFile, []byte("themes_dir: design/themes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.FindProject(root)
+	if err != nil {
+		t.Fatalf("FindProject failed: %v", err)
+	}
+	if project == nil {
+		t.Fatal("expected project to be found")
+	}
+	if project.ConfigFile != configFile {
+		t.Errorf("ConfigFile = %q, want %q", project.ConfigFile, configFile)
+	}
+
+	want := filepath.Join(root, "design", "themes")
+	if got := project.ThemesPath(); got != want {
+		t.Errorf("ThemesPath() = %q, want %q", got, want)
+	}
+}
+
+// TestProjectLintConfig tests that lint.disable is read from .veve.yaml.
+func TestProjectLintConfig(t *testing.T) {
+	root := t.TempDir()
+	configFile := filepath.Join(root, config.ProjectConfigFile)
+	if err := os.WriteFile(configFile, []byte("lint:\n  disable: [bare-urls, trailing-spaces]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.LoadProjectConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	want := []string{"bare-urls", "trailing-spaces"}
+	if !reflect.DeepEqual(project.Lint.Disable, want) {
+		t.Errorf("Lint.Disable = %v, want %v", project.Lint.Disable, want)
+	}
+}
+
+// TestProjectHooksConfig tests that hooks are read from .veve.yaml.
+func TestProjectHooksConfig(t *testing.T) {
+	root := t.TempDir()
+	configFile := filepath.Join(root, config.ProjectConfigFile)
+	content := "hooks:\n  pre_convert:\n    - vale \"$VEVE_INPUT\"\n  post_convert:\n    - exiftool -Title=Docs \"$VEVE_OUTPUT\"\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.LoadProjectConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	if want := []string{`vale "$VEVE_INPUT"`}; !reflect.DeepEqual(project.Hooks.PreConvert, want) {
+		t.Errorf("Hooks.PreConvert = %v, want %v", project.Hooks.PreConvert, want)
+	}
+	if want := []string{`exiftool -Title=Docs "$VEVE_OUTPUT"`}; !reflect.DeepEqual(project.Hooks.PostConvert, want) {
+		t.Errorf("Hooks.PostConvert = %v, want %v", project.Hooks.PostConvert, want)
+	}
+}
+
+// TestProjectPinnedAssets tests reading themes and filters pinned by checksum.
+func TestProjectPinnedAssets(t *testing.T) {
+	root := t.TempDir()
+	configFile := filepath.Join(root, config.ProjectConfigFile)
+	content := `themes:
+  - name: brand
+    url: https://example.com/themes.zip
+    sha256: abc123
+    file: brand/brand.css
+filters:
+  - path: scripts/toc.sh
+    url: https://example.com/toc.sh
+    sha256: def456
+    executable: true
+`
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.LoadProjectConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	wantThemes := []config.PinnedTheme{{Name: "brand", URL: "https://example.com/themes.zip", SHA256: "abc123", File: "brand/brand.css"}}
+	if !reflect.DeepEqual(project.Themes, wantThemes) {
+		t.Errorf("Themes = %+v, want %+v", project.Themes, wantThemes)
+	}
+	wantFilters := []config.PinnedFilter{{Path: "scripts/toc.sh", URL: "https://example.com/toc.sh", SHA256: "def456", Executable: true}}
+	if !reflect.DeepEqual(project.Filters, wantFilters) {
+		t.Errorf("Filters = %+v, want %+v", project.Filters, wantFilters)
+	}
+}
+
+// TestFindProjectNone tests that directories outside a project return nil.
+func TestFindProjectNone(t *testing.T) {
+	project, err := config.FindProject(t.TempDir())
+	if err != nil {
+		t.Fatalf("FindProject failed: %v", err)
+	}
+	if project != nil {
+		t.Errorf("expected no project, got root %q", project.Root)
+	}
+}
+
+// TestFindVault tests that the nearest directory with .obsidian is the vault.
+func TestFindVault(t *testing.T) {
+	vault := t.TempDir()
+	notes := filepath.Join(vault, "notes", "daily")
+	if err := os.MkdirAll(filepath.Join(vault, ".obsidian"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(notes, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := config.FindVault(notes); got != vault {
+		t.Errorf("FindVault = %q, want %q", got, vault)
+	}
+	if got := config.FindVault(t.TempDir()); got != "" {
+		t.Errorf("FindVault outside a vault = %q, want none", got)
+	}
+
+	project := &config.ProjectConfig{Root: vault, Vault: "notes"}
+	if got, want := project.VaultPath(), filepath.Join(vault, "notes"); got != want {
+		t.Errorf("VaultPath = %q, want %q", got, want)
+	}
+}
+
+// TestResourcePaths tests that resource_dirs resolve against the project root.
+func TestResourcePaths(t *testing.T) {
+	root := t.TempDir()
+	shared := t.TempDir()
+	content := "resource_dirs:\n  - assets\n  - references/csl\n  - " + shared + "\n"
+	if err := os.WriteFile(filepath.Join(root, ".veve.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(root, "docs")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.FindProject(nested)
+	if err != nil || project == nil {
+		t.Fatalf("FindProject = %v, %v", project, err)
+	}
+	want := []string{filepath.Join(root, "assets"), filepath.Join(root, "references", "csl"), shared}
+	if got := project.ResourcePaths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ResourcePaths() = %q, want %q", got, want)
+	}
+}
+
+func TestImageExtensionMap(t *testing.T) {
+	root := t.TempDir()
+	configFile := filepath.Join(root, config.ProjectConfigFile)
+	content := "image_extensions:\n  - type: image/vnd.ms-photo\n    extension: .jxr\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.LoadProjectConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	want := map[string]string{"image/vnd.ms-photo": ".jxr"}
+	if got := project.ImageExtensionMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ImageExtensionMap() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,190 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+)
+
+// TestSetSetting tests that settings are written where LoadConfig reads
+// them, keeping the file's other settings.
+func TestSetSetting(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "veve", "veve.toml")
+	if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte("[aliases]\nreport = \"--theme academic\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string]string{
+		"theme":                 "dark",
+		"remote-images-timeout": "30",
+		"enable-remote-images":  "false",
+		"metrics":               "true",
+	} {
+		if err := config.SetSetting(configFile, name, value); err != nil {
+			t.Fatalf("SetSetting(%s, %s) failed: %v", name, value, err)
+		}
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	images := cfg.Defaults.RemoteImages
+	if cfg.Defaults.Theme != "dark" || !cfg.Metrics {
+		t.Errorf("theme, metrics = %q, %v; want dark, true", cfg.Defaults.Theme, cfg.Metrics)
+	}
+	if images.Timeout == nil || *images.Timeout != 30 || images.Enabled == nil || *images.Enabled {
+		t.Errorf("remote images = %+v, want a 30s timeout, disabled", images)
+	}
+	if cfg.Aliases["report"] != "--theme academic" {
+		t.Errorf("aliases = %v, want the report alias kept", cfg.Aliases)
+	}
+
+	values, err := config.ReadSettings(configFile)
+	if err != nil {
+		t.Fatalf("ReadSettings failed: %v", err)
+	}
+	if values["theme"] != "dark" || values["remote-images-timeout"] != "30" {
+		t.Errorf("ReadSettings() = %v", values)
+	}
+	if _, ok := values["pdf-engine"]; ok {
+		t.Error("pdf-engine should not be set")
+	}
+
+	if err := config.UnsetSetting(configFile, "remote-images-timeout"); err != nil {
+		t.Fatalf("UnsetSetting failed: %v", err)
+	}
+	if values, _ := config.ReadSettings(configFile); values["remote-images-timeout"] != "" || values["enable-remote-images"] != "false" {
+		t.Errorf("after unset, ReadSettings() = %v", values)
+	}
+}
+
+// TestSetSettingInvalid tests that a bad setting or value leaves the file
+// as it was.
+func TestSetSettingInvalid(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "veve.toml")
+	if err := config.SetSetting(configFile, "theme", "dark"); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range [][2]string{
+		{"colour", "blue"},
+		{"remote-images-timeout", "soon"},
+		{"remote-images-timeout", "0"},
+		{"enable-remote-images", "maybe"},
+	} {
+		if err := config.SetSetting(configFile, tt[0], tt[1]); err == nil {
+			t.Errorf("SetSetting(%s, %s) succeeded, want an error", tt[0], tt[1])
+		}
+	}
+	after, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("file changed to:\n%s", after)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(configFile))
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".veve-") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+// TestSetSettingKeepsFile tests that setting and unsetting change only the
+// setting's line, keeping comments and the case of alias names.
+func TestSetSettingKeepsFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "veve.toml")
+	original := `# veve settings
+metrics = false # local log only
+
+# Shortcuts for the team
+[aliases]
+MyReport = "--theme academic" # mixed case on purpose
+
+[defaults]
+# The house style
+theme = "default"
+`
+	if err := os.WriteFile(configFile, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dark := strings.Replace(original, `theme = "default"`, `theme = "dark"`, 1)
+	darkMetrics := strings.Replace(dark, "metrics = false", "metrics = true", 1)
+	steps := []struct {
+		set, value, unset string
+		want              string
+	}{
+		{set: "theme", value: "dark", want: dark},
+		{set: "metrics", value: "true", want: darkMetrics},
+		{set: "remote-images-timeout", value: "30", want: darkMetrics + "\n[defaults.remote_images]\ntimeout = 30\n"},
+		{unset: "remote-images-timeout", want: darkMetrics},
+		{set: "pdf-engine", value: "weasyprint", want: strings.Replace(darkMetrics, `theme = "dark"`, "theme = \"dark\"\npdf_engine = \"weasyprint\"", 1)},
+		{unset: "pdf-engine", want: darkMetrics},
+	}
+
+	for _, step := range steps {
+		var err error
+		if step.unset != "" {
+			err = config.UnsetSetting(configFile, step.unset)
+		} else {
+			err = config.SetSetting(configFile, step.set, step.value)
+		}
+		if err != nil {
+			t.Fatalf("%+v failed: %v", step, err)
+		}
+		got, err := os.ReadFile(configFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != step.want {
+			t.Fatalf("after %+v the file is:\n%s\nwant:\n%s", step, got, step.want)
+		}
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Defaults.Theme != "dark" || !cfg.Metrics || len(cfg.Aliases) != 1 {
+		t.Errorf("config = %+v", cfg)
+	}
+}
+
+// TestSetSettingInlineTable tests that a setting inside an inline table is
+// refused, rather than added as a second definition of the table.
+func TestSetSettingInlineTable(t *testing.T) {
+	tests := []struct {
+		content, name, value string
+	}{
+		{"defaults = { theme = \"default\" }\n", "theme", "dark"},
+		{"[defaults]\nremote_images = { timeout = 10 }\n", "remote-images-timeout", "30"},
+	}
+	for _, tt := range tests {
+		configFile := filepath.Join(t.TempDir(), "veve.toml")
+		if err := os.WriteFile(configFile, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := config.SetSetting(configFile, tt.name, tt.value); err == nil || !strings.Contains(err.Error(), "edit it by hand") {
+			t.Errorf("SetSetting(%s) in %q = %v, want an error to edit it by hand", tt.name, tt.content, err)
+		}
+		if err := config.UnsetSetting(configFile, tt.name); err == nil {
+			t.Errorf("UnsetSetting(%s) in %q succeeded, want an error", tt.name, tt.content)
+		}
+		if got, _ := os.ReadFile(configFile); string(got) != tt.content {
+			t.Errorf("file changed to:\n%s", got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestFilterAudience(t *testing.T) {
+	content := "# Results\n\n" +
+		"::: {.internal}\nMargins are below target.\n:::\n\n" +
+		"::: external\nContact sales for details.\n:::\n\n" +
+		"::: {.note .internal .external}\nFor everyone.\n:::\n\n" +
+		"::: {.note}\nA note.\n\n:::: {.internal}\nNested internal.\n::::\n:::\n\n" +
+		"```markdown\n::: {.internal}\nIn code.\n:::\n```\n"
+
+	tests := []struct {
+		name     string
+		audience string
+		want     string
+	}{
+		{
+			name:     "no audience keeps everything",
+			audience: "",
+			want:     content,
+		},
+		{
+			name:     "internal",
+			audience: "internal",
+			want: "# Results\n\n" +
+				"::: {.internal}\nMargins are below target.\n:::\n\n" +
+				"\n" +
+				"::: {.note .internal .external}\nFor everyone.\n:::\n\n" +
+				"::: {.note}\nA note.\n\n:::: {.internal}\nNested internal.\n::::\n:::\n\n" +
+				"```markdown\n::: {.internal}\nIn code.\n:::\n```\n",
+		},
+		{
+			name:     "external",
+			audience: "external",
+			want: "# Results\n\n" +
+				"\n" +
+				"::: external\nContact sales for details.\n:::\n\n" +
+				"::: {.note .internal .external}\nFor everyone.\n:::\n\n" +
+				"::: {.note}\nA note.\n\n:::\n\n" +
+				"```markdown\n::: {.internal}\nIn code.\n:::\n```\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := converter.FilterAudience(content, tt.audience, converter.DefaultAudiences)
+			if got != tt.want {
+				t.Errorf("FilterAudience() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterAudienceCustomAudiences(t *testing.T) {
+	content := "::: {.partner}\nPartner pricing.\n:::\n::: {.internal}\nNot an audience here.\n:::\n"
+	got := converter.FilterAudience(content, "customer", []string{"partner", "customer"})
+	want := "::: {.internal}\nNot an audience here.\n:::\n"
+	if got != want {
+		t.Errorf("FilterAudience() = %q, want %q", got, want)
+	}
+}
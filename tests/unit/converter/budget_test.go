@@ -0,0 +1,41 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	tests := []struct {
+		name        string
+		budget      converter.MemoryBudget
+		concurrency int
+		buffer      int
+		section     int
+		stream      bool
+	}{
+		{"none", 0, 5, 256 << 10, converter.SectionSize, false},
+		{"large", 4 << 30, 5, 256 << 10, converter.SectionSize, true},
+		{"small container", 64 << 20, 4, 64 << 10, 1 << 20, true},
+		{"tiny", 8 << 20, 1, 32 << 10, 128 << 10, true},
+		{"too small", 1 << 20, 1, 32 << 10, 64 << 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.budget.ImageConcurrency(); got != tt.concurrency {
+				t.Errorf("ImageConcurrency() = %d, want %d", got, tt.concurrency)
+			}
+			if got := tt.budget.CopyBufferSize(); got != tt.buffer {
+				t.Errorf("CopyBufferSize() = %d, want %d", got, tt.buffer)
+			}
+			if got := tt.budget.SectionSize(); got != tt.section {
+				t.Errorf("SectionSize() = %d, want %d", got, tt.section)
+			}
+			if got := tt.budget.StreamOutput(); got != tt.stream {
+				t.Errorf("StreamOutput() = %v, want %v", got, tt.stream)
+			}
+		})
+	}
+}
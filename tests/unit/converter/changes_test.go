@@ -0,0 +1,74 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+// changed returns block wrapped the way MarkChanges marks it.
+func changed(block string) string {
+	return "::: {.changed}\n```{=latex}\n\\cbstart\n```\n\n" + block + "\n\n```{=latex}\n\\cbend\n```\n:::\n"
+}
+
+func TestMarkChanges(t *testing.T) {
+	old := "---\ntitle: Spec\n---\n\n# Scope\n\nThe service stores\norders.\n\n- one\n- two\n\nClosing words.\n"
+
+	tests := []struct {
+		name        string
+		content     string
+		want        string
+		wantChanged bool
+	}{
+		{
+			name:        "unchanged, rewrapped",
+			content:     "---\ntitle: Spec\n---\n\n# Scope\n\nThe service stores orders.\n\n- one\n- two\n\nClosing words.\n",
+			want:        "---\ntitle: Spec\n---\n\n# Scope\n\nThe service stores orders.\n\n- one\n- two\n\nClosing words.\n",
+			wantChanged: false,
+		},
+		{
+			name:        "changed paragraph and list",
+			content:     "---\ntitle: Spec v2\n---\n\n# Scope\n\nThe service stores orders and refunds.\n\n- one\n- three\n\nClosing words.\n",
+			want:        "---\ntitle: Spec v2\n---\n\n# Scope\n\n" + changed("The service stores orders and refunds.\n\n- one\n- three") + "\nClosing words.\n",
+			wantChanged: true,
+		},
+		{
+			name:        "added at the end",
+			content:     old + "\n## Appendix\n",
+			want:        "---\ntitle: Spec\n---\n\n# Scope\n\nThe service stores\norders.\n\n- one\n- two\n\nClosing words.\n\n" + changed("## Appendix"),
+			wantChanged: true,
+		},
+		{
+			name:        "removed only",
+			content:     "---\ntitle: Spec\n---\n\n# Scope\n\nClosing words.\n",
+			want:        "---\ntitle: Spec\n---\n\n# Scope\n\nClosing words.\n",
+			wantChanged: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotChanged := converter.MarkChanges(tt.content, old)
+			if got != tt.want {
+				t.Errorf("MarkChanges() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if gotChanged != tt.wantChanged {
+				t.Errorf("MarkChanges() changed = %v, want %v", gotChanged, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestMarkChangesKeepsBlocksWhole(t *testing.T) {
+	old := "1. Step\n\n   Details.\n\n2. Next\n\n```\ncode\n\nmore\n```\n\n::: note\nA\n\nB\n:::\n"
+	content := strings.Replace(old, "Details.", "More details.", 1)
+	content = strings.Replace(content, "more\n", "less\n", 1)
+	content = strings.Replace(content, "B\n", "C\n", 1)
+
+	got, _ := converter.MarkChanges(content, old)
+	want := changed("1. Step\n\n   More details.\n\n2. Next\n\n```\ncode\n\nless\n```\n\n::: note\nA\n\nC\n:::")
+	if got != want {
+		t.Errorf("MarkChanges() =\n%s\nwant\n%s", got, want)
+	}
+}
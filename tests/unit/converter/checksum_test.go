@@ -0,0 +1,53 @@
+package converter_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestWriteChecksum(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(output, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := converter.WriteChecksum(output, converter.ChecksumSHA256)
+	if err != nil {
+		t.Fatalf("WriteChecksum failed: %v", err)
+	}
+	const want = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if sum != want {
+		t.Errorf("checksum = %s, want %s", sum, want)
+	}
+	sidecar, err := os.ReadFile(output + ".sha256")
+	if err != nil {
+		t.Fatalf("reading sidecar: %v", err)
+	}
+	if got := string(sidecar); got != want+"  report.pdf\n" {
+		t.Errorf("sidecar = %q, want sha256sum's format naming report.pdf", got)
+	}
+
+	if _, err := converter.WriteChecksum(output, converter.ChecksumSHA512); err != nil {
+		t.Fatalf("WriteChecksum(sha512) failed: %v", err)
+	}
+	if _, err := os.Stat(output + ".sha512"); err != nil {
+		t.Errorf("expected a .sha512 sidecar: %v", err)
+	}
+}
+
+func TestValidateChecksum(t *testing.T) {
+	for _, algorithm := range []string{converter.ChecksumOff, "sha256", "sha512"} {
+		if err := converter.ValidateChecksum(algorithm); err != nil {
+			t.Errorf("ValidateChecksum(%q) = %v", algorithm, err)
+		}
+	}
+	for _, algorithm := range []string{"md5", "SHA-256"} {
+		if err := converter.ValidateChecksum(algorithm); err == nil {
+			t.Errorf("ValidateChecksum(%q) succeeded, want an error", algorithm)
+		}
+	}
+}
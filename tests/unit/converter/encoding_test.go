@@ -0,0 +1,93 @@
+package converter_test
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+// encodeUTF16 encodes s as UTF-16 in the given byte order, with bom in front.
+func encodeUTF16(s string, order binary.AppendByteOrder, bom bool) []byte {
+	var data []byte
+	if bom {
+		data = order.AppendUint16(data, 0xFEFF)
+	}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		data = order.AppendUint16(data, unit)
+	}
+	return data
+}
+
+func TestNormalizeText(t *testing.T) {
+	const text = "# Café 世界 🎉\n\n![Chart](images/chart.png)\n"
+	crlf := "# Café 世界 🎉\r\n\r\n![Chart](images/chart.png)\r\n"
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"plain", []byte(text)},
+		{"UTF-8 BOM", append([]byte{0xEF, 0xBB, 0xBF}, text...)},
+		{"CRLF", []byte(crlf)},
+		{"UTF-8 BOM and CRLF", append([]byte{0xEF, 0xBB, 0xBF}, crlf...)},
+		{"UTF-16LE with BOM", encodeUTF16(crlf, binary.LittleEndian, true)},
+		{"UTF-16BE with BOM", encodeUTF16(crlf, binary.BigEndian, true)},
+		{"UTF-16LE without BOM", encodeUTF16(text, binary.LittleEndian, false)},
+		{"UTF-16BE without BOM", encodeUTF16(text, binary.BigEndian, false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(converter.NormalizeText(tt.input)); got != text {
+				t.Errorf("NormalizeText() = %q, want %q", got, text)
+			}
+		})
+	}
+}
+
+func TestNormalizeTextKeepsOtherContent(t *testing.T) {
+	// Lone carriage returns and a BOM inside the text are content
+	input := "a\rb\n\uFEFFc"
+	if got := string(converter.NormalizeText([]byte(input))); got != input {
+		t.Errorf("NormalizeText() = %q, want it unchanged", got)
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		text  string
+	}{
+		{"UTF-8", "# Café 世界\n", "", ""},
+		{"Latin-1", "# Caf\xe9 na\xefve\n", converter.EncodingLatin1, "# Café naïve\n"},
+		{"windows-1252 quotes", "# \x93Caf\xe9\x94 \x96 \x80\n", converter.EncodingWindows1252, "# “Café” – €\n"},
+		{"Shift_JIS", "# \x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd \x93\xfa\x96\x7b\n", converter.EncodingShiftJIS, "# こんにちは 日本\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := converter.DetectEncoding([]byte(tt.input))
+			if got != tt.want {
+				t.Fatalf("DetectEncoding() = %q, want %q", got, tt.want)
+			}
+			if got == "" {
+				return
+			}
+			text, err := converter.Transcode([]byte(tt.input), got)
+			if err != nil {
+				t.Fatalf("Transcode() error: %v", err)
+			}
+			if string(text) != tt.text {
+				t.Errorf("Transcode() = %q, want %q", text, tt.text)
+			}
+		})
+	}
+
+	if _, err := converter.Transcode([]byte("x"), "EBCDIC"); err == nil {
+		t.Error("Transcode() of an unknown encoding succeeded")
+	}
+}
@@ -0,0 +1,206 @@
+package converter_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/tests/testutil"
+)
+
+func TestMemoryFetcher(t *testing.T) {
+	pngData, contentType := testutil.CreateTestImageData("png")
+	fetcher := converter.NewMemoryFetcher().Add("https://example.com/a.png", contentType, pngData)
+
+	body, meta, err := fetcher.Fetch(context.Background(), "https://example.com/a.png")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	defer body.Close()
+
+	data, _ := io.ReadAll(body)
+	if len(data) != len(pngData) {
+		t.Errorf("expected %d bytes, got %d", len(pngData), len(data))
+	}
+	if meta.ContentType != "image/png" || meta.ContentLength != int64(len(pngData)) {
+		t.Errorf("unexpected meta: %+v", meta)
+	}
+
+	_, _, err = fetcher.Fetch(context.Background(), "https://example.com/missing.png")
+	var statusErr *converter.HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 HTTPStatusError, got %v", err)
+	}
+}
+
+func TestFileFetcher(t *testing.T) {
+	pngData, _ := testutil.CreateTestImageData("png")
+	path := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(path, pngData, 0o644); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+
+	body, meta, err := converter.NewFileFetcher().Fetch(context.Background(), "file://"+filepath.ToSlash(path))
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	body.Close()
+
+	if meta.ContentType != "image/png" {
+		t.Errorf("expected image/png, got %q", meta.ContentType)
+	}
+	if meta.ContentLength != int64(len(pngData)) {
+		t.Errorf("expected length %d, got %d", len(pngData), meta.ContentLength)
+	}
+}
+
+func TestS3FetcherUsesEndpointOverride(t *testing.T) {
+	mock := testutil.NewMockHTTPServer()
+	defer mock.Close()
+	mock.RegisterImage("/docs-bucket/img/diagram.png", "png")
+
+	t.Setenv("AWS_ENDPOINT_URL_S3", mock.URL())
+
+	body, meta, err := converter.NewS3Fetcher(nil).Fetch(context.Background(), "s3://docs-bucket/img/diagram.png")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	body.Close()
+
+	if meta.ContentType != "image/png" {
+		t.Errorf("expected image/png, got %q", meta.ContentType)
+	}
+
+	if _, _, err := converter.NewS3Fetcher(nil).Fetch(context.Background(), "s3://bucket-only"); err == nil {
+		t.Error("expected error for S3 URL without key")
+	}
+}
+
+func TestSchemeFetcherRouting(t *testing.T) {
+	pngData, contentType := testutil.CreateTestImageData("png")
+	mem := converter.NewMemoryFetcher().Add("mem://logo", contentType, pngData)
+	fetcher := converter.NewSchemeFetcher().Register("MEM", mem)
+
+	if !fetcher.Supports("mem://logo") {
+		t.Error("expected mem:// to be supported")
+	}
+	if fetcher.Supports("https://example.com/a.png") || fetcher.Supports("relative/path.png") {
+		t.Error("expected unregistered schemes and plain paths to be unsupported")
+	}
+
+	if _, _, err := fetcher.Fetch(context.Background(), "ftp://example.com/a.png"); err == nil {
+		t.Error("expected error for unregistered scheme")
+	}
+}
+
+func TestProcessMarkdownWithCustomFetcher(t *testing.T) {
+	pngData, contentType := testutil.CreateTestImageData("png")
+	mem := converter.NewMemoryFetcher().Add("mem://diagram", contentType, pngData)
+
+	processor := converter.NewImageProcessor(t.TempDir()).
+		WithFetcher(converter.NewSchemeFetcher().Register("mem", mem))
+	defer processor.Cleanup()
+
+	content := "# Doc\n\n![diagram](mem://diagram)\n![web](https://example.com/a.png)\n"
+	processed, err := processor.ProcessMarkdown(content)
+	if err != nil {
+		t.Fatalf("ProcessMarkdown failed: %v", err)
+	}
+
+	if strings.Contains(processed, "mem://diagram") {
+		t.Errorf("expected mem:// image to be rewritten, got:\n%s", processed)
+	}
+	if !strings.Contains(processed, "https://example.com/a.png") {
+		t.Errorf("expected unsupported https image to be left untouched, got:\n%s", processed)
+	}
+}
+
+func TestDownloadSniffsMislabeledImages(t *testing.T) {
+	pngData, _ := testutil.CreateTestImageData("png")
+	svgData := []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="1" height="1"></svg>`)
+	fetcher := converter.NewMemoryFetcher().
+		Add("https://example.com/octet", "application/octet-stream", pngData).
+		Add("https://example.com/plain", "text/plain", svgData).
+		Add("https://example.com/page", "text/html", []byte("<html><body>Not found</body></html>"))
+
+	tests := []struct {
+		url     string
+		strict  bool
+		wantExt string // "" if the download should fail
+	}{
+		{"https://example.com/octet", false, ".png"},
+		{"https://example.com/plain", false, ".svg"},
+		{"https://example.com/page", false, ""},
+		{"https://example.com/octet", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s strict=%v", tt.url, tt.strict), func(t *testing.T) {
+			processor := converter.NewImageProcessor(t.TempDir()).
+				WithFetcher(fetcher).
+				WithStrictContentType(tt.strict)
+			defer processor.Cleanup()
+
+			path, err := processor.DownloadImageOnce(tt.url)
+			if tt.wantExt == "" {
+				if err == nil {
+					t.Fatalf("expected download to fail, saved %s", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DownloadImageOnce failed: %v", err)
+			}
+			if !strings.HasSuffix(path, tt.wantExt) {
+				t.Errorf("expected a %s file, got %s", tt.wantExt, path)
+			}
+		})
+	}
+}
+
+func TestDownloadUnknownImageTypes(t *testing.T) {
+	t.Setenv("PATH", "") // Without ImageMagick
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	fetcher := converter.NewMemoryFetcher().
+		Add("https://example.com/x-png", "image/x-png", pngData.Bytes()).
+		Add("https://example.com/pixmap", "image/x-portable-pixmap", []byte("P3\n1 1\n255\n0 0 0\n"))
+
+	processor := converter.NewImageProcessor(t.TempDir()).WithFetcher(fetcher)
+	defer processor.Cleanup()
+
+	// A decodable image of an unknown type is converted to PNG
+	path, err := processor.DownloadImageOnce("https://example.com/x-png")
+	if err != nil {
+		t.Fatalf("DownloadImageOnce failed: %v", err)
+	}
+	if !strings.HasSuffix(path, ".png") {
+		t.Errorf("expected a .png file, got %s", path)
+	}
+
+	// One that can't be converted is not saved as an unloadable file
+	if path, err := processor.DownloadImageOnce("https://example.com/pixmap"); err == nil {
+		t.Fatalf("expected download to fail, saved %s", path)
+	}
+
+	// Unless the project maps its type to an extension
+	processor.WithImageExtensions(map[string]string{"image/x-portable-pixmap": "ppm"})
+	path, err = processor.DownloadImageOnce("https://example.com/pixmap")
+	if err != nil {
+		t.Fatalf("DownloadImageOnce failed: %v", err)
+	}
+	if !strings.HasSuffix(path, ".ppm") {
+		t.Errorf("expected a .ppm file, got %s", path)
+	}
+}
@@ -0,0 +1,136 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestPrepareFigures(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "title_becomes_caption",
+			content:  "![](diagram.png \"System overview\")\n",
+			expected: "![System overview](diagram.png \"System overview\")\n",
+		},
+		{
+			name:     "attributes_preserved",
+			content:  "![](diagram.png 'Overview'){width=50%}\n",
+			expected: "![Overview](diagram.png 'Overview'){width=50%}\n",
+		},
+		{
+			name:     "existing_alt_text_kept",
+			content:  "![Alt caption](diagram.png \"Title\")\n",
+			expected: "![Alt caption](diagram.png \"Title\")\n",
+		},
+		{
+			name:     "inline_image_unchanged",
+			content:  "See ![](icon.png \"Icon\") here.\n",
+			expected: "See ![](icon.png \"Icon\") here.\n",
+		},
+		{
+			name:     "brackets_escaped",
+			content:  "![](chart.png \"Results [2024]\")\n",
+			expected: "![Results \\[2024\\]](chart.png \"Results [2024]\")\n",
+		},
+		{
+			name:     "own_line_in_paragraph_separated",
+			content:  "Revenue grew.\n![Quarterly revenue](chart.png)\nSee the appendix.\n",
+			expected: "Revenue grew.\n\n![Quarterly revenue](chart.png)\n\nSee the appendix.\n",
+		},
+		{
+			name:     "title_caption_in_paragraph",
+			content:  "Intro\n![](diagram.png \"Overview\"){width=50%}\n",
+			expected: "Intro\n\n![Overview](diagram.png \"Overview\"){width=50%}\n",
+		},
+		{
+			name:     "consecutive_images_separated",
+			content:  "![First](a.png)\n![Second](b.png)\n",
+			expected: "![First](a.png)\n\n![Second](b.png)\n",
+		},
+		{
+			name:     "uncaptioned_image_unchanged",
+			content:  "Text\n![](a.png)\n",
+			expected: "Text\n![](a.png)\n",
+		},
+		{
+			name:     "list_image_unchanged",
+			content:  "- Item\n  ![Chart](a.png)\n",
+			expected: "- Item\n  ![Chart](a.png)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := converter.PrepareFigures(tt.content); got != tt.expected {
+				t.Errorf("PrepareFigures():\nGot:\n%s\nWant:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestPrepareFiguresCaptions tests that each captionable image on a line of
+// its own ends up alone in its paragraph, which pandoc renders as a figure,
+// captioned with its alt text or title.
+func TestPrepareFiguresCaptions(t *testing.T) {
+	content := strings.Join([]string{
+		"# Results\n",
+		"Revenue grew in every region.\n![Quarterly revenue](revenue.png)\nMargins held.\n",
+		"![](architecture.png \"System overview\"){width=80%}\n",
+		"The logo ![Logo](logo.png) stays inline.\n",
+	}, "\n")
+
+	source := []byte(converter.PrepareFigures(content))
+	var captions []string
+	doc := goldmark.New().Parser().Parse(text.NewReader(source))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		paragraph, ok := n.(*ast.Paragraph)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		image, ok := paragraph.FirstChild().(*ast.Image)
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		// Only a pandoc attribute block may follow a figure's image
+		for sibling := image.NextSibling(); sibling != nil; sibling = sibling.NextSibling() {
+			textNode, ok := sibling.(*ast.Text)
+			if !ok || !strings.HasPrefix(strings.TrimSpace(string(textNode.Segment.Value(source))), "{") {
+				return ast.WalkSkipChildren, nil
+			}
+		}
+		var alt strings.Builder
+		for child := image.FirstChild(); child != nil; child = child.NextSibling() {
+			if textNode, ok := child.(*ast.Text); ok {
+				alt.Write(textNode.Segment.Value(source))
+			}
+		}
+		captions = append(captions, alt.String())
+		return ast.WalkSkipChildren, nil
+	})
+
+	want := []string{"Quarterly revenue", "System overview"}
+	if strings.Join(captions, "|") != strings.Join(want, "|") {
+		t.Errorf("figure captions = %q, want %q:\n%s", captions, want, source)
+	}
+}
+
+func TestRewritePreservesImageAttributes(t *testing.T) {
+	processor := converter.NewImageProcessor(t.TempDir())
+	processor.SetImageMap("https://example.com/a.png", "/tmp/veve-image-a.png")
+
+	content := "![Chart](https://example.com/a.png \"Quarterly\"){width=50% #fig:chart}\n"
+	expected := "![Chart](/tmp/veve-image-a.png \"Quarterly\"){width=50% #fig:chart}\n"
+
+	if got := processor.RewriteMarkdownImageURLs(content); got != expected {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, expected)
+	}
+}
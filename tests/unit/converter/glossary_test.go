@@ -0,0 +1,124 @@
+package converter_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func testGlossary() *converter.Glossary {
+	return &converter.Glossary{Terms: []converter.GlossaryTerm{
+		{Term: "API", Expansion: "Application Programming Interface"},
+		{Term: "SLA", Expansion: "Service Level Agreement", Definition: "The availability a service commits to."},
+		{Term: "SLA credit", Definition: "A refund for a missed SLA."},
+		{Term: "idempotent", Definition: "Giving the same result when repeated."},
+	}}
+}
+
+func TestGlossaryExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		want     string
+		wantUsed []string
+	}{
+		{
+			name:     "first use only",
+			content:  "The API is public. Call the API.\n",
+			want:     "The Application Programming Interface (API) is public. Call the API.\n",
+			wantUsed: []string{"API"},
+		},
+		{
+			name:     "whole words",
+			content:  "APIs and RAPID use no terms.\n",
+			want:     "APIs and RAPID use no terms.\n",
+			wantUsed: nil,
+		},
+		{
+			name:     "headings, code, and links are skipped",
+			content:  "# The API\n\nRun `API` or see [the API](api.md) and <https://example.com/API>.\n\n```\nAPI\n```\n\nThe API.\n",
+			want:     "# The API\n\nRun `API` or see [the API](api.md) and <https://example.com/API>.\n\n```\nAPI\n```\n\nThe Application Programming Interface (API).\n",
+			wantUsed: []string{"API"},
+		},
+		{
+			name:     "already written out",
+			content:  "An Application Programming Interface (API) is an API.\n",
+			want:     "An Application Programming Interface (API) is an API.\n",
+			wantUsed: []string{"API"},
+		},
+		{
+			name:     "front matter",
+			content:  "---\ntitle: API guide\n---\n\nThe *API* is idempotent.\n",
+			want:     "---\ntitle: API guide\n---\n\nThe *Application Programming Interface (API)* is idempotent.\n",
+			wantUsed: []string{"API", "idempotent"},
+		},
+		{
+			name:     "longest term wins",
+			content:  "Each SLA credit is paid monthly.\n",
+			want:     "Each SLA credit is paid monthly.\n",
+			wantUsed: []string{"SLA credit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, used := testGlossary().Expand(tt.content)
+			if got != tt.want {
+				t.Errorf("Expand() = %q, want %q", got, tt.want)
+			}
+			var names []string
+			for _, term := range used {
+				names = append(names, term.Term)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantUsed, ",") {
+				t.Errorf("used = %v, want %v", names, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestGlossarySection(t *testing.T) {
+	terms := testGlossary().Terms
+	got := converter.GlossarySection("", []converter.GlossaryTerm{terms[3], terms[1], terms[0]})
+	want := "# Glossary {#glossary .unnumbered}\n\n" +
+		"API\n:   Application Programming Interface\n\n" +
+		"idempotent\n:   Giving the same result when repeated.\n\n" +
+		"SLA\n:   Service Level Agreement — The availability a service commits to.\n\n"
+	if got != want {
+		t.Errorf("GlossarySection() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLoadGlossary(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "glossary.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	glossary, err := converter.LoadGlossary(write("terms:\n  - term: API\n    expansion: Application Programming Interface\n"))
+	if err != nil {
+		t.Fatalf("LoadGlossary failed: %v", err)
+	}
+	if len(glossary.Terms) != 1 || glossary.Terms[0].Expansion != "Application Programming Interface" {
+		t.Errorf("Terms = %+v", glossary.Terms)
+	}
+
+	for name, content := range map[string]string{
+		"no name":      "terms:\n  - expansion: x\n",
+		"duplicate":    "terms:\n  - {term: A, expansion: x}\n  - {term: A, expansion: y}\n",
+		"empty":        "terms:\n  - term: A\n",
+		"multiline":    "terms:\n  - term: A\n    expansion: \"x\\ny\"\n",
+		"invalid yaml": "terms: [\n",
+	} {
+		if _, err := converter.LoadGlossary(write(content)); err == nil {
+			t.Errorf("%s: LoadGlossary succeeded, want an error", name)
+		}
+	}
+}
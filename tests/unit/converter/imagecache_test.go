@@ -0,0 +1,174 @@
+package converter_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/tests/testutil"
+)
+
+// TestImageCacheLookup tests that a cached image is found with the validators
+// it was stored with, and that URLs serving the same image share it.
+func TestImageCacheLookup(t *testing.T) {
+	cache := converter.NewImageCache(filepath.Join(t.TempDir(), "images"))
+	image := filepath.Join(t.TempDir(), "logo.png")
+	data, _ := testutil.CreateTestImageData("png")
+	if err := os.WriteFile(image, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	validators := converter.CacheValidators{ETag: `"v1"`, LastModified: "Mon, 05 Oct 2026 09:00:00 GMT"}
+	if err := cache.Store("https://example.com/logo.png", validators, image); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	cached, ok := cache.Lookup("https://example.com/logo.png")
+	if !ok {
+		t.Fatal("expected a cached image for the stored URL")
+	}
+	if cached.Validators != validators {
+		t.Errorf("Validators = %+v, want %+v", cached.Validators, validators)
+	}
+	if got, _ := os.ReadFile(cached.Path); !bytes.Equal(got, data) || filepath.Ext(cached.Path) != ".png" {
+		t.Errorf("cached image %s differs from the stored one", cached.Path)
+	}
+	if _, ok := cache.Lookup("https://example.com/other.png"); ok {
+		t.Error("expected a miss for another URL")
+	}
+
+	// Without validators, nothing is stored
+	if err := cache.Store("https://example.com/plain.png", converter.CacheValidators{}, image); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if _, ok := cache.Lookup("https://example.com/plain.png"); ok {
+		t.Error("expected no cached image without validators")
+	}
+
+	// The same image from another URL is stored once
+	mirrorValidators := converter.CacheValidators{LastModified: "Tue, 06 Oct 2026 09:00:00 GMT"}
+	if err := cache.Store("https://mirror.example.com/logo.png", mirrorValidators, image); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if mirror, _ := cache.Lookup("https://mirror.example.com/logo.png"); mirror.Path != cached.Path {
+		t.Errorf("mirror cached as %s, want the shared %s", mirror.Path, cached.Path)
+	}
+
+	images, size, err := cache.Clear()
+	if err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if images != 1 || size != int64(len(data)) {
+		t.Errorf("Clear() = %d images, %d bytes; want 1, %d", images, size, len(data))
+	}
+	if _, ok := cache.Lookup("https://example.com/logo.png"); ok {
+		t.Error("expected a miss after Clear")
+	}
+}
+
+// TestDownloadUsesImageCache tests that a later run serves an image from the
+// cache while a server that ignores conditional requests sends the same ETag,
+// and downloads it once it changes.
+func TestDownloadUsesImageCache(t *testing.T) {
+	mock := testutil.NewMockHTTPServer()
+	defer mock.Close()
+
+	pngData, _ := testutil.CreateTestImageData("png")
+	etag := `"v1"`
+	mock.RegisterWithHandler("/logo.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("ETag", etag)
+		w.Write(pngData)
+	})
+	imageURL := mock.ImageURL("/logo.png")
+	cache := converter.NewImageCache(filepath.Join(t.TempDir(), "images"))
+
+	download := func() *converter.ImageProcessor {
+		t.Helper()
+		processor := converter.NewImageProcessor(t.TempDir()).WithImageCache(cache)
+		localPath, err := processor.DownloadImageOnce(imageURL)
+		if err != nil {
+			t.Fatalf("DownloadImageOnce failed: %v", err)
+		}
+		if data, _ := os.ReadFile(localPath); !bytes.Equal(data, pngData) {
+			t.Errorf("image at %s differs from the served one", localPath)
+		}
+		return processor
+	}
+
+	if cached := download().CachedImages(); cached != 0 {
+		t.Errorf("first run: CachedImages() = %d, want 0", cached)
+	}
+	if cached := download().CachedImages(); cached != 1 {
+		t.Errorf("second run: CachedImages() = %d, want 1", cached)
+	}
+	etag = `"v2"`
+	if cached := download().CachedImages(); cached != 0 {
+		t.Errorf("after the image changed: CachedImages() = %d, want 0", cached)
+	}
+}
+
+// TestDownloadRevalidatesCachedImages tests that a cached image is requested
+// with If-None-Match and If-Modified-Since, and served from the cache when the
+// server answers 304 Not Modified.
+func TestDownloadRevalidatesCachedImages(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string // Validator the server sends
+		value   string
+		matches func(r *http.Request, value string) bool
+	}{
+		{"etag", "ETag", `W/"v1"`, func(r *http.Request, value string) bool {
+			return r.Header.Get("If-None-Match") == value
+		}},
+		{"last_modified", "Last-Modified", "Mon, 05 Oct 2026 09:00:00 GMT", func(r *http.Request, value string) bool {
+			return r.Header.Get("If-Modified-Since") == value
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := testutil.NewMockHTTPServer()
+			defer mock.Close()
+
+			pngData, _ := testutil.CreateTestImageData("png")
+			var full, notModified int
+			mock.RegisterWithHandler("/chart.png", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(tt.header, tt.value)
+				if tt.matches(r, tt.value) {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full++
+				w.Header().Set("Content-Type", "image/png")
+				w.Write(pngData)
+			})
+			imageURL := mock.ImageURL("/chart.png")
+			cache := converter.NewImageCache(filepath.Join(t.TempDir(), "images"))
+
+			for run := 0; run < 2; run++ {
+				processor := converter.NewImageProcessor(t.TempDir()).WithImageCache(cache)
+				localPath, err := processor.DownloadImageOnce(imageURL)
+				if err != nil {
+					t.Fatalf("run %d: DownloadImageOnce failed: %v", run, err)
+				}
+				if data, _ := os.ReadFile(localPath); !bytes.Equal(data, pngData) {
+					t.Errorf("run %d: image at %s differs from the served one", run, localPath)
+				}
+				if cached := processor.CachedImages(); cached != run {
+					t.Errorf("run %d: CachedImages() = %d, want %d", run, cached, run)
+				}
+			}
+			if full != 1 || notModified != 1 {
+				t.Errorf("server sent %d full and %d not modified responses, want 1 of each", full, notModified)
+			}
+
+			// Without the cache, no validators are sent, and 304 isn't expected
+			if _, err := converter.NewImageProcessor(t.TempDir()).DownloadImageOnce(imageURL); err != nil || full != 2 {
+				t.Errorf("uncached download: err %v, %d full responses; want a full response", err, full)
+			}
+		})
+	}
+}
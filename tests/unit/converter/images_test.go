@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -141,6 +143,52 @@ And ![another](https://cdn.example.com/image.gif) one.`,
 	}
 }
 
+func TestCountImages(t *testing.T) {
+	content := "![a](local.png)\n\n![b](https://example.com/b.png) and ![c](HTTP://example.com/c.png)\n\n" +
+		"```\n![code](https://example.com/code.png)\n```\n"
+
+	total, remote := converter.NewImageProcessor("").CountImages(content)
+	if total != 3 || remote != 2 {
+		t.Errorf("CountImages() = %d, %d; want 3, 2", total, remote)
+	}
+}
+
+func TestOptionalSchemes(t *testing.T) {
+	content := "![a](https://example.com/a.png) ![b](file:///etc/b.png) ![c](s3://bucket/c.png)\n"
+
+	processor := converter.NewImageProcessor("")
+	if got := processor.DetectRemoteImages(content); len(got) != 1 {
+		t.Errorf("DetectRemoteImages() = %v; want only the https image by default", got)
+	}
+	if _, remote := processor.CountImages(content); remote != 1 {
+		t.Errorf("CountImages() counted %d remote images; want 1", remote)
+	}
+
+	processor.WithSchemes("file", "S3")
+	if got := processor.DetectRemoteImages(content); len(got) != 3 {
+		t.Errorf("DetectRemoteImages() = %v; want all 3 with file and s3 enabled", got)
+	}
+	if _, remote := processor.CountImages(content); remote != 3 {
+		t.Errorf("CountImages() counted %d remote images; want 3", remote)
+	}
+
+	if err := converter.CheckSchemes([]string{"file", "ftp"}); err == nil {
+		t.Error("CheckSchemes() accepted ftp")
+	}
+}
+
+func TestRebaseImages(t *testing.T) {
+	content := "![a](img/a.png) ![b](<my pics/b.png>) ![c](https://example.com/c.png) ![d](/abs/d.png)\n\n" +
+		"`![code](img/code.png)`\n"
+
+	got := converter.RebaseImages(content, "/book/chapters")
+	want := "![a](/book/chapters/img/a.png) ![b](</book/chapters/my pics/b.png>) ![c](https://example.com/c.png) ![d](/abs/d.png)\n\n" +
+		"`![code](img/code.png)`\n"
+	if got != want {
+		t.Errorf("RebaseImages() =\n%s\nwant\n%s", got, want)
+	}
+}
+
 // ============================================================================
 // T012: URL Validation Unit Tests
 // ============================================================================
@@ -227,6 +275,9 @@ func TestGetExtensionFromContentType(t *testing.T) {
 		{contentType: "image/svg+xml", expected: ".svg", testName: "SVG"},
 		{contentType: "image/bmp", expected: ".bmp", testName: "BMP"},
 		{contentType: "image/tiff", expected: ".tiff", testName: "TIFF"},
+		{contentType: "image/avif", expected: ".avif", testName: "AVIF"},
+		{contentType: "image/heic", expected: ".heic", testName: "HEIC"},
+		{contentType: "image/jxl", expected: ".jxl", testName: "JPEG XL"},
 		{contentType: "image/png; charset=utf-8", expected: ".png", testName: "PNG with charset"},
 		{contentType: "image/unknown", expected: ".img", testName: "unknown type"},
 		{contentType: "", expected: ".img", testName: "empty type"},
@@ -542,6 +593,102 @@ Some text
 	}
 }
 
+// ============================================================================
+// Code Block Handling Unit Tests
+// ============================================================================
+
+func TestImagesInCodeAreIgnored(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "backtick_fence",
+			content: "```markdown\n![alt](https://example.com/a.png)\n```",
+		},
+		{
+			name:    "tilde_fence",
+			content: "~~~\n![alt](https://example.com/a.png)\n~~~",
+		},
+		{
+			name:    "fence_with_pandoc_attributes",
+			content: "```{.markdown .numberLines}\n![alt](https://example.com/a.png)\n```",
+		},
+		{
+			name:    "indented_code_block",
+			content: "Example:\n\n    ![alt](https://example.com/a.png)\n",
+		},
+		{
+			name:    "inline_code",
+			content: "Write `![alt](https://example.com/a.png)` to embed an image.",
+		},
+		{
+			name:    "double_backtick_inline_code",
+			content: "Write ``![alt](https://example.com/a.png) and `more` `` here.",
+		},
+		{
+			name:    "fence_in_list_item",
+			content: "- Step one:\n\n  ```\n  ![alt](https://example.com/a.png)\n  ```\n",
+		},
+		{
+			name:    "html_comment",
+			content: "<!--\n![alt](https://example.com/a.png)\n-->\n",
+		},
+		{
+			name:    "fence_in_blockquote",
+			content: "> ```\n> ![alt](https://example.com/a.png)\n> ```\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := converter.NewImageProcessor(t.TempDir())
+
+			if urls := processor.DetectRemoteImages(tt.content); len(urls) != 0 {
+				t.Errorf("expected no images detected in code, got %v", urls)
+			}
+
+			processor.SetImageMap("https://example.com/a.png", "/tmp/veve-image-a.png")
+			if got := processor.RewriteMarkdownImageURLs(tt.content); got != tt.content {
+				t.Errorf("expected code to be left untouched, got:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestRewriteSkipsCodeButNotProse(t *testing.T) {
+	processor := converter.NewImageProcessor(t.TempDir())
+	processor.SetImageMap("https://example.com/a.png", "/tmp/veve-image-a.png")
+
+	content := "Syntax: `![alt](https://example.com/a.png)`\n\n![alt](https://example.com/a.png)\n"
+	expected := "Syntax: `![alt](https://example.com/a.png)`\n\n![alt](/tmp/veve-image-a.png)\n"
+
+	if got := processor.RewriteMarkdownImageURLs(content); got != expected {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, expected)
+	}
+}
+
+func TestProcessMarkdownDoesNotFetchCodeImages(t *testing.T) {
+	// An empty MemoryFetcher fails every request, so any attempted
+	// download would show up in the stats as a failure.
+	processor := converter.NewImageProcessor(t.TempDir()).
+		WithFetcher(converter.NewMemoryFetcher())
+	defer processor.Cleanup()
+
+	content := "# Markdown images\n\n```\n![alt](https://example.com/a.png)\n```\n\nInline: `![b](https://example.com/b.png)`\n"
+	processed, err := processor.ProcessMarkdown(content)
+	if err != nil {
+		t.Fatalf("ProcessMarkdown failed: %v", err)
+	}
+
+	if processed != content {
+		t.Errorf("expected content to be unchanged, got:\n%s", processed)
+	}
+	if _, _, total := processor.GetDownloadStats(); total != 0 {
+		t.Errorf("expected no download attempts, got %d", total)
+	}
+}
+
 // ============================================================================
 // T024: Transient Error Classification Unit Tests
 // ============================================================================
@@ -929,10 +1076,13 @@ func TestCleanupFileRemoval(t *testing.T) {
 	}
 
 	// Call cleanup
-	err := processor.Cleanup()
-	if err != nil {
+	report := processor.Cleanup()
+	if err := report.Err(); err != nil {
 		t.Errorf("Cleanup returned error: %v", err)
 	}
+	if len(report.Removed) != 3 {
+		t.Errorf("expected both files and the directory to be reported removed, got %v", report.Removed)
+	}
 
 	// Verify files are deleted
 	if _, err := os.Stat(file1Path); err == nil {
@@ -956,8 +1106,7 @@ func TestCleanupWithMissingFiles(t *testing.T) {
 	processor.SetImageMap("https://example.com/missing2.jpg", filepath.Join(tempDir, "missing2.jpg"))
 
 	// Cleanup should not error even though files don't exist
-	err := processor.Cleanup()
-	if err != nil {
+	if err := processor.Cleanup().Err(); err != nil {
 		t.Errorf("Cleanup should not error with missing files, got: %v", err)
 	}
 
@@ -1022,6 +1171,41 @@ func TestDiskSpaceTracking(t *testing.T) {
 	}
 }
 
+func TestReserveBytesConcurrent(t *testing.T) {
+	processor := converter.NewImageProcessor(t.TempDir())
+
+	// Ten concurrent 100MB downloads: only five fit in the 500MB session
+	var wg sync.WaitGroup
+	var reserved atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if processor.ReserveBytes(100*1024*1024) == nil {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := reserved.Load(); got != 5 {
+		t.Fatalf("expected 5 reservations to succeed, %d succeeded", got)
+	}
+	if err := processor.ValidateImageSize(1); err == nil {
+		t.Error("reserved bytes should count towards the session limit")
+	}
+
+	// A failed download hands its bytes back; a completed one keeps what it used
+	processor.ReleaseBytes(100 * 1024 * 1024)
+	processor.CommitBytes(100*1024*1024, 40*1024*1024)
+	if err := processor.ReserveBytes(160 * 1024 * 1024); err != nil {
+		t.Errorf("released and unused bytes should be available again: %v", err)
+	}
+	if err := processor.ReserveBytes(1); err == nil {
+		t.Error("expected the session to be full")
+	}
+}
+
 // ============================================================================
 // T043: Per-Image Size Validation Unit Tests
 // ============================================================================
@@ -1083,3 +1267,49 @@ func TestPerImageSizeValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateLocalImage(t *testing.T) {
+	dir := t.TempDir()
+	pngData, _ := testutil.CreateTestImageData("png")
+
+	files := map[string][]byte{
+		"cover.png":  pngData,
+		"logo.svg":   []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`),
+		"fake.png":   []byte("not an image"),
+		"notes.txt":  []byte("text"),
+		"photo.JPEG": pngData, // sniffed as an image, whatever the extension says
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir.png"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		shouldFail bool
+	}{
+		{"cover.png", false},
+		{"logo.svg", false},
+		{"photo.JPEG", false},
+		{"fake.png", true},
+		{"notes.txt", true},
+		{"missing.png", true},
+		{"dir.png", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			absPath, err := converter.ValidateLocalImage(filepath.Join(dir, tt.name))
+			if (err != nil) != tt.shouldFail {
+				t.Fatalf("ValidateLocalImage(%s) error = %v, shouldFail %v", tt.name, err, tt.shouldFail)
+			}
+			if err == nil && !filepath.IsAbs(absPath) {
+				t.Errorf("expected absolute path, got %s", absPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestImposeLaTeX(t *testing.T) {
+	tests := []struct {
+		name string
+		opts converter.ImposeOptions
+		want []string
+	}{
+		{"booklet", converter.ImposeOptions{Booklet: true}, []string{`\documentclass[a4paper]{article}`, `\includepdf[pages=-,booklet=true,landscape]{pages.pdf}`}},
+		{"two up", converter.ImposeOptions{NUp: 2, Paper: "letter"}, []string{`\documentclass[letterpaper]{article}`, `\includepdf[pages=-,nup=1x2,landscape]{pages.pdf}`}},
+		{"four up", converter.ImposeOptions{NUp: 4}, []string{`\includepdf[pages=-,nup=2x2]{pages.pdf}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := converter.ImposeLaTeX("pages.pdf", tt.opts)
+			for _, want := range tt.want {
+				if !strings.Contains(doc, want) {
+					t.Errorf("document missing %q:\n%s", want, doc)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateNUp(t *testing.T) {
+	for _, n := range []int{0, 2, 4} {
+		if err := converter.ValidateNUp(n); err != nil {
+			t.Errorf("ValidateNUp(%d) = %v, want nil", n, err)
+		}
+	}
+	for _, n := range []int{-1, 1, 3, 8} {
+		if err := converter.ValidateNUp(n); err == nil {
+			t.Errorf("ValidateNUp(%d) = nil, want an error", n)
+		}
+	}
+}
+
+func TestThemePaper(t *testing.T) {
+	tests := map[string]string{
+		"":                                    "a4",
+		"@page { size: letter; }":             "letter",
+		"@page { size: Legal; }":              "legal",
+		"@page { size: a5; }":                 "a4",
+		"body { size: letter; }":              "a4",
+		"@page { margin: 1in; size: letter }": "letter",
+	}
+	for css, want := range tests {
+		if got := converter.ThemePaper(css); got != want {
+			t.Errorf("ThemePaper(%q) = %q, want %q", css, got, want)
+		}
+	}
+}
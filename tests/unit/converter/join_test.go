@@ -0,0 +1,67 @@
+package converter_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestJoinArgs(t *testing.T) {
+	inputs := []string{"a.pdf", "b.pdf"}
+	if got, want := converter.JoinArgs("qpdf", inputs, "out.pdf"), []string{"--empty", "--pages", "a.pdf", "b.pdf", "--", "out.pdf"}; !slices.Equal(got, want) {
+		t.Errorf("qpdf args = %v, want %v", got, want)
+	}
+	if got, want := converter.JoinArgs("pdfunite", inputs, "out.pdf"), []string{"a.pdf", "b.pdf", "out.pdf"}; !slices.Equal(got, want) {
+		t.Errorf("pdfunite args = %v, want %v", got, want)
+	}
+}
+
+// TestJoinPDFs tests that the tool's output replaces the output file, that
+// qpdf's warnings exit code is a success, and that a failed join leaves the
+// output as it was.
+func TestJoinPDFs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh scripts")
+	}
+	dir := t.TempDir()
+	bin := t.TempDir()
+	var inputs []string
+	for _, name := range []string{"a.pdf", "b.pdf"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, path)
+	}
+	// Writes its inputs, in order, to the file after "--", then exits with $EXIT
+	qpdf := filepath.Join(bin, "qpdf")
+	script := "#!/bin/sh\nshift 2\nfiles=\"\"\nwhile [ \"$1\" != -- ]; do files=\"$files $1\"; shift; done\ncat $files > \"$2\"\nexit ${EXIT:-0}\n"
+	if err := os.WriteFile(qpdf, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "out", "joined.pdf")
+	t.Setenv("EXIT", "3")
+	if err := converter.JoinPDFs(inputs, output, qpdf); err != nil {
+		t.Fatalf("JoinPDFs failed: %v", err)
+	}
+	if got, _ := os.ReadFile(output); string(got) != "a.pdf\nb.pdf\n" {
+		t.Errorf("joined file = %q, want both inputs in order", got)
+	}
+
+	t.Setenv("EXIT", "2")
+	if err := converter.JoinPDFs(inputs[:1], output, qpdf); err == nil || !strings.Contains(err.Error(), "qpdf") {
+		t.Errorf("JoinPDFs error = %v, want qpdf's failure", err)
+	}
+	if got, _ := os.ReadFile(output); string(got) != "a.pdf\nb.pdf\n" {
+		t.Errorf("failed join changed the output to %q", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(output)); len(entries) != 1 {
+		t.Errorf("failed join left %d files in the output directory, want 1", len(entries))
+	}
+}
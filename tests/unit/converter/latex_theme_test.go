@@ -0,0 +1,165 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/themes"
+)
+
+func TestTranslateCSSToLaTeX(t *testing.T) {
+	css := `
+/* comment { ignored } */
+@page { size: A4; margin: 2cm 1.5cm; }
+body {
+  font-family: "Missing Font", "DejaVu Serif", serif;
+  font-size: 11pt;
+  line-height: 1.4;
+  color: #333;
+  background-color: #fff;
+}
+h1, h2 { color: rgb(44, 62, 80); font-weight: 600; }
+h1 { font-size: 24pt; border-bottom: 3px solid #3498db; }
+a { color: #3498db; }
+a:hover { text-decoration: underline; }
+code { font-family: "DejaVu Sans Mono", monospace; }
+@media print { body { color: black; } }
+`
+	installed := func(family string) bool { return strings.HasPrefix(family, "DejaVu") }
+	settings := converter.TranslateCSSToLaTeX(css, installed)
+
+	wantVars := map[string]string{
+		"papersize":   "a4",
+		"geometry":    "top=2cm,bottom=2cm,left=1.5cm,right=1.5cm",
+		"mainfont":    "DejaVu Serif",
+		"monofont":    "DejaVu Sans Mono",
+		"fontsize":    "11pt",
+		"linestretch": "1.4",
+		"colorlinks":  "true",
+		"linkcolor":   "veveLink",
+		"urlcolor":    "veveLink",
+	}
+	for name, want := range wantVars {
+		if got := settings.Variables[name]; got != want {
+			t.Errorf("variable %s = %q, want %q", name, got, want)
+		}
+	}
+
+	if len(settings.HeaderIncludes) != 1 {
+		t.Fatalf("expected one header include, got %d", len(settings.HeaderIncludes))
+	}
+	header := settings.HeaderIncludes[0]
+	for _, want := range []string{
+		`\definecolor{veveText}{HTML}{333333}`,
+		`\definecolor{veveLink}{HTML}{3498DB}`,
+		`\sectionfont{\color[HTML]{2C3E50}\bfseries\fontsize{24pt}{28.8pt}\selectfont}`,
+		`\subsectionfont{\color[HTML]{2C3E50}\bfseries}`,
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q:\n%s", want, header)
+		}
+	}
+	if strings.Contains(header, `\pagecolor`) {
+		t.Errorf("white background should not set a page color:\n%s", header)
+	}
+
+	for _, want := range []string{"h1 { border-bottom }", "a:hover { text-decoration }"} {
+		if !containsIgnored(settings.Ignored, want) {
+			t.Errorf("expected %q to be reported as ignored, got %v", want, settings.Ignored)
+		}
+	}
+}
+
+func TestTranslateCSSToLaTeXWithoutFontspec(t *testing.T) {
+	settings := converter.TranslateCSSToLaTeX(`body { font-family: "DejaVu Serif"; }`, nil)
+
+	if _, ok := settings.Variables["mainfont"]; ok {
+		t.Error("mainfont should not be set without a font lookup")
+	}
+	if !containsIgnored(settings.Ignored, "body { font-family }") {
+		t.Errorf("expected font-family to be ignored, got %v", settings.Ignored)
+	}
+}
+
+func TestTranslateCSSToLaTeXPagination(t *testing.T) {
+	css := `p { widows: 3; orphans: 1; } h2 { page-break-after: avoid; } hr { break-after: avoid; }`
+	settings := converter.TranslateCSSToLaTeX(css, nil)
+
+	if len(settings.HeaderIncludes) != 1 {
+		t.Fatalf("expected one header include, got %d: %v", len(settings.HeaderIncludes), settings.HeaderIncludes)
+	}
+	header := settings.HeaderIncludes[0]
+	for _, want := range []string{`\widowpenalty=10000`, `\clubpenalty=0`, `\needspace`} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q:\n%s", want, header)
+		}
+	}
+	if strings.Contains(header, `\usepackage{xcolor}`) {
+		t.Errorf("pagination alone should not load xcolor:\n%s", header)
+	}
+	if !containsIgnored(settings.Ignored, "hr { break-after }") {
+		t.Errorf("expected hr break-after to be ignored, got %v", settings.Ignored)
+	}
+	if containsIgnored(settings.Ignored, "h2 { page-break-after }") {
+		t.Errorf("h2 page-break-after should be translated, got %v", settings.Ignored)
+	}
+}
+
+func TestTranslateCSSToLaTeXTaskGlyphs(t *testing.T) {
+	css := `.task-done::before { content: "✔"; color: green; } .task-todo:before { content: '#'; }`
+	settings := converter.TranslateCSSToLaTeX(css, nil)
+
+	if len(settings.HeaderIncludes) != 1 {
+		t.Fatalf("expected one header include, got %d", len(settings.HeaderIncludes))
+	}
+	for _, want := range []string{`\newcommand{\vevetaskdone}{✔}`, `\newcommand{\vevetasktodo}{\#}`} {
+		if !strings.Contains(settings.HeaderIncludes[0], want) {
+			t.Errorf("header missing %q:\n%s", want, settings.HeaderIncludes[0])
+		}
+	}
+	if !containsIgnored(settings.Ignored, ".task-done::before { color }") {
+		t.Errorf("expected color to be ignored, got %v", settings.Ignored)
+	}
+}
+
+func TestTranslateCSSToLaTeXNotes(t *testing.T) {
+	settings := converter.TranslateCSSToLaTeX(`.footnotes { font-size: 8pt; color: #666; text-align: left; }`, nil)
+
+	if len(settings.HeaderIncludes) != 1 {
+		t.Fatalf("expected one header include, got %d", len(settings.HeaderIncludes))
+	}
+	for _, want := range []string{
+		`\newcommand{\vevenotestyle}{\fontsize{8pt}{9.6pt}\selectfont\color[HTML]{666666}}`,
+		`\renewcommand*{\footnotelayout}{\vevenotestyle}`,
+	} {
+		if !strings.Contains(settings.HeaderIncludes[0], want) {
+			t.Errorf("header missing %q:\n%s", want, settings.HeaderIncludes[0])
+		}
+	}
+	if !containsIgnored(settings.Ignored, ".footnotes { text-align }") {
+		t.Errorf("expected text-align to be ignored, got %v", settings.Ignored)
+	}
+}
+
+func TestTranslateBuiltInThemes(t *testing.T) {
+	for _, name := range themes.BuiltInThemes() {
+		css, ok := themes.GetBuiltInTheme(name)
+		if !ok {
+			t.Fatalf("built-in theme %s not found", name)
+		}
+		settings := converter.TranslateCSSToLaTeX(css, func(string) bool { return false })
+		if settings.Variables["geometry"] == "" {
+			t.Errorf("%s: expected body margin to be translated", name)
+		}
+	}
+}
+
+func containsIgnored(list []converter.IgnoredDeclaration, want string) bool {
+	for _, d := range list {
+		if d.String() == want {
+			return true
+		}
+	}
+	return false
+}
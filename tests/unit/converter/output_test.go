@@ -0,0 +1,64 @@
+package converter_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestStageOutputCommit(t *testing.T) {
+	dir := t.TempDir()
+	final := filepath.Join(dir, "out", "doc.pdf")
+
+	staged, err := converter.StageOutput(final)
+	if err != nil {
+		t.Fatalf("StageOutput failed: %v", err)
+	}
+	defer staged.Discard()
+	if filepath.Dir(staged.Path) != filepath.Dir(final) || !strings.HasSuffix(staged.Path, ".pdf") {
+		t.Errorf("staged path %q should be a .pdf beside %q", staged.Path, final)
+	}
+
+	if err := os.WriteFile(staged.Path, []byte("new"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := staged.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	data, err := os.ReadFile(final)
+	if err != nil || string(data) != "new" {
+		t.Errorf("output = %q, %v; want %q", data, err, "new")
+	}
+	if _, err := os.Stat(staged.Path); !os.IsNotExist(err) {
+		t.Errorf("staged file should be gone after Commit, got %v", err)
+	}
+}
+
+func TestStageOutputDiscardKeepsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	final := filepath.Join(dir, "doc.pdf")
+	if err := os.WriteFile(final, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	staged, err := converter.StageOutput(final)
+	if err != nil {
+		t.Fatalf("StageOutput failed: %v", err)
+	}
+	if err := os.WriteFile(staged.Path, []byte("trunc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	staged.Discard()
+
+	data, err := os.ReadFile(final)
+	if err != nil || string(data) != "previous" {
+		t.Errorf("output = %q, %v; want the previous output", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Discard left %d files, want only the output", len(entries))
+	}
+}
@@ -0,0 +1,52 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestPreparePageBreaks(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		breaks int
+	}{
+		{"latex command", "# One\n\n\\newpage\n\n# Two\n", 1},
+		{"html comment", "# One\n\n<!-- pagebreak -->\n\n# Two\n", 1},
+		{"html comment without spaces", "# One\n\n<!--PAGEBREAK-->\n# Two\n", 1},
+		{"fence marker", "# One\n\n---pagebreak---\n\n# Two\n", 1},
+		{"several markers", "a\n\n\\newpage\n\nb\n\n<!-- pagebreak -->\n\nc\n", 2},
+		{"marker at end without newline", "a\n\n\\newpage", 1},
+		{"marker inside paragraph", "some text\n\\newpage\nmore text\n", 0},
+		{"marker in code block", "```\n\\newpage\n<!-- pagebreak -->\n```\n", 0},
+		{"marker in indented code", "    \\newpage\n", 0},
+		{"marker in list", "- item\n\n  \\newpage\n", 0},
+		{"other comment", "<!-- note -->\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := converter.PreparePageBreaks(tt.input)
+			if n := strings.Count(got, "```{=latex}\n\\newpage\n```"); n != tt.breaks {
+				t.Errorf("expected %d LaTeX page break(s), got %d in %q", tt.breaks, n, got)
+			}
+			if n := strings.Count(got, `class="pagebreak"`); n != tt.breaks {
+				t.Errorf("expected %d HTML page break(s), got %d in %q", tt.breaks, n, got)
+			}
+			if tt.breaks == 0 && got != tt.input {
+				t.Errorf("expected content unchanged, got %q", got)
+			}
+		})
+	}
+}
+
+func TestPreparePageBreaksKeepsSurroundingContent(t *testing.T) {
+	input := "# One\n\n\\newpage\n\n# Two\n"
+	got := converter.PreparePageBreaks(input)
+
+	if !strings.HasPrefix(got, "# One\n\n```{=latex}") || !strings.HasSuffix(got, "```\n\n# Two\n") {
+		t.Errorf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,32 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestNeedsLaTeXPasses(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		variables map[string]string
+		want      bool
+	}{
+		{"plain document", "# Intro\n\nText.\n", nil, false},
+		{"toc in front matter", "---\ntitle: Report\ntoc: true\n---\n\n# Intro\n", nil, true},
+		{"toc turned off", "---\ntoc: false\n---\n\n# Intro\n", nil, false},
+		{"list of figures", "---\nlof: true\n---\n\n# Intro\n", nil, true},
+		{"toc variable", "# Intro\n", map[string]string{"toc": "true"}, true},
+		{"page reference", "See page \\pageref{sec:results}.\n", nil, true},
+		{"reference", "As Figure \\ref{fig:chart} shows.\n", nil, true},
+		{"other command", "A \\textbf{bold} \\reflection.\n", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := converter.NeedsLaTeXPasses(tt.content, tt.variables); got != tt.want {
+				t.Errorf("NeedsLaTeXPasses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestStripPrivateComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "own line",
+			content: "First paragraph\n<!-- veve:private ask legal -->\ncontinues.\n",
+			want:    "First paragraph\ncontinues.\n",
+		},
+		{
+			name:    "inline",
+			content: "Revenue grew 12%<!-- veve:private unaudited --> this year.\n",
+			want:    "Revenue grew 12% this year.\n",
+		},
+		{
+			name:    "multiline",
+			content: "# Plan\n\n<!-- veve:private\nTODO: confirm budget\nwith finance\n-->\n\nText\n",
+			want:    "# Plan\n\n\nText\n",
+		},
+		{
+			name:    "ordinary comments kept",
+			content: "<!-- pagebreak -->\n<!-- veve:privateer -->\n",
+			want:    "<!-- pagebreak -->\n<!-- veve:privateer -->\n",
+		},
+		{
+			name:    "code kept",
+			content: "```html\n<!-- veve:private example -->\n```\nUse `<!-- veve:private -->` to hide notes.\n",
+			want:    "```html\n<!-- veve:private example -->\n```\nUse `<!-- veve:private -->` to hide notes.\n",
+		},
+		{
+			name:    "unclosed",
+			content: "Public\n<!-- veve:private secret\nmore secret\n",
+			want:    "Public\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := converter.StripPrivateComments(tt.content, converter.DefaultPrivateMarker); got != tt.want {
+				t.Errorf("StripPrivateComments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripPrivateCommentsCustomMarker(t *testing.T) {
+	content := "Text <!-- internal: draft --> and <!-- veve:private kept -->\n"
+	want := "Text  and <!-- veve:private kept -->\n"
+	if got := converter.StripPrivateComments(content, "internal:"); got != want {
+		t.Errorf("StripPrivateComments() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package converter_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/madstone-tech/veve-cli/internal/engines"
+	"github.com/madstone-tech/veve-cli/internal/logging"
+)
+
+func TestRetryLaTeX(t *testing.T) {
+	latexFailure := errors.New("pandoc conversion failed: exit status 43\nPandoc stderr: Error producing PDF.\n! LaTeX Error: File `output.aux' is corrupt.")
+
+	t.Run("retries a LaTeX failure in a clean directory", func(t *testing.T) {
+		var tempDir string
+		var warnings []string
+		err := converter.RetryLaTeX(latexFailure, "xelatex", func(dir string) error {
+			tempDir = dir
+			entries, err := os.ReadDir(dir)
+			if err != nil || len(entries) != 0 {
+				t.Errorf("retry directory %q is not empty: %v %v", dir, entries, err)
+			}
+			return nil
+		}, func(category logging.Category, msg string) {
+			warnings = append(warnings, msg)
+		})
+		if err != nil {
+			t.Fatalf("expected the retry to succeed, got %v", err)
+		}
+		if tempDir == "" {
+			t.Fatal("expected a retry")
+		}
+		if _, err := os.Stat(tempDir); !os.IsNotExist(err) {
+			t.Errorf("retry directory %s was not removed", tempDir)
+		}
+		if len(warnings) != 1 {
+			t.Errorf("expected a warning about the retry, got %q", warnings)
+		}
+	})
+
+	t.Run("returns the retry's error", func(t *testing.T) {
+		retryErr := fmt.Errorf("failed again")
+		err := converter.RetryLaTeX(latexFailure, "lualatex", func(string) error { return retryErr }, nil)
+		if err != retryErr {
+			t.Errorf("got %v, want the retry's error", err)
+		}
+	})
+
+	skipped := []struct {
+		name   string
+		err    error
+		engine string
+	}{
+		{"success", nil, "xelatex"},
+		{"HTML engine", latexFailure, "weasyprint"},
+		{"not a LaTeX run failure", errors.New("pandoc conversion failed: Could not find data file"), "xelatex"},
+		{"missing packages", &engines.MissingLaTeXPackagesError{Files: []string{"mdframed.sty"}, Packages: []string{"mdframed"}, Err: latexFailure}, "xelatex"},
+	}
+	for _, tt := range skipped {
+		t.Run("does not retry "+tt.name, func(t *testing.T) {
+			err := converter.RetryLaTeX(tt.err, tt.engine, func(string) error {
+				t.Error("unexpected retry")
+				return nil
+			}, nil)
+			if err != tt.err {
+				t.Errorf("got %v, want the error unchanged", err)
+			}
+		})
+	}
+}
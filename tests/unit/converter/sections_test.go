@@ -0,0 +1,114 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestSplitSections(t *testing.T) {
+	content := strings.Join([]string{
+		"---\ntitle: Report\n---\n",
+		"# One\n\ntext\n\n```\n# not a heading\n```\n\n",
+		"## Two\n\n<!--\n# commented out\n-->\n\n~~~~\n# fenced\n~~~~\n",
+		"# Three\n\n#hashtag\n",
+	}, "")
+
+	sections := converter.SplitSections(content, 1)
+	if got := converter.JoinSections(sections); got != content {
+		t.Fatalf("sections don't join back to the content: %q", got)
+	}
+
+	var headings []string
+	for _, section := range sections {
+		headings = append(headings, strings.SplitN(section, "\n", 2)[0])
+	}
+	want := []string{"---", "# One", "## Two", "# Three"}
+	if strings.Join(headings, "|") != strings.Join(want, "|") {
+		t.Errorf("sections start with %q, want %q", headings, want)
+	}
+
+	if sections := converter.SplitSections(content, len(content)); len(sections) != 1 {
+		t.Errorf("content no larger than size split into %d sections", len(sections))
+	}
+}
+
+func TestSplitSectionsPreparesLikeWholeDocument(t *testing.T) {
+	var sb strings.Builder
+	for i := range 50 {
+		sb.WriteString("# Chapter\n\n![Chart](chart.png)\n\n<!-- pagebreak -->\n\n")
+		sb.WriteString("- [ ] open\n- [x] done\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n")
+		if i%2 == 0 {
+			sb.WriteString("```\n# code\n```\n\n")
+		}
+	}
+	content := sb.String()
+
+	prepare := func(s string) string {
+		s = converter.PrepareFigures(s)
+		s = converter.PreparePageBreaks(s)
+		s, _ = converter.PrepareTables(s)
+		s, _ = converter.PrepareTasks(s)
+		return s
+	}
+
+	sections := converter.SplitSections(content, 200)
+	if len(sections) < 2 {
+		t.Fatalf("expected several sections, got %d", len(sections))
+	}
+	for i := range sections {
+		sections[i] = prepare(sections[i])
+	}
+	if got, want := converter.JoinSections(sections), prepare(content); got != want {
+		t.Errorf("preparing sections differs from preparing the whole document:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestSplitSectionsCopiesReferenceDefinitions tests that a reference-style
+// image parses as an image in its section when its definition is in
+// another, and that joining the sections drops the copies.
+func TestSplitSectionsCopiesReferenceDefinitions(t *testing.T) {
+	content := strings.Join([]string{
+		"# Results\n\n![Quarterly revenue][q3]\n\n",
+		"# Sources\n\n[q3]: charts/q3.png \"Q3\"\n[^1]: A footnote.\n\n",
+		"# Appendix\n\n```\n[code]: not-a-definition.png\n```\n",
+	}, "")
+
+	sections := converter.SplitSections(content, 1)
+	if len(sections) != 3 {
+		t.Fatalf("expected 3 sections, got %d", len(sections))
+	}
+	if got := converter.JoinSections(sections); got != content {
+		t.Errorf("sections don't join back to the content: %q", got)
+	}
+
+	var destinations []string
+	source := []byte(sections[0])
+	doc := goldmark.New().Parser().Parse(text.NewReader(source))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if image, ok := n.(*ast.Image); ok && entering {
+			destinations = append(destinations, string(image.Destination))
+		}
+		return ast.WalkContinue, nil
+	})
+	if len(destinations) != 1 || destinations[0] != "charts/q3.png" {
+		t.Errorf("images in the first section = %q, want [charts/q3.png]:\n%s", destinations, sections[0])
+	}
+
+	// Footnotes and lines in code blocks aren't link reference definitions
+	for _, i := range []int{0, 2} {
+		if strings.Count(sections[i], `[q3]: charts/q3.png "Q3"`) != 1 || strings.Contains(sections[i], "[^1]") {
+			t.Errorf("section %d should have a copy of [q3]: and only that:\n%s", i, sections[i])
+		}
+	}
+	if strings.Contains(sections[0]+sections[1], "not-a-definition") {
+		t.Errorf("a line in a code block was copied:\n%s%s", sections[0], sections[1])
+	}
+	if strings.Contains(sections[1], "<!-- veve:references -->") {
+		t.Errorf("section defining the only reference has copies:\n%s", sections[1])
+	}
+}
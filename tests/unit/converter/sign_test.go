@@ -0,0 +1,103 @@
+package converter_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal"
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+// fakeSigner installs a script named tool on PATH that records its
+// arguments in the file it returns, then runs body. Bodies find the path to
+// write by the flag before it, so a changed argument order fails the test
+// rather than writing into the working directory.
+func fakeSigner(t *testing.T, tool, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > '" + argsFile + "'\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, tool), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return argsFile
+}
+
+// TestSign tests that gpg and minisign are run to write a detached signature
+// beside the output, with the key given.
+func TestSign(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh scripts")
+	}
+	output := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(output, []byte("%PDF-1.7"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tool, key, body, wantArgs string
+	}{
+		{
+			converter.SignerGPG, "release@example.com",
+			`while [ "$1" != --output ]; do shift; done; echo sig > "$2"`,
+			"--batch --yes --armor --detach-sign --output " + output + ".asc --local-user release@example.com " + output,
+		},
+		{
+			converter.SignerMinisign, "",
+			`while [ "$1" != -x ]; do shift; done; echo sig > "$2"`,
+			"-S -m " + output + " -x " + output + ".minisig",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			argsFile := fakeSigner(t, tt.tool, tt.body)
+			signature, err := converter.Sign(output, tt.tool, tt.key)
+			if err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+			if signature != converter.SignatureFile(output, tt.tool) {
+				t.Errorf("signature = %s, want %s", signature, converter.SignatureFile(output, tt.tool))
+			}
+			if _, err := os.Stat(signature); err != nil {
+				t.Errorf("signature not written: %v", err)
+			}
+			args, _ := os.ReadFile(argsFile)
+			if got := strings.TrimSpace(string(args)); got != tt.wantArgs {
+				t.Errorf("%s run with %q, want %q", tt.tool, got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+// TestSignFailure tests that a failing tool's message is reported, and a
+// missing tool is an engine error.
+func TestSignFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh scripts")
+	}
+	output := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(output, []byte("%PDF-1.7"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeSigner(t, converter.SignerGPG, `echo "gpg: skipped: No secret key" >&2; exit 2`)
+	_, err := converter.Sign(output, converter.SignerGPG, "")
+	if err == nil || !strings.Contains(err.Error(), "No secret key") {
+		t.Errorf("Sign() error = %v, want the tool's message", err)
+	}
+
+	_, err = converter.Sign(output, converter.SignerMinisign, "")
+	var veveErr *internal.VeveError
+	if !errors.As(err, &veveErr) || veveErr.ErrorCode() != "signer-not-found" {
+		t.Errorf("Sign() with no minisign = %v, want signer-not-found", err)
+	}
+
+	if _, err := converter.Sign(output, "ssh", ""); err == nil {
+		t.Error("expected an error for an unknown tool")
+	}
+}
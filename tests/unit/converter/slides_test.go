@@ -0,0 +1,79 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestTranslateCSSToBeamer(t *testing.T) {
+	css := `
+body { font-family: "Missing Font", "DejaVu Sans", sans-serif; color: #333; background-color: #fafafa; }
+h1 { color: #000; }
+h2 { color: rgb(44, 62, 80); }
+a { color: #3498db; }
+code { font-family: "DejaVu Sans Mono", monospace; }
+@media print { body { color: black; } }
+`
+	installed := func(family string) bool { return strings.HasPrefix(family, "DejaVu") }
+	settings := converter.TranslateCSSToBeamer(css, installed)
+
+	wantVars := map[string]string{
+		"mainfont": "DejaVu Sans",
+		"sansfont": "DejaVu Sans",
+		"monofont": "DejaVu Sans Mono",
+	}
+	for name, want := range wantVars {
+		if got := settings.Variables[name]; got != want {
+			t.Errorf("variable %s = %q, want %q", name, got, want)
+		}
+	}
+	if len(settings.Variables) != len(wantVars) {
+		t.Errorf("variables = %v, want only %v", settings.Variables, wantVars)
+	}
+
+	header := strings.Join(settings.HeaderIncludes, "\n")
+	for _, want := range []string{
+		`\definecolor{vevetext}{HTML}{333333}`,
+		`\definecolor{vevebackground}{HTML}{FAFAFA}`,
+		`\definecolor{veveheading}{HTML}{2C3E50}`, // h2 colors frame titles, not h1
+		`\definecolor{vevelink}{HTML}{3498DB}`,
+		`\setbeamercolor{normal text}{fg=vevetext}`,
+		`\setbeamercolor{background canvas}{bg=vevebackground}`,
+		`\setbeamercolor{frametitle}{fg=veveheading}`,
+		`urlcolor=vevelink`,
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header includes missing %q:\n%s", want, header)
+		}
+	}
+	if strings.Contains(header, "000000") {
+		t.Errorf("header includes use the h1 or @media color:\n%s", header)
+	}
+}
+
+func TestTranslateCSSToBeamerWithoutFontspec(t *testing.T) {
+	settings := converter.TranslateCSSToBeamer(`body { font-family: serif; }`, nil)
+	if len(settings.Variables) != 0 || len(settings.HeaderIncludes) != 0 {
+		t.Errorf("settings = %+v, want none without colors or a font check", settings)
+	}
+}
+
+func TestHandoutLayout(t *testing.T) {
+	layout := converter.HandoutLayout(3, "letter")
+	for _, want := range []string{
+		`\usepackage{pgfpages}`,
+		`logical pages=3`,
+		`\pgfpageslogicalpageoptions{3}{`,
+		`border code=\vevenotes`,
+		`\pgfpagesuselayout{veve handout}[letterpaper]`,
+	} {
+		if !strings.Contains(layout, want) {
+			t.Errorf("layout missing %q:\n%s", want, layout)
+		}
+	}
+	if strings.Contains(layout, `\pgfpageslogicalpageoptions{4}`) {
+		t.Errorf("layout has a fourth slide:\n%s", layout)
+	}
+}
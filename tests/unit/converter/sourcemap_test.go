@@ -0,0 +1,73 @@
+package converter_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestLocateError(t *testing.T) {
+	source := "---\ntitle: Report\n---\n\n# Intro\n\n![Chart](https://example.com/chart.png)\n\nSee \\foo here.\n\nThe end.\n"
+	// Remote image rewritten, and a figure caption line added before it
+	prepared := "---\ntitle: Report\n---\n\n# Intro\n\n: Chart\n![Chart](/tmp/veve-images/chart.png)\n\nSee \\foo here.\n\nThe end.\n"
+	const preparedFile = "/tmp/veve-run-1/processed-42.md"
+
+	tests := []struct {
+		name        string
+		stderr      string
+		wantLine    int
+		wantMessage string
+	}{
+		{
+			name:        "pandoc position",
+			stderr:      `Error parsing YAML metadata at "` + preparedFile + `" (line 2, column 1):` + "\nmapping values are not allowed",
+			wantLine:    2,
+			wantMessage: "Error parsing YAML metadata",
+		},
+		{
+			name:        "pandoc position on a rewritten line",
+			stderr:      `Error at "` + preparedFile + `" (line 8, column 3):` + "\nunexpected",
+			wantLine:    7,
+			wantMessage: "Error",
+		},
+		{
+			name:        "latex",
+			stderr:      "Error producing PDF.\n! Undefined control sequence.\nl.64 See \\foo\n",
+			wantLine:    9,
+			wantMessage: "Undefined control sequence.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cause := errors.New("exit status 43")
+			err := converter.LocateError(
+				errors.Join(errors.New("pandoc conversion failed\nPandoc stderr: "+tt.stderr), cause),
+				"report.md", preparedFile, source, prepared)
+
+			var located *converter.SourceError
+			if !errors.As(err, &located) {
+				t.Fatalf("LocateError() = %v, want a SourceError", err)
+			}
+			if located.File != "report.md" || located.Line != tt.wantLine || located.Message != tt.wantMessage {
+				t.Errorf("LocateError() = %s:%d %q, want report.md:%d %q",
+					located.File, located.Line, located.Message, tt.wantLine, tt.wantMessage)
+			}
+			if strings.Contains(err.Error(), preparedFile) {
+				t.Errorf("Error() names the prepared file:\n%s", err)
+			}
+			if !errors.Is(err, cause) {
+				t.Error("LocateError() doesn't wrap the conversion error")
+			}
+		})
+	}
+}
+
+func TestLocateErrorUnlocated(t *testing.T) {
+	err := errors.New("pandoc conversion failed: exit status 1")
+	if got := converter.LocateError(err, "report.md", "/tmp/processed-1.md", "text\n", "text\n"); got != err {
+		t.Errorf("LocateError() = %v, want the error unchanged", got)
+	}
+}
@@ -0,0 +1,65 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestPrepareTables(t *testing.T) {
+	narrow := "| a | b |\n|---|---|\n| 1 | 2 |\n"
+	wide := "| a | b | c | d | e | f |\n|---|---|---|---|---|---|\n| 1 | 2 | 3 | 4 | 5 | 6 |\n"
+	landscape := "| 1 | 2 | 3 | 4 | 5 | 6 | 7 | 8 | 9 |\n|---|---|---|---|---|---|---|---|---|\n"
+	long := "| a | b |\n|---|---|\n| " + strings.Repeat("x", 120) + " | y |\n"
+
+	tests := []struct {
+		name      string
+		input     string
+		found     bool
+		wrapped   bool
+		landscape bool
+	}{
+		{"no tables", "# Title\n\ntext\n", false, false, false},
+		{"narrow table", "intro\n\n" + narrow, true, false, false},
+		{"many columns", "intro\n\n" + wide, true, true, false},
+		{"long rows", long, true, true, false},
+		{"very wide", landscape, true, true, true},
+		{"table continuing a paragraph", "text\n" + wide, true, false, false},
+		{"table in code block", "```\n" + wide + "```\n", false, false, false},
+		{"table in block quote", "> " + strings.ReplaceAll(wide, "\n", "\n> "), true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := converter.PrepareTables(tt.input)
+			if found != tt.found {
+				t.Errorf("found = %v, want %v", found, tt.found)
+			}
+			if wrapped := strings.Contains(got, "::: {.wide-table"); wrapped != tt.wrapped {
+				t.Errorf("wrapped = %v, want %v:\n%s", wrapped, tt.wrapped, got)
+			}
+			if rotated := strings.Contains(got, `\begin{vevelandscape}`); rotated != tt.landscape {
+				t.Errorf("landscape = %v, want %v:\n%s", rotated, tt.landscape, got)
+			}
+			if !tt.wrapped && got != tt.input {
+				t.Errorf("content changed:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestPrepareTablesKeepsCaption(t *testing.T) {
+	input := "Table: Results\n\n| a | b | c | d | e | f |\n|---|---|---|---|---|---|\n| 1 | 2 | 3 | 4 | 5 | 6 |\n\nAfter.\n"
+	got, _ := converter.PrepareTables(input)
+
+	caption := strings.Index(got, "Table: Results")
+	open := strings.Index(got, "::: {.wide-table}")
+	closing := strings.LastIndex(got, ":::\n")
+	if open < 0 || caption < open || caption > closing {
+		t.Errorf("caption should be inside the wrapper:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "\\endgroup\n```\n\nAfter.\n") {
+		t.Errorf("text after the table should follow the wrapper:\n%s", got)
+	}
+}
@@ -0,0 +1,51 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+func TestPrepareTasks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		done  int
+		todo  int
+	}{
+		{"checked and unchecked", "- [x] shipped\n- [ ] pending\n- [X] also done\n", 2, 1},
+		{"ordered list", "1. [ ] first\n2. [x] second\n", 1, 1},
+		{"nested list", "- [ ] parent\n  - [x] child\n", 1, 1},
+		{"loose list", "- [ ] one\n\n- [ ] two\n", 0, 2},
+		{"plain list", "- item\n- [link](http://example.com)\n", 0, 0},
+		{"brackets mid-item", "- see [ ] here\n", 0, 0},
+		{"not a list", "[x] at paragraph start\n", 0, 0},
+		{"code block", "```\n- [x] code\n```\n", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := converter.PrepareTasks(tt.input)
+			done := strings.Count(got, "{.task .task-done}")
+			todo := strings.Count(got, "{.task .task-todo}")
+			if done != tt.done || todo != tt.todo {
+				t.Errorf("got %d done and %d todo boxes, want %d and %d:\n%s", done, todo, tt.done, tt.todo, got)
+			}
+			if found != (tt.done+tt.todo > 0) {
+				t.Errorf("found = %v for %d boxes", found, tt.done+tt.todo)
+			}
+			if !found && got != tt.input {
+				t.Errorf("content changed:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestPrepareTasksKeepsItemText(t *testing.T) {
+	got, _ := converter.PrepareTasks("- [x] Write **docs**\n")
+	want := "- `\\vevetaskdone{}`{=latex}[]{.task .task-done} Write **docs**\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
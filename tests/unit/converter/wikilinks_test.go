@@ -0,0 +1,86 @@
+package converter_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/converter"
+)
+
+// newTestVault creates a vault with notes/main.md, notes/Other Note.md,
+// archive/Other Note.md, and attachments/pic.png.
+func newTestVault(t *testing.T) string {
+	t.Helper()
+	vault := t.TempDir()
+	for _, file := range []string{
+		".obsidian/app.json", "notes/main.md", "notes/Other Note.md",
+		"archive/Other Note.md", "attachments/pic.png",
+	} {
+		path := filepath.Join(vault, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return vault
+}
+
+func TestWikiLinkResolver(t *testing.T) {
+	vault := newTestVault(t)
+	image := filepath.ToSlash(filepath.Join(vault, "attachments", "pic.png"))
+
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		warnings int
+	}{
+		{"note", "See [[Other Note]].", "See [Other Note](Other%20Note.pdf).", 0},
+		{"heading and alias", "[[Other Note#Set Up, Part 2|setup]]", "[setup](Other%20Note.pdf#set-up-part-2)", 0},
+		{"heading display", "[[Other Note#Intro]]", "[Other Note > Intro](Other%20Note.pdf#intro)", 0},
+		{"path", "[[archive/Other Note]]", "[Other Note](../archive/Other%20Note.pdf)", 0},
+		{"same document", "[[main#Usage]]", "[main > Usage](#usage)", 0},
+		{"local heading", "[[#2. Results]]", "[2. Results](#results)", 0},
+		{"unresolved", "[[Missing|gone]]", "gone", 1},
+		{"sized image", "![[pic.png|300x200]]", "![](<" + image + ">){width=300px height=200px}", 0},
+		{"width", "![[pic.png|300]]", "![](<" + image + ">){width=300px}", 0},
+		{"alt text", "![[pic.png|A cat]]", "![A cat](<" + image + ">)", 0},
+		{"note embed", "![[Other Note]]", "[Other Note](Other%20Note.pdf)", 1},
+		{"code span", "`[[Other Note]]`", "`[[Other Note]]`", 0},
+		{"code block", "```\n[[Other Note]]\n```\n", "```\n[[Other Note]]\n```\n", 0},
+	}
+
+	resolver, err := converter.NewWikiLinkResolver(vault, filepath.Join(vault, "notes", "main.md"))
+	if err != nil {
+		t.Fatalf("NewWikiLinkResolver failed: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := resolver.Prepare(tt.input)
+			if got != tt.want {
+				t.Errorf("Prepare(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("got warnings %q, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestWikiLinkResolverStdin(t *testing.T) {
+	vault := newTestVault(t)
+
+	resolver, err := converter.NewWikiLinkResolver(vault, "")
+	if err != nil {
+		t.Fatalf("NewWikiLinkResolver failed: %v", err)
+	}
+	// Links are relative to the vault root, and the shallowest match wins
+	got, _ := resolver.Prepare("[[Other Note]]")
+	if !strings.Contains(got, "(archive/Other%20Note.pdf)") {
+		t.Errorf("got %q, want a link to archive/Other Note.pdf", got)
+	}
+}
@@ -0,0 +1,46 @@
+package engines_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// TestUnicodeFidelity verifies distinct non-ASCII characters are counted
+func TestUnicodeFidelity(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		rendered     string
+		found, total int
+	}{
+		{"all rendered", "Café 世界 🎉", "Café 世界 🎉", 4, 4},
+		{"emoji dropped", "Café 🎉 🚀", "Café", 1, 3},
+		{"repeats counted once", "é é é", "", 0, 1},
+		{"ascii only", "plain text", "plain text", 0, 0},
+		// The variation selector and joiner are not characters of their own
+		{"joiners ignored", "❤️ 👨‍💻", "❤ 👨💻", 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, total := engines.UnicodeFidelity(tt.source, tt.rendered)
+			if found != tt.found || total != tt.total {
+				t.Errorf("UnicodeFidelity() = %d/%d, want %d/%d", found, total, tt.found, tt.total)
+			}
+		})
+	}
+}
+
+// TestBenchmarkDocument verifies the built-in document has content to measure
+func TestBenchmarkDocument(t *testing.T) {
+	doc := engines.BenchmarkDocument()
+	for _, want := range []string{"| Engine", "```go", "🎉", "世界", "∑"} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("BenchmarkDocument() is missing %q", want)
+		}
+	}
+	if _, total := engines.UnicodeFidelity(doc, ""); total < 40 {
+		t.Errorf("BenchmarkDocument() has %d unicode characters, want a range", total)
+	}
+}
@@ -0,0 +1,116 @@
+package engines_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// fakeEngines puts logging pandoc and weasyprint scripts alone on PATH and
+// returns the log of their invocations.
+func fakeEngines(t *testing.T) (dir, log string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script engines")
+	}
+	dir = t.TempDir()
+	log = filepath.Join(dir, "calls.log")
+	scripts := map[string]string{
+		"pandoc": `echo pandoc >> ` + log + `
+prev=""
+for a in "$@"; do
+  if [ "$prev" = "--output" ]; then echo "%PDF-1.4" > "$a"; fi
+  prev="$a"
+done`,
+		"weasyprint": `echo weasyprint >> ` + log + `
+echo "WeasyPrint version 60.0"`,
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	return dir, log
+}
+
+// calls returns the invocations recorded in log, and clears it.
+func calls(t *testing.T, log string) string {
+	data, _ := os.ReadFile(log)
+	os.Remove(log)
+	return strings.Join(strings.Fields(string(data)), " ")
+}
+
+// TestDetectionCache verifies detection results are reused until the engine
+// binary changes or a refresh is requested
+func TestDetectionCache(t *testing.T) {
+	dir, log := fakeEngines(t)
+	cacheFile := filepath.Join(t.TempDir(), "engines.json")
+	t.Cleanup(func() { engines.SetDetectionCache("", false) })
+
+	detect := func(refresh bool) {
+		t.Helper()
+		engines.SetDetectionCache(cacheFile, refresh)
+		selector, err := engines.NewEngineSelector()
+		if err != nil {
+			t.Fatalf("NewEngineSelector() error: %v", err)
+		}
+		engine, err := selector.SelectDefaultEngine()
+		if err != nil || engine.Name != "weasyprint" || engine.Version != "WeasyPrint version 60.0" {
+			t.Fatalf("SelectDefaultEngine() = %+v, %v", engine, err)
+		}
+	}
+
+	detect(false)
+	if got := calls(t, log); got != "weasyprint pandoc" {
+		t.Errorf("first run calls = %q, want version and unicode test", got)
+	}
+
+	detect(false)
+	if got := calls(t, log); got != "" {
+		t.Errorf("cached run calls = %q, want none", got)
+	}
+
+	detect(true)
+	if got := calls(t, log); got != "weasyprint pandoc" {
+		t.Errorf("refresh calls = %q, want version and unicode test", got)
+	}
+
+	// An upgraded engine is detected again
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "weasyprint"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	detect(false)
+	if got := calls(t, log); got != "weasyprint pandoc" {
+		t.Errorf("calls after engine change = %q, want version and unicode test", got)
+	}
+
+	// As is the unicode test when pandoc changes
+	if err := os.Chtimes(filepath.Join(dir, "pandoc"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	detect(false)
+	if got := calls(t, log); got != "pandoc" {
+		t.Errorf("calls after pandoc change = %q, want unicode test", got)
+	}
+}
+
+// TestDetectionCache_Disabled verifies nothing is cached without a cache file
+func TestDetectionCache_Disabled(t *testing.T) {
+	_, log := fakeEngines(t)
+	engines.SetDetectionCache("", false)
+
+	for i := 0; i < 2; i++ {
+		if _, err := engines.NewEngineSelector(); err != nil {
+			t.Fatalf("NewEngineSelector() error: %v", err)
+		}
+		if got := calls(t, log); got != "weasyprint pandoc" {
+			t.Errorf("run %d calls = %q, want version and unicode test", i+1, got)
+		}
+	}
+}
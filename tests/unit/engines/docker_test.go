@@ -0,0 +1,73 @@
+package engines_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// TestParseDockerEngine verifies docker engine names and the images they run
+func TestParseDockerEngine(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantImage string
+		wantOK    bool
+	}{
+		{"docker", engines.DefaultDockerImage, true},
+		{"docker:pandoc/extra:3.5", "pandoc/extra:3.5", true},
+		{"docker:", "", true},
+		{"xelatex", "", false},
+		{"dockerx", "", false},
+	}
+	for _, tt := range tests {
+		image, ok := engines.ParseDockerEngine(tt.name)
+		if image != tt.wantImage || ok != tt.wantOK {
+			t.Errorf("ParseDockerEngine(%q) = %q, %v; want %q, %v", tt.name, image, ok, tt.wantImage, tt.wantOK)
+		}
+	}
+}
+
+// TestDockerEngine verifies a docker engine runs xelatex in its image
+func TestDockerEngine(t *testing.T) {
+	engine := engines.DockerEngine("pandoc/latex:3.5")
+	if engine.Name != engines.DockerPDFEngine || engine.Image != "pandoc/latex:3.5" || !engine.IsInstalled {
+		t.Errorf("unexpected engine %+v", engine)
+	}
+	if !engines.IsLaTeXEngine(engine.Name) {
+		t.Errorf("expected a LaTeX engine, got %s", engine.Name)
+	}
+}
+
+// TestValidateDockerImage verifies image references are checked against
+// docker's grammar, so none can be passed to docker as an option
+func TestValidateDockerImage(t *testing.T) {
+	tests := []struct {
+		image   string
+		wantErr bool
+	}{
+		{"pandoc/latex:3.5", false},
+		{"pandoc/latex", false},
+		{"ubuntu", false},
+		{"ghcr.io/org/pandoc-tex:v1.2_3", false},
+		{"localhost:5000/pandoc/latex:3.5", false},
+		{"pandoc/latex@sha256:" + strings.Repeat("a", 64), false},
+		{"pandoc/latex:3.5@sha256:" + strings.Repeat("0", 64), false},
+		{"", true},
+		{"--privileged", true},
+		{"-v", true},
+		{"--volume=/:/host", true},
+		{"Pandoc/Latex", true},
+		{"pandoc/latex:", true},
+		{"pandoc latex", true},
+		{"pandoc/latex:3.5 --privileged", true},
+		{"pandoc//latex", true},
+		{"pandoc/latex:-3.5", true},
+	}
+	for _, tt := range tests {
+		err := engines.ValidateDockerImage(tt.image)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateDockerImage(%q) = %v, want error: %v", tt.image, err, tt.wantErr)
+		}
+	}
+}
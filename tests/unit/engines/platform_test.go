@@ -0,0 +1,90 @@
+package engines_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// TestInstallDirs_Windows verifies the default install folders are searched
+// and that only existing directories are returned, newest first
+func TestInstallDirs_Windows(t *testing.T) {
+	root := t.TempDir()
+	programFiles := filepath.Join(root, "Program Files")
+	localAppData := filepath.Join(root, "AppData", "Local")
+	dirs := []string{
+		filepath.Join(programFiles, "MiKTeX", "miktex", "bin", "x64"),
+		filepath.Join(root, "texlive", "2023", "bin", "windows"),
+		filepath.Join(root, "texlive", "2024", "bin", "windows"),
+		filepath.Join(programFiles, "wkhtmltopdf", "bin"),
+		filepath.Join(localAppData, "Programs", "Python", "Python312", "Scripts"),
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := map[string]string{
+		"ProgramFiles": programFiles,
+		"LOCALAPPDATA": localAppData,
+		"SystemDrive":  root,
+	}
+	getenv := func(key string) string { return env[key] }
+
+	tests := []struct {
+		engine string
+		want   []string
+	}{
+		{"xelatex", []string{dirs[0], filepath.Dir(dirs[0]), dirs[2], dirs[1]}},
+		{"wkhtmltopdf", []string{dirs[3]}},
+		{"weasyprint", []string{dirs[4]}},
+		{"prince", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			got := engines.InstallDirs(tt.engine, "windows", getenv)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InstallDirs(%s) = %v, want %v", tt.engine, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestInstallDirs_Unix verifies that only PATH is searched outside Windows
+func TestInstallDirs_Unix(t *testing.T) {
+	for _, goos := range []string{"linux", "darwin"} {
+		if dirs := engines.InstallDirs("xelatex", goos, os.Getenv); dirs != nil {
+			t.Errorf("InstallDirs on %s = %v, want none", goos, dirs)
+		}
+	}
+}
+
+// TestSearchEngineInDirs_Exe verifies Windows executables are found by name
+func TestSearchEngineInDirs_Exe(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "wkhtmltopdf.exe")
+	if err := os.WriteFile(exe, []byte{}, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := engines.SearchEngineInDirs("wkhtmltopdf", []string{filepath.Join(dir, "missing"), dir})
+	if err != nil || got != exe {
+		t.Errorf("SearchEngineInDirs() = %q, %v; want %q", got, err, exe)
+	}
+}
+
+// TestPDFEngineCommand verifies engines outside PATH run by full path
+func TestPDFEngineCommand(t *testing.T) {
+	engine := engines.PDFEngine{Name: "xelatex"}
+	if got := engine.Command(); got != "xelatex" {
+		t.Errorf("Command() = %q, want xelatex", got)
+	}
+	engine.Path = `C:\Program Files\MiKTeX\miktex\bin\x64\xelatex.exe`
+	if got := engine.Command(); got != engine.Path {
+		t.Errorf("Command() = %q, want %q", got, engine.Path)
+	}
+}
@@ -0,0 +1,109 @@
+package engines_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// TestLaTeXPackageFor tests mapping files to the packages providing them.
+func TestLaTeXPackageFor(t *testing.T) {
+	tests := map[string]string{
+		"fontspec.sty":  "fontspec",
+		"longtable.sty": "tools",
+		"xeCJK.sty":     "xecjk",
+		"tikz.sty":      "pgf",
+		"mdframed.sty":  "mdframed",
+	}
+	for file, want := range tests {
+		if got := engines.LaTeXPackageFor(file); got != want {
+			t.Errorf("LaTeXPackageFor(%q) = %q, want %q", file, got, want)
+		}
+	}
+
+	files := []string{"fontspec.sty", "scrartcl.cls", "scrbook.cls", "ec-lmr10.tfm"}
+	if got, want := engines.LaTeXPackages(files), []string{"fontspec", "koma-script", "lm"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LaTeXPackages = %v, want %v", got, want)
+	}
+}
+
+// TestMissingLaTeXPackagesError tests the install command for each distribution.
+func TestMissingLaTeXPackagesError(t *testing.T) {
+	missing := &engines.MissingLaTeXPackagesError{Files: []string{"a.sty", "b.sty"}, Packages: []string{"a", "b"}, Distribution: engines.TeXLive}
+	if got := missing.InstallCommand(); got != "tlmgr install a b" {
+		t.Errorf("TeX Live InstallCommand = %q", got)
+	}
+	if msg := missing.Error(); !strings.HasPrefix(msg, "LaTeX is missing a.sty, b.sty (packages a, b)") || strings.Contains(msg, "apt-file") {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	missing.Distribution = engines.MiKTeX
+	if got := missing.InstallCommand(); got != "mpm --install=a --install=b" {
+		t.Errorf("MiKTeX InstallCommand = %q", got)
+	}
+
+	missing.Distribution = ""
+	missing.Engine = "xelatex"
+	msg := missing.Error()
+	if !strings.HasPrefix(msg, "xelatex is installed, but LaTeX is missing") || !strings.Contains(msg, "tlmgr install a b") || !strings.Contains(msg, "apt-file search a.sty") {
+		t.Errorf("unexpected message without a distribution %q", msg)
+	}
+}
+
+// writeTool writes a shell script named name into dir.
+func writeTool(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestProbeLaTeXPackages tests finding the required files missing from the
+// TeX installation next to the engine.
+func TestProbeLaTeXPackages(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as TeX tools")
+	}
+	dir := t.TempDir()
+	engine := writeTool(t, dir, "xelatex", "")
+	writeTool(t, dir, "tlmgr", "")
+	// A kpsewhich that finds everything but fontspec and geometry
+	writeTool(t, dir, "kpsewhich", `for f in "$@"; do
+  case "$f" in fontspec.sty|geometry.sty) ;; *) echo "/texmf/tex/latex/$f" ;; esac
+done
+exit 1
+`)
+
+	missing := engines.ProbeLaTeXPackages(engines.PDFEngine{Name: "xelatex"}, engine)
+	if missing == nil {
+		t.Fatal("expected missing packages")
+	}
+	if want := []string{"fontspec.sty", "geometry.sty"}; !reflect.DeepEqual(missing.Files, want) {
+		t.Errorf("Files = %v, want %v", missing.Files, want)
+	}
+	if missing.Distribution != engines.TeXLive || missing.InstallCommand() != "tlmgr install fontspec geometry" {
+		t.Errorf("install command = %q (%s)", missing.InstallCommand(), missing.Distribution)
+	}
+	if missing.Engine != "xelatex" {
+		t.Errorf("Engine = %q", missing.Engine)
+	}
+
+	// A complete installation
+	writeTool(t, dir, "kpsewhich", `for f in "$@"; do echo "/texmf/$f"; done`)
+	if missing := engines.ProbeLaTeXPackages(engines.PDFEngine{Name: "xelatex"}, engine); missing != nil {
+		t.Errorf("unexpected missing packages %v", missing.Files)
+	}
+
+	// A kpsewhich that finds nothing, not even LaTeX itself, can't tell
+	writeTool(t, dir, "kpsewhich", "exit 1\n")
+	if missing := engines.ProbeLaTeXPackages(engines.PDFEngine{Name: "xelatex"}, engine); missing != nil {
+		t.Errorf("unexpected missing packages %v from a broken kpsewhich", missing.Files)
+	}
+}
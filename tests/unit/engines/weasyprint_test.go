@@ -0,0 +1,128 @@
+package engines_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/engines"
+)
+
+// TestDiagnoseWeasyPrintOutput tests recognizing broken Python environments
+// in WeasyPrint's output.
+func TestDiagnoseWeasyPrintOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantProblem string
+		wantDetail  string
+	}{
+		{
+			name:        "missing dependency",
+			output:      "Traceback (most recent call last):\n  File \"/venv/bin/weasyprint\", line 5\nModuleNotFoundError: No module named 'pydyf'\n",
+			wantProblem: engines.WeasyPrintMissingModule,
+			wantDetail:  "pydyf",
+		},
+		{
+			name:        "mismatched versions",
+			output:      "ImportError: cannot import name 'Stream' from 'pydyf' (/venv/lib/pydyf/__init__.py)\n",
+			wantProblem: engines.WeasyPrintMissingModule,
+			wantDetail:  "pydyf",
+		},
+		{
+			name:        "missing Pango",
+			output:      "OSError: cannot load library 'libpango-1.0-0': libpango-1.0-0: cannot open shared object file\n",
+			wantProblem: engines.WeasyPrintMissingLibrary,
+			wantDetail:  "libpango-1.0-0",
+		},
+		{
+			name:        "deleted virtual environment",
+			output:      "/bin/sh: /home/me/.venv/bin/weasyprint: /home/me/.venv/bin/python3: bad interpreter: No such file or directory\n",
+			wantProblem: engines.WeasyPrintBrokenInterpreter,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diagnosis := engines.DiagnoseWeasyPrintOutput("/venv/bin/weasyprint", test.output)
+			if diagnosis == nil {
+				t.Fatal("expected a diagnosis")
+			}
+			if diagnosis.Problem != test.wantProblem || diagnosis.Detail != test.wantDetail {
+				t.Errorf("diagnosis = %s %q, want %s %q", diagnosis.Problem, diagnosis.Detail, test.wantProblem, test.wantDetail)
+			}
+		})
+	}
+
+	if diagnosis := engines.DiagnoseWeasyPrintOutput("/venv/bin/weasyprint", "ERROR: Failed to load image at 'x.png'"); diagnosis != nil {
+		t.Errorf("unexpected diagnosis %v for a document error", diagnosis)
+	}
+}
+
+// TestDiagnoseWeasyPrint tests telling a missing WeasyPrint from a broken one.
+func TestDiagnoseWeasyPrint(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as Python commands")
+	}
+
+	t.Run("not installed", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		if got := engines.DiagnoseWeasyPrint(); got == nil || got.Problem != engines.WeasyPrintNotInstalled {
+			t.Errorf("DiagnoseWeasyPrint = %v, want not installed", got)
+		}
+	})
+
+	t.Run("module without command", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTool(t, dir, "python3", "exit 0\n")
+		t.Setenv("PATH", dir)
+		got := engines.DiagnoseWeasyPrint()
+		if got == nil || got.Problem != engines.WeasyPrintNotOnPath {
+			t.Fatalf("DiagnoseWeasyPrint = %v, want not on PATH", got)
+		}
+		if !strings.Contains(got.Error(), "not on PATH") {
+			t.Errorf("unexpected message %q", got.Error())
+		}
+	})
+
+	t.Run("deleted interpreter", func(t *testing.T) {
+		dir := t.TempDir()
+		// A script whose Python doesn't exist
+		script := "#!/gone/venv/bin/python3\nimport weasyprint\n"
+		if err := os.WriteFile(filepath.Join(dir, "weasyprint"), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PATH", dir)
+		got := engines.DiagnoseWeasyPrint()
+		if got == nil || got.Problem != engines.WeasyPrintBrokenInterpreter || got.Interpreter != "/gone/venv/bin/python3" {
+			t.Fatalf("DiagnoseWeasyPrint = %+v, want a broken interpreter", got)
+		}
+		var err error = got
+		var diagnosis *engines.WeasyPrintError
+		if !errors.As(err, &diagnosis) || !strings.Contains(err.Error(), "pipx reinstall weasyprint") {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+	})
+
+	t.Run("missing module", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTool(t, dir, "weasyprint", "echo \"ModuleNotFoundError: No module named 'cffi'\" >&2\nexit 1\n")
+		t.Setenv("PATH", dir)
+		got := engines.DiagnoseWeasyPrint()
+		if got == nil || got.Problem != engines.WeasyPrintMissingModule || got.Detail != "cffi" {
+			t.Errorf("DiagnoseWeasyPrint = %+v, want a missing module", got)
+		}
+	})
+
+	t.Run("working", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTool(t, dir, "weasyprint", "echo 'WeasyPrint version 62.3'\n")
+		t.Setenv("PATH", dir)
+		if got := engines.DiagnoseWeasyPrint(); got != nil {
+			t.Errorf("DiagnoseWeasyPrint = %v, want nil", got)
+		}
+	})
+}
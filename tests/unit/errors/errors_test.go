@@ -0,0 +1,90 @@
+package errors_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want internal.Kind
+	}{
+		{"plain", errors.New("boom"), internal.KindGeneral},
+		{"veve error", internal.ThemeNotFound("veve", "report", "default"), internal.KindTheme},
+		{"wrapped", fmt.Errorf("converting: %w", internal.InputFileNotFound("veve", "a.md")), internal.KindInput},
+		{"too many remote images", internal.TooManyRemoteImages("convert", "a.md", 5, 3), internal.KindInput},
+		{"with kind", internal.WithKind(errors.New("timeout"), internal.KindNetwork), internal.KindNetwork},
+		{"general veve error", internal.NewVeveError("veve", "run", "failed", "", internal.ConfigLoadFailed("veve.toml", errors.New("bad"))), internal.KindConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := internal.KindOf(tt.err); got != tt.want {
+				t.Errorf("KindOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithKind(t *testing.T) {
+	if internal.WithKind(nil, internal.KindInput) != nil {
+		t.Error("WithKind(nil) should be nil")
+	}
+
+	base := errors.New("no such file")
+	err := internal.WithKind(base, internal.KindInput)
+	if err.Error() != base.Error() {
+		t.Errorf("message changed: %q", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("expected the error to wrap its cause")
+	}
+
+	// An error that already has a kind keeps it
+	theme := internal.ThemeNotFound("veve", "x", "default")
+	if got := internal.KindOf(internal.WithKind(theme, internal.KindPandoc)); got != internal.KindTheme {
+		t.Errorf("kind = %q, want theme", got)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	codes := map[internal.Kind]int{
+		internal.KindGeneral: internal.ExitError,
+		internal.KindUsage:   internal.ExitUsage,
+		internal.KindInput:   internal.ExitInput,
+		internal.KindTheme:   internal.ExitTheme,
+		internal.KindEngine:  internal.ExitEngine,
+		internal.KindNetwork: internal.ExitNetwork,
+		internal.KindPandoc:  internal.ExitPandoc,
+		internal.KindConfig:  internal.ExitConfig,
+	}
+	seen := map[int]bool{}
+	for kind, want := range codes {
+		if got := kind.ExitCode(); got != want {
+			t.Errorf("%s.ExitCode() = %d, want %d", kind, got, want)
+		}
+		if seen[want] {
+			t.Errorf("exit code %d is shared", want)
+		}
+		seen[want] = true
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := internal.Report(fmt.Errorf("wrapped: %w", internal.PandocNotFound()))
+	if report.Category != internal.KindPandoc || report.Code != "pandoc-not-found" {
+		t.Errorf("report = %+v", report)
+	}
+	if report.Suggestion == "" || report.Action == "" {
+		t.Errorf("expected the VeveError's fields: %+v", report)
+	}
+
+	report = internal.Report(internal.WithKind(errors.New("HTTP 503"), internal.KindNetwork))
+	if report.Category != internal.KindNetwork || report.Code != "network" || report.Message != "HTTP 503" {
+		t.Errorf("report = %+v", report)
+	}
+}
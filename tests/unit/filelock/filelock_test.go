@@ -0,0 +1,90 @@
+package filelock_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/filelock"
+)
+
+func TestAcquireExcludes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "entry.lock")
+
+	lock, err := filelock.Acquire(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filelock.Acquire(path, 100*time.Millisecond); err == nil {
+		t.Fatal("expected a second lock to time out")
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := filelock.Acquire(path, time.Second)
+	if err != nil {
+		t.Fatalf("expected the lock to be free after Unlock: %v", err)
+	}
+	again.Unlock()
+}
+
+// TestAcquireSerializes runs read-modify-write cycles concurrently; without
+// mutual exclusion increments would be lost.
+func TestAcquireSerializes(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "counter")
+	if err := os.WriteFile(counter, []byte{0}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := filelock.Acquire(filepath.Join(dir, "counter.lock"), 10*time.Second)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer lock.Unlock()
+			data, err := os.ReadFile(counter)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			time.Sleep(time.Millisecond)
+			if err := filelock.WriteFile(counter, []byte{data[0] + 1}, 0o644); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != 20 {
+		t.Errorf("counter = %d, want 20", data[0])
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "theme.css")
+	if err := filelock.WriteFile(path, []byte("body {}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "body {}" {
+		t.Fatalf("unexpected content %q (%v)", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files to remain, got %d entries", len(entries))
+	}
+}
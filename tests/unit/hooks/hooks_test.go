@@ -0,0 +1,65 @@
+package hooks_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/hooks"
+)
+
+// TestRunEnvironment verifies hooks run in the project root with the
+// conversion in their environment
+func TestRunEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	root := t.TempDir()
+	conversion := hooks.Conversion{
+		Input:   filepath.Join(root, "doc.md"),
+		Output:  filepath.Join(root, "doc.pdf"),
+		Theme:   "academic",
+		Engine:  "weasyprint",
+		Project: root,
+	}
+
+	var stdout, stderr bytes.Buffer
+	commands := []string{
+		`echo "$VEVE_HOOK $VEVE_INPUT $VEVE_OUTPUT $VEVE_THEME $VEVE_ENGINE $VEVE_PROJECT_ROOT"`,
+		`pwd`,
+	}
+	if err := hooks.Run(hooks.PostConvert, commands, conversion, &stdout, &stderr); err != nil {
+		t.Fatalf("Run() error: %v (stderr: %s)", err, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	want := strings.Join([]string{"post-convert", conversion.Input, conversion.Output, "academic", "weasyprint", root}, " ")
+	if len(lines) != 2 || lines[0] != want {
+		t.Fatalf("hook output = %q, want %q first", lines, want)
+	}
+	if resolved, _ := filepath.EvalSymlinks(root); lines[1] != root && lines[1] != resolved {
+		t.Errorf("hook ran in %q, want %q", lines[1], root)
+	}
+}
+
+// TestRunStopsAtFailure verifies a failing command stops the remaining hooks
+func TestRunStopsAtFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+
+	var stdout bytes.Buffer
+	commands := []string{"echo first", "exit 3", "echo never"}
+	err := hooks.Run(hooks.PreConvert, commands, hooks.Conversion{Project: t.TempDir()}, &stdout, &stdout)
+
+	var hookErr *hooks.Error
+	if !errors.As(err, &hookErr) || hookErr.Command != "exit 3" || hookErr.Stage != hooks.PreConvert {
+		t.Fatalf("Run() error = %v, want hook error for 'exit 3'", err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "first" {
+		t.Errorf("hook output = %q, want only the first command's", got)
+	}
+}
@@ -0,0 +1,77 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/lint"
+)
+
+func TestHeadingIncrement(t *testing.T) {
+	content := "# Title\n\n## Section\n\n#### Too deep\n\n## Back up\n"
+
+	issues := lint.Lint(content, nil)
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %v", issues)
+	}
+	if issues[0].Rule != "heading-increment" || issues[0].Line != 5 {
+		t.Errorf("unexpected issue %v", issues[0])
+	}
+}
+
+func TestTrailingSpaces(t *testing.T) {
+	content := "one \nhard break  \nthree   \n\n```\ncode   \n```\n"
+
+	issues := lint.Lint(content, nil)
+	var lines []int
+	for _, issue := range issues {
+		if issue.Rule == "trailing-spaces" {
+			lines = append(lines, issue.Line)
+		}
+	}
+	if len(lines) != 2 || lines[0] != 1 || lines[1] != 3 {
+		t.Errorf("expected trailing spaces on lines 1 and 3, got %v", issues)
+	}
+	if issues[0].Column != 4 {
+		t.Errorf("Column = %d, want 4", issues[0].Column)
+	}
+}
+
+func TestBareURLs(t *testing.T) {
+	content := "Visit https://example.com/docs.\n\n" +
+		"Linked: [docs](https://example.com), <https://example.com> and `https://example.com`.\n"
+
+	issues := lint.Lint(content, nil)
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %v", issues)
+	}
+	want := lint.Issue{
+		Rule:    "bare-urls",
+		Line:    1,
+		Column:  7,
+		Message: "bare URL https://example.com/docs is not a link; write <https://example.com/docs>",
+	}
+	if issues[0] != want {
+		t.Errorf("got %+v, want %+v", issues[0], want)
+	}
+}
+
+func TestDisabledRules(t *testing.T) {
+	content := "# Title\n\n### Deep \n\nhttps://example.com\n"
+
+	if issues := lint.Lint(content, nil); len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %v", issues)
+	}
+	issues := lint.Lint(content, []string{"bare-urls", "trailing-spaces"})
+	if len(issues) != 1 || issues[0].Rule != "heading-increment" {
+		t.Errorf("expected only heading-increment, got %v", issues)
+	}
+}
+
+func TestValidateRuleNames(t *testing.T) {
+	if err := lint.ValidateRuleNames([]string{"bare-urls"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := lint.ValidateRuleNames([]string{"no-such-rule"}); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
@@ -0,0 +1,68 @@
+package logging_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/logging"
+)
+
+func TestWarningsCollected(t *testing.T) {
+	logger := logging.NewLogger(false, false)
+	logger.WarnIn(logging.CategoryImages, "Failed to download %s", "a.png")
+	logger.Warn("general  ")
+	logger.WarnIn(logging.CategoryImages, "Failed to download %s", "a.png")
+
+	got := logger.Warnings()
+	want := []logging.Warning{
+		{Category: logging.CategoryImages, Message: "Failed to download a.png"},
+		{Category: logging.CategoryGeneral, Message: "general"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Warnings() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Warnings()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	logger := logging.NewLogger(false, false)
+	var out bytes.Buffer
+	logger.WriteSummary(&out)
+	if out.Len() != 0 {
+		t.Errorf("expected no summary without warnings, got %q", out.String())
+	}
+
+	logger.WarnIn(logging.CategoryTheme, "rule ignored")
+	logger.WarnIn(logging.CategoryImages, "a.png failed")
+	logger.WarnIn(logging.CategoryTheme, "line one\nline two")
+	logger.WriteSummary(&out)
+	want := `
+3 warning(s):
+  theme:
+    - rule ignored
+    - line one
+      line two
+  images:
+    - a.png failed
+`
+	if out.String() != want {
+		t.Errorf("summary = %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteSummaryQuiet(t *testing.T) {
+	logger := logging.NewLogger(true, false)
+	logger.Warn("ignored")
+	var out bytes.Buffer
+	logger.WriteSummary(&out)
+	if out.Len() != 0 {
+		t.Errorf("expected a quiet logger to write no summary, got %q", out.String())
+	}
+	if len(logger.Warnings()) != 1 {
+		t.Error("expected a quiet logger to still collect warnings")
+	}
+}
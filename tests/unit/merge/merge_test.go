@@ -0,0 +1,128 @@
+package merge_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/merge"
+)
+
+func writeData(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{"yaml list", "data.yaml", "- name: Ann\n- name: Bob\n", 2, false},
+		{"json list", "data.json", `[{"name": "Ann"}, {"name": "Bob"}, {"name": "Cy"}]`, 3, false},
+		{"single mapping", "data.yaml", "name: Ann\n", 1, false},
+		{"empty list", "data.yaml", "[]\n", 0, true},
+		{"empty file", "data.yaml", "", 0, true},
+		{"scalars", "data.yaml", "- Ann\n- Bob\n", 0, true},
+		{"scalar", "data.yaml", "Ann\n", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := merge.LoadRecords(writeData(t, tt.file, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadRecords() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(records) != tt.want {
+				t.Errorf("LoadRecords() returned %d records, want %d", len(records), tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	records, err := merge.LoadRecords(writeData(t, "invoices.yaml", `
+- number: INV-1
+  client: acme
+  items:
+    - {qty: 2, price: 1500}
+    - {qty: 1, price: "250.5"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := merge.Parse("invoice.md",
+		`# {{.number}} for {{upper .client}}{{range .items}}
+- {{money (mul .qty .price)}}{{end}}
+Total: {{money (sum .items "qty" "price")}} ({{default "none" .note | printf "%v"}})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = merge.Render(tmpl, records[0], 1)
+	if err == nil || !strings.Contains(err.Error(), "record 1") {
+		t.Fatalf("Render() with a missing field: error = %v, want a record 1 error", err)
+	}
+
+	records[0]["note"] = ""
+	got, err := merge.Render(tmpl, records[0], 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# INV-1 for ACME\n- 3,000.00\n- 250.50\nTotal: 3,250.50 (none)"
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFuncsErrors(t *testing.T) {
+	tests := []string{
+		`{{money "abc"}}`,
+		`{{sum .items}}`,
+		`{{sum .name "qty"}}`,
+		`{{add 1 .name}}`,
+	}
+	record := merge.Record{"name": "Ann", "items": []any{map[string]any{"qty": 1}}}
+	for _, text := range tests {
+		tmpl, err := merge.Parse("t.md", text)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", text, err)
+		}
+		if _, err := merge.Render(tmpl, record, 1); err == nil {
+			t.Errorf("Render(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := merge.Parse("t.md", "{{.name"); err == nil {
+		t.Error("Parse() of an unclosed action succeeded, want an error")
+	}
+}
+
+func TestStripFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"front matter", "---\ntitle: A\n---\n\n# Body\n", "\n# Body\n"},
+		{"dots", "---\ntitle: A\n...\nBody\n", "Body\n"},
+		{"none", "# Body\n---\n", "# Body\n---\n"},
+		{"unclosed", "---\ntitle: A\n", "---\ntitle: A\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := merge.StripFrontMatter(tt.content); got != tt.want {
+				t.Errorf("StripFrontMatter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
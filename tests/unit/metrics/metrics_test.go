@@ -0,0 +1,118 @@
+package metrics_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madstone-tech/veve-cli/internal/metrics"
+)
+
+// TestAppendRead verifies records round-trip through the log
+func TestAppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "veve", metrics.FileName)
+
+	records, err := metrics.Read(path)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("Read() of missing log = %v, %v; want no records", records, err)
+	}
+
+	want := metrics.Record{
+		Time:         time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Input:        "/docs/guide.md",
+		Engine:       "weasyprint",
+		Duration:     1500 * time.Millisecond,
+		InputBytes:   2048,
+		Images:       3,
+		RemoteImages: 1,
+	}
+	if err := metrics.Append(path, want); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if err := metrics.Append(path, metrics.Record{Input: "/docs/other.md", Error: "failed"}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	records, err = metrics.Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(records) != 2 || records[0] != want || records[1].Error != "failed" {
+		t.Errorf("Read() = %+v", records)
+	}
+}
+
+// TestReadSkipsBadLines verifies unparseable lines don't hide the others
+func TestReadSkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), metrics.FileName)
+	content := `{"input":"a.md","duration_ns":1}
+not json
+{"input":"b.md","duration_ns":2}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := metrics.Read(path)
+	if err != nil || len(records) != 2 || records[1].Input != "b.md" {
+		t.Errorf("Read() = %+v, %v; want a.md and b.md", records, err)
+	}
+}
+
+// TestAppendConcurrent verifies parallel conversions don't interleave lines
+func TestAppendConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), metrics.FileName)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := metrics.Append(path, metrics.Record{Input: "doc.md", Duration: time.Second}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	records, err := metrics.Read(path)
+	if err != nil || len(records) != 20 {
+		t.Errorf("Read() = %d records, %v; want 20", len(records), err)
+	}
+}
+
+// TestSummarize verifies grouping, failure counts, and percentiles
+func TestSummarize(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.Local) }
+	records := []metrics.Record{
+		{Time: day(2), Engine: "xelatex", Duration: 3 * time.Second, InputBytes: 100, Images: 1},
+		{Time: day(1), Engine: "weasyprint", Duration: 1 * time.Second, InputBytes: 100},
+		{Time: day(1), Engine: "weasyprint", Duration: 2 * time.Second, InputBytes: 100, Images: 2},
+		{Time: day(1), Engine: "weasyprint", Duration: 9 * time.Second, InputBytes: 100},
+		{Time: day(1), Engine: "weasyprint", Error: "failed", InputBytes: 100},
+	}
+
+	byDay := metrics.Summarize(records, metrics.ByDay)
+	if len(byDay) != 2 || byDay[0].Group != "2025-03-01" || byDay[1].Group != "2025-03-02" {
+		t.Fatalf("Summarize(ByDay) groups = %+v", byDay)
+	}
+	first := byDay[0]
+	if first.Conversions != 4 || first.Failed != 1 || first.InputBytes != 400 || first.Images != 2 {
+		t.Errorf("first day = %+v", first)
+	}
+	if first.Median != 2*time.Second || first.P95 != 9*time.Second {
+		t.Errorf("first day median, p95 = %s, %s; want 2s, 9s", first.Median, first.P95)
+	}
+
+	byEngine := metrics.Summarize(records, metrics.ByEngine)
+	if len(byEngine) != 2 || byEngine[0].Group != "weasyprint" || byEngine[1].Group != "xelatex" {
+		t.Errorf("Summarize(ByEngine) groups = %+v", byEngine)
+	}
+
+	failedOnly := metrics.Summarize(records[4:], metrics.ByEngine)
+	if failedOnly[0].Median != 0 {
+		t.Errorf("median of failed conversions = %s, want 0", failedOnly[0].Median)
+	}
+}
@@ -0,0 +1,64 @@
+package pdfdiff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/pdfdiff"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	old := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	new := []string{"a", "B", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+
+	got := pdfdiff.UnifiedDiff("old", "new", old, new, 1)
+	want := `--- old
++++ new
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+@@ -10 +10,2 @@
+ j
++k
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffMergesNearbyChanges(t *testing.T) {
+	old := []string{"a", "b", "c", "d", "e"}
+	new := []string{"a", "x", "c", "y", "e"}
+
+	got := pdfdiff.UnifiedDiff("old", "new", old, new, 1)
+	if strings.Count(got, "@@ -") != 1 {
+		t.Errorf("changes two lines apart should share a hunk:\n%s", got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@") {
+		t.Errorf("unexpected hunk header:\n%s", got)
+	}
+}
+
+func TestUnifiedDiffEqual(t *testing.T) {
+	lines := []string{"same", "text"}
+	if got := pdfdiff.UnifiedDiff("old", "new", lines, lines, 3); got != "" {
+		t.Errorf("expected no diff, got:\n%s", got)
+	}
+}
+
+func TestUnifiedDiffDeletion(t *testing.T) {
+	got := pdfdiff.UnifiedDiff("old", "new", []string{"a", "b"}, nil, 3)
+	if !strings.Contains(got, "@@ -1,2 +0,0 @@\n-a\n-b\n") {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+}
+
+func TestLines(t *testing.T) {
+	got := pdfdiff.Lines([]string{"first\nsecond", ""})
+	want := []string{"[page 1]", "first", "second", "[page 2]"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Lines = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package preflight_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/preflight"
+)
+
+func TestCheckFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []preflight.Status
+	}{
+		{"no front matter", "# Title\n", []preflight.Status{preflight.StatusPass}},
+		{"valid", "---\ntitle: Report\nauthor: [Ann, {name: Bob}]\ntoc: true\ntoc-depth: 2\ncustom: [1, 2]\n---\n", []preflight.Status{preflight.StatusPass}},
+		{"invalid YAML", "---\ntitle: [unclosed\n---\n", []preflight.Status{preflight.StatusFail}},
+		{"wrong types", "---\ntitle: {a: b}\ntoc: yes please\n---\n", []preflight.Status{preflight.StatusFail, preflight.StatusFail}},
+		{"author without name", "---\nauthor:\n  - affiliation: ACME\n---\n", []preflight.Status{preflight.StatusFail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := preflight.CheckFrontMatter(tt.content)
+			var got []preflight.Status
+			for _, result := range results {
+				got = append(got, result.Status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statuses = %v, want %v (%+v)", got, tt.want, results)
+			}
+		})
+	}
+}
+
+func TestCheckFrontMatterLine(t *testing.T) {
+	results := preflight.CheckFrontMatter("---\ntitle: Report\ntoc: maybe\n---\n")
+	if len(results) != 1 || results[0].Subject != "toc" || results[0].Message != "line 3: must be true or false" {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
+
+func TestImageDestinations(t *testing.T) {
+	content := "![a](a.png) ![b](b.png) ![a again](a.png)\n\n```\n![code](c.png)\n```\n\n`![span](d.png)`\n"
+
+	got := preflight.ImageDestinations(content)
+	want := []string{"a.png", "b.png"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ImageDestinations() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckImages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected a HEAD request, got %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/ok.png":
+			w.Header().Set("Content-Type", "image/png")
+		case "/page.png":
+			w.Header().Set("Content-Type", "text/html")
+		case "/nohead.png":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "local.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "![](" + server.URL + "/ok.png)\n" +
+		"![](" + server.URL + "/page.png)\n" +
+		"![](" + server.URL + "/nohead.png)\n" +
+		"![](" + server.URL + "/missing.png)\n" +
+		"![](local.png)\n" +
+		"![](absent.png)\n" +
+		"![](s3://bucket/key.png)\n"
+
+	results := preflight.CheckImages(context.Background(), server.Client(), content, []string{dir})
+	want := []preflight.Status{
+		preflight.StatusPass,
+		preflight.StatusWarn,
+		preflight.StatusWarn,
+		preflight.StatusFail,
+		preflight.StatusPass,
+		preflight.StatusFail,
+		preflight.StatusWarn,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), results)
+	}
+	for i, result := range results {
+		if result.Status != want[i] {
+			t.Errorf("%s: status %s, want %s (%s)", result.Subject, result.Status, want[i], result.Message)
+		}
+	}
+}
+
+func TestReportFailed(t *testing.T) {
+	report := &preflight.Report{Input: "doc.md"}
+	report.Add("input", preflight.StatusPass, "", "readable")
+	report.Add("image", preflight.StatusWarn, "a.png", "not checked")
+	if report.Failed() {
+		t.Error("warnings should not fail the report")
+	}
+	report.Add("image", preflight.StatusFail, "b.png", "file not found")
+	if !report.Failed() || report.Count(preflight.StatusFail) != 1 {
+		t.Error("expected the report to fail")
+	}
+}
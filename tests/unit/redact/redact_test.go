@@ -0,0 +1,129 @@
+package redact_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/redact"
+)
+
+func writePatterns(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "patterns.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApply(t *testing.T) {
+	rules, err := redact.Load(writePatterns(t, `rules:
+  - name: email
+    pattern: '[\w.+-]+@[\w-]+(\.[\w-]+)+'
+  - name: key
+    pattern: 'sk_live_\w{4}(\w{4})'
+    replace: '[key …$1]'
+  - pattern: 'Jane Doe'
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	content := "# Contacts\n\nÉcrire à jane@example.com.\nKey sk_live_abcd1234, owner Jane Doe.\n"
+	got, redactions := rules.Apply(content)
+
+	want := "# Contacts\n\nÉcrire à ████████████████.\nKey [key …1234], owner ████ ███.\n"
+	if got != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+	wantRedactions := []struct {
+		rule         string
+		line, column int
+		length       int
+	}{
+		{"email", 3, 10, 16},
+		{"key", 4, 5, 16},
+		{"rule 3", 4, 29, 8},
+	}
+	if len(redactions) != len(wantRedactions) {
+		t.Fatalf("redactions = %+v, want %d", redactions, len(wantRedactions))
+	}
+	for i, w := range wantRedactions {
+		r := redactions[i]
+		if r.Rule != w.rule || r.Line != w.line || r.Column != w.column || r.Length != w.length {
+			t.Errorf("redaction %d = %+v, want %+v", i, r, w)
+		}
+		if len(r.SHA256) != 64 {
+			t.Errorf("redaction %d checksum = %q", i, r.SHA256)
+		}
+	}
+}
+
+func TestApplyOverlapsAndMultiline(t *testing.T) {
+	rules, err := redact.Load(writePatterns(t, `rules:
+  - name: project
+    pattern: 'Project\s+Falcon'
+  - name: word
+    pattern: 'Falcon'
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	got, redactions := rules.Apply("About Project\nFalcon and Falcon\n")
+	if want := "About ███████\n██████ and ██████\n"; got != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+	if len(redactions) != 2 || redactions[0].Rule != "project" || redactions[1].Rule != "word" || redactions[1].Line != 2 || redactions[1].Column != 12 {
+		t.Errorf("redactions = %+v", redactions)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"no rules":      "rules: []\n",
+		"no pattern":    "rules:\n  - name: x\n",
+		"invalid regex": "rules:\n  - pattern: '('\n",
+		"multiline":     "rules:\n  - pattern: x\n    replace: \"a\\nb\"\n",
+	} {
+		if _, err := redact.Load(writePatterns(t, content)); err == nil {
+			t.Errorf("%s: Load succeeded, want an error", name)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	rules, err := redact.Load(writePatterns(t, "rules:\n  - name: a\n    pattern: a\n  - name: b\n    pattern: b\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	_, redactions := rules.Apply("aaa")
+	report := redact.NewReport(rules, redactions)
+
+	path := filepath.Join(t.TempDir(), "out.redactions.json")
+	if err := report.Write(path); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded redact.Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("report is not JSON: %v", err)
+	}
+	if decoded.Counts["a"] != 3 || decoded.Counts["b"] != 0 || decoded.PatternsSHA256 != rules.SHA256 {
+		t.Errorf("report = %+v", decoded)
+	}
+	if strings.Contains(string(data), `"aaa"`) {
+		t.Errorf("report contains redacted text:\n%s", data)
+	}
+}
+
+func TestReportPath(t *testing.T) {
+	if got := redact.ReportPath("out/report.pdf"); got != "out/report.redactions.json" {
+		t.Errorf("ReportPath() = %q", got)
+	}
+}
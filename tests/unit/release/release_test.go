@@ -0,0 +1,151 @@
+package release_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/release"
+)
+
+const checksums = `1111111111111111111111111111111111111111111111111111111111111111  veve-cli_Darwin_all.tar.gz
+2222222222222222222222222222222222222222222222222222222222222222  veve-cli_Linux_x86_64.tar.gz
+3333333333333333333333333333333333333333333333333333333333333333 *veve-cli_Linux_arm64.tar.gz
+4444444444444444444444444444444444444444444444444444444444444444  veve-cli_Windows_x86_64.zip
+5555555555555555555555555555555555555555555555555555555555555555  veve-cli_0.3.0_source.tar.gz
+`
+
+func testRelease(t *testing.T) *release.Release {
+	t.Helper()
+	artifacts, err := release.ParseChecksums(strings.NewReader(checksums), "https://example.com/v0.3.0/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &release.Release{
+		Version:     "0.3.0",
+		Description: "Markdown to PDF",
+		Homepage:    "https://example.com",
+		License:     "MIT",
+		Maintainer:  "Jane Doe <jane@example.com>",
+		Arch:        "amd64",
+		Artifacts:   artifacts,
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	r := testRelease(t)
+	if len(r.Artifacts) != 4 {
+		t.Fatalf("expected 4 archives, got %+v", r.Artifacts)
+	}
+
+	for _, want := range []struct{ os, arch, name string }{
+		{"darwin", "universal", "veve-cli_Darwin_all.tar.gz"},
+		{"linux", "amd64", "veve-cli_Linux_x86_64.tar.gz"},
+		{"linux", "arm64", "veve-cli_Linux_arm64.tar.gz"},
+		{"windows", "amd64", "veve-cli_Windows_x86_64.zip"},
+	} {
+		artifact := r.Find(want.os, want.arch)
+		if artifact == nil {
+			t.Errorf("no %s/%s archive", want.os, want.arch)
+			continue
+		}
+		if artifact.Name != want.name {
+			t.Errorf("%s/%s: got %s, want %s", want.os, want.arch, artifact.Name, want.name)
+		}
+		if artifact.URL != "https://example.com/v0.3.0/"+want.name {
+			t.Errorf("%s/%s: unexpected URL %s", want.os, want.arch, artifact.URL)
+		}
+	}
+}
+
+func TestParseChecksumsMalformed(t *testing.T) {
+	if _, err := release.ParseChecksums(strings.NewReader("abc veve-cli_Linux_arm64.tar.gz\n"), ""); err == nil {
+		t.Error("expected an error for a short digest")
+	}
+}
+
+func TestRenderHomebrew(t *testing.T) {
+	var out bytes.Buffer
+	if err := release.Render(&out, "homebrew", testRelease(t)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`class Veve < Formula`,
+		`version "0.3.0"`,
+		`url "https://example.com/v0.3.0/veve-cli_Darwin_all.tar.gz"`,
+		`sha256 "1111111111111111111111111111111111111111111111111111111111111111"`,
+		`depends_on "pandoc"`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("formula missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRenderScoop(t *testing.T) {
+	var out bytes.Buffer
+	if err := release.Render(&out, "scoop", testRelease(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest struct {
+		Version      string
+		Architecture map[string]struct{ URL, Hash string }
+	}
+	if err := json.Unmarshal(out.Bytes(), &manifest); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v\n%s", err, out.String())
+	}
+	if manifest.Version != "0.3.0" {
+		t.Errorf("unexpected version %q", manifest.Version)
+	}
+	if got := manifest.Architecture["64bit"].Hash; got != strings.Repeat("4", 64) {
+		t.Errorf("unexpected 64bit hash %q", got)
+	}
+}
+
+func TestRenderDeb(t *testing.T) {
+	r := testRelease(t)
+	r.Arch = "arm64"
+
+	var out bytes.Buffer
+	if err := release.Render(&out, "deb", r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Package: veve\n", "Version: 0.3.0\n", "Architecture: arm64\n", "Maintainer: Jane Doe <jane@example.com>\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("control file missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	linuxOnly := testRelease(t)
+	linuxOnly.Artifacts = []release.Artifact{*linuxOnly.Find("linux", "amd64")}
+
+	noMaintainer := testRelease(t)
+	noMaintainer.Maintainer = ""
+
+	i386 := testRelease(t)
+	i386.Arch = "i386"
+
+	tests := []struct {
+		name   string
+		format string
+		r      *release.Release
+	}{
+		{"unknown format", "rpm", testRelease(t)},
+		{"homebrew without macOS archive", "homebrew", linuxOnly},
+		{"scoop without Windows archive", "scoop", linuxOnly},
+		{"deb without maintainer", "deb", noMaintainer},
+		{"deb without matching archive", "deb", i386},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := release.Render(&out, tt.format, tt.r); err == nil {
+				t.Errorf("expected an error, got:\n%s", out.String())
+			}
+		})
+	}
+}
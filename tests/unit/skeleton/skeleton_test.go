@@ -0,0 +1,122 @@
+package skeleton_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/madstone-tech/veve-cli/internal/skeleton"
+	"github.com/madstone-tech/veve-cli/themes"
+)
+
+// frontMatter parses the YAML front matter of a rendered document.
+func frontMatter(t *testing.T, doc string) map[string]any {
+	t.Helper()
+	block, _, ok := strings.Cut(strings.TrimPrefix(doc, "---\n"), "\n---\n")
+	if !ok || !strings.HasPrefix(doc, "---\n") {
+		t.Fatalf("document has no front matter:\n%s", doc)
+	}
+	meta := make(map[string]any)
+	if err := yaml.Unmarshal([]byte(block), &meta); err != nil {
+		t.Fatalf("front matter is not valid YAML: %v\n%s", err, block)
+	}
+	return meta
+}
+
+func TestRenderEveryKind(t *testing.T) {
+	date := time.Date(2025, time.March, 4, 0, 0, 0, 0, time.UTC)
+	for _, kind := range skeleton.Kinds() {
+		t.Run(kind.Name, func(t *testing.T) {
+			if _, ok := themes.GetBuiltInTheme(kind.Theme); !ok {
+				t.Errorf("theme %q is not a built-in theme", kind.Theme)
+			}
+
+			var buf bytes.Buffer
+			doc := skeleton.Document{Title: `Q1: "Results"`, Author: "Jane Doe", Date: date}
+			if err := skeleton.Render(&buf, kind, doc); err != nil {
+				t.Fatalf("Render() error: %v", err)
+			}
+			meta := frontMatter(t, buf.String())
+			title := meta["title"]
+			if kind.Name == "letter" {
+				title = meta["pagetitle"]
+			}
+			if title != `Q1: "Results"` {
+				t.Errorf("title = %v, want the given title", title)
+			}
+			if !strings.Contains(buf.String(), "Jane Doe") {
+				t.Error("document doesn't name the author")
+			}
+			if !strings.Contains(buf.String(), "2025-03-04") && !strings.Contains(buf.String(), "March 4, 2025") {
+				t.Error("document isn't dated")
+			}
+		})
+	}
+}
+
+func TestRenderDefaults(t *testing.T) {
+	kind, err := skeleton.Find("report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := skeleton.Render(&buf, kind, skeleton.Document{}); err != nil {
+		t.Fatal(err)
+	}
+	meta := frontMatter(t, buf.String())
+	if meta["title"] != kind.Title {
+		t.Errorf("title = %v, want %q", meta["title"], kind.Title)
+	}
+	if _, ok := meta["author"]; ok {
+		t.Error("document without an author has an author field")
+	}
+	if _, err := skeleton.Find("memo"); err == nil {
+		t.Error("Find() of an unknown type succeeded")
+	}
+}
+
+func TestNumberedDocuments(t *testing.T) {
+	dir := t.TempDir()
+	if n, err := skeleton.NextNumber(filepath.Join(dir, "missing")); err != nil || n != 1 {
+		t.Errorf("NextNumber() of a missing directory = %d, %v; want 1", n, err)
+	}
+	for _, name := range []string{"0001-first.md", "0007-seventh.md", "2025-03-04-notes.md", "0012-draft.txt", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "0099-archive.md"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	n, err := skeleton.NextNumber(dir)
+	if err != nil || n != 8 {
+		t.Fatalf("NextNumber() = %d, %v; want 8", n, err)
+	}
+
+	kind, err := skeleton.Find("adr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := skeleton.Document{Title: "Use PostgreSQL 16!", Number: n}
+	if got := skeleton.FileName(kind, doc); got != "0008-use-postgresql-16.md" {
+		t.Errorf("FileName() = %q", got)
+	}
+	var buf bytes.Buffer
+	if err := skeleton.Render(&buf, kind, doc); err != nil {
+		t.Fatal(err)
+	}
+	meta := frontMatter(t, buf.String())
+	if meta["title"] != "ADR 0008: Use PostgreSQL 16!" || meta["number"] != 8 || meta["status"] != "Proposed" {
+		t.Errorf("front matter = %v", meta)
+	}
+
+	report, _ := skeleton.Find("report")
+	if got := skeleton.FileName(report, skeleton.Document{Number: 3}); got != "report.md" {
+		t.Errorf("FileName() of an unnumbered kind = %q", got)
+	}
+}
@@ -0,0 +1,126 @@
+package transform_test
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/config"
+	"github.com/madstone-tech/veve-cli/internal/transform"
+)
+
+func init() {
+	transform.Register("test-upper", transform.Func(func(doc transform.Document, content string) (string, error) {
+		return strings.ToUpper(content), nil
+	}))
+}
+
+// TestBuiltin verifies registered transformers are available by name
+func TestBuiltin(t *testing.T) {
+	if !slices.Contains(transform.Builtins(), "test-upper") {
+		t.Fatalf("Builtins() = %v, want test-upper", transform.Builtins())
+	}
+
+	step, err := transform.New(config.TransformerConfig{Builtin: "test-upper"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if step.Name != "test-upper" || step.External {
+		t.Errorf("New() = %+v, want internal step named test-upper", step)
+	}
+
+	if _, err := transform.New(config.TransformerConfig{Builtin: "missing"}, ""); err == nil {
+		t.Error("expected an error for an unknown builtin")
+	}
+}
+
+// TestNewRequiresOneKind verifies a transformer sets exactly one kind
+func TestNewRequiresOneKind(t *testing.T) {
+	for _, cfg := range []config.TransformerConfig{
+		{Name: "empty"},
+		{Name: "both", Command: "cat", Builtin: "test-upper"},
+	} {
+		if _, err := transform.New(cfg, ""); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
+
+// TestCommand verifies command transformers filter stdin to stdout in the
+// project root, with the document in the environment
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	root := t.TempDir()
+	step, err := transform.New(config.TransformerConfig{Command: `sed "s/NAME/$VEVE_THEME/"; echo "$VEVE_PROJECT_ROOT"`}, root)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if !step.External {
+		t.Error("command transformers should be external")
+	}
+
+	got, err := step.Transformer.Transform(transform.Document{Theme: "academic", Project: root}, "# NAME\n")
+	if err != nil {
+		t.Fatalf("Transform() error: %v", err)
+	}
+	if want := "# academic\n" + root + "\n"; got != want {
+		t.Errorf("Transform() = %q, want %q", got, want)
+	}
+}
+
+// TestCommandErrors verifies failing and silent commands are errors
+func TestCommandErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	doc := transform.Document{}
+
+	_, err := transform.Command{Command: "echo 'bad front matter' >&2; exit 1"}.Transform(doc, "# Title\n")
+	if err == nil || !strings.Contains(err.Error(), "bad front matter") {
+		t.Errorf("Transform() error = %v, want stderr in the message", err)
+	}
+
+	if _, err := (transform.Command{Command: "true"}).Transform(doc, "# Title\n"); err == nil {
+		t.Error("expected an error for a command that wrote nothing")
+	}
+}
+
+// TestApply verifies steps run in order and errors name the step
+func TestApply(t *testing.T) {
+	appendText := func(text string) transform.Transformer {
+		return transform.Func(func(doc transform.Document, content string) (string, error) {
+			return content + text, nil
+		})
+	}
+	failing := transform.Func(func(doc transform.Document, content string) (string, error) {
+		return "", errors.New("boom")
+	})
+
+	got, err := transform.Apply([]transform.Step{
+		{Name: "a", Transformer: appendText("a")},
+		{Name: "b", Transformer: appendText("b")},
+	}, transform.Document{}, "x")
+	if err != nil || got != "xab" {
+		t.Errorf("Apply() = %q, %v; want xab", got, err)
+	}
+
+	_, err = transform.Apply([]transform.Step{{Name: "redact", Transformer: failing}}, transform.Document{}, "x")
+	if err == nil || !strings.Contains(err.Error(), `"redact"`) {
+		t.Errorf("Apply() error = %v, want it to name the transformer", err)
+	}
+}
+
+// TestPluginUnsupported verifies the error when plugins are not built in
+func TestPluginUnsupported(t *testing.T) {
+	if transform.PluginSupported {
+		t.Skip("built with Go plugin support")
+	}
+	_, err := transform.New(config.TransformerConfig{Plugin: "filters/redact.so"}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "goplugin") {
+		t.Errorf("New() error = %v, want a rebuild hint", err)
+	}
+}
@@ -0,0 +1,165 @@
+package workspace_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/madstone-tech/veve-cli/internal/workspace"
+)
+
+func TestWorkspacePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permissions not applicable on Windows")
+	}
+
+	ws, err := workspace.New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer ws.Remove()
+
+	info, err := os.Stat(ws.Dir)
+	if err != nil {
+		t.Fatalf("workspace dir missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("expected workspace dir mode 0700, got %o", perm)
+	}
+
+	path, err := ws.WriteFile("theme-*.css", []byte("body {}"))
+	if err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if filepath.Dir(path) != ws.Dir {
+		t.Errorf("expected file inside workspace, got %s", path)
+	}
+	info, err = os.Stat(path)
+	if err != nil {
+		t.Fatalf("written file missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected file mode 0600, got %o", perm)
+	}
+
+	images, err := ws.Subdir("images")
+	if err != nil {
+		t.Fatalf("Subdir failed: %v", err)
+	}
+	if info, err := os.Stat(images); err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("expected images dir with mode 0700, got %v, %v", info, err)
+	}
+}
+
+func TestWorkspacesDoNotCollide(t *testing.T) {
+	first, err := workspace.New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer first.Remove()
+
+	second, err := workspace.New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer second.Remove()
+
+	if first.Dir == second.Dir {
+		t.Fatalf("expected distinct workspaces, both are %s", first.Dir)
+	}
+
+	a, _ := first.WriteFile("theme-*.css", []byte("a"))
+	b, _ := first.WriteFile("theme-*.css", []byte("b"))
+	if a == b {
+		t.Errorf("expected distinct file names, both are %s", a)
+	}
+}
+
+func TestWorkspaceRemove(t *testing.T) {
+	ws, err := workspace.New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := ws.WriteFile("processed-*.md", []byte("# doc")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if err := ws.Remove(); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(ws.Dir); !os.IsNotExist(err) {
+		t.Errorf("expected workspace to be removed, stat returned %v", err)
+	}
+}
+
+func TestWorkspaceCleanupReport(t *testing.T) {
+	ws, err := workspace.New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer ws.Remove()
+
+	css, err := ws.WriteFile("theme-*.css", []byte("body {}"))
+	if err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	images, err := ws.Subdir("images")
+	if err != nil {
+		t.Fatalf("Subdir failed: %v", err)
+	}
+	image := filepath.Join(images, "a.png")
+	if err := os.WriteFile(image, []byte("png"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	report := ws.Cleanup()
+	if err := report.Err(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	for _, path := range []string{css, image, images, ws.Dir} {
+		if !slices.Contains(report.Removed, path) {
+			t.Errorf("expected %s to be reported removed, got %v", path, report.Removed)
+		}
+	}
+	if _, err := os.Stat(ws.Dir); !os.IsNotExist(err) {
+		t.Errorf("expected workspace to be removed, got %v", err)
+	}
+}
+
+func TestWorkspaceCleanupFailure(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs unix permissions that apply to the current user")
+	}
+
+	ws, err := workspace.New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	images, err := ws.Subdir("images")
+	if err != nil {
+		t.Fatalf("Subdir failed: %v", err)
+	}
+	image := filepath.Join(images, "a.png")
+	if err := os.WriteFile(image, []byte("png"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(images, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		os.Chmod(images, 0o700)
+		ws.Remove()
+	}()
+
+	// The image can't be removed, so neither can the directories holding it;
+	// only the image is reported
+	report := ws.Cleanup()
+	if len(report.Failed) != 1 || report.Failed[0].Path != image {
+		t.Errorf("expected only %s to fail, got %v", image, report.Failed)
+	}
+	if report.Err() == nil {
+		t.Error("expected Err to report the failure")
+	}
+}
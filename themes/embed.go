@@ -1,33 +1,55 @@
 package themes
 
 import (
-	_ "embed"
+	"embed"
 )
 
-//go:embed default.css
-var DefaultCSS string
+// files holds the CSS of every built-in theme, stored as <name>.css.
+//
+//go:embed *.css
+var files embed.FS
 
-//go:embed dark.css
-var DarkCSS string
+// BuiltIn describes a theme embedded in the binary.
+type BuiltIn struct {
+	Name        string
+	DisplayName string
+	Description string
+}
 
-//go:embed academic.css
-var AcademicCSS string
+// builtIns registers the built-in themes. Adding a theme is one <name>.css file
+// in this directory plus one entry here.
+var builtIns = []BuiltIn{
+	{Name: "default", DisplayName: "Default", Description: "Clean, professional default theme with blue accents"},
+	{Name: "dark", DisplayName: "Dark", Description: "Dark theme with blue accents, easy on the eyes"},
+	{Name: "academic", DisplayName: "Academic", Description: "Formal academic paper style with Times New Roman"},
+	{Name: "letter", DisplayName: "Letter", Description: "Business letter with sender, recipient, date, and signature blocks"},
+	{Name: "invoice", DisplayName: "Invoice", Description: "Invoice with item table, totals, and payment notes blocks"},
+}
+
+// List returns the registered built-in themes.
+func List() []BuiltIn {
+	return append([]BuiltIn(nil), builtIns...)
+}
 
 // GetBuiltInTheme returns the CSS content for a built-in theme by name.
 func GetBuiltInTheme(name string) (string, bool) {
-	switch name {
-	case "default":
-		return DefaultCSS, true
-	case "dark":
-		return DarkCSS, true
-	case "academic":
-		return AcademicCSS, true
-	default:
-		return "", false
+	for _, t := range builtIns {
+		if t.Name == name {
+			css, err := files.ReadFile(name + ".css")
+			if err != nil {
+				return "", false
+			}
+			return string(css), true
+		}
 	}
+	return "", false
 }
 
 // BuiltInThemes returns a list of built-in theme names.
 func BuiltInThemes() []string {
-	return []string{"default", "dark", "academic"}
+	names := make([]string, len(builtIns))
+	for i, t := range builtIns {
+		names[i] = t.Name
+	}
+	return names
 }
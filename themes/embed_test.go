@@ -0,0 +1,38 @@
+package themes
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// TestBuiltInThemesRegistered tests that every embedded CSS file has a registry
+// entry and every entry has a CSS file.
+func TestBuiltInThemesRegistered(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, name := range BuiltInThemes() {
+		registered[name] = true
+
+		css, ok := GetBuiltInTheme(name)
+		if !ok || strings.TrimSpace(css) == "" {
+			t.Errorf("built-in theme %s has no CSS", name)
+		}
+	}
+
+	cssFiles, err := fs.Glob(files, "*.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range cssFiles {
+		if name := strings.TrimSuffix(file, ".css"); !registered[name] {
+			t.Errorf("%s is embedded but not registered in builtIns", file)
+		}
+	}
+}
+
+// TestGetBuiltInThemeUnknown tests that unknown names are not found.
+func TestGetBuiltInThemeUnknown(t *testing.T) {
+	if _, ok := GetBuiltInTheme("missing"); ok {
+		t.Error("expected unknown theme not to be found")
+	}
+}